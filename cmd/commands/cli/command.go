@@ -60,9 +60,17 @@ func NewCommand() *cli.Command {
 		Usage: "Starts a CLI client with a Tequilapi",
 		Action: func(ctx *cli.Context) error {
 			nodeOptions := cmd.ParseFlagsNode(ctx)
+			tequilapi, err := cmd.NewTequilapiClient(nodeOptions)
+			if tequilapi == nil {
+				return err
+			}
+			if err != nil {
+				warn(err)
+				info("Use 'login <username> <password>' to authenticate")
+			}
 			cmdCLI := &cliApp{
 				historyFile: filepath.Join(nodeOptions.Directories.Data, ".cli_history"),
-				tequilapi:   tequilapi_client.NewClient(nodeOptions.TequilapiAddress, nodeOptions.TequilapiPort),
+				tequilapi:   tequilapi,
 			}
 			cmd.RegisterSignalCallback(utils.SoftKiller(cmdCLI.Kill))
 
@@ -167,6 +175,8 @@ func (c *cliApp) handleActions(line string) {
 		{"registration", c.registration},
 		{"proposals", c.proposals},
 		{"service", c.service},
+		{"login", c.login},
+		{"token", c.token},
 	}
 
 	for _, cmd := range staticCmds {
@@ -425,6 +435,11 @@ func (c *cliApp) status() {
 	} else {
 		info("Status:", status.Status)
 		info("SID:", status.SessionID)
+		info("Kill switch:", status.KillSwitch)
+		if status.Routes != nil {
+			info("Routes included:", strings.Join(status.Routes.Include, ", "))
+			info("Routes excluded:", strings.Join(status.Routes.Exclude, ", "))
+		}
 	}
 
 	if status.Status == StatusConnected {
@@ -440,6 +455,37 @@ func (c *cliApp) status() {
 	}
 }
 
+func (c *cliApp) login(argsString string) {
+	loginSignature := "login <username> <password>"
+	args := strings.Fields(argsString)
+	if len(args) != 2 {
+		info("Please type in username and password configured on the node.\n", loginSignature)
+		return
+	}
+
+	_, err := c.tequilapi.Login(args[0], args[1])
+	if err != nil {
+		warn(err)
+		return
+	}
+	success("Logged in")
+}
+
+func (c *cliApp) token(argsString string) {
+	const usage = "token command:\n    rotate"
+	if argsString != "rotate" {
+		info(usage)
+		return
+	}
+
+	_, err := c.tequilapi.RotateToken()
+	if err != nil {
+		warn(err)
+		return
+	}
+	success("API token rotated, clients using the old one have to login again")
+}
+
 func (c *cliApp) healthcheck() {
 	healthcheck, err := c.tequilapi.Healthcheck()
 	if err != nil {
@@ -738,6 +784,11 @@ func newAutocompleter(tequilapi *tequilapi_client.Client, proposals []tequilapi_
 				getIdentityOptionList(tequilapi),
 			),
 		),
+		readline.PcItem("login"),
+		readline.PcItem(
+			"token",
+			readline.PcItem("rotate"),
+		),
 		readline.PcItem("dashboard"),
 		readline.PcItem("logo"),
 	)
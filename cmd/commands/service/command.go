@@ -82,8 +82,13 @@ func NewCommand(licenseCommandName string) *cli.Command {
 
 			cmd.RegisterSignalCallback(func() { errorChannel <- nil })
 
+			tequilapi, err := cmd.NewTequilapiClient(nodeOptions)
+			if err != nil {
+				return err
+			}
+
 			cmdService := &serviceCommand{
-				tequilapi:    client.NewClient(nodeOptions.TequilapiAddress, nodeOptions.TequilapiPort),
+				tequilapi:    tequilapi,
 				errorChannel: errorChannel,
 				ap:           parseAccessPolicyFlag(ctx),
 			}
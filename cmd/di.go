@@ -20,6 +20,7 @@ package cmd
 import (
 	"fmt"
 	"net"
+	"net/http"
 	"path/filepath"
 	"time"
 
@@ -27,12 +28,13 @@ import (
 	"github.com/ethereum/go-ethereum/accounts/keystore"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/julienschmidt/httprouter"
 	"github.com/mysteriumnetwork/node/blockchain"
 	"github.com/mysteriumnetwork/node/communication"
-	"github.com/mysteriumnetwork/node/communication/nats"
 	nats_dialog "github.com/mysteriumnetwork/node/communication/nats/dialog"
 	nats_discovery "github.com/mysteriumnetwork/node/communication/nats/discovery"
 	"github.com/mysteriumnetwork/node/consumer/bandwidth"
+	"github.com/mysteriumnetwork/node/consumer/selector"
 	consumer_session "github.com/mysteriumnetwork/node/consumer/session"
 	"github.com/mysteriumnetwork/node/consumer/statistics"
 	"github.com/mysteriumnetwork/node/core/connection"
@@ -47,6 +49,7 @@ import (
 	"github.com/mysteriumnetwork/node/core/storage/boltdb"
 	"github.com/mysteriumnetwork/node/core/storage/boltdb/migrations/history"
 	"github.com/mysteriumnetwork/node/eventbus"
+	"github.com/mysteriumnetwork/node/firewall"
 	"github.com/mysteriumnetwork/node/identity"
 	identity_registry "github.com/mysteriumnetwork/node/identity/registry"
 	identity_selector "github.com/mysteriumnetwork/node/identity/selector"
@@ -57,8 +60,9 @@ import (
 	"github.com/mysteriumnetwork/node/market/mysterium"
 	"github.com/mysteriumnetwork/node/metadata"
 	"github.com/mysteriumnetwork/node/metrics"
-	"github.com/mysteriumnetwork/node/money"
+	metrics_prometheus "github.com/mysteriumnetwork/node/metrics/prometheus"
 	"github.com/mysteriumnetwork/node/nat"
+	"github.com/mysteriumnetwork/node/nat/egress"
 	"github.com/mysteriumnetwork/node/nat/event"
 	"github.com/mysteriumnetwork/node/nat/mapping"
 	"github.com/mysteriumnetwork/node/nat/traversal"
@@ -67,7 +71,6 @@ import (
 	"github.com/mysteriumnetwork/node/services"
 	service_noop "github.com/mysteriumnetwork/node/services/noop"
 	service_openvpn "github.com/mysteriumnetwork/node/services/openvpn"
-	"github.com/mysteriumnetwork/node/services/openvpn/discovery/dto"
 	"github.com/mysteriumnetwork/node/session"
 	"github.com/mysteriumnetwork/node/session/balance"
 	session_payment "github.com/mysteriumnetwork/node/session/payment"
@@ -75,14 +78,23 @@ import (
 	"github.com/mysteriumnetwork/node/session/promise"
 	"github.com/mysteriumnetwork/node/session/promise/validators"
 	"github.com/mysteriumnetwork/node/tequilapi"
+	tequilapi_auth "github.com/mysteriumnetwork/node/tequilapi/auth"
 	tequilapi_endpoints "github.com/mysteriumnetwork/node/tequilapi/endpoints"
 	"github.com/mysteriumnetwork/node/ui"
 	"github.com/mysteriumnetwork/node/utils"
 	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 )
 
 const logPrefix = "[service bootstrap] "
 
+// proposalCacheRefreshInterval is how often the proposals requested from discovery are refreshed in the background
+const proposalCacheRefreshInterval = time.Minute
+
+// brokerProposalTTL is how long a proposal announced thru the broker is kept without being pinged by its provider
+const brokerProposalTTL = 3 * time.Minute
+
 // Storage stores persistent objects for future usage
 type Storage interface {
 	Store(issuer string, data interface{}) error
@@ -145,10 +157,12 @@ type Dependencies struct {
 
 	NetworkDefinition    metadata.NetworkDefinition
 	MysteriumAPI         *mysterium.MysteriumAPI
+	ProposalCache        *mysterium.ProposalCache
 	MysteriumMorqaClient market_metrics.QualityOracle
 	EtherClient          *ethclient.Client
 
 	NATService           nat.NATService
+	EgressPolicy         egress.Policy
 	Storage              Storage
 	Keystore             *keystore.KeyStore
 	PromiseStorage       *promise.Storage
@@ -158,7 +172,9 @@ type Dependencies struct {
 	IdentityRegistration identity_registry.RegistrationDataProvider
 	IdentitySelector     identity_selector.Handler
 
-	DiscoveryFactory service.DiscoveryFactory
+	DiscoveryFactory         service.DiscoveryFactory
+	DiscoveryBroker          *nats_discovery.AddressNATS
+	DiscoveryBrokerProposals *discovery_broker.ProposalProvider
 
 	IPResolver       ip.Resolver
 	LocationResolver CacheResolver
@@ -170,18 +186,21 @@ type Dependencies struct {
 	EventBus eventbus.EventBus
 
 	ConnectionManager  connection.Manager
+	ConnectionPool     *connection.Pool
 	ConnectionRegistry *connection.Registry
 
 	ServicesManager       *service.Manager
 	ServiceRegistry       *service.Registry
-	ServiceSessionStorage *session.StorageMemory
+	ServiceSessionStorage *session.StorageBolt
 
 	NATPinger        NatPinger
 	NATTracker       NatEventTracker
 	NATEventSender   NatEventSender
 	NATStatusTracker NATStatusTracker
 
-	MetricsSender *metrics.Sender
+	MetricsSender    *metrics.Sender
+	MetricsRegistry  *prometheus.Registry
+	MetricsCollector *metrics_prometheus.Collector
 
 	BandwidthTracker *bandwidth.Tracker
 
@@ -210,11 +229,11 @@ func (di *Dependencies) Bootstrap(nodeOptions node.Options) error {
 		return err
 	}
 
+	di.bootstrapEventBus()
 	if err := di.bootstrapStorage(nodeOptions.Directories.Storage); err != nil {
 		return err
 	}
 
-	di.bootstrapEventBus()
 	di.bootstrapIdentityComponents(nodeOptions)
 
 	if err := di.bootstrapDiscoveryComponents(nodeOptions.Discovery); err != nil {
@@ -231,10 +250,16 @@ func (di *Dependencies) Bootstrap(nodeOptions node.Options) error {
 		return err
 	}
 
-	di.bootstrapMetrics(nodeOptions)
+	if err := di.bootstrapMetrics(nodeOptions); err != nil {
+		return err
+	}
 	di.bootstrapNATComponents(nodeOptions)
-	di.bootstrapServices(nodeOptions)
-	di.bootstrapNodeComponents(nodeOptions, tequilaListener)
+	if err := di.bootstrapServices(nodeOptions); err != nil {
+		return err
+	}
+	if err := di.bootstrapNodeComponents(nodeOptions, tequilaListener); err != nil {
+		return err
+	}
 
 	di.registerConnections(nodeOptions)
 
@@ -286,17 +311,39 @@ func (di *Dependencies) Shutdown() (err error) {
 			errs = append(errs, err)
 		}
 	}
+	if di.ProposalCache != nil {
+		di.ProposalCache.Stop()
+	}
+	if di.DiscoveryBrokerProposals != nil {
+		di.DiscoveryBrokerProposals.Stop()
+	}
+	if di.DiscoveryBroker != nil {
+		di.DiscoveryBroker.Disconnect()
+	}
 	if di.NATService != nil {
 		if err := di.NATService.Disable(); err != nil {
 			errs = append(errs, err)
 		}
 	}
+	if di.ServicesManager != nil {
+		if err := firewall.ShutdownInbound(); err != nil {
+			errs = append(errs, err)
+		}
+	}
 
+	if di.ConnectionPool != nil {
+		if err := di.ConnectionPool.Close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
 	if di.Node != nil {
 		if err := di.Node.Kill(); err != nil {
 			errs = append(errs, err)
 		}
 	}
+	if di.MetricsSender != nil {
+		di.MetricsSender.Stop()
+	}
 	if di.Storage != nil {
 		if err := di.Storage.Close(); err != nil {
 			errs = append(errs, err)
@@ -320,6 +367,7 @@ func (di *Dependencies) bootstrapStorage(path string) error {
 	}
 
 	di.Storage = localStorage
+	di.PromiseStorage = promise.NewStorage(di.Storage, di.EventBus)
 	return nil
 }
 
@@ -346,6 +394,10 @@ func (di *Dependencies) subscribeEventConsumers() error {
 	if err != nil {
 		return err
 	}
+	err = di.EventBus.Subscribe(connection.PooledEventTopic, di.SessionStorage.ConsumePooledEvent)
+	if err != nil {
+		return err
+	}
 
 	// statistics events
 	err = di.EventBus.Subscribe(connection.StatisticsEventTopic, di.StatisticsTracker.ConsumeStatisticsEvent)
@@ -366,7 +418,7 @@ func (di *Dependencies) subscribeEventConsumers() error {
 	return di.EventBus.Subscribe(event.Topic, di.NATStatusTracker.ConsumeNATEvent)
 }
 
-func (di *Dependencies) bootstrapNodeComponents(nodeOptions node.Options, listener net.Listener) {
+func (di *Dependencies) bootstrapNodeComponents(nodeOptions node.Options, listener net.Listener) error {
 	dialogFactory := func(consumerID, providerID identity.Identity, contact market.Contact) (communication.Dialog, error) {
 		dialogEstablisher := nats_dialog.NewDialogEstablisher(consumerID, di.SignerFactory(consumerID))
 		return dialogEstablisher.EstablishDialog(providerID, contact)
@@ -381,58 +433,125 @@ func (di *Dependencies) bootstrapNodeComponents(nodeOptions node.Options, listen
 		time.Minute,
 	)
 	di.SessionStorage = consumer_session.NewSessionStorage(di.Storage, di.StatisticsTracker)
-	di.PromiseStorage = promise.NewStorage(di.Storage)
 
 	di.ConnectionRegistry = connection.NewRegistry()
-	di.ConnectionManager = connection.NewManager(
-		dialogFactory,
-		payment_factory.PaymentIssuerFactoryFunc(nodeOptions, di.SignerFactory),
-		di.ConnectionRegistry.CreateConnection,
-		di.EventBus,
-		di.IPResolver,
+	newConnectionManager := func(publisher connection.Publisher, killSwitch firewall.KillSwitch) connection.PoolManager {
+		return connection.NewManager(
+			dialogFactory,
+			payment_factory.PaymentIssuerFactoryFunc(nodeOptions, di.SignerFactory),
+			di.ConnectionRegistry.CreateConnection,
+			publisher,
+			di.IPResolver,
+			di.ProposalCache,
+			killSwitch,
+		)
+	}
+	// kill switch of the primary connection lets the traffic thru the tunnels of the additional ones
+	killSwitch := connection.NewPoolKillSwitch(firewall.NewKillSwitch())
+	di.ConnectionPool = connection.NewPool(
+		newConnectionManager(di.EventBus, killSwitch.Connection(connection.PrimaryID)),
+		func(id connection.ID) connection.PoolManager {
+			// statistics, session history and location follow the primary connection only
+			return newConnectionManager(connection.NewPooledPublisher(di.EventBus, id), killSwitch.Connection(id))
+		},
 	)
+	di.ConnectionManager = di.ConnectionPool
 
 	router := tequilapi.NewAPIRouter()
 	tequilapi_endpoints.AddRouteForStop(router, utils.SoftKiller(di.Shutdown))
 	tequilapi_endpoints.AddRoutesForIdentities(router, di.IdentityManager, di.IdentitySelector)
-	tequilapi_endpoints.AddRoutesForConnection(router, di.ConnectionManager, di.IPResolver, di.StatisticsTracker, di.MysteriumAPI)
+	proposalSelector := selector.NewSelector(di.ProposalCache, di.MysteriumMorqaClient, di.SessionStorage)
+	tequilapi_endpoints.AddRoutesForConnection(router, di.ConnectionManager, di.IPResolver, di.StatisticsTracker, di.ProposalCache, proposalSelector)
+	tequilapi_endpoints.AddRoutesForConnections(router, di.ConnectionPool, di.ProposalCache, proposalSelector)
 	tequilapi_endpoints.AddRoutesForConnectionSessions(router, di.SessionStorage)
 	tequilapi_endpoints.AddRoutesForConnectionLocation(router, di.ConnectionManager, di.LocationResolver)
 	tequilapi_endpoints.AddRoutesForLocation(router, di.LocationResolver)
-	tequilapi_endpoints.AddRoutesForProposals(router, di.MysteriumAPI, di.MysteriumMorqaClient)
+	tequilapi_endpoints.AddRoutesForProposals(router, di.ProposalCache, di.MysteriumMorqaClient)
 	tequilapi_endpoints.AddRoutesForService(router, di.ServicesManager, serviceTypesRequestParser, nodeOptions.AccessPolicyEndpointAddress)
-	tequilapi_endpoints.AddRoutesForServiceSessions(router, di.ServiceSessionStorage)
+	tequilapi_endpoints.AddRoutesForServiceSessions(router, di.ServiceSessionStorage, di.PromiseStorage)
 	tequilapi_endpoints.AddRoutesForPayout(router, di.IdentityManager, di.SignerFactory, di.MysteriumAPI)
 	tequilapi_endpoints.AddRoutesForAccessPolicies(router, nodeOptions.AccessPolicyEndpointAddress)
 	tequilapi_endpoints.AddRoutesForNAT(router, di.NATStatusTracker.Status)
+	tequilapi_endpoints.AddRoutesForMetrics(router, promhttp.HandlerFor(di.MetricsRegistry, promhttp.HandlerOpts{}))
+	if err := tequilapi_endpoints.AddRoutesForEvents(router, di.EventBus, di.StatisticsTracker); err != nil {
+		return err
+	}
 	identity_registry.AddIdentityRegistrationEndpoint(router, di.IdentityRegistration, di.IdentityRegistry)
 
+	handler, err := di.bootstrapTequilapiAuth(nodeOptions, router)
+	if err != nil {
+		return err
+	}
+
+	listeners, err := bootstrapTequilapiListeners(nodeOptions, listener)
+	if err != nil {
+		return err
+	}
+
 	corsPolicy := tequilapi.NewMysteriumCorsPolicy()
-	httpAPIServer := tequilapi.NewServer(listener, router, corsPolicy)
+	httpAPIServer := tequilapi.NewServer(listeners, handler, corsPolicy)
 
 	di.Node = node.NewNode(di.ConnectionManager, httpAPIServer, di.EventBus, di.MetricsSender, di.NATPinger, di.UIServer)
+	return nil
+}
+
+// bootstrapTequilapiAuth adds the auth routes and requires the API token on the other ones, unless the authentication is disabled
+func (di *Dependencies) bootstrapTequilapiAuth(nodeOptions node.Options, router *httprouter.Router) (http.Handler, error) {
+	if nodeOptions.TequilapiAuth.Disabled {
+		log.Warn(logPrefix, "Tequilapi authentication is disabled, API is served to anyone who can reach it")
+		return router, nil
+	}
+
+	credentials := tequilapi_auth.Credentials{
+		Username: nodeOptions.TequilapiAuth.Username,
+		Password: nodeOptions.TequilapiAuth.Password,
+	}
+	authenticator, err := tequilapi_auth.NewAuthenticator(nodeOptions.Directories.Data, credentials)
+	if err != nil {
+		return nil, err
+	}
+
+	tequilapi_endpoints.AddRoutesForAuth(router, authenticator)
+	return tequilapi.ApplyAuthentication(router, authenticator), nil
+}
+
+// bootstrapTequilapiListeners serves HTTPS on the TCP listener if TLS is enabled and adds the Unix domain socket listener if it is configured
+func bootstrapTequilapiListeners(nodeOptions node.Options, tcpListener net.Listener) ([]net.Listener, error) {
+	listeners := []net.Listener{tcpListener}
+
+	if nodeOptions.TequilapiTLS.Enabled {
+		hosts := []string{"localhost", "127.0.0.1", "::1"}
+		if ip := net.ParseIP(nodeOptions.TequilapiAddress); ip == nil || !ip.IsUnspecified() {
+			hosts = append(hosts, nodeOptions.TequilapiAddress)
+		}
+		certificate, err := tequilapi.LoadOrGenerateCertificate(nodeOptions.TequilapiTLS.CertFile, nodeOptions.TequilapiTLS.KeyFile, hosts)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to load tequilapi certificate")
+		}
+		listeners[0] = tequilapi.NewTLSListener(tcpListener, certificate)
+	}
+
+	if nodeOptions.TequilapiSocket.Path != "" {
+		socketListener, err := tequilapi.ListenUnix(nodeOptions.TequilapiSocket.Path, nodeOptions.TequilapiSocket.Mode)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to listen on tequilapi socket")
+		}
+		listeners = append(listeners, socketListener)
+	}
+
+	return listeners, nil
 }
 
 func newSessionManagerFactory(
 	proposal market.ServiceProposal,
-	sessionStorage *session.StorageMemory,
+	sessionStorage *session.StorageBolt,
 	promiseStorage session_payment.PromiseStorage,
 	natPingerChan func(*traversal.Params),
 	natTracker NatEventTracker,
 	serviceID string,
 ) session.ManagerFactory {
 	return func(dialog communication.Dialog) *session.Manager {
-		providerBalanceTrackerFactory := func(consumerID, receiverID, issuerID identity.Identity) (session.BalanceTracker, error) {
-			timeTracker := session.NewTracker(time.Now)
-			// TODO: set the time and proper payment info
-			payment := dto.PaymentPerTime{
-				Price: money.Money{
-					Currency: money.CurrencyMyst,
-					Amount:   uint64(0),
-				},
-				Duration: time.Minute,
-			}
-			amountCalc := session.AmountCalc{PaymentDef: payment}
+		providerBalanceTrackerFactory := func(consumerID, receiverID, issuerID identity.Identity, dataTransfer session.DataTransferProvider) (session.BalanceTracker, error) {
 			sender := balance.NewBalanceSender(dialog)
 			promiseChan := make(chan promise.Message, 1)
 			listener := promise.NewListener(promiseChan)
@@ -442,9 +561,9 @@ func newSessionManagerFactory(
 			}
 
 			// TODO: the ints and times here need to be passed in as well, or defined as constants
-			tracker := balance.NewBalanceTracker(&timeTracker, amountCalc, 0)
+			tracker, dataTransferred := newProviderBalanceTracker(proposal.PaymentMethod, dataTransfer)
 			validator := validators.NewIssuedPromiseValidator(consumerID, receiverID, issuerID)
-			return session_payment.NewSessionBalance(sender, tracker, promiseChan, payment_factory.BalanceSendPeriod, payment_factory.PromiseWaitTimeout, validator, promiseStorage, consumerID, receiverID, issuerID), nil
+			return session_payment.NewSessionBalance(sender, tracker, promiseChan, payment_factory.BalanceSendPeriod, payment_factory.PromiseWaitTimeout, validator, promiseStorage, dataTransferred, consumerID, receiverID, issuerID), nil
 		}
 		return session.NewManager(
 			proposal,
@@ -458,6 +577,20 @@ func newSessionManagerFactory(
 	}
 }
 
+// newProviderBalanceTracker creates balance tracker charging the consumer according to the proposed payment method.
+// For the payments per data volume, it also returns the traffic provider charges for
+func newProviderBalanceTracker(paymentMethod market.PaymentMethod, dataTransfer session.DataTransferProvider) (*balance.BalanceTracker, session_payment.DataTransferred) {
+	if perGB, ok := paymentMethod.(market.PaymentPerGB); ok {
+		dataTracker := session.NewDataTracker(dataTransfer)
+		amountCalc := session.DataAmountCalc{PaymentDef: perGB}
+		return balance.NewDataBalanceTracker(&dataTracker, amountCalc, 0), dataTracker.Transferred
+	}
+
+	timeTracker := session.NewTracker(time.Now)
+	amountCalc := session.AmountCalc{PaymentDef: session.TimePayment(paymentMethod)}
+	return balance.NewBalanceTracker(&timeTracker, amountCalc, 0), nil
+}
+
 // function decides on network definition combined from testnet/localnet flags and possible overrides
 func (di *Dependencies) bootstrapNetworkComponents(options node.OptionsNetwork) (err error) {
 	network := metadata.DefaultNetwork
@@ -530,18 +663,39 @@ func (di *Dependencies) bootstrapIdentityComponents(options node.Options) {
 
 func (di *Dependencies) bootstrapDiscoveryComponents(options node.OptionsDiscovery) error {
 	var registry discovery.ProposalRegistry
+	var finder mysterium.ProposalFinder
 	switch options.Type {
 	case node.DiscoveryTypeAPI:
 		registry = discovery_api.NewRegistry(di.MysteriumAPI)
+		finder = di.MysteriumAPI
 	case node.DiscoveryTypeBroker:
-		sender := discovery_broker.NewSender(nats.NewConnectionMock())
-		registry = discovery_broker.NewRegistry(sender)
+		broker, err := nats_discovery.NewAddressFromHost(di.NetworkDefinition.BrokerAddress)
+		if err != nil {
+			return err
+		}
+		if err := broker.Connect(); err != nil {
+			return errors.Wrap(err, "failed to connect to discovery broker")
+		}
+		di.DiscoveryBroker = broker
+
+		registry = discovery_broker.NewRegistry(discovery_broker.NewSender(broker.GetConnection()))
+		di.DiscoveryBrokerProposals = discovery_broker.NewProposalProvider(
+			discovery_broker.NewReceiver(broker.GetConnection()),
+			brokerProposalTTL,
+		)
+		if err := di.DiscoveryBrokerProposals.Start(); err != nil {
+			return err
+		}
+		finder = di.DiscoveryBrokerProposals
 	default:
 		return fmt.Errorf("unknown discovery provider: %s", options.Type)
 	}
 
+	di.ProposalCache = mysterium.NewProposalCache(finder, proposalCacheRefreshInterval)
+	di.ProposalCache.Start()
+
 	di.DiscoveryFactory = func() service.Discovery {
-		return discovery.NewService(di.IdentityRegistry, di.IdentityRegistration, registry, di.SignerFactory)
+		return discovery.NewService(di.IdentityRegistry, di.IdentityRegistration, registry, di.SignerFactory, di.EventBus)
 	}
 
 	return nil
@@ -592,14 +746,27 @@ func (di *Dependencies) bootstrapBandwidthTracker() error {
 	return di.EventBus.SubscribeAsync(connection.StatisticsEventTopic, di.BandwidthTracker.ConsumeStatisticsEvent)
 }
 
-func (di *Dependencies) bootstrapMetrics(options node.Options) {
+func (di *Dependencies) bootstrapMetrics(options node.Options) error {
 	loader := &upnp.GatewayLoader{}
 
 	// warm up the loader as the load takes up to a couple of secs
 	go loader.Get()
 
 	appVersion := metadata.VersionAsString()
-	di.MetricsSender = metrics.NewSender(options.DisableMetrics, options.MetricsAddress, appVersion, loader.HumanReadable)
+	sinkOptions := metrics.SinkOptions{
+		ElasticSearchAddress: options.MetricsAddress,
+		WebhookAddress:       options.MetricsWebhook,
+		FilePath:             options.MetricsFile,
+	}
+	di.MetricsSender = metrics.NewSender(options.DisableMetrics, sinkOptions, di.Storage, appVersion, loader.HumanReadable)
+	if err := di.EventBus.Subscribe(connection.SessionEventTopic, di.MetricsSender.ConsumeSessionEvent); err != nil {
+		return err
+	}
+
+	di.MetricsRegistry = prometheus.NewRegistry()
+	di.MetricsCollector = metrics_prometheus.NewCollector(di.MetricsRegistry)
+	di.MetricsCollector.CollectPromises(di.PromiseStorage)
+	return di.MetricsCollector.Subscribe(di.EventBus)
 }
 
 func (di *Dependencies) bootstrapNATComponents(options node.Options) {
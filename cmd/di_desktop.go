@@ -21,6 +21,7 @@ package cmd
 
 import (
 	"errors"
+	"fmt"
 
 	log "github.com/cihub/seelog"
 	"github.com/mysteriumnetwork/node/communication"
@@ -30,9 +31,12 @@ import (
 	"github.com/mysteriumnetwork/node/core/node"
 	"github.com/mysteriumnetwork/node/core/port"
 	"github.com/mysteriumnetwork/node/core/service"
+	"github.com/mysteriumnetwork/node/dns"
+	"github.com/mysteriumnetwork/node/firewall"
 	"github.com/mysteriumnetwork/node/identity"
 	"github.com/mysteriumnetwork/node/market"
 	"github.com/mysteriumnetwork/node/nat"
+	"github.com/mysteriumnetwork/node/nat/egress"
 	"github.com/mysteriumnetwork/node/nat/mapping"
 	service_noop "github.com/mysteriumnetwork/node/services/noop"
 	service_openvpn "github.com/mysteriumnetwork/node/services/openvpn"
@@ -45,15 +49,23 @@ import (
 )
 
 // bootstrapServices loads all the components required for running services
-func (di *Dependencies) bootstrapServices(nodeOptions node.Options) {
-	di.bootstrapServiceComponents(nodeOptions)
+func (di *Dependencies) bootstrapServices(nodeOptions node.Options) error {
+	if err := di.bootstrapServiceComponents(nodeOptions); err != nil {
+		return err
+	}
 
 	di.bootstrapServiceOpenvpn(nodeOptions)
 	di.bootstrapServiceNoop(nodeOptions)
 	di.bootstrapServiceWireguard(nodeOptions)
+
+	if err := di.ServicesManager.RecoverSessions(); err != nil {
+		log.Error(logPrefix, "Failed to recover unfinished service sessions: ", err)
+	}
+	return nil
 }
 
 func (di *Dependencies) bootstrapServiceWireguard(nodeOptions node.Options) {
+	natService := di.egressNATService()
 	di.ServiceRegistry.Register(
 		wireguard.ServiceType,
 		func(serviceOptions service.Options) (service.Service, market.ServiceProposal, error) {
@@ -86,13 +98,15 @@ func (di *Dependencies) bootstrapServiceWireguard(nodeOptions node.Options) {
 				portPool = port.NewPool()
 			}
 
-			return wireguard_service.NewManager(di.IPResolver, di.NATService, mapPort, wgOptions, portPool),
-				wireguard_service.GetProposal(location), nil
+			return wireguard_service.NewManager(di.IPResolver, natService, mapPort, wgOptions, portPool),
+				wireguard_service.GetProposal(location, wgOptions), nil
 		},
 	)
+	di.ServiceRegistry.RegisterSessionCleanup(wireguard.ServiceType, wireguard_service.NewSessionCleanup(di.IPResolver, natService))
 }
 
 func (di *Dependencies) bootstrapServiceOpenvpn(nodeOptions node.Options) {
+	natService := di.egressNATService()
 	createService := func(serviceOptions service.Options) (service.Service, market.ServiceProposal, error) {
 		if err := nodeOptions.Openvpn.Check(); err != nil {
 			return nil, market.ServiceProposal{}, err
@@ -136,6 +150,9 @@ func (di *Dependencies) bootstrapServiceOpenvpn(nodeOptions node.Options) {
 		}
 
 		proposal := openvpn_discovery.NewServiceProposalWithLocation(currentLocation, transportOptions.Protocol)
+		if transportOptions.Priced() {
+			proposal.PaymentMethodType, proposal.PaymentMethod = transportOptions.PaymentMethod()
+		}
 
 		var portPool port.ServicePortSupplier
 		if transportOptions.Port != 0 {
@@ -149,7 +166,7 @@ func (di *Dependencies) bootstrapServiceOpenvpn(nodeOptions node.Options) {
 			transportOptions,
 			locationInfo,
 			di.ServiceSessionStorage,
-			di.NATService,
+			natService,
 			di.NATPinger,
 			mapPort,
 			di.NATTracker,
@@ -158,6 +175,7 @@ func (di *Dependencies) bootstrapServiceOpenvpn(nodeOptions node.Options) {
 		return manager, proposal, nil
 	}
 	di.ServiceRegistry.Register(service_openvpn.ServiceType, createService)
+	di.ServiceRegistry.RegisterSessionCleanup(service_openvpn.ServiceType, openvpn_service.NewSessionCleanup(di.IPResolver, natService))
 }
 
 func (di *Dependencies) bootstrapServiceNoop(nodeOptions node.Options) {
@@ -175,13 +193,33 @@ func (di *Dependencies) bootstrapServiceNoop(nodeOptions node.Options) {
 }
 
 // bootstrapServiceComponents initiates ServicesManager dependency
-func (di *Dependencies) bootstrapServiceComponents(nodeOptions node.Options) {
+func (di *Dependencies) bootstrapServiceComponents(nodeOptions node.Options) error {
 	di.NATService = nat.NewService()
 	if err := di.NATService.Enable(); err != nil {
 		log.Warn(logPrefix, "Failed to enable NAT forwarding: ", err)
 	}
+	if err := firewall.BootstrapInbound(); err != nil {
+		log.Warn(logPrefix, "Failed to bootstrap inbound firewall: ", err)
+	}
+
+	di.EgressPolicy = egress.Policy{
+		BlockedPorts: nodeOptions.Egress.BlockedPorts,
+		BlockPrivate: nodeOptions.Egress.BlockPrivate,
+	}
+	if nodeOptions.Egress.BlocklistPath != "" {
+		blocklist, err := egress.LoadBlocklist(nodeOptions.Egress.BlocklistPath)
+		if err != nil {
+			return fmt.Errorf("failed to load egress blocklist: %v", err)
+		}
+		di.EgressPolicy.Blocklist = blocklist
+	}
+	dnsForwarderFactory, err := newDNSForwarderFactory(nodeOptions.DNS)
+	if err != nil {
+		return err
+	}
 	di.ServiceRegistry = service.NewRegistry()
-	di.ServiceSessionStorage = session.NewStorageMemory()
+	di.ServiceSessionStorage = session.NewStorageBolt(di.Storage, di.PromiseStorage)
+	di.MetricsCollector.CollectServiceSessions(di.ServiceSessionStorage)
 
 	registeredIdentityValidator := func(peerID identity.Identity) error {
 		registered, err := di.IdentityRegistry.IsRegistered(peerID)
@@ -215,6 +253,7 @@ func (di *Dependencies) bootstrapServiceComponents(nodeOptions node.Options) {
 		return nats_dialog.NewDialogWaiter(
 			address,
 			di.SignerFactory(providerID),
+			di.EventBus,
 			registeredIdentityValidator,
 			allowedIdentityValidator,
 		), nil
@@ -235,12 +274,51 @@ func (di *Dependencies) bootstrapServiceComponents(nodeOptions node.Options) {
 		newDialogHandler,
 		di.DiscoveryFactory,
 		di.EventBus,
+		di.ServiceSessionStorage,
+		dnsForwarderFactory,
 	)
 
 	serviceCleaner := service.Cleaner{SessionStorage: di.ServiceSessionStorage}
 	if err := di.EventBus.Subscribe(service.StopTopic, serviceCleaner.Cleanup); err != nil {
 		log.Error(logPrefix, "failed to subscribe service cleaner")
 	}
+	return nil
+}
+
+// newDNSForwarderFactory returns the factory of the forwarders serving DNS to consumers, nil if the forwarder is disabled
+func newDNSForwarderFactory(options node.OptionsDNS) (service.DNSForwarderFactory, error) {
+	if !options.Forwarder {
+		return nil, nil
+	}
+
+	forwarderOptions := dns.ForwarderOptions{
+		Upstreams: options.Upstreams,
+		CacheSize: options.CacheSize,
+	}
+	if len(forwarderOptions.Upstreams) == 0 {
+		forwarderOptions.Upstreams = dns.SystemUpstreams()
+	}
+	if options.BlocklistPath != "" {
+		blocklist, err := dns.LoadBlocklist(options.BlocklistPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load DNS blocklist: %v", err)
+		}
+		log.Info(logPrefix, "DNS forwarder blocks ", blocklist.Len(), " domains")
+		forwarderOptions.Blocklist = blocklist
+	}
+
+	return func() (service.DNSForwarder, error) {
+		forwarder, err := dns.NewForwarder(forwarderOptions)
+		if err != nil {
+			return nil, err
+		}
+		return forwarder, nil
+	}, nil
+}
+
+// egressNATService returns NAT service enforcing the egress policy, every service type gets its own to count the blocked traffic separately
+func (di *Dependencies) egressNATService() *egress.NATService {
+	return egress.NewNATService(di.NATService, egress.NewEnforcer(di.EgressPolicy))
 }
 
 func (di *Dependencies) registerConnections(nodeOptions node.Options) {
@@ -0,0 +1,68 @@
+/*
+ * Copyright (C) 2019 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package cmd
+
+import (
+	log "github.com/cihub/seelog"
+	"github.com/mysteriumnetwork/node/core/node"
+	"github.com/mysteriumnetwork/node/dns"
+	"github.com/urfave/cli"
+)
+
+var (
+	dnsForwarderFlag = cli.BoolTFlag{
+		Name:  "dns.forwarder",
+		Usage: "Serve DNS to consumers on the provider tunnel addresses",
+	}
+	dnsUpstreamsFlag = cli.StringFlag{
+		Name:  "dns.upstreams",
+		Usage: "Comma separated DNS servers (IP or IP:port) the forwarder resolves names with, the system ones are used if empty",
+		Value: "",
+	}
+	dnsCacheSizeFlag = cli.IntFlag{
+		Name:  "dns.cache-size",
+		Usage: "Number of answers the DNS forwarder caches, 0 disables caching",
+		Value: dns.DefaultCacheSize,
+	}
+	dnsBlocklistFlag = cli.StringFlag{
+		Name:  "dns.blocklist",
+		Usage: "Path of the file listing domains the DNS forwarder does not resolve, one per line or in hosts file format",
+		Value: "",
+	}
+)
+
+// RegisterFlagsDNS function register DNS forwarder flags to flag list
+func RegisterFlagsDNS(flags *[]cli.Flag) {
+	*flags = append(*flags, dnsForwarderFlag, dnsUpstreamsFlag, dnsCacheSizeFlag, dnsBlocklistFlag)
+}
+
+// ParseFlagsDNS function fills in DNS forwarder options from CLI context
+func ParseFlagsDNS(ctx *cli.Context) node.OptionsDNS {
+	upstreams, err := dns.ParseUpstreams(ctx.GlobalString(dnsUpstreamsFlag.Name))
+	if err != nil {
+		log.Warn(logPrefix, "Failed to parse upstream DNS servers, the system ones are used. ", err)
+		upstreams = nil
+	}
+
+	return node.OptionsDNS{
+		Forwarder:     ctx.GlobalBoolT(dnsForwarderFlag.Name),
+		Upstreams:     upstreams,
+		CacheSize:     ctx.GlobalInt(dnsCacheSizeFlag.Name),
+		BlocklistPath: ctx.GlobalString(dnsBlocklistFlag.Name),
+	}
+}
@@ -0,0 +1,76 @@
+/*
+ * Copyright (C) 2019 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package cmd
+
+import (
+	"strconv"
+	"strings"
+
+	log "github.com/cihub/seelog"
+	"github.com/mysteriumnetwork/node/core/node"
+	"github.com/urfave/cli"
+)
+
+var (
+	egressBlockedPortsFlag = cli.StringFlag{
+		Name:  "egress.blocked-ports",
+		Usage: "Comma separated destination ports consumers are not allowed to reach thru the provider",
+		Value: "25",
+	}
+	egressBlockPrivateFlag = cli.BoolFlag{
+		Name:  "egress.block-private",
+		Usage: "Do not allow consumers to reach private and link-local networks, e.g. the LAN of the provider",
+	}
+	egressBlocklistFlag = cli.StringFlag{
+		Name:  "egress.blocklist",
+		Usage: "Path of the file listing destination CIDRs, IPs or domains consumers are not allowed to reach, one per line. Domains are resolved when the policy is applied to a consumer network, e.g. when a session or the service starts",
+		Value: "",
+	}
+)
+
+// RegisterFlagsEgress function register egress policy flags to flag list
+func RegisterFlagsEgress(flags *[]cli.Flag) {
+	*flags = append(*flags, egressBlockedPortsFlag, egressBlockPrivateFlag, egressBlocklistFlag)
+}
+
+// ParseFlagsEgress function fills in egress policy options from CLI context
+func ParseFlagsEgress(ctx *cli.Context) node.OptionsEgress {
+	return node.OptionsEgress{
+		BlockedPorts:  parsePorts(ctx.GlobalString(egressBlockedPortsFlag.Name)),
+		BlockPrivate:  ctx.GlobalBool(egressBlockPrivateFlag.Name),
+		BlocklistPath: ctx.GlobalString(egressBlocklistFlag.Name),
+	}
+}
+
+func parsePorts(value string) []int {
+	var ports []int
+	for _, field := range strings.Split(value, ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+
+		port, err := strconv.Atoi(field)
+		if err != nil || port < 1 || port > 65535 {
+			log.Warn(logPrefix, "Ignoring invalid blocked egress port: ", field)
+			continue
+		}
+		ports = append(ports, port)
+	}
+	return ports
+}
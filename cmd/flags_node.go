@@ -46,9 +46,17 @@ var (
 	}
 	metricsAddressFlag = cli.StringFlag{
 		Name:  "metrics.address",
-		Usage: "Address of metrics service",
+		Usage: "Address of metrics service, empty address stops sending metrics to it",
 		Value: "http://metrics.mysterium.network:8091",
 	}
+	metricsWebhookFlag = cli.StringFlag{
+		Name:  "metrics.webhook",
+		Usage: "URL to post usage metrics to as JSON array, in addition to the metrics service",
+	}
+	metricsFileFlag = cli.StringFlag{
+		Name:  "metrics.file",
+		Usage: "File to append usage metrics to, one JSON document per line",
+	}
 )
 
 // ParseKeystoreFlags parses the keystore options for node
@@ -65,12 +73,15 @@ func RegisterFlagsNode(flags *[]cli.Flag) error {
 	}
 
 	*flags = append(*flags, tequilapiAddressFlag, tequilapiPortFlag, keystoreLightweightFlag, metricsDisableFlag,
-		metricsAddressFlag)
+		metricsAddressFlag, metricsWebhookFlag, metricsFileFlag)
 
+	RegisterFlagsTequilapi(flags)
 	RegisterFlagsNetwork(flags)
 	RegisterFlagsDiscovery(flags)
 	openvpn_core.RegisterFlags(flags)
 	RegisterFlagsLocation(flags)
+	RegisterFlagsEgress(flags)
+	RegisterFlagsDNS(flags)
 	RegisterFlagsUI(flags)
 
 	return nil
@@ -79,21 +90,29 @@ func RegisterFlagsNode(flags *[]cli.Flag) error {
 // ParseFlagsNode function fills in node options from CLI context
 func ParseFlagsNode(ctx *cli.Context) node.Options {
 	logconfig.ParseFlags(ctx)
+	directories := ParseFlagsDirectory(ctx)
 	return node.Options{
-		Directories: ParseFlagsDirectory(ctx),
+		Directories: directories,
 
 		TequilapiAddress: ctx.GlobalString(tequilapiAddressFlag.Name),
 		TequilapiPort:    ctx.GlobalInt(tequilapiPortFlag.Name),
+		TequilapiAuth:    ParseFlagsTequilapiAuth(ctx),
+		TequilapiSocket:  ParseFlagsTequilapiSocket(ctx),
+		TequilapiTLS:     ParseFlagsTequilapiTLS(ctx, directories.Data),
 		UI:               ParseFlagsUI(ctx),
 
 		DisableMetrics: ctx.GlobalBool(metricsDisableFlag.Name),
 		MetricsAddress: ctx.GlobalString(metricsAddressFlag.Name),
+		MetricsWebhook: ctx.GlobalString(metricsWebhookFlag.Name),
+		MetricsFile:    ctx.GlobalString(metricsFileFlag.Name),
 
 		Keystore: ParseKeystoreFlags(ctx),
 
 		OptionsNetwork: ParseFlagsNetwork(ctx),
 		Discovery:      ParseFlagsDiscovery(ctx),
 		Location:       ParseFlagsLocation(ctx),
+		Egress:         ParseFlagsEgress(ctx),
+		DNS:            ParseFlagsDNS(ctx),
 
 		Openvpn: wrapper{nodeOptions: openvpn_core.ParseFlags(ctx)},
 	}
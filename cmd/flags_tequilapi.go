@@ -0,0 +1,123 @@
+/*
+ * Copyright (C) 2019 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+
+	log "github.com/cihub/seelog"
+	"github.com/mysteriumnetwork/node/core/node"
+	"github.com/urfave/cli"
+)
+
+const defaultTequilapiSocketMode = os.FileMode(0600)
+
+var (
+	tequilapiAuthDisableFlag = cli.BoolFlag{
+		Name:  "tequilapi.auth.disable",
+		Usage: "Serves tequilapi without authentication to anyone who can reach it",
+	}
+	tequilapiAuthUsernameFlag = cli.StringFlag{
+		Name:  "tequilapi.auth.username",
+		Usage: "Username exchanged for the API token at login, login is disabled if it is empty",
+		Value: "",
+	}
+	tequilapiAuthPasswordFlag = cli.StringFlag{
+		Name:  "tequilapi.auth.password",
+		Usage: "Password exchanged for the API token at login, login is disabled if it is empty",
+		Value: "",
+	}
+
+	tequilapiSocketFlag = cli.StringFlag{
+		Name:  "tequilapi.socket",
+		Usage: "Path of the Unix domain socket to serve tequilapi on next to TCP, the socket is not used if it is empty",
+		Value: "",
+	}
+	tequilapiSocketModeFlag = cli.StringFlag{
+		Name:  "tequilapi.socket.mode",
+		Usage: "Octal file mode restricting access to the tequilapi socket",
+		Value: "0600",
+	}
+
+	tequilapiTLSFlag = cli.BoolFlag{
+		Name:  "tequilapi.tls",
+		Usage: "Serves tequilapi over HTTPS instead of plain HTTP on the tequilapi address and port",
+	}
+	tequilapiTLSCertFlag = cli.StringFlag{
+		Name:  "tequilapi.tls.cert",
+		Usage: "Path of the PEM encoded tequilapi certificate, self-signed one is generated if neither the certificate nor the key exists (default: tequilapi.crt in data directory)",
+		Value: "",
+	}
+	tequilapiTLSKeyFlag = cli.StringFlag{
+		Name:  "tequilapi.tls.key",
+		Usage: "Path of the PEM encoded tequilapi certificate key (default: tequilapi.key in data directory)",
+		Value: "",
+	}
+)
+
+// RegisterFlagsTequilapi function register tequilapi authentication and transport flags to flag list
+func RegisterFlagsTequilapi(flags *[]cli.Flag) {
+	*flags = append(
+		*flags,
+		tequilapiAuthDisableFlag, tequilapiAuthUsernameFlag, tequilapiAuthPasswordFlag,
+		tequilapiSocketFlag, tequilapiSocketModeFlag,
+		tequilapiTLSFlag, tequilapiTLSCertFlag, tequilapiTLSKeyFlag,
+	)
+}
+
+// ParseFlagsTequilapiAuth function fills in tequilapi authentication options from CLI context
+func ParseFlagsTequilapiAuth(ctx *cli.Context) node.OptionsTequilapiAuth {
+	return node.OptionsTequilapiAuth{
+		Disabled: ctx.GlobalBool(tequilapiAuthDisableFlag.Name),
+		Username: ctx.GlobalString(tequilapiAuthUsernameFlag.Name),
+		Password: ctx.GlobalString(tequilapiAuthPasswordFlag.Name),
+	}
+}
+
+// ParseFlagsTequilapiSocket function fills in tequilapi socket options from CLI context
+func ParseFlagsTequilapiSocket(ctx *cli.Context) node.OptionsTequilapiSocket {
+	mode, err := strconv.ParseUint(ctx.GlobalString(tequilapiSocketModeFlag.Name), 8, 32)
+	if err != nil {
+		log.Warn(logPrefix, "Failed to parse tequilapi socket mode, ", defaultTequilapiSocketMode, " is used. ", err)
+		mode = uint64(defaultTequilapiSocketMode)
+	}
+
+	return node.OptionsTequilapiSocket{
+		Path: ctx.GlobalString(tequilapiSocketFlag.Name),
+		Mode: os.FileMode(mode).Perm(),
+	}
+}
+
+// ParseFlagsTequilapiTLS function fills in tequilapi TLS options from CLI context,
+// certificate and its key are kept in the given data directory unless their paths are set
+func ParseFlagsTequilapiTLS(ctx *cli.Context, dataDir string) node.OptionsTequilapiTLS {
+	options := node.OptionsTequilapiTLS{
+		Enabled:  ctx.GlobalBool(tequilapiTLSFlag.Name),
+		CertFile: ctx.GlobalString(tequilapiTLSCertFlag.Name),
+		KeyFile:  ctx.GlobalString(tequilapiTLSKeyFlag.Name),
+	}
+	if options.CertFile == "" {
+		options.CertFile = filepath.Join(dataDir, "tequilapi.crt")
+	}
+	if options.KeyFile == "" {
+		options.KeyFile = filepath.Join(dataDir, "tequilapi.key")
+	}
+	return options
+}
@@ -0,0 +1,69 @@
+/*
+ * Copyright (C) 2019 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package cmd
+
+import (
+	"net"
+
+	"github.com/mysteriumnetwork/node/core/node"
+	"github.com/mysteriumnetwork/node/tequilapi/auth"
+	tequilapi_client "github.com/mysteriumnetwork/node/tequilapi/client"
+	"github.com/pkg/errors"
+)
+
+// NewTequilapiClient creates the client of the node tequilapi. Client logs in if the credentials are configured,
+// otherwise it uses the API token stored in the node data directory. Client is returned even if it is not authenticated,
+// it is nil only if the transport could not be set up.
+func NewTequilapiClient(options node.Options) (*tequilapi_client.Client, error) {
+	client, err := newTequilapiTransportClient(options)
+	if err != nil {
+		return nil, err
+	}
+	if options.TequilapiAuth.Disabled {
+		return client, nil
+	}
+
+	if options.TequilapiAuth.Username != "" {
+		_, err := client.Login(options.TequilapiAuth.Username, options.TequilapiAuth.Password)
+		return client, errors.Wrap(err, "failed to login to tequilapi")
+	}
+
+	token, err := auth.LoadToken(options.Directories.Data)
+	if err != nil {
+		return client, errors.Wrap(err, "failed to load tequilapi token")
+	}
+	client.SetToken(token)
+	return client, nil
+}
+
+// newTequilapiTransportClient prefers the Unix domain socket over TCP, as it is not exposed to the network
+func newTequilapiTransportClient(options node.Options) (*tequilapi_client.Client, error) {
+	if options.TequilapiSocket.Path != "" {
+		return tequilapi_client.NewUnixClient(options.TequilapiSocket.Path), nil
+	}
+
+	address := options.TequilapiAddress
+	if ip := net.ParseIP(address); ip != nil && ip.IsUnspecified() {
+		address = "127.0.0.1"
+	}
+	if options.TequilapiTLS.Enabled {
+		client, err := tequilapi_client.NewTLSClient(address, options.TequilapiPort, options.TequilapiTLS.CertFile)
+		return client, errors.Wrap(err, "failed to load tequilapi certificate")
+	}
+	return tequilapi_client.NewClient(address, options.TequilapiPort), nil
+}
@@ -34,10 +34,11 @@ import (
 type validator func(peerID identity.Identity) error
 
 // NewDialogWaiter constructs new DialogWaiter which works through NATS connection.
-func NewDialogWaiter(address *discovery.AddressNATS, signer identity.Signer, validators ...validator) *dialogWaiter {
+func NewDialogWaiter(address *discovery.AddressNATS, signer identity.Signer, publisher Publisher, validators ...validator) *dialogWaiter {
 	return &dialogWaiter{
 		address:    address,
 		signer:     signer,
+		publisher:  publisher,
 		dialogs:    make([]communication.Dialog, 0),
 		validators: validators,
 	}
@@ -48,6 +49,7 @@ const waiterLogPrefix = "[NATS.DialogWaiter] "
 type dialogWaiter struct {
 	address    *discovery.AddressNATS
 	signer     identity.Signer
+	publisher  Publisher
 	dialogs    []communication.Dialog
 	validators []validator
 
@@ -84,12 +86,14 @@ func (waiter *dialogWaiter) ServeDialogs(dialogHandler communication.DialogHandl
 		err := waiter.validateDialogRequest(request)
 		if err != nil {
 			log.Error(waiterLogPrefix, "Validation check failed: ", err.Error())
+			waiter.publishFailed(request.PeerID, FailedStageValidation, err)
 			return &responseInvalidIdentity, nil
 		}
 
 		uid, err := uuid.NewV4()
 		if err != nil {
 			log.Error(waiterLogPrefix, "Failed to generate unique topic: ", err)
+			waiter.publishFailed(request.PeerID, FailedStageHandling, err)
 			return &responseInternalError, errors.Wrap(err, "failed to generate unique topic")
 		}
 
@@ -103,6 +107,7 @@ func (waiter *dialogWaiter) ServeDialogs(dialogHandler communication.DialogHandl
 		err = dialogHandler.Handle(dialog)
 		if err != nil {
 			log.Error(waiterLogPrefix, fmt.Sprintf("Failed dialog from: '%s'. %s", request.PeerID, err))
+			waiter.publishFailed(request.PeerID, FailedStageHandling, err)
 			return &responseInternalError, nil
 		}
 
@@ -123,6 +128,14 @@ func (waiter *dialogWaiter) ServeDialogs(dialogHandler communication.DialogHandl
 	return receiver.Respond(&dialogCreateConsumer{createDialog})
 }
 
+func (waiter *dialogWaiter) publishFailed(peerID string, stage string, err error) {
+	waiter.publisher.Publish(FailedTopic, FailedEvent{
+		PeerID: identity.FromAddress(peerID),
+		Stage:  stage,
+		Error:  err,
+	})
+}
+
 func (waiter *dialogWaiter) newCodecForPeer(peerID identity.Identity) *codecSecured {
 	return NewCodecSecured(
 		communication.NewCodecJSON(),
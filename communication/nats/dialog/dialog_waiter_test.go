@@ -37,7 +37,7 @@ func TestDialogWaiter_Factory(t *testing.T) {
 	address := discovery.NewAddress("custom", "nats://far-server:4222")
 	signer := &identity.SignerFake{}
 
-	waiter := NewDialogWaiter(address, signer)
+	waiter := NewDialogWaiter(address, signer, &publisherFake{})
 	assert.NotNil(t, waiter)
 	assert.Equal(t, address, waiter.address)
 	assert.Equal(t, signer, waiter.signer)
@@ -88,7 +88,7 @@ func TestDialogWaiter_ServeDialogsTopicUUID(t *testing.T) {
 		dialogReceived: make(chan communication.Dialog),
 	}
 
-	waiter := NewDialogWaiter(discovery.NewAddressWithConnection(connection, "my-topic"), signer)
+	waiter := NewDialogWaiter(discovery.NewAddressWithConnection(connection, "my-topic"), signer, &publisherFake{})
 
 	err := waiter.ServeDialogs(handler)
 	assert.NoError(t, err)
@@ -144,7 +144,8 @@ func TestDialogWaiter_ServeDialogsRejectConsumersUsingValidator(t *testing.T) {
 		dialogReceived: make(chan communication.Dialog),
 	}
 
-	waiter := NewDialogWaiter(discovery.NewAddressWithConnection(connection, "test-topic"), signer, func(_ identity.Identity) error { return errors.New("expected error") })
+	publisher := &publisherFake{}
+	waiter := NewDialogWaiter(discovery.NewAddressWithConnection(connection, "test-topic"), signer, publisher, func(_ identity.Identity) error { return errors.New("expected error") })
 
 	err := waiter.ServeDialogs(mockeDialogHandler)
 	assert.NoError(t, err)
@@ -166,13 +167,19 @@ func TestDialogWaiter_ServeDialogsRejectConsumersUsingValidator(t *testing.T) {
 		}`,
 		string(msg.Data),
 	)
+
+	assert.Equal(t, FailedTopic, publisher.topic)
+	failedEvent := publisher.data.(FailedEvent)
+	assert.Equal(t, identity.FromAddress("0x28bf83df144ab7a566bc8509d1fff5d5470bd4ea"), failedEvent.PeerID)
+	assert.Equal(t, FailedStageValidation, failedEvent.Stage)
 }
 
 func dialogServe(connection nats.Connection, signer identity.Signer) (waiter *dialogWaiter, handler *dialogHandler) {
 	topic := "my-topic"
 	waiter = &dialogWaiter{
-		address: discovery.NewAddressWithConnection(connection, topic),
-		signer:  signer,
+		address:   discovery.NewAddressWithConnection(connection, topic),
+		signer:    signer,
+		publisher: &publisherFake{},
 	}
 	handler = &dialogHandler{
 		dialogReceived: make(chan communication.Dialog),
@@ -203,6 +210,16 @@ func dialogWait(handler *dialogHandler) (communication.Dialog, error) {
 	}
 }
 
+type publisherFake struct {
+	topic string
+	data  interface{}
+}
+
+func (publisher *publisherFake) Publish(topic string, data interface{}) {
+	publisher.topic = topic
+	publisher.data = data
+}
+
 type dialogHandler struct {
 	dialogReceived chan communication.Dialog
 }
@@ -0,0 +1,43 @@
+/*
+ * Copyright (C) 2017 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package dialog
+
+import "github.com/mysteriumnetwork/node/identity"
+
+// FailedTopic is the topic on which dialogs refused by the waiter are published
+const FailedTopic = "Dialog failed"
+
+// Stages of the dialog creation at which it can fail
+const (
+	// FailedStageValidation means the peer did not pass the validation
+	FailedStageValidation = "validation"
+	// FailedStageHandling means the dialog was not accepted by its handler
+	FailedStageHandling = "handling"
+)
+
+// FailedEvent is published when the dialog requested by the peer is refused
+type FailedEvent struct {
+	PeerID identity.Identity
+	Stage  string
+	Error  error
+}
+
+// Publisher is responsible for publishing given events
+type Publisher interface {
+	Publish(topic string, data interface{})
+}
@@ -25,6 +25,7 @@ import (
 
 	log "github.com/cihub/seelog"
 	"github.com/mysteriumnetwork/node/communication/nats"
+	"github.com/mysteriumnetwork/node/firewall"
 	"github.com/mysteriumnetwork/node/identity"
 	"github.com/mysteriumnetwork/node/market"
 	nats_lib "github.com/nats-io/go-nats"
@@ -42,6 +43,26 @@ func NewAddress(topic string, addresses ...string) *AddressNATS {
 
 // NewAddressFromHostAndID generates NATS address for current node
 func NewAddressFromHostAndID(uri string, myID identity.Identity, serviceType string) (*AddressNATS, error) {
+	server, err := brokerServer(uri)
+	if err != nil {
+		return nil, err
+	}
+
+	topic := fmt.Sprintf("%v.%v", myID.Address, serviceType)
+	return NewAddress(topic, server), nil
+}
+
+// NewAddressFromHost generates NATS address to the broker without a topic
+func NewAddressFromHost(uri string) (*AddressNATS, error) {
+	server, err := brokerServer(uri)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewAddress("", server), nil
+}
+
+func brokerServer(uri string) (string, error) {
 	// Add scheme first otherwise url.Parse() fails.
 	var rawurl string
 	if strings.HasPrefix(uri, "nats:") {
@@ -52,15 +73,14 @@ func NewAddressFromHostAndID(uri string, myID identity.Identity, serviceType str
 
 	url, err := url.Parse(rawurl)
 	if err != nil {
-		return nil, err
+		return "", err
 	}
 
 	if url.Port() == "" {
 		url.Host = fmt.Sprintf("%s:%d", url.Host, BrokerPort)
 	}
 
-	topic := fmt.Sprintf("%v.%v", myID.Address, serviceType)
-	return NewAddress(topic, url.String()), nil
+	return url.String(), nil
 }
 
 // NewAddressForContact extracts NATS address from given contact structure
@@ -103,6 +123,8 @@ func (address *AddressNATS) Connect() (err error) {
 	options.MaxReconnect = BrokerMaxReconnect
 	options.ReconnectWait = BrokerReconnectWait
 	options.Timeout = BrokerTimeout
+	// broker is reached while the tunnel is down as well, e.g. to fail over to another provider
+	options.Dialer = firewall.NewDialer(BrokerTimeout)
 	options.PingInterval = 10 * time.Second
 	options.DisconnectedCB = func(nc *nats_lib.Conn) { log.Warn(natsLogPrefix, "Disconnected") }
 	options.ReconnectedCB = func(nc *nats_lib.Conn) { log.Warn(natsLogPrefix, "Reconnected") }
@@ -69,6 +69,20 @@ func TestNewAddressFromHostAndID(t *testing.T) {
 	}
 }
 
+func TestNewAddressFromHost(t *testing.T) {
+	address, err := NewAddressFromHost("example.com")
+
+	assert.NoError(t, err)
+	assert.Equal(
+		t,
+		&AddressNATS{
+			servers: []string{"nats://example.com:4222"},
+			topic:   "",
+		},
+		address,
+	)
+}
+
 func TestNewAddressForContact(t *testing.T) {
 	address, err := NewAddressForContact(market.Contact{
 		Type: "nats/v1",
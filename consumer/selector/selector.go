@@ -0,0 +1,136 @@
+/*
+ * Copyright (C) 2019 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package selector
+
+import (
+	"sort"
+	"time"
+
+	log "github.com/cihub/seelog"
+	"github.com/mysteriumnetwork/node/consumer/session"
+	"github.com/mysteriumnetwork/node/market"
+	"github.com/mysteriumnetwork/node/market/metrics"
+)
+
+const selectorLogPrefix = "[proposal-selector] "
+
+// failedConnectsPeriod is how far back the failed connects are taken into account when ranking proposals
+const failedConnectsPeriod = session.FailedConnectsRetention
+
+// the prior of the connection success rate, proposals without metrics are ranked as if one of two attempts succeeded
+const (
+	priorSuccesses = 1
+	priorAttempts  = 2
+)
+
+// ProposalFinder allows to find proposals matching the filter
+type ProposalFinder interface {
+	FindProposals(filter market.ProposalFilter) ([]market.ServiceProposal, error)
+}
+
+// FailedConnectHistory keeps the failed attempts to connect to providers
+type FailedConnectHistory interface {
+	GetFailedConnects(since time.Time) ([]session.FailedConnect, error)
+}
+
+// Criteria describes the proposals acceptable for the connection
+type Criteria struct {
+	Filter market.ProposalFilter
+	// QualityMin is the lowest acceptable connection success rate (from 0 to 1) measured by the quality oracle,
+	// the rate is smoothed the same way as for ranking, so the proposals without metrics have 0.5
+	QualityMin float64
+}
+
+// Selector ranks proposals using the quality oracle metrics and the local history of failed connects
+type Selector struct {
+	proposalFinder ProposalFinder
+	qualityOracle  metrics.QualityOracle
+	history        FailedConnectHistory
+	timeGetter     func() time.Time
+}
+
+// NewSelector creates a new proposal selector
+func NewSelector(proposalFinder ProposalFinder, qualityOracle metrics.QualityOracle, history FailedConnectHistory) *Selector {
+	return &Selector{
+		proposalFinder: proposalFinder,
+		qualityOracle:  qualityOracle,
+		history:        history,
+		timeGetter:     time.Now,
+	}
+}
+
+type rankedProposal struct {
+	proposal market.ServiceProposal
+	score    float64
+}
+
+// Candidates returns the proposals matching the criteria, best ranked first.
+// Proposal is ranked by its connection success rate, which is lowered by every recent failed connect to it.
+// The success rate is smoothed towards a neutral prior, so that the proposals without metrics are not ranked last.
+func (selector *Selector) Candidates(criteria Criteria) ([]market.ServiceProposal, error) {
+	proposals, err := selector.proposalFinder.FindProposals(criteria.Filter)
+	if err != nil {
+		return nil, err
+	}
+
+	connectCounts := metrics.ParseConnectCounts(selector.qualityOracle.ProposalsMetrics())
+	failedConnects := selector.failedConnectCounts()
+
+	ranked := make([]rankedProposal, 0, len(proposals))
+	for _, proposal := range proposals {
+		id := market.ProposalID{ProviderID: proposal.ProviderID, ServiceType: proposal.ServiceType}
+		quality := smoothedSuccessRate(connectCounts[id])
+		if quality < criteria.QualityMin {
+			continue
+		}
+		ranked = append(ranked, rankedProposal{
+			proposal: proposal,
+			score:    quality / float64(1+failedConnects[id]),
+		})
+	}
+
+	sort.SliceStable(ranked, func(i, j int) bool {
+		return ranked[i].score > ranked[j].score
+	})
+
+	candidates := make([]market.ServiceProposal, len(ranked))
+	for i, r := range ranked {
+		candidates[i] = r.proposal
+	}
+	return candidates, nil
+}
+
+func smoothedSuccessRate(count metrics.ConnectCount) float64 {
+	attempts := count.Success + count.Fail + count.Timeout
+	return float64(count.Success+priorSuccesses) / float64(attempts+priorAttempts)
+}
+
+func (selector *Selector) failedConnectCounts() map[market.ProposalID]int {
+	counts := make(map[market.ProposalID]int)
+
+	failedConnects, err := selector.history.GetFailedConnects(selector.timeGetter().Add(-failedConnectsPeriod))
+	if err != nil {
+		log.Warn(selectorLogPrefix, "Failed to get failed connects history: ", err)
+		return counts
+	}
+
+	for _, failedConnect := range failedConnects {
+		counts[market.ProposalID{ProviderID: failedConnect.ProviderID.Address, ServiceType: failedConnect.ServiceType}]++
+	}
+	return counts
+}
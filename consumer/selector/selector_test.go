@@ -0,0 +1,143 @@
+/*
+ * Copyright (C) 2019 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package selector
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/mysteriumnetwork/node/consumer/session"
+	"github.com/mysteriumnetwork/node/identity"
+	"github.com/mysteriumnetwork/node/market"
+	"github.com/stretchr/testify/assert"
+)
+
+func proposal(providerID string) market.ServiceProposal {
+	return market.ServiceProposal{ProviderID: providerID, ServiceType: "openvpn"}
+}
+
+func connectCount(providerID string, success, fail int) json.RawMessage {
+	return json.RawMessage(fmt.Sprintf(`{
+		"proposalID": {"providerID": "%s", "serviceType": "openvpn"},
+		"connectCount": {"success": %d, "fail": %d, "timeout": 0}
+	}`, providerID, success, fail))
+}
+
+func Test_Selector_RanksByQuality(t *testing.T) {
+	finder := &mockProposalFinder{proposals: []market.ServiceProposal{proposal("0x1"), proposal("0x2"), proposal("0x3")}}
+	oracle := &mockQualityOracle{metrics: []json.RawMessage{connectCount("0x1", 5, 5), connectCount("0x2", 9, 1)}}
+	selector := NewSelector(finder, oracle, &mockFailedConnectHistory{})
+
+	filter := market.ProposalFilter{ServiceType: "openvpn", LocationCountry: "LT"}
+	candidates, err := selector.Candidates(Criteria{Filter: filter})
+
+	assert.NoError(t, err)
+	assert.Equal(t, filter, finder.recordedFilter)
+	assert.Equal(t, []market.ServiceProposal{proposal("0x2"), proposal("0x1"), proposal("0x3")}, candidates)
+}
+
+func Test_Selector_RanksProposalsWithoutMetricsAsNeutral(t *testing.T) {
+	finder := &mockProposalFinder{proposals: []market.ServiceProposal{proposal("0x1"), proposal("0x2"), proposal("0x3")}}
+	oracle := &mockQualityOracle{metrics: []json.RawMessage{connectCount("0x1", 1, 9), connectCount("0x2", 9, 1)}}
+	selector := NewSelector(finder, oracle, &mockFailedConnectHistory{})
+
+	candidates, err := selector.Candidates(Criteria{})
+
+	assert.NoError(t, err)
+	assert.Equal(t, []market.ServiceProposal{proposal("0x2"), proposal("0x3"), proposal("0x1")}, candidates)
+}
+
+func Test_Selector_SkipsLowQuality(t *testing.T) {
+	finder := &mockProposalFinder{proposals: []market.ServiceProposal{proposal("0x1"), proposal("0x2"), proposal("0x3")}}
+	oracle := &mockQualityOracle{metrics: []json.RawMessage{connectCount("0x1", 8, 2), connectCount("0x2", 1, 9)}}
+	selector := NewSelector(finder, oracle, &mockFailedConnectHistory{})
+
+	candidates, err := selector.Candidates(Criteria{QualityMin: 0.6})
+
+	assert.NoError(t, err)
+	assert.Equal(t, []market.ServiceProposal{proposal("0x1")}, candidates)
+}
+
+func Test_Selector_LowersRankOfRecentlyFailedProviders(t *testing.T) {
+	finder := &mockProposalFinder{proposals: []market.ServiceProposal{proposal("0x1"), proposal("0x2")}}
+	oracle := &mockQualityOracle{metrics: []json.RawMessage{connectCount("0x1", 9, 1), connectCount("0x2", 6, 4)}}
+	history := &mockFailedConnectHistory{failedConnects: []session.FailedConnect{
+		{ProviderID: identity.FromAddress("0x1"), ServiceType: "openvpn"},
+		{ProviderID: identity.FromAddress("0x2"), ServiceType: "wireguard"},
+	}}
+	selector := NewSelector(finder, oracle, history)
+	selector.timeGetter = func() time.Time { return time.Date(2019, 1, 2, 0, 0, 0, 0, time.UTC) }
+
+	candidates, err := selector.Candidates(Criteria{})
+
+	assert.NoError(t, err)
+	assert.Equal(t, []market.ServiceProposal{proposal("0x2"), proposal("0x1")}, candidates)
+	assert.Equal(t, time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC), history.recordedSince)
+}
+
+func Test_Selector_IgnoresHistoryError(t *testing.T) {
+	finder := &mockProposalFinder{proposals: []market.ServiceProposal{proposal("0x1")}}
+	selector := NewSelector(finder, &mockQualityOracle{}, &mockFailedConnectHistory{err: errors.New("boom")})
+
+	candidates, err := selector.Candidates(Criteria{})
+
+	assert.NoError(t, err)
+	assert.Equal(t, []market.ServiceProposal{proposal("0x1")}, candidates)
+}
+
+func Test_Selector_ReturnsFinderError(t *testing.T) {
+	finder := &mockProposalFinder{err: errors.New("boom")}
+	selector := NewSelector(finder, &mockQualityOracle{}, &mockFailedConnectHistory{})
+
+	_, err := selector.Candidates(Criteria{})
+
+	assert.Error(t, err)
+}
+
+type mockProposalFinder struct {
+	proposals      []market.ServiceProposal
+	err            error
+	recordedFilter market.ProposalFilter
+}
+
+func (finder *mockProposalFinder) FindProposals(filter market.ProposalFilter) ([]market.ServiceProposal, error) {
+	finder.recordedFilter = filter
+	return finder.proposals, finder.err
+}
+
+type mockQualityOracle struct {
+	metrics []json.RawMessage
+}
+
+func (oracle *mockQualityOracle) ProposalsMetrics() []json.RawMessage {
+	return oracle.metrics
+}
+
+type mockFailedConnectHistory struct {
+	failedConnects []session.FailedConnect
+	err            error
+	recordedSince  time.Time
+}
+
+func (history *mockFailedConnectHistory) GetFailedConnects(since time.Time) ([]session.FailedConnect, error) {
+	history.recordedSince = since
+	return history.failedConnects, history.err
+}
@@ -64,3 +64,11 @@ func (se *History) GetDuration() uint64 {
 	}
 	return 0
 }
+
+// FailedConnect holds a failed attempt to connect to the provider
+type FailedConnect struct {
+	ID          int `storm:"id,increment"`
+	ProviderID  identity.Identity
+	ServiceType string
+	Failed      time.Time
+}
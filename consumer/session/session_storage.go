@@ -19,16 +19,25 @@ package session
 
 import (
 	"fmt"
+	"sync"
 	"time"
 
 	log "github.com/cihub/seelog"
 	"github.com/mysteriumnetwork/node/consumer"
 	"github.com/mysteriumnetwork/node/core/connection"
+	"github.com/mysteriumnetwork/node/identity"
 	"github.com/mysteriumnetwork/node/session"
 )
 
 const sessionStorageLogPrefix = "[session-storage] "
 const sessionStorageBucketName = "session-history"
+const failedConnectsBucketName = "failed-connects"
+
+// FailedConnectsRetention is how long the failed connects are kept
+const FailedConnectsRetention = 24 * time.Hour
+
+// maxFailedConnects caps the kept failed connects, the oldest ones are removed first
+const maxFailedConnects = 1000
 
 // StatsRetriever can fetch current session stats
 type StatsRetriever interface {
@@ -40,12 +49,17 @@ type Storer interface {
 	Store(bucket string, object interface{}) error
 	Update(bucket string, object interface{}) error
 	GetAllFrom(bucket string, array interface{}) error
+	Delete(bucket string, object interface{}) error
 }
 
 // Storage contains functions for storing, getting session objects
 type Storage struct {
 	storage        Storer
 	statsRetriever StatsRetriever
+
+	// pooledStats keeps the latest statistics of the additional connections of the pool
+	pooledStats map[connection.ID]consumer.SessionStatistics
+	lock        sync.Mutex
 }
 
 // NewSessionStorage creates session repository with given dependencies
@@ -53,6 +67,7 @@ func NewSessionStorage(storage Storer, statsRetriever StatsRetriever) *Storage {
 	return &Storage{
 		storage:        storage,
 		statsRetriever: statsRetriever,
+		pooledStats:    make(map[connection.ID]consumer.SessionStatistics),
 	}
 }
 
@@ -66,21 +81,67 @@ func (repo *Storage) GetAll() ([]History, error) {
 	return sessions, nil
 }
 
+// GetFailedConnects returns the failed attempts to connect to providers made since the given time
+func (repo *Storage) GetFailedConnects(since time.Time) ([]FailedConnect, error) {
+	var failedConnects []FailedConnect
+	err := repo.storage.GetAllFrom(failedConnectsBucketName, &failedConnects)
+	if err != nil {
+		return nil, err
+	}
+
+	recent := make([]FailedConnect, 0)
+	for _, failedConnect := range failedConnects {
+		if !failedConnect.Failed.Before(since) {
+			recent = append(recent, failedConnect)
+		}
+	}
+	return recent, nil
+}
+
 // ConsumeSessionEvent consumes the session state change events
 func (repo *Storage) ConsumeSessionEvent(sessionEvent connection.SessionEvent) {
 	switch sessionEvent.Status {
 	case connection.SessionEndedStatus:
-		repo.handleEndedEvent(sessionEvent.SessionInfo.SessionID)
+		repo.handleEndedEvent(sessionEvent.SessionInfo.SessionID, repo.statsRetriever.Retrieve())
 	case connection.SessionCreatedStatus:
 		repo.handleCreatedEvent(sessionEvent.SessionInfo)
+	case connection.SessionConnectFailedStatus:
+		repo.handleConnectFailedEvent(sessionEvent.SessionInfo)
+	}
+}
+
+// ConsumePooledEvent consumes the events of the additional connections of the pool,
+// their sessions are stored with the statistics of the connection instead of the primary one
+func (repo *Storage) ConsumePooledEvent(pooledEvent connection.PooledEvent) {
+	switch event := pooledEvent.Event.(type) {
+	case consumer.SessionStatistics:
+		repo.lock.Lock()
+		repo.pooledStats[pooledEvent.ConnectionID] = event
+		repo.lock.Unlock()
+	case connection.SessionEvent:
+		switch event.Status {
+		case connection.SessionEndedStatus:
+			repo.lock.Lock()
+			stats := repo.pooledStats[pooledEvent.ConnectionID]
+			delete(repo.pooledStats, pooledEvent.ConnectionID)
+			repo.lock.Unlock()
+			repo.handleEndedEvent(event.SessionInfo.SessionID, stats)
+		case connection.SessionCreatedStatus:
+			repo.lock.Lock()
+			delete(repo.pooledStats, pooledEvent.ConnectionID)
+			repo.lock.Unlock()
+			repo.handleCreatedEvent(event.SessionInfo)
+		case connection.SessionConnectFailedStatus:
+			repo.handleConnectFailedEvent(event.SessionInfo)
+		}
 	}
 }
 
-func (repo *Storage) handleEndedEvent(sessionID session.ID) {
+func (repo *Storage) handleEndedEvent(sessionID session.ID, stats consumer.SessionStatistics) {
 	updatedSession := &History{
 		SessionID: sessionID,
 		Updated:   time.Now().UTC(),
-		DataStats: repo.statsRetriever.Retrieve(),
+		DataStats: stats,
 		Status:    SessionStatusCompleted,
 	}
 	err := repo.storage.Update(sessionStorageBucketName, updatedSession)
@@ -103,3 +164,41 @@ func (repo *Storage) handleCreatedEvent(sessionInfo connection.SessionInfo) {
 		log.Trace(sessionStorageLogPrefix, fmt.Sprintf("Session %v saved", sessionInfo.SessionID))
 	}
 }
+
+func (repo *Storage) handleConnectFailedEvent(sessionInfo connection.SessionInfo) {
+	failedConnect := &FailedConnect{
+		ProviderID:  identity.FromAddress(sessionInfo.Proposal.ProviderID),
+		ServiceType: sessionInfo.Proposal.ServiceType,
+		Failed:      time.Now().UTC(),
+	}
+	err := repo.storage.Store(failedConnectsBucketName, failedConnect)
+	if err != nil {
+		log.Error(sessionStorageLogPrefix, err)
+		return
+	}
+	log.Trace(sessionStorageLogPrefix, fmt.Sprintf("Failed connect to %v saved", sessionInfo.Proposal.ProviderID))
+
+	if err := repo.pruneFailedConnects(failedConnect.Failed.Add(-FailedConnectsRetention)); err != nil {
+		log.Error(sessionStorageLogPrefix, "Failed to prune failed connects: ", err)
+	}
+}
+
+// pruneFailedConnects removes the failed connects older than the given time and the oldest ones above the cap
+func (repo *Storage) pruneFailedConnects(before time.Time) error {
+	var failedConnects []FailedConnect
+	if err := repo.storage.GetAllFrom(failedConnectsBucketName, &failedConnects); err != nil {
+		return err
+	}
+
+	// failed connects are listed by their incremental ID, oldest first
+	excess := len(failedConnects) - maxFailedConnects
+	for i := range failedConnects {
+		if i >= excess && !failedConnects[i].Failed.Before(before) {
+			continue
+		}
+		if err := repo.storage.Delete(failedConnectsBucketName, &failedConnects[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -20,6 +20,7 @@ package session
 import (
 	"errors"
 	"testing"
+	"time"
 
 	"github.com/mysteriumnetwork/node/consumer"
 	"github.com/mysteriumnetwork/node/core/connection"
@@ -115,6 +116,99 @@ func TestSessionStorageConsumeEventConnectedError(t *testing.T) {
 	assert.True(t, storer.SaveCalled)
 }
 
+func TestSessionStorageConsumeEventConnectFailedOK(t *testing.T) {
+	storer := &StubSessionStorer{}
+
+	storage := NewSessionStorage(storer, stubRetriever)
+	storage.ConsumeSessionEvent(connection.SessionEvent{
+		Status:      connection.SessionConnectFailedStatus,
+		SessionInfo: mockPayload.SessionInfo,
+	})
+	assert.True(t, storer.SaveCalled)
+	failedConnect := storer.Saved.(*FailedConnect)
+	assert.Equal(t, providerID, failedConnect.ProviderID)
+	assert.Equal(t, serviceType, failedConnect.ServiceType)
+}
+
+func TestSessionStorageConsumeEventConnectFailedPrunesOldFailedConnects(t *testing.T) {
+	now := time.Now().UTC()
+	storer := &StubSessionStorer{
+		FailedConnects: []FailedConnect{
+			{ID: 1, ProviderID: providerID, Failed: now.Add(-FailedConnectsRetention - time.Hour)},
+			{ID: 2, ProviderID: providerID, Failed: now.Add(-time.Hour)},
+		},
+	}
+
+	storage := NewSessionStorage(storer, stubRetriever)
+	storage.ConsumeSessionEvent(connection.SessionEvent{
+		Status:      connection.SessionConnectFailedStatus,
+		SessionInfo: mockPayload.SessionInfo,
+	})
+	assert.Equal(t, []interface{}{&storer.FailedConnects[0]}, storer.Deleted)
+}
+
+func TestSessionStorageConsumeEventConnectFailedCapsFailedConnects(t *testing.T) {
+	now := time.Now().UTC()
+	storer := &StubSessionStorer{}
+	for i := 0; i < maxFailedConnects+2; i++ {
+		storer.FailedConnects = append(storer.FailedConnects, FailedConnect{ID: i + 1, ProviderID: providerID, Failed: now})
+	}
+
+	storage := NewSessionStorage(storer, stubRetriever)
+	storage.ConsumeSessionEvent(connection.SessionEvent{
+		Status:      connection.SessionConnectFailedStatus,
+		SessionInfo: mockPayload.SessionInfo,
+	})
+	assert.Equal(t, []interface{}{&storer.FailedConnects[0], &storer.FailedConnects[1]}, storer.Deleted)
+}
+
+func TestSessionStorageConsumePooledEventStoresSessionWithItsStatistics(t *testing.T) {
+	storer := &StubSessionStorer{}
+	storage := NewSessionStorage(storer, &StubRetriever{Value: consumer.SessionStatistics{BytesSent: 1}})
+	pooled := func(event interface{}) connection.PooledEvent {
+		return connection.PooledEvent{ConnectionID: connection.ID("1"), Event: event}
+	}
+
+	storage.ConsumePooledEvent(pooled(mockPayload))
+	assert.True(t, storer.SaveCalled)
+
+	storage.ConsumePooledEvent(pooled(consumer.SessionStatistics{BytesSent: 10, BytesReceived: 20}))
+	storage.ConsumePooledEvent(pooled(connection.SessionEvent{
+		Status:      connection.SessionEndedStatus,
+		SessionInfo: mockPayload.SessionInfo,
+	}))
+	assert.True(t, storer.UpdateCalled)
+	updated := storer.Updated.(*History)
+	assert.Equal(t, sessionID, updated.SessionID)
+	assert.Equal(t, consumer.SessionStatistics{BytesSent: 10, BytesReceived: 20}, updated.DataStats)
+}
+
+func TestSessionStorageConsumePooledEventStoresFailedConnect(t *testing.T) {
+	storer := &StubSessionStorer{}
+	storage := NewSessionStorage(storer, stubRetriever)
+
+	storage.ConsumePooledEvent(connection.PooledEvent{
+		ConnectionID: connection.ID("1"),
+		Event:        connection.SessionEvent{Status: connection.SessionConnectFailedStatus, SessionInfo: mockPayload.SessionInfo},
+	})
+	assert.Equal(t, providerID, storer.Saved.(*FailedConnect).ProviderID)
+}
+
+func TestSessionStorageGetFailedConnects(t *testing.T) {
+	now := time.Now()
+	storer := &StubSessionStorer{
+		FailedConnects: []FailedConnect{
+			{ID: 1, ProviderID: providerID, Failed: now.Add(-2 * time.Hour)},
+			{ID: 2, ProviderID: providerID, Failed: now},
+		},
+	}
+
+	storage := NewSessionStorage(storer, stubRetriever)
+	failedConnects, err := storage.GetFailedConnects(now.Add(-time.Hour))
+	assert.NoError(t, err)
+	assert.Equal(t, []FailedConnect{storer.FailedConnects[1]}, failedConnects)
+}
+
 // StubSessionStorer allows us to get all sessions, save and update them
 type StubSessionStorer struct {
 	SaveError    error
@@ -123,23 +217,38 @@ type StubSessionStorer struct {
 	UpdateCalled bool
 	GetAllCalled bool
 	GetAllError  error
+
+	Saved          interface{}
+	Updated        interface{}
+	FailedConnects []FailedConnect
+	Deleted        []interface{}
 }
 
 func (sss *StubSessionStorer) Store(from string, object interface{}) error {
 	sss.SaveCalled = true
+	sss.Saved = object
 	return sss.SaveError
 }
 
 func (sss *StubSessionStorer) Update(from string, object interface{}) error {
 	sss.UpdateCalled = true
+	sss.Updated = object
 	return sss.UpdateError
 }
 
 func (sss *StubSessionStorer) GetAllFrom(from string, array interface{}) error {
 	sss.GetAllCalled = true
+	if failedConnects, ok := array.(*[]FailedConnect); ok {
+		*failedConnects = sss.FailedConnects
+	}
 	return sss.GetAllError
 }
 
+func (sss *StubSessionStorer) Delete(from string, object interface{}) error {
+	sss.Deleted = append(sss.Deleted, object)
+	return nil
+}
+
 type StubRetriever struct {
 	Value consumer.SessionStatistics
 }
@@ -18,6 +18,7 @@
 package connection
 
 import (
+	"github.com/mysteriumnetwork/node/core/connection/split"
 	"github.com/mysteriumnetwork/node/identity"
 	"github.com/mysteriumnetwork/node/market"
 	"github.com/mysteriumnetwork/node/session"
@@ -27,6 +28,12 @@ import (
 type ConnectParams struct {
 	// kill switch option restricting communication only through VPN
 	DisableKillSwitch bool
+	// Reconnect describes whether and how a lost connection is reestablished
+	Reconnect ReconnectPolicy
+	// Split lists the destinations routed thru or around the tunnel, all the traffic is routed thru it if empty
+	Split split.Params
+	// DisableDNS leaves the system DNS settings to another connection, e.g. the primary one of the pool
+	DisableDNS bool
 }
 
 // ConnectOptions represents the params we need to ensure a successful connection
@@ -36,4 +43,8 @@ type ConnectOptions struct {
 	Proposal      market.ServiceProposal
 	SessionID     session.ID
 	SessionConfig []byte
+	// Routes is the route set the connection configures for the tunnel
+	Routes split.Routes
+	// DisableDNS makes the connection leave the system DNS settings alone
+	DisableDNS bool
 }
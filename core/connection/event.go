@@ -25,12 +25,16 @@ const (
 	StatisticsEventTopic = "Statistics"
 	// SessionEventTopic represents the session event
 	SessionEventTopic = "Session"
+	// PooledEventTopic represents the events of the additional connections of the pool
+	PooledEventTopic = "Pooled"
 )
 
 // StateEvent is the struct we'll emit on a StateEvent topic event
 type StateEvent struct {
 	State       State
 	SessionInfo SessionInfo
+	// ReconnectAttempt is set when the state is published by the manager while reestablishing a lost connection
+	ReconnectAttempt int
 }
 
 const (
@@ -38,6 +42,9 @@ const (
 	SessionCreatedStatus = "Created"
 	// SessionEndedStatus represents a session end
 	SessionEndedStatus = "Ended"
+	// SessionConnectFailedStatus represents a failed attempt to connect to the provider,
+	// the session ID is not set if the session was not created before the failure
+	SessionConnectFailedStatus = "ConnectFailed"
 )
 
 // SessionEvent represents a session related event
@@ -45,3 +52,11 @@ type SessionEvent struct {
 	Status      string
 	SessionInfo SessionInfo
 }
+
+// PooledEvent wraps the event published by an additional connection of the pool
+type PooledEvent struct {
+	ConnectionID ID
+	// Topic is the topic the event would be published on by the primary connection
+	Topic string
+	Event interface{}
+}
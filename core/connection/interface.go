@@ -20,6 +20,7 @@ package connection
 import (
 	"github.com/mysteriumnetwork/node/communication"
 	"github.com/mysteriumnetwork/node/consumer"
+	"github.com/mysteriumnetwork/node/firewall"
 	"github.com/mysteriumnetwork/node/identity"
 	"github.com/mysteriumnetwork/node/market"
 )
@@ -38,6 +39,12 @@ type Connection interface {
 	GetConfig() (ConsumerConfig, error)
 }
 
+// TunnelProvider is implemented by connections which are able to describe their VPN tunnel,
+// kill switch restricts the traffic to the described tunnel before the connection is started
+type TunnelProvider interface {
+	Tunnel(options ConnectOptions) (firewall.Tunnel, error)
+}
+
 // StateChannel is the channel we receive state change events on
 type StateChannel chan State
 
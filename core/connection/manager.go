@@ -20,18 +20,18 @@ package connection
 import (
 	"context"
 	"errors"
+	"net"
 	"sync"
 	"time"
 
 	log "github.com/cihub/seelog"
 	"github.com/mysteriumnetwork/node/communication"
 	"github.com/mysteriumnetwork/node/consumer"
+	"github.com/mysteriumnetwork/node/core/connection/split"
 	"github.com/mysteriumnetwork/node/core/ip"
 	"github.com/mysteriumnetwork/node/firewall"
 	"github.com/mysteriumnetwork/node/identity"
 	"github.com/mysteriumnetwork/node/market"
-	"github.com/mysteriumnetwork/node/money"
-	"github.com/mysteriumnetwork/node/services/openvpn/discovery/dto"
 	"github.com/mysteriumnetwork/node/session"
 	"github.com/mysteriumnetwork/node/session/balance"
 	"github.com/mysteriumnetwork/node/session/promise"
@@ -73,13 +73,15 @@ type PaymentIssuer interface {
 	Stop()
 }
 
-// PaymentIssuerFactory creates a new payment issuer from the given params
+// PaymentIssuerFactory creates a new payment issuer from the given params.
+// Data transfer provider gives the traffic of the session, measured by the consumer
 type PaymentIssuerFactory func(
 	initialState promise.PaymentInfo,
-	paymentDefinition dto.PaymentPerTime,
+	paymentMethod market.PaymentMethod,
 	messageChan chan balance.Message,
 	dialog communication.Dialog,
-	consumer, provider identity.Identity) (PaymentIssuer, error)
+	consumer, provider identity.Identity,
+	dataTransfer session.DataTransferProvider) (PaymentIssuer, error)
 
 type connectionManager struct {
 	//these are passed on creation
@@ -88,15 +90,24 @@ type connectionManager struct {
 	newConnection        Creator
 	eventPublisher       Publisher
 	resolver             ip.Resolver
+	proposalFinder       ProposalFinder
+	killSwitch           firewall.KillSwitch
+	lookupIP             func(host string) ([]net.IP, error)
 
 	//these are populated by Connect at runtime
 	ctx         context.Context
 	status      Status
 	statusLock  sync.RWMutex
 	sessionInfo SessionInfo
+	params      ConnectParams
+	routes      split.Routes
 	cleanup     []func() error
 	cancel      func()
 
+	statistics     consumer.SessionStatistics
+	sessionStart   time.Time
+	statisticsLock sync.Mutex
+
 	discoLock sync.Mutex
 }
 
@@ -107,6 +118,8 @@ func NewManager(
 	connectionCreator Creator,
 	eventPublisher Publisher,
 	resolver ip.Resolver,
+	proposalFinder ProposalFinder,
+	killSwitch firewall.KillSwitch,
 ) *connectionManager {
 	return &connectionManager{
 		newDialog:            dialogCreator,
@@ -116,6 +129,9 @@ func NewManager(
 		eventPublisher:       eventPublisher,
 		cleanup:              make([]func() error, 0),
 		resolver:             resolver,
+		proposalFinder:       proposalFinder,
+		killSwitch:           killSwitch,
+		lookupIP:             net.LookupIP,
 	}
 }
 
@@ -125,6 +141,7 @@ func (manager *connectionManager) Connect(consumerID identity.Identity, proposal
 	}
 
 	manager.ctx, manager.cancel = context.WithCancel(context.Background())
+	manager.params = params
 
 	manager.setStatus(statusConnecting())
 	defer func() {
@@ -133,6 +150,40 @@ func (manager *connectionManager) Connect(consumerID identity.Identity, proposal
 		}
 	}()
 
+	// domains are resolved once before the tunnel is up, reconnects reuse the same routes
+	routes, err := split.Resolve(params.Split, manager.lookupIP)
+	if err != nil {
+		return err
+	}
+	manager.setRoutes(routes)
+
+	err = manager.connect(consumerID, proposal, params)
+	if err != nil {
+		log.Info(managerLogPrefix, "Cancelling connection initiation: ", err)
+		logDisconnectError(manager.Disconnect())
+	}
+	if err == context.Canceled {
+		return ErrConnectionCancelled
+	}
+	if err != nil {
+		manager.publishConnectFailed(consumerID, proposal)
+	}
+	return err
+}
+
+func (manager *connectionManager) publishConnectFailed(consumerID identity.Identity, proposal market.ServiceProposal) {
+	manager.eventPublisher.Publish(SessionEventTopic, SessionEvent{
+		Status: SessionConnectFailedStatus,
+		SessionInfo: SessionInfo{
+			ConsumerID: consumerID,
+			Proposal:   proposal,
+		},
+	})
+}
+
+// connect runs all the steps needed to establish a connection to the given proposal.
+// On failure the caller is responsible for cleaning up what was already set up.
+func (manager *connectionManager) connect(consumerID identity.Identity, proposal market.ServiceProposal, params ConnectParams) error {
 	providerID := identity.FromAddress(proposal.ProviderID)
 
 	dialog, err := manager.createDialog(consumerID, providerID, proposal.ProviderContacts[0])
@@ -153,19 +204,16 @@ func (manager *connectionManager) Connect(consumerID identity.Identity, proposal
 		return err
 	}
 
-	err = manager.launchPayments(paymentInfo, dialog, consumerID, providerID)
+	manager.setStatistics(consumer.SessionStatistics{})
+	err = manager.launchPayments(paymentInfo, proposal.PaymentMethod, dialog, consumerID, providerID)
 	if err != nil {
 		return err
 	}
 
-	err = manager.startConnection(connection, consumerID, proposal, params, sessionDTO, stateChannel, statisticsChannel)
-	if err == context.Canceled {
-		return ErrConnectionCancelled
-	}
-	return err
+	return manager.startConnection(connection, consumerID, proposal, params, sessionDTO, stateChannel, statisticsChannel)
 }
 
-func (manager *connectionManager) launchPayments(paymentInfo *promise.PaymentInfo, dialog communication.Dialog, consumerID, providerID identity.Identity) error {
+func (manager *connectionManager) launchPayments(paymentInfo *promise.PaymentInfo, paymentMethod market.PaymentMethod, dialog communication.Dialog, consumerID, providerID identity.Identity) error {
 	var promiseState promise.PaymentInfo
 	if paymentInfo != nil {
 		promiseState.FreeCredit = paymentInfo.FreeCredit
@@ -174,16 +222,11 @@ func (manager *connectionManager) launchPayments(paymentInfo *promise.PaymentInf
 
 	messageChan := make(chan balance.Message, 1)
 
-	// TODO: set the time and proper payment info
-	payment := dto.PaymentPerTime{
-		Price: money.Money{
-			Currency: money.CurrencyMyst,
-			Amount:   uint64(0),
-		},
-		Duration: time.Minute,
+	if _, perGB := paymentMethod.(market.PaymentPerGB); !perGB {
+		paymentMethod = session.TimePayment(paymentMethod)
 	}
 
-	payments, err := manager.paymentIssuerFactory(promiseState, payment, messageChan, dialog, consumerID, providerID)
+	payments, err := manager.paymentIssuerFactory(promiseState, paymentMethod, messageChan, dialog, consumerID, providerID, manager.sessionDataTransfer)
 	if err != nil {
 		return err
 	}
@@ -199,6 +242,10 @@ func (manager *connectionManager) launchPayments(paymentInfo *promise.PaymentInf
 
 func (manager *connectionManager) cleanConnection() {
 	manager.cancel()
+	manager.runCleanup()
+}
+
+func (manager *connectionManager) runCleanup() {
 	for i := len(manager.cleanup) - 1; i >= 0; i-- {
 		err := manager.cleanup[i]()
 		if err != nil {
@@ -236,6 +283,7 @@ func (manager *connectionManager) createSession(c Connection, dialog communicati
 
 	manager.cleanup = append(manager.cleanup, func() error { return session.RequestSessionDestroy(dialog, s.ID) })
 
+	manager.setSessionStart(time.Now())
 	// set the session info for future use
 	manager.sessionInfo = SessionInfo{
 		SessionID:  s.ID,
@@ -266,14 +314,7 @@ func (manager *connectionManager) startConnection(
 	params ConnectParams,
 	sessionDTO session.SessionDto,
 	stateChannel chan State,
-	statisticsChannel chan consumer.SessionStatistics) (err error) {
-
-	defer func() {
-		if err != nil {
-			log.Info(managerLogPrefix, "Cancelling connection initiation: ", err)
-			logDisconnectError(manager.Disconnect())
-		}
-	}()
+	statisticsChannel chan consumer.SessionStatistics) error {
 
 	connectOptions := ConnectOptions{
 		SessionID:     sessionDTO.ID,
@@ -281,9 +322,15 @@ func (manager *connectionManager) startConnection(
 		ConsumerID:    consumerID,
 		ProviderID:    identity.FromAddress(proposal.ProviderID),
 		Proposal:      proposal,
+		Routes:        manager.currentRoutes(),
+		DisableDNS:    params.DisableDNS,
 	}
 
-	if err = connection.Start(connectOptions); err != nil {
+	if err := manager.enableKillSwitch(connection, connectOptions, params, false); err != nil {
+		return err
+	}
+
+	if err := connection.Start(connectOptions); err != nil {
 		return err
 	}
 	manager.cleanup = append(manager.cleanup, func() error {
@@ -291,29 +338,71 @@ func (manager *connectionManager) startConnection(
 		return nil
 	})
 
+	if err := manager.enableKillSwitch(connection, connectOptions, params, true); err != nil {
+		return err
+	}
+
 	//consume statistics right after start - openvpn3 will publish them even before connected state
 	go manager.consumeStats(statisticsChannel)
-	err = manager.waitForConnectedState(stateChannel, sessionDTO.ID)
+	err := manager.waitForConnectedState(stateChannel, sessionDTO.ID)
 	if err != nil {
 		return err
 	}
 
-	if !params.DisableKillSwitch {
-		// TODO: Implement fw based kill switch for respective OS
-		// we may need to wait for tun device setup to be finished
-		firewall.NewKillSwitch().Enable()
+	lost := manager.connectionLostHandler(manager.ctx)
+	go manager.consumeConnectionStates(stateChannel, lost)
+	go manager.connectionWaiter(connection, lost)
+	return nil
+}
+
+// enableKillSwitch restricts the traffic to the tunnel of the connection before it is started,
+// so only the transport of the tunnel being established passes it, including the one to another provider on reconnect.
+// Kill switch is not disabled on reconnect, so it keeps protecting the traffic until the tunnel is reestablished.
+// Tunnel interface is usually known only once the connection is started, it is let thru by enabling the kill switch again.
+func (manager *connectionManager) enableKillSwitch(connection Connection, options ConnectOptions, params ConnectParams, started bool) error {
+	if params.DisableKillSwitch {
+		return nil
 	}
 
-	go manager.consumeConnectionStates(stateChannel)
-	go manager.connectionWaiter(connection)
-	return nil
+	provider, ok := connection.(TunnelProvider)
+	if !ok {
+		log.Warn(managerLogPrefix, "Kill switch is not supported by the connection")
+		return nil
+	}
+
+	tunnel, err := provider.Tunnel(options)
+	if err != nil {
+		return err
+	}
+	if started && tunnel.Interface == "" {
+		return nil
+	}
+	tunnel.Include, tunnel.Exclude = options.Routes.Include, options.Routes.Exclude
+
+	err = manager.killSwitch.Enable(tunnel)
+	if err == firewall.ErrKillSwitchNotSupported {
+		log.Warn(managerLogPrefix, "Kill switch is not enabled: ", err)
+		return nil
+	}
+	return err
+}
+
+func (manager *connectionManager) disableKillSwitch() {
+	if err := manager.killSwitch.Disable(); err != nil {
+		log.Error(managerLogPrefix, "Failed to disable kill switch: ", err)
+	}
 }
 
 func (manager *connectionManager) Status() Status {
 	manager.statusLock.RLock()
 	defer manager.statusLock.RUnlock()
 
-	return manager.status
+	status := manager.status
+	status.KillSwitch = manager.killSwitch.Enabled()
+	if status.State != NotConnected {
+		status.Routes = manager.routes
+	}
+	return status
 }
 
 func (manager *connectionManager) setStatus(cs Status) {
@@ -322,7 +411,26 @@ func (manager *connectionManager) setStatus(cs Status) {
 	manager.statusLock.Unlock()
 }
 
+func (manager *connectionManager) setRoutes(routes split.Routes) {
+	manager.statusLock.Lock()
+	manager.routes = routes
+	manager.statusLock.Unlock()
+}
+
+func (manager *connectionManager) currentRoutes() split.Routes {
+	manager.statusLock.RLock()
+	defer manager.statusLock.RUnlock()
+
+	return manager.routes
+}
+
 func (manager *connectionManager) Disconnect() error {
+	if manager.Status().State == NotConnected {
+		return ErrNoConnection
+	}
+
+	// cancel before taking the lock, so that a reconnect in progress stops retrying and releases it
+	manager.cancel()
 	manager.discoLock.Lock()
 	defer manager.discoLock.Unlock()
 
@@ -332,6 +440,8 @@ func (manager *connectionManager) Disconnect() error {
 
 	manager.setStatus(statusDisconnecting())
 	manager.cleanConnection()
+	manager.setSessionStart(time.Time{})
+	manager.disableKillSwitch()
 	manager.setStatus(statusNotConnected())
 
 	manager.eventPublisher.Publish(StateEventTopic, StateEvent{
@@ -356,7 +466,7 @@ func warnOnClean() {
 	log.Warn(managerLogPrefix, "Trying to close when there is nothing to close. Possible bug or race condition")
 }
 
-func (manager *connectionManager) connectionWaiter(connection Connection) {
+func (manager *connectionManager) connectionWaiter(connection Connection, onLost func()) {
 	err := connection.Wait()
 	if err != nil {
 		log.Warn(managerLogPrefix, "Connection exited with error: ", err)
@@ -364,7 +474,7 @@ func (manager *connectionManager) connectionWaiter(connection Connection) {
 		log.Info(managerLogPrefix, "Connection exited")
 	}
 
-	logDisconnectError(manager.Disconnect())
+	onLost()
 }
 
 func (manager *connectionManager) waitForConnectedState(stateChannel <-chan State, sessionID session.ID) error {
@@ -388,21 +498,55 @@ func (manager *connectionManager) waitForConnectedState(stateChannel <-chan Stat
 	}
 }
 
-func (manager *connectionManager) consumeConnectionStates(stateChannel <-chan State) {
+func (manager *connectionManager) consumeConnectionStates(stateChannel <-chan State, onLost func()) {
 	for state := range stateChannel {
 		manager.onStateChanged(state)
 	}
 
 	log.Debug(managerLogPrefix, "State updater stopCalled")
-	logDisconnectError(manager.Disconnect())
+	onLost()
 }
 
 func (manager *connectionManager) consumeStats(statisticsChannel <-chan consumer.SessionStatistics) {
 	for stats := range statisticsChannel {
+		manager.setStatistics(stats)
 		manager.eventPublisher.Publish(StatisticsEventTopic, stats)
 	}
 }
 
+func (manager *connectionManager) setStatistics(stats consumer.SessionStatistics) {
+	manager.statisticsLock.Lock()
+	defer manager.statisticsLock.Unlock()
+	manager.statistics = stats
+}
+
+func (manager *connectionManager) setSessionStart(start time.Time) {
+	manager.statisticsLock.Lock()
+	defer manager.statisticsLock.Unlock()
+	manager.sessionStart = start
+}
+
+// Statistics returns the traffic of the current session and the time passed since the session was created
+func (manager *connectionManager) Statistics() (consumer.SessionStatistics, time.Duration) {
+	manager.statisticsLock.Lock()
+	defer manager.statisticsLock.Unlock()
+
+	if manager.sessionStart.IsZero() {
+		return manager.statistics, 0
+	}
+	return manager.statistics, time.Since(manager.sessionStart)
+}
+
+// sessionDataTransfer returns the traffic of the current session, measured by the consumer
+func (manager *connectionManager) sessionDataTransfer() (session.DataTransfer, error) {
+	manager.statisticsLock.Lock()
+	defer manager.statisticsLock.Unlock()
+	return session.DataTransfer{
+		BytesSent:     manager.statistics.BytesSent,
+		BytesReceived: manager.statistics.BytesReceived,
+	}, nil
+}
+
 func (manager *connectionManager) onStateChanged(state State) {
 	manager.eventPublisher.Publish(StateEventTopic, StateEvent{
 		State:       state,
@@ -422,3 +566,123 @@ func logDisconnectError(err error) {
 		log.Error(managerLogPrefix, "Disconnect error", err)
 	}
 }
+
+// connectionLostHandler returns a function which handles the loss of a single established connection.
+// Both the state consumer and the connection waiter report the loss, so reconnect is started only once.
+func (manager *connectionManager) connectionLostHandler(ctx context.Context) func() {
+	var once sync.Once
+	reconnect := manager.params.Reconnect.Enabled()
+	return func() {
+		if ctx.Err() != nil || !reconnect {
+			logDisconnectError(manager.Disconnect())
+			return
+		}
+		once.Do(manager.reconnect)
+	}
+}
+
+// reconnect reestablishes the lost connection, holding the disconnect lock while touching the connection state.
+// It stops retrying once a disconnect is requested.
+func (manager *connectionManager) reconnect() {
+	manager.discoLock.Lock()
+	if manager.ctx.Err() != nil {
+		manager.discoLock.Unlock()
+		return
+	}
+	policy := manager.params.Reconnect
+	sessionInfo := manager.sessionInfo
+	original := sessionInfo.Proposal
+
+	log.Info(managerLogPrefix, "Connection lost, reconnecting to: ", original.ProviderID)
+	manager.runCleanup()
+	manager.discoLock.Unlock()
+
+	for attempt := 1; attempt <= policy.MaxAttempts; attempt++ {
+		if !manager.startReconnectAttempt(attempt, sessionInfo) {
+			return
+		}
+
+		select {
+		case <-time.After(policy.delay(attempt)):
+		case <-manager.ctx.Done():
+			return
+		}
+
+		for _, proposal := range manager.reconnectCandidates(original, policy) {
+			if manager.reconnectTo(sessionInfo.ConsumerID, proposal, attempt) {
+				return
+			}
+		}
+	}
+
+	log.Warn(managerLogPrefix, "Giving up reconnecting after ", policy.MaxAttempts, " attempts")
+	logDisconnectError(manager.Disconnect())
+}
+
+// startReconnectAttempt reports the given reconnect attempt, unless a disconnect was requested meanwhile
+func (manager *connectionManager) startReconnectAttempt(attempt int, sessionInfo SessionInfo) bool {
+	manager.discoLock.Lock()
+	defer manager.discoLock.Unlock()
+
+	if manager.ctx.Err() != nil {
+		return false
+	}
+
+	manager.setStatus(statusReconnectAttempt(attempt, sessionInfo.Proposal))
+	manager.eventPublisher.Publish(StateEventTopic, StateEvent{
+		State:            Reconnecting,
+		SessionInfo:      sessionInfo,
+		ReconnectAttempt: attempt,
+	})
+	return true
+}
+
+// reconnectTo makes a single attempt to connect to the given proposal.
+// It returns true when reconnecting is over, either connected or cancelled by a disconnect.
+func (manager *connectionManager) reconnectTo(consumerID identity.Identity, proposal market.ServiceProposal, attempt int) bool {
+	manager.discoLock.Lock()
+	defer manager.discoLock.Unlock()
+
+	if manager.ctx.Err() != nil {
+		return true
+	}
+
+	err := manager.connect(consumerID, proposal, manager.params)
+	if err == nil {
+		log.Info(managerLogPrefix, "Reconnected to: ", proposal.ProviderID)
+		return true
+	}
+
+	log.Warn(managerLogPrefix, "Reconnect attempt ", attempt, " to ", proposal.ProviderID, " failed: ", err)
+	manager.runCleanup()
+	if manager.ctx.Err() != nil {
+		return true
+	}
+	manager.publishConnectFailed(consumerID, proposal)
+	return false
+}
+
+// reconnectCandidates lists proposals to try on a reconnect attempt, starting with the original one
+func (manager *connectionManager) reconnectCandidates(original market.ServiceProposal, policy ReconnectPolicy) []market.ServiceProposal {
+	candidates := []market.ServiceProposal{original}
+	if !policy.Failover || manager.proposalFinder == nil {
+		return candidates
+	}
+
+	filter := policy.ProposalFilter
+	if filter.ServiceType == "" {
+		filter.ServiceType = original.ServiceType
+	}
+	proposals, err := manager.proposalFinder.FindProposals(filter)
+	if err != nil {
+		log.Warn(managerLogPrefix, "Failed to find proposals to fail over to: ", err)
+		return candidates
+	}
+
+	for _, proposal := range proposals {
+		if proposal.ProviderID != original.ProviderID {
+			candidates = append(candidates, proposal)
+		}
+	}
+	return candidates
+}
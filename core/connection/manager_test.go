@@ -19,15 +19,19 @@ package connection
 
 import (
 	"errors"
+	"net"
 	"sync"
 	"testing"
 	"time"
 
 	"github.com/mysteriumnetwork/node/communication"
 	"github.com/mysteriumnetwork/node/consumer"
+	"github.com/mysteriumnetwork/node/core/connection/split"
 	"github.com/mysteriumnetwork/node/core/ip"
+	"github.com/mysteriumnetwork/node/firewall"
 	"github.com/mysteriumnetwork/node/identity"
 	"github.com/mysteriumnetwork/node/market"
+	"github.com/mysteriumnetwork/node/money"
 	"github.com/mysteriumnetwork/node/services/openvpn/discovery/dto"
 	"github.com/mysteriumnetwork/node/session"
 	"github.com/mysteriumnetwork/node/session/balance"
@@ -45,6 +49,10 @@ type testContext struct {
 	stubPublisher         *StubPublisher
 	mockStatistics        consumer.SessionStatistics
 	fakeResolver          ip.Resolver
+	mockProposalFinder    *mockProposalFinder
+	fakeKillSwitch        *killSwitchFake
+	unreachableProviderID identity.Identity
+	dialogsRequested      int
 	sync.RWMutex
 }
 
@@ -68,9 +76,14 @@ func (tc *testContext) SetupTest() {
 	defer tc.Unlock()
 
 	tc.stubPublisher = NewStubPublisher()
+	tc.unreachableProviderID = identity.Identity{}
 	dialogCreator := func(consumer, provider identity.Identity, contact market.Contact) (communication.Dialog, error) {
 		tc.Lock()
 		defer tc.Unlock()
+		tc.dialogsRequested++
+		if provider == tc.unreachableProviderID {
+			return nil, errors.New("provider unreachable")
+		}
 		tc.mockDialog = &mockDialog{
 			sessionID:   establishedSessionID,
 			paymentInfo: paymentInfo,
@@ -79,14 +92,16 @@ func (tc *testContext) SetupTest() {
 	}
 
 	mockPaymentFactory := func(initialState promise.PaymentInfo,
-		paymentDefinition dto.PaymentPerTime,
+		paymentMethod market.PaymentMethod,
 		messageChan chan balance.Message,
 		dialog communication.Dialog,
-		consumer, provider identity.Identity) (PaymentIssuer, error) {
+		consumer, provider identity.Identity,
+		dataTransfer session.DataTransferProvider) (PaymentIssuer, error) {
 		tc.MockPaymentIssuer = &MockPaymentIssuer{
-			initialState:      initialState,
-			paymentDefinition: paymentDefinition,
-			stopChan:          make(chan struct{}),
+			initialState:  initialState,
+			paymentMethod: paymentMethod,
+			dataTransfer:  dataTransfer,
+			stopChan:      make(chan struct{}),
 		}
 		return tc.MockPaymentIssuer, nil
 	}
@@ -120,12 +135,16 @@ func (tc *testContext) SetupTest() {
 		},
 	}
 
+	tc.mockProposalFinder = &mockProposalFinder{}
+	tc.fakeKillSwitch = &killSwitchFake{}
 	tc.connManager = NewManager(
 		dialogCreator,
 		mockPaymentFactory,
 		tc.fakeConnectionFactory.CreateConnection,
 		tc.stubPublisher,
 		ip.NewResolverMock("1.1.1.1"),
+		tc.mockProposalFinder,
+		tc.fakeKillSwitch,
 	)
 }
 
@@ -288,6 +307,27 @@ func (tc *testContext) Test_PaymentManager_OnConnectErrorIsStopped() {
 	assert.True(tc.T(), tc.MockPaymentIssuer.StopCalled())
 }
 
+func (tc *testContext) Test_ConnectFailedPublished_OnConnectError() {
+	tc.stubPublisher.Clear()
+
+	tc.fakeConnectionFactory.mockConnection.onStartReturnError = errors.New("fatal connection error")
+	err := tc.connManager.Connect(consumerID, activeProposal, ConnectParams{})
+	assert.Error(tc.T(), err)
+
+	failed := 0
+	for _, v := range tc.stubPublisher.GetEventHistory() {
+		if v.calledWithTopic == SessionEventTopic {
+			event := v.calledWithData.(SessionEvent)
+			if event.Status == SessionConnectFailedStatus {
+				failed++
+				assert.Equal(tc.T(), consumerID, event.SessionInfo.ConsumerID)
+				assert.Equal(tc.T(), activeProposal.ProviderID, event.SessionInfo.Proposal.ProviderID)
+			}
+		}
+	}
+	assert.Equal(tc.T(), 1, failed)
+}
+
 func (tc *testContext) Test_SessionEndPublished_OnConnectError() {
 	tc.stubPublisher.Clear()
 
@@ -333,6 +373,37 @@ func (tc *testContext) Test_ManagerSetsPaymentInfo() {
 	assert.Exactly(tc.T(), *paymentInfo, tc.MockPaymentIssuer.initialState)
 }
 
+func (tc *testContext) Test_ManagerUsesZeroPricePerTimeByDefault() {
+	err := tc.connManager.Connect(consumerID, activeProposal, ConnectParams{})
+	assert.NoError(tc.T(), err)
+	assert.IsType(tc.T(), dto.PaymentPerTime{}, tc.MockPaymentIssuer.paymentMethod)
+}
+
+func (tc *testContext) Test_ManagerPaysPerTimeWithProposedPrice() {
+	proposal := activeProposal
+	proposal.PaymentMethodType = dto.PaymentMethodPerTime
+	proposal.PaymentMethod = dto.PaymentPerTime{Price: money.NewMoney(1, money.CurrencyMyst), Duration: time.Hour}
+
+	err := tc.connManager.Connect(consumerID, proposal, ConnectParams{})
+	assert.NoError(tc.T(), err)
+	assert.Exactly(tc.T(), proposal.PaymentMethod, tc.MockPaymentIssuer.paymentMethod)
+}
+
+func (tc *testContext) Test_ManagerPaysPerGBWithConsumerStatistics() {
+	proposal := activeProposal
+	proposal.PaymentMethodType = market.PaymentMethodPerGB
+	proposal.PaymentMethod = market.PaymentPerGB{Price: money.NewMoney(1, money.CurrencyMyst)}
+
+	err := tc.connManager.Connect(consumerID, proposal, ConnectParams{})
+	assert.NoError(tc.T(), err)
+	assert.Exactly(tc.T(), proposal.PaymentMethod, tc.MockPaymentIssuer.paymentMethod)
+
+	waitABit()
+	dataTransfer, err := tc.MockPaymentIssuer.dataTransfer()
+	assert.NoError(tc.T(), err)
+	assert.Equal(tc.T(), session.DataTransfer{BytesSent: 20, BytesReceived: 10}, dataTransfer)
+}
+
 func (tc *testContext) Test_ManagerPublishesEvents() {
 	tc.stubPublisher.Clear()
 
@@ -373,6 +444,231 @@ func (tc *testContext) Test_ManagerPublishesEvents() {
 	}
 }
 
+func (tc *testContext) TestLostConnectionIsNotReestablishedWithoutReconnectPolicy() {
+	tc.fakeConnectionFactory.mockConnection.onStopReportStates = []fakeState{}
+	assert.NoError(tc.T(), tc.connManager.Connect(consumerID, activeProposal, ConnectParams{}))
+
+	tc.fakeConnectionFactory.mockConnection.reportState(processExited)
+	waitABit()
+
+	assert.Equal(tc.T(), statusNotConnected(), tc.connManager.Status())
+}
+
+func (tc *testContext) TestLostConnectionIsReestablishedToSameProvider() {
+	tc.fakeConnectionFactory.mockConnection.onStopReportStates = []fakeState{}
+	params := ConnectParams{Reconnect: ReconnectPolicy{MaxAttempts: 3}}
+	assert.NoError(tc.T(), tc.connManager.Connect(consumerID, activeProposal, params))
+	tc.stubPublisher.Clear()
+
+	tc.fakeConnectionFactory.mockConnection.reportState(processExited)
+	waitABit()
+
+	assert.Equal(tc.T(), statusConnected(establishedSessionID, activeProposal), tc.connManager.Status())
+	assert.False(tc.T(), tc.mockProposalFinder.findCalled)
+
+	reconnecting := 0
+	for _, v := range tc.stubPublisher.GetEventHistory() {
+		if v.calledWithTopic == StateEventTopic {
+			event := v.calledWithData.(StateEvent)
+			if event.State == Reconnecting {
+				reconnecting++
+				assert.Equal(tc.T(), 1, event.ReconnectAttempt)
+			}
+		}
+	}
+	assert.Equal(tc.T(), 1, reconnecting)
+}
+
+func (tc *testContext) TestLostConnectionFailsOverToOtherProvider() {
+	tc.fakeConnectionFactory.mockConnection.onStopReportStates = []fakeState{}
+	backupProposal := activeProposal
+	backupProposal.ProviderID = "fake-node-2"
+	tc.mockProposalFinder.proposals = []market.ServiceProposal{activeProposal, backupProposal}
+
+	params := ConnectParams{Reconnect: ReconnectPolicy{MaxAttempts: 1, Failover: true}}
+	assert.NoError(tc.T(), tc.connManager.Connect(consumerID, activeProposal, params))
+
+	tc.Lock()
+	tc.unreachableProviderID = activeProviderID
+	tc.Unlock()
+	tc.fakeConnectionFactory.mockConnection.reportState(processExited)
+	waitABit()
+
+	assert.Equal(tc.T(), statusConnected(establishedSessionID, backupProposal), tc.connManager.Status())
+	assert.Equal(tc.T(), activeServiceType, tc.mockProposalFinder.requestedFilter.ServiceType)
+}
+
+func (tc *testContext) TestReconnectGivesUpAfterMaxAttempts() {
+	tc.fakeConnectionFactory.mockConnection.onStopReportStates = []fakeState{}
+	params := ConnectParams{Reconnect: ReconnectPolicy{MaxAttempts: 2}}
+	assert.NoError(tc.T(), tc.connManager.Connect(consumerID, activeProposal, params))
+
+	tc.Lock()
+	tc.unreachableProviderID = activeProviderID
+	tc.Unlock()
+	tc.fakeConnectionFactory.mockConnection.reportState(processExited)
+	waitABit()
+
+	assert.Equal(tc.T(), statusNotConnected(), tc.connManager.Status())
+}
+
+func (tc *testContext) TestReconnectCanBeCancelledByDisconnect() {
+	tc.fakeConnectionFactory.mockConnection.onStopReportStates = []fakeState{}
+	params := ConnectParams{Reconnect: ReconnectPolicy{MaxAttempts: 3, Backoff: time.Hour}}
+	assert.NoError(tc.T(), tc.connManager.Connect(consumerID, activeProposal, params))
+
+	tc.fakeConnectionFactory.mockConnection.reportState(processExited)
+	waitABit()
+	assert.Equal(tc.T(), statusReconnectAttempt(1, activeProposal), tc.connManager.Status())
+
+	assert.NoError(tc.T(), tc.connManager.Disconnect())
+	waitABit()
+	assert.Equal(tc.T(), statusNotConnected(), tc.connManager.Status())
+}
+
+func (tc *testContext) TestReconnectStopsRetryingAfterDisconnect() {
+	tc.fakeConnectionFactory.mockConnection.onStopReportStates = []fakeState{}
+	params := ConnectParams{Reconnect: ReconnectPolicy{MaxAttempts: 1000, Backoff: time.Millisecond}}
+	assert.NoError(tc.T(), tc.connManager.Connect(consumerID, activeProposal, params))
+
+	tc.Lock()
+	tc.unreachableProviderID = activeProviderID
+	tc.Unlock()
+	tc.fakeConnectionFactory.mockConnection.reportState(processExited)
+	waitABit()
+
+	assert.NoError(tc.T(), tc.connManager.Disconnect())
+	tc.RLock()
+	requested := tc.dialogsRequested
+	tc.RUnlock()
+
+	waitABit()
+	waitABit()
+	assert.Equal(tc.T(), statusNotConnected(), tc.connManager.Status())
+	tc.RLock()
+	assert.Equal(tc.T(), requested, tc.dialogsRequested)
+	tc.RUnlock()
+}
+
+func (tc *testContext) TestKillSwitchIsEnabledForTunnelUntilDisconnect() {
+	tunnel := firewall.Tunnel{Endpoint: net.ParseIP("1.2.3.4"), Port: 1194, Protocol: "udp"}
+	tc.fakeConnectionFactory.mockTunnel = &tunnel
+
+	assert.NoError(tc.T(), tc.connManager.Connect(consumerID, activeProposal, ConnectParams{}))
+	assert.True(tc.T(), tc.connManager.Status().KillSwitch)
+	assert.Equal(tc.T(), tunnel, tc.fakeKillSwitch.tunnel)
+
+	assert.NoError(tc.T(), tc.connManager.Disconnect())
+	assert.Equal(tc.T(), statusNotConnected(), tc.connManager.Status())
+}
+
+func (tc *testContext) TestKillSwitchIsNotEnabledWhenDisabledByParams() {
+	tc.fakeConnectionFactory.mockTunnel = &firewall.Tunnel{}
+
+	assert.NoError(tc.T(), tc.connManager.Connect(consumerID, activeProposal, ConnectParams{DisableKillSwitch: true}))
+	assert.False(tc.T(), tc.connManager.Status().KillSwitch)
+}
+
+func (tc *testContext) TestKillSwitchStaysEnabledWhileReconnecting() {
+	tc.fakeConnectionFactory.mockTunnel = &firewall.Tunnel{}
+	tc.fakeConnectionFactory.mockConnection.onStopReportStates = []fakeState{}
+	params := ConnectParams{Reconnect: ReconnectPolicy{MaxAttempts: 3, Backoff: time.Hour}}
+	assert.NoError(tc.T(), tc.connManager.Connect(consumerID, activeProposal, params))
+
+	tc.fakeConnectionFactory.mockConnection.reportState(processExited)
+	waitABit()
+
+	status := tc.connManager.Status()
+	assert.Equal(tc.T(), Reconnecting, status.State)
+	assert.True(tc.T(), status.KillSwitch)
+
+	assert.NoError(tc.T(), tc.connManager.Disconnect())
+	assert.False(tc.T(), tc.connManager.Status().KillSwitch)
+}
+
+func (tc *testContext) TestKillSwitchIsEnabledBeforeConnectionIsStarted() {
+	tc.fakeConnectionFactory.mockTunnel = &firewall.Tunnel{}
+	tc.fakeConnectionFactory.mockConnection.onStartReturnError = errors.New("handshake failed")
+
+	assert.Error(tc.T(), tc.connManager.Connect(consumerID, activeProposal, ConnectParams{}))
+	assert.Equal(tc.T(), 1, tc.fakeKillSwitch.Enables())
+	assert.False(tc.T(), tc.connManager.Status().KillSwitch)
+}
+
+func (tc *testContext) TestKillSwitchLetsTunnelInterfaceThruOnceConnectionIsStarted() {
+	tc.fakeConnectionFactory.mockTunnel = &firewall.Tunnel{Endpoint: net.ParseIP("1.2.3.4"), Port: 1194, Protocol: "udp"}
+	tc.fakeConnectionFactory.mockStartedInterface = "myst-tun0"
+
+	assert.NoError(tc.T(), tc.connManager.Connect(consumerID, activeProposal, ConnectParams{}))
+	assert.Equal(tc.T(), 2, tc.fakeKillSwitch.Enables())
+	assert.Equal(tc.T(), "myst-tun0", tc.fakeKillSwitch.tunnel.Interface)
+	assert.Equal(tc.T(), "1.2.3.4", tc.fakeKillSwitch.tunnel.Endpoint.String())
+
+	assert.NoError(tc.T(), tc.connManager.Disconnect())
+}
+
+func (tc *testContext) TestKillSwitchIsReappliedOnFailoverToOtherProvider() {
+	tc.fakeConnectionFactory.mockTunnel = &firewall.Tunnel{}
+	tc.fakeConnectionFactory.mockConnection.onStopReportStates = []fakeState{}
+	backupProposal := activeProposal
+	backupProposal.ProviderID = "fake-node-2"
+	tc.mockProposalFinder.proposals = []market.ServiceProposal{activeProposal, backupProposal}
+
+	params := ConnectParams{Reconnect: ReconnectPolicy{MaxAttempts: 1, Failover: true}}
+	assert.NoError(tc.T(), tc.connManager.Connect(consumerID, activeProposal, params))
+	assert.Equal(tc.T(), 1, tc.fakeKillSwitch.Enables())
+
+	tc.Lock()
+	tc.unreachableProviderID = activeProviderID
+	tc.Unlock()
+	tc.fakeConnectionFactory.mockConnection.reportState(processExited)
+	waitABit()
+
+	status := tc.connManager.Status()
+	assert.Equal(tc.T(), Connected, status.State)
+	assert.Equal(tc.T(), backupProposal.ProviderID, status.Proposal.ProviderID)
+	assert.True(tc.T(), status.KillSwitch)
+	assert.Equal(tc.T(), 2, tc.fakeKillSwitch.Enables())
+}
+
+func (tc *testContext) TestSplitRoutesAreResolvedAndReportedInStatus() {
+	tunnel := firewall.Tunnel{}
+	tc.fakeConnectionFactory.mockTunnel = &tunnel
+	tc.connManager.lookupIP = func(host string) ([]net.IP, error) {
+		return []net.IP{net.ParseIP("93.184.216.34"), net.ParseIP("2606:2800:220:1::1")}, nil
+	}
+
+	params := ConnectParams{Split: split.Params{Include: []string{"10.8.0.0/16"}, Exclude: []string{"example.com"}}}
+	assert.NoError(tc.T(), tc.connManager.Connect(consumerID, activeProposal, params))
+
+	_, included, _ := net.ParseCIDR("10.8.0.0/16")
+	_, excluded, _ := net.ParseCIDR("93.184.216.34/32")
+	expected := split.Routes{Include: []net.IPNet{*included}, Exclude: []net.IPNet{*excluded}}
+	assert.Equal(tc.T(), expected, tc.connManager.Status().Routes)
+	assert.Equal(tc.T(), expected.Include, tc.fakeKillSwitch.tunnel.Include)
+	assert.Equal(tc.T(), expected.Exclude, tc.fakeKillSwitch.tunnel.Exclude)
+
+	assert.NoError(tc.T(), tc.connManager.Disconnect())
+	assert.Equal(tc.T(), split.Routes{}, tc.connManager.Status().Routes)
+}
+
+func (tc *testContext) TestConnectFailsWhenSplitDestinationCanNotBeResolved() {
+	tc.connManager.lookupIP = func(host string) ([]net.IP, error) {
+		return nil, errors.New("no such host")
+	}
+
+	params := ConnectParams{Split: split.Params{Exclude: []string{"example.com"}}}
+	assert.Error(tc.T(), tc.connManager.Connect(consumerID, activeProposal, params))
+	assert.Equal(tc.T(), statusNotConnected(), tc.connManager.Status())
+}
+
+func TestReconnectPolicyDelayIsDoubledUpToLimit(t *testing.T) {
+	policy := ReconnectPolicy{MaxAttempts: 20, Backoff: time.Second}
+	assert.Equal(t, time.Second, policy.delay(1))
+	assert.Equal(t, 4*time.Second, policy.delay(3))
+	assert.Equal(t, maxReconnectBackoff, policy.delay(20))
+}
+
 func TestConnectionManagerSuite(t *testing.T) {
 	suite.Run(t, new(testContext))
 }
@@ -388,12 +684,13 @@ type fakeServiceDefinition struct{}
 func (fs *fakeServiceDefinition) GetLocation() market.Location { return market.Location{} }
 
 type MockPaymentIssuer struct {
-	initialState      promise.PaymentInfo
-	paymentDefinition dto.PaymentPerTime
-	startCalled       bool
-	stopCalled        bool
-	MockError         error
-	stopChan          chan struct{}
+	initialState  promise.PaymentInfo
+	paymentMethod market.PaymentMethod
+	dataTransfer  session.DataTransferProvider
+	startCalled   bool
+	stopCalled    bool
+	MockError     error
+	stopChan      chan struct{}
 	sync.Mutex
 }
 
@@ -0,0 +1,265 @@
+/*
+ * Copyright (C) 2019 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package connection
+
+import (
+	"errors"
+	"strconv"
+	"sync"
+	"time"
+
+	log "github.com/cihub/seelog"
+	"github.com/mysteriumnetwork/node/consumer"
+	"github.com/mysteriumnetwork/node/identity"
+	"github.com/mysteriumnetwork/node/market"
+	"github.com/mysteriumnetwork/node/utils"
+)
+
+const poolLogPrefix = "[connection-pool] "
+
+// PrimaryID identifies the primary connection of the pool
+const PrimaryID = ID("primary")
+
+var (
+	// ErrConnectionNotFound indicates that pool holds no connection with the given ID
+	ErrConnectionNotFound = errors.New("connection not found")
+	// ErrFullTunnelExists indicates that all the traffic is already routed thru the tunnel of another connection,
+	// only the connections routing the included networks can be established next to it
+	ErrFullTunnelExists = errors.New("all traffic is already routed thru another connection")
+)
+
+// ID identifies the connection held by the pool
+type ID string
+
+// PoolManager manages a single connection of the pool
+type PoolManager interface {
+	Manager
+	// Statistics returns the traffic of the current session and the time passed since the session was created
+	Statistics() (consumer.SessionStatistics, time.Duration)
+}
+
+// ManagerFactory creates the manager of the additional connection with the given ID
+type ManagerFactory func(id ID) PoolManager
+
+type poolEntry struct {
+	manager PoolManager
+	full    bool
+	// started is set once the connect attempt is over, the entry is dropped when the manager disconnects after it
+	started bool
+}
+
+// Pool holds the primary connection and the additional ones established next to it.
+// Pool manages the primary connection as a Manager, so it is a drop-in replacement of the single connection manager.
+type Pool struct {
+	primary     PoolManager
+	primaryFull bool
+	newManager  ManagerFactory
+
+	entries map[ID]*poolEntry
+	order   []ID
+	lastID  int
+	lock    sync.Mutex
+}
+
+// NewPool creates the pool of the given primary connection manager, additional managers are created with the factory
+func NewPool(primary PoolManager, newManager ManagerFactory) *Pool {
+	return &Pool{
+		primary:    primary,
+		newManager: newManager,
+		entries:    make(map[ID]*poolEntry),
+	}
+}
+
+// Connect establishes the primary connection
+func (pool *Pool) Connect(consumerID identity.Identity, proposal market.ServiceProposal, params ConnectParams) error {
+	pool.lock.Lock()
+	if err := pool.checkFullTunnel(PrimaryID, params); err != nil {
+		pool.lock.Unlock()
+		return err
+	}
+	pool.primaryFull = fullTunnel(params)
+	pool.lock.Unlock()
+
+	return pool.primary.Connect(consumerID, proposal, params)
+}
+
+// Status returns the status of the primary connection
+func (pool *Pool) Status() Status {
+	return pool.primary.Status()
+}
+
+// Disconnect closes the primary connection
+func (pool *Pool) Disconnect() error {
+	return pool.primary.Disconnect()
+}
+
+// Add establishes an additional connection and returns its ID once it is connected.
+// System DNS settings are left to the primary connection.
+func (pool *Pool) Add(consumerID identity.Identity, proposal market.ServiceProposal, params ConnectParams) (ID, error) {
+	params.DisableDNS = true
+
+	pool.lock.Lock()
+	if err := pool.checkFullTunnel("", params); err != nil {
+		pool.lock.Unlock()
+		return "", err
+	}
+	pool.lastID++
+	id := ID(strconv.Itoa(pool.lastID))
+	entry := &poolEntry{manager: pool.newManager(id), full: fullTunnel(params)}
+	pool.entries[id] = entry
+	pool.order = append(pool.order, id)
+	pool.lock.Unlock()
+
+	err := entry.manager.Connect(consumerID, proposal, params)
+
+	pool.lock.Lock()
+	defer pool.lock.Unlock()
+
+	entry.started = true
+	if err != nil {
+		pool.remove(id)
+		return "", err
+	}
+	log.Info(poolLogPrefix, "Additional connection ", id, " established to: ", proposal.ProviderID)
+	return id, nil
+}
+
+// Get returns the manager of the connection with the given ID
+func (pool *Pool) Get(id ID) (PoolManager, error) {
+	if id == PrimaryID {
+		return pool.primary, nil
+	}
+
+	pool.lock.Lock()
+	defer pool.lock.Unlock()
+
+	pool.prune()
+	entry, exists := pool.entries[id]
+	if !exists {
+		return nil, ErrConnectionNotFound
+	}
+	return entry.manager, nil
+}
+
+// Remove closes the connection with the given ID, the one being established is cancelled
+func (pool *Pool) Remove(id ID) error {
+	manager, err := pool.Get(id)
+	if err != nil {
+		return err
+	}
+	if err := manager.Disconnect(); err != nil {
+		return err
+	}
+
+	if id != PrimaryID {
+		pool.lock.Lock()
+		pool.remove(id)
+		pool.lock.Unlock()
+	}
+	return nil
+}
+
+// Connections returns the IDs of the held connections, the primary one goes first if it is not disconnected
+func (pool *Pool) Connections() []ID {
+	pool.lock.Lock()
+	defer pool.lock.Unlock()
+
+	pool.prune()
+	ids := make([]ID, 0, len(pool.order)+1)
+	if pool.primary.Status().State != NotConnected {
+		ids = append(ids, PrimaryID)
+	}
+	return append(ids, pool.order...)
+}
+
+// Close disconnects the additional connections, the primary one is left to Disconnect
+func (pool *Pool) Close() error {
+	pool.lock.Lock()
+	ids := append([]ID(nil), pool.order...)
+	pool.lock.Unlock()
+
+	errs := utils.ErrorCollection{}
+	for _, id := range ids {
+		if err := pool.Remove(id); err != nil && err != ErrNoConnection && err != ErrConnectionNotFound {
+			errs.Add(err)
+		}
+	}
+	return errs.Errorf("failed to close connections: %v", ", ")
+}
+
+// checkFullTunnel does not let two connections to route all the traffic, their default routes would override each other
+func (pool *Pool) checkFullTunnel(except ID, params ConnectParams) error {
+	if !fullTunnel(params) {
+		return nil
+	}
+
+	pool.prune()
+	if except != PrimaryID && pool.primaryFull && pool.primary.Status().State != NotConnected {
+		return ErrFullTunnelExists
+	}
+	for id, entry := range pool.entries {
+		if id != except && entry.full {
+			return ErrFullTunnelExists
+		}
+	}
+	return nil
+}
+
+// prune drops the additional connections which were lost and not reestablished
+func (pool *Pool) prune() {
+	for id, entry := range pool.entries {
+		if entry.started && entry.manager.Status().State == NotConnected {
+			pool.remove(id)
+		}
+	}
+}
+
+func (pool *Pool) remove(id ID) {
+	delete(pool.entries, id)
+	for i := range pool.order {
+		if pool.order[i] == id {
+			pool.order = append(pool.order[:i], pool.order[i+1:]...)
+			return
+		}
+	}
+}
+
+func fullTunnel(params ConnectParams) bool {
+	return len(params.Split.Include) == 0
+}
+
+// PooledPublisher publishes the events of an additional connection tagged with its ID,
+// so that the subscribers of the connection events keep following the primary connection only
+type PooledPublisher struct {
+	publisher Publisher
+	id        ID
+}
+
+// NewPooledPublisher creates the publisher of the events of the additional connection with the given ID
+func NewPooledPublisher(publisher Publisher, id ID) *PooledPublisher {
+	return &PooledPublisher{publisher: publisher, id: id}
+}
+
+// Publish publishes the event on the PooledEventTopic
+func (p *PooledPublisher) Publish(topic string, data interface{}) {
+	p.publisher.Publish(PooledEventTopic, PooledEvent{
+		ConnectionID: p.id,
+		Topic:        topic,
+		Event:        data,
+	})
+}
@@ -0,0 +1,130 @@
+/*
+ * Copyright (C) 2019 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package connection
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/mysteriumnetwork/node/firewall"
+)
+
+// PoolKillSwitch shares the kill switch of the host between the connections of the pool.
+// Kill switch is enabled by the primary connection only, once enabled it lets the traffic thru the tunnels of all the pooled connections.
+type PoolKillSwitch struct {
+	killSwitch firewall.KillSwitch
+	tunnels    map[ID][]firewall.Tunnel
+	enabled    bool
+	lock       sync.Mutex
+}
+
+// NewPoolKillSwitch creates the kill switch shared by the connections of the pool
+func NewPoolKillSwitch(killSwitch firewall.KillSwitch) *PoolKillSwitch {
+	return &PoolKillSwitch{
+		killSwitch: killSwitch,
+		tunnels:    make(map[ID][]firewall.Tunnel),
+	}
+}
+
+// Connection returns the kill switch used by the connection with the given ID
+func (ks *PoolKillSwitch) Connection(id ID) firewall.KillSwitch {
+	return &pooledKillSwitch{pool: ks, id: id}
+}
+
+func (ks *PoolKillSwitch) enable(id ID, tunnels []firewall.Tunnel) error {
+	ks.lock.Lock()
+	defer ks.lock.Unlock()
+
+	ks.tunnels[id] = tunnels
+	if id == PrimaryID {
+		ks.enabled = true
+	}
+	if !ks.enabled {
+		return nil
+	}
+
+	err := ks.killSwitch.Enable(ks.allTunnels()...)
+	if err == firewall.ErrKillSwitchNotSupported && id == PrimaryID {
+		ks.enabled = false
+	}
+	return err
+}
+
+func (ks *PoolKillSwitch) disable(id ID) error {
+	ks.lock.Lock()
+	defer ks.lock.Unlock()
+
+	delete(ks.tunnels, id)
+	if id == PrimaryID {
+		ks.enabled = false
+		return ks.killSwitch.Disable()
+	}
+	if !ks.enabled {
+		return nil
+	}
+	return ks.killSwitch.Enable(ks.allTunnels()...)
+}
+
+func (ks *PoolKillSwitch) isEnabled(id ID) bool {
+	ks.lock.Lock()
+	defer ks.lock.Unlock()
+
+	if id == PrimaryID {
+		return ks.killSwitch.Enabled()
+	}
+	_, whitelisted := ks.tunnels[id]
+	return ks.enabled && whitelisted
+}
+
+// allTunnels lists the tunnels of the primary connection first, so DNS queries are redirected thru it
+func (ks *PoolKillSwitch) allTunnels() []firewall.Tunnel {
+	ids := make([]string, 0, len(ks.tunnels))
+	for id := range ks.tunnels {
+		if id != PrimaryID {
+			ids = append(ids, string(id))
+		}
+	}
+	sort.Strings(ids)
+
+	tunnels := append([]firewall.Tunnel(nil), ks.tunnels[PrimaryID]...)
+	for _, id := range ids {
+		tunnels = append(tunnels, ks.tunnels[ID(id)]...)
+	}
+	return tunnels
+}
+
+// pooledKillSwitch is the view of the pool kill switch for a single connection
+type pooledKillSwitch struct {
+	pool *PoolKillSwitch
+	id   ID
+}
+
+// Enable restricts the traffic to the tunnels of the pool, the additional connections only whitelist their tunnels
+func (ks *pooledKillSwitch) Enable(tunnels ...firewall.Tunnel) error {
+	return ks.pool.enable(ks.id, tunnels)
+}
+
+// Disable removes the tunnels of the connection, the kill switch is disabled together with the primary connection
+func (ks *pooledKillSwitch) Disable() error {
+	return ks.pool.disable(ks.id)
+}
+
+// Enabled tells if the kill switch lets the traffic thru the tunnel of the connection only
+func (ks *pooledKillSwitch) Enabled() bool {
+	return ks.pool.isEnabled(ks.id)
+}
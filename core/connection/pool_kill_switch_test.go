@@ -0,0 +1,65 @@
+/*
+ * Copyright (C) 2019 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package connection
+
+import (
+	"net"
+	"testing"
+
+	"github.com/mysteriumnetwork/node/firewall"
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	primaryTunnel = firewall.Tunnel{Interface: "myst+", Endpoint: net.ParseIP("1.2.3.4"), Port: 52820, Protocol: "udp"}
+	pooledTunnel  = firewall.Tunnel{Interface: "tun+", Endpoint: net.ParseIP("5.6.7.8"), Port: 1194, Protocol: "udp"}
+)
+
+func TestPoolKillSwitch_AdditionalTunnelsAreWhitelistedOnceEnabledByPrimary(t *testing.T) {
+	killSwitch := &killSwitchFake{}
+	pool := NewPoolKillSwitch(killSwitch)
+
+	assert.NoError(t, pool.Connection(ID("1")).Enable(pooledTunnel))
+	assert.False(t, killSwitch.Enabled())
+	assert.False(t, pool.Connection(ID("1")).Enabled())
+
+	assert.NoError(t, pool.Connection(PrimaryID).Enable(primaryTunnel))
+	assert.True(t, pool.Connection(PrimaryID).Enabled())
+	assert.True(t, pool.Connection(ID("1")).Enabled())
+	assert.Equal(t, []firewall.Tunnel{primaryTunnel, pooledTunnel}, killSwitch.tunnels)
+
+	assert.NoError(t, pool.Connection(ID("1")).Disable())
+	assert.True(t, killSwitch.Enabled())
+	assert.Equal(t, []firewall.Tunnel{primaryTunnel}, killSwitch.tunnels)
+}
+
+func TestPoolKillSwitch_DisabledWithPrimary(t *testing.T) {
+	killSwitch := &killSwitchFake{}
+	pool := NewPoolKillSwitch(killSwitch)
+
+	assert.NoError(t, pool.Connection(PrimaryID).Enable(primaryTunnel))
+	assert.NoError(t, pool.Connection(ID("1")).Enable(pooledTunnel))
+	assert.NoError(t, pool.Connection(PrimaryID).Disable())
+
+	assert.False(t, killSwitch.Enabled())
+	assert.False(t, pool.Connection(ID("1")).Enabled())
+
+	enables := killSwitch.Enables()
+	assert.NoError(t, pool.Connection(ID("1")).Disable())
+	assert.Equal(t, enables, killSwitch.Enables())
+}
@@ -0,0 +1,208 @@
+/*
+ * Copyright (C) 2019 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package connection
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mysteriumnetwork/node/consumer"
+	"github.com/mysteriumnetwork/node/core/connection/split"
+	"github.com/mysteriumnetwork/node/identity"
+	"github.com/mysteriumnetwork/node/market"
+	"github.com/stretchr/testify/assert"
+)
+
+var splitParams = ConnectParams{Split: split.Params{Include: []string{"10.8.0.0/16"}}}
+
+type poolManagerFake struct {
+	connectError error
+	params       ConnectParams
+	state        State
+	sync.Mutex
+}
+
+func (m *poolManagerFake) Connect(_ identity.Identity, _ market.ServiceProposal, params ConnectParams) error {
+	m.Lock()
+	defer m.Unlock()
+
+	if m.connectError != nil {
+		return m.connectError
+	}
+	m.params = params
+	m.state = Connected
+	return nil
+}
+
+func (m *poolManagerFake) Status() Status {
+	m.Lock()
+	defer m.Unlock()
+
+	if m.state == "" {
+		return statusNotConnected()
+	}
+	return Status{State: m.state}
+}
+
+func (m *poolManagerFake) Disconnect() error {
+	m.Lock()
+	defer m.Unlock()
+
+	if m.state == "" || m.state == NotConnected {
+		return ErrNoConnection
+	}
+	m.state = NotConnected
+	return nil
+}
+
+func (m *poolManagerFake) Statistics() (consumer.SessionStatistics, time.Duration) {
+	return consumer.SessionStatistics{BytesSent: 1, BytesReceived: 2}, time.Minute
+}
+
+type poolManagerFactoryFake struct {
+	connectError error
+	created      []*poolManagerFake
+}
+
+func (f *poolManagerFactoryFake) create(_ ID) PoolManager {
+	manager := &poolManagerFake{connectError: f.connectError}
+	f.created = append(f.created, manager)
+	return manager
+}
+
+func TestPool_ManagesPrimaryConnection(t *testing.T) {
+	primary := &poolManagerFake{}
+	pool := NewPool(primary, (&poolManagerFactoryFake{}).create)
+
+	assert.NoError(t, pool.Connect(consumerID, activeProposal, ConnectParams{}))
+	assert.Equal(t, Connected, pool.Status().State)
+	assert.Equal(t, []ID{PrimaryID}, pool.Connections())
+
+	manager, err := pool.Get(PrimaryID)
+	assert.NoError(t, err)
+	assert.Equal(t, primary, manager)
+
+	assert.NoError(t, pool.Disconnect())
+	assert.Equal(t, NotConnected, pool.Status().State)
+	assert.Empty(t, pool.Connections())
+}
+
+func TestPool_AddEstablishesAdditionalConnections(t *testing.T) {
+	factory := &poolManagerFactoryFake{}
+	pool := NewPool(&poolManagerFake{}, factory.create)
+	assert.NoError(t, pool.Connect(consumerID, activeProposal, ConnectParams{}))
+
+	first, err := pool.Add(consumerID, activeProposal, splitParams)
+	assert.NoError(t, err)
+	second, err := pool.Add(consumerID, activeProposal, splitParams)
+	assert.NoError(t, err)
+
+	assert.Equal(t, ID("1"), first)
+	assert.Equal(t, ID("2"), second)
+	assert.Equal(t, []ID{PrimaryID, first, second}, pool.Connections())
+	assert.True(t, factory.created[0].params.DisableDNS)
+	assert.False(t, factory.created[0].params.DisableKillSwitch)
+
+	manager, err := pool.Get(second)
+	assert.NoError(t, err)
+	assert.Equal(t, factory.created[1], manager)
+}
+
+func TestPool_AddDropsFailedConnection(t *testing.T) {
+	factory := &poolManagerFactoryFake{connectError: errors.New("connect failed")}
+	pool := NewPool(&poolManagerFake{}, factory.create)
+
+	_, err := pool.Add(consumerID, activeProposal, splitParams)
+	assert.EqualError(t, err, "connect failed")
+	assert.Empty(t, pool.Connections())
+}
+
+func TestPool_OnlyOneConnectionRoutesAllTraffic(t *testing.T) {
+	pool := NewPool(&poolManagerFake{}, (&poolManagerFactoryFake{}).create)
+	assert.NoError(t, pool.Connect(consumerID, activeProposal, ConnectParams{}))
+
+	_, err := pool.Add(consumerID, activeProposal, ConnectParams{})
+	assert.Equal(t, ErrFullTunnelExists, err)
+
+	assert.NoError(t, pool.Disconnect())
+	_, err = pool.Add(consumerID, activeProposal, ConnectParams{})
+	assert.NoError(t, err)
+	assert.Equal(t, ErrFullTunnelExists, pool.Connect(consumerID, activeProposal, ConnectParams{}))
+	assert.NoError(t, pool.Connect(consumerID, activeProposal, splitParams))
+}
+
+func TestPool_RemoveDisconnectsConnection(t *testing.T) {
+	factory := &poolManagerFactoryFake{}
+	pool := NewPool(&poolManagerFake{}, factory.create)
+	id, err := pool.Add(consumerID, activeProposal, splitParams)
+	assert.NoError(t, err)
+
+	assert.NoError(t, pool.Remove(id))
+	assert.Equal(t, NotConnected, factory.created[0].Status().State)
+	assert.Empty(t, pool.Connections())
+
+	_, err = pool.Get(id)
+	assert.Equal(t, ErrConnectionNotFound, err)
+	assert.Equal(t, ErrConnectionNotFound, pool.Remove(id))
+}
+
+func TestPool_LostConnectionIsDropped(t *testing.T) {
+	factory := &poolManagerFactoryFake{}
+	pool := NewPool(&poolManagerFake{}, factory.create)
+	id, err := pool.Add(consumerID, activeProposal, splitParams)
+	assert.NoError(t, err)
+
+	assert.NoError(t, factory.created[0].Disconnect())
+
+	assert.Empty(t, pool.Connections())
+	_, err = pool.Get(id)
+	assert.Equal(t, ErrConnectionNotFound, err)
+}
+
+func TestPool_CloseDisconnectsAdditionalConnections(t *testing.T) {
+	factory := &poolManagerFactoryFake{}
+	primary := &poolManagerFake{}
+	pool := NewPool(primary, factory.create)
+	assert.NoError(t, pool.Connect(consumerID, activeProposal, ConnectParams{}))
+	_, err := pool.Add(consumerID, activeProposal, splitParams)
+	assert.NoError(t, err)
+	_, err = pool.Add(consumerID, activeProposal, splitParams)
+	assert.NoError(t, err)
+
+	assert.NoError(t, pool.Close())
+	assert.Equal(t, []ID{PrimaryID}, pool.Connections())
+	assert.Equal(t, Connected, primary.Status().State)
+}
+
+func TestPooledPublisher_TagsEventsWithConnectionID(t *testing.T) {
+	publisher := NewStubPublisher()
+	pooled := NewPooledPublisher(publisher, ID("1"))
+
+	pooled.Publish(StateEventTopic, StateEvent{State: Connected})
+
+	history := publisher.GetEventHistory()
+	assert.Len(t, history, 1)
+	assert.Equal(t, PooledEventTopic, history[0].calledWithTopic)
+	assert.Equal(
+		t,
+		PooledEvent{ConnectionID: ID("1"), Topic: StateEventTopic, Event: StateEvent{State: Connected}},
+		history[0].calledWithData,
+	)
+}
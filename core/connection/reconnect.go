@@ -0,0 +1,61 @@
+/*
+ * Copyright (C) 2019 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package connection
+
+import (
+	"time"
+
+	"github.com/mysteriumnetwork/node/market"
+)
+
+// maxReconnectBackoff limits the delay between two reconnect attempts
+const maxReconnectBackoff = 5 * time.Minute
+
+// ProposalFinder looks up proposals to fail over to when the original provider is unreachable
+type ProposalFinder interface {
+	FindProposals(filter market.ProposalFilter) ([]market.ServiceProposal, error)
+}
+
+// ReconnectPolicy describes how the manager reacts when an established connection is lost
+type ReconnectPolicy struct {
+	// MaxAttempts is the number of reconnect attempts, zero disables reconnecting
+	MaxAttempts int
+	// Backoff is the delay before the first attempt, it is doubled on every subsequent attempt
+	Backoff time.Duration
+	// Failover allows to reconnect to other providers matching the ProposalFilter
+	Failover bool
+	// ProposalFilter is used to find other proposals when failing over
+	ProposalFilter market.ProposalFilter
+}
+
+// Enabled tells if the connection should be reestablished once lost
+func (policy ReconnectPolicy) Enabled() bool {
+	return policy.MaxAttempts > 0
+}
+
+// delay returns the time to wait before the given reconnect attempt
+func (policy ReconnectPolicy) delay(attempt int) time.Duration {
+	delay := policy.Backoff
+	for i := 1; i < attempt && delay < maxReconnectBackoff; i++ {
+		delay *= 2
+	}
+	if delay > maxReconnectBackoff {
+		return maxReconnectBackoff
+	}
+	return delay
+}
@@ -0,0 +1,126 @@
+/*
+ * Copyright (C) 2019 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package split
+
+import (
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/mysteriumnetwork/node/netutil"
+)
+
+// Params are the destinations routed thru or around the tunnel, each one is a CIDR, an IP or a domain
+type Params struct {
+	Include []string
+	Exclude []string
+}
+
+// Routes is the effective route set of the tunnel
+type Routes struct {
+	// Include are the networks routed thru the tunnel, all the traffic is routed thru it if empty
+	Include []net.IPNet
+	// Exclude are the IPv4 networks routed around the tunnel, the most specific route wins if they overlap with the included ones
+	Exclude []net.IPNet
+}
+
+// Full tells if all the traffic except the excluded networks is routed thru the tunnel
+func (routes Routes) Full() bool {
+	return len(routes.Include) == 0
+}
+
+// Validate checks that destinations are CIDRs, IPs or domains, IPv6 networks can not be excluded
+func (params Params) Validate() error {
+	for _, destination := range params.Include {
+		if _, err := parseDestination(destination); err != nil {
+			return err
+		}
+	}
+	for _, destination := range params.Exclude {
+		network, err := parseDestination(destination)
+		if err != nil {
+			return err
+		}
+		if network != nil && network.IP.To4() == nil {
+			return fmt.Errorf("IPv6 destination can not be excluded: %s", destination)
+		}
+	}
+	return nil
+}
+
+// Resolve turns the destinations into networks, domains are resolved with the given lookup
+// and only their IPv4 addresses are excluded
+func Resolve(params Params, lookupIP func(host string) ([]net.IP, error)) (Routes, error) {
+	if err := params.Validate(); err != nil {
+		return Routes{}, err
+	}
+
+	include, err := resolve(params.Include, lookupIP, false)
+	if err != nil {
+		return Routes{}, err
+	}
+	exclude, err := resolve(params.Exclude, lookupIP, true)
+	if err != nil {
+		return Routes{}, err
+	}
+	return Routes{Include: include, Exclude: exclude}, nil
+}
+
+func resolve(destinations []string, lookupIP func(host string) ([]net.IP, error), ipv4Only bool) ([]net.IPNet, error) {
+	var networks []net.IPNet
+	for _, destination := range destinations {
+		destination = strings.TrimSpace(destination)
+		network, _ := parseDestination(destination)
+		if network != nil {
+			networks = append(networks, *network)
+			continue
+		}
+
+		ips, err := lookupIP(destination)
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve %s: %v", destination, err)
+		}
+		for _, ip := range ips {
+			if ipv4Only && ip.To4() == nil {
+				continue
+			}
+			networks = append(networks, netutil.HostNetwork(ip))
+		}
+	}
+	return networks, nil
+}
+
+// parseDestination returns the network of CIDR or IP destination, nil is returned for a domain
+func parseDestination(destination string) (*net.IPNet, error) {
+	destination = strings.TrimSpace(destination)
+	if strings.Contains(destination, "/") {
+		_, network, err := net.ParseCIDR(destination)
+		if err != nil {
+			return nil, fmt.Errorf("invalid network: %s", destination)
+		}
+		return network, nil
+	}
+	if ip := net.ParseIP(destination); ip != nil {
+		network := netutil.HostNetwork(ip)
+		return &network, nil
+	}
+	if netutil.IsDomain(destination) {
+		return nil, nil
+	}
+	return nil, fmt.Errorf("invalid destination: %s", destination)
+}
@@ -0,0 +1,84 @@
+/*
+ * Copyright (C) 2019 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package split
+
+import (
+	"errors"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func lookupStub(host string) ([]net.IP, error) {
+	if host == "example.com" {
+		return []net.IP{net.ParseIP("93.184.216.34"), net.ParseIP("2606:2800:220:1::1")}, nil
+	}
+	return nil, errors.New("no such host")
+}
+
+func network(cidr string) net.IPNet {
+	_, network, _ := net.ParseCIDR(cidr)
+	return *network
+}
+
+func Test_Resolve(t *testing.T) {
+	routes, err := Resolve(
+		Params{
+			Include: []string{"10.0.0.0/8", " 1.1.1.1", "example.com", "fd00::/64"},
+			Exclude: []string{"10.1.0.0/16", "example.com"},
+		},
+		lookupStub,
+	)
+
+	assert.NoError(t, err)
+	assert.Equal(
+		t,
+		[]net.IPNet{
+			network("10.0.0.0/8"),
+			network("1.1.1.1/32"),
+			network("93.184.216.34/32"),
+			network("2606:2800:220:1::1/128"),
+			network("fd00::/64"),
+		},
+		routes.Include,
+	)
+	assert.Equal(t, []net.IPNet{network("10.1.0.0/16"), network("93.184.216.34/32")}, routes.Exclude)
+	assert.False(t, routes.Full())
+}
+
+func Test_Resolve_WithoutDestinationsRoutesEverything(t *testing.T) {
+	routes, err := Resolve(Params{}, lookupStub)
+
+	assert.NoError(t, err)
+	assert.True(t, routes.Full())
+	assert.Empty(t, routes.Exclude)
+}
+
+func Test_Resolve_FailsIfDomainIsNotResolved(t *testing.T) {
+	_, err := Resolve(Params{Include: []string{"unknown.example.org"}}, lookupStub)
+	assert.EqualError(t, err, "failed to resolve unknown.example.org: no such host")
+}
+
+func Test_Params_Validate(t *testing.T) {
+	assert.NoError(t, Params{Include: []string{"10.0.0.0/8", "::1", "example.com"}, Exclude: []string{"192.168.0.1"}}.Validate())
+
+	assert.EqualError(t, Params{Include: []string{"10.0.0.0/33"}}.Validate(), "invalid network: 10.0.0.0/33")
+	assert.EqualError(t, Params{Include: []string{"not a host"}}.Validate(), "invalid destination: not a host")
+	assert.EqualError(t, Params{Exclude: []string{"fd00::/64"}}.Validate(), "IPv6 destination can not be excluded: fd00::/64")
+}
@@ -18,6 +18,7 @@
 package connection
 
 import (
+	"github.com/mysteriumnetwork/node/core/connection/split"
 	"github.com/mysteriumnetwork/node/market"
 	"github.com/mysteriumnetwork/node/session"
 )
@@ -45,6 +46,12 @@ type Status struct {
 	State     State
 	SessionID session.ID
 	Proposal  market.ServiceProposal
+	// ReconnectAttempt is the number of the ongoing reconnect attempt, zero if manager is not reconnecting
+	ReconnectAttempt int
+	// KillSwitch tells if the kill switch restricts the traffic to the VPN tunnel
+	KillSwitch bool
+	// Routes is the effective route set of the tunnel
+	Routes split.Routes
 }
 
 func statusConnecting() Status {
@@ -52,7 +59,7 @@ func statusConnecting() Status {
 }
 
 func statusConnected(sessionID session.ID, proposal market.ServiceProposal) Status {
-	return Status{Connected, sessionID, proposal}
+	return Status{State: Connected, SessionID: sessionID, Proposal: proposal}
 }
 
 func statusNotConnected() Status {
@@ -63,6 +70,10 @@ func statusReconnecting() Status {
 	return Status{State: Reconnecting}
 }
 
+func statusReconnectAttempt(attempt int, proposal market.ServiceProposal) Status {
+	return Status{State: Reconnecting, Proposal: proposal, ReconnectAttempt: attempt}
+}
+
 func statusDisconnecting() Status {
 	return Status{State: Disconnecting}
 }
@@ -24,7 +24,9 @@ import (
 	log "github.com/cihub/seelog"
 	"github.com/mysteriumnetwork/node/communication"
 	"github.com/mysteriumnetwork/node/consumer"
+	"github.com/mysteriumnetwork/node/firewall"
 	"github.com/mysteriumnetwork/node/identity"
+	"github.com/mysteriumnetwork/node/market"
 	"github.com/mysteriumnetwork/node/session"
 	"github.com/mysteriumnetwork/node/session/promise"
 )
@@ -98,6 +100,18 @@ func (sss *StubSessionStorer) GetAll(array interface{}) error {
 	return sss.GetAllError
 }
 
+type mockProposalFinder struct {
+	proposals       []market.ServiceProposal
+	requestedFilter market.ProposalFilter
+	findCalled      bool
+}
+
+func (mpf *mockProposalFinder) FindProposals(filter market.ProposalFilter) ([]market.ServiceProposal, error) {
+	mpf.findCalled = true
+	mpf.requestedFilter = filter
+	return mpf.proposals, nil
+}
+
 type fakeState string
 
 const (
@@ -116,6 +130,10 @@ const (
 type connectionFactoryFake struct {
 	mockError      error
 	mockConnection *connectionMock
+	// mockTunnel makes created connections describe their tunnel for the kill switch
+	mockTunnel *firewall.Tunnel
+	// mockStartedInterface is the tunnel interface described once the connection is started
+	mockStartedInterface string
 }
 
 func (cff *connectionFactoryFake) CreateConnection(serviceType string, stateChannel StateChannel, statisticsChannel StatisticsChannel) (Connection, error) {
@@ -153,9 +171,72 @@ func (cff *connectionFactoryFake) CreateConnection(serviceType string, stateChan
 		stopBlock:           cff.mockConnection.stopBlock,
 	}
 
+	if cff.mockTunnel != nil {
+		return &tunnelConnectionMock{connectionMock: &copy, tunnel: *cff.mockTunnel, startedInterface: cff.mockStartedInterface}, nil
+	}
 	return &copy, nil
 }
 
+type tunnelConnectionMock struct {
+	*connectionMock
+	tunnel           firewall.Tunnel
+	startedInterface string
+	started          bool
+}
+
+func (foc *tunnelConnectionMock) Start(options ConnectOptions) error {
+	if err := foc.connectionMock.Start(options); err != nil {
+		return err
+	}
+	foc.started = true
+	return nil
+}
+
+func (foc *tunnelConnectionMock) Tunnel(_ ConnectOptions) (firewall.Tunnel, error) {
+	tunnel := foc.tunnel
+	if foc.started {
+		tunnel.Interface = foc.startedInterface
+	}
+	return tunnel, nil
+}
+
+type killSwitchFake struct {
+	enabled bool
+	enables int
+	tunnel  firewall.Tunnel
+	tunnels []firewall.Tunnel
+	sync.Mutex
+}
+
+func (ks *killSwitchFake) Enable(tunnels ...firewall.Tunnel) error {
+	ks.Lock()
+	defer ks.Unlock()
+	ks.enabled = true
+	ks.enables++
+	ks.tunnel = tunnels[0]
+	ks.tunnels = tunnels
+	return nil
+}
+
+func (ks *killSwitchFake) Enables() int {
+	ks.Lock()
+	defer ks.Unlock()
+	return ks.enables
+}
+
+func (ks *killSwitchFake) Disable() error {
+	ks.Lock()
+	defer ks.Unlock()
+	ks.enabled = false
+	return nil
+}
+
+func (ks *killSwitchFake) Enabled() bool {
+	ks.Lock()
+	defer ks.Unlock()
+	return ks.enabled
+}
+
 type connectionMock struct {
 	onStartReturnError  error
 	onStartReportStates []fakeState
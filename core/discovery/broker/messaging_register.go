@@ -18,16 +18,20 @@
 package broker
 
 import (
+	"encoding/json"
 	"fmt"
 	"reflect"
 
 	"github.com/mysteriumnetwork/node/communication"
-	"github.com/mysteriumnetwork/node/market"
 )
 
 // registerMessage structure represents message that the Provider sends about newly announced Proposal
+// Proposal is kept serialized as it was signed, so that the signature can be verified by the receiver
 type registerMessage struct {
-	Proposal market.ServiceProposal `json:"proposal"`
+	Proposal json.RawMessage `json:"proposal"`
+	// SignedAt is the unix time in nanoseconds the message was signed at, receivers reject the stale messages
+	SignedAt  int64  `json:"signed_at"`
+	Signature string `json:"signature"`
 }
 
 const registerEndpoint = communication.MessageEndpoint("proposal-register")
@@ -51,12 +55,12 @@ func (pmc *registerConsumer) NewMessage() (messagePtr interface{}) {
 
 // Consume handles messages from endpoint
 func (pmc *registerConsumer) Consume(messagePtr interface{}) error {
-	msg, ok := messagePtr.(registerMessage)
+	msg, ok := messagePtr.(*registerMessage)
 	if !ok {
-		return fmt.Errorf("consume received message of type %q, expected registerMessage instead", reflect.TypeOf(messagePtr))
+		return fmt.Errorf("consume received message of type %q, expected *registerMessage instead", reflect.TypeOf(messagePtr))
 	}
 
-	pmc.queue <- msg
+	pmc.queue <- *msg
 	return nil
 }
 
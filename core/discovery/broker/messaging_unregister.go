@@ -18,16 +18,20 @@
 package broker
 
 import (
+	"encoding/json"
 	"fmt"
 	"reflect"
 
 	"github.com/mysteriumnetwork/node/communication"
-	"github.com/mysteriumnetwork/node/market"
 )
 
 // unregisterMessage structure represents message that the Provider sends about de-announced Proposal
+// Proposal is kept serialized as it was signed, so that the signature can be verified by the receiver
 type unregisterMessage struct {
-	Proposal market.ServiceProposal `json:"proposal"`
+	Proposal json.RawMessage `json:"proposal"`
+	// SignedAt is the unix time in nanoseconds the message was signed at, receivers reject the stale messages
+	SignedAt  int64  `json:"signed_at"`
+	Signature string `json:"signature"`
 }
 
 const unregisterEndpoint = communication.MessageEndpoint("proposal-unregister")
@@ -51,12 +55,12 @@ func (pmc *unregisterConsumer) NewMessage() (messagePtr interface{}) {
 
 // Consume handles messages from endpoint
 func (pmc *unregisterConsumer) Consume(messagePtr interface{}) error {
-	msg, ok := messagePtr.(unregisterMessage)
+	msg, ok := messagePtr.(*unregisterMessage)
 	if !ok {
-		return fmt.Errorf("consume received message of type %q, expected unregisterMessage instead", reflect.TypeOf(messagePtr))
+		return fmt.Errorf("consume received message of type %q, expected *unregisterMessage instead", reflect.TypeOf(messagePtr))
 	}
 
-	pmc.queue <- msg
+	pmc.queue <- *msg
 	return nil
 }
 
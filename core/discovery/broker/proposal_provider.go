@@ -0,0 +1,279 @@
+/*
+ * Copyright (C) 2019 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package broker
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	log "github.com/cihub/seelog"
+	"github.com/mysteriumnetwork/node/communication"
+	"github.com/mysteriumnetwork/node/identity"
+	"github.com/mysteriumnetwork/node/market"
+)
+
+const proposalProviderLogPrefix = "[discovery-broker] "
+
+// messageMaxAge is the time the proposal messages are accepted for, it tolerates the clock skew between the peers too
+const messageMaxAge = 2 * time.Minute
+
+// ErrProposalNotFound is returned when the proposal was not announced or it has already expired
+var ErrProposalNotFound = errors.New("proposal not found")
+
+type proposalRecord struct {
+	proposal  market.ServiceProposal
+	expiresAt time.Time
+}
+
+// ProposalProvider keeps an index of proposals announced by providers thru the broker.
+// Proposals are kept only while providers keep pinging them, and announcements with invalid signatures are ignored.
+type ProposalProvider struct {
+	receiver        communication.Receiver
+	proposalTTL     time.Duration
+	verifierFactory func(id identity.Identity) identity.Verifier
+	timeGetter      func() time.Time
+
+	registerQueue   chan registerMessage
+	unregisterQueue chan unregisterMessage
+
+	proposals map[market.ProposalID]proposalRecord
+	// lastSignedAt keeps the time the last accepted message of each proposal was signed at, the older ones are replays
+	lastSignedAt map[market.ProposalID]int64
+	lock         sync.RWMutex
+	stop         chan struct{}
+	once         sync.Once
+}
+
+// NewProposalProvider creates a provider of proposals, which are dropped if they are not pinged during the given TTL
+func NewProposalProvider(receiver communication.Receiver, proposalTTL time.Duration) *ProposalProvider {
+	return &ProposalProvider{
+		receiver:    receiver,
+		proposalTTL: proposalTTL,
+		verifierFactory: func(id identity.Identity) identity.Verifier {
+			return identity.NewVerifierIdentity(id)
+		},
+		timeGetter:      time.Now,
+		registerQueue:   make(chan registerMessage),
+		unregisterQueue: make(chan unregisterMessage),
+		proposals:       make(map[market.ProposalID]proposalRecord),
+		lastSignedAt:    make(map[market.ProposalID]int64),
+		stop:            make(chan struct{}),
+	}
+}
+
+// Start subscribes to the proposal announcements and starts indexing them
+func (pp *ProposalProvider) Start() error {
+	if err := pp.receiver.Receive(&registerConsumer{queue: pp.registerQueue}); err != nil {
+		return err
+	}
+	if err := pp.receiver.Receive(&unregisterConsumer{queue: pp.unregisterQueue}); err != nil {
+		return err
+	}
+
+	go pp.indexProposals()
+	return nil
+}
+
+// Stop unsubscribes from the proposal announcements
+func (pp *ProposalProvider) Stop() {
+	pp.once.Do(func() {
+		pp.receiver.Unsubscribe()
+		close(pp.stop)
+	})
+}
+
+// GetProposal returns the announced proposal of the given provider and service type
+func (pp *ProposalProvider) GetProposal(id market.ProposalID) (*market.ServiceProposal, error) {
+	proposals, err := pp.FindProposals(market.ProposalFilter{
+		ProviderID:  id.ProviderID,
+		ServiceType: id.ServiceType,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(proposals) == 0 {
+		return nil, ErrProposalNotFound
+	}
+
+	return &proposals[0], nil
+}
+
+// FindProposals returns the announced proposals matching the filter
+func (pp *ProposalProvider) FindProposals(filter market.ProposalFilter) ([]market.ServiceProposal, error) {
+	now := pp.timeGetter()
+
+	pp.lock.RLock()
+	defer pp.lock.RUnlock()
+
+	proposals := make([]market.ServiceProposal, 0)
+	for _, record := range pp.proposals {
+		if !record.expiresAt.After(now) {
+			continue
+		}
+		if matches(record.proposal, filter) {
+			proposals = append(proposals, record.proposal)
+		}
+	}
+	return proposals, nil
+}
+
+func (pp *ProposalProvider) indexProposals() {
+	ticker := time.NewTicker(pp.proposalTTL)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-pp.stop:
+			return
+		case msg := <-pp.registerQueue:
+			pp.handleRegister(msg)
+		case msg := <-pp.unregisterQueue:
+			pp.handleUnregister(msg)
+		case <-ticker.C:
+			pp.removeExpired()
+		}
+	}
+}
+
+func (pp *ProposalProvider) handleRegister(msg registerMessage) {
+	proposal, err := pp.verifiedProposal(registerEndpoint, msg.Proposal, msg.SignedAt, msg.Signature)
+	if err != nil {
+		log.Warn(proposalProviderLogPrefix, "Ignoring proposal registration: ", err)
+		return
+	}
+
+	pp.lock.Lock()
+	defer pp.lock.Unlock()
+
+	id := proposalID(proposal)
+	if !pp.accept(id, msg.SignedAt) {
+		log.Warn(proposalProviderLogPrefix, "Ignoring replayed proposal registration of provider ", proposal.ProviderID)
+		return
+	}
+	pp.proposals[id] = proposalRecord{
+		proposal:  proposal,
+		expiresAt: pp.timeGetter().Add(pp.proposalTTL),
+	}
+}
+
+func (pp *ProposalProvider) handleUnregister(msg unregisterMessage) {
+	proposal, err := pp.verifiedProposal(unregisterEndpoint, msg.Proposal, msg.SignedAt, msg.Signature)
+	if err != nil {
+		log.Warn(proposalProviderLogPrefix, "Ignoring proposal unregistration: ", err)
+		return
+	}
+
+	pp.lock.Lock()
+	defer pp.lock.Unlock()
+
+	id := proposalID(proposal)
+	if !pp.accept(id, msg.SignedAt) {
+		log.Warn(proposalProviderLogPrefix, "Ignoring replayed proposal unregistration of provider ", proposal.ProviderID)
+		return
+	}
+	delete(pp.proposals, id)
+}
+
+// accept records the time the message of the proposal was signed at, messages not newer than the last accepted one are replays
+func (pp *ProposalProvider) accept(id market.ProposalID, signedAt int64) bool {
+	if last, exists := pp.lastSignedAt[id]; exists && signedAt <= last {
+		return false
+	}
+	pp.lastSignedAt[id] = signedAt
+	return true
+}
+
+func (pp *ProposalProvider) removeExpired() {
+	now := pp.timeGetter()
+
+	pp.lock.Lock()
+	defer pp.lock.Unlock()
+
+	for id, record := range pp.proposals {
+		if !record.expiresAt.After(now) {
+			log.Info(proposalProviderLogPrefix, "Proposal expired: ", id.ProviderID, " ", id.ServiceType)
+			delete(pp.proposals, id)
+		}
+	}
+	// stale messages are rejected anyway, so there is nothing to compare them with
+	for id, signedAt := range pp.lastSignedAt {
+		if now.Sub(time.Unix(0, signedAt)) > messageMaxAge {
+			delete(pp.lastSignedAt, id)
+		}
+	}
+}
+
+// verifiedProposal decodes the proposal and checks that the message of the given type was signed by its provider recently
+func (pp *ProposalProvider) verifiedProposal(endpoint communication.MessageEndpoint, proposalJSON json.RawMessage, signedAt int64, signature string) (market.ServiceProposal, error) {
+	var proposal market.ServiceProposal
+	if err := json.Unmarshal(proposalJSON, &proposal); err != nil {
+		return proposal, fmt.Errorf("invalid proposal: %v", err)
+	}
+	if proposal.ProviderID == "" {
+		return proposal, errors.New("proposal without provider")
+	}
+
+	age := pp.timeGetter().Sub(time.Unix(0, signedAt))
+	if age > messageMaxAge || age < -messageMaxAge {
+		return proposal, fmt.Errorf("stale message of provider %s signed %v ago", proposal.ProviderID, age)
+	}
+
+	verifier := pp.verifierFactory(identity.FromAddress(proposal.ProviderID))
+	if !verifier.Verify(signedPayload(endpoint, signedAt, proposalJSON), identity.SignatureBase64(signature)) {
+		return proposal, fmt.Errorf("invalid signature of provider %s", proposal.ProviderID)
+	}
+	return proposal, nil
+}
+
+func proposalID(proposal market.ServiceProposal) market.ProposalID {
+	return market.ProposalID{
+		ServiceType: proposal.ServiceType,
+		ProviderID:  proposal.ProviderID,
+		ID:          proposal.ID,
+	}
+}
+
+func matches(proposal market.ServiceProposal, filter market.ProposalFilter) bool {
+	if filter.ProviderID != "" && filter.ProviderID != proposal.ProviderID {
+		return false
+	}
+	if filter.ServiceType != "" && filter.ServiceType != proposal.ServiceType {
+		return false
+	}
+	if filter.AccessPolicyID != "" || filter.AccessPolicySource != "" {
+		if !hasAccessPolicy(proposal, filter.AccessPolicyID, filter.AccessPolicySource) {
+			return false
+		}
+	}
+	return proposal.IsSupported() && filter.Matches(proposal)
+}
+
+func hasAccessPolicy(proposal market.ServiceProposal, id, source string) bool {
+	if proposal.AccessPolicies == nil {
+		return false
+	}
+	for _, policy := range *proposal.AccessPolicies {
+		if (id == "" || policy.ID == id) && (source == "" || policy.Source == source) {
+			return true
+		}
+	}
+	return false
+}
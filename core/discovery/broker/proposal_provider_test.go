@@ -0,0 +1,219 @@
+/*
+ * Copyright (C) 2019 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package broker
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/mysteriumnetwork/node/communication/nats"
+	"github.com/mysteriumnetwork/node/identity"
+	"github.com/mysteriumnetwork/node/market"
+	"github.com/mysteriumnetwork/node/money"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockServiceDefinition struct {
+	Location market.Location `json:"location"`
+}
+
+func (service mockServiceDefinition) GetLocation() market.Location {
+	return service.Location
+}
+
+func init() {
+	market.RegisterServiceDefinitionUnserializer("mock_service", func(rawDefinition *json.RawMessage) (market.ServiceDefinition, error) {
+		var definition mockServiceDefinition
+		err := json.Unmarshal(*rawDefinition, &definition)
+		return definition, err
+	})
+	market.RegisterPaymentMethodUnserializer(market.PaymentMethodPerGB, market.UnserializePaymentPerGB)
+	market.RegisterContactUnserializer("mock_contact", func(rawMessage *json.RawMessage) (market.ContactDefinition, error) {
+		return struct{}{}, nil
+	})
+}
+
+func mockProposal(providerID, country string) market.ServiceProposal {
+	return market.ServiceProposal{
+		ID:                1,
+		ServiceType:       "mock_service",
+		ProviderID:        providerID,
+		ServiceDefinition: mockServiceDefinition{Location: market.Location{Country: country}},
+		PaymentMethodType: market.PaymentMethodPerGB,
+		PaymentMethod:     market.PaymentPerGB{Price: money.NewMoney(0.5, money.CurrencyMyst)},
+		ProviderContacts:  market.ContactList{{Type: "mock_contact", Definition: struct{}{}}},
+	}
+}
+
+func signedRegisterMessage(t *testing.T, proposal market.ServiceProposal, signedAt time.Time) registerMessage {
+	proposalJSON, signature, err := signProposal(registerEndpoint, proposal, signedAt.UnixNano(), &identity.SignerFake{})
+	assert.NoError(t, err)
+	return registerMessage{Proposal: proposalJSON, SignedAt: signedAt.UnixNano(), Signature: signature}
+}
+
+func signedUnregisterMessage(t *testing.T, proposal market.ServiceProposal, signedAt time.Time) unregisterMessage {
+	proposalJSON, signature, err := signProposal(unregisterEndpoint, proposal, signedAt.UnixNano(), &identity.SignerFake{})
+	assert.NoError(t, err)
+	return unregisterMessage{Proposal: proposalJSON, SignedAt: signedAt.UnixNano(), Signature: signature}
+}
+
+func newTestProposalProvider(now *time.Time) *ProposalProvider {
+	provider := NewProposalProvider(NewReceiver(nats.NewConnectionMock()), time.Minute)
+	provider.verifierFactory = func(_ identity.Identity) identity.Verifier {
+		return &identity.VerifierFake{}
+	}
+	provider.timeGetter = func() time.Time {
+		return *now
+	}
+	return provider
+}
+
+func Test_ProposalProvider_IndexesRegisteredProposals(t *testing.T) {
+	now := time.Now()
+	provider := newTestProposalProvider(&now)
+
+	provider.handleRegister(signedRegisterMessage(t, mockProposal("0x1", "LT"), now))
+	provider.handleRegister(signedRegisterMessage(t, mockProposal("0x2", "NL"), now))
+
+	proposals, err := provider.FindProposals(market.ProposalFilter{})
+	assert.NoError(t, err)
+	assert.Len(t, proposals, 2)
+
+	proposals, err = provider.FindProposals(market.ProposalFilter{LocationCountry: "nl"})
+	assert.NoError(t, err)
+	assert.Len(t, proposals, 1)
+	assert.Equal(t, "0x2", proposals[0].ProviderID)
+
+	proposal, err := provider.GetProposal(market.ProposalID{ProviderID: "0x1", ServiceType: "mock_service"})
+	assert.NoError(t, err)
+	assert.Equal(t, "0x1", proposal.ProviderID)
+
+	_, err = provider.GetProposal(market.ProposalID{ProviderID: "0x3", ServiceType: "mock_service"})
+	assert.Equal(t, ErrProposalNotFound, err)
+}
+
+func Test_ProposalProvider_IgnoresInvalidSignature(t *testing.T) {
+	now := time.Now()
+	provider := newTestProposalProvider(&now)
+
+	msg := signedRegisterMessage(t, mockProposal("0x1", "LT"), now)
+	msg.Proposal, _ = json.Marshal(mockProposal("0x1", "NL"))
+	provider.handleRegister(msg)
+
+	msg = signedRegisterMessage(t, mockProposal("0x2", "LT"), now)
+	msg.Signature = ""
+	provider.handleRegister(msg)
+
+	proposals, err := provider.FindProposals(market.ProposalFilter{})
+	assert.NoError(t, err)
+	assert.Len(t, proposals, 0)
+}
+
+func Test_ProposalProvider_ExpiresProposalsWithoutPing(t *testing.T) {
+	now := time.Now()
+	provider := newTestProposalProvider(&now)
+
+	provider.handleRegister(signedRegisterMessage(t, mockProposal("0x1", "LT"), now))
+	provider.handleRegister(signedRegisterMessage(t, mockProposal("0x2", "LT"), now))
+
+	now = now.Add(40 * time.Second)
+	provider.handleRegister(signedRegisterMessage(t, mockProposal("0x2", "LT"), now))
+
+	now = now.Add(40 * time.Second)
+	proposals, err := provider.FindProposals(market.ProposalFilter{})
+	assert.NoError(t, err)
+	assert.Len(t, proposals, 1)
+	assert.Equal(t, "0x2", proposals[0].ProviderID)
+
+	provider.removeExpired()
+	assert.Len(t, provider.proposals, 1)
+}
+
+func Test_ProposalProvider_RemovesUnregisteredProposals(t *testing.T) {
+	now := time.Now()
+	provider := newTestProposalProvider(&now)
+
+	provider.handleRegister(signedRegisterMessage(t, mockProposal("0x1", "LT"), now))
+	now = now.Add(time.Second)
+	unregistration := signedUnregisterMessage(t, mockProposal("0x1", "LT"), now)
+	unregistration.Signature = "invalid"
+	provider.handleUnregister(unregistration)
+	assert.Len(t, provider.proposals, 1)
+
+	provider.handleUnregister(signedUnregisterMessage(t, mockProposal("0x1", "LT"), now))
+	assert.Len(t, provider.proposals, 0)
+}
+
+func Test_ProposalProvider_IgnoresRegistrationPassedOffAsUnregistration(t *testing.T) {
+	now := time.Now()
+	provider := newTestProposalProvider(&now)
+
+	registration := signedRegisterMessage(t, mockProposal("0x1", "LT"), now)
+	provider.handleRegister(registration)
+	provider.handleUnregister(unregisterMessage(registration))
+
+	assert.Len(t, provider.proposals, 1)
+}
+
+func Test_ProposalProvider_IgnoresStaleMessages(t *testing.T) {
+	now := time.Now()
+	provider := newTestProposalProvider(&now)
+
+	provider.handleRegister(signedRegisterMessage(t, mockProposal("0x1", "LT"), now.Add(-messageMaxAge-time.Second)))
+	provider.handleRegister(signedRegisterMessage(t, mockProposal("0x2", "LT"), now.Add(messageMaxAge+time.Second)))
+
+	assert.Len(t, provider.proposals, 0)
+}
+
+func Test_ProposalProvider_IgnoresReplayedUnregistration(t *testing.T) {
+	now := time.Now()
+	provider := newTestProposalProvider(&now)
+
+	provider.handleRegister(signedRegisterMessage(t, mockProposal("0x1", "LT"), now))
+	unregistration := signedUnregisterMessage(t, mockProposal("0x1", "LT"), now.Add(time.Second))
+	provider.handleUnregister(unregistration)
+	provider.handleRegister(signedRegisterMessage(t, mockProposal("0x1", "LT"), now.Add(2*time.Second)))
+
+	now = now.Add(10 * time.Second)
+	provider.handleUnregister(unregistration)
+	assert.Len(t, provider.proposals, 1)
+}
+
+func Test_ProposalProvider_ReceivesProposalsFromRegistry(t *testing.T) {
+	connection := nats.NewConnectionMock()
+	provider := NewProposalProvider(NewReceiver(connection), time.Minute)
+	provider.verifierFactory = func(_ identity.Identity) identity.Verifier {
+		return &identity.VerifierFake{}
+	}
+	assert.NoError(t, provider.Start())
+	defer provider.Stop()
+
+	connection.Start()
+	defer connection.Close()
+
+	registry := NewRegistry(NewSender(connection))
+	assert.NoError(t, registry.RegisterProposal(mockProposal("0x1", "LT"), &identity.SignerFake{}))
+
+	var proposals []market.ServiceProposal
+	for i := 0; i < 100 && len(proposals) == 0; i++ {
+		time.Sleep(time.Millisecond)
+		proposals, _ = provider.FindProposals(market.ProposalFilter{})
+	}
+	assert.Len(t, proposals, 1)
+}
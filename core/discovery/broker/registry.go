@@ -18,6 +18,10 @@
 package broker
 
 import (
+	"encoding/json"
+	"fmt"
+	"time"
+
 	"github.com/mysteriumnetwork/node/communication"
 	"github.com/mysteriumnetwork/node/communication/nats"
 	"github.com/mysteriumnetwork/node/identity"
@@ -33,31 +37,73 @@ func NewSender(connection nats.Connection) communication.Sender {
 	)
 }
 
+// NewReceiver creates receiver of proposal announcements thru NATS
+func NewReceiver(connection nats.Connection) communication.Receiver {
+	return nats.NewReceiver(
+		connection,
+		communication.NewCodecJSON(),
+		"*",
+	)
+}
+
 type registry struct {
-	sender communication.Sender
+	sender     communication.Sender
+	timeGetter func() time.Time
 }
 
 // NewRegistry create an instance of Broker registry
 func NewRegistry(sender communication.Sender) *registry {
 	return &registry{
-		sender: sender,
+		sender:     sender,
+		timeGetter: time.Now,
 	}
 }
 
 // RegisterProposal registers service proposal to discovery service
 func (registry *registry) RegisterProposal(proposal market.ServiceProposal, signer identity.Signer) error {
-	message := &registerMessage{Proposal: proposal}
+	signedAt := registry.timeGetter().UnixNano()
+	proposalJSON, signature, err := signProposal(registerEndpoint, proposal, signedAt, signer)
+	if err != nil {
+		return err
+	}
+
+	message := &registerMessage{Proposal: proposalJSON, SignedAt: signedAt, Signature: signature}
 	return registry.sender.Send(&registerProducer{message: message})
 }
 
 // UnregisterProposal unregisters a service proposal when client disconnects
 func (registry *registry) UnregisterProposal(proposal market.ServiceProposal, signer identity.Signer) error {
-	message := &unregisterMessage{Proposal: proposal}
+	signedAt := registry.timeGetter().UnixNano()
+	proposalJSON, signature, err := signProposal(unregisterEndpoint, proposal, signedAt, signer)
+	if err != nil {
+		return err
+	}
+
+	message := &unregisterMessage{Proposal: proposalJSON, SignedAt: signedAt, Signature: signature}
 	return registry.sender.Send(&unregisterProducer{message: message})
 }
 
 // PingProposal pings service proposal as being alive
 func (registry *registry) PingProposal(proposal market.ServiceProposal, signer identity.Signer) error {
-	message := &registerMessage{Proposal: proposal}
-	return registry.sender.Send(&registerProducer{message: message})
+	return registry.RegisterProposal(proposal, signer)
+}
+
+func signProposal(endpoint communication.MessageEndpoint, proposal market.ServiceProposal, signedAt int64, signer identity.Signer) (json.RawMessage, string, error) {
+	proposalJSON, err := json.Marshal(proposal)
+	if err != nil {
+		return nil, "", err
+	}
+
+	signature, err := signer.Sign(signedPayload(endpoint, signedAt, proposalJSON))
+	if err != nil {
+		return nil, "", err
+	}
+
+	return proposalJSON, signature.Base64(), nil
+}
+
+// signedPayload binds the proposal to the message type and the time it was signed at,
+// so that the message can neither be replayed later nor passed off as a message of the other type
+func signedPayload(endpoint communication.MessageEndpoint, signedAt int64, proposalJSON []byte) []byte {
+	return []byte(fmt.Sprintf("%s:%d:%s", endpoint, signedAt, proposalJSON))
 }
@@ -18,9 +18,13 @@
 package broker
 
 import (
+	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"testing"
+	"time"
 
+	"github.com/mysteriumnetwork/node/communication"
 	"github.com/mysteriumnetwork/node/communication/nats"
 	"github.com/mysteriumnetwork/node/identity"
 	"github.com/mysteriumnetwork/node/market"
@@ -30,26 +34,34 @@ import (
 var (
 	newProposal           = market.ServiceProposal{ProviderID: "0x1"}
 	newProposalPayload, _ = json.Marshal(newProposal)
+	signedAt              = time.Unix(1560000000, 0)
 )
 
+func newProposalSignature(endpoint communication.MessageEndpoint) string {
+	return base64.StdEncoding.EncodeToString(append([]byte("signed"), signedPayload(endpoint, signedAt.UnixNano(), newProposalPayload)...))
+}
+
+func newTestRegistry(connection nats.Connection) *registry {
+	registry := NewRegistry(NewSender(connection))
+	registry.timeGetter = func() time.Time {
+		return signedAt
+	}
+	return registry
+}
+
 func Test_NewRegistry(t *testing.T) {
 	sender := NewSender(nats.NewConnectionMock())
-	assert.Equal(
-		t,
-		&registry{
-			sender: sender,
-		},
-		NewRegistry(sender),
-	)
+	registry := NewRegistry(sender)
+
+	assert.Equal(t, sender, registry.sender)
+	assert.NotNil(t, registry.timeGetter)
 }
 
 func Test_Registry_RegisterProposal(t *testing.T) {
 	connection := nats.StartConnectionMock()
 	defer connection.Close()
 
-	registry := &registry{
-		sender: NewSender(connection),
-	}
+	registry := newTestRegistry(connection)
 	err := registry.RegisterProposal(newProposal, &identity.SignerFake{})
 	assert.NoError(t, err)
 
@@ -57,7 +69,9 @@ func Test_Registry_RegisterProposal(t *testing.T) {
 	assert.JSONEq(
 		t,
 		`{
-			"proposal": `+string(newProposalPayload)+`
+			"proposal": `+string(newProposalPayload)+`,
+			"signed_at": 1560000000000000000,
+			"signature": "`+newProposalSignature(registerEndpoint)+`"
 		}`,
 		string(connection.GetLastMessage()),
 	)
@@ -67,9 +81,7 @@ func Test_Registry_UnregisterProposal(t *testing.T) {
 	connection := nats.StartConnectionMock()
 	defer connection.Close()
 
-	registry := &registry{
-		sender: NewSender(connection),
-	}
+	registry := newTestRegistry(connection)
 	err := registry.UnregisterProposal(newProposal, &identity.SignerFake{})
 	assert.NoError(t, err)
 
@@ -77,7 +89,9 @@ func Test_Registry_UnregisterProposal(t *testing.T) {
 	assert.JSONEq(
 		t,
 		`{
-			"proposal": `+string(newProposalPayload)+`
+			"proposal": `+string(newProposalPayload)+`,
+			"signed_at": 1560000000000000000,
+			"signature": "`+newProposalSignature(unregisterEndpoint)+`"
 		}`,
 		string(connection.GetLastMessage()),
 	)
@@ -87,9 +101,7 @@ func Test_Registry_PingProposal(t *testing.T) {
 	connection := nats.StartConnectionMock()
 	defer connection.Close()
 
-	registry := &registry{
-		sender: NewSender(connection),
-	}
+	registry := newTestRegistry(connection)
 	err := registry.PingProposal(newProposal, &identity.SignerFake{})
 	assert.NoError(t, err)
 
@@ -97,8 +109,20 @@ func Test_Registry_PingProposal(t *testing.T) {
 	assert.JSONEq(
 		t,
 		`{
-			"proposal": `+string(newProposalPayload)+`
+			"proposal": `+string(newProposalPayload)+`,
+			"signed_at": 1560000000000000000,
+			"signature": "`+newProposalSignature(registerEndpoint)+`"
 		}`,
 		string(connection.GetLastMessage()),
 	)
 }
+
+func Test_Registry_RegisterProposal_SigningFails(t *testing.T) {
+	connection := nats.StartConnectionMock()
+	defer connection.Close()
+
+	registry := newTestRegistry(connection)
+	err := registry.RegisterProposal(newProposal, &identity.SignerFake{ErrorMock: errors.New("no key")})
+	assert.EqualError(t, err, "no key")
+	assert.Empty(t, connection.GetLastMessageSubject())
+}
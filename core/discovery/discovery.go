@@ -54,6 +54,7 @@ type Discovery struct {
 	signerCreate         identity.SignerFactory
 	signer               identity.Signer
 	proposal             market.ServiceProposal
+	eventPublisher       Publisher
 
 	statusChan                  chan Status
 	status                      Status
@@ -70,12 +71,14 @@ func NewService(
 	identityRegistration identity_registry.RegistrationDataProvider,
 	proposalRegistry ProposalRegistry,
 	signerCreate identity.SignerFactory,
+	eventPublisher Publisher,
 ) *Discovery {
 	return &Discovery{
 		identityRegistry:            identityRegistry,
 		identityRegistration:        identityRegistration,
 		proposalRegistry:            proposalRegistry,
 		signerCreate:                signerCreate,
+		eventPublisher:              eventPublisher,
 		statusChan:                  make(chan Status),
 		status:                      StatusUndefined,
 		proposalAnnouncementStopped: &sync.WaitGroup{},
@@ -106,6 +109,14 @@ func (d *Discovery) Start(ownIdentity identity.Identity, proposal market.Service
 	go d.mainDiscoveryLoop(stopLoop)
 }
 
+// UpdateProposal replaces the announced proposal, the change is announced with the next proposal ping
+func (d *Discovery) UpdateProposal(proposal market.ServiceProposal) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.proposal = proposal
+}
+
 // Wait wait for proposal announcements to stop / unregister
 func (d *Discovery) Wait() {
 	d.proposalAnnouncementStopped.Wait()
@@ -175,7 +186,7 @@ func (d *Discovery) registerIdentity() {
 }
 
 func (d *Discovery) registerProposal() {
-	err := d.proposalRegistry.RegisterProposal(d.proposal, d.signer)
+	err := d.proposalRegistry.RegisterProposal(d.currentProposal(), d.signer)
 	if err != nil {
 		log.Errorf("%s Failed to register proposal, retrying after 1 min. %s", logPrefix, err.Error())
 		time.Sleep(1 * time.Minute)
@@ -187,15 +198,17 @@ func (d *Discovery) registerProposal() {
 
 func (d *Discovery) pingProposal() {
 	time.Sleep(1 * time.Minute)
-	err := d.proposalRegistry.PingProposal(d.proposal, d.signer)
+	proposal := d.currentProposal()
+	err := d.proposalRegistry.PingProposal(proposal, d.signer)
 	if err != nil {
 		log.Error(logPrefix, "Failed to ping proposal: ", err)
+		d.eventPublisher.Publish(PingFailedTopic, PingFailedEvent{Proposal: proposal, Error: err})
 	}
 	d.changeStatus(PingProposal)
 }
 
 func (d *Discovery) unregisterProposal() {
-	err := d.proposalRegistry.UnregisterProposal(d.proposal, d.signer)
+	err := d.proposalRegistry.UnregisterProposal(d.currentProposal(), d.signer)
 	if err != nil {
 		log.Error(logPrefix, "Failed to unregister proposal: ", err)
 		d.changeStatus(UnregisterProposalFailed)
@@ -204,6 +217,13 @@ func (d *Discovery) unregisterProposal() {
 	d.changeStatus(ProposalUnregistered)
 }
 
+func (d *Discovery) currentProposal() market.ServiceProposal {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	return d.proposal
+}
+
 func (d *Discovery) checkRegistration() {
 	// check if node's identity is registered
 	registered, err := d.identityRegistry.IsRegistered(d.ownIdentity)
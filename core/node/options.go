@@ -30,16 +30,23 @@ type Options struct {
 
 	TequilapiAddress string
 	TequilapiPort    int
+	TequilapiAuth    OptionsTequilapiAuth
+	TequilapiSocket  OptionsTequilapiSocket
+	TequilapiTLS     OptionsTequilapiTLS
 	UI               OptionsUI
 
 	DisableMetrics bool
 	MetricsAddress string
+	MetricsWebhook string
+	MetricsFile    string
 
 	Keystore OptionsKeystore
 
 	OptionsNetwork
 	Discovery OptionsDiscovery
 	Location  OptionsLocation
+	Egress    OptionsEgress
+	DNS       OptionsDNS
 
 	Openvpn Openvpn
 }
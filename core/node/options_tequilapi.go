@@ -0,0 +1,46 @@
+/*
+ * Copyright (C) 2019 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package node
+
+import "os"
+
+// OptionsTequilapiAuth describes how tequilapi clients are authenticated
+type OptionsTequilapiAuth struct {
+	// Disabled serves tequilapi to anyone who can reach it
+	Disabled bool
+	// Username and Password are exchanged for the API token at login, login is disabled if they are not set
+	Username string
+	Password string
+}
+
+// OptionsTequilapiSocket describes the Unix domain socket tequilapi is served on next to TCP
+type OptionsTequilapiSocket struct {
+	// Path of the socket, tequilapi is not served on socket if it is empty
+	Path string
+	// Mode restricts access to the socket
+	Mode os.FileMode
+}
+
+// OptionsTequilapiTLS describes HTTPS of tequilapi
+type OptionsTequilapiTLS struct {
+	// Enabled serves HTTPS instead of plain HTTP on the tequilapi address and port
+	Enabled bool
+	// CertFile and KeyFile hold the PEM encoded certificate and its key, self-signed ones are generated if neither exists
+	CertFile string
+	KeyFile  string
+}
@@ -20,12 +20,16 @@ package service
 import (
 	"encoding/json"
 	"errors"
+	"fmt"
+	"net"
 
 	log "github.com/cihub/seelog"
 	"github.com/gofrs/uuid"
 	"github.com/mysteriumnetwork/node/communication"
+	"github.com/mysteriumnetwork/node/datasize"
 	"github.com/mysteriumnetwork/node/identity"
 	"github.com/mysteriumnetwork/node/market"
+	"github.com/mysteriumnetwork/node/nat/egress"
 	"github.com/mysteriumnetwork/node/nat/traversal"
 	"github.com/mysteriumnetwork/node/session"
 )
@@ -38,6 +42,8 @@ var (
 	ErrorLocation = errors.New("failed to detect service location")
 	// ErrUnsupportedServiceType indicates that manager tried to create an unsupported service type
 	ErrUnsupportedServiceType = errors.New("unsupported service type")
+	// ErrBandwidthLimitNotSupported indicates that the service can not change the bandwidth limit of its sessions
+	ErrBandwidthLimitNotSupported = errors.New("service does not support bandwidth limit")
 )
 
 // Service interface represents pluggable Mysterium service
@@ -47,6 +53,41 @@ type Service interface {
 	ProvideConfig(sessionConfig json.RawMessage, traversalParams *traversal.Params) (*session.ConfigParams, error)
 }
 
+// BandwidthLimiter is implemented by the services which can change the bandwidth limit of their sessions at runtime
+type BandwidthLimiter interface {
+	SetBandwidthLimit(limit datasize.BitSize) error
+}
+
+// BandwidthAnnouncer is implemented by the service definitions announcing the session bandwidth limit to consumers
+type BandwidthAnnouncer interface {
+	WithSessionBandwidth(limit datasize.BitSize) market.ServiceDefinition
+}
+
+// EgressReporter is implemented by the services which enforce the egress policy on consumer traffic
+type EgressReporter interface {
+	EgressStats() (egress.Stats, bool)
+}
+
+// DNSListener binds the DNS forwarder to the provider tunnel addresses
+type DNSListener interface {
+	Listen(ip net.IP) error
+	Unlisten(ip net.IP) error
+}
+
+// DNSForwarder resolves names for the consumers of a service instance
+type DNSForwarder interface {
+	DNSListener
+	Stop() error
+}
+
+// DNSForwarderFactory creates the DNS forwarder of a service instance
+type DNSForwarderFactory func() (DNSForwarder, error)
+
+// DNSHost is implemented by the services which serve DNS to consumers on the provider tunnel addresses
+type DNSHost interface {
+	HostDNS(listener DNSListener)
+}
+
 // DialogWaiterFactory initiates communication channel which waits for incoming dialogs
 type DialogWaiterFactory func(providerID identity.Identity, serviceType string, allowedIDs []identity.Identity) (communication.DialogWaiter, error)
 
@@ -59,10 +100,17 @@ type DiscoveryFactory func() Discovery
 // Discovery registers the service to the discovery api periodically
 type Discovery interface {
 	Start(ownIdentity identity.Identity, proposal market.ServiceProposal)
+	UpdateProposal(proposal market.ServiceProposal)
 	Stop()
 	Wait()
 }
 
+// UnfinishedSessionStorage keeps track of the sessions which were not terminated
+type UnfinishedSessionStorage interface {
+	GetUnfinished() ([]session.Record, error)
+	Terminate(id session.ID, reason string) error
+}
+
 // WaitForNATHole blocks until NAT hole is punched towards consumer through local NAT or until hole punching failed
 type WaitForNATHole func() error
 
@@ -73,6 +121,8 @@ func NewManager(
 	dialogHandlerFactory DialogHandlerFactory,
 	discoveryFactory DiscoveryFactory,
 	eventPublisher Publisher,
+	sessionStorage UnfinishedSessionStorage,
+	dnsForwarderFactory DNSForwarderFactory,
 ) *Manager {
 	return &Manager{
 		serviceRegistry:      serviceRegistry,
@@ -80,6 +130,8 @@ func NewManager(
 		dialogWaiterFactory:  dialogWaiterFactory,
 		dialogHandlerFactory: dialogHandlerFactory,
 		discoveryFactory:     discoveryFactory,
+		sessionStorage:       sessionStorage,
+		dnsForwarderFactory:  dnsForwarderFactory,
 	}
 }
 
@@ -92,6 +144,35 @@ type Manager struct {
 	servicePool     *Pool
 
 	discoveryFactory DiscoveryFactory
+
+	sessionStorage UnfinishedSessionStorage
+
+	// dnsForwarderFactory is nil if the services do not serve DNS to consumers
+	dnsForwarderFactory DNSForwarderFactory
+}
+
+// RecoverSessions terminates the sessions left unfinished by the previous node run
+// and tears down the resources they left behind, e.g. peers and NAT rules.
+func (manager *Manager) RecoverSessions() error {
+	records, err := manager.sessionStorage.GetUnfinished()
+	if err != nil {
+		return err
+	}
+
+	for _, record := range records {
+		if cleanup, exists := manager.serviceRegistry.SessionCleanup(record.ServiceType); exists {
+			if err := cleanup(record); err != nil {
+				log.Warn(fmt.Sprintf("Session %v cleanup failed: %v", record.ID, err))
+			}
+		}
+
+		if err := manager.sessionStorage.Terminate(record.ID, session.TerminationReasonNodeRestarted); err != nil {
+			return err
+		}
+		log.Info(fmt.Sprintf("Unfinished %s session %v terminated", record.ServiceType, record.ID))
+	}
+
+	return nil
 }
 
 // Start starts an instance of the given service type if knows one in service registry.
@@ -109,6 +190,18 @@ func (manager *Manager) Start(providerID identity.Identity, serviceType string,
 		return id, err
 	}
 
+	dnsForwarder, err := manager.startDNSForwarder(service)
+	if err != nil {
+		return id, err
+	}
+	defer func() {
+		if err != nil && dnsForwarder != nil {
+			if stopErr := dnsForwarder.Stop(); stopErr != nil {
+				log.Error("DNS forwarder stop failed: ", stopErr)
+			}
+		}
+	}()
+
 	dialogWaiter, err := manager.dialogWaiterFactory(providerID, serviceType, allowedIDs)
 	if err != nil {
 		return id, err
@@ -140,6 +233,7 @@ func (manager *Manager) Start(providerID identity.Identity, serviceType string,
 		proposal:     proposal,
 		dialogWaiter: dialogWaiter,
 		discovery:    discovery,
+		dnsForwarder: dnsForwarder,
 	}
 
 	manager.servicePool.Add(&instance)
@@ -165,6 +259,21 @@ func (manager *Manager) Start(providerID identity.Identity, serviceType string,
 	return id, nil
 }
 
+// startDNSForwarder hands a DNS forwarder to the service if it serves DNS to consumers
+func (manager *Manager) startDNSForwarder(service Service) (DNSForwarder, error) {
+	host, ok := service.(DNSHost)
+	if !ok || manager.dnsForwarderFactory == nil {
+		return nil, nil
+	}
+
+	forwarder, err := manager.dnsForwarderFactory()
+	if err != nil {
+		return nil, err
+	}
+	host.HostDNS(forwarder)
+	return forwarder, nil
+}
+
 func generateID() (ID, error) {
 	uid, err := uuid.NewV4()
 	if err != nil {
@@ -197,3 +306,29 @@ func (manager *Manager) Stop(id ID) error {
 func (manager *Manager) Service(id ID) *Instance {
 	return manager.servicePool.Instance(id)
 }
+
+// SetBandwidthLimit changes the bandwidth limit of the running service sessions and announces it in the service proposal.
+func (manager *Manager) SetBandwidthLimit(id ID, limit datasize.BitSize) error {
+	instance := manager.servicePool.Instance(id)
+	if instance == nil {
+		return ErrNoSuchInstance
+	}
+
+	limiter, ok := instance.service.(BandwidthLimiter)
+	if !ok {
+		return ErrBandwidthLimitNotSupported
+	}
+	if err := limiter.SetBandwidthLimit(limit); err != nil {
+		return err
+	}
+
+	proposal := instance.Proposal()
+	if announcer, ok := proposal.ServiceDefinition.(BandwidthAnnouncer); ok {
+		proposal.ServiceDefinition = announcer.WithSessionBandwidth(limit)
+		instance.setProposal(proposal)
+		if instance.discovery != nil {
+			instance.discovery.UpdateProposal(proposal)
+		}
+	}
+	return nil
+}
@@ -19,10 +19,13 @@ package service
 
 import (
 	"errors"
+	"net"
 	"testing"
 
+	"github.com/mysteriumnetwork/node/datasize"
 	"github.com/mysteriumnetwork/node/identity"
 	"github.com/mysteriumnetwork/node/market"
+	"github.com/mysteriumnetwork/node/session"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -46,6 +49,8 @@ func TestManager_StartRemovesServiceFromPoolIfServiceCrashes(t *testing.T) {
 		MockDialogHandlerFactory,
 		discoveryFactory,
 		&mockPublisher{},
+		&mockSessionStorage{},
+		nil,
 	)
 	_, err := manager.Start(identity.FromAddress(proposalMock.ProviderID), serviceType, nil, struct{}{})
 	assert.Nil(t, err)
@@ -70,6 +75,8 @@ func TestManager_StartDoesNotCrashIfStoppedByUser(t *testing.T) {
 		MockDialogHandlerFactory,
 		discoveryFactory,
 		&mockPublisher{},
+		&mockSessionStorage{},
+		nil,
 	)
 	id, err := manager.Start(identity.FromAddress(proposalMock.ProviderID), serviceType, nil, struct{}{})
 	assert.Nil(t, err)
@@ -96,6 +103,8 @@ func TestManager_StopSendsEvent_SucceedsAndPublishesEvent(t *testing.T) {
 		MockDialogHandlerFactory,
 		discoveryFactory,
 		eventBus,
+		&mockSessionStorage{},
+		nil,
 	)
 
 	id, err := manager.Start(identity.FromAddress(proposalMock.ProviderID), serviceType, nil, struct{}{})
@@ -107,3 +116,205 @@ func TestManager_StopSendsEvent_SucceedsAndPublishesEvent(t *testing.T) {
 	assert.Equal(t, StopTopic, eventBus.publishedTopic)
 	assert.Equal(t, &mockCopy, eventBus.publishedData.(*Instance).service)
 }
+
+func TestManager_StartHandsDNSForwarderToServiceAndStopStopsIt(t *testing.T) {
+	registry := NewRegistry()
+	host := &dnsHostFake{serviceFake: serviceFake{mockProcess: make(chan struct{})}}
+	registry.Register(serviceType, func(options Options) (Service, market.ServiceProposal, error) {
+		return host, proposalMock, nil
+	})
+
+	forwarder := &dnsForwarderFake{}
+	discovery := mockDiscovery{}
+	manager := NewManager(
+		registry,
+		MockDialogWaiterFactory,
+		MockDialogHandlerFactory,
+		MockDiscoveryFactoryFunc(&discovery),
+		&mockPublisher{},
+		&mockSessionStorage{},
+		func() (DNSForwarder, error) {
+			return forwarder, nil
+		},
+	)
+
+	id, err := manager.Start(identity.FromAddress(proposalMock.ProviderID), serviceType, nil, struct{}{})
+	assert.NoError(t, err)
+	assert.Equal(t, forwarder, host.listener)
+
+	assert.NoError(t, manager.Stop(id))
+	discovery.Wait()
+	assert.True(t, forwarder.stopped)
+}
+
+func TestManager_StartFailsIfDNSForwarderFails(t *testing.T) {
+	registry := NewRegistry()
+	registry.Register(serviceType, func(options Options) (Service, market.ServiceProposal, error) {
+		return &dnsHostFake{}, proposalMock, nil
+	})
+
+	forwarderErr := errors.New("no upstreams")
+	manager := NewManager(
+		registry,
+		MockDialogWaiterFactory,
+		MockDialogHandlerFactory,
+		MockDiscoveryFactoryFunc(&mockDiscovery{}),
+		&mockPublisher{},
+		&mockSessionStorage{},
+		func() (DNSForwarder, error) {
+			return nil, forwarderErr
+		},
+	)
+
+	_, err := manager.Start(identity.FromAddress(proposalMock.ProviderID), serviceType, nil, struct{}{})
+	assert.Exactly(t, forwarderErr, err)
+	assert.Len(t, manager.servicePool.List(), 0)
+}
+
+func TestManager_SetBandwidthLimitAnnouncesLimit(t *testing.T) {
+	limiter := &limitedServiceFake{}
+	discovery := &mockDiscovery{}
+	manager := NewManager(NewRegistry(), MockDialogWaiterFactory, MockDialogHandlerFactory, MockDiscoveryFactoryFunc(discovery), &mockPublisher{}, &mockSessionStorage{}, nil)
+	manager.servicePool.Add(&Instance{
+		id:        "service-id",
+		service:   limiter,
+		proposal:  market.ServiceProposal{ServiceDefinition: limitedDefinitionFake{}},
+		discovery: discovery,
+	})
+
+	err := manager.SetBandwidthLimit("service-id", datasize.MB)
+	assert.NoError(t, err)
+
+	assert.Equal(t, datasize.MB, limiter.limit)
+	expectedDefinition := limitedDefinitionFake{SessionBandwidth: datasize.MB}
+	assert.Equal(t, expectedDefinition, manager.Service("service-id").Proposal().ServiceDefinition)
+	assert.Equal(t, expectedDefinition, discovery.proposal.ServiceDefinition)
+}
+
+func TestManager_SetBandwidthLimitFailsIfServiceDoesNotSupportIt(t *testing.T) {
+	manager := NewManager(NewRegistry(), MockDialogWaiterFactory, MockDialogHandlerFactory, nil, &mockPublisher{}, &mockSessionStorage{}, nil)
+	manager.servicePool.Add(&Instance{id: "service-id", service: &serviceFake{}})
+
+	assert.Equal(t, ErrBandwidthLimitNotSupported, manager.SetBandwidthLimit("service-id", datasize.MB))
+	assert.Equal(t, ErrNoSuchInstance, manager.SetBandwidthLimit("unknown-id", datasize.MB))
+}
+
+func TestManager_RecoverSessionsCleansUpAndTerminates(t *testing.T) {
+	registry := NewRegistry()
+	var cleaned []session.ID
+	registry.RegisterSessionCleanup(serviceType, func(record session.Record) error {
+		cleaned = append(cleaned, record.ID)
+		return nil
+	})
+
+	storage := &mockSessionStorage{
+		unfinished: []session.Record{
+			{ID: "session1", ServiceType: serviceType},
+			{ID: "session2", ServiceType: "unknown-service-type"},
+		},
+	}
+	manager := NewManager(registry, MockDialogWaiterFactory, MockDialogHandlerFactory, MockDiscoveryFactoryFunc(&mockDiscovery{}), &mockPublisher{}, storage, nil)
+
+	err := manager.RecoverSessions()
+	assert.NoError(t, err)
+	assert.Equal(t, []session.ID{"session1"}, cleaned)
+	assert.Equal(
+		t,
+		map[session.ID]string{
+			"session1": session.TerminationReasonNodeRestarted,
+			"session2": session.TerminationReasonNodeRestarted,
+		},
+		storage.terminated,
+	)
+}
+
+func TestManager_RecoverSessionsTerminatesWhenCleanupFails(t *testing.T) {
+	registry := NewRegistry()
+	registry.RegisterSessionCleanup(serviceType, func(record session.Record) error {
+		return errors.New("cleanup failed")
+	})
+
+	storage := &mockSessionStorage{
+		unfinished: []session.Record{{ID: "session1", ServiceType: serviceType}},
+	}
+	manager := NewManager(registry, MockDialogWaiterFactory, MockDialogHandlerFactory, MockDiscoveryFactoryFunc(&mockDiscovery{}), &mockPublisher{}, storage, nil)
+
+	err := manager.RecoverSessions()
+	assert.NoError(t, err)
+	assert.Contains(t, storage.terminated, session.ID("session1"))
+}
+
+func TestManager_RecoverSessionsBubblesStorageErrors(t *testing.T) {
+	storageErr := errors.New("storage failed")
+	manager := NewManager(NewRegistry(), MockDialogWaiterFactory, MockDialogHandlerFactory, MockDiscoveryFactoryFunc(&mockDiscovery{}), &mockPublisher{}, &mockSessionStorage{getErr: storageErr}, nil)
+
+	err := manager.RecoverSessions()
+	assert.Exactly(t, storageErr, err)
+}
+
+type mockSessionStorage struct {
+	unfinished []session.Record
+	terminated map[session.ID]string
+	getErr     error
+}
+
+func (mss *mockSessionStorage) GetUnfinished() ([]session.Record, error) {
+	return mss.unfinished, mss.getErr
+}
+
+func (mss *mockSessionStorage) Terminate(id session.ID, reason string) error {
+	if mss.terminated == nil {
+		mss.terminated = make(map[session.ID]string)
+	}
+	mss.terminated[id] = reason
+	return nil
+}
+
+type limitedServiceFake struct {
+	serviceFake
+	limit datasize.BitSize
+}
+
+func (service *limitedServiceFake) SetBandwidthLimit(limit datasize.BitSize) error {
+	service.limit = limit
+	return nil
+}
+
+type limitedDefinitionFake struct {
+	SessionBandwidth datasize.BitSize
+}
+
+func (definition limitedDefinitionFake) GetLocation() market.Location {
+	return market.Location{}
+}
+
+func (definition limitedDefinitionFake) WithSessionBandwidth(limit datasize.BitSize) market.ServiceDefinition {
+	definition.SessionBandwidth = limit
+	return definition
+}
+
+type dnsHostFake struct {
+	serviceFake
+	listener DNSListener
+}
+
+func (service *dnsHostFake) HostDNS(listener DNSListener) {
+	service.listener = listener
+}
+
+type dnsForwarderFake struct {
+	stopped bool
+}
+
+func (forwarder *dnsForwarderFake) Listen(ip net.IP) error {
+	return nil
+}
+
+func (forwarder *dnsForwarderFake) Unlisten(ip net.IP) error {
+	return nil
+}
+
+func (forwarder *dnsForwarderFake) Stop() error {
+	forwarder.stopped = true
+	return nil
+}
@@ -23,6 +23,7 @@ import (
 
 	"github.com/mysteriumnetwork/node/communication"
 	"github.com/mysteriumnetwork/node/market"
+	"github.com/mysteriumnetwork/node/nat/egress"
 	"github.com/mysteriumnetwork/node/utils"
 )
 
@@ -99,6 +100,9 @@ func (p *Pool) stop(id ID) error {
 	if instance.service != nil {
 		errStop.Add(instance.service.Stop())
 	}
+	if instance.dnsForwarder != nil {
+		errStop.Add(instance.dnsForwarder.Stop())
+	}
 
 	p.del(id)
 	p.eventPublisher.Publish(StopTopic, instance)
@@ -159,6 +163,15 @@ type Instance struct {
 	proposal     market.ServiceProposal
 	dialogWaiter communication.DialogWaiter
 	discovery    Discovery
+	dnsForwarder DNSForwarder
+
+	// proposalLock guards the proposal, which changes when the service limits are changed at runtime
+	proposalLock sync.RWMutex
+}
+
+// ID returns the ID of the service instance.
+func (i *Instance) ID() ID {
+	return i.id
 }
 
 // Options returns options used to start service
@@ -168,9 +181,29 @@ func (i *Instance) Options() Options {
 
 // Proposal returns service proposal of the running service instance.
 func (i *Instance) Proposal() market.ServiceProposal {
+	i.proposalLock.RLock()
+	defer i.proposalLock.RUnlock()
+
 	return i.proposal
 }
 
+func (i *Instance) setProposal(proposal market.ServiceProposal) {
+	i.proposalLock.Lock()
+	defer i.proposalLock.Unlock()
+
+	i.proposal = proposal
+}
+
+// EgressStats returns the counters of the consumer traffic blocked by the egress policy,
+// false is returned if the service does not enforce the policy.
+func (i *Instance) EgressStats() (egress.Stats, bool) {
+	reporter, ok := i.service.(EgressReporter)
+	if !ok {
+		return egress.Stats{}, false
+	}
+	return reporter.EgressStats()
+}
+
 // State returns the service instance state.
 func (i *Instance) State() State {
 	return i.state
@@ -19,20 +19,26 @@ package service
 
 import (
 	"github.com/mysteriumnetwork/node/market"
+	"github.com/mysteriumnetwork/node/session"
 )
 
 // RegistryFactory initiates instance which is able to serve
 type RegistryFactory func(options Options) (Service, market.ServiceProposal, error)
 
+// SessionCleanup tears down resources of a session left unfinished by the previous node run
+type SessionCleanup func(record session.Record) error
+
 // Registry holds all pluggable services
 type Registry struct {
 	factories map[string]RegistryFactory
+	cleanups  map[string]SessionCleanup
 }
 
 // NewRegistry creates a registry of pluggable services
 func NewRegistry() *Registry {
 	return &Registry{
 		factories: make(map[string]RegistryFactory),
+		cleanups:  make(map[string]SessionCleanup),
 	}
 }
 
@@ -50,3 +56,14 @@ func (registry *Registry) Create(serviceType string, options Options) (Service,
 
 	return createService(options)
 }
+
+// RegisterSessionCleanup registers cleanup of the unfinished sessions for a pluggable service
+func (registry *Registry) RegisterSessionCleanup(serviceType string, cleanup SessionCleanup) {
+	registry.cleanups[serviceType] = cleanup
+}
+
+// SessionCleanup returns cleanup of the unfinished sessions for a pluggable service
+func (registry *Registry) SessionCleanup(serviceType string) (SessionCleanup, bool) {
+	cleanup, exists := registry.cleanups[serviceType]
+	return cleanup, exists
+}
@@ -22,6 +22,7 @@ import (
 	"testing"
 
 	"github.com/mysteriumnetwork/node/market"
+	"github.com/mysteriumnetwork/node/session"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -85,9 +86,26 @@ func TestRegistry_Create_BubblesErrors(t *testing.T) {
 	assert.Exactly(t, fakeErr, err)
 }
 
+func TestRegistry_RegisterSessionCleanup(t *testing.T) {
+	registry := mockRegistryEmpty()
+
+	cleanup, exists := registry.SessionCleanup("any")
+	assert.False(t, exists)
+	assert.Nil(t, cleanup)
+
+	registry.RegisterSessionCleanup("any", func(record session.Record) error {
+		return nil
+	})
+
+	cleanup, exists = registry.SessionCleanup("any")
+	assert.True(t, exists)
+	assert.NotNil(t, cleanup)
+}
+
 func mockRegistryEmpty() *Registry {
 	return &Registry{
 		factories: map[string]RegistryFactory{},
+		cleanups:  map[string]SessionCleanup{},
 	}
 }
 
@@ -96,5 +114,6 @@ func mockRegistryWith(serviceType string, serviceFactory RegistryFactory) *Regis
 		factories: map[string]RegistryFactory{
 			serviceType: serviceFactory,
 		},
+		cleanups: map[string]SessionCleanup{},
 	}
 }
@@ -95,12 +95,17 @@ func MockDialogHandlerFactory(market.ServiceProposal, session.ConfigNegotiator,
 }
 
 type mockDiscovery struct {
-	wg sync.WaitGroup
+	wg       sync.WaitGroup
+	proposal market.ServiceProposal
 }
 
 func (mds *mockDiscovery) Start(ownIdentity identity.Identity, proposal market.ServiceProposal) {
 	mds.wg.Add(1)
 }
+func (mds *mockDiscovery) UpdateProposal(proposal market.ServiceProposal) {
+	mds.proposal = proposal
+}
+
 func (mds *mockDiscovery) Stop() {
 	mds.wg.Done()
 }
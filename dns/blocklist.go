@@ -0,0 +1,93 @@
+/*
+ * Copyright (C) 2019 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package dns
+
+import (
+	"bufio"
+	"io"
+	"net"
+	"os"
+	"strings"
+)
+
+// Blocklist holds the domains forwarder does not resolve, their subdomains are blocked too
+type Blocklist struct {
+	domains map[string]struct{}
+}
+
+// LoadBlocklist reads the blocklist file, see ParseBlocklist for the format
+func LoadBlocklist(path string) (Blocklist, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return Blocklist{}, err
+	}
+	defer file.Close()
+
+	return ParseBlocklist(file)
+}
+
+// ParseBlocklist reads a domain per line or the hosts file format used by the ad and malware blocklists,
+// e.g. "0.0.0.0 ads.example.com", lines starting with # are comments and names without a dot are skipped
+func ParseBlocklist(reader io.Reader) (Blocklist, error) {
+	blocklist := Blocklist{domains: make(map[string]struct{})}
+
+	scanner := bufio.NewScanner(reader)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if comment := strings.Index(line, "#"); comment >= 0 {
+			line = line[:comment]
+		}
+
+		fields := strings.Fields(line)
+		if len(fields) > 1 && net.ParseIP(fields[0]) != nil {
+			fields = fields[1:]
+		}
+		for _, field := range fields {
+			domain := strings.ToLower(strings.TrimSuffix(field, "."))
+			if strings.Contains(domain, ".") && net.ParseIP(domain) == nil {
+				blocklist.domains[domain] = struct{}{}
+			}
+		}
+	}
+
+	return blocklist, scanner.Err()
+}
+
+// Len returns the number of blocked domains
+func (blocklist Blocklist) Len() int {
+	return len(blocklist.domains)
+}
+
+// Blocked checks if the lower case name or any of its parent domains is blocked
+func (blocklist Blocklist) Blocked(name string) bool {
+	if len(blocklist.domains) == 0 {
+		return false
+	}
+
+	for {
+		if _, blocked := blocklist.domains[name]; blocked {
+			return true
+		}
+
+		dot := strings.Index(name, ".")
+		if dot < 0 {
+			return false
+		}
+		name = name[dot+1:]
+	}
+}
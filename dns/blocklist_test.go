@@ -0,0 +1,54 @@
+/*
+ * Copyright (C) 2019 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package dns
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_ParseBlocklist(t *testing.T) {
+	blocklist, err := ParseBlocklist(strings.NewReader(`
+# hosts file format
+127.0.0.1 localhost
+0.0.0.0 ads.example.com tracker.example.net # trailing comment
+::1 ip6-localhost
+malware.example.org.
+Upper.Example.COM
+1.2.3.4
+`))
+	assert.NoError(t, err)
+	assert.Equal(t, 4, blocklist.Len())
+
+	assert.True(t, blocklist.Blocked("ads.example.com"))
+	assert.True(t, blocklist.Blocked("cdn.ads.example.com"))
+	assert.True(t, blocklist.Blocked("tracker.example.net"))
+	assert.True(t, blocklist.Blocked("malware.example.org"))
+	assert.True(t, blocklist.Blocked("upper.example.com"))
+
+	assert.False(t, blocklist.Blocked("example.com"))
+	assert.False(t, blocklist.Blocked("notads.example.com"))
+	assert.False(t, blocklist.Blocked("localhost"))
+	assert.False(t, blocklist.Blocked("1.2.3.4"))
+}
+
+func Test_Blocklist_EmptyBlocksNothing(t *testing.T) {
+	assert.False(t, Blocklist{}.Blocked("example.com"))
+}
@@ -0,0 +1,98 @@
+/*
+ * Copyright (C) 2019 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package dns
+
+import (
+	"sync"
+	"time"
+)
+
+type cacheEntry struct {
+	response []byte
+	stored   time.Time
+	expires  time.Time
+}
+
+// cache keeps the upstream responses until their TTL expires, nothing is cached if size is not positive
+type cache struct {
+	size    int
+	entries map[string]cacheEntry
+	now     func() time.Time
+	lock    sync.Mutex
+}
+
+func newCache(size int) *cache {
+	return &cache{
+		size:    size,
+		entries: make(map[string]cacheEntry),
+		now:     time.Now,
+	}
+}
+
+// get returns the cached response with TTLs of its records counting down the remaining lifetime of the entry
+func (c *cache) get(key string) ([]byte, bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	entry, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	now := c.now()
+	if !now.Before(entry.expires) {
+		delete(c.entries, key)
+		return nil, false
+	}
+	return withTTL(entry.response, now.Sub(entry.stored), entry.expires.Sub(now)), true
+}
+
+func (c *cache) put(key string, response []byte, ttl time.Duration) {
+	if c.size <= 0 || ttl <= 0 {
+		return
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if _, exists := c.entries[key]; !exists && len(c.entries) >= c.size {
+		c.evict()
+	}
+
+	stored := make([]byte, len(response))
+	copy(stored, response)
+	now := c.now()
+	c.entries[key] = cacheEntry{response: stored, stored: now, expires: now.Add(ttl)}
+}
+
+// evict drops the expired entries, an arbitrary one is dropped if none has expired
+func (c *cache) evict() {
+	now := c.now()
+	for key, entry := range c.entries {
+		if !now.Before(entry.expires) {
+			delete(c.entries, key)
+		}
+	}
+	if len(c.entries) < c.size {
+		return
+	}
+
+	for key := range c.entries {
+		delete(c.entries, key)
+		return
+	}
+}
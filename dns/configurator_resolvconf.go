@@ -0,0 +1,59 @@
+/*
+ * Copyright (C) 2019 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package dns
+
+import (
+	"net"
+	"strings"
+
+	log "github.com/cihub/seelog"
+)
+
+const (
+	dnsLogPrefix = "[dns] "
+
+	resolvconfBinary = "/sbin/resolvconf"
+)
+
+// configuratorResolvconf adds the DNS servers as a separate resolvconf record of the interface,
+// so the original settings are restored by deleting the record
+type configuratorResolvconf struct {
+	exec func(input string, args ...string) error
+}
+
+func (configurator *configuratorResolvconf) Configure(iface string, servers []net.IP) error {
+	var conf strings.Builder
+	for _, server := range servers {
+		conf.WriteString("nameserver " + server.String() + "\n")
+	}
+
+	if err := configurator.exec(conf.String(), resolvconfBinary, "-a", recordName(iface)); err != nil {
+		return err
+	}
+
+	log.Info(dnsLogPrefix, "DNS servers of ", iface, " set to ", servers)
+	return nil
+}
+
+func (configurator *configuratorResolvconf) Clean(iface string) error {
+	return configurator.exec("", resolvconfBinary, "-d", recordName(iface))
+}
+
+func recordName(iface string) string {
+	return iface + ".myst"
+}
@@ -0,0 +1,52 @@
+/*
+ * Copyright (C) 2019 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package dns
+
+import (
+	"net"
+
+	log "github.com/cihub/seelog"
+)
+
+const resolvectlBinary = "/usr/bin/resolvectl"
+
+// configuratorResolved sets the DNS servers of the interface link in systemd-resolved
+// and routes all the domains to them, reverting the link restores the original settings
+type configuratorResolved struct {
+	exec func(args ...string) error
+}
+
+func (configurator *configuratorResolved) Configure(iface string, servers []net.IP) error {
+	args := []string{resolvectlBinary, "dns", iface}
+	for _, server := range servers {
+		args = append(args, server.String())
+	}
+	if err := configurator.exec(args...); err != nil {
+		return err
+	}
+	if err := configurator.exec(resolvectlBinary, "domain", iface, "~."); err != nil {
+		return err
+	}
+
+	log.Info(dnsLogPrefix, "DNS servers of ", iface, " set to ", servers)
+	return nil
+}
+
+func (configurator *configuratorResolved) Clean(iface string) error {
+	return configurator.exec(resolvectlBinary, "revert", iface)
+}
@@ -0,0 +1,79 @@
+/*
+ * Copyright (C) 2019 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package dns
+
+import (
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type execRecorder struct {
+	commands []string
+	inputs   []string
+}
+
+func (er *execRecorder) exec(args ...string) error {
+	er.commands = append(er.commands, strings.Join(args, " "))
+	return nil
+}
+
+func (er *execRecorder) execWithInput(input string, args ...string) error {
+	er.inputs = append(er.inputs, input)
+	return er.exec(args...)
+}
+
+var serversStub = []net.IP{net.ParseIP("10.182.0.1"), net.ParseIP("fd10:182::1")}
+
+func Test_configuratorResolvconf_ConfigureAndClean(t *testing.T) {
+	recorder := &execRecorder{}
+	configurator := &configuratorResolvconf{exec: recorder.execWithInput}
+
+	assert.NoError(t, configurator.Configure("myst0", serversStub))
+	assert.NoError(t, configurator.Clean("myst0"))
+
+	assert.Equal(
+		t,
+		[]string{
+			"/sbin/resolvconf -a myst0.myst",
+			"/sbin/resolvconf -d myst0.myst",
+		},
+		recorder.commands,
+	)
+	assert.Equal(t, "nameserver 10.182.0.1\nnameserver fd10:182::1\n", recorder.inputs[0])
+}
+
+func Test_configuratorResolved_ConfigureAndClean(t *testing.T) {
+	recorder := &execRecorder{}
+	configurator := &configuratorResolved{exec: recorder.exec}
+
+	assert.NoError(t, configurator.Configure("myst0", serversStub))
+	assert.NoError(t, configurator.Clean("myst0"))
+
+	assert.Equal(
+		t,
+		[]string{
+			"/usr/bin/resolvectl dns myst0 10.182.0.1 fd10:182::1",
+			"/usr/bin/resolvectl domain myst0 ~.",
+			"/usr/bin/resolvectl revert myst0",
+		},
+		recorder.commands,
+	)
+}
@@ -0,0 +1,39 @@
+/*
+ * Copyright (C) 2019 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package dns
+
+import (
+	"os"
+	"strings"
+
+	"github.com/mysteriumnetwork/node/utils"
+)
+
+// NewConfigurator returns linux os specific DNS configurator,
+// systemd-resolved is used if it manages resolv.conf and resolvconf otherwise
+func NewConfigurator() Configurator {
+	if usesResolved() {
+		return &configuratorResolved{exec: utils.SudoExec}
+	}
+	return &configuratorResolvconf{exec: utils.SudoExecWithInput}
+}
+
+func usesResolved() bool {
+	target, err := os.Readlink(resolvConfPath)
+	return err == nil && strings.Contains(target, "/run/systemd/resolve/")
+}
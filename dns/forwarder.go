@@ -0,0 +1,441 @@
+/*
+ * Copyright (C) 2019 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package dns
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/cihub/seelog"
+	"github.com/mysteriumnetwork/node/utils"
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+const (
+	// DefaultPort is the port forwarder listens on if no other is configured
+	DefaultPort = 53
+	// DefaultCacheSize is the number of answers forwarder caches by default
+	DefaultCacheSize = 1000
+
+	upstreamTimeout = 2 * time.Second
+	// tcpTimeout limits the time a client is given to send its query over TCP and read the answer
+	tcpTimeout = 5 * time.Second
+	// maxQueries is the number of queries resolved concurrently, the following ones wait in the socket buffers
+	maxQueries = 100
+	// negativeTTL is the time failed lookups are cached for if the upstream does not tell it
+	negativeTTL = 60 * time.Second
+	maxTTL      = time.Hour
+	maxMessage  = 65535
+)
+
+// ErrNoUpstreams indicates that forwarder has no upstream servers to resolve the names with
+var ErrNoUpstreams = errors.New("no upstream DNS servers")
+
+// ForwarderOptions describes how the forwarder resolves names
+type ForwarderOptions struct {
+	// Port is the port forwarder listens on, DefaultPort is used if zero
+	Port int
+	// Upstreams are the host:port addresses of the servers queries are forwarded to, tried in order
+	Upstreams []string
+	// CacheSize is the number of cached answers, caching is disabled if zero
+	CacheSize int
+	// Blocklist has the domains which are answered with NXDOMAIN instead of being forwarded
+	Blocklist Blocklist
+}
+
+// Forwarder is a caching DNS forwarder serving consumers on the provider tunnel addresses
+type Forwarder struct {
+	port      int
+	upstreams []string
+	blocklist Blocklist
+	cache     *cache
+	exchange  func(network string, query []byte, upstream string) ([]byte, error)
+	queries   chan struct{}
+
+	listeners map[string]*listener
+	lock      sync.Mutex
+}
+
+// listener serves the queries sent to an address over both UDP and TCP
+type listener struct {
+	packetConn net.PacketConn
+	streamConn net.Listener
+}
+
+func (l *listener) close() error {
+	errs := utils.ErrorCollection{}
+	errs.Add(l.packetConn.Close())
+	errs.Add(l.streamConn.Close())
+	return errs.Errorf("failed to close DNS listener: %v", ", ")
+}
+
+// NewForwarder creates a forwarder which does not listen on any address until told so
+func NewForwarder(options ForwarderOptions) (*Forwarder, error) {
+	if len(options.Upstreams) == 0 {
+		return nil, ErrNoUpstreams
+	}
+
+	port := options.Port
+	if port == 0 {
+		port = DefaultPort
+	}
+
+	return &Forwarder{
+		port:      port,
+		upstreams: options.Upstreams,
+		blocklist: options.Blocklist,
+		cache:     newCache(options.CacheSize),
+		exchange:  exchange,
+		queries:   make(chan struct{}, maxQueries),
+		listeners: make(map[string]*listener),
+	}, nil
+}
+
+// Listen starts serving the queries sent to the given address, address is allowed to be not assigned yet on linux
+func (forwarder *Forwarder) Listen(ip net.IP) error {
+	forwarder.lock.Lock()
+	defer forwarder.lock.Unlock()
+
+	address := net.JoinHostPort(ip.String(), strconv.Itoa(forwarder.port))
+	if _, exists := forwarder.listeners[address]; exists {
+		return nil
+	}
+
+	packetConn, err := listenPacket(address)
+	if err != nil {
+		return err
+	}
+	streamConn, err := listenStream(address)
+	if err != nil {
+		packetConn.Close()
+		return err
+	}
+	forwarder.listeners[address] = &listener{packetConn: packetConn, streamConn: streamConn}
+
+	go forwarder.servePacket(packetConn)
+	go forwarder.serveStream(streamConn)
+	log.Info(dnsLogPrefix, "DNS forwarder listening on ", address)
+	return nil
+}
+
+// Unlisten stops serving the queries sent to the given address
+func (forwarder *Forwarder) Unlisten(ip net.IP) error {
+	forwarder.lock.Lock()
+	defer forwarder.lock.Unlock()
+
+	address := net.JoinHostPort(ip.String(), strconv.Itoa(forwarder.port))
+	l, exists := forwarder.listeners[address]
+	if !exists {
+		return nil
+	}
+
+	delete(forwarder.listeners, address)
+	return l.close()
+}
+
+// Stop stops serving the queries on all addresses
+func (forwarder *Forwarder) Stop() error {
+	forwarder.lock.Lock()
+	defer forwarder.lock.Unlock()
+
+	errs := utils.ErrorCollection{}
+	for address, l := range forwarder.listeners {
+		errs.Add(l.close())
+		delete(forwarder.listeners, address)
+	}
+	return errs.Errorf("failed to stop DNS forwarder: %v", ", ")
+}
+
+func (forwarder *Forwarder) servePacket(conn net.PacketConn) {
+	buffer := make([]byte, maxMessage)
+	for {
+		n, addr, err := conn.ReadFrom(buffer)
+		if err != nil {
+			// listener is closed
+			return
+		}
+
+		query := make([]byte, n)
+		copy(query, buffer[:n])
+		forwarder.queries <- struct{}{}
+		go func() {
+			defer func() { <-forwarder.queries }()
+
+			response, err := forwarder.Resolve(query)
+			if err == nil && len(response) > udpSize(query) {
+				response, err = truncated(response)
+			}
+			if err != nil {
+				log.Debug(dnsLogPrefix, "Dropping DNS query from ", addr, ": ", err)
+				return
+			}
+			if _, err := conn.WriteTo(response, addr); err != nil {
+				log.Debug(dnsLogPrefix, "Failed to answer DNS query of ", addr, ": ", err)
+			}
+		}()
+	}
+}
+
+// serveStream answers a single query per TCP connection, clients use TCP to retry the truncated UDP responses
+func (forwarder *Forwarder) serveStream(streamConn net.Listener) {
+	for {
+		conn, err := streamConn.Accept()
+		if err != nil {
+			// listener is closed
+			return
+		}
+
+		forwarder.queries <- struct{}{}
+		go func() {
+			defer func() { <-forwarder.queries }()
+			defer conn.Close()
+
+			if err := forwarder.answerStream(conn); err != nil {
+				log.Debug(dnsLogPrefix, "Dropping DNS query from ", conn.RemoteAddr(), ": ", err)
+			}
+		}()
+	}
+}
+
+func (forwarder *Forwarder) answerStream(conn net.Conn) error {
+	if err := conn.SetDeadline(time.Now().Add(tcpTimeout)); err != nil {
+		return err
+	}
+
+	query, err := readStream(conn)
+	if err != nil {
+		return err
+	}
+	response, err := forwarder.Resolve(query)
+	if err != nil {
+		return err
+	}
+	return writeStream(conn, response)
+}
+
+// Resolve answers the query from blocklist, cache or upstream servers
+func (forwarder *Forwarder) Resolve(query []byte) ([]byte, error) {
+	var parser dnsmessage.Parser
+	header, err := parser.Start(query)
+	if err != nil {
+		return nil, err
+	}
+	question, err := parser.Question()
+	if err != nil {
+		return nil, err
+	}
+
+	name := strings.ToLower(strings.TrimSuffix(question.Name.String(), "."))
+	if forwarder.blocklist.Blocked(name) {
+		return reply(header, question, dnsmessage.RCodeNameError)
+	}
+
+	key := fmt.Sprintf("%s/%d/%d", name, question.Type, question.Class)
+	if response, ok := forwarder.cache.get(key); ok {
+		return withID(response, header.ID), nil
+	}
+
+	for _, upstream := range forwarder.upstreams {
+		response, err := forwarder.exchange("udp", query, upstream)
+		if err == nil && isTruncated(response) {
+			response, err = forwarder.exchange("tcp", query, upstream)
+		}
+		if err != nil {
+			log.Debug(dnsLogPrefix, "Upstream DNS server ", upstream, " failed: ", err)
+			continue
+		}
+
+		if ttl, cacheable := responseTTL(response); cacheable {
+			forwarder.cache.put(key, response, ttl)
+		}
+		return response, nil
+	}
+
+	return reply(header, question, dnsmessage.RCodeServerFailure)
+}
+
+func exchange(network string, query []byte, upstream string) ([]byte, error) {
+	if network == "tcp" {
+		return exchangeTCP(query, upstream)
+	}
+	return exchangeUDP(query, upstream)
+}
+
+func exchangeUDP(query []byte, upstream string) ([]byte, error) {
+	conn, err := net.DialTimeout("udp", upstream, upstreamTimeout)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	if err := conn.SetDeadline(time.Now().Add(upstreamTimeout)); err != nil {
+		return nil, err
+	}
+	if _, err := conn.Write(query); err != nil {
+		return nil, err
+	}
+
+	buffer := make([]byte, maxMessage)
+	for {
+		n, err := conn.Read(buffer)
+		if err != nil {
+			return nil, err
+		}
+		// responses of the other queries are ignored, e.g. the late ones of the previous query
+		if n >= 2 && buffer[0] == query[0] && buffer[1] == query[1] {
+			return buffer[:n], nil
+		}
+	}
+}
+
+func exchangeTCP(query []byte, upstream string) ([]byte, error) {
+	conn, err := net.DialTimeout("tcp", upstream, upstreamTimeout)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	if err := conn.SetDeadline(time.Now().Add(upstreamTimeout)); err != nil {
+		return nil, err
+	}
+	if err := writeStream(conn, query); err != nil {
+		return nil, err
+	}
+	return readStream(conn)
+}
+
+// readStream reads the message prefixed with its length as it is sent over TCP
+func readStream(conn net.Conn) ([]byte, error) {
+	var length [2]byte
+	if _, err := io.ReadFull(conn, length[:]); err != nil {
+		return nil, err
+	}
+
+	message := make([]byte, binary.BigEndian.Uint16(length[:]))
+	if _, err := io.ReadFull(conn, message); err != nil {
+		return nil, err
+	}
+	return message, nil
+}
+
+// writeStream writes the message prefixed with its length as it is sent over TCP
+func writeStream(conn net.Conn, message []byte) error {
+	if len(message) > maxMessage {
+		return errMalformedMessage
+	}
+
+	prefixed := make([]byte, 2+len(message))
+	binary.BigEndian.PutUint16(prefixed, uint16(len(message)))
+	copy(prefixed[2:], message)
+	_, err := conn.Write(prefixed)
+	return err
+}
+
+func isTruncated(response []byte) bool {
+	var parser dnsmessage.Parser
+	header, err := parser.Start(response)
+	return err == nil && header.Truncated
+}
+
+// truncated strips the records of the response which does not fit into UDP datagram, so the client retries over TCP
+func truncated(response []byte) ([]byte, error) {
+	var parser dnsmessage.Parser
+	header, err := parser.Start(response)
+	if err != nil {
+		return nil, err
+	}
+	questions, err := parser.AllQuestions()
+	if err != nil {
+		return nil, err
+	}
+
+	header.Truncated = true
+	message := dnsmessage.Message{Header: header, Questions: questions}
+	return message.Pack()
+}
+
+// responseTTL returns the time the response is valid for, truncated and failed responses are not cached
+func responseTTL(response []byte) (time.Duration, bool) {
+	var parser dnsmessage.Parser
+	header, err := parser.Start(response)
+	if err != nil || header.Truncated {
+		return 0, false
+	}
+	if header.RCode != dnsmessage.RCodeSuccess && header.RCode != dnsmessage.RCodeNameError {
+		return 0, false
+	}
+	if err := parser.SkipAllQuestions(); err != nil {
+		return 0, false
+	}
+
+	var ttl uint32
+	found := false
+	for {
+		resource, err := parser.AnswerHeader()
+		if err == dnsmessage.ErrSectionDone {
+			break
+		} else if err != nil {
+			return 0, false
+		}
+		if !found || resource.TTL < ttl {
+			ttl, found = resource.TTL, true
+		}
+		if err := parser.SkipAnswer(); err != nil {
+			return 0, false
+		}
+	}
+
+	if !found {
+		return negativeTTL, true
+	}
+	if duration := time.Duration(ttl) * time.Second; duration < maxTTL {
+		return duration, true
+	}
+	return maxTTL, true
+}
+
+func reply(query dnsmessage.Header, question dnsmessage.Question, rcode dnsmessage.RCode) ([]byte, error) {
+	message := dnsmessage.Message{
+		Header: dnsmessage.Header{
+			ID:                 query.ID,
+			Response:           true,
+			OpCode:             query.OpCode,
+			RecursionDesired:   query.RecursionDesired,
+			RecursionAvailable: true,
+			RCode:              rcode,
+		},
+		Questions: []dnsmessage.Question{question},
+	}
+	return message.Pack()
+}
+
+// withID copies the cached response giving it the ID of the query it answers
+func withID(response []byte, id uint16) []byte {
+	answer := make([]byte, len(response))
+	copy(answer, response)
+	answer[0] = byte(id >> 8)
+	answer[1] = byte(id)
+	return answer
+}
@@ -0,0 +1,309 @@
+/*
+ * Copyright (C) 2019 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package dns
+
+import (
+	"errors"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+type upstreamFake struct {
+	queries         []string
+	responses       map[string][]byte
+	streamQueries   []string
+	streamResponses map[string][]byte
+	lock            sync.Mutex
+}
+
+func (uf *upstreamFake) exchange(network string, query []byte, upstream string) ([]byte, error) {
+	uf.lock.Lock()
+	defer uf.lock.Unlock()
+
+	responses := uf.responses
+	if network == "tcp" {
+		uf.streamQueries = append(uf.streamQueries, upstream)
+		responses = uf.streamResponses
+	} else {
+		uf.queries = append(uf.queries, upstream)
+	}
+	response, ok := responses[upstream]
+	if !ok {
+		return nil, errors.New("upstream unreachable")
+	}
+	return withID(response, uint16(query[0])<<8|uint16(query[1])), nil
+}
+
+func newForwarderStub(t *testing.T, upstream *upstreamFake, blocklist string) *Forwarder {
+	parsed, err := ParseBlocklist(strings.NewReader(blocklist))
+	assert.NoError(t, err)
+
+	forwarder, err := NewForwarder(ForwarderOptions{
+		Upstreams: []string{"10.0.0.1:53", "10.0.0.2:53"},
+		CacheSize: DefaultCacheSize,
+		Blocklist: parsed,
+	})
+	assert.NoError(t, err)
+	forwarder.exchange = upstream.exchange
+	return forwarder
+}
+
+func packQuery(t *testing.T, id uint16, name string) []byte {
+	message := dnsmessage.Message{
+		Header: dnsmessage.Header{ID: id, RecursionDesired: true},
+		Questions: []dnsmessage.Question{{
+			Name:  dnsmessage.MustNewName(name),
+			Type:  dnsmessage.TypeA,
+			Class: dnsmessage.ClassINET,
+		}},
+	}
+	query, err := message.Pack()
+	assert.NoError(t, err)
+	return query
+}
+
+func packAnswer(t *testing.T, name string, ttl uint32) []byte {
+	return packAnswers(t, name, ttl, 1, false)
+}
+
+func packAnswers(t *testing.T, name string, ttl uint32, count int, truncated bool) []byte {
+	message := dnsmessage.Message{
+		Header: dnsmessage.Header{Response: true, RecursionAvailable: true, Truncated: truncated},
+		Questions: []dnsmessage.Question{{
+			Name:  dnsmessage.MustNewName(name),
+			Type:  dnsmessage.TypeA,
+			Class: dnsmessage.ClassINET,
+		}},
+	}
+	for i := 0; i < count; i++ {
+		message.Answers = append(message.Answers, dnsmessage.Resource{
+			Header: dnsmessage.ResourceHeader{
+				Name:  dnsmessage.MustNewName(name),
+				Type:  dnsmessage.TypeA,
+				Class: dnsmessage.ClassINET,
+				TTL:   ttl,
+			},
+			Body: &dnsmessage.AResource{A: [4]byte{1, 2, 3, byte(i)}},
+		})
+	}
+	response, err := message.Pack()
+	assert.NoError(t, err)
+	return response
+}
+
+func unpack(t *testing.T, response []byte) dnsmessage.Message {
+	var message dnsmessage.Message
+	assert.NoError(t, message.Unpack(response))
+	return message
+}
+
+func Test_NewForwarder_RequiresUpstreams(t *testing.T) {
+	_, err := NewForwarder(ForwarderOptions{})
+	assert.Equal(t, ErrNoUpstreams, err)
+}
+
+func Test_Forwarder_ResolvesThruFirstWorkingUpstream(t *testing.T) {
+	upstream := &upstreamFake{responses: map[string][]byte{"10.0.0.2:53": packAnswer(t, "example.com.", 300)}}
+	forwarder := newForwarderStub(t, upstream, "")
+
+	response, err := forwarder.Resolve(packQuery(t, 42, "example.com."))
+	assert.NoError(t, err)
+
+	message := unpack(t, response)
+	assert.Equal(t, uint16(42), message.Header.ID)
+	assert.Equal(t, dnsmessage.RCodeSuccess, message.Header.RCode)
+	assert.Equal(t, &dnsmessage.AResource{A: [4]byte{1, 2, 3, 0}}, message.Answers[0].Body)
+	assert.Equal(t, []string{"10.0.0.1:53", "10.0.0.2:53"}, upstream.queries)
+}
+
+func Test_Forwarder_AnswersFromCache(t *testing.T) {
+	upstream := &upstreamFake{responses: map[string][]byte{"10.0.0.1:53": packAnswer(t, "example.com.", 300)}}
+	forwarder := newForwarderStub(t, upstream, "")
+
+	_, err := forwarder.Resolve(packQuery(t, 1, "example.com."))
+	assert.NoError(t, err)
+	response, err := forwarder.Resolve(packQuery(t, 2, "Example.COM."))
+	assert.NoError(t, err)
+
+	assert.Equal(t, uint16(2), unpack(t, response).Header.ID)
+	assert.Len(t, upstream.queries, 1)
+}
+
+func Test_Forwarder_ForwardsAgainWhenCacheExpires(t *testing.T) {
+	upstream := &upstreamFake{responses: map[string][]byte{"10.0.0.1:53": packAnswer(t, "example.com.", 300)}}
+	forwarder := newForwarderStub(t, upstream, "")
+	now := time.Now()
+	forwarder.cache.now = func() time.Time { return now }
+
+	_, err := forwarder.Resolve(packQuery(t, 1, "example.com."))
+	assert.NoError(t, err)
+	now = now.Add(301 * time.Second)
+	_, err = forwarder.Resolve(packQuery(t, 2, "example.com."))
+	assert.NoError(t, err)
+
+	assert.Len(t, upstream.queries, 2)
+}
+
+func Test_Forwarder_CountsDownTTLOfCachedAnswers(t *testing.T) {
+	upstream := &upstreamFake{responses: map[string][]byte{"10.0.0.1:53": packAnswer(t, "example.com.", 300)}}
+	forwarder := newForwarderStub(t, upstream, "")
+	now := time.Now()
+	forwarder.cache.now = func() time.Time { return now }
+
+	_, err := forwarder.Resolve(packQuery(t, 1, "example.com."))
+	assert.NoError(t, err)
+	now = now.Add(100 * time.Second)
+	response, err := forwarder.Resolve(packQuery(t, 2, "example.com."))
+	assert.NoError(t, err)
+
+	assert.Equal(t, uint32(200), unpack(t, response).Answers[0].Header.TTL)
+}
+
+func Test_Forwarder_CachedAnswersDoNotOutliveCacheEntry(t *testing.T) {
+	upstream := &upstreamFake{responses: map[string][]byte{"10.0.0.1:53": packAnswer(t, "example.com.", 7200)}}
+	forwarder := newForwarderStub(t, upstream, "")
+	now := time.Now()
+	forwarder.cache.now = func() time.Time { return now }
+
+	_, err := forwarder.Resolve(packQuery(t, 1, "example.com."))
+	assert.NoError(t, err)
+	now = now.Add(100 * time.Second)
+	response, err := forwarder.Resolve(packQuery(t, 2, "example.com."))
+	assert.NoError(t, err)
+
+	assert.Equal(t, uint32(3500), unpack(t, response).Answers[0].Header.TTL)
+}
+
+func Test_Forwarder_RetriesTruncatedAnswerOverTCP(t *testing.T) {
+	upstream := &upstreamFake{
+		responses:       map[string][]byte{"10.0.0.1:53": packAnswers(t, "example.com.", 300, 1, true)},
+		streamResponses: map[string][]byte{"10.0.0.1:53": packAnswers(t, "example.com.", 300, 50, false)},
+	}
+	forwarder := newForwarderStub(t, upstream, "")
+
+	response, err := forwarder.Resolve(packQuery(t, 5, "example.com."))
+	assert.NoError(t, err)
+
+	message := unpack(t, response)
+	assert.False(t, message.Header.Truncated)
+	assert.Len(t, message.Answers, 50)
+	assert.Equal(t, []string{"10.0.0.1:53"}, upstream.streamQueries)
+}
+
+func Test_Forwarder_AnswersBlockedDomainsWithNXDomain(t *testing.T) {
+	upstream := &upstreamFake{responses: map[string][]byte{"10.0.0.1:53": packAnswer(t, "ads.example.com.", 300)}}
+	forwarder := newForwarderStub(t, upstream, "0.0.0.0 ads.example.com")
+
+	response, err := forwarder.Resolve(packQuery(t, 7, "tracker.ads.example.com."))
+	assert.NoError(t, err)
+
+	message := unpack(t, response)
+	assert.Equal(t, uint16(7), message.Header.ID)
+	assert.Equal(t, dnsmessage.RCodeNameError, message.Header.RCode)
+	assert.Empty(t, upstream.queries)
+}
+
+func Test_Forwarder_AnswersServerFailureWhenUpstreamsFail(t *testing.T) {
+	upstream := &upstreamFake{}
+	forwarder := newForwarderStub(t, upstream, "")
+
+	response, err := forwarder.Resolve(packQuery(t, 3, "example.com."))
+	assert.NoError(t, err)
+	assert.Equal(t, dnsmessage.RCodeServerFailure, unpack(t, response).Header.RCode)
+}
+
+func Test_Forwarder_RejectsMalformedQuery(t *testing.T) {
+	forwarder := newForwarderStub(t, &upstreamFake{}, "")
+
+	_, err := forwarder.Resolve([]byte{0, 1, 2})
+	assert.Error(t, err)
+}
+
+func Test_Forwarder_ServesQueriesOnListenedAddress(t *testing.T) {
+	upstream := &upstreamFake{responses: map[string][]byte{"10.0.0.1:53": packAnswer(t, "example.com.", 300)}}
+	forwarder := newForwarderStub(t, upstream, "")
+	forwarder.port = freeUDPPort(t)
+
+	assert.NoError(t, forwarder.Listen(net.ParseIP("127.0.0.1")))
+	defer forwarder.Stop()
+
+	conn, err := net.Dial("udp", net.JoinHostPort("127.0.0.1", strconv.Itoa(forwarder.port)))
+	assert.NoError(t, err)
+	defer conn.Close()
+	assert.NoError(t, conn.SetDeadline(time.Now().Add(time.Second)))
+
+	_, err = conn.Write(packQuery(t, 9, "example.com."))
+	assert.NoError(t, err)
+	buffer := make([]byte, maxMessage)
+	n, err := conn.Read(buffer)
+	assert.NoError(t, err)
+	assert.Equal(t, uint16(9), unpack(t, buffer[:n]).Header.ID)
+
+	assert.NoError(t, forwarder.Unlisten(net.ParseIP("127.0.0.1")))
+	assert.Empty(t, forwarder.listeners)
+}
+
+func Test_Forwarder_TruncatesLargeAnswersServedOverUDP(t *testing.T) {
+	upstream := &upstreamFake{responses: map[string][]byte{"10.0.0.1:53": packAnswers(t, "example.com.", 300, 50, false)}}
+	forwarder := newForwarderStub(t, upstream, "")
+	forwarder.port = freeUDPPort(t)
+
+	assert.NoError(t, forwarder.Listen(net.ParseIP("127.0.0.1")))
+	defer forwarder.Stop()
+	address := net.JoinHostPort("127.0.0.1", strconv.Itoa(forwarder.port))
+
+	conn, err := net.Dial("udp", address)
+	assert.NoError(t, err)
+	defer conn.Close()
+	assert.NoError(t, conn.SetDeadline(time.Now().Add(time.Second)))
+
+	_, err = conn.Write(packQuery(t, 9, "example.com."))
+	assert.NoError(t, err)
+	buffer := make([]byte, maxMessage)
+	n, err := conn.Read(buffer)
+	assert.NoError(t, err)
+	message := unpack(t, buffer[:n])
+	assert.True(t, message.Header.Truncated)
+	assert.Empty(t, message.Answers)
+
+	stream, err := net.Dial("tcp", address)
+	assert.NoError(t, err)
+	defer stream.Close()
+	assert.NoError(t, stream.SetDeadline(time.Now().Add(time.Second)))
+
+	assert.NoError(t, writeStream(stream, packQuery(t, 10, "example.com.")))
+	response, err := readStream(stream)
+	assert.NoError(t, err)
+	message = unpack(t, response)
+	assert.Equal(t, uint16(10), message.Header.ID)
+	assert.Len(t, message.Answers, 50)
+}
+
+func freeUDPPort(t *testing.T) int {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	defer conn.Close()
+	return conn.LocalAddr().(*net.UDPAddr).Port
+}
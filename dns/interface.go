@@ -0,0 +1,34 @@
+/*
+ * Copyright (C) 2019 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package dns
+
+import (
+	"errors"
+	"net"
+)
+
+// ErrNotSupported indicates that DNS servers of the interface can not be configured on the platform
+var ErrNotSupported = errors.New("DNS configuration is not supported on this platform")
+
+// Configurator makes the system resolve names thru the DNS servers of the network interface
+type Configurator interface {
+	// Configure sets the DNS servers used while the given interface is up
+	Configure(iface string, servers []net.IP) error
+	// Clean restores the DNS settings which were used before the interface was configured
+	Clean(iface string) error
+}
@@ -0,0 +1,50 @@
+/*
+ * Copyright (C) 2019 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package dns
+
+import (
+	"context"
+	"net"
+	"syscall"
+)
+
+// listenPacket binds UDP socket with IP_FREEBIND, so the forwarder is able to listen on the tunnel address
+// before the tunnel interface comes up, e.g. the one of the openvpn server
+func listenPacket(address string) (net.PacketConn, error) {
+	return freebindConfig().ListenPacket(context.Background(), "udp", address)
+}
+
+// listenStream binds TCP socket with IP_FREEBIND for the same reason as listenPacket
+func listenStream(address string) (net.Listener, error) {
+	return freebindConfig().Listen(context.Background(), "tcp", address)
+}
+
+func freebindConfig() *net.ListenConfig {
+	return &net.ListenConfig{
+		Control: func(network, address string, conn syscall.RawConn) error {
+			var optErr error
+			err := conn.Control(func(fd uintptr) {
+				optErr = syscall.SetsockoptInt(int(fd), syscall.SOL_IP, syscall.IP_FREEBIND, 1)
+			})
+			if err != nil {
+				return err
+			}
+			return optErr
+		},
+	}
+}
@@ -0,0 +1,153 @@
+/*
+ * Copyright (C) 2019 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package dns
+
+import (
+	"encoding/binary"
+	"errors"
+	"time"
+)
+
+const (
+	headerLength = 12
+	// minUDPSize is the size of the UDP responses every client accepts
+	minUDPSize = 512
+	typeOPT    = 41
+)
+
+const (
+	sectionAnswer = iota
+	sectionAuthority
+	sectionAdditional
+)
+
+var errMalformedMessage = errors.New("malformed DNS message")
+
+// resource is the fixed part of the resource record which follows its name
+type resource struct {
+	section   int
+	rrType    uint16
+	class     uint16
+	ttlOffset int
+}
+
+// resources walks the wire format of the message listing the records of answer, authority and additional sections
+func resources(message []byte) ([]resource, error) {
+	if len(message) < headerLength {
+		return nil, errMalformedMessage
+	}
+
+	offset := headerLength
+	var err error
+	for i := 0; i < int(binary.BigEndian.Uint16(message[4:])); i++ {
+		if offset, err = skipName(message, offset); err != nil {
+			return nil, err
+		}
+		// type and class
+		offset += 4
+	}
+
+	var records []resource
+	for section := sectionAnswer; section <= sectionAdditional; section++ {
+		count := int(binary.BigEndian.Uint16(message[6+2*section:]))
+		for i := 0; i < count; i++ {
+			if offset, err = skipName(message, offset); err != nil {
+				return nil, err
+			}
+			// type, class, TTL and data length
+			if offset+10 > len(message) {
+				return nil, errMalformedMessage
+			}
+			records = append(records, resource{
+				section:   section,
+				rrType:    binary.BigEndian.Uint16(message[offset:]),
+				class:     binary.BigEndian.Uint16(message[offset+2:]),
+				ttlOffset: offset + 4,
+			})
+			offset += 10 + int(binary.BigEndian.Uint16(message[offset+8:]))
+		}
+	}
+
+	if offset > len(message) {
+		return nil, errMalformedMessage
+	}
+	return records, nil
+}
+
+func skipName(message []byte, offset int) (int, error) {
+	for offset < len(message) {
+		length := int(message[offset])
+		switch {
+		case length == 0:
+			return offset + 1, nil
+		case length&0xC0 == 0xC0:
+			// compression pointer ends the name
+			return offset + 2, nil
+		default:
+			offset += 1 + length
+		}
+	}
+	return 0, errMalformedMessage
+}
+
+// withTTL copies the cached response decreasing the TTLs of its records by the age of the response,
+// none of the records outlives the cache entry
+func withTTL(response []byte, age, remaining time.Duration) []byte {
+	answer := make([]byte, len(response))
+	copy(answer, response)
+
+	records, err := resources(answer)
+	if err != nil {
+		return answer
+	}
+
+	elapsed, left := uint32(age/time.Second), uint32(remaining/time.Second)
+	for _, record := range records {
+		// TTL of OPT record keeps the extended flags
+		if record.rrType == typeOPT {
+			continue
+		}
+
+		ttl := binary.BigEndian.Uint32(answer[record.ttlOffset:])
+		if ttl > elapsed {
+			ttl -= elapsed
+		} else {
+			ttl = 0
+		}
+		if ttl > left {
+			ttl = left
+		}
+		binary.BigEndian.PutUint32(answer[record.ttlOffset:], ttl)
+	}
+	return answer
+}
+
+// udpSize returns the size of the largest UDP response the client accepts, it is advertised by EDNS
+func udpSize(query []byte) int {
+	records, err := resources(query)
+	if err != nil {
+		return minUDPSize
+	}
+
+	for _, record := range records {
+		if record.section == sectionAdditional && record.rrType == typeOPT && int(record.class) > minUDPSize {
+			return int(record.class)
+		}
+	}
+	return minUDPSize
+}
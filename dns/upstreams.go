@@ -0,0 +1,94 @@
+/*
+ * Copyright (C) 2019 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package dns
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+)
+
+const resolvConfPath = "/etc/resolv.conf"
+
+// fallbackUpstreams are used if the system has no DNS servers configured
+var fallbackUpstreams = []string{"208.67.222.222:53", "208.67.220.220:53"}
+
+// ParseUpstreams parses comma separated addresses of upstream DNS servers, port 53 is used if address has no port
+func ParseUpstreams(value string) ([]string, error) {
+	var upstreams []string
+	for _, upstream := range strings.Split(value, ",") {
+		upstream = strings.TrimSpace(upstream)
+		if upstream == "" {
+			continue
+		}
+
+		if ip := net.ParseIP(strings.Trim(upstream, "[]")); ip != nil {
+			upstreams = append(upstreams, net.JoinHostPort(ip.String(), strconv.Itoa(DefaultPort)))
+			continue
+		}
+
+		host, port, err := net.SplitHostPort(upstream)
+		if err != nil || net.ParseIP(host) == nil {
+			return nil, fmt.Errorf("invalid upstream DNS server: %s", upstream)
+		}
+		if portNum, err := strconv.Atoi(port); err != nil || portNum < 1 || portNum > 65535 {
+			return nil, fmt.Errorf("invalid upstream DNS server port: %s", upstream)
+		}
+		upstreams = append(upstreams, upstream)
+	}
+	return upstreams, nil
+}
+
+// SystemUpstreams returns the DNS servers the system resolves names with
+func SystemUpstreams() []string {
+	file, err := os.Open(resolvConfPath)
+	if err != nil {
+		return fallbackUpstreams
+	}
+	defer file.Close()
+
+	upstreams := parseResolvConf(file)
+	if len(upstreams) == 0 {
+		return fallbackUpstreams
+	}
+	return upstreams
+}
+
+func parseResolvConf(reader io.Reader) []string {
+	var upstreams []string
+
+	scanner := bufio.NewScanner(reader)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 2 || fields[0] != "nameserver" {
+			continue
+		}
+
+		// link-local servers have a zone which can not be dialed without the interface
+		ip := net.ParseIP(fields[1])
+		if ip == nil || ip.IsLinkLocalUnicast() {
+			continue
+		}
+		upstreams = append(upstreams, net.JoinHostPort(ip.String(), strconv.Itoa(DefaultPort)))
+	}
+	return upstreams
+}
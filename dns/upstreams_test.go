@@ -0,0 +1,52 @@
+/*
+ * Copyright (C) 2019 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package dns
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_ParseUpstreams(t *testing.T) {
+	upstreams, err := ParseUpstreams(" 1.1.1.1, 9.9.9.9:5353,2606:4700::1111,[2620:fe::fe]:53,")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"1.1.1.1:53", "9.9.9.9:5353", "[2606:4700::1111]:53", "[2620:fe::fe]:53"}, upstreams)
+
+	upstreams, err = ParseUpstreams("")
+	assert.NoError(t, err)
+	assert.Empty(t, upstreams)
+
+	_, err = ParseUpstreams("dns.example.com")
+	assert.Error(t, err)
+
+	_, err = ParseUpstreams("1.1.1.1:99999")
+	assert.Error(t, err)
+}
+
+func Test_parseResolvConf(t *testing.T) {
+	upstreams := parseResolvConf(strings.NewReader(`
+# generated by resolvconf
+nameserver 127.0.0.53
+nameserver fe80::1%eth0
+nameserver 2001:4860:4860::8888
+search example.com
+`))
+	assert.Equal(t, []string{"127.0.0.53:53", "[2001:4860:4860::8888]:53"}, upstreams)
+}
@@ -20,6 +20,7 @@ package e2e
 import (
 	"flag"
 
+	"github.com/cihub/seelog"
 	"github.com/ethereum/go-ethereum/ethclient"
 	tequilapi_client "github.com/mysteriumnetwork/node/tequilapi/client"
 	"github.com/mysteriumnetwork/payments/cli/helpers"
@@ -43,12 +44,27 @@ var (
 	consumerServices      = flag.String("consumer.services", "openvpn,noop,wireguard", "Comma separated list of services to try and use")
 )
 
+// Tequilapi credentials, nodes which do not require authentication ignore them
+var (
+	tequilapiUsername = flag.String("tequilapi.username", "e2e", "Specify Tequilapi username of the nodes")
+	tequilapiPassword = flag.String("tequilapi.password", "e2e-secret", "Specify Tequilapi password of the nodes")
+)
+
 func newTequilapiConsumer() *tequilapi_client.Client {
-	return tequilapi_client.NewClient(*consumerTequilapiHost, *consumerTequilapiPort)
+	return newTequilapi(*consumerTequilapiHost, *consumerTequilapiPort)
 }
 
 func newTequilapiProvider() *tequilapi_client.Client {
-	return tequilapi_client.NewClient(*providerTequilapiHost, *providerTequilapiPort)
+	return newTequilapi(*providerTequilapiHost, *providerTequilapiPort)
+}
+
+func newTequilapi(host string, port int) *tequilapi_client.Client {
+	client := tequilapi_client.NewClient(host, port)
+	if _, err := client.Login(*tequilapiUsername, *tequilapiPassword); err != nil {
+		// older nodes serve tequilapi without authentication
+		seelog.Warn("Tequilapi login failed, continuing unauthenticated: ", err)
+	}
+	return client
 }
 
 func newEthClient() (*ethclient.Client, error) {
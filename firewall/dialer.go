@@ -0,0 +1,37 @@
+/*
+ * Copyright (C) 2019 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package firewall
+
+import (
+	"net"
+	"time"
+)
+
+// controlMark marks the sockets of the node's own control traffic, kill switch lets it thru while the tunnel is down
+const controlMark = 0x6d797374
+
+// NewDialer returns the dialer of the node's control traffic (discovery, broker and the like),
+// which passes the kill switch so the node is able to reestablish the tunnel or to fail over to another provider.
+// It is meant for the control traffic only, the rest of the traffic should stay restricted to the tunnel.
+func NewDialer(timeout time.Duration) *net.Dialer {
+	return &net.Dialer{
+		Timeout:  timeout,
+		Control:  markControlSocket,
+		Resolver: controlResolver(timeout),
+	}
+}
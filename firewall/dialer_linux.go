@@ -0,0 +1,62 @@
+/*
+ * Copyright (C) 2019 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package firewall
+
+import (
+	"net"
+	"sync"
+	"syscall"
+	"time"
+
+	log "github.com/cihub/seelog"
+)
+
+var markWarning sync.Once
+
+func markControlSocket(_, _ string, conn syscall.RawConn) error {
+	return conn.Control(func(fd uintptr) {
+		// kill switch refuses to be enabled without the marking, so the failure matters only if it is disabled
+		if err := markSocket(int(fd)); err != nil {
+			markWarning.Do(func() {
+				log.Warn(firewallLogPrefix, "Failed to mark control traffic socket, kill switch will not be available: ", err)
+			})
+		}
+	})
+}
+
+// canMarkSockets tells if the node is allowed to mark its control traffic, it needs CAP_NET_ADMIN for that
+func canMarkSockets() error {
+	fd, err := syscall.Socket(syscall.AF_INET, syscall.SOCK_DGRAM, 0)
+	if err != nil {
+		return err
+	}
+	defer syscall.Close(fd)
+
+	return markSocket(fd)
+}
+
+func markSocket(fd int) error {
+	return syscall.SetsockoptInt(fd, syscall.SOL_SOCKET, syscall.SO_MARK, controlMark)
+}
+
+// controlResolver resolves names thru the marked connections, DNS queries of the system resolver
+// are redirected to the tunnel by the kill switch and are not answered while the tunnel is down
+func controlResolver(timeout time.Duration) *net.Resolver {
+	dialer := &net.Dialer{Timeout: timeout, Control: markControlSocket}
+	return &net.Resolver{PreferGo: true, Dial: dialer.DialContext}
+}
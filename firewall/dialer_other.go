@@ -0,0 +1,36 @@
+//+build !linux
+
+/*
+ * Copyright (C) 2019 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package firewall
+
+import (
+	"net"
+	"syscall"
+	"time"
+)
+
+// markControlSocket does nothing, kill switch is implemented for Linux only
+func markControlSocket(_, _ string, _ syscall.RawConn) error {
+	return nil
+}
+
+// controlResolver returns nil, so the default resolver is used
+func controlResolver(_ time.Duration) *net.Resolver {
+	return nil
+}
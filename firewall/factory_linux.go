@@ -17,7 +17,9 @@
 
 package firewall
 
-// NewKillSwitch returns mocked kill switch service
+import "github.com/mysteriumnetwork/node/utils"
+
+// NewKillSwitch returns iptables based kill switch service
 func NewKillSwitch() KillSwitch {
-	return &iptablesKillSwitch{}
+	return newIPTablesKillSwitch(utils.SudoExec, controlMark, canMarkSockets)
 }
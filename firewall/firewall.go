@@ -1,4 +1,4 @@
-//+build !windows
+//+build !windows,!linux
 
 /*
  * Copyright (C) 2019 The "MysteriumNetwork/node" Authors.
@@ -19,6 +19,16 @@
 
 package firewall
 
+// BootstrapInbound prepares the platform specific firewall for the inbound rules.
+func BootstrapInbound() error {
+	return nil
+}
+
+// ShutdownInbound removes all inbound rules added by the node.
+func ShutdownInbound() error {
+	return nil
+}
+
 // AddInboundRule adds new inbound rule to the platform specific firewall.
 func AddInboundRule(proto string, port int) error {
 	// TODO adding firewall rules should be implemented for every platform.
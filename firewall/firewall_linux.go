@@ -0,0 +1,43 @@
+/*
+ * Copyright (C) 2019 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package firewall
+
+import "github.com/mysteriumnetwork/node/utils"
+
+var inbound = newInboundIPTables(utils.SudoExec)
+
+// BootstrapInbound prepares the platform specific firewall for the inbound rules.
+// The rules left by the previous node run are removed.
+func BootstrapInbound() error {
+	return inbound.bootstrap()
+}
+
+// ShutdownInbound removes all inbound rules added by the node.
+func ShutdownInbound() error {
+	return inbound.shutdown()
+}
+
+// AddInboundRule adds new inbound rule to the platform specific firewall.
+func AddInboundRule(proto string, port int) error {
+	return inbound.add(proto, port)
+}
+
+// RemoveInboundRule removes inbound rule from the platform specific firewall.
+func RemoveInboundRule(proto string, port int) error {
+	return inbound.remove(proto, port)
+}
@@ -25,9 +25,15 @@ import (
 	"github.com/mysteriumnetwork/node/utils"
 )
 
-const (
-	firewallLogPrefix = "[firewall] "
-)
+// BootstrapInbound prepares the platform specific firewall for the inbound rules.
+func BootstrapInbound() error {
+	return nil
+}
+
+// ShutdownInbound removes all inbound rules added by the node.
+func ShutdownInbound() error {
+	return nil
+}
 
 // AddInboundRule adds new inbound rule to the platform specific firewall.
 func AddInboundRule(proto string, port int) error {
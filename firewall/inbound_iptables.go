@@ -0,0 +1,152 @@
+/*
+ * Copyright (C) 2019 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package firewall
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"sync"
+
+	log "github.com/cihub/seelog"
+	"github.com/mysteriumnetwork/node/utils"
+)
+
+const (
+	// inboundChain is owned by the node, INPUT chain jumps to it to accept the traffic of the service ports
+	inboundChain = "MYST-INBOUND"
+	inputChain   = "INPUT"
+)
+
+type inboundRule struct {
+	proto string
+	port  int
+}
+
+func (rule inboundRule) arguments() []string {
+	return []string{"--protocol", rule.proto, "--dport", strconv.Itoa(rule.port), "--jump", "ACCEPT"}
+}
+
+type inboundIPTables struct {
+	exec func(args ...string) error
+
+	mu      sync.Mutex
+	started bool
+	rules   map[inboundRule]struct{}
+}
+
+func newInboundIPTables(exec func(args ...string) error) *inboundIPTables {
+	return &inboundIPTables{
+		exec:  exec,
+		rules: make(map[inboundRule]struct{}),
+	}
+}
+
+func (fw *inboundIPTables) bootstrap() error {
+	fw.mu.Lock()
+	defer fw.mu.Unlock()
+
+	return fw.start()
+}
+
+func (fw *inboundIPTables) add(proto string, port int) error {
+	fw.mu.Lock()
+	defer fw.mu.Unlock()
+
+	if err := fw.start(); err != nil {
+		return err
+	}
+
+	rule := inboundRule{proto: strings.ToLower(proto), port: port}
+	if _, ok := fw.rules[rule]; ok {
+		return nil
+	}
+
+	if err := fw.iptables(append([]string{"--append", inboundChain}, rule.arguments()...)...); err != nil {
+		log.Trace(firewallLogPrefix, "Failed to add firewall rule: ", err)
+		return err
+	}
+	fw.rules[rule] = struct{}{}
+
+	log.Info(firewallLogPrefix, "Inbound ", rule.proto, " traffic allowed to port ", rule.port)
+	return nil
+}
+
+func (fw *inboundIPTables) remove(proto string, port int) error {
+	fw.mu.Lock()
+	defer fw.mu.Unlock()
+
+	rule := inboundRule{proto: strings.ToLower(proto), port: port}
+	if _, ok := fw.rules[rule]; !ok {
+		return errors.New("firewall rule not found")
+	}
+
+	if err := fw.iptables(append([]string{"--delete", inboundChain}, rule.arguments()...)...); err != nil {
+		log.Trace(firewallLogPrefix, "Failed to remove firewall rule: ", err)
+		return err
+	}
+	delete(fw.rules, rule)
+
+	log.Info(firewallLogPrefix, "Inbound ", rule.proto, " traffic disallowed to port ", rule.port)
+	return nil
+}
+
+func (fw *inboundIPTables) shutdown() error {
+	fw.mu.Lock()
+	defer fw.mu.Unlock()
+
+	if !fw.started {
+		return nil
+	}
+
+	errs := utils.ErrorCollection{}
+	errs.Add(
+		fw.iptables("--delete", inputChain, "--jump", inboundChain),
+		fw.iptables("--flush", inboundChain),
+		fw.iptables("--delete-chain", inboundChain),
+	)
+	fw.started = false
+	fw.rules = make(map[inboundRule]struct{})
+
+	return errs.Errorf("failed to remove inbound firewall chain: %s", ", ")
+}
+
+// start creates the chain of the node or flushes the one left by the previous run, since its services are gone
+func (fw *inboundIPTables) start() error {
+	if fw.started {
+		return nil
+	}
+
+	if err := fw.iptables("--new-chain", inboundChain); err != nil {
+		if err := fw.iptables("--flush", inboundChain); err != nil {
+			return err
+		}
+	}
+	if err := fw.iptables("--check", inputChain, "--jump", inboundChain); err != nil {
+		if err := fw.iptables("--insert", inputChain, "--jump", inboundChain); err != nil {
+			return err
+		}
+	}
+
+	fw.started = true
+	return nil
+}
+
+func (fw *inboundIPTables) iptables(args ...string) error {
+	return fw.exec(append([]string{"/sbin/iptables"}, args...)...)
+}
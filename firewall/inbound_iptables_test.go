@@ -0,0 +1,130 @@
+/*
+ * Copyright (C) 2019 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package firewall
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type execFake struct {
+	commands []string
+	failing  map[string]bool
+}
+
+func newExecFake() *execFake {
+	return &execFake{failing: make(map[string]bool)}
+}
+
+func (ef *execFake) exec(args ...string) error {
+	command := strings.Join(args, " ")
+	ef.commands = append(ef.commands, command)
+	if ef.failing[command] {
+		return errors.New("iptables failed")
+	}
+	return nil
+}
+
+func Test_inboundIPTables_AddCreatesChainOnce(t *testing.T) {
+	executor := newExecFake()
+	executor.failing["/sbin/iptables --check INPUT --jump MYST-INBOUND"] = true
+	fw := newInboundIPTables(executor.exec)
+
+	assert.NoError(t, fw.add("UDP", 1194))
+	assert.NoError(t, fw.add("udp", 1194))
+	assert.NoError(t, fw.add("tcp", 443))
+
+	assert.Equal(
+		t,
+		[]string{
+			"/sbin/iptables --new-chain MYST-INBOUND",
+			"/sbin/iptables --check INPUT --jump MYST-INBOUND",
+			"/sbin/iptables --insert INPUT --jump MYST-INBOUND",
+			"/sbin/iptables --append MYST-INBOUND --protocol udp --dport 1194 --jump ACCEPT",
+			"/sbin/iptables --append MYST-INBOUND --protocol tcp --dport 443 --jump ACCEPT",
+		},
+		executor.commands,
+	)
+}
+
+func Test_inboundIPTables_BootstrapReconcilesLeftChain(t *testing.T) {
+	executor := newExecFake()
+	executor.failing["/sbin/iptables --new-chain MYST-INBOUND"] = true
+	fw := newInboundIPTables(executor.exec)
+
+	assert.NoError(t, fw.bootstrap())
+
+	assert.Equal(
+		t,
+		[]string{
+			"/sbin/iptables --new-chain MYST-INBOUND",
+			"/sbin/iptables --flush MYST-INBOUND",
+			"/sbin/iptables --check INPUT --jump MYST-INBOUND",
+		},
+		executor.commands,
+	)
+}
+
+func Test_inboundIPTables_BootstrapFailsWithoutChain(t *testing.T) {
+	executor := newExecFake()
+	executor.failing["/sbin/iptables --new-chain MYST-INBOUND"] = true
+	executor.failing["/sbin/iptables --flush MYST-INBOUND"] = true
+	fw := newInboundIPTables(executor.exec)
+
+	assert.Error(t, fw.bootstrap())
+	assert.Error(t, fw.add("udp", 1194))
+	assert.False(t, fw.started)
+}
+
+func Test_inboundIPTables_Remove(t *testing.T) {
+	executor := newExecFake()
+	fw := newInboundIPTables(executor.exec)
+	assert.NoError(t, fw.add("udp", 52820))
+	executor.commands = nil
+
+	assert.NoError(t, fw.remove("UDP", 52820))
+	assert.EqualError(t, fw.remove("udp", 52820), "firewall rule not found")
+
+	assert.Equal(t, []string{"/sbin/iptables --delete MYST-INBOUND --protocol udp --dport 52820 --jump ACCEPT"}, executor.commands)
+}
+
+func Test_inboundIPTables_ShutdownRemovesChain(t *testing.T) {
+	executor := newExecFake()
+	fw := newInboundIPTables(executor.exec)
+
+	assert.NoError(t, fw.shutdown())
+	assert.Empty(t, executor.commands)
+
+	assert.NoError(t, fw.add("udp", 52820))
+	executor.commands = nil
+
+	assert.NoError(t, fw.shutdown())
+	assert.Equal(
+		t,
+		[]string{
+			"/sbin/iptables --delete INPUT --jump MYST-INBOUND",
+			"/sbin/iptables --flush MYST-INBOUND",
+			"/sbin/iptables --delete-chain MYST-INBOUND",
+		},
+		executor.commands,
+	)
+	assert.Empty(t, fw.rules)
+}
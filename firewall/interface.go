@@ -17,8 +17,37 @@
 
 package firewall
 
+import (
+	"errors"
+	"net"
+)
+
+const firewallLogPrefix = "[firewall] "
+
+// ErrKillSwitchNotSupported indicates that kill switch is not implemented for the platform
+var ErrKillSwitchNotSupported = errors.New("kill switch is not supported on this platform")
+
 // KillSwitch enables fw rules restricting all communication except via VPN
 type KillSwitch interface {
-	Enable()
-	Disable()
+	// Enable restricts the traffic to the given tunnels, calling it again replaces the previous ones.
+	// DNS queries are redirected to the DNS servers of the first tunnel which has them.
+	Enable(tunnels ...Tunnel) error
+	Disable() error
+	Enabled() bool
+}
+
+// Tunnel describes the VPN tunnel which kill switch allows the traffic thru
+type Tunnel struct {
+	// Interface is the exact name of the tunnel interface, it is empty until the interface is known
+	Interface string
+	// Endpoint, Port and Protocol describe where the tunnel is established to
+	Endpoint net.IP
+	Port     int
+	Protocol string
+	// DNS servers reachable thru the tunnel, DNS queries are redirected to the first one of each IP family
+	DNS []net.IP
+	// Include restricts the traffic of the given networks only, all the traffic is restricted if empty
+	Include []net.IPNet
+	// Exclude are the networks routed around the tunnel, their traffic is let thru
+	Exclude []net.IPNet
 }
@@ -20,10 +20,17 @@ package firewall
 type fakeKillSwitch struct {
 }
 
-// Enable enables kill switch mock
-func (ks *fakeKillSwitch) Enable() {
+// Enable reports that kill switch is not supported
+func (ks *fakeKillSwitch) Enable(_ ...Tunnel) error {
+	return ErrKillSwitchNotSupported
 }
 
-// Enable disables kill switch mock
-func (ks *fakeKillSwitch) Disable() {
+// Disable disables kill switch mock
+func (ks *fakeKillSwitch) Disable() error {
+	return nil
+}
+
+// Enabled is always false since kill switch is not supported
+func (ks *fakeKillSwitch) Enabled() bool {
+	return false
 }
@@ -17,13 +17,225 @@
 
 package firewall
 
+import (
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+
+	log "github.com/cihub/seelog"
+	"github.com/mysteriumnetwork/node/utils"
+	"github.com/pkg/errors"
+)
+
+const (
+	// killSwitchChain is owned by the node, OUTPUT chain jumps to it to drop the traffic leaving outside of the tunnel
+	killSwitchChain = "MYST-KILLSWITCH"
+	// killSwitchDNSChain redirects DNS queries to the DNS servers reachable thru the tunnel
+	killSwitchDNSChain = "MYST-KILLSWITCH-DNS"
+	outputChain        = "OUTPUT"
+)
+
 type iptablesKillSwitch struct {
+	exec func(args ...string) error
+	// mark of the node's control traffic, it is exempted to be able to reestablish the tunnel
+	mark int
+	// canMark tells if the node is allowed to mark its control traffic
+	canMark func() error
+
+	mu      sync.Mutex
+	enabled bool
+}
+
+func newIPTablesKillSwitch(exec func(args ...string) error, mark int, canMark func() error) *iptablesKillSwitch {
+	return &iptablesKillSwitch{exec: exec, mark: mark, canMark: canMark}
+}
+
+// Enable restricts all the outgoing traffic to the given tunnels
+func (ks *iptablesKillSwitch) Enable(tunnels ...Tunnel) error {
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+
+	// unmarked control traffic would be dropped, so the node could neither reconnect nor fail over
+	if err := ks.canMark(); err != nil {
+		return errors.Wrap(err, "kill switch needs CAP_NET_ADMIN to let the control traffic of the node thru")
+	}
+
+	// rules stay in place on failure until disabled, so no traffic leaks outside of the tunnels
+	ks.enabled = true
+	for _, family := range []ipFamily{ipv4, ipv6} {
+		if err := ks.enable(family, tunnels); err != nil {
+			return err
+		}
+	}
+
+	log.Info(firewallLogPrefix, "Kill switch enabled for tunnels: ", len(tunnels))
+	return nil
 }
 
-// Enable enables kill switch mock
-func (ks *iptablesKillSwitch) Enable() {
+// Disable removes the kill switch rules
+func (ks *iptablesKillSwitch) Disable() error {
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+
+	if !ks.enabled {
+		return nil
+	}
+
+	errs := utils.ErrorCollection{}
+	for _, family := range []ipFamily{ipv4, ipv6} {
+		errs.Add(
+			ks.iptables(family, "--delete", outputChain, "--jump", killSwitchChain),
+			ks.iptables(family, "--flush", killSwitchChain),
+			ks.iptables(family, "--delete-chain", killSwitchChain),
+			ks.iptables(family, "--table", "nat", "--delete", outputChain, "--jump", killSwitchDNSChain),
+			ks.iptables(family, "--table", "nat", "--flush", killSwitchDNSChain),
+			ks.iptables(family, "--table", "nat", "--delete-chain", killSwitchDNSChain),
+		)
+	}
+	ks.enabled = false
+
+	if err := errs.Errorf("failed to disable kill switch: %s", ", "); err != nil {
+		return err
+	}
+	log.Info(firewallLogPrefix, "Kill switch disabled")
+	return nil
 }
 
-// Enable disables kill switch mock
-func (ks *iptablesKillSwitch) Disable() {
+// Enabled tells if the kill switch rules are in place
+func (ks *iptablesKillSwitch) Enabled() bool {
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+
+	return ks.enabled
+}
+
+func (ks *iptablesKillSwitch) enable(family ipFamily, tunnels []Tunnel) error {
+	if err := ks.startChain(family, "filter", killSwitchChain); err != nil {
+		return err
+	}
+	for _, rule := range ks.filterRules(family, tunnels) {
+		if err := ks.iptables(family, append([]string{"--append", killSwitchChain}, rule...)...); err != nil {
+			// fail closed, the traffic should not leak even if the tunnel is allowed only partially
+			if err := ks.iptables(family, "--append", killSwitchChain, "--jump", "DROP"); err != nil {
+				log.Error(firewallLogPrefix, "Failed to drop the traffic outside of the tunnel: ", err)
+			}
+			return err
+		}
+	}
+
+	if err := ks.startChain(family, "nat", killSwitchDNSChain); err != nil {
+		return err
+	}
+	for _, rule := range ks.dnsRules(family, tunnels) {
+		if err := ks.iptables(family, append([]string{"--table", "nat", "--append", killSwitchDNSChain}, rule...)...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (ks *iptablesKillSwitch) filterRules(family ipFamily, tunnels []Tunnel) [][]string {
+	rules := [][]string{{"--out-interface", "lo", "--jump", "ACCEPT"}}
+	interfaces := make(map[string]bool)
+	for _, tunnel := range tunnels {
+		if tunnel.Interface != "" && !interfaces[tunnel.Interface] {
+			interfaces[tunnel.Interface] = true
+			rules = append(rules, []string{"--out-interface", tunnel.Interface, "--jump", "ACCEPT"})
+		}
+	}
+	for _, tunnel := range tunnels {
+		if family.matches(tunnel.Endpoint) {
+			rules = append(rules, []string{
+				"--destination", tunnel.Endpoint.String(),
+				"--protocol", strings.ToLower(tunnel.Protocol),
+				"--dport", strconv.Itoa(tunnel.Port),
+				"--jump", "ACCEPT",
+			})
+		}
+	}
+	rules = append(rules, []string{"--match", "mark", "--mark", ks.markString(), "--jump", "ACCEPT"})
+	for _, tunnel := range tunnels {
+		for _, network := range tunnel.Exclude {
+			if family.matches(network.IP) {
+				rules = append(rules, []string{"--destination", network.String(), "--jump", "ACCEPT"})
+			}
+		}
+	}
+
+	for _, tunnel := range tunnels {
+		if len(tunnel.Include) == 0 {
+			return append(rules, []string{"--jump", "DROP"})
+		}
+	}
+	// split tunnels restrict only the traffic of the included networks, the rest is left to the OUTPUT chain
+	for _, tunnel := range tunnels {
+		for _, network := range tunnel.Include {
+			if family.matches(network.IP) {
+				rules = append(rules, []string{"--destination", network.String(), "--jump", "DROP"})
+			}
+		}
+	}
+	return rules
+}
+
+func (ks *iptablesKillSwitch) dnsRules(family ipFamily, tunnels []Tunnel) [][]string {
+	var dns net.IP
+	for _, tunnel := range tunnels {
+		for _, server := range tunnel.DNS {
+			if family.matches(server) {
+				dns = server
+				break
+			}
+		}
+		if dns != nil {
+			break
+		}
+	}
+	if dns == nil {
+		return nil
+	}
+
+	return [][]string{
+		{"--destination", family.loopback, "--jump", "RETURN"},
+		{"--match", "mark", "--mark", ks.markString(), "--jump", "RETURN"},
+		{"--protocol", "udp", "--dport", "53", "--jump", "DNAT", "--to-destination", dns.String()},
+		{"--protocol", "tcp", "--dport", "53", "--jump", "DNAT", "--to-destination", dns.String()},
+	}
+}
+
+func (ks *iptablesKillSwitch) markString() string {
+	return "0x" + strconv.FormatInt(int64(ks.mark), 16)
+}
+
+// startChain creates the chain or flushes the existing one and makes sure that OUTPUT chain jumps to it
+func (ks *iptablesKillSwitch) startChain(family ipFamily, table, chain string) error {
+	if err := ks.iptables(family, "--table", table, "--new-chain", chain); err != nil {
+		if err := ks.iptables(family, "--table", table, "--flush", chain); err != nil {
+			return err
+		}
+	}
+	if err := ks.iptables(family, "--table", table, "--check", outputChain, "--jump", chain); err != nil {
+		return ks.iptables(family, "--table", table, "--insert", outputChain, "--jump", chain)
+	}
+	return nil
+}
+
+func (ks *iptablesKillSwitch) iptables(family ipFamily, args ...string) error {
+	return ks.exec(append([]string{family.command}, args...)...)
+}
+
+type ipFamily struct {
+	command  string
+	loopback string
+	ipv6     bool
+}
+
+var (
+	ipv4 = ipFamily{command: "/sbin/iptables", loopback: "127.0.0.0/8"}
+	ipv6 = ipFamily{command: "/sbin/ip6tables", loopback: "::1/128", ipv6: true}
+)
+
+func (family ipFamily) matches(ip net.IP) bool {
+	return ip != nil && (ip.To4() == nil) == family.ipv6
 }
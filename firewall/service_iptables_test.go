@@ -0,0 +1,204 @@
+/*
+ * Copyright (C) 2019 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package firewall
+
+import (
+	"errors"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func canMarkStub() error {
+	return nil
+}
+
+var tunnelStub = Tunnel{
+	Interface: "myst0",
+	Endpoint:  net.ParseIP("1.2.3.4"),
+	Port:      52820,
+	Protocol:  "UDP",
+	DNS:       []net.IP{net.ParseIP("10.182.0.1")},
+}
+
+func Test_iptablesKillSwitch_Enable(t *testing.T) {
+	executor := newExecFake()
+	executor.failing["/sbin/iptables --table filter --check OUTPUT --jump MYST-KILLSWITCH"] = true
+	executor.failing["/sbin/iptables --table nat --new-chain MYST-KILLSWITCH-DNS"] = true
+	ks := newIPTablesKillSwitch(executor.exec, 0x6d797374, canMarkStub)
+
+	assert.NoError(t, ks.Enable(tunnelStub))
+	assert.True(t, ks.Enabled())
+
+	assert.Equal(
+		t,
+		[]string{
+			"/sbin/iptables --table filter --new-chain MYST-KILLSWITCH",
+			"/sbin/iptables --table filter --check OUTPUT --jump MYST-KILLSWITCH",
+			"/sbin/iptables --table filter --insert OUTPUT --jump MYST-KILLSWITCH",
+			"/sbin/iptables --append MYST-KILLSWITCH --out-interface lo --jump ACCEPT",
+			"/sbin/iptables --append MYST-KILLSWITCH --out-interface myst0 --jump ACCEPT",
+			"/sbin/iptables --append MYST-KILLSWITCH --destination 1.2.3.4 --protocol udp --dport 52820 --jump ACCEPT",
+			"/sbin/iptables --append MYST-KILLSWITCH --match mark --mark 0x6d797374 --jump ACCEPT",
+			"/sbin/iptables --append MYST-KILLSWITCH --jump DROP",
+			"/sbin/iptables --table nat --new-chain MYST-KILLSWITCH-DNS",
+			"/sbin/iptables --table nat --flush MYST-KILLSWITCH-DNS",
+			"/sbin/iptables --table nat --check OUTPUT --jump MYST-KILLSWITCH-DNS",
+			"/sbin/iptables --table nat --append MYST-KILLSWITCH-DNS --destination 127.0.0.0/8 --jump RETURN",
+			"/sbin/iptables --table nat --append MYST-KILLSWITCH-DNS --match mark --mark 0x6d797374 --jump RETURN",
+			"/sbin/iptables --table nat --append MYST-KILLSWITCH-DNS --protocol udp --dport 53 --jump DNAT --to-destination 10.182.0.1",
+			"/sbin/iptables --table nat --append MYST-KILLSWITCH-DNS --protocol tcp --dport 53 --jump DNAT --to-destination 10.182.0.1",
+			"/sbin/ip6tables --table filter --new-chain MYST-KILLSWITCH",
+			"/sbin/ip6tables --table filter --check OUTPUT --jump MYST-KILLSWITCH",
+			"/sbin/ip6tables --append MYST-KILLSWITCH --out-interface lo --jump ACCEPT",
+			"/sbin/ip6tables --append MYST-KILLSWITCH --out-interface myst0 --jump ACCEPT",
+			"/sbin/ip6tables --append MYST-KILLSWITCH --match mark --mark 0x6d797374 --jump ACCEPT",
+			"/sbin/ip6tables --append MYST-KILLSWITCH --jump DROP",
+			"/sbin/ip6tables --table nat --new-chain MYST-KILLSWITCH-DNS",
+			"/sbin/ip6tables --table nat --check OUTPUT --jump MYST-KILLSWITCH-DNS",
+		},
+		executor.commands,
+	)
+}
+
+func Test_iptablesKillSwitch_EnableFailsWhenControlTrafficCanNotBeMarked(t *testing.T) {
+	executor := newExecFake()
+	ks := newIPTablesKillSwitch(executor.exec, 0x6d797374, func() error {
+		return errors.New("operation not permitted")
+	})
+
+	assert.Error(t, ks.Enable(tunnelStub))
+	assert.False(t, ks.Enabled())
+	assert.Empty(t, executor.commands)
+}
+
+func Test_iptablesKillSwitch_EnableSkipsUnknownInterface(t *testing.T) {
+	executor := newExecFake()
+	ks := newIPTablesKillSwitch(executor.exec, 0x6d797374, canMarkStub)
+
+	tunnel := tunnelStub
+	tunnel.Interface = ""
+	assert.NoError(t, ks.Enable(tunnel))
+
+	assert.NotContains(t, executor.commands, "/sbin/iptables --append MYST-KILLSWITCH --out-interface  --jump ACCEPT")
+	assert.Contains(t, executor.commands, "/sbin/iptables --append MYST-KILLSWITCH --destination 1.2.3.4 --protocol udp --dport 52820 --jump ACCEPT")
+}
+
+func Test_iptablesKillSwitch_EnableFailsClosed(t *testing.T) {
+	executor := newExecFake()
+	executor.failing["/sbin/iptables --append MYST-KILLSWITCH --destination 1.2.3.4 --protocol udp --dport 52820 --jump ACCEPT"] = true
+	ks := newIPTablesKillSwitch(executor.exec, 0x6d797374, canMarkStub)
+
+	assert.Error(t, ks.Enable(tunnelStub))
+	assert.True(t, ks.Enabled())
+	assert.Equal(t, "/sbin/iptables --append MYST-KILLSWITCH --jump DROP", executor.commands[len(executor.commands)-1])
+}
+
+func Test_iptablesKillSwitch_Disable(t *testing.T) {
+	executor := newExecFake()
+	ks := newIPTablesKillSwitch(executor.exec, 0x6d797374, canMarkStub)
+
+	assert.NoError(t, ks.Disable())
+	assert.Empty(t, executor.commands)
+
+	assert.NoError(t, ks.Enable(tunnelStub))
+	executor.commands = nil
+
+	assert.NoError(t, ks.Disable())
+	assert.False(t, ks.Enabled())
+	assert.Equal(
+		t,
+		[]string{
+			"/sbin/iptables --delete OUTPUT --jump MYST-KILLSWITCH",
+			"/sbin/iptables --flush MYST-KILLSWITCH",
+			"/sbin/iptables --delete-chain MYST-KILLSWITCH",
+			"/sbin/iptables --table nat --delete OUTPUT --jump MYST-KILLSWITCH-DNS",
+			"/sbin/iptables --table nat --flush MYST-KILLSWITCH-DNS",
+			"/sbin/iptables --table nat --delete-chain MYST-KILLSWITCH-DNS",
+			"/sbin/ip6tables --delete OUTPUT --jump MYST-KILLSWITCH",
+			"/sbin/ip6tables --flush MYST-KILLSWITCH",
+			"/sbin/ip6tables --delete-chain MYST-KILLSWITCH",
+			"/sbin/ip6tables --table nat --delete OUTPUT --jump MYST-KILLSWITCH-DNS",
+			"/sbin/ip6tables --table nat --flush MYST-KILLSWITCH-DNS",
+			"/sbin/ip6tables --table nat --delete-chain MYST-KILLSWITCH-DNS",
+		},
+		executor.commands,
+	)
+}
+
+func Test_iptablesKillSwitch_EnableUsesIPv6Endpoint(t *testing.T) {
+	executor := newExecFake()
+	ks := newIPTablesKillSwitch(executor.exec, 0x6d797374, canMarkStub)
+
+	tunnel := tunnelStub
+	tunnel.Endpoint = net.ParseIP("2001:db8::1")
+	tunnel.DNS = []net.IP{net.ParseIP("fd00::1")}
+	assert.NoError(t, ks.Enable(tunnel))
+
+	assert.Contains(t, executor.commands, "/sbin/ip6tables --append MYST-KILLSWITCH --destination 2001:db8::1 --protocol udp --dport 52820 --jump ACCEPT")
+	assert.Contains(t, executor.commands, "/sbin/ip6tables --table nat --append MYST-KILLSWITCH-DNS --protocol udp --dport 53 --jump DNAT --to-destination fd00::1")
+	assert.NotContains(t, executor.commands, "/sbin/iptables --append MYST-KILLSWITCH --destination 2001:db8::1 --protocol udp --dport 52820 --jump ACCEPT")
+}
+
+func Test_iptablesKillSwitch_EnableSplitTunnel(t *testing.T) {
+	executor := newExecFake()
+	ks := newIPTablesKillSwitch(executor.exec, 0x6d797374, canMarkStub)
+
+	_, included, _ := net.ParseCIDR("10.8.0.0/16")
+	_, excluded, _ := net.ParseCIDR("192.168.1.0/24")
+	tunnel := tunnelStub
+	tunnel.Include = []net.IPNet{*included}
+	tunnel.Exclude = []net.IPNet{*excluded}
+	assert.NoError(t, ks.Enable(tunnel))
+
+	assert.Contains(t, executor.commands, "/sbin/iptables --append MYST-KILLSWITCH --destination 192.168.1.0/24 --jump ACCEPT")
+	assert.Contains(t, executor.commands, "/sbin/iptables --append MYST-KILLSWITCH --destination 10.8.0.0/16 --jump DROP")
+	assert.NotContains(t, executor.commands, "/sbin/iptables --append MYST-KILLSWITCH --jump DROP")
+	assert.NotContains(t, executor.commands, "/sbin/ip6tables --append MYST-KILLSWITCH --destination 10.8.0.0/16 --jump DROP")
+}
+
+func Test_iptablesKillSwitch_EnableSeveralTunnels(t *testing.T) {
+	executor := newExecFake()
+	ks := newIPTablesKillSwitch(executor.exec, 0x6d797374, canMarkStub)
+
+	_, included, _ := net.ParseCIDR("10.8.0.0/16")
+	pooled := Tunnel{
+		Interface: "myst0",
+		Endpoint:  net.ParseIP("5.6.7.8"),
+		Port:      52821,
+		Protocol:  "udp",
+		DNS:       []net.IP{net.ParseIP("10.182.1.1")},
+		Include:   []net.IPNet{*included},
+	}
+	assert.NoError(t, ks.Enable(tunnelStub, pooled))
+
+	assert.Contains(t, executor.commands, "/sbin/iptables --append MYST-KILLSWITCH --destination 1.2.3.4 --protocol udp --dport 52820 --jump ACCEPT")
+	assert.Contains(t, executor.commands, "/sbin/iptables --append MYST-KILLSWITCH --destination 5.6.7.8 --protocol udp --dport 52821 --jump ACCEPT")
+	assert.Contains(t, executor.commands, "/sbin/iptables --append MYST-KILLSWITCH --jump DROP")
+	assert.Contains(t, executor.commands, "/sbin/iptables --table nat --append MYST-KILLSWITCH-DNS --protocol udp --dport 53 --jump DNAT --to-destination 10.182.0.1")
+	assert.NotContains(t, executor.commands, "/sbin/iptables --table nat --append MYST-KILLSWITCH-DNS --protocol udp --dport 53 --jump DNAT --to-destination 10.182.1.1")
+
+	interfaceRules := 0
+	for _, command := range executor.commands {
+		if command == "/sbin/iptables --append MYST-KILLSWITCH --out-interface myst0 --jump ACCEPT" {
+			interfaceRules++
+		}
+	}
+	assert.Equal(t, 1, interfaceRules)
+}
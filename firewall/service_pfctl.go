@@ -20,10 +20,17 @@ package firewall
 type pfCtlKillSwitch struct {
 }
 
-// Enable enables kill switch mock
-func (ks *pfCtlKillSwitch) Enable() {
+// Enable reports that kill switch is not supported
+func (ks *pfCtlKillSwitch) Enable(_ ...Tunnel) error {
+	return ErrKillSwitchNotSupported
 }
 
-// Enable disables kill switch mock
-func (ks *pfCtlKillSwitch) Disable() {
+// Disable disables kill switch mock
+func (ks *pfCtlKillSwitch) Disable() error {
+	return nil
+}
+
+// Enabled is always false since kill switch is not supported
+func (ks *pfCtlKillSwitch) Enabled() bool {
+	return false
 }
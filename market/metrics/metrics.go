@@ -21,6 +21,7 @@ import (
 	"encoding/json"
 
 	log "github.com/cihub/seelog"
+	"github.com/mysteriumnetwork/node/market"
 )
 
 const mysteriumMetricsLogPrefix = "[Mysterium.metrics] "
@@ -35,6 +36,45 @@ type ServiceMetricsResponse struct {
 	Connects []json.RawMessage `json:"connects"`
 }
 
+// ConnectCount represents the connection attempts to the proposal measured by the quality oracle
+type ConnectCount struct {
+	Success int `json:"success"`
+	Fail    int `json:"fail"`
+	Timeout int `json:"timeout"`
+}
+
+// SuccessRate returns the share of successful connection attempts, zero if there were no attempts
+func (count ConnectCount) SuccessRate() float64 {
+	total := count.Success + count.Fail + count.Timeout
+	if total == 0 {
+		return 0
+	}
+	return float64(count.Success) / float64(total)
+}
+
+// ParseConnectCounts parses JSON metrics messages to the connect counts of the proposals.
+// Connect counts are keyed by the provider and service type of the proposal, messages failing to parse are skipped.
+func ParseConnectCounts(msgs []json.RawMessage) map[market.ProposalID]ConnectCount {
+	counts := make(map[market.ProposalID]ConnectCount, len(msgs))
+	for _, msg := range msgs {
+		var metrics struct {
+			ProposalID struct {
+				ProviderID  string `json:"providerID"`
+				ServiceType string `json:"serviceType"`
+			} `json:"proposalID"`
+			ConnectCount ConnectCount `json:"connectCount"`
+		}
+		if err := json.Unmarshal(msg, &metrics); err != nil {
+			log.Warn(mysteriumMetricsLogPrefix, "Failed to parse connect count")
+			continue
+		}
+
+		id := market.ProposalID{ProviderID: metrics.ProposalID.ProviderID, ServiceType: metrics.ProposalID.ServiceType}
+		counts[id] = metrics.ConnectCount
+	}
+	return counts
+}
+
 // Parse parses JSON metrics message to the proposal, and return JSON with metrics only
 func Parse(msg json.RawMessage, proposal interface{}) ([]byte, error) {
 	var metrics struct {
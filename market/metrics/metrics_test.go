@@ -0,0 +1,46 @@
+/*
+ * Copyright (C) 2019 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package metrics
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/mysteriumnetwork/node/market"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_ConnectCount_SuccessRate(t *testing.T) {
+	assert.Equal(t, 0.0, ConnectCount{}.SuccessRate())
+	assert.Equal(t, 0.5, ConnectCount{Success: 5, Fail: 3, Timeout: 2}.SuccessRate())
+	assert.Equal(t, 1.0, ConnectCount{Success: 1}.SuccessRate())
+}
+
+func Test_ParseConnectCounts(t *testing.T) {
+	counts := ParseConnectCounts([]json.RawMessage{
+		json.RawMessage(`{
+			"proposalID": {"providerID": "0x1", "serviceType": "openvpn"},
+			"connectCount": {"success": 5, "fail": 3, "timeout": 2}
+		}`),
+		json.RawMessage(`not a json`),
+	})
+
+	assert.Equal(t, map[market.ProposalID]ConnectCount{
+		{ProviderID: "0x1", ServiceType: "openvpn"}: {Success: 5, Fail: 3, Timeout: 2},
+	}, counts)
+}
@@ -20,6 +20,7 @@ package mysterium
 import (
 	"fmt"
 	"net/url"
+	"strconv"
 	"time"
 
 	log "github.com/cihub/seelog"
@@ -42,7 +43,8 @@ type MysteriumAPI struct {
 // NewClient creates Mysterium centralized api instance with real communication
 func NewClient(discoveryAPIAddress string) *MysteriumAPI {
 	return &MysteriumAPI{
-		requests.NewHTTPClient(1 * time.Minute),
+		// discovery is reached while the tunnel is down, so the node is able to fail over to another provider
+		requests.NewControlHTTPClient(1 * time.Minute),
 		discoveryAPIAddress,
 	}
 }
@@ -182,8 +184,35 @@ func (mApi *MysteriumAPI) FindProposals(filter market.ProposalFilter) ([]market.
 	if filter.AccessPolicySource != "" {
 		query.Set("access_policy[source]", filter.AccessPolicySource)
 	}
+	if filter.LocationCountry != "" {
+		query.Set("location_country", filter.LocationCountry)
+	}
+	if filter.LocationContinent != "" {
+		query.Set("location_continent", filter.LocationContinent)
+	}
+	if filter.LocationISP != "" {
+		query.Set("location_isp", filter.LocationISP)
+	}
+	if filter.LocationASN != 0 {
+		query.Set("location_asn", strconv.Itoa(filter.LocationASN))
+	}
+	if filter.LocationNodeType != "" {
+		query.Set("location_node_type", filter.LocationNodeType)
+	}
+	if filter.PaymentMethodType != "" {
+		query.Set("payment_method_type", filter.PaymentMethodType)
+	}
+	if filter.PriceMax != 0 {
+		query.Set("price_max", strconv.FormatUint(filter.PriceMax, 10))
+	}
 
-	return mApi.doProposalRequest(query)
+	proposals, err := mApi.doProposalRequest(query)
+	if err != nil {
+		return nil, err
+	}
+
+	// discovery might not support some of the conditions, so proposals are filtered locally as well
+	return matchingProposalsOnly(proposals, filter), nil
 }
 
 func (mApi *MysteriumAPI) doProposalRequest(query url.Values) ([]market.ServiceProposal, error) {
@@ -219,6 +248,15 @@ func (mApi *MysteriumAPI) SendSessionStats(sessionID session.ID, sessionStats Se
 	return nil
 }
 
+func matchingProposalsOnly(proposals []market.ServiceProposal, filter market.ProposalFilter) (matching []market.ServiceProposal) {
+	for _, proposal := range proposals {
+		if filter.Matches(proposal) {
+			matching = append(matching, proposal)
+		}
+	}
+	return
+}
+
 func supportedProposalsOnly(proposals []market.ServiceProposal) (supported []market.ServiceProposal) {
 	for _, proposal := range proposals {
 		if proposal.IsSupported() {
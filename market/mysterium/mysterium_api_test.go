@@ -18,11 +18,14 @@
 package mysterium
 
 import (
+	"encoding/json"
 	"net"
 	"net/http"
+	"net/url"
 	"testing"
 	"time"
 
+	"github.com/mysteriumnetwork/node/market"
 	"github.com/mysteriumnetwork/node/requests"
 	"github.com/stretchr/testify/assert"
 )
@@ -55,6 +58,76 @@ func TestHttpTransportDoesntBlockForeverIfServerFailsToSendAnyResponse(t *testin
 	}
 }
 
+type mockServiceDefinition struct {
+	Location market.Location `json:"location"`
+}
+
+func (service mockServiceDefinition) GetLocation() market.Location {
+	return service.Location
+}
+
+func init() {
+	market.RegisterServiceDefinitionUnserializer("mock_service", func(rawDefinition *json.RawMessage) (market.ServiceDefinition, error) {
+		var definition mockServiceDefinition
+		err := json.Unmarshal(*rawDefinition, &definition)
+		return definition, err
+	})
+	market.RegisterPaymentMethodUnserializer(market.PaymentMethodPerGB, market.UnserializePaymentPerGB)
+	market.RegisterContactUnserializer("mock_contact", func(rawMessage *json.RawMessage) (market.ContactDefinition, error) {
+		return struct{}{}, nil
+	})
+}
+
+func TestFindProposalsFiltersLocally(t *testing.T) {
+	var query url.Values
+	address, err := createHTTPServer(func(writer http.ResponseWriter, request *http.Request) {
+		query = request.URL.Query()
+		writer.Write([]byte(`{"proposals": [
+			{
+				"provider_id": "0x1",
+				"service_type": "mock_service",
+				"service_definition": {"location": {"country": "LT", "node_type": "residential"}},
+				"payment_method_type": "PER_GB",
+				"payment_method": {"price": {"amount": 100, "currency": "MYST"}},
+				"provider_contacts": [{"type": "mock_contact", "definition": {}}]
+			},
+			{
+				"provider_id": "0x2",
+				"service_type": "mock_service",
+				"service_definition": {"location": {"country": "NL", "node_type": "residential"}},
+				"payment_method_type": "PER_GB",
+				"payment_method": {"price": {"amount": 100, "currency": "MYST"}},
+				"provider_contacts": [{"type": "mock_contact", "definition": {}}]
+			},
+			{
+				"provider_id": "0x3",
+				"service_type": "mock_service",
+				"service_definition": {"location": {"country": "LT", "node_type": "residential"}},
+				"payment_method_type": "PER_GB",
+				"payment_method": {"price": {"amount": 500, "currency": "MYST"}},
+				"provider_contacts": [{"type": "mock_contact", "definition": {}}]
+			}
+		]}`))
+	})
+	assert.NoError(t, err)
+
+	api := NewClient("http://" + address)
+	proposals, err := api.FindProposals(market.ProposalFilter{
+		LocationCountry:   "LT",
+		LocationNodeType:  "residential",
+		PaymentMethodType: market.PaymentMethodPerGB,
+		PriceMax:          200,
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "LT", query.Get("location_country"))
+	assert.Equal(t, "residential", query.Get("location_node_type"))
+	assert.Equal(t, "PER_GB", query.Get("payment_method_type"))
+	assert.Equal(t, "200", query.Get("price_max"))
+	assert.Len(t, proposals, 1)
+	assert.Equal(t, "0x1", proposals[0].ProviderID)
+}
+
 func createHTTPServer(handlerFunc http.HandlerFunc) (address string, err error) {
 	listener, err := net.Listen("tcp", "localhost:0")
 	if err != nil {
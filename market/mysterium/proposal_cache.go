@@ -0,0 +1,204 @@
+/*
+ * Copyright (C) 2019 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package mysterium
+
+import (
+	"sync"
+	"time"
+
+	log "github.com/cihub/seelog"
+	"github.com/mysteriumnetwork/node/market"
+)
+
+const proposalCacheLogPrefix = "[proposal-cache] "
+
+const (
+	// proposals older than this number of refresh intervals are fetched again on request
+	proposalCacheExpiryIntervals = 2
+	// proposals not requested during this number of refresh intervals are not refreshed anymore and are dropped
+	proposalCacheIdleIntervals = 10
+)
+
+// ProposalFinder fetches proposals matching the filter from discovery
+type ProposalFinder interface {
+	FindProposals(filter market.ProposalFilter) ([]market.ServiceProposal, error)
+}
+
+// Freshness describes when the cached proposals were fetched from discovery
+type Freshness struct {
+	FetchedAt time.Time
+	// Stale is set when the last attempt to refresh the proposals failed, so older proposals are served
+	Stale bool
+}
+
+type proposalCacheEntry struct {
+	proposals []market.ServiceProposal
+	freshness Freshness
+	err       error
+	requested time.Time
+	// fetching is closed once the ongoing fetch completes, nil if proposals are not being fetched
+	fetching chan struct{}
+}
+
+// ProposalCache keeps proposals fetched from discovery and refreshes them in the background.
+// Proposals are cached for every filter separately, and the last fetched proposals are served while discovery is unreachable.
+type ProposalCache struct {
+	finder          ProposalFinder
+	refreshInterval time.Duration
+	timeGetter      func() time.Time
+
+	entries map[market.ProposalFilter]*proposalCacheEntry
+	lock    sync.Mutex
+	stop    chan struct{}
+	once    sync.Once
+}
+
+// NewProposalCache creates a new proposal cache, which refreshes the proposals every given interval
+func NewProposalCache(finder ProposalFinder, refreshInterval time.Duration) *ProposalCache {
+	return &ProposalCache{
+		finder:          finder,
+		refreshInterval: refreshInterval,
+		timeGetter:      time.Now,
+		entries:         make(map[market.ProposalFilter]*proposalCacheEntry),
+		stop:            make(chan struct{}),
+	}
+}
+
+// Start starts refreshing the requested proposals in the background
+func (cache *ProposalCache) Start() {
+	go func() {
+		ticker := time.NewTicker(cache.refreshInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				cache.refreshAll()
+			case <-cache.stop:
+				return
+			}
+		}
+	}()
+}
+
+// Stop stops refreshing the proposals
+func (cache *ProposalCache) Stop() {
+	cache.once.Do(func() {
+		close(cache.stop)
+	})
+}
+
+// GetProposal returns service proposal by exact ID
+func (cache *ProposalCache) GetProposal(id market.ProposalID) (*market.ServiceProposal, error) {
+	proposals, err := cache.FindProposals(market.ProposalFilter{
+		ProviderID:  id.ProviderID,
+		ServiceType: id.ServiceType,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(proposals) == 0 {
+		return nil, nil
+	}
+
+	return &proposals[0], nil
+}
+
+// FindProposals returns cached proposals matching the filter, or fetches them if the cached ones are expired.
+// If fetching fails, the last fetched proposals are returned.
+func (cache *ProposalCache) FindProposals(filter market.ProposalFilter) ([]market.ServiceProposal, error) {
+	cache.lock.Lock()
+	entry, found := cache.entries[filter]
+	if !found {
+		entry = &proposalCacheEntry{}
+		cache.entries[filter] = entry
+	}
+	entry.requested = cache.timeGetter()
+	expired := entry.freshness.FetchedAt.IsZero() ||
+		cache.timeGetter().Sub(entry.freshness.FetchedAt) >= proposalCacheExpiryIntervals*cache.refreshInterval
+	cache.lock.Unlock()
+
+	if expired {
+		cache.refresh(filter, entry)
+	}
+
+	cache.lock.Lock()
+	defer cache.lock.Unlock()
+	if entry.freshness.FetchedAt.IsZero() {
+		return nil, entry.err
+	}
+	return entry.proposals, nil
+}
+
+// Freshness returns when the proposals matching the filter were fetched, false if they were not fetched yet
+func (cache *ProposalCache) Freshness(filter market.ProposalFilter) (Freshness, bool) {
+	cache.lock.Lock()
+	defer cache.lock.Unlock()
+
+	entry, found := cache.entries[filter]
+	if !found || entry.freshness.FetchedAt.IsZero() {
+		return Freshness{}, false
+	}
+	return entry.freshness, true
+}
+
+// refresh fetches proposals of the entry, concurrent refreshes of the same entry wait for the single fetch to complete
+func (cache *ProposalCache) refresh(filter market.ProposalFilter, entry *proposalCacheEntry) {
+	cache.lock.Lock()
+	if entry.fetching != nil {
+		fetching := entry.fetching
+		cache.lock.Unlock()
+		<-fetching
+		return
+	}
+	entry.fetching = make(chan struct{})
+	cache.lock.Unlock()
+
+	proposals, err := cache.finder.FindProposals(filter)
+
+	cache.lock.Lock()
+	defer cache.lock.Unlock()
+	if err != nil {
+		log.Warn(proposalCacheLogPrefix, "Failed to fetch proposals: ", err)
+		entry.err = err
+		entry.freshness.Stale = !entry.freshness.FetchedAt.IsZero()
+	} else {
+		entry.proposals = proposals
+		entry.err = nil
+		entry.freshness = Freshness{FetchedAt: cache.timeGetter()}
+	}
+	close(entry.fetching)
+	entry.fetching = nil
+}
+
+func (cache *ProposalCache) refreshAll() {
+	cache.lock.Lock()
+	idleSince := cache.timeGetter().Add(-proposalCacheIdleIntervals * cache.refreshInterval)
+	refreshed := make(map[market.ProposalFilter]*proposalCacheEntry)
+	for filter, entry := range cache.entries {
+		if entry.requested.Before(idleSince) {
+			delete(cache.entries, filter)
+			continue
+		}
+		refreshed[filter] = entry
+	}
+	cache.lock.Unlock()
+
+	for filter, entry := range refreshed {
+		cache.refresh(filter, entry)
+	}
+}
@@ -0,0 +1,191 @@
+/*
+ * Copyright (C) 2019 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package mysterium
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mysteriumnetwork/node/market"
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	cachedProposal = market.ServiceProposal{ProviderID: "0x1", ServiceType: "openvpn"}
+	cacheFilter    = market.ProposalFilter{ServiceType: "openvpn"}
+)
+
+type cacheClock struct {
+	now time.Time
+}
+
+func (clock *cacheClock) Now() time.Time {
+	return clock.now
+}
+
+func newTestProposalCache(finder ProposalFinder) (*ProposalCache, *cacheClock) {
+	clock := &cacheClock{now: time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC)}
+	cache := NewProposalCache(finder, time.Minute)
+	cache.timeGetter = clock.Now
+	return cache, clock
+}
+
+func TestProposalCache_ServesCachedProposals(t *testing.T) {
+	finder := &mockProposalFinder{proposals: []market.ServiceProposal{cachedProposal}}
+	cache, clock := newTestProposalCache(finder)
+
+	proposals, err := cache.FindProposals(cacheFilter)
+	assert.NoError(t, err)
+	assert.Equal(t, []market.ServiceProposal{cachedProposal}, proposals)
+
+	clock.now = clock.now.Add(time.Minute)
+	proposals, err = cache.FindProposals(cacheFilter)
+	assert.NoError(t, err)
+	assert.Equal(t, []market.ServiceProposal{cachedProposal}, proposals)
+	assert.Equal(t, 1, finder.callCount())
+	assert.Equal(t, cacheFilter, finder.recordedFilter)
+
+	freshness, found := cache.Freshness(cacheFilter)
+	assert.True(t, found)
+	assert.Equal(t, Freshness{FetchedAt: time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC)}, freshness)
+}
+
+func TestProposalCache_FetchesExpiredProposals(t *testing.T) {
+	finder := &mockProposalFinder{proposals: []market.ServiceProposal{cachedProposal}}
+	cache, clock := newTestProposalCache(finder)
+
+	cache.FindProposals(cacheFilter)
+	clock.now = clock.now.Add(2 * time.Minute)
+	cache.FindProposals(cacheFilter)
+
+	assert.Equal(t, 2, finder.callCount())
+}
+
+func TestProposalCache_ServesStaleProposalsWhenDiscoveryFails(t *testing.T) {
+	finder := &mockProposalFinder{proposals: []market.ServiceProposal{cachedProposal}}
+	cache, clock := newTestProposalCache(finder)
+
+	cache.FindProposals(cacheFilter)
+	finder.setError(errors.New("discovery unreachable"))
+	clock.now = clock.now.Add(2 * time.Minute)
+
+	proposals, err := cache.FindProposals(cacheFilter)
+	assert.NoError(t, err)
+	assert.Equal(t, []market.ServiceProposal{cachedProposal}, proposals)
+
+	freshness, found := cache.Freshness(cacheFilter)
+	assert.True(t, found)
+	assert.True(t, freshness.Stale)
+	assert.Equal(t, time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC), freshness.FetchedAt)
+}
+
+func TestProposalCache_ReturnsErrorWhenNothingIsCached(t *testing.T) {
+	finder := &mockProposalFinder{err: errors.New("discovery unreachable")}
+	cache, _ := newTestProposalCache(finder)
+
+	proposals, err := cache.FindProposals(cacheFilter)
+	assert.Error(t, err)
+	assert.Nil(t, proposals)
+
+	_, found := cache.Freshness(cacheFilter)
+	assert.False(t, found)
+}
+
+func TestProposalCache_DeduplicatesConcurrentFetches(t *testing.T) {
+	finder := &mockProposalFinder{proposals: []market.ServiceProposal{cachedProposal}, block: make(chan struct{})}
+	cache, _ := newTestProposalCache(finder)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 3; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			proposals, err := cache.FindProposals(cacheFilter)
+			assert.NoError(t, err)
+			assert.Equal(t, []market.ServiceProposal{cachedProposal}, proposals)
+		}()
+	}
+
+	time.Sleep(20 * time.Millisecond)
+	close(finder.block)
+	wg.Wait()
+
+	assert.Equal(t, 1, finder.callCount())
+}
+
+func TestProposalCache_RefreshesRequestedAndDropsIdleProposals(t *testing.T) {
+	finder := &mockProposalFinder{proposals: []market.ServiceProposal{cachedProposal}}
+	cache, clock := newTestProposalCache(finder)
+
+	idleFilter := market.ProposalFilter{ServiceType: "wireguard"}
+	cache.FindProposals(idleFilter)
+	clock.now = clock.now.Add(11 * time.Minute)
+	cache.FindProposals(cacheFilter)
+
+	cache.refreshAll()
+
+	assert.Equal(t, 3, finder.callCount())
+	assert.Equal(t, cacheFilter, finder.recordedFilter)
+	_, found := cache.Freshness(idleFilter)
+	assert.False(t, found)
+}
+
+func TestProposalCache_GetProposal(t *testing.T) {
+	finder := &mockProposalFinder{proposals: []market.ServiceProposal{cachedProposal}}
+	cache, _ := newTestProposalCache(finder)
+
+	proposal, err := cache.GetProposal(market.ProposalID{ProviderID: "0x1", ServiceType: "openvpn"})
+	assert.NoError(t, err)
+	assert.Equal(t, &cachedProposal, proposal)
+	assert.Equal(t, market.ProposalFilter{ProviderID: "0x1", ServiceType: "openvpn"}, finder.recordedFilter)
+}
+
+type mockProposalFinder struct {
+	proposals      []market.ServiceProposal
+	err            error
+	block          chan struct{}
+	calls          int
+	recordedFilter market.ProposalFilter
+	lock           sync.Mutex
+}
+
+func (finder *mockProposalFinder) FindProposals(filter market.ProposalFilter) ([]market.ServiceProposal, error) {
+	if finder.block != nil {
+		<-finder.block
+	}
+
+	finder.lock.Lock()
+	defer finder.lock.Unlock()
+	finder.calls++
+	finder.recordedFilter = filter
+	return finder.proposals, finder.err
+}
+
+func (finder *mockProposalFinder) setError(err error) {
+	finder.lock.Lock()
+	defer finder.lock.Unlock()
+	finder.err = err
+}
+
+func (finder *mockProposalFinder) callCount() int {
+	finder.lock.Lock()
+	defer finder.lock.Unlock()
+	return finder.calls
+}
@@ -0,0 +1,45 @@
+/*
+ * Copyright (C) 2019 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package market
+
+import (
+	"encoding/json"
+
+	"github.com/mysteriumnetwork/node/money"
+)
+
+// PaymentMethodPerGB indicates payment method priced per volume of the transferred data
+const PaymentMethodPerGB = "PER_GB"
+
+// PaymentPerGB structure describes price of a gigabyte of data transferred in both directions
+type PaymentPerGB struct {
+	Price money.Money `json:"price"`
+}
+
+// GetPrice returns price of payment per gigabyte
+func (method PaymentPerGB) GetPrice() money.Money {
+	return method.Price
+}
+
+// UnserializePaymentPerGB unserializes PaymentPerGB from the proposal payment method definition
+func UnserializePaymentPerGB(rawDefinition *json.RawMessage) (PaymentMethod, error) {
+	var method PaymentPerGB
+	err := json.Unmarshal(*rawDefinition, &method)
+
+	return method, err
+}
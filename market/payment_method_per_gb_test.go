@@ -0,0 +1,48 @@
+/*
+ * Copyright (C) 2019 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package market
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/mysteriumnetwork/node/money"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_PaymentPerGB_SerializedInProposal(t *testing.T) {
+	RegisterPaymentMethodUnserializer(PaymentMethodPerGB, UnserializePaymentPerGB)
+
+	sp := ServiceProposal{
+		ServiceType:       "mock_service",
+		ServiceDefinition: serviceDefinition,
+		PaymentMethodType: PaymentMethodPerGB,
+		PaymentMethod:     PaymentPerGB{Price: money.NewMoney(0.5, money.CurrencyMyst)},
+		ProviderContacts:  ContactList{},
+	}
+
+	jsonBytes, err := json.Marshal(sp)
+	assert.NoError(t, err)
+
+	var actual ServiceProposal
+	err = json.Unmarshal(jsonBytes, &actual)
+	assert.NoError(t, err)
+	assert.Equal(t, PaymentMethodPerGB, actual.PaymentMethodType)
+	assert.Equal(t, sp.PaymentMethod, actual.PaymentMethod)
+	assert.Equal(t, money.NewMoney(0.5, money.CurrencyMyst), actual.PaymentMethod.GetPrice())
+}
@@ -17,10 +17,58 @@
 
 package market
 
+import "strings"
+
 // ProposalFilter defines all flags for proposal filtering in discovery of Mysterium Network
 type ProposalFilter struct {
 	ProviderID         string
 	ServiceType        string
 	AccessPolicyID     string
 	AccessPolicySource string
+
+	LocationCountry   string
+	LocationContinent string
+	LocationISP       string
+	LocationASN       int
+	LocationNodeType  string
+
+	// PaymentMethodType restricts the proposals to the given payment method
+	PaymentMethodType string
+	// PriceMax is the highest acceptable price amount of PaymentMethodType proposals, zero means any price.
+	// Prices of different payment methods are metered in different units, so PriceMax is used together with PaymentMethodType.
+	PriceMax uint64
+}
+
+// Matches checks whether the proposal satisfies the location, payment method and price conditions of the filter.
+// It allows to filter proposals locally, when the discovery does not support some of the conditions.
+func (filter ProposalFilter) Matches(proposal ServiceProposal) bool {
+	var location Location
+	if proposal.ServiceDefinition != nil {
+		location = proposal.ServiceDefinition.GetLocation()
+	}
+
+	if filter.LocationCountry != "" && !strings.EqualFold(filter.LocationCountry, location.Country) {
+		return false
+	}
+	if filter.LocationContinent != "" && !strings.EqualFold(filter.LocationContinent, location.Continent) {
+		return false
+	}
+	if filter.LocationISP != "" && !strings.EqualFold(filter.LocationISP, location.ISP) {
+		return false
+	}
+	if filter.LocationASN != 0 && filter.LocationASN != location.ASN {
+		return false
+	}
+	if filter.LocationNodeType != "" && !strings.EqualFold(filter.LocationNodeType, location.NodeType) {
+		return false
+	}
+	if filter.PaymentMethodType != "" && !strings.EqualFold(filter.PaymentMethodType, proposal.PaymentMethodType) {
+		return false
+	}
+	if filter.PriceMax != 0 {
+		if proposal.PaymentMethod == nil || proposal.PaymentMethod.GetPrice().Amount > filter.PriceMax {
+			return false
+		}
+	}
+	return true
 }
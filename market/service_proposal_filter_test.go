@@ -0,0 +1,83 @@
+/*
+ * Copyright (C) 2019 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package market
+
+import (
+	"testing"
+
+	"github.com/mysteriumnetwork/node/money"
+	"github.com/stretchr/testify/assert"
+)
+
+type locatedServiceDefinition struct {
+	location Location
+}
+
+func (service locatedServiceDefinition) GetLocation() Location {
+	return service.location
+}
+
+var filteredProposal = ServiceProposal{
+	PaymentMethodType: PaymentMethodPerGB,
+	ServiceDefinition: locatedServiceDefinition{Location{
+		Continent: "EU",
+		Country:   "LT",
+		ASN:       8764,
+		ISP:       "Telia Lietuva, AB",
+		NodeType:  "residential",
+	}},
+	PaymentMethod: PaymentPerGB{Price: money.NewMoney(0.5, money.CurrencyMyst)},
+}
+
+func Test_ProposalFilter_MatchesEmptyFilter(t *testing.T) {
+	assert.True(t, ProposalFilter{}.Matches(filteredProposal))
+	assert.True(t, ProposalFilter{}.Matches(ServiceProposal{}))
+}
+
+func Test_ProposalFilter_MatchesLocation(t *testing.T) {
+	assert.True(t, ProposalFilter{LocationCountry: "lt", LocationContinent: "EU"}.Matches(filteredProposal))
+	assert.True(t, ProposalFilter{LocationISP: "Telia Lietuva, AB", LocationASN: 8764}.Matches(filteredProposal))
+	assert.True(t, ProposalFilter{LocationNodeType: "residential"}.Matches(filteredProposal))
+
+	assert.False(t, ProposalFilter{LocationCountry: "NL"}.Matches(filteredProposal))
+	assert.False(t, ProposalFilter{LocationContinent: "NA"}.Matches(filteredProposal))
+	assert.False(t, ProposalFilter{LocationISP: "Other ISP"}.Matches(filteredProposal))
+	assert.False(t, ProposalFilter{LocationASN: 1}.Matches(filteredProposal))
+	assert.False(t, ProposalFilter{LocationNodeType: "datacenter"}.Matches(filteredProposal))
+	assert.False(t, ProposalFilter{LocationCountry: "LT"}.Matches(ServiceProposal{}))
+}
+
+func Test_ProposalFilter_MatchesPrice(t *testing.T) {
+	assert.True(t, ProposalFilter{PaymentMethodType: PaymentMethodPerGB, PriceMax: 50000000}.Matches(filteredProposal))
+	assert.False(t, ProposalFilter{PaymentMethodType: PaymentMethodPerGB, PriceMax: 49999999}.Matches(filteredProposal))
+	assert.False(t, ProposalFilter{PaymentMethodType: PaymentMethodPerGB, PriceMax: 1}.Matches(ServiceProposal{}))
+}
+
+func Test_ProposalFilter_MatchesPriceOfPaymentMethodOnly(t *testing.T) {
+	perTime := filteredProposal
+	perTime.PaymentMethodType = "PER_TIME"
+	perTime.PaymentMethod = PaymentPerGB{Price: money.NewMoney(0.001, money.CurrencyMyst)}
+
+	filter := ProposalFilter{PaymentMethodType: "per_gb", PriceMax: 50000000}
+	assert.True(t, filter.Matches(filteredProposal))
+	assert.False(t, filter.Matches(perTime))
+
+	filter = ProposalFilter{PaymentMethodType: "PER_TIME", PriceMax: 100000}
+	assert.True(t, filter.Matches(perTime))
+	assert.False(t, filter.Matches(filteredProposal))
+}
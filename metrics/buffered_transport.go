@@ -0,0 +1,227 @@
+/*
+ * Copyright (C) 2019 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package metrics
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	log "github.com/cihub/seelog"
+	"github.com/mysteriumnetwork/node/utils"
+)
+
+const bufferedTransportLogPrefix = "[metrics-buffer] "
+const bufferBucketPrefix = "metrics-events-"
+
+// Storer allows us to persist the events until they are delivered
+type Storer interface {
+	Store(bucket string, object interface{}) error
+	GetAllFrom(bucket string, array interface{}) error
+	Delete(bucket string, object interface{}) error
+}
+
+// Sink is the named transport the buffered events are delivered to
+type Sink struct {
+	Name      string
+	Transport Transport
+}
+
+// BufferOptions describes how the buffered events are delivered
+type BufferOptions struct {
+	// BatchSize is the maximum number of events sent at once
+	BatchSize int
+	// FlushInterval is the period of delivering the buffered events
+	FlushInterval time.Duration
+	// MaxBackoff limits the delay between retries, which doubles after every failed delivery
+	MaxBackoff time.Duration
+	// MaxEvents is the number of events kept for the sink, the oldest ones are dropped above it
+	MaxEvents int
+}
+
+// DefaultBufferOptions returns the options used by the node
+func DefaultBufferOptions() BufferOptions {
+	return BufferOptions{
+		BatchSize:     50,
+		FlushInterval: 10 * time.Second,
+		MaxBackoff:    10 * time.Minute,
+		MaxEvents:     10000,
+	}
+}
+
+// storedEvent is the event waiting in the buffer to be delivered to the sink
+type storedEvent struct {
+	ID    uint64 `storm:"id,increment"`
+	Event Event
+}
+
+// BufferedTransport persists the events, so they are not lost while the sinks are unreachable,
+// and delivers them to every sink independently in batches, retrying with backoff
+type BufferedTransport struct {
+	storage Storer
+	sinks   []Sink
+	options BufferOptions
+
+	stop     chan struct{}
+	stopOnce sync.Once
+	stopped  sync.WaitGroup
+}
+
+// NewBufferedTransport creates the transport buffering the events for the given sinks in the storage
+func NewBufferedTransport(storage Storer, options BufferOptions, sinks ...Sink) *BufferedTransport {
+	return &BufferedTransport{
+		storage: storage,
+		sinks:   sinks,
+		options: options,
+		stop:    make(chan struct{}),
+	}
+}
+
+// SendEvent puts the event into the buffer of every sink
+func (transport *BufferedTransport) SendEvent(event Event) error {
+	errs := utils.ErrorCollection{}
+	for _, sink := range transport.sinks {
+		if err := transport.storage.Store(bufferBucketPrefix+sink.Name, &storedEvent{Event: event}); err != nil {
+			errs.Add(fmt.Errorf("failed to buffer event for %s: %v", sink.Name, err))
+		}
+	}
+	return errs.Errorf("%s", ", ")
+}
+
+// Start starts delivering the buffered events, including the ones left undelivered by the previous run
+func (transport *BufferedTransport) Start() {
+	for _, sink := range transport.sinks {
+		transport.stopped.Add(1)
+		go transport.deliverLoop(sink)
+	}
+}
+
+// Stop stops delivering the events, the undelivered ones stay in the buffer
+func (transport *BufferedTransport) Stop() {
+	transport.stopOnce.Do(func() {
+		close(transport.stop)
+	})
+	transport.stopped.Wait()
+}
+
+func (transport *BufferedTransport) deliverLoop(sink Sink) {
+	defer transport.stopped.Done()
+
+	delay := transport.options.FlushInterval
+	for {
+		select {
+		case <-transport.stop:
+			return
+		case <-time.After(delay):
+		}
+
+		if err := transport.deliver(sink); err != nil {
+			delay = transport.nextBackoff(delay)
+			log.Warn(bufferedTransportLogPrefix, "Failed to deliver events to ", sink.Name, ", retrying in ", delay, ": ", err)
+		} else {
+			delay = transport.options.FlushInterval
+		}
+	}
+}
+
+func (transport *BufferedTransport) nextBackoff(delay time.Duration) time.Duration {
+	delay *= 2
+	if delay > transport.options.MaxBackoff {
+		return transport.options.MaxBackoff
+	}
+	return delay
+}
+
+// deliver sends the buffered events of the sink in batches, until the buffer is empty or the sink fails
+func (transport *BufferedTransport) deliver(sink Sink) error {
+	bucket := bufferBucketPrefix + sink.Name
+
+	var events []storedEvent
+	if err := transport.storage.GetAllFrom(bucket, &events); err != nil {
+		return err
+	}
+	events = transport.dropOverflow(bucket, events)
+
+	for len(events) > 0 {
+		select {
+		case <-transport.stop:
+			return nil
+		default:
+		}
+
+		batch := events
+		if len(batch) > transport.options.BatchSize {
+			batch = batch[:transport.options.BatchSize]
+		}
+		events = events[len(batch):]
+
+		delivered, err := transport.sendBatch(sink.Transport, batch)
+		for i := range batch[:delivered] {
+			if err := transport.storage.Delete(bucket, &batch[i]); err != nil {
+				log.Error(bufferedTransportLogPrefix, "Failed to remove delivered event from the buffer of ", sink.Name, ": ", err)
+			}
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// dropOverflow removes the oldest events above the limit, so the buffer does not grow while the sink is unreachable
+func (transport *BufferedTransport) dropOverflow(bucket string, events []storedEvent) []storedEvent {
+	overflow := len(events) - transport.options.MaxEvents
+	if overflow <= 0 {
+		return events
+	}
+
+	log.Warn(bufferedTransportLogPrefix, "Buffer ", bucket, " is full, dropping ", overflow, " oldest events")
+	for i := range events[:overflow] {
+		if err := transport.storage.Delete(bucket, &events[i]); err != nil {
+			log.Error(bufferedTransportLogPrefix, "Failed to drop event from the buffer: ", err)
+		}
+	}
+	return events[overflow:]
+}
+
+// sendBatch returns the number of events delivered before the sink failed or the delivery was stopped
+func (transport *BufferedTransport) sendBatch(sink Transport, batch []storedEvent) (int, error) {
+	if batchTransport, ok := sink.(BatchTransport); ok {
+		events := make([]Event, len(batch))
+		for i := range batch {
+			events[i] = batch[i].Event
+		}
+		if err := batchTransport.SendEvents(events); err != nil {
+			return 0, err
+		}
+		return len(batch), nil
+	}
+
+	for i := range batch {
+		select {
+		case <-transport.stop:
+			return i, nil
+		default:
+		}
+
+		if err := sink.SendEvent(batch[i].Event); err != nil {
+			return i, err
+		}
+	}
+	return len(batch), nil
+}
@@ -0,0 +1,197 @@
+/*
+ * Copyright (C) 2019 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package metrics
+
+import (
+	"errors"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type stubEventStorer struct {
+	buckets map[string]map[uint64]storedEvent
+	lastID  uint64
+	lock    sync.Mutex
+}
+
+func newStubEventStorer() *stubEventStorer {
+	return &stubEventStorer{buckets: make(map[string]map[uint64]storedEvent)}
+}
+
+func (ses *stubEventStorer) Store(bucket string, object interface{}) error {
+	ses.lock.Lock()
+	defer ses.lock.Unlock()
+
+	if ses.buckets[bucket] == nil {
+		ses.buckets[bucket] = make(map[uint64]storedEvent)
+	}
+	event := object.(*storedEvent)
+	ses.lastID++
+	event.ID = ses.lastID
+	ses.buckets[bucket][event.ID] = *event
+	return nil
+}
+
+func (ses *stubEventStorer) GetAllFrom(bucket string, array interface{}) error {
+	ses.lock.Lock()
+	defer ses.lock.Unlock()
+
+	events := array.(*[]storedEvent)
+	for _, event := range ses.buckets[bucket] {
+		*events = append(*events, event)
+	}
+	sort.Slice(*events, func(i, j int) bool { return (*events)[i].ID < (*events)[j].ID })
+	return nil
+}
+
+func (ses *stubEventStorer) Delete(bucket string, object interface{}) error {
+	ses.lock.Lock()
+	defer ses.lock.Unlock()
+
+	delete(ses.buckets[bucket], object.(*storedEvent).ID)
+	return nil
+}
+
+func (ses *stubEventStorer) count(bucket string) int {
+	ses.lock.Lock()
+	defer ses.lock.Unlock()
+	return len(ses.buckets[bucket])
+}
+
+type batchTransportStub struct {
+	batches [][]Event
+	err     error
+}
+
+func (bts *batchTransportStub) SendEvent(event Event) error {
+	return bts.SendEvents([]Event{event})
+}
+
+func (bts *batchTransportStub) SendEvents(events []Event) error {
+	if bts.err != nil {
+		return bts.err
+	}
+	bts.batches = append(bts.batches, events)
+	return nil
+}
+
+type singleTransportStub struct {
+	sent     []Event
+	failFrom int
+}
+
+func (sts *singleTransportStub) SendEvent(event Event) error {
+	if sts.failFrom > 0 && len(sts.sent) >= sts.failFrom {
+		return errors.New("sink unreachable")
+	}
+	sts.sent = append(sts.sent, event)
+	return nil
+}
+
+func testBufferOptions() BufferOptions {
+	return BufferOptions{BatchSize: 2, FlushInterval: time.Millisecond, MaxBackoff: 4 * time.Millisecond, MaxEvents: 10}
+}
+
+func TestBufferedTransport_DeliversEventsToEverySinkInBatches(t *testing.T) {
+	storage := newStubEventStorer()
+	batchSink := &batchTransportStub{}
+	singleSink := &singleTransportStub{}
+	transport := NewBufferedTransport(storage, testBufferOptions(), Sink{Name: "batch", Transport: batchSink}, Sink{Name: "single", Transport: singleSink})
+
+	for _, name := range []string{"first", "second", "third"} {
+		assert.NoError(t, transport.SendEvent(Event{EventName: name}))
+	}
+	assert.NoError(t, transport.deliver(transport.sinks[0]))
+	assert.NoError(t, transport.deliver(transport.sinks[1]))
+
+	assert.Equal(t, [][]Event{{{EventName: "first"}, {EventName: "second"}}, {{EventName: "third"}}}, batchSink.batches)
+	assert.Equal(t, []Event{{EventName: "first"}, {EventName: "second"}, {EventName: "third"}}, singleSink.sent)
+	assert.Equal(t, 0, storage.count(bufferBucketPrefix+"batch"))
+	assert.Equal(t, 0, storage.count(bufferBucketPrefix+"single"))
+}
+
+func TestBufferedTransport_KeepsUndeliveredEvents(t *testing.T) {
+	storage := newStubEventStorer()
+	batchSink := &batchTransportStub{err: errors.New("sink unreachable")}
+	singleSink := &singleTransportStub{failFrom: 1}
+	transport := NewBufferedTransport(storage, testBufferOptions(), Sink{Name: "batch", Transport: batchSink}, Sink{Name: "single", Transport: singleSink})
+
+	for _, name := range []string{"first", "second", "third"} {
+		assert.NoError(t, transport.SendEvent(Event{EventName: name}))
+	}
+
+	assert.EqualError(t, transport.deliver(transport.sinks[0]), "sink unreachable")
+	assert.Equal(t, 3, storage.count(bufferBucketPrefix+"batch"))
+	assert.EqualError(t, transport.deliver(transport.sinks[1]), "sink unreachable")
+	assert.Equal(t, 2, storage.count(bufferBucketPrefix+"single"))
+
+	batchSink.err = nil
+	assert.NoError(t, transport.deliver(transport.sinks[0]))
+	assert.Equal(t, 0, storage.count(bufferBucketPrefix+"batch"))
+	assert.Len(t, batchSink.batches, 2)
+}
+
+func TestBufferedTransport_DropsOldestEventsAboveLimit(t *testing.T) {
+	storage := newStubEventStorer()
+	sink := &singleTransportStub{}
+	options := testBufferOptions()
+	options.MaxEvents = 2
+	transport := NewBufferedTransport(storage, options, Sink{Name: "single", Transport: sink})
+
+	for _, name := range []string{"first", "second", "third"} {
+		assert.NoError(t, transport.SendEvent(Event{EventName: name}))
+	}
+	assert.NoError(t, transport.deliver(transport.sinks[0]))
+
+	assert.Equal(t, []Event{{EventName: "second"}, {EventName: "third"}}, sink.sent)
+}
+
+func TestBufferedTransport_BacksOffUpToLimit(t *testing.T) {
+	transport := NewBufferedTransport(newStubEventStorer(), testBufferOptions())
+
+	assert.Equal(t, 2*time.Millisecond, transport.nextBackoff(time.Millisecond))
+	assert.Equal(t, 4*time.Millisecond, transport.nextBackoff(2*time.Millisecond))
+	assert.Equal(t, 4*time.Millisecond, transport.nextBackoff(4*time.Millisecond))
+}
+
+func TestBufferedTransport_DeliversInBackgroundUntilStopped(t *testing.T) {
+	storage := newStubEventStorer()
+	transport := NewBufferedTransport(storage, testBufferOptions(), Sink{Name: "batch", Transport: &batchTransportStub{}})
+	transport.Start()
+
+	assert.NoError(t, transport.SendEvent(Event{EventName: "startup"}))
+	waitForCount(t, storage, bufferBucketPrefix+"batch", 0)
+
+	transport.Stop()
+	assert.NoError(t, transport.SendEvent(Event{EventName: "shutdown"}))
+	time.Sleep(10 * time.Millisecond)
+	assert.Equal(t, 1, storage.count(bufferBucketPrefix+"batch"))
+}
+
+func waitForCount(t *testing.T, storage *stubEventStorer, bucket string, count int) {
+	for deadline := time.Now().Add(time.Second); time.Now().Before(deadline); time.Sleep(time.Millisecond) {
+		if storage.count(bucket) == count {
+			return
+		}
+	}
+	t.Fatalf("bucket %s has %d events, expected %d", bucket, storage.count(bucket), count)
+}
@@ -21,14 +21,41 @@ import (
 	"time"
 )
 
-// NewSender creates metrics sender with appropriate transport
-func NewSender(disableMetrics bool, metricsAddress, appVersion string, gatewayLoader func() []map[string]string) *Sender {
+const sinkTimeout = 10 * time.Second
+
+// SinkOptions describes where the events are delivered to, sinks with empty address are not used
+type SinkOptions struct {
+	ElasticSearchAddress string
+	WebhookAddress       string
+	FilePath             string
+}
+
+// NewSender creates metrics sender with appropriate transport.
+// Events are buffered in the given storage and delivered to all the configured sinks.
+func NewSender(disableMetrics bool, sinkOptions SinkOptions, storage Storer, appVersion string, gatewayLoader func() []map[string]string) *Sender {
 	var transport Transport
-	if disableMetrics {
+	sinks := newSinks(sinkOptions)
+	if disableMetrics || len(sinks) == 0 {
 		transport = NewNoopTransport()
 	} else {
-		transport = NewElasticSearchTransport(metricsAddress, 10*time.Second)
+		bufferedTransport := NewBufferedTransport(storage, DefaultBufferOptions(), sinks...)
+		bufferedTransport.Start()
+		transport = bufferedTransport
 	}
 
 	return &Sender{Transport: transport, AppVersion: appVersion, GatewayLoader: gatewayLoader}
 }
+
+func newSinks(options SinkOptions) []Sink {
+	var sinks []Sink
+	if options.ElasticSearchAddress != "" {
+		sinks = append(sinks, Sink{Name: "elasticsearch", Transport: NewElasticSearchTransport(options.ElasticSearchAddress, sinkTimeout)})
+	}
+	if options.WebhookAddress != "" {
+		sinks = append(sinks, Sink{Name: "webhook", Transport: NewWebhookTransport(options.WebhookAddress, sinkTimeout)})
+	}
+	if options.FilePath != "" {
+		sinks = append(sinks, Sink{Name: "file", Transport: NewFileTransport(options.FilePath)})
+	}
+	return sinks
+}
@@ -0,0 +1,62 @@
+/*
+ * Copyright (C) 2019 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package metrics
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"sync"
+)
+
+// NewFileTransport creates transport appending events to the local file, one JSON document per line
+func NewFileTransport(path string) BatchTransport {
+	return &fileTransport{path: path}
+}
+
+type fileTransport struct {
+	path string
+	lock sync.Mutex
+}
+
+func (transport *fileTransport) SendEvent(event Event) error {
+	return transport.SendEvents([]Event{event})
+}
+
+func (transport *fileTransport) SendEvents(events []Event) error {
+	var lines bytes.Buffer
+	encoder := json.NewEncoder(&lines)
+	for _, event := range events {
+		if err := encoder.Encode(event); err != nil {
+			return err
+		}
+	}
+
+	transport.lock.Lock()
+	defer transport.lock.Unlock()
+
+	file, err := os.OpenFile(transport.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := file.Write(lines.Bytes()); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
@@ -0,0 +1,49 @@
+/*
+ * Copyright (C) 2019 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package metrics
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFileTransport_SendEvents_AppendsJSONLines(t *testing.T) {
+	dir, err := ioutil.TempDir("", "metrics")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "metrics.jsonl")
+
+	transport := NewFileTransport(path)
+	assert.NoError(t, transport.SendEvent(Event{EventName: "startup", CreatedAt: 1}))
+	assert.NoError(t, transport.SendEvents([]Event{{EventName: "session_started", CreatedAt: 2}, {EventName: "session_ended", CreatedAt: 3}}))
+
+	content, err := ioutil.ReadFile(path)
+	assert.NoError(t, err)
+	assert.Equal(
+		t,
+		`{"application":{"name":"","version":""},"eventName":"startup","createdAt":1,"context":null}
+{"application":{"name":"","version":""},"eventName":"session_started","createdAt":2,"context":null}
+{"application":{"name":"","version":""},"eventName":"session_ended","createdAt":3,"context":null}
+`,
+		string(content),
+	)
+}
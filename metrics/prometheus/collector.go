@@ -0,0 +1,332 @@
+/*
+ * Copyright (C) 2019 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package prometheus
+
+import (
+	"strconv"
+	"sync"
+
+	log "github.com/cihub/seelog"
+	"github.com/mysteriumnetwork/node/communication/nats/dialog"
+	"github.com/mysteriumnetwork/node/consumer"
+	"github.com/mysteriumnetwork/node/core/connection"
+	"github.com/mysteriumnetwork/node/core/discovery"
+	"github.com/mysteriumnetwork/node/identity"
+	"github.com/mysteriumnetwork/node/nat/event"
+	"github.com/mysteriumnetwork/node/session"
+	"github.com/mysteriumnetwork/node/session/promise"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const collectorLogPrefix = "[prometheus-collector] "
+
+// namespace prefixes the names of all node metrics
+const namespace = "myst"
+
+const (
+	directionSent     = "sent"
+	directionReceived = "received"
+)
+
+// EventSubscriber allows subscribing to the events published by the node
+type EventSubscriber interface {
+	Subscribe(topic string, fn interface{}) error
+}
+
+// ServiceSessions provides the sessions currently served by the provider
+type ServiceSessions interface {
+	GetAll() []session.Session
+}
+
+// PromiseStorage provides the promises known to the node
+type PromiseStorage interface {
+	GetAllKnownIssuers() []identity.Identity
+	GetAllPromisesFromIssuer(issuerID identity.Identity) ([]promise.StoredPromise, error)
+}
+
+// Collector populates the registry with the node metrics from the events published on the event bus
+// and the sessions and promises kept in the storages
+type Collector struct {
+	registerer prometheus.Registerer
+
+	connectionState  *prometheus.GaugeVec
+	connectionBytes  *prometheus.CounterVec
+	consumerSessions *prometheus.CounterVec
+	natTraversal     *prometheus.CounterVec
+	dialogFailures   *prometheus.CounterVec
+	pingFailures     *prometheus.CounterVec
+	promises         prometheus.Counter
+	promiseAmount    prometheus.Counter
+
+	lastState connection.State
+	lastStats consumer.SessionStatistics
+	// pooledStats keeps the latest statistics of the additional connections of the pool
+	pooledStats map[connection.ID]consumer.SessionStatistics
+	lock        sync.Mutex
+}
+
+// NewCollector registers the event based metrics with the given registerer
+func NewCollector(registerer prometheus.Registerer) *Collector {
+	collector := &Collector{
+		registerer:  registerer,
+		pooledStats: make(map[connection.ID]consumer.SessionStatistics),
+		connectionState: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "connection_state",
+			Help:      "Whether the consumer connection is in the given state",
+		}, []string{"state"}),
+		connectionBytes: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "connection_bytes_total",
+			Help:      "Bytes transferred through the consumer connection",
+		}, []string{"direction"}),
+		consumerSessions: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "connection_sessions_total",
+			Help:      "Consumer sessions by their status: created, ended or failed to connect",
+		}, []string{"status"}),
+		natTraversal: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "nat_traversal_total",
+			Help:      "Outcomes of the NAT traversal stages",
+		}, []string{"stage", "successful"}),
+		dialogFailures: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "dialog_failures_total",
+			Help:      "Dialogs requested by consumers and refused by the provider",
+		}, []string{"stage"}),
+		pingFailures: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "discovery_ping_failures_total",
+			Help:      "Failed pings of the service proposals announced to the discovery",
+		}, []string{"service_type"}),
+		promises: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "promises_total",
+			Help:      "Promises received from consumers",
+		}),
+		promiseAmount: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "promise_amount_total",
+			Help:      "Amount promised by consumers",
+		}),
+	}
+
+	registerer.MustRegister(
+		collector.connectionState,
+		collector.connectionBytes,
+		collector.consumerSessions,
+		collector.natTraversal,
+		collector.dialogFailures,
+		collector.pingFailures,
+	)
+	return collector
+}
+
+// Subscribe starts collecting the metrics from the events published on the event bus
+func (collector *Collector) Subscribe(subscriber EventSubscriber) error {
+	if err := subscriber.Subscribe(connection.StateEventTopic, collector.ConsumeStateEvent); err != nil {
+		return err
+	}
+	if err := subscriber.Subscribe(connection.StatisticsEventTopic, collector.ConsumeStatisticsEvent); err != nil {
+		return err
+	}
+	if err := subscriber.Subscribe(connection.SessionEventTopic, collector.ConsumeSessionEvent); err != nil {
+		return err
+	}
+	if err := subscriber.Subscribe(connection.PooledEventTopic, collector.ConsumePooledEvent); err != nil {
+		return err
+	}
+	if err := subscriber.Subscribe(promise.AmountTopic, collector.ConsumePromiseAmountEvent); err != nil {
+		return err
+	}
+	if err := subscriber.Subscribe(event.Topic, collector.ConsumeNATEvent); err != nil {
+		return err
+	}
+	if err := subscriber.Subscribe(dialog.FailedTopic, collector.ConsumeDialogFailedEvent); err != nil {
+		return err
+	}
+	return subscriber.Subscribe(discovery.PingFailedTopic, collector.ConsumePingFailedEvent)
+}
+
+// ConsumeStateEvent marks the current state of the consumer connection
+func (collector *Collector) ConsumeStateEvent(stateEvent connection.StateEvent) {
+	collector.lock.Lock()
+	defer collector.lock.Unlock()
+
+	if collector.lastState != "" {
+		collector.connectionState.WithLabelValues(string(collector.lastState)).Set(0)
+	}
+	collector.connectionState.WithLabelValues(string(stateEvent.State)).Set(1)
+	collector.lastState = stateEvent.State
+}
+
+// ConsumeStatisticsEvent counts the bytes transferred since the previous statistics of the session
+func (collector *Collector) ConsumeStatisticsEvent(stats consumer.SessionStatistics) {
+	collector.lock.Lock()
+	defer collector.lock.Unlock()
+
+	collector.addBytes(collector.lastStats, stats)
+	collector.lastStats = stats
+}
+
+// ConsumeSessionEvent counts consumer sessions, statistics are tracked from scratch for the new session
+func (collector *Collector) ConsumeSessionEvent(sessionEvent connection.SessionEvent) {
+	collector.consumerSessions.WithLabelValues(sessionEvent.Status).Inc()
+
+	if sessionEvent.Status == connection.SessionCreatedStatus {
+		collector.lock.Lock()
+		collector.lastStats = consumer.SessionStatistics{}
+		collector.lock.Unlock()
+	}
+}
+
+// ConsumePooledEvent counts the sessions and the bytes of the additional connections of the pool,
+// connection state follows the primary connection only
+func (collector *Collector) ConsumePooledEvent(pooledEvent connection.PooledEvent) {
+	switch event := pooledEvent.Event.(type) {
+	case consumer.SessionStatistics:
+		collector.lock.Lock()
+		defer collector.lock.Unlock()
+
+		collector.addBytes(collector.pooledStats[pooledEvent.ConnectionID], event)
+		collector.pooledStats[pooledEvent.ConnectionID] = event
+	case connection.SessionEvent:
+		collector.consumerSessions.WithLabelValues(event.Status).Inc()
+
+		switch event.Status {
+		case connection.SessionCreatedStatus, connection.SessionEndedStatus:
+			collector.lock.Lock()
+			delete(collector.pooledStats, pooledEvent.ConnectionID)
+			collector.lock.Unlock()
+		}
+	}
+}
+
+// ConsumePromiseAmountEvent counts the promises and the amounts promised by consumers
+func (collector *Collector) ConsumePromiseAmountEvent(amountEvent promise.AmountEvent) {
+	if amountEvent.First {
+		collector.promises.Inc()
+	}
+	collector.promiseAmount.Add(float64(amountEvent.Increment))
+}
+
+// ConsumeNATEvent counts the outcome of the NAT traversal stage
+func (collector *Collector) ConsumeNATEvent(natEvent event.Event) {
+	collector.natTraversal.WithLabelValues(natEvent.Stage, strconv.FormatBool(natEvent.Successful)).Inc()
+}
+
+// ConsumeDialogFailedEvent counts the dialogs refused by the provider
+func (collector *Collector) ConsumeDialogFailedEvent(failedEvent dialog.FailedEvent) {
+	collector.dialogFailures.WithLabelValues(failedEvent.Stage).Inc()
+}
+
+// ConsumePingFailedEvent counts the failed proposal pings
+func (collector *Collector) ConsumePingFailedEvent(failedEvent discovery.PingFailedEvent) {
+	collector.pingFailures.WithLabelValues(failedEvent.Proposal.ServiceType).Inc()
+}
+
+// CollectServiceSessions registers the metrics of the sessions currently served by the provider
+func (collector *Collector) CollectServiceSessions(sessions ServiceSessions) {
+	collector.registerer.MustRegister(newServiceSessionsCollector(sessions))
+}
+
+// CollectPromises registers the metrics of the promises received by the node.
+// The promises stored so far are counted once, later ones are counted from the events published by the storage.
+func (collector *Collector) CollectPromises(promises PromiseStorage) {
+	for _, issuer := range promises.GetAllKnownIssuers() {
+		storedPromises, err := promises.GetAllPromisesFromIssuer(issuer)
+		if err != nil {
+			log.Warn(collectorLogPrefix, "Failed to load promises of issuer ", issuer.Address, ": ", err)
+			continue
+		}
+
+		for _, storedPromise := range storedPromises {
+			// promise is only reserved for the consumer until its first message arrives
+			if storedPromise.Message != nil {
+				collector.promises.Inc()
+				collector.promiseAmount.Add(float64(storedPromise.Message.Amount))
+			}
+		}
+	}
+	collector.registerer.MustRegister(collector.promises, collector.promiseAmount)
+}
+
+func (collector *Collector) addBytes(previous, current consumer.SessionStatistics) {
+	collector.connectionBytes.WithLabelValues(directionSent).Add(bytesDelta(previous.BytesSent, current.BytesSent))
+	collector.connectionBytes.WithLabelValues(directionReceived).Add(bytesDelta(previous.BytesReceived, current.BytesReceived))
+}
+
+// bytesDelta calculates the bytes transferred since the previous statistics,
+// counters which went down were reset by the new session
+func bytesDelta(previous, current uint64) float64 {
+	if current < previous {
+		return float64(current)
+	}
+	return float64(current - previous)
+}
+
+// serviceSessionsCollector samples the sessions currently served by the provider on every scrape
+type serviceSessionsCollector struct {
+	sessions   ServiceSessions
+	activeDesc *prometheus.Desc
+	bytesDesc  *prometheus.Desc
+}
+
+func newServiceSessionsCollector(sessions ServiceSessions) *serviceSessionsCollector {
+	return &serviceSessionsCollector{
+		sessions: sessions,
+		activeDesc: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "", "service_sessions_active"),
+			"Sessions currently served by the service",
+			[]string{"service_id", "service_type"},
+			nil,
+		),
+		bytesDesc: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "", "service_session_bytes"),
+			"Bytes transferred during the sessions currently served by the service",
+			[]string{"service_id", "service_type", "direction"},
+			nil,
+		),
+	}
+}
+
+// Describe implements prometheus.Collector
+func (ssc *serviceSessionsCollector) Describe(descs chan<- *prometheus.Desc) {
+	descs <- ssc.activeDesc
+	descs <- ssc.bytesDesc
+}
+
+// Collect implements prometheus.Collector
+func (ssc *serviceSessionsCollector) Collect(metrics chan<- prometheus.Metric) {
+	counts := make(map[[2]string]int)
+	transferred := make(map[[3]string]uint64)
+	for _, sessionInstance := range ssc.sessions.GetAll() {
+		serviceID, serviceType := sessionInstance.ServiceID(), sessionInstance.ServiceType()
+		counts[[2]string{serviceID, serviceType}]++
+		transferred[[3]string{serviceID, serviceType, directionSent}] += sessionInstance.DataTransfer.BytesSent
+		transferred[[3]string{serviceID, serviceType, directionReceived}] += sessionInstance.DataTransfer.BytesReceived
+	}
+
+	for service, count := range counts {
+		metrics <- prometheus.MustNewConstMetric(ssc.activeDesc, prometheus.GaugeValue, float64(count), service[:]...)
+	}
+	for labels, bytes := range transferred {
+		metrics <- prometheus.MustNewConstMetric(ssc.bytesDesc, prometheus.GaugeValue, float64(bytes), labels[:]...)
+	}
+}
@@ -0,0 +1,178 @@
+/*
+ * Copyright (C) 2019 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package prometheus
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/mysteriumnetwork/node/communication/nats/dialog"
+	"github.com/mysteriumnetwork/node/consumer"
+	"github.com/mysteriumnetwork/node/core/connection"
+	"github.com/mysteriumnetwork/node/core/discovery"
+	"github.com/mysteriumnetwork/node/identity"
+	"github.com/mysteriumnetwork/node/market"
+	"github.com/mysteriumnetwork/node/nat/event"
+	"github.com/mysteriumnetwork/node/session"
+	"github.com/mysteriumnetwork/node/session/promise"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/stretchr/testify/assert"
+)
+
+type serviceSessionsFake struct {
+	sessions []session.Session
+}
+
+func (fake *serviceSessionsFake) GetAll() []session.Session {
+	return fake.sessions
+}
+
+type promiseStorageFake struct {
+	promises map[identity.Identity][]promise.StoredPromise
+}
+
+func (fake *promiseStorageFake) GetAllKnownIssuers() []identity.Identity {
+	issuers := make([]identity.Identity, 0, len(fake.promises))
+	for issuer := range fake.promises {
+		issuers = append(issuers, issuer)
+	}
+	return issuers
+}
+
+func (fake *promiseStorageFake) GetAllPromisesFromIssuer(issuerID identity.Identity) ([]promise.StoredPromise, error) {
+	return fake.promises[issuerID], nil
+}
+
+func metricsOutput(t *testing.T, registry *prometheus.Registry) string {
+	resp := httptest.NewRecorder()
+	req, err := http.NewRequest(http.MethodGet, "/metrics", nil)
+	assert.NoError(t, err)
+
+	promhttp.HandlerFor(registry, promhttp.HandlerOpts{}).ServeHTTP(resp, req)
+	assert.Equal(t, http.StatusOK, resp.Code)
+	return resp.Body.String()
+}
+
+func TestCollectorCountsConnectionBytes(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	collector := NewCollector(registry)
+
+	collector.ConsumeSessionEvent(connection.SessionEvent{Status: connection.SessionCreatedStatus})
+	collector.ConsumeStatisticsEvent(consumer.SessionStatistics{BytesSent: 100, BytesReceived: 1000})
+	collector.ConsumeStatisticsEvent(consumer.SessionStatistics{BytesSent: 250, BytesReceived: 3000})
+	collector.ConsumeSessionEvent(connection.SessionEvent{Status: connection.SessionEndedStatus})
+	collector.ConsumeSessionEvent(connection.SessionEvent{Status: connection.SessionCreatedStatus})
+	collector.ConsumeStatisticsEvent(consumer.SessionStatistics{BytesSent: 50, BytesReceived: 500})
+
+	output := metricsOutput(t, registry)
+	assert.Contains(t, output, `myst_connection_bytes_total{direction="sent"} 300`+"\n")
+	assert.Contains(t, output, `myst_connection_bytes_total{direction="received"} 3500`+"\n")
+	assert.Contains(t, output, `myst_connection_sessions_total{status="Created"} 2`+"\n")
+	assert.Contains(t, output, `myst_connection_sessions_total{status="Ended"} 1`+"\n")
+}
+
+func TestCollectorCountsPooledConnections(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	collector := NewCollector(registry)
+	pooled := func(id string, event interface{}) connection.PooledEvent {
+		return connection.PooledEvent{ConnectionID: connection.ID(id), Event: event}
+	}
+
+	collector.ConsumeStatisticsEvent(consumer.SessionStatistics{BytesSent: 100, BytesReceived: 1000})
+	collector.ConsumePooledEvent(pooled("1", connection.SessionEvent{Status: connection.SessionCreatedStatus}))
+	collector.ConsumePooledEvent(pooled("1", consumer.SessionStatistics{BytesSent: 10, BytesReceived: 20}))
+	collector.ConsumePooledEvent(pooled("2", consumer.SessionStatistics{BytesSent: 5, BytesReceived: 5}))
+	collector.ConsumePooledEvent(pooled("1", consumer.SessionStatistics{BytesSent: 30, BytesReceived: 40}))
+	collector.ConsumePooledEvent(pooled("1", connection.StateEvent{State: connection.Connected}))
+
+	output := metricsOutput(t, registry)
+	assert.Contains(t, output, `myst_connection_bytes_total{direction="sent"} 135`+"\n")
+	assert.Contains(t, output, `myst_connection_bytes_total{direction="received"} 1045`+"\n")
+	assert.Contains(t, output, `myst_connection_sessions_total{status="Created"} 1`+"\n")
+	assert.NotContains(t, output, `myst_connection_state{state="Connected"} 1`)
+}
+
+func TestCollectorMarksConnectionState(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	collector := NewCollector(registry)
+
+	collector.ConsumeStateEvent(connection.StateEvent{State: connection.Connecting})
+	collector.ConsumeStateEvent(connection.StateEvent{State: connection.Connected})
+
+	output := metricsOutput(t, registry)
+	assert.Contains(t, output, `myst_connection_state{state="Connected"} 1`+"\n")
+	assert.Contains(t, output, `myst_connection_state{state="Connecting"} 0`+"\n")
+}
+
+func TestCollectorCountsFailures(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	collector := NewCollector(registry)
+
+	collector.ConsumeNATEvent(event.BuildSuccessfulEvent("port_mapping"))
+	collector.ConsumeNATEvent(event.BuildFailureEvent("hole_punching", errors.New("timeout")))
+	collector.ConsumeDialogFailedEvent(dialog.FailedEvent{Stage: dialog.FailedStageValidation})
+	collector.ConsumePingFailedEvent(discovery.PingFailedEvent{Proposal: market.ServiceProposal{ServiceType: "openvpn"}})
+	collector.ConsumePingFailedEvent(discovery.PingFailedEvent{Proposal: market.ServiceProposal{ServiceType: "openvpn"}})
+
+	output := metricsOutput(t, registry)
+	assert.Contains(t, output, `myst_nat_traversal_total{stage="hole_punching",successful="false"} 1`+"\n")
+	assert.Contains(t, output, `myst_nat_traversal_total{stage="port_mapping",successful="true"} 1`+"\n")
+	assert.Contains(t, output, `myst_dialog_failures_total{stage="validation"} 1`+"\n")
+	assert.Contains(t, output, `myst_discovery_ping_failures_total{service_type="openvpn"} 2`+"\n")
+}
+
+func TestCollectorCollectsServiceSessions(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	collector := NewCollector(registry)
+	collector.CollectServiceSessions(&serviceSessionsFake{
+		sessions: []session.Session{
+			{ID: "1", DataTransfer: session.DataTransfer{BytesSent: 10, BytesReceived: 20}},
+			{ID: "2", DataTransfer: session.DataTransfer{BytesSent: 5, BytesReceived: 1}},
+		},
+	})
+
+	output := metricsOutput(t, registry)
+	assert.Contains(t, output, `myst_service_sessions_active{service_id="",service_type=""} 2`+"\n")
+	assert.Contains(t, output, `myst_service_session_bytes{direction="sent",service_id="",service_type=""} 15`+"\n")
+	assert.Contains(t, output, `myst_service_session_bytes{direction="received",service_id="",service_type=""} 21`+"\n")
+}
+
+func TestCollectorCountsPromises(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	collector := NewCollector(registry)
+	collector.CollectPromises(&promiseStorageFake{
+		promises: map[identity.Identity][]promise.StoredPromise{
+			identity.FromAddress("0xissuer"): {
+				{SequenceID: 1, Message: &promise.Message{Amount: 100}, Cleared: true},
+				{SequenceID: 2, Message: &promise.Message{Amount: 40}},
+				{SequenceID: 3},
+			},
+		},
+	})
+
+	collector.ConsumePromiseAmountEvent(promise.AmountEvent{Increment: 10, First: true})
+	collector.ConsumePromiseAmountEvent(promise.AmountEvent{Increment: 5})
+
+	output := metricsOutput(t, registry)
+	assert.Contains(t, output, "myst_promises_total 3\n")
+	assert.Contains(t, output, "myst_promise_amount_total 155\n")
+	assert.NotContains(t, output, "0xissuer")
+}
@@ -24,6 +24,9 @@ import (
 const appName = "myst"
 const startupEventName = "startup"
 const natMappingEventName = "nat_mapping"
+const sessionStartedEventName = "session_started"
+const sessionEndedEventName = "session_ended"
+const connectionFailedEventName = "connection_failed"
 
 // Sender builds events and sends them using given transport
 type Sender struct {
@@ -37,6 +40,12 @@ type Transport interface {
 	SendEvent(Event) error
 }
 
+// BatchTransport allows sending several events at once
+type BatchTransport interface {
+	Transport
+	SendEvents([]Event) error
+}
+
 // Event contains data about event, which is sent using transport
 type Event struct {
 	Application appInfo     `json:"application"`
@@ -57,6 +66,12 @@ type natMappingContext struct {
 	Gateways     []map[string]string `json:"gateways,omitempty"`
 }
 
+type sessionContext struct {
+	SessionID   string `json:"session_id,omitempty"`
+	ProviderID  string `json:"provider_id"`
+	ServiceType string `json:"service_type"`
+}
+
 // SendStartupEvent sends startup event
 func (sender *Sender) SendStartupEvent() error {
 	return sender.sendEvent(startupEventName, nil)
@@ -75,6 +90,31 @@ func (sender *Sender) SendNATMappingFailEvent(stage string, err error) error {
 	return sender.sendEvent(natMappingEventName, context)
 }
 
+// SendSessionStartedEvent sends event about the consumer session established with the provider
+func (sender *Sender) SendSessionStartedEvent(sessionID, providerID, serviceType string) error {
+	context := sessionContext{SessionID: sessionID, ProviderID: providerID, ServiceType: serviceType}
+	return sender.sendEvent(sessionStartedEventName, context)
+}
+
+// SendSessionEndedEvent sends event about the finished consumer session
+func (sender *Sender) SendSessionEndedEvent(sessionID, providerID, serviceType string) error {
+	context := sessionContext{SessionID: sessionID, ProviderID: providerID, ServiceType: serviceType}
+	return sender.sendEvent(sessionEndedEventName, context)
+}
+
+// SendConnectionFailedEvent sends event about the failed attempt to connect to the provider
+func (sender *Sender) SendConnectionFailedEvent(providerID, serviceType string) error {
+	context := sessionContext{ProviderID: providerID, ServiceType: serviceType}
+	return sender.sendEvent(connectionFailedEventName, context)
+}
+
+// Stop stops delivering the events buffered by the transport
+func (sender *Sender) Stop() {
+	if bufferedTransport, ok := sender.Transport.(*BufferedTransport); ok {
+		bufferedTransport.Stop()
+	}
+}
+
 func (sender *Sender) sendEvent(eventName string, context interface{}) error {
 	app := appInfo{Name: appName, Version: sender.AppVersion}
 	event := Event{Application: app, EventName: eventName, CreatedAt: time.Now().Unix(), Context: context}
@@ -21,6 +21,8 @@ import (
 	"errors"
 	"testing"
 
+	"github.com/mysteriumnetwork/node/core/connection"
+	"github.com/mysteriumnetwork/node/market"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -124,3 +126,33 @@ func TestSender_SendNATMappingFailEvent_ReturnsTransportErrors(t *testing.T) {
 	err := sender.SendNATMappingFailEvent("hole_punching", errors.New("mock nat mapping error"))
 	assert.Error(t, err)
 }
+
+func TestSender_ConsumeSessionEvent_SendsSessionEvents(t *testing.T) {
+	mockTransport := buildMockEventsTransport(nil)
+	sender := &Sender{Transport: mockTransport, AppVersion: "test version", GatewayLoader: mockGatewayLoader}
+	sessionInfo := connection.SessionInfo{
+		SessionID: "session-id",
+		Proposal:  market.ServiceProposal{ProviderID: "0xprovider", ServiceType: "openvpn"},
+	}
+
+	sender.ConsumeSessionEvent(connection.SessionEvent{Status: connection.SessionCreatedStatus, SessionInfo: sessionInfo})
+	assert.Equal(t, "session_started", mockTransport.sentEvent.EventName)
+	assert.Equal(t, sessionContext{SessionID: "session-id", ProviderID: "0xprovider", ServiceType: "openvpn"}, mockTransport.sentEvent.Context)
+
+	sender.ConsumeSessionEvent(connection.SessionEvent{Status: connection.SessionEndedStatus, SessionInfo: sessionInfo})
+	assert.Equal(t, "session_ended", mockTransport.sentEvent.EventName)
+	assert.Equal(t, sessionContext{SessionID: "session-id", ProviderID: "0xprovider", ServiceType: "openvpn"}, mockTransport.sentEvent.Context)
+}
+
+func TestSender_ConsumeSessionEvent_SendsConnectionFailedEvent(t *testing.T) {
+	mockTransport := buildMockEventsTransport(nil)
+	sender := &Sender{Transport: mockTransport, AppVersion: "test version", GatewayLoader: mockGatewayLoader}
+
+	sender.ConsumeSessionEvent(connection.SessionEvent{
+		Status:      connection.SessionConnectFailedStatus,
+		SessionInfo: connection.SessionInfo{Proposal: market.ServiceProposal{ProviderID: "0xprovider", ServiceType: "wireguard"}},
+	})
+
+	assert.Equal(t, "connection_failed", mockTransport.sentEvent.EventName)
+	assert.Equal(t, sessionContext{ProviderID: "0xprovider", ServiceType: "wireguard"}, mockTransport.sentEvent.Context)
+}
@@ -0,0 +1,44 @@
+/*
+ * Copyright (C) 2019 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package metrics
+
+import (
+	log "github.com/cihub/seelog"
+	"github.com/mysteriumnetwork/node/core/connection"
+)
+
+const senderLogPrefix = "[metrics-sender] "
+
+// ConsumeSessionEvent sends events about the consumer sessions and the failed connections
+func (sender *Sender) ConsumeSessionEvent(sessionEvent connection.SessionEvent) {
+	sessionID := string(sessionEvent.SessionInfo.SessionID)
+	proposal := sessionEvent.SessionInfo.Proposal
+
+	var err error
+	switch sessionEvent.Status {
+	case connection.SessionCreatedStatus:
+		err = sender.SendSessionStartedEvent(sessionID, proposal.ProviderID, proposal.ServiceType)
+	case connection.SessionEndedStatus:
+		err = sender.SendSessionEndedEvent(sessionID, proposal.ProviderID, proposal.ServiceType)
+	case connection.SessionConnectFailedStatus:
+		err = sender.SendConnectionFailedEvent(proposal.ProviderID, proposal.ServiceType)
+	}
+	if err != nil {
+		log.Warn(senderLogPrefix, "Failed to send session event: ", err)
+	}
+}
@@ -0,0 +1,50 @@
+/*
+ * Copyright (C) 2019 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package metrics
+
+import (
+	"time"
+
+	"github.com/mysteriumnetwork/node/requests"
+)
+
+// NewWebhookTransport creates transport allowing to post events as JSON array to any HTTP endpoint
+func NewWebhookTransport(url string, timeout time.Duration) BatchTransport {
+	return &webhookTransport{
+		http: requests.NewHTTPClient(timeout),
+		url:  url,
+	}
+}
+
+type webhookTransport struct {
+	http requests.HTTPTransport
+	url  string
+}
+
+func (transport *webhookTransport) SendEvent(event Event) error {
+	return transport.SendEvents([]Event{event})
+}
+
+func (transport *webhookTransport) SendEvents(events []Event) error {
+	req, err := requests.NewPostRequest(transport.url, "", events)
+	if err != nil {
+		return err
+	}
+
+	return transport.http.DoRequest(req)
+}
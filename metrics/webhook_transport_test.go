@@ -0,0 +1,59 @@
+/*
+ * Copyright (C) 2019 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package metrics
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWebhookTransport_SendEvents_PostsJSONArray(t *testing.T) {
+	var body string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		bodyBytes, _ := ioutil.ReadAll(r.Body)
+		body = string(bodyBytes)
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	transport := NewWebhookTransport(server.URL, time.Second)
+	err := transport.SendEvents([]Event{{EventName: "startup", CreatedAt: 1}, {EventName: "session_started", CreatedAt: 2}})
+
+	assert.NoError(t, err)
+	assert.JSONEq(t, `[
+		{"application": {"name": "", "version": ""}, "eventName": "startup", "createdAt": 1, "context": null},
+		{"application": {"name": "", "version": ""}, "eventName": "session_started", "createdAt": 2, "context": null}
+	]`, body)
+}
+
+func TestWebhookTransport_SendEvent_WithUnexpectedStatus(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	transport := NewWebhookTransport(server.URL, time.Second)
+
+	assert.Error(t, transport.SendEvent(Event{}))
+}
@@ -220,7 +220,7 @@ func (ocf *OpenvpnConnectionFactory) Create(stateChannel connection.StateChannel
 			sessionConfig.RemotePort = sessionConfig.LocalPort + 1
 		}
 
-		vpnClientConfig, err := openvpn.NewClientConfigFromSession(sessionConfig, "", "")
+		vpnClientConfig, err := openvpn.NewClientConfigFromSession(sessionConfig, options.Routes, "", "")
 		if err != nil {
 			return nil, nil, err
 		}
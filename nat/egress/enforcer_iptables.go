@@ -0,0 +1,179 @@
+/*
+ * Copyright (C) 2019 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package egress
+
+import (
+	"fmt"
+	"hash/fnv"
+	"net"
+	"os/exec"
+	"strconv"
+	"strings"
+
+	log "github.com/cihub/seelog"
+	"github.com/mysteriumnetwork/node/nat"
+	"github.com/mysteriumnetwork/node/utils"
+)
+
+const (
+	forwardChain = "FORWARD"
+	inputChain   = "INPUT"
+	chainPrefix  = "MYST-EGRESS-"
+)
+
+// enforcerIPTables jumps from the FORWARD and INPUT chains to a separate chain per source network,
+// so the policy of every network can be removed and counted on its own.
+// INPUT chain covers the traffic to the addresses of the provider host, e.g. to its LAN address.
+type enforcerIPTables struct {
+	policy   Policy
+	run      func(args ...string) (string, error)
+	lookupIP func(host string) ([]net.IP, error)
+}
+
+func (enforcer *enforcerIPTables) Apply(source string) error {
+	binary, chain := iptablesBinary(source), chainName(source)
+
+	// chain might be left by the previous node run
+	if _, err := enforcer.run(binary, "--new-chain", chain); err != nil {
+		if _, err := enforcer.run(binary, "--flush", chain); err != nil {
+			return err
+		}
+	}
+
+	for _, rule := range enforcer.rules(nat.RuleForwarding{SourceAddress: source}.IPv6()) {
+		if _, err := enforcer.run(append([]string{binary, "--append", chain}, rule...)...); err != nil {
+			enforcer.Remove(source)
+			return err
+		}
+	}
+
+	for _, jump := range jumps(source, chain) {
+		enforcer.run(append([]string{binary, "--delete"}, jump...)...)
+		if _, err := enforcer.run(append([]string{binary, "--insert"}, jump...)...); err != nil {
+			enforcer.Remove(source)
+			return err
+		}
+	}
+
+	log.Info(egressLogPrefix, "Egress policy applied to ", source)
+	return nil
+}
+
+func (enforcer *enforcerIPTables) Remove(source string) (Stats, error) {
+	binary, chain := iptablesBinary(source), chainName(source)
+
+	stats, err := enforcer.Stats(source)
+	if err != nil {
+		log.Warn(egressLogPrefix, "Failed to read egress counters of ", source, ": ", err)
+	}
+
+	errs := utils.ErrorCollection{}
+	for _, jump := range jumps(source, chain) {
+		_, err = enforcer.run(append([]string{binary, "--delete"}, jump...)...)
+		errs.Add(err)
+	}
+	_, err = enforcer.run(binary, "--flush", chain)
+	errs.Add(err)
+	_, err = enforcer.run(binary, "--delete-chain", chain)
+	errs.Add(err)
+
+	log.Info(egressLogPrefix, "Egress policy removed from ", source)
+	return stats, errs.Errorf("failed to remove egress policy: %s", ", ")
+}
+
+func (enforcer *enforcerIPTables) Stats(source string) (Stats, error) {
+	output, err := enforcer.run(iptablesBinary(source), "--list", chainName(source), "--verbose", "--exact", "--numeric")
+	if err != nil {
+		return Stats{}, err
+	}
+	return parseCounters(output), nil
+}
+
+// jumps lists the rules jumping to the chain of the source network.
+// Traffic to the tunnel network itself, e.g. to the DNS forwarder on the tunnel address of the provider, is let thru.
+func jumps(source, chain string) [][]string {
+	return [][]string{
+		{forwardChain, "--source", source, "--jump", chain},
+		{inputChain, "--source", source, "!", "--destination", source, "--jump", chain},
+	}
+}
+
+// rules lists the arguments of every rule dropping the traffic not allowed by the policy
+func (enforcer *enforcerIPTables) rules(ipv6 bool) [][]string {
+	var rules [][]string
+	for _, port := range enforcer.policy.BlockedPorts {
+		for _, protocol := range []string{"tcp", "udp"} {
+			rules = append(rules, []string{
+				"--protocol", protocol, "--destination-port", strconv.Itoa(port),
+				"--match", "comment", "--comment", CategoryPort, "--jump", "DROP",
+			})
+		}
+	}
+	for _, destination := range enforcer.policy.destinations(ipv6, enforcer.lookupIP) {
+		rules = append(rules, []string{
+			"--destination", destination.network.String(),
+			"--match", "comment", "--comment", destination.category, "--jump", "DROP",
+		})
+	}
+	return rules
+}
+
+// parseCounters sums the packet counters of the listed rules by the category in their comments
+func parseCounters(output string) Stats {
+	var stats Stats
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		packets, err := strconv.ParseUint(fields[0], 10, 64)
+		if err != nil {
+			// chain and column headers
+			continue
+		}
+
+		start, end := strings.Index(line, "/* "), strings.Index(line, " */")
+		if start < 0 || end < start {
+			continue
+		}
+		stats.count(line[start+3:end], packets)
+	}
+	return stats
+}
+
+func iptablesBinary(source string) string {
+	if (nat.RuleForwarding{SourceAddress: source}).IPv6() {
+		return "/sbin/ip6tables"
+	}
+	return "/sbin/iptables"
+}
+
+// chainName derives the chain from the source network, so the chain left by the previous run is found again
+func chainName(source string) string {
+	hash := fnv.New32a()
+	hash.Write([]byte(source))
+	return fmt.Sprintf("%s%08X", chainPrefix, hash.Sum32())
+}
+
+func sudoOutput(args ...string) (string, error) {
+	output, err := exec.Command("sudo", args...).CombinedOutput()
+	if err != nil {
+		return string(output), fmt.Errorf("'sudo %v': %v output: %s", strings.Join(args, " "), err, output)
+	}
+	return string(output), nil
+}
@@ -0,0 +1,141 @@
+/*
+ * Copyright (C) 2019 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package egress
+
+import (
+	"errors"
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type commandRecorder struct {
+	commands []string
+	outputs  map[string]string
+	failing  map[string]bool
+}
+
+func (cr *commandRecorder) run(args ...string) (string, error) {
+	command := strings.Join(args, " ")
+	cr.commands = append(cr.commands, command)
+	if cr.failing[command] {
+		return "", errors.New("command failed")
+	}
+	return cr.outputs[command], nil
+}
+
+func newTestEnforcer(policy Policy) (*enforcerIPTables, *commandRecorder) {
+	recorder := &commandRecorder{outputs: make(map[string]string), failing: make(map[string]bool)}
+	enforcer := &enforcerIPTables{
+		policy: policy,
+		run:    recorder.run,
+		lookupIP: func(host string) ([]net.IP, error) {
+			return nil, errors.New("no such host")
+		},
+	}
+	return enforcer, recorder
+}
+
+func Test_enforcerIPTables_Apply(t *testing.T) {
+	enforcer, recorder := newTestEnforcer(Policy{
+		BlockedPorts: []int{25},
+		Blocklist:    Blocklist{Networks: []net.IPNet{cidr("198.51.100.0/24"), cidr("2001:db8::/32")}},
+	})
+
+	assert.NoError(t, enforcer.Apply("10.182.1.2/24"))
+
+	chain := chainName("10.182.1.2/24")
+	assert.Equal(
+		t,
+		[]string{
+			"/sbin/iptables --new-chain " + chain,
+			"/sbin/iptables --append " + chain + " --protocol tcp --destination-port 25 --match comment --comment port --jump DROP",
+			"/sbin/iptables --append " + chain + " --protocol udp --destination-port 25 --match comment --comment port --jump DROP",
+			"/sbin/iptables --append " + chain + " --destination 198.51.100.0/24 --match comment --comment blocklist --jump DROP",
+			"/sbin/iptables --delete FORWARD --source 10.182.1.2/24 --jump " + chain,
+			"/sbin/iptables --insert FORWARD --source 10.182.1.2/24 --jump " + chain,
+			"/sbin/iptables --delete INPUT --source 10.182.1.2/24 ! --destination 10.182.1.2/24 --jump " + chain,
+			"/sbin/iptables --insert INPUT --source 10.182.1.2/24 ! --destination 10.182.1.2/24 --jump " + chain,
+		},
+		recorder.commands,
+	)
+}
+
+func Test_enforcerIPTables_ApplyReusesLeftChain(t *testing.T) {
+	enforcer, recorder := newTestEnforcer(Policy{})
+	chain := chainName("fd10:182::/48")
+	recorder.failing["/sbin/ip6tables --new-chain "+chain] = true
+
+	assert.NoError(t, enforcer.Apply("fd10:182::/48"))
+	assert.Equal(t, "/sbin/ip6tables --flush "+chain, recorder.commands[1])
+}
+
+func Test_enforcerIPTables_ApplyCleansUpOnFailure(t *testing.T) {
+	enforcer, recorder := newTestEnforcer(Policy{})
+	chain := chainName("10.8.0.0/24")
+	recorder.failing["/sbin/iptables --insert FORWARD --source 10.8.0.0/24 --jump "+chain] = true
+
+	assert.Error(t, enforcer.Apply("10.8.0.0/24"))
+	assert.Equal(t, "/sbin/iptables --delete-chain "+chain, recorder.commands[len(recorder.commands)-1])
+}
+
+func Test_enforcerIPTables_ApplyCleansUpOnInputFailure(t *testing.T) {
+	enforcer, recorder := newTestEnforcer(Policy{})
+	chain := chainName("10.8.0.0/24")
+	recorder.failing["/sbin/iptables --insert INPUT --source 10.8.0.0/24 ! --destination 10.8.0.0/24 --jump "+chain] = true
+
+	assert.Error(t, enforcer.Apply("10.8.0.0/24"))
+	assert.Contains(t, recorder.commands, "/sbin/iptables --delete FORWARD --source 10.8.0.0/24 --jump "+chain)
+	assert.Equal(t, "/sbin/iptables --delete-chain "+chain, recorder.commands[len(recorder.commands)-1])
+}
+
+func Test_enforcerIPTables_RemoveReturnsCounters(t *testing.T) {
+	enforcer, recorder := newTestEnforcer(Policy{})
+	chain := chainName("10.8.0.0/24")
+	recorder.outputs["/sbin/iptables --list "+chain+" --verbose --exact --numeric"] = `Chain ` + chain + ` (1 references)
+    pkts      bytes target     prot opt in     out     source               destination
+       3      180 DROP       tcp  --  *      *       0.0.0.0/0            0.0.0.0/0            tcp dpt:25 /* port */
+       2      120 DROP       udp  --  *      *       0.0.0.0/0            0.0.0.0/0            udp dpt:25 /* port */
+       7      420 DROP       all  --  *      *       0.0.0.0/0            10.0.0.0/8           /* private */
+       0        0 DROP       all  --  *      *       0.0.0.0/0            198.51.100.0/24      /* blocklist */
+`
+
+	stats, err := enforcer.Remove("10.8.0.0/24")
+
+	assert.NoError(t, err)
+	assert.Equal(t, Stats{BlockedPorts: 5, BlockedPrivate: 7}, stats)
+	assert.Equal(
+		t,
+		[]string{
+			"/sbin/iptables --list " + chain + " --verbose --exact --numeric",
+			"/sbin/iptables --delete FORWARD --source 10.8.0.0/24 --jump " + chain,
+			"/sbin/iptables --delete INPUT --source 10.8.0.0/24 ! --destination 10.8.0.0/24 --jump " + chain,
+			"/sbin/iptables --flush " + chain,
+			"/sbin/iptables --delete-chain " + chain,
+		},
+		recorder.commands,
+	)
+}
+
+func Test_chainName(t *testing.T) {
+	assert.Equal(t, chainName("10.8.0.0/24"), chainName("10.8.0.0/24"))
+	assert.NotEqual(t, chainName("10.8.0.0/24"), chainName("10.182.1.2/24"))
+	assert.True(t, len(chainName("10.8.0.0/24")) < 29, "iptables chain name is too long")
+}
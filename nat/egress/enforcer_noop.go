@@ -0,0 +1,43 @@
+/*
+ * Copyright (C) 2019 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package egress
+
+import (
+	"sync"
+
+	log "github.com/cihub/seelog"
+)
+
+type enforcerNoop struct {
+	once sync.Once
+}
+
+func (enforcer *enforcerNoop) Apply(source string) error {
+	enforcer.once.Do(func() {
+		log.Warn(egressLogPrefix, "Egress policy is not supported on this platform, consumer traffic is not filtered")
+	})
+	return nil
+}
+
+func (enforcer *enforcerNoop) Remove(source string) (Stats, error) {
+	return Stats{}, nil
+}
+
+func (enforcer *enforcerNoop) Stats(source string) (Stats, error) {
+	return Stats{}, nil
+}
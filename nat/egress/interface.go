@@ -0,0 +1,62 @@
+/*
+ * Copyright (C) 2019 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package egress
+
+const egressLogPrefix = "[egress] "
+
+// Enforcer drops the traffic of a consumer network which is not allowed by the egress policy
+type Enforcer interface {
+	// Apply starts enforcing the policy on the traffic from the source network, both forwarded and to the host itself
+	Apply(source string) error
+	// Remove stops enforcing the policy and returns the final counters of the source network
+	Remove(source string) (Stats, error)
+	// Stats returns the counters of the traffic blocked from the source network
+	Stats(source string) (Stats, error)
+}
+
+// Reporter provides the counters of the traffic blocked by the egress policy
+type Reporter interface {
+	Stats() Stats
+}
+
+// Stats holds the number of packets blocked by every category of the policy
+type Stats struct {
+	BlockedPorts     uint64
+	BlockedPrivate   uint64
+	BlockedBlocklist uint64
+}
+
+// Add sums the counters of both stats
+func (stats Stats) Add(other Stats) Stats {
+	return Stats{
+		BlockedPorts:     stats.BlockedPorts + other.BlockedPorts,
+		BlockedPrivate:   stats.BlockedPrivate + other.BlockedPrivate,
+		BlockedBlocklist: stats.BlockedBlocklist + other.BlockedBlocklist,
+	}
+}
+
+func (stats *Stats) count(category string, packets uint64) {
+	switch category {
+	case CategoryPort:
+		stats.BlockedPorts += packets
+	case CategoryPrivate:
+		stats.BlockedPrivate += packets
+	case CategoryBlocklist:
+		stats.BlockedBlocklist += packets
+	}
+}
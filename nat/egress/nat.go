@@ -0,0 +1,150 @@
+/*
+ * Copyright (C) 2019 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package egress
+
+import (
+	"sync"
+	"time"
+
+	log "github.com/cihub/seelog"
+	"github.com/mysteriumnetwork/node/nat"
+	"github.com/pkg/errors"
+)
+
+// statsTTL limits how often the counters are read from the firewall, services are listed with their counters frequently
+const statsTTL = 10 * time.Second
+
+// NATService enforces the egress policy on every consumer network forwarded by the underlying NAT service
+type NATService struct {
+	natService nat.NATService
+	enforcer   Enforcer
+	now        func() time.Time
+
+	mu      sync.Mutex
+	sources map[string]struct{}
+	// removed keeps the counters of the networks which are not forwarded anymore
+	removed Stats
+	// forwarded caches the counters of the forwarded networks, it is dropped when the networks change
+	forwarded     Stats
+	forwardedRead time.Time
+	// changes counts the changes of the forwarded networks, so the counters read meanwhile are not cached
+	changes uint64
+}
+
+// NewNATService returns NAT service which applies the egress policy before forwarding the consumer network
+func NewNATService(natService nat.NATService, enforcer Enforcer) *NATService {
+	return &NATService{
+		natService: natService,
+		enforcer:   enforcer,
+		now:        time.Now,
+		sources:    make(map[string]struct{}),
+	}
+}
+
+// Enable enables the underlying NAT service
+func (service *NATService) Enable() error {
+	return service.natService.Enable()
+}
+
+// Disable disables the underlying NAT service
+func (service *NATService) Disable() error {
+	return service.natService.Disable()
+}
+
+// Add applies the egress policy to the source network first, so the forwarded traffic is never unfiltered
+func (service *NATService) Add(rule nat.RuleForwarding) error {
+	if err := service.enforcer.Apply(rule.SourceAddress); err != nil {
+		return errors.Wrap(err, "failed to apply egress policy")
+	}
+
+	if err := service.natService.Add(rule); err != nil {
+		if _, removeErr := service.enforcer.Remove(rule.SourceAddress); removeErr != nil {
+			log.Error(egressLogPrefix, "Failed to remove egress policy: ", removeErr)
+		}
+		return err
+	}
+
+	service.mu.Lock()
+	defer service.mu.Unlock()
+
+	service.sources[rule.SourceAddress] = struct{}{}
+	service.forwardedRead = time.Time{}
+	service.changes++
+	return nil
+}
+
+// Del stops forwarding the source network and removes its egress policy
+func (service *NATService) Del(rule nat.RuleForwarding) error {
+	if err := service.natService.Del(rule); err != nil {
+		return err
+	}
+
+	stats, err := service.enforcer.Remove(rule.SourceAddress)
+
+	service.mu.Lock()
+	defer service.mu.Unlock()
+
+	delete(service.sources, rule.SourceAddress)
+	service.removed = service.removed.Add(stats)
+	service.forwardedRead = time.Time{}
+	service.changes++
+	return errors.Wrap(err, "failed to remove egress policy")
+}
+
+// Stats returns the counters of the traffic blocked from all networks forwarded by the service.
+// Counters of the forwarded networks are read from the firewall at most once per statsTTL, without holding the lock.
+func (service *NATService) Stats() Stats {
+	service.mu.Lock()
+	now := service.now()
+	if !service.forwardedRead.IsZero() && now.Sub(service.forwardedRead) < statsTTL {
+		defer service.mu.Unlock()
+		return service.removed.Add(service.forwarded)
+	}
+	sources := make([]string, 0, len(service.sources))
+	for source := range service.sources {
+		sources = append(sources, source)
+	}
+	changes := service.changes
+	service.mu.Unlock()
+
+	read := make(map[string]Stats, len(sources))
+	for _, source := range sources {
+		stats, err := service.enforcer.Stats(source)
+		if err != nil {
+			log.Warn(egressLogPrefix, "Failed to read egress counters of ", source, ": ", err)
+			continue
+		}
+		read[source] = stats
+	}
+
+	service.mu.Lock()
+	defer service.mu.Unlock()
+
+	var forwarded Stats
+	for source, stats := range read {
+		// counters of the networks removed meanwhile are already counted as removed
+		if _, ok := service.sources[source]; ok {
+			forwarded = forwarded.Add(stats)
+		}
+	}
+	if changes == service.changes {
+		service.forwarded = forwarded
+		service.forwardedRead = now
+	}
+	return service.removed.Add(forwarded)
+}
@@ -0,0 +1,157 @@
+/*
+ * Copyright (C) 2019 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package egress
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/mysteriumnetwork/node/nat"
+	"github.com/stretchr/testify/assert"
+)
+
+type natServiceMock struct {
+	rules  map[nat.RuleForwarding]struct{}
+	addErr error
+}
+
+func (service *natServiceMock) Enable() error  { return nil }
+func (service *natServiceMock) Disable() error { return nil }
+func (service *natServiceMock) Add(rule nat.RuleForwarding) error {
+	if service.addErr != nil {
+		return service.addErr
+	}
+	service.rules[rule] = struct{}{}
+	return nil
+}
+func (service *natServiceMock) Del(rule nat.RuleForwarding) error {
+	delete(service.rules, rule)
+	return nil
+}
+
+type enforcerMock struct {
+	sources    map[string]Stats
+	applyErr   error
+	statsReads int
+	// onStats is called while the counters are read
+	onStats func()
+}
+
+func (enforcer *enforcerMock) Apply(source string) error {
+	if enforcer.applyErr != nil {
+		return enforcer.applyErr
+	}
+	enforcer.sources[source] = Stats{}
+	return nil
+}
+func (enforcer *enforcerMock) Remove(source string) (Stats, error) {
+	stats := enforcer.sources[source]
+	delete(enforcer.sources, source)
+	return stats, nil
+}
+func (enforcer *enforcerMock) Stats(source string) (Stats, error) {
+	enforcer.statsReads++
+	stats := enforcer.sources[source]
+	if enforcer.onStats != nil {
+		enforcer.onStats()
+	}
+	return stats, nil
+}
+
+func newTestNATService() (*NATService, *natServiceMock, *enforcerMock) {
+	natService := &natServiceMock{rules: make(map[nat.RuleForwarding]struct{})}
+	enforcer := &enforcerMock{sources: make(map[string]Stats)}
+	return NewNATService(natService, enforcer), natService, enforcer
+}
+
+func Test_NATService_AddAppliesPolicy(t *testing.T) {
+	service, natService, enforcer := newTestNATService()
+	rule := nat.RuleForwarding{SourceAddress: "10.8.0.0/24", TargetIP: "192.168.1.10"}
+
+	assert.NoError(t, service.Add(rule))
+
+	assert.Contains(t, natService.rules, rule)
+	assert.Contains(t, enforcer.sources, "10.8.0.0/24")
+}
+
+func Test_NATService_AddDoesNotForwardIfPolicyFails(t *testing.T) {
+	service, natService, enforcer := newTestNATService()
+	enforcer.applyErr = errors.New("iptables failed")
+
+	assert.EqualError(t, service.Add(nat.RuleForwarding{SourceAddress: "10.8.0.0/24"}), "failed to apply egress policy: iptables failed")
+	assert.Empty(t, natService.rules)
+}
+
+func Test_NATService_AddRemovesPolicyIfForwardingFails(t *testing.T) {
+	service, natService, enforcer := newTestNATService()
+	natService.addErr = errors.New("rule already exists")
+
+	assert.Error(t, service.Add(nat.RuleForwarding{SourceAddress: "10.8.0.0/24"}))
+	assert.Empty(t, enforcer.sources)
+}
+
+func Test_NATService_StatsIncludeRemovedNetworks(t *testing.T) {
+	service, _, enforcer := newTestNATService()
+	rule1 := nat.RuleForwarding{SourceAddress: "10.182.1.2/24"}
+	rule2 := nat.RuleForwarding{SourceAddress: "10.182.2.2/24"}
+	assert.NoError(t, service.Add(rule1))
+	assert.NoError(t, service.Add(rule2))
+	enforcer.sources[rule1.SourceAddress] = Stats{BlockedPorts: 2}
+	enforcer.sources[rule2.SourceAddress] = Stats{BlockedPrivate: 3}
+
+	assert.NoError(t, service.Del(rule1))
+
+	assert.Equal(t, Stats{BlockedPorts: 2, BlockedPrivate: 3}, service.Stats())
+}
+
+func Test_NATService_StatsAreCached(t *testing.T) {
+	service, _, enforcer := newTestNATService()
+	now := time.Now()
+	service.now = func() time.Time { return now }
+	rule := nat.RuleForwarding{SourceAddress: "10.182.1.2/24"}
+	assert.NoError(t, service.Add(rule))
+	enforcer.sources[rule.SourceAddress] = Stats{BlockedPorts: 2}
+
+	assert.Equal(t, Stats{BlockedPorts: 2}, service.Stats())
+	enforcer.sources[rule.SourceAddress] = Stats{BlockedPorts: 5}
+	assert.Equal(t, Stats{BlockedPorts: 2}, service.Stats())
+	assert.Equal(t, 1, enforcer.statsReads)
+
+	now = now.Add(statsTTL)
+	assert.Equal(t, Stats{BlockedPorts: 5}, service.Stats())
+	assert.Equal(t, 2, enforcer.statsReads)
+
+	assert.NoError(t, service.Del(rule))
+	assert.Equal(t, Stats{BlockedPorts: 5}, service.Stats())
+}
+
+func Test_NATService_StatsAreReadWithoutLocking(t *testing.T) {
+	service, _, enforcer := newTestNATService()
+	rule := nat.RuleForwarding{SourceAddress: "10.182.1.2/24"}
+	assert.NoError(t, service.Add(rule))
+	enforcer.sources[rule.SourceAddress] = Stats{BlockedPorts: 2}
+
+	enforcer.onStats = func() {
+		enforcer.onStats = nil
+		assert.NoError(t, service.Del(rule))
+	}
+
+	assert.Equal(t, Stats{BlockedPorts: 2}, service.Stats())
+	assert.Equal(t, Stats{BlockedPorts: 2}, service.Stats())
+}
@@ -0,0 +1,144 @@
+/*
+ * Copyright (C) 2019 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package egress
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strings"
+
+	log "github.com/cihub/seelog"
+	"github.com/mysteriumnetwork/node/netutil"
+)
+
+// Categories of the policy rules, blocked traffic is counted per category
+const (
+	CategoryPort      = "port"
+	CategoryPrivate   = "private"
+	CategoryBlocklist = "blocklist"
+)
+
+var (
+	privateNetworks = []string{
+		"10.0.0.0/8",
+		"172.16.0.0/12",
+		"192.168.0.0/16",
+		"100.64.0.0/10",
+		"169.254.0.0/16",
+		"fc00::/7",
+		"fe80::/10",
+	}
+)
+
+// Policy describes the consumer traffic which provider refuses to forward
+type Policy struct {
+	// BlockedPorts are the destination TCP and UDP ports, e.g. 25 to keep provider from becoming a spam relay
+	BlockedPorts []int
+	// BlockPrivate blocks the private and link-local networks, e.g. the LAN of the provider
+	BlockPrivate bool
+	Blocklist    Blocklist
+}
+
+// Blocklist holds the destinations loaded from the blocklist file.
+// Domains are resolved once the policy is applied to a consumer network and are not resolved again while it is applied,
+// so the addresses the domains move to later are blocked only for the consumer networks forwarded afterwards.
+type Blocklist struct {
+	Networks []net.IPNet
+	Domains  []string
+}
+
+// LoadBlocklist reads the blocklist file having a destination CIDR, IP or domain per line, lines starting with # are comments
+func LoadBlocklist(path string) (Blocklist, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return Blocklist{}, err
+	}
+	defer file.Close()
+
+	return ParseBlocklist(file)
+}
+
+// ParseBlocklist reads the blocklist having a destination CIDR, IP or domain per line, lines starting with # are comments
+func ParseBlocklist(reader io.Reader) (Blocklist, error) {
+	var blocklist Blocklist
+
+	scanner := bufio.NewScanner(reader)
+	for line := 1; scanner.Scan(); line++ {
+		entry := strings.TrimSpace(scanner.Text())
+		if entry == "" || strings.HasPrefix(entry, "#") {
+			continue
+		}
+
+		if strings.Contains(entry, "/") {
+			_, network, err := net.ParseCIDR(entry)
+			if err != nil {
+				return Blocklist{}, fmt.Errorf("invalid blocklist network on line %d: %v", line, err)
+			}
+			blocklist.Networks = append(blocklist.Networks, *network)
+		} else if ip := net.ParseIP(entry); ip != nil {
+			blocklist.Networks = append(blocklist.Networks, netutil.HostNetwork(ip))
+		} else if netutil.IsDomain(entry) {
+			blocklist.Domains = append(blocklist.Domains, strings.ToLower(entry))
+		} else {
+			return Blocklist{}, fmt.Errorf("invalid blocklist entry on line %d: %s", line, entry)
+		}
+	}
+
+	return blocklist, scanner.Err()
+}
+
+type destination struct {
+	network  net.IPNet
+	category string
+}
+
+// destinations lists the blocked networks of the given IP family, domains are resolved on every call
+// since their addresses change
+func (policy Policy) destinations(ipv6 bool, lookupIP func(host string) ([]net.IP, error)) []destination {
+	var destinations []destination
+	add := func(network net.IPNet, category string) {
+		if (network.IP.To4() == nil) == ipv6 {
+			destinations = append(destinations, destination{network: network, category: category})
+		}
+	}
+
+	if policy.BlockPrivate {
+		for _, cidr := range privateNetworks {
+			_, network, _ := net.ParseCIDR(cidr)
+			add(*network, CategoryPrivate)
+		}
+	}
+	for _, network := range policy.Blocklist.Networks {
+		add(network, CategoryBlocklist)
+	}
+	for _, domain := range policy.Blocklist.Domains {
+		ips, err := lookupIP(domain)
+		if err != nil {
+			log.Warn(egressLogPrefix, "Failed to resolve blocked domain ", domain, ": ", err)
+			continue
+		}
+		for _, ip := range ips {
+			add(netutil.HostNetwork(ip), CategoryBlocklist)
+		}
+	}
+
+	return destinations
+}
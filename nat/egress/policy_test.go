@@ -0,0 +1,105 @@
+/*
+ * Copyright (C) 2019 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package egress
+
+import (
+	"errors"
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_ParseBlocklist(t *testing.T) {
+	blocklist, err := ParseBlocklist(strings.NewReader(`
+# spam sources
+198.51.100.0/24
+203.0.113.7
+2001:db8::/32
+Mail.Example.com
+`))
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"198.51.100.0/24", "203.0.113.7/32", "2001:db8::/32"}, networkStrings(blocklist.Networks))
+	assert.Equal(t, []string{"mail.example.com"}, blocklist.Domains)
+}
+
+func Test_ParseBlocklist_RejectsInvalidEntries(t *testing.T) {
+	_, err := ParseBlocklist(strings.NewReader("10.0.0.0/8\n10.0.0.0/99\n"))
+	assert.EqualError(t, err, "invalid blocklist network on line 2: invalid CIDR address: 10.0.0.0/99")
+
+	_, err = ParseBlocklist(strings.NewReader("not a domain"))
+	assert.EqualError(t, err, "invalid blocklist entry on line 1: not a domain")
+}
+
+func Test_Policy_destinationsAreSplitByFamily(t *testing.T) {
+	_, network, _ := net.ParseCIDR("198.51.100.0/24")
+	policy := Policy{
+		BlockPrivate: true,
+		Blocklist: Blocklist{
+			Networks: []net.IPNet{*network},
+			Domains:  []string{"mail.example.com", "unknown.example.com"},
+		},
+	}
+	lookupIP := func(host string) ([]net.IP, error) {
+		if host == "mail.example.com" {
+			return []net.IP{net.ParseIP("203.0.113.7"), net.ParseIP("2001:db8::7")}, nil
+		}
+		return nil, errors.New("no such host")
+	}
+
+	assert.Equal(
+		t,
+		[]destination{
+			{network: cidr("10.0.0.0/8"), category: CategoryPrivate},
+			{network: cidr("172.16.0.0/12"), category: CategoryPrivate},
+			{network: cidr("192.168.0.0/16"), category: CategoryPrivate},
+			{network: cidr("100.64.0.0/10"), category: CategoryPrivate},
+			{network: cidr("169.254.0.0/16"), category: CategoryPrivate},
+			{network: cidr("198.51.100.0/24"), category: CategoryBlocklist},
+			{network: cidr("203.0.113.7/32"), category: CategoryBlocklist},
+		},
+		policy.destinations(false, lookupIP),
+	)
+	assert.Equal(
+		t,
+		[]destination{
+			{network: cidr("fc00::/7"), category: CategoryPrivate},
+			{network: cidr("fe80::/10"), category: CategoryPrivate},
+			{network: cidr("2001:db8::7/128"), category: CategoryBlocklist},
+		},
+		policy.destinations(true, lookupIP),
+	)
+}
+
+func cidr(value string) net.IPNet {
+	ip, network, _ := net.ParseCIDR(value)
+	if ip4 := ip.To4(); ip4 != nil {
+		network.IP = ip4
+	}
+	return *network
+}
+
+func networkStrings(networks []net.IPNet) []string {
+	var values []string
+	for _, network := range networks {
+		values = append(values, network.String())
+	}
+	return values
+}
@@ -30,6 +30,14 @@ func NewService() NATService {
 			CommandDisable: []string{"sudo", "/sbin/sysctl", "-w", "net.ipv4.ip_forward=0"},
 			CommandRead:    []string{"/sbin/sysctl", "-n", "net.ipv4.ip_forward"},
 		},
+		ip6Forward: serviceIPForward{
+			CommandFactory: func(name string, arg ...string) Command {
+				return exec.Command(name, arg...)
+			},
+			CommandEnable:  []string{"sudo", "/sbin/sysctl", "-w", "net.ipv6.conf.all.forwarding=1"},
+			CommandDisable: []string{"sudo", "/sbin/sysctl", "-w", "net.ipv6.conf.all.forwarding=0"},
+			CommandRead:    []string{"/sbin/sysctl", "-n", "net.ipv6.conf.all.forwarding"},
+		},
 		rules: make(map[RuleForwarding]struct{}),
 	}
 }
@@ -17,6 +17,8 @@
 
 package nat
 
+import "net"
+
 // NATService describes fake nat service for darwin
 type NATService interface {
 	Enable() error
@@ -28,5 +30,15 @@ type NATService interface {
 // RuleForwarding describes fake nat rule
 type RuleForwarding struct {
 	SourceAddress string
-	TargetIP      string
+	// TargetIP is not used for IPv6 rules, IPv6 traffic is masqueraded
+	TargetIP string
+}
+
+// IPv6 checks whether the rule forwards IPv6 traffic
+func (rule RuleForwarding) IPv6() bool {
+	ip, _, err := net.ParseCIDR(rule.SourceAddress)
+	if err != nil {
+		ip = net.ParseIP(rule.SourceAddress)
+	}
+	return ip != nil && ip.To4() == nil
 }
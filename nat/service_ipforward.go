@@ -28,7 +28,10 @@ type serviceIPForward struct {
 	CommandDisable []string
 	CommandRead    []string
 	CommandFactory CommandFactory
-	forward        bool
+	// forward is set if forwarding was enabled before the service started
+	forward bool
+	// enabled is set if forwarding was enabled by the service, only then it is disabled again
+	enabled bool
 }
 
 // CommandFactory is responsible for creating new instances of command
@@ -41,6 +44,9 @@ type Command interface {
 }
 
 func (service *serviceIPForward) Enable() error {
+	if service.enabled {
+		return nil
+	}
 	if service.Enabled() {
 		service.forward = true
 		log.Info(natLogPrefix, "IP forwarding already enabled")
@@ -52,14 +58,17 @@ func (service *serviceIPForward) Enable() error {
 		return err
 	}
 
+	service.enabled = true
 	log.Info(natLogPrefix, "IP forwarding enabled")
 	return nil
 }
 
+// Disable restores the previous value, forwarding is disabled only if the service has enabled it
 func (service *serviceIPForward) Disable() {
-	if service.forward {
+	if service.forward || !service.enabled {
 		return
 	}
+	service.enabled = false
 
 	if output, err := service.CommandFactory(service.CommandDisable[0], service.CommandDisable[1:]...).CombinedOutput(); err != nil {
 		log.Warn("Failed to disable IP forwarding: ", service.CommandDisable[1:], " Returned exit error: ", err.Error(), " Cmd output: ", string(output))
@@ -41,9 +41,11 @@ func (mc *mockCommand) Output() ([]byte, error) {
 
 type mockCommandFactory struct {
 	MockCommand Command
+	Created     []string
 }
 
 func (mcf *mockCommandFactory) Create(name string, arg ...string) Command {
+	mcf.Created = append(mcf.Created, name)
 	return mcf.MockCommand
 }
 
@@ -114,3 +116,23 @@ func Test_ServiceIPForward_Disable(t *testing.T) {
 	service.forward = true
 	service.Disable()
 }
+
+func Test_ServiceIPForward_DisableRestoresPreviousValue(t *testing.T) {
+	mc := &mockCommand{OutputRes: []byte("0")}
+	mf := &mockCommandFactory{MockCommand: mc}
+	service := &serviceIPForward{
+		CommandFactory: mf.Create,
+		CommandRead:    []string{"read"},
+		CommandEnable:  []string{"enable"},
+		CommandDisable: []string{"disable"},
+	}
+
+	service.Disable()
+	assert.Empty(t, mf.Created)
+
+	assert.NoError(t, service.Enable())
+	assert.NoError(t, service.Enable())
+	service.Disable()
+	service.Disable()
+	assert.Equal(t, []string{"read", "enable", "disable"}, mf.Created)
+}
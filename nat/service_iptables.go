@@ -28,9 +28,10 @@ import (
 const natLogPrefix = "[nat] "
 
 type serviceIPTables struct {
-	mu        sync.Mutex
-	rules     map[RuleForwarding]struct{}
-	ipForward serviceIPForward
+	mu         sync.Mutex
+	rules      map[RuleForwarding]struct{}
+	ipForward  serviceIPForward
+	ip6Forward serviceIPForward
 }
 
 func (service *serviceIPTables) Add(rule RuleForwarding) error {
@@ -40,6 +41,12 @@ func (service *serviceIPTables) Add(rule RuleForwarding) error {
 	if _, ok := service.rules[rule]; ok {
 		return errors.New("rule already exists")
 	}
+	// IPv6 forwarding makes the host ignore router advertisements, so it is enabled only once IPv6 traffic is forwarded
+	if rule.IPv6() {
+		if err := service.ip6Forward.Enable(); err != nil {
+			return errors.Wrap(err, "failed to enable IPv6 forwarding")
+		}
+	}
 	service.rules[rule] = struct{}{}
 
 	err := iptables("append", rule)
@@ -67,11 +74,12 @@ func (service *serviceIPTables) Enable() error {
 }
 
 func (service *serviceIPTables) Disable() (err error) {
-	service.ipForward.Disable()
-
 	service.mu.Lock()
 	defer service.mu.Unlock()
 
+	service.ipForward.Disable()
+	service.ip6Forward.Disable()
+
 	for rule := range service.rules {
 		if delErr := iptables("delete", rule); delErr != nil && err == nil {
 			err = delErr
@@ -81,11 +89,7 @@ func (service *serviceIPTables) Disable() (err error) {
 }
 
 func iptables(action string, rule RuleForwarding) error {
-	arguments := "/sbin/iptables --table nat --" + action + " POSTROUTING --source " +
-		rule.SourceAddress + " ! --destination " +
-		rule.SourceAddress + " --jump SNAT --to " +
-		rule.TargetIP
-	cmd := utils.SplitCommand("sudo", arguments)
+	cmd := utils.SplitCommand("sudo", iptablesArguments(action, rule))
 	if output, err := cmd.CombinedOutput(); err != nil {
 		log.Warn("Failed to "+action+" ip forwarding rule: ", cmd.Args, " Returned exit error: ", err.Error(), " Cmd output: ", string(output))
 		return errors.Wrap(err, string(output))
@@ -94,3 +98,16 @@ func iptables(action string, rule RuleForwarding) error {
 	log.Info(natLogPrefix, "Action '"+action+"' applied for forwarding packets from '", rule.SourceAddress, "' to IP: ", rule.TargetIP)
 	return nil
 }
+
+func iptablesArguments(action string, rule RuleForwarding) string {
+	if rule.IPv6() {
+		return "/sbin/ip6tables --table nat --" + action + " POSTROUTING --source " +
+			rule.SourceAddress + " ! --destination " +
+			rule.SourceAddress + " --jump MASQUERADE"
+	}
+
+	return "/sbin/iptables --table nat --" + action + " POSTROUTING --source " +
+		rule.SourceAddress + " ! --destination " +
+		rule.SourceAddress + " --jump SNAT --to " +
+		rule.TargetIP
+}
@@ -0,0 +1,63 @@
+/*
+ * Copyright (C) 2019 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package nat
+
+import (
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_iptablesArguments_IPv4(t *testing.T) {
+	rule := RuleForwarding{SourceAddress: "10.182.0.0/24", TargetIP: "192.168.1.10"}
+
+	assert.False(t, rule.IPv6())
+	assert.Equal(
+		t,
+		"/sbin/iptables --table nat --append POSTROUTING --source 10.182.0.0/24 ! --destination 10.182.0.0/24 --jump SNAT --to 192.168.1.10",
+		iptablesArguments("append", rule),
+	)
+}
+
+func Test_iptablesArguments_IPv6(t *testing.T) {
+	rule := RuleForwarding{SourceAddress: "fd10:182::/48"}
+
+	assert.True(t, rule.IPv6())
+	assert.Equal(
+		t,
+		"/sbin/ip6tables --table nat --delete POSTROUTING --source fd10:182::/48 ! --destination fd10:182::/48 --jump MASQUERADE",
+		iptablesArguments("delete", rule),
+	)
+}
+
+func Test_serviceIPTables_Add_FailsWhenIPv6CanNotBeForwarded(t *testing.T) {
+	mc := &mockCommand{OutputRes: []byte("0"), CombinedOutputError: errors.New("permission denied")}
+	mf := &mockCommandFactory{MockCommand: mc}
+	service := &serviceIPTables{
+		rules: make(map[RuleForwarding]struct{}),
+		ip6Forward: serviceIPForward{
+			CommandFactory: mf.Create,
+			CommandRead:    []string{"read"},
+			CommandEnable:  []string{"enable"},
+		},
+	}
+
+	assert.Error(t, service.Add(RuleForwarding{SourceAddress: "fd10:182::/48"}))
+	assert.Empty(t, service.rules)
+}
@@ -36,6 +36,10 @@ type servicePFCtl struct {
 }
 
 func (service *servicePFCtl) Add(rule RuleForwarding) error {
+	if rule.IPv6() {
+		return errors.New("IPv6 forwarding is not supported")
+	}
+
 	service.mu.Lock()
 	service.rules[rule] = struct{}{}
 	service.mu.Unlock()
@@ -0,0 +1,41 @@
+/*
+ * Copyright (C) 2019 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package netutil
+
+import (
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsDomain(t *testing.T) {
+	assert.True(t, IsDomain("example.com"))
+	assert.True(t, IsDomain("sub-1.example.co.uk"))
+	assert.False(t, IsDomain("localhost"))
+	assert.False(t, IsDomain("-bad.example.com"))
+	assert.False(t, IsDomain("10.0.0.1"))
+}
+
+func TestHostNetwork(t *testing.T) {
+	network := HostNetwork(net.ParseIP("10.0.0.1"))
+	assert.Equal(t, "10.0.0.1/32", network.String())
+
+	network = HostNetwork(net.ParseIP("fd00::1"))
+	assert.Equal(t, "fd00::1/128", network.String())
+}
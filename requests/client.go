@@ -18,11 +18,15 @@
 package requests
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
+	"net"
 	"net/http"
 	"time"
+
+	"github.com/mysteriumnetwork/node/firewall"
 )
 
 // HTTPTransport describes a client for performing HTTP requests.
@@ -34,8 +38,19 @@ type HTTPTransport interface {
 
 // NewHTTPClient creates a new HTTP client.
 func NewHTTPClient(timeout time.Duration) *client {
+	return newClient(timeout, nil)
+}
+
+// NewControlHTTPClient creates a new HTTP client of the node's control traffic,
+// it passes the kill switch, so the node reaches its services while the tunnel is down.
+func NewControlHTTPClient(timeout time.Duration) *client {
+	return newClient(timeout, firewall.NewDialer(timeout).DialContext)
+}
+
+func newClient(timeout time.Duration, dial func(ctx context.Context, network, address string) (net.Conn, error)) *client {
 	return &client{
 		&http.Client{Transport: &http.Transport{
+			DialContext: dial,
 			//dont cache tcp connections - first requests after state change (direct -> tunneled and vice versa) will always fail
 			//as stale tcp states are not closed after switch. Probably some kind of CloseIdleConnections will help in the future
 			DisableKeepAlives: true,
@@ -58,4 +58,6 @@ func Bootstrap() {
 			return method, err
 		},
 	)
+
+	market.RegisterPaymentMethodUnserializer(market.PaymentMethodPerGB, market.UnserializePaymentPerGB)
 }
@@ -18,12 +18,15 @@
 package openvpn
 
 import (
+	"encoding/json"
+	"net"
 	"sync"
 
 	log "github.com/cihub/seelog"
 	"github.com/mysteriumnetwork/go-openvpn/openvpn"
 	"github.com/mysteriumnetwork/node/core/connection"
 	"github.com/mysteriumnetwork/node/core/ip"
+	"github.com/mysteriumnetwork/node/firewall"
 	"github.com/mysteriumnetwork/node/nat/traversal"
 	"github.com/pkg/errors"
 )
@@ -44,6 +47,7 @@ type NATPinger interface {
 type Client struct {
 	process        openvpn.Process
 	processFactory processFactory
+	clientConfig   *ClientConfig
 	ipResolver     ip.Resolver
 	natPinger      NATPinger
 	publicIP       string
@@ -61,6 +65,7 @@ func (c *Client) Start(options connection.ConnectOptions) error {
 		return err
 	}
 	c.process = proc
+	c.clientConfig = clientConfig
 	log.Infof("client config: %v", clientConfig)
 
 	if clientConfig.VpnConfig.LocalPort > 0 {
@@ -95,6 +100,31 @@ func (c *Client) Stop() {
 	})
 }
 
+// Tunnel describes the tunnel to the provider for the kill switch.
+// The provider address is taken from the session config, as the client config points to the local proxy of natted providers.
+// The tun device is known only once the connection is started.
+func (c *Client) Tunnel(options connection.ConnectOptions) (firewall.Tunnel, error) {
+	vpnConfig := &VPNConfig{}
+	if err := json.Unmarshal(options.SessionConfig, vpnConfig); err != nil {
+		return firewall.Tunnel{}, err
+	}
+
+	tunnel := firewall.Tunnel{
+		Endpoint: net.ParseIP(vpnConfig.RemoteIP),
+		Port:     vpnConfig.RemotePort,
+		Protocol: vpnConfig.RemoteProtocol,
+	}
+	if c.clientConfig != nil {
+		tunnel.Interface = c.clientConfig.Device
+	}
+	for _, dns := range vpnConfig.dnsServers() {
+		if ip := net.ParseIP(dns); ip != nil {
+			tunnel.DNS = append(tunnel.DNS, ip)
+		}
+	}
+	return tunnel, nil
+}
+
 // GetConfig returns the consumer-side configuration.
 func (c *Client) GetConfig() (connection.ConsumerConfig, error) {
 	ip, err := c.ipResolver.GetPublicIP()
@@ -125,4 +155,16 @@ type VPNConfig struct {
 	RemoteProtocol  string `json:"protocol"`
 	TLSPresharedKey string `json:"TLSPresharedKey"`
 	CACertificate   string `json:"CACertificate"`
+	// DNS servers reachable thru the tunnel, default ones are used if empty
+	DNS []string `json:"dns,omitempty"`
+	// KeepSystemDNS leaves the system DNS settings alone, they are configured by another connection
+	KeepSystemDNS bool `json:"-"`
+}
+
+// dnsServers returns the DNS servers consumer resolves names thru
+func (c *VPNConfig) dnsServers() []string {
+	if len(c.DNS) > 0 {
+		return c.DNS
+	}
+	return defaultDNSServers
 }
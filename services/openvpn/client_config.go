@@ -18,16 +18,23 @@
 package openvpn
 
 import (
+	"net"
 	"strconv"
 
 	"github.com/mysteriumnetwork/go-openvpn/openvpn/config"
+	"github.com/mysteriumnetwork/node/core/connection/split"
 )
 
+// defaultDNSServers are reached thru the tunnel if provider does not push its own
+var defaultDNSServers = []string{"208.67.222.222", "208.67.220.220"}
+
 // ClientConfig represents specific "openvpn as client" configuration
 type ClientConfig struct {
 	*config.GenericConfig
 	LocalPort int
 	VpnConfig *VPNConfig
+	// Device is the name of the tun device, it is empty if left for openvpn to choose
+	Device string
 }
 
 // SetClientMode adds config arguments for openvpn behave as client
@@ -46,6 +53,23 @@ func (c *ClientConfig) SetClientMode(serverIP string, serverPort, localPort int)
 	c.SetFlag("management-query-passwords")
 }
 
+// SetRoutes routes all the traffic or only the included networks thru the tunnel, excluded networks are routed around it
+func (c *ClientConfig) SetRoutes(routes split.Routes) {
+	if routes.Full() {
+		c.SetParam("redirect-gateway", "def1", "bypass-dhcp")
+	}
+	for _, network := range routes.Include {
+		if network.IP.To4() == nil {
+			c.SetParam("route-ipv6", network.String())
+		} else {
+			c.SetParam("route", network.IP.String(), net.IP(network.Mask).String())
+		}
+	}
+	for _, network := range routes.Exclude {
+		c.SetParam("route", network.IP.String(), net.IP(network.Mask).String(), "net_gateway")
+	}
+}
+
 // SetProtocol specifies openvpn connection protocol type (tcp or udp)
 func (c *ClientConfig) SetProtocol(protocol string) {
 	if protocol == "tcp" {
@@ -58,7 +82,7 @@ func (c *ClientConfig) SetProtocol(protocol string) {
 func defaultClientConfig(runtimeDir string, scriptSearchPath string) *ClientConfig {
 	clientConfig := ClientConfig{GenericConfig: config.NewConfig(runtimeDir, scriptSearchPath), VpnConfig: nil}
 
-	clientConfig.SetDevice("tun")
+	clientConfig.setDevice()
 	clientConfig.SetParam("cipher", "AES-256-GCM")
 	clientConfig.SetParam("verb", "3")
 	clientConfig.SetParam("tls-cipher", "TLS-ECDHE-ECDSA-WITH-AES-256-GCM-SHA384")
@@ -70,9 +94,6 @@ func defaultClientConfig(runtimeDir string, scriptSearchPath string) *ClientConf
 
 	clientConfig.SetParam("reneg-sec", "60")
 	clientConfig.SetParam("resolv-retry", "infinite")
-	clientConfig.SetParam("redirect-gateway", "def1", "bypass-dhcp")
-	clientConfig.SetParam("dhcp-option", "DNS", "208.67.222.222")
-	clientConfig.SetParam("dhcp-option", "DNS", "208.67.220.220")
 
 	return &clientConfig
 }
@@ -80,7 +101,7 @@ func defaultClientConfig(runtimeDir string, scriptSearchPath string) *ClientConf
 // NewClientConfigFromSession creates client configuration structure for given VPNConfig, configuration dir to store serialized file args, and
 // configuration filename to store other args
 // TODO this will become the part of openvpn service consumer separate package
-func NewClientConfigFromSession(vpnConfig *VPNConfig, configDir string, runtimeDir string) (*ClientConfig, error) {
+func NewClientConfigFromSession(vpnConfig *VPNConfig, routes split.Routes, configDir string, runtimeDir string) (*ClientConfig, error) {
 	// TODO Rename `vpnConfig` to `sessionConfig`
 	err := NewDefaultValidator().IsValid(vpnConfig)
 	if err != nil {
@@ -92,8 +113,15 @@ func NewClientConfigFromSession(vpnConfig *VPNConfig, configDir string, runtimeD
 	clientFileConfig.SetReconnectRetry(2)
 	clientFileConfig.SetClientMode(vpnConfig.RemoteIP, vpnConfig.RemotePort, vpnConfig.LocalPort)
 	clientFileConfig.SetProtocol(vpnConfig.RemoteProtocol)
+	clientFileConfig.SetRoutes(routes)
 	clientFileConfig.SetTLSCACertificate(vpnConfig.CACertificate)
 	clientFileConfig.SetTLSCrypt(vpnConfig.TLSPresharedKey)
+	// on linux DNS servers are applied by update-resolv-conf script
+	if !vpnConfig.KeepSystemDNS {
+		for _, dns := range vpnConfig.dnsServers() {
+			clientFileConfig.SetParam("dhcp-option", "DNS", dns)
+		}
+	}
 
 	return clientFileConfig, nil
 }
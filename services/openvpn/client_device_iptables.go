@@ -0,0 +1,38 @@
+// +build linux,!android
+
+/*
+ * Copyright (C) 2019 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package openvpn
+
+import (
+	"fmt"
+	"net"
+)
+
+// setDevice names the tun device, so kill switch lets the traffic thru exactly it and not thru the devices of other VPNs
+func (c *ClientConfig) setDevice() {
+	for i := 0; ; i++ {
+		device := fmt.Sprintf("myst-tun%d", i)
+		if _, err := net.InterfaceByName(device); err != nil {
+			c.Device = device
+			c.SetDevice(device)
+			c.SetParam("dev-type", "tun")
+			return
+		}
+	}
+}
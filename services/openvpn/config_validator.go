@@ -45,6 +45,7 @@ func NewDefaultValidator() *ConfigValidator {
 			validIPFormat,
 			validTLSPresharedKey,
 			validCACertificate,
+			validDNS,
 		},
 	}
 }
@@ -87,6 +88,15 @@ func validIPFormat(config *VPNConfig) error {
 	return nil
 }
 
+func validDNS(config *VPNConfig) error {
+	for _, dns := range config.DNS {
+		if net.ParseIP(dns) == nil {
+			return errors.New("unable to parse DNS server address " + dns)
+		}
+	}
+	return nil
+}
+
 // preshared key format (PEM blocks with data encoded to hex) are taken from
 // openvpn --genkey --secret static.key, which is openvpn specific
 // side effect: it reformats key from single line to multiline fixed length strings
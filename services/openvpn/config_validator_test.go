@@ -70,10 +70,20 @@ func TestValidatorReturnsNilErrorOnValidVPNConfig(t *testing.T) {
 		"tcp",
 		tlsTestKey,
 		caCertificate,
+		[]string{"10.8.0.1"},
+		false,
 	}
 	assert.NoError(t, NewDefaultValidator().IsValid(vpnConfig))
 }
 
+func TestDNSMustBeIPAddresses(t *testing.T) {
+	vpnConfig := VPNConfig{DNS: []string{"10.8.0.1", "fd00::1"}}
+	assert.NoError(t, validDNS(&vpnConfig))
+
+	vpnConfig = VPNConfig{DNS: []string{"10.8.0.1\nup /bin/sh"}}
+	assert.Error(t, validDNS(&vpnConfig))
+}
+
 func TestIPv6AreNotAllowed(t *testing.T) {
 	vpnConfig := VPNConfig{RemoteIP: "2001:db8:85a3::8a2e:370:7334"}
 	assert.Error(t, validIPFormat(&vpnConfig))
@@ -92,7 +92,9 @@ func (op *ProcessBasedConnectionFactory) Create(stateChannel connection.StateCha
 			sessionConfig.OriginalRemotePort = sessionConfig.RemotePort
 		}
 
-		vpnClientConfig, err := NewClientConfigFromSession(sessionConfig, op.configDirectory, op.runtimeDirectory)
+		sessionConfig.KeepSystemDNS = options.DisableDNS
+
+		vpnClientConfig, err := NewClientConfigFromSession(sessionConfig, options.Routes, op.configDirectory, op.runtimeDirectory)
 		if err != nil {
 			return nil, nil, err
 		}
@@ -0,0 +1,87 @@
+/*
+ * Copyright (C) 2019 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package bytescount
+
+import (
+	"regexp"
+	"strconv"
+	"time"
+
+	"github.com/mysteriumnetwork/go-openvpn/openvpn/management"
+)
+
+// ClientBytecount represents the data transferred by a single client, as seen by the openvpn server
+type ClientBytecount struct {
+	ClientID int
+	BytesIn  int
+	BytesOut int
+}
+
+// ClientStatsHandler is invoked on every client bytecount report
+type ClientStatsHandler func(ClientBytecount) error
+
+type middleware struct {
+	statsHandler ClientStatsHandler
+	interval     time.Duration
+}
+
+// NewMiddleware returns new server bytescount middleware, which reports per client bytecounts every given interval
+func NewMiddleware(statsHandler ClientStatsHandler, interval time.Duration) management.Middleware {
+	return &middleware{
+		statsHandler: statsHandler,
+		interval:     interval,
+	}
+}
+
+func (middleware *middleware) Start(commandWriter management.CommandWriter) error {
+	_, err := commandWriter.SingleLineCommand("bytecount %d", int(middleware.interval.Seconds()))
+	return err
+}
+
+func (middleware *middleware) Stop(commandWriter management.CommandWriter) error {
+	_, err := commandWriter.SingleLineCommand("bytecount %d", 0)
+	return err
+}
+
+var rule = regexp.MustCompile(`^>BYTECOUNT_CLI:(\d+),(\d+),(\d+)$`)
+
+func (middleware *middleware) ConsumeLine(line string) (consumed bool, err error) {
+	match := rule.FindStringSubmatch(line)
+	consumed = len(match) > 0
+	if !consumed {
+		return
+	}
+
+	clientID, err := strconv.Atoi(match[1])
+	if err != nil {
+		return
+	}
+
+	bytesIn, err := strconv.Atoi(match[2])
+	if err != nil {
+		return
+	}
+
+	bytesOut, err := strconv.Atoi(match[3])
+	if err != nil {
+		return
+	}
+
+	err = middleware.statsHandler(ClientBytecount{ClientID: clientID, BytesIn: bytesIn, BytesOut: bytesOut})
+	return
+}
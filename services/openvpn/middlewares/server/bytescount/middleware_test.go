@@ -0,0 +1,79 @@
+/*
+ * Copyright (C) 2019 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package bytescount
+
+import (
+	"testing"
+	"time"
+
+	"github.com/mysteriumnetwork/go-openvpn/openvpn/management"
+	"github.com/mysteriumnetwork/node/session"
+	"github.com/stretchr/testify/assert"
+)
+
+type statsRecorder struct {
+	clientID     int
+	dataTransfer session.DataTransfer
+}
+
+func (recorder *statsRecorder) update(clientID int, dataTransfer session.DataTransfer) error {
+	recorder.clientID = clientID
+	recorder.dataTransfer = dataTransfer
+	return nil
+}
+
+func Test_Start_EnablesBytecount(t *testing.T) {
+	connection := &management.MockConnection{CommandResult: "SUCCESS"}
+	middleware := NewMiddleware(NewSessionStatsSaver((&statsRecorder{}).update), 5*time.Second)
+
+	err := middleware.Start(connection)
+	assert.NoError(t, err)
+	assert.Equal(t, "bytecount 5", connection.LastLine)
+}
+
+func Test_Stop_DisablesBytecount(t *testing.T) {
+	connection := &management.MockConnection{CommandResult: "SUCCESS"}
+	middleware := NewMiddleware(NewSessionStatsSaver((&statsRecorder{}).update), 5*time.Second)
+
+	err := middleware.Stop(connection)
+	assert.NoError(t, err)
+	assert.Equal(t, "bytecount 0", connection.LastLine)
+}
+
+func Test_ConsumeLine_SavesClientStats(t *testing.T) {
+	recorder := &statsRecorder{}
+	middleware := NewMiddleware(NewSessionStatsSaver(recorder.update), 5*time.Second)
+
+	consumed, err := middleware.ConsumeLine(">BYTECOUNT_CLI:7,3018,3264")
+	assert.NoError(t, err)
+	assert.True(t, consumed)
+	assert.Equal(t, 7, recorder.clientID)
+	assert.Equal(t, session.DataTransfer{BytesSent: 3264, BytesReceived: 3018}, recorder.dataTransfer)
+}
+
+func Test_ConsumeLine_SkipsOtherLines(t *testing.T) {
+	recorder := &statsRecorder{}
+	middleware := NewMiddleware(NewSessionStatsSaver(recorder.update), 5*time.Second)
+
+	for _, line := range []string{">BYTECOUNT:3018,3264", ">CLIENT:CONNECT,1,4", "SUCCESS: bytecount interval changed"} {
+		consumed, err := middleware.ConsumeLine(line)
+		assert.NoError(t, err, line)
+		assert.False(t, consumed, line)
+	}
+	assert.Equal(t, session.DataTransfer{}, recorder.dataTransfer)
+}
@@ -0,0 +1,33 @@
+/*
+ * Copyright (C) 2019 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package bytescount
+
+import (
+	"github.com/mysteriumnetwork/node/session"
+)
+
+// DataTransferUpdater updates the data transfer counters of the session served to the given client
+type DataTransferUpdater func(clientID int, dataTransfer session.DataTransfer) error
+
+// NewSessionStatsSaver returns stats handler, which saves client stats into its session
+func NewSessionStatsSaver(updateDataTransfer DataTransferUpdater) ClientStatsHandler {
+	return func(bc ClientBytecount) error {
+		// bytes received by the server are sent by the client and vice versa
+		return updateDataTransfer(bc.ClientID, session.DataTransfer{BytesSent: uint64(bc.BytesOut), BytesReceived: uint64(bc.BytesIn)})
+	}
+}
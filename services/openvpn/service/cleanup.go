@@ -0,0 +1,47 @@
+/*
+ * Copyright (C) 2019 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package service
+
+import (
+	"github.com/mysteriumnetwork/node/core/ip"
+	"github.com/mysteriumnetwork/node/nat"
+	"github.com/mysteriumnetwork/node/session"
+	"github.com/pkg/errors"
+)
+
+// NewSessionCleanup creates cleanup which deletes the NAT forwarding rule left behind
+// by an openvpn service which was serving unfinished sessions when the node stopped.
+// The rule is shared by all sessions of the service, so it is deleted once per service.
+func NewSessionCleanup(ipResolver ip.Resolver, natService nat.NATService) func(record session.Record) error {
+	cleanedServices := make(map[string]struct{})
+
+	return func(record session.Record) error {
+		if _, cleaned := cleanedServices[record.ServiceID]; cleaned {
+			return nil
+		}
+		cleanedServices[record.ServiceID] = struct{}{}
+
+		outIP, err := ipResolver.GetOutboundIP()
+		if err != nil {
+			return err
+		}
+
+		natRule := nat.RuleForwarding{SourceAddress: natSubnet, TargetIP: outIP}
+		return errors.Wrap(natService.Del(natRule), "failed to delete NAT forwarding rule")
+	}
+}
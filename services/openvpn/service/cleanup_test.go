@@ -0,0 +1,51 @@
+/*
+ * Copyright (C) 2019 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package service
+
+import (
+	"testing"
+
+	"github.com/mysteriumnetwork/node/core/ip"
+	"github.com/mysteriumnetwork/node/nat"
+	"github.com/mysteriumnetwork/node/session"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSessionCleanup_DeletesNATRuleOncePerService(t *testing.T) {
+	natService := &mockNATService{}
+	cleanup := NewSessionCleanup(ip.NewResolverMock("1.2.3.4"), natService)
+
+	assert.NoError(t, cleanup(session.Record{ID: "session1", ServiceID: "service1"}))
+	assert.NoError(t, cleanup(session.Record{ID: "session2", ServiceID: "service1"}))
+	assert.NoError(t, cleanup(session.Record{ID: "session3", ServiceID: "service2"}))
+
+	rule := nat.RuleForwarding{SourceAddress: "10.8.0.0/24", TargetIP: "1.2.3.4"}
+	assert.Equal(t, []nat.RuleForwarding{rule, rule}, natService.deleted)
+}
+
+type mockNATService struct {
+	deleted []nat.RuleForwarding
+}
+
+func (service *mockNATService) Enable() error                     { return nil }
+func (service *mockNATService) Add(rule nat.RuleForwarding) error { return nil }
+func (service *mockNATService) Disable() error                    { return nil }
+func (service *mockNATService) Del(rule nat.RuleForwarding) error {
+	service.deleted = append(service.deleted, rule)
+	return nil
+}
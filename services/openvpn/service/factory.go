@@ -20,6 +20,7 @@ package service
 import (
 	"crypto/x509/pkix"
 	"encoding/json"
+	"time"
 
 	log "github.com/cihub/seelog"
 	"github.com/mysteriumnetwork/go-openvpn/openvpn"
@@ -33,10 +34,18 @@ import (
 	"github.com/mysteriumnetwork/node/nat"
 	"github.com/mysteriumnetwork/node/nat/traversal"
 	openvpn_service "github.com/mysteriumnetwork/node/services/openvpn"
+	openvpn_bytescount "github.com/mysteriumnetwork/node/services/openvpn/middlewares/server/bytescount"
 	openvpn_session "github.com/mysteriumnetwork/node/services/openvpn/session"
 	"github.com/mysteriumnetwork/node/session"
 )
 
+const (
+	serverNetwork = "10.8.0.0"
+	serverNetmask = "255.255.255.0"
+	// serverTunnelIP is the address openvpn server takes from its network
+	serverTunnelIP = "10.8.0.1"
+)
+
 // NewManager creates new instance of Openvpn service
 func NewManager(nodeOptions node.Options,
 	serviceOptions Options,
@@ -75,7 +84,7 @@ func newServerConfigFactory(nodeOptions node.Options, serviceOptions Options) Se
 		return openvpn_service.NewServerConfig(
 			nodeOptions.Directories.Runtime,
 			nodeOptions.Directories.Config,
-			"10.8.0.0", "255.255.255.0",
+			serverNetwork, serverNetmask,
 			secPrimitives,
 			port,
 			serviceOptions.Protocol,
@@ -90,13 +99,14 @@ func newServerFactory(nodeOptions node.Options, sessionValidator *openvpn_sessio
 			config.GenericConfig,
 			auth.NewMiddleware(sessionValidator.Validate),
 			state.NewMiddleware(vpnStateCallback),
+			openvpn_bytescount.NewMiddleware(openvpn_bytescount.NewSessionStatsSaver(sessionValidator.UpdateDataTransfer), 5*time.Second),
 		)
 	}
 }
 
 // newSessionConfigNegotiatorFactory returns function generating session config for remote client
 func newSessionConfigNegotiatorFactory(networkOptions node.OptionsNetwork, serviceOptions Options, natEventGetter NATEventGetter, portPool port.ServicePortSupplier) SessionConfigNegotiatorFactory {
-	return func(secPrimitives *tls.Primitives, outboundIP, publicIP string, port int) session.ConfigNegotiator {
+	return func(secPrimitives *tls.Primitives, outboundIP, publicIP string, port int, dnsServing bool) session.ConfigNegotiator {
 		serverIP := vpnServerIP(serviceOptions, outboundIP, publicIP, networkOptions.Localnet)
 		return &OpenvpnConfigNegotiator{
 			natEventGetter: natEventGetter,
@@ -106,6 +116,7 @@ func newSessionConfigNegotiatorFactory(networkOptions node.OptionsNetwork, servi
 				RemoteProtocol:  serviceOptions.Protocol,
 				TLSPresharedKey: secPrimitives.PresharedKey.ToPEMFormat(),
 				CACertificate:   secPrimitives.CertificateAuthority.ToPEMFormat(),
+				DNS:             consumerDNS(serviceOptions, dnsServing),
 			},
 			portPool: portPool,
 		}
@@ -127,6 +138,23 @@ func (ocn *OpenvpnConfigNegotiator) ProvideConfig(sessionConfig json.RawMessage,
 	return &session.ConfigParams{SessionServiceConfig: ocn.vpnConfig, TraversalParams: traversalParams}, nil
 }
 
+// consumerDNS returns the DNS servers pushed to the consumer, consumer resolves names thru the server tunnel address
+// if the forwarder serves DNS there and falls back to its default servers if nothing is pushed
+func consumerDNS(serviceOptions Options, dnsServing bool) []string {
+	if len(serviceOptions.DNS) == 0 {
+		if dnsServing {
+			return []string{serverTunnelIP}
+		}
+		return nil
+	}
+
+	var servers []string
+	for _, server := range serviceOptions.DNS {
+		servers = append(servers, server.String())
+	}
+	return servers
+}
+
 func vpnServerIP(serviceOptions Options, outboundIP, publicIP string, isLocalnet bool) string {
 	//TODO public ip could be overridden by arg nodeOptions if needed
 	if publicIP == outboundIP {
@@ -19,15 +19,18 @@ package service
 
 import (
 	"encoding/json"
+	"net"
 
 	log "github.com/cihub/seelog"
 	"github.com/mysteriumnetwork/go-openvpn/openvpn"
 	"github.com/mysteriumnetwork/go-openvpn/openvpn/tls"
 	"github.com/mysteriumnetwork/node/core/port"
+	"github.com/mysteriumnetwork/node/core/service"
 	"github.com/mysteriumnetwork/node/firewall"
 	"github.com/mysteriumnetwork/node/identity"
 	"github.com/mysteriumnetwork/node/market"
 	"github.com/mysteriumnetwork/node/nat"
+	"github.com/mysteriumnetwork/node/nat/egress"
 	"github.com/mysteriumnetwork/node/nat/event"
 	"github.com/mysteriumnetwork/node/nat/mapping"
 	"github.com/mysteriumnetwork/node/nat/traversal"
@@ -39,6 +42,9 @@ import (
 
 const logPrefix = "[service-openvpn] "
 
+// natSubnet is the subnet of openvpn clients forwarded through NAT
+const natSubnet = "10.8.0.0/24"
+
 // ServerConfigFactory callback generates session config for remote client
 type ServerConfigFactory func(secPrimitives *tls.Primitives, port int) *openvpn_service.ServerConfig
 
@@ -49,7 +55,7 @@ type ServerFactory func(*openvpn_service.ServerConfig) openvpn.Process
 type ProposalFactory func(currentLocation market.Location) market.ServiceProposal
 
 // SessionConfigNegotiatorFactory initiates ConfigProvider instance during runtime
-type SessionConfigNegotiatorFactory func(secPrimitives *tls.Primitives, outboundIP, publicIP string, port int) session.ConfigNegotiator
+type SessionConfigNegotiatorFactory func(secPrimitives *tls.Primitives, outboundIP, publicIP string, port int, dnsServing bool) session.ConfigNegotiator
 
 // NATPinger defined Pinger interface for Provider
 type NATPinger interface {
@@ -85,12 +91,15 @@ type Manager struct {
 	outboundIP      string
 	currentLocation string
 	serviceOptions  Options
+
+	// dnsListener is set if the service serves DNS to consumers on the server tunnel address
+	dnsListener service.DNSListener
 }
 
 // Serve starts service - does block
 func (m *Manager) Serve(providerID identity.Identity) (err error) {
 	err = m.natService.Add(nat.RuleForwarding{
-		SourceAddress: "10.8.0.0/24",
+		SourceAddress: natSubnet,
 		TargetIP:      m.outboundIP,
 	})
 	if err != nil {
@@ -111,7 +120,11 @@ func (m *Manager) Serve(providerID identity.Identity) (err error) {
 		return
 	}
 
-	m.vpnServiceConfigProvider = m.sessionConfigNegotiatorFactory(primitives, m.outboundIP, m.publicIP, m.vpnServerPort)
+	dnsServing, err := m.listenDNS()
+	if err != nil {
+		return err
+	}
+	m.vpnServiceConfigProvider = m.sessionConfigNegotiatorFactory(primitives, m.outboundIP, m.publicIP, m.vpnServerPort, dnsServing)
 
 	vpnServerConfig := m.vpnServerConfigFactory(primitives, m.vpnServerPort)
 	m.vpnServer = m.vpnServerFactory(vpnServerConfig)
@@ -145,7 +158,7 @@ func (m *Manager) Stop() (err error) {
 
 	if m.natService != nil {
 		return m.natService.Del(nat.RuleForwarding{
-			SourceAddress: "10.8.0.0/24",
+			SourceAddress: natSubnet,
 			TargetIP:      m.outboundIP,
 		})
 	}
@@ -153,6 +166,23 @@ func (m *Manager) Stop() (err error) {
 	return nil
 }
 
+// HostDNS makes the service to serve DNS to consumers on the server tunnel address
+func (m *Manager) HostDNS(listener service.DNSListener) {
+	m.dnsListener = listener
+}
+
+// listenDNS serves DNS on the server tunnel address before the server brings its interface up,
+// it reports whether the address is served, the service fails if the forwarder is enabled but can not listen
+func (m *Manager) listenDNS() (bool, error) {
+	if m.dnsListener == nil {
+		return false, nil
+	}
+	if err := m.dnsListener.Listen(net.ParseIP(serverTunnelIP)); err != nil {
+		return false, errors.Wrap(err, "failed to serve DNS on "+serverTunnelIP)
+	}
+	return true, nil
+}
+
 // ProvideConfig takes session creation config from end consumer and provides the service configuration to the end consumer
 func (m *Manager) ProvideConfig(sessionConfig json.RawMessage, traversalParams *traversal.Params) (*session.ConfigParams, error) {
 	if m.vpnServiceConfigProvider == nil {
@@ -192,6 +222,15 @@ func (m *Manager) ProvideConfig(sessionConfig json.RawMessage, traversalParams *
 	return m.vpnServiceConfigProvider.ProvideConfig(sessionConfig, traversalParams)
 }
 
+// EgressStats returns the counters of the consumer traffic blocked by the egress policy
+func (m *Manager) EgressStats() (egress.Stats, bool) {
+	reporter, ok := m.natService.(egress.Reporter)
+	if !ok {
+		return egress.Stats{}, false
+	}
+	return reporter.Stats(), true
+}
+
 func (m *Manager) isBehindNAT() bool {
 	return m.outboundIP != m.publicIP
 }
@@ -19,8 +19,16 @@ package service
 
 import (
 	"encoding/json"
+	"net"
+	"time"
 
+	log "github.com/cihub/seelog"
 	"github.com/mysteriumnetwork/node/core/service"
+	"github.com/mysteriumnetwork/node/dns"
+	"github.com/mysteriumnetwork/node/market"
+	"github.com/mysteriumnetwork/node/money"
+	"github.com/mysteriumnetwork/node/services/openvpn/discovery/dto"
+	"github.com/pkg/errors"
 	"github.com/urfave/cli"
 )
 
@@ -28,6 +36,13 @@ import (
 type Options struct {
 	Protocol string `json:"protocol"`
 	Port     int    `json:"port"`
+
+	// PricePerMinute and PricePerGB are zero unless the provider prices the service, the default price is charged then
+	PricePerMinute float64 `json:"pricePerMinute"`
+	PricePerGB     float64 `json:"pricePerGB"`
+
+	// DNS servers pushed to consumers, the provider tunnel address is pushed if empty
+	DNS []net.IP `json:"dns,omitempty"`
 }
 
 var (
@@ -41,6 +56,20 @@ var (
 		Usage: "Openvpn port to use. If not specified, random port will be used",
 		Value: defaultOptions.Port,
 	}
+	pricePerMinuteFlag = cli.Float64Flag{
+		Name:  "openvpn.price-per-minute",
+		Usage: "Price in MYST charged for a minute of the openvpn service. If not specified, 0.125 MYST per hour is charged",
+		Value: defaultOptions.PricePerMinute,
+	}
+	pricePerGBFlag = cli.Float64Flag{
+		Name:  "openvpn.price-per-gb",
+		Usage: "Price in MYST charged for a gigabyte of traffic of the openvpn service, takes precedence over the price per minute",
+		Value: defaultOptions.PricePerGB,
+	}
+	dnsFlag = cli.StringFlag{
+		Name:  "openvpn.dns",
+		Usage: "Comma separated DNS servers pushed to consumers, the provider tunnel address is used if empty",
+	}
 	defaultOptions = Options{
 		Protocol: "udp",
 		Port:     0,
@@ -49,14 +78,25 @@ var (
 
 // RegisterFlags function register Openvpn flags to flag list
 func RegisterFlags(flags *[]cli.Flag) {
-	*flags = append(*flags, protocolFlag, portFlag)
+	*flags = append(*flags, protocolFlag, portFlag, pricePerMinuteFlag, pricePerGBFlag, dnsFlag)
 }
 
 // ParseFlags function fills in Openvpn options from CLI context
 func ParseFlags(ctx *cli.Context) service.Options {
+	dnsServers, err := dns.ParseServers(ctx.String(dnsFlag.Name))
+	if err != nil {
+		log.Warn(logPrefix, "Failed to parse DNS servers, the provider tunnel address is used. ", err)
+		dnsServers = nil
+	}
+
 	return Options{
 		Protocol: ctx.String(protocolFlag.Name),
 		Port:     ctx.Int(portFlag.Name),
+
+		PricePerMinute: ctx.Float64(pricePerMinuteFlag.Name),
+		PricePerGB:     ctx.Float64(pricePerGBFlag.Name),
+
+		DNS: dnsServers,
 	}
 }
 
@@ -67,6 +107,30 @@ func ParseJSONOptions(request *json.RawMessage) (service.Options, error) {
 	}
 
 	opts := defaultOptions
-	err := json.Unmarshal(*request, &opts)
-	return opts, err
+	if err := json.Unmarshal(*request, &opts); err != nil {
+		return opts, err
+	}
+	if opts.PricePerMinute < 0 || opts.PricePerGB < 0 {
+		return opts, errors.New("price can not be negative")
+	}
+	return opts, nil
+}
+
+// Priced tells if the provider prices the service, the default payment method of the proposal is used otherwise
+func (o Options) Priced() bool {
+	return o.PricePerMinute > 0 || o.PricePerGB > 0
+}
+
+// PaymentMethod returns the payment method of the service priced by the options
+func (o Options) PaymentMethod() (string, market.PaymentMethod) {
+	if o.PricePerGB > 0 {
+		return market.PaymentMethodPerGB, market.PaymentPerGB{
+			Price: money.NewMoney(o.PricePerGB, money.CurrencyMyst),
+		}
+	}
+
+	return dto.PaymentMethodPerTime, dto.PaymentPerTime{
+		Price:    money.NewMoney(o.PricePerMinute, money.CurrencyMyst),
+		Duration: time.Minute,
+	}
 }
@@ -19,8 +19,13 @@ package service
 
 import (
 	"encoding/json"
+	"net"
 	"testing"
+	"time"
 
+	"github.com/mysteriumnetwork/node/market"
+	"github.com/mysteriumnetwork/node/money"
+	"github.com/mysteriumnetwork/node/services/openvpn/discovery/dto"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -44,5 +49,55 @@ func Test_ParseJSONOptions_ValidRequest(t *testing.T) {
 	options, err := ParseJSONOptions(&request)
 
 	assert.NoError(t, err)
-	assert.Equal(t, Options{"udp", 1123}, options)
+	assert.Equal(t, Options{Protocol: "udp", Port: 1123}, options)
+}
+
+func Test_ParseJSONOptions_ParsesPrices(t *testing.T) {
+	request := json.RawMessage(`{"pricePerMinute": 0.01, "pricePerGB": 0.5}`)
+	options, err := ParseJSONOptions(&request)
+
+	assert.NoError(t, err)
+	assert.Equal(t, Options{Protocol: "udp", PricePerMinute: 0.01, PricePerGB: 0.5}, options)
+}
+
+func Test_Options_UnmarshalJSONResetsPricesToZero(t *testing.T) {
+	options := Options{Protocol: "udp", PricePerMinute: 0.01, PricePerGB: 0.5}
+	err := json.Unmarshal([]byte(`{"pricePerMinute": 0, "pricePerGB": 0}`), &options)
+
+	assert.NoError(t, err)
+	assert.Equal(t, Options{Protocol: "udp"}, options)
+}
+
+func Test_ParseJSONOptions_ParsesDNS(t *testing.T) {
+	request := json.RawMessage(`{"dns": ["1.1.1.1", "1.0.0.1"]}`)
+	options, err := ParseJSONOptions(&request)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []net.IP{net.ParseIP("1.1.1.1"), net.ParseIP("1.0.0.1")}, options.(Options).DNS)
+
+	request = json.RawMessage(`{"dns": ["dns.example"]}`)
+	_, err = ParseJSONOptions(&request)
+
+	assert.Error(t, err)
+}
+
+func Test_ParseJSONOptions_RejectsNegativePrice(t *testing.T) {
+	request := json.RawMessage(`{"pricePerGB": -0.5}`)
+	_, err := ParseJSONOptions(&request)
+
+	assert.Error(t, err)
+}
+
+func Test_Options_PaymentMethod(t *testing.T) {
+	assert.False(t, Options{}.Priced())
+	assert.True(t, Options{PricePerMinute: 0.01}.Priced())
+	assert.True(t, Options{PricePerGB: 0.5}.Priced())
+
+	methodType, method := Options{PricePerMinute: 0.01}.PaymentMethod()
+	assert.Equal(t, dto.PaymentMethodPerTime, methodType)
+	assert.Equal(t, dto.PaymentPerTime{Price: money.NewMoney(0.01, money.CurrencyMyst), Duration: time.Minute}, method)
+
+	methodType, method = Options{PricePerMinute: 0.01, PricePerGB: 0.5}.PaymentMethod()
+	assert.Equal(t, market.PaymentMethodPerGB, methodType)
+	assert.Equal(t, market.PaymentPerGB{Price: money.NewMoney(0.5, money.CurrencyMyst)}, method)
 }
@@ -29,6 +29,7 @@ type SessionMap interface {
 	Add(session.Session)
 	Find(session.ID) (session.Session, bool)
 	Remove(session.ID)
+	UpdateDataTransfer(session.ID, session.DataTransfer)
 }
 
 // clientMap extends current sessions with client id metadata from Openvpn
@@ -67,6 +68,21 @@ func (cm *clientMap) UpdateClientSession(clientID int, id session.ID) bool {
 	return cm.sessionClientIDs[id] == clientID
 }
 
+// UpdateClientDataTransfer updates data transfer counters of the session served to the given client,
+// returns false if client has no session
+func (cm *clientMap) UpdateClientDataTransfer(clientID int, dataTransfer session.DataTransfer) bool {
+	cm.sessionMapLock.Lock()
+	defer cm.sessionMapLock.Unlock()
+
+	for id, sessionClientID := range cm.sessionClientIDs {
+		if sessionClientID == clientID {
+			cm.sessions.UpdateDataTransfer(id, dataTransfer)
+			return true
+		}
+	}
+	return false
+}
+
 // RemoveSession removes given session from underlying session managers
 func (cm *clientMap) RemoveSession(id session.ID) error {
 	cm.sessionMapLock.Lock()
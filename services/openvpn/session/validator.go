@@ -18,6 +18,7 @@
 package session
 
 import (
+	"fmt"
 	"sync"
 
 	"github.com/mysteriumnetwork/node/identity"
@@ -67,6 +68,15 @@ func (v *Validator) Validate(clientID int, sessionString, signatureString string
 	return currentSession.ConsumerID == extractedIdentity, nil
 }
 
+// UpdateDataTransfer provides glue code for openvpn management interface to save data transfer counters
+// reported for the given client into its session
+func (v *Validator) UpdateDataTransfer(clientID int, dataTransfer session.DataTransfer) error {
+	if !v.clientMap.UpdateClientDataTransfer(clientID, dataTransfer) {
+		return fmt.Errorf("no session exists for client: %d", clientID)
+	}
+	return nil
+}
+
 // Cleanup removes session from underlying session managers
 func (v *Validator) Cleanup(sessionString string) error {
 	sessionID := session.ID(sessionString)
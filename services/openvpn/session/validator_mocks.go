@@ -28,8 +28,8 @@ func mockValidator(identityToExtract identity.Identity) *Validator {
 		nil,
 	}
 	mockSessions := &mockSessions{
-		session.Session{},
-		false,
+		OnFindReturnSession: session.Session{},
+		OnFindReturnSuccess: false,
 	}
 	return NewValidator(mockSessions, mockExtractor)
 }
@@ -40,8 +40,8 @@ func mockValidatorWithSession(identityToExtract identity.Identity, sessionInstan
 		nil,
 	}
 	mockSessions := &mockSessions{
-		sessionInstance,
-		true,
+		OnFindReturnSession: sessionInstance,
+		OnFindReturnSuccess: true,
 	}
 	return NewValidator(mockSessions, mockExtractor)
 }
@@ -60,6 +60,7 @@ func (extractor *mockIdentityExtractor) Extract(message []byte, signature identi
 type mockSessions struct {
 	OnFindReturnSession session.Session
 	OnFindReturnSuccess bool
+	LastDataTransfer    session.DataTransfer
 }
 
 func (sessions *mockSessions) Add(sessionInstance session.Session) {
@@ -73,3 +74,7 @@ func (sessions *mockSessions) Remove(session.ID) {
 	sessions.OnFindReturnSession = session.Session{}
 	sessions.OnFindReturnSuccess = false
 }
+
+func (sessions *mockSessions) UpdateDataTransfer(_ session.ID, dataTransfer session.DataTransfer) {
+	sessions.LastDataTransfer = dataTransfer
+}
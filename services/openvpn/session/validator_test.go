@@ -102,3 +102,22 @@ func TestCleanupReturnsErrorIfSessionNotExists(t *testing.T) {
 
 	assert.Errorf(t, err, "no underlying session exists: nonexistent_session")
 }
+
+func TestUpdateDataTransferSavesCountersOfClientSession(t *testing.T) {
+	validator := mockValidatorWithSession(identityExisting, sessionExisting)
+	dataTransfer := session.DataTransfer{BytesSent: 1, BytesReceived: 2}
+
+	validator.Validate(1, sessionExistingString, "not important")
+	err := validator.UpdateDataTransfer(1, dataTransfer)
+
+	assert.NoError(t, err)
+	assert.Equal(t, dataTransfer, validator.clientMap.sessions.(*mockSessions).LastDataTransfer)
+}
+
+func TestUpdateDataTransferReturnsErrorIfClientUnknown(t *testing.T) {
+	validator := mockValidatorWithSession(identityExisting, sessionExisting)
+
+	err := validator.UpdateDataTransfer(1, session.DataTransfer{BytesSent: 1})
+
+	assert.Error(t, err)
+}
@@ -45,4 +45,6 @@ func Bootstrap() {
 			return method, err
 		},
 	)
+
+	market.RegisterPaymentMethodUnserializer(market.PaymentMethodPerGB, market.UnserializePaymentPerGB)
 }
@@ -26,6 +26,8 @@ import (
 	log "github.com/cihub/seelog"
 	"github.com/mysteriumnetwork/node/consumer"
 	"github.com/mysteriumnetwork/node/core/connection"
+	"github.com/mysteriumnetwork/node/dns"
+	"github.com/mysteriumnetwork/node/firewall"
 	wg "github.com/mysteriumnetwork/node/services/wireguard"
 	endpoint "github.com/mysteriumnetwork/node/services/wireguard/endpoint"
 	"github.com/mysteriumnetwork/node/services/wireguard/key"
@@ -34,6 +36,9 @@ import (
 
 const logPrefix = "[connection-wireguard] "
 
+// dnsServers are reached thru the tunnel, kill switch redirects DNS queries to them if provider does not push its own
+var dnsServers = []net.IP{net.ParseIP("208.67.222.222"), net.ParseIP("208.67.220.220")}
+
 // Connection which does wireguard tunneling.
 type Connection struct {
 	connection  sync.WaitGroup
@@ -44,6 +49,9 @@ type Connection struct {
 
 	config             wg.ServiceConfig
 	connectionEndpoint wg.ConnectionEndpoint
+	dnsConfigurator    dns.Configurator
+	// systemDNS is set when the connection configures the system to resolve names thru the provider
+	systemDNS bool
 }
 
 // Start establish wireguard connection to the service provider.
@@ -54,6 +62,9 @@ func (c *Connection) Start(options connection.ConnectOptions) (err error) {
 	}
 	c.config.Provider = config.Provider
 	c.config.Consumer.IPAddress = config.Consumer.IPAddress
+	c.config.Consumer.IPv6Address = config.Consumer.IPv6Address
+	c.config.Consumer.DNS = config.Consumer.DNS
+	c.systemDNS = !options.DisableDNS && len(config.Consumer.DNS) > 0
 
 	// We do not need port mapping for consumer, since it initiates the session
 	fakePortMapper := func(port int) (releasePortMapping func()) {
@@ -87,7 +98,7 @@ func (c *Connection) Start(options connection.ConnectOptions) (err error) {
 		return errors.Wrap(err, "failed to add peer to the connection endpoint")
 	}
 
-	if err := c.connectionEndpoint.ConfigureRoutes(c.config.Provider.Endpoint.IP); err != nil {
+	if err := c.connectionEndpoint.ConfigureRoutes(c.config.Provider.Endpoint.IP, options.Routes); err != nil {
 		c.stateChannel <- connection.NotConnected
 		c.connection.Done()
 		return errors.Wrap(err, "failed to configure routes for connection endpoint")
@@ -99,6 +110,7 @@ func (c *Connection) Start(options connection.ConnectOptions) (err error) {
 		return errors.Wrap(err, "failed while waiting for a peer handshake")
 	}
 
+	c.configureDNS()
 	go c.runPeriodically(time.Second)
 
 	c.stateChannel <- connection.Connected
@@ -122,10 +134,53 @@ func (c *Connection) GetConfig() (connection.ConsumerConfig, error) {
 	}, nil
 }
 
+// Tunnel describes the tunnel to the provider for the kill switch,
+// its interface is known only once the connection is started
+func (c *Connection) Tunnel(options connection.ConnectOptions) (firewall.Tunnel, error) {
+	var config wg.ServiceConfig
+	if err := json.Unmarshal(options.SessionConfig, &config); err != nil {
+		return firewall.Tunnel{}, errors.Wrap(err, "failed to unmarshal connection config")
+	}
+
+	tunnel := firewall.Tunnel{
+		Endpoint: config.Provider.Endpoint.IP,
+		Port:     config.Provider.Endpoint.Port,
+		Protocol: "udp",
+		DNS:      config.Consumer.DNS,
+	}
+	if len(tunnel.DNS) == 0 {
+		tunnel.DNS = dnsServers
+	}
+	if c.connectionEndpoint != nil {
+		tunnel.Interface = c.connectionEndpoint.InterfaceName()
+	}
+	return tunnel, nil
+}
+
+// configureDNS makes the system to resolve names thru the DNS servers pushed by the provider
+func (c *Connection) configureDNS() {
+	if !c.systemDNS {
+		return
+	}
+	if err := c.dnsConfigurator.Configure(c.connectionEndpoint.InterfaceName(), c.config.Consumer.DNS); err != nil {
+		log.Warn(logPrefix, "Failed to configure DNS servers pushed by the provider: ", err)
+	}
+}
+
+func (c *Connection) cleanDNS() {
+	if !c.systemDNS {
+		return
+	}
+	if err := c.dnsConfigurator.Clean(c.connectionEndpoint.InterfaceName()); err != nil {
+		log.Error(logPrefix, "Failed to restore DNS settings: ", err)
+	}
+}
+
 // Stop stops wireguard connection and closes connection endpoint.
 func (c *Connection) Stop() {
 	c.stateChannel <- connection.Disconnecting
 	c.sendStats()
+	c.cleanDNS()
 
 	if err := c.connectionEndpoint.Stop(); err != nil {
 		log.Error(logPrefix, "Failed to close wireguard connection: ", err)
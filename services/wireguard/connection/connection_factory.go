@@ -19,6 +19,7 @@ package connection
 
 import (
 	"github.com/mysteriumnetwork/node/core/connection"
+	"github.com/mysteriumnetwork/node/dns"
 	wg "github.com/mysteriumnetwork/node/services/wireguard"
 	"github.com/mysteriumnetwork/node/services/wireguard/key"
 )
@@ -40,6 +41,7 @@ func (f *Factory) Create(stateChannel connection.StateChannel, statisticsChannel
 		stateChannel:      stateChannel,
 		statisticsChannel: statisticsChannel,
 		config:            config,
+		dnsConfigurator:   dns.NewConfigurator(),
 	}, nil
 }
 
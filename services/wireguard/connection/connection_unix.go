@@ -24,7 +24,10 @@ import (
 	"github.com/mysteriumnetwork/node/services/wireguard/service"
 )
 
+// resourceAllocator is shared by all the connections, so the interfaces of the concurrent connections do not clash.
+// Resource allocator uses config received from the provider. No configuration options required, passing default ones.
+var resourceAllocator = resources.NewAllocator(nil, service.DefaultOptions.Subnet, service.DefaultOptions.Subnet6)
+
 func connectionResourceAllocator() *resources.Allocator {
-	// Resource allocator uses config received from the provider. No configuration options required, passing default ones.
-	return resources.NewAllocator(nil, service.DefaultOptions.Subnet)
+	return resourceAllocator
 }
@@ -43,3 +43,18 @@ func NewConnectionEndpoint(
 		connectDelay:       connectDelay,
 	}, err
 }
+
+// DestroyDevice destroys wireguard network interface by the given name.
+func DestroyDevice(name string) error {
+	client, err := userspace.NewWireguardClient()
+	if err != nil {
+		return err
+	}
+	return client.DestroyDevice(name)
+}
+
+// IsDevice reports whether the network interface by the given name is a wireguard device.
+// User space devices vanish together with the node process, so there are no devices left to look for.
+func IsDevice(name string) bool {
+	return false
+}
@@ -27,6 +27,7 @@ import (
 	"github.com/mysteriumnetwork/node/services/wireguard/endpoint/userspace"
 	"github.com/mysteriumnetwork/node/services/wireguard/resources"
 	"github.com/mysteriumnetwork/node/utils"
+	"golang.zx2c4.com/wireguard/wgctrl"
 )
 
 // NewConnectionEndpoint creates new wireguard connection endpoint.
@@ -51,6 +52,23 @@ func NewConnectionEndpoint(
 	}, nil
 }
 
+// DestroyDevice destroys wireguard network interface by the given name.
+func DestroyDevice(name string) error {
+	return utils.SudoExec("ip", "link", "del", "dev", name)
+}
+
+// IsDevice reports whether the network interface by the given name is a wireguard device.
+func IsDevice(name string) bool {
+	client, err := wgctrl.New()
+	if err != nil {
+		return false
+	}
+	defer client.Close()
+
+	_, err = client.Device(name)
+	return err == nil
+}
+
 func getWGClient() (wgClient wgClient, err error) {
 	if isKernelSpaceSupported() {
 		return kernelspace.NewWireguardClient()
@@ -20,8 +20,10 @@ package endpoint
 import (
 	"fmt"
 	"net"
+	"sync"
 
 	log "github.com/cihub/seelog"
+	"github.com/mysteriumnetwork/node/core/connection/split"
 	"github.com/mysteriumnetwork/node/core/ip"
 	wg "github.com/mysteriumnetwork/node/services/wireguard"
 	"github.com/mysteriumnetwork/node/services/wireguard/key"
@@ -30,13 +32,19 @@ import (
 
 const logPrefix = "[wireguard-connection-endpoint] "
 
+// cleanAbandonedOnce makes interfaces left by the previous run of the node to be destroyed once at startup,
+// later on all the interfaces belong to the running services and connections, even if allocated by another allocator
+var cleanAbandonedOnce sync.Once
+
 type wgClient interface {
 	ConfigureDevice(name string, config wg.DeviceConfig, subnet net.IPNet) error
-	ConfigureRoutes(iface string, ip net.IP) error
+	ConfigureRoutes(iface string, ip net.IP, routes split.Routes) error
+	AssignIPv6(iface string, ipAddr net.IPNet) error
 	DestroyDevice(name string) error
 	AddPeer(name string, peer wg.PeerInfo, allowedIP ...string) error
 	RemovePeer(name string, publicKey string) error
 	PeerStats() (wg.Stats, error)
+	PeerStatsByKey(publicKey string) (wg.Stats, error)
 	Close() error
 }
 
@@ -45,20 +53,46 @@ type connectionEndpoint struct {
 	privateKey         string
 	ipResolver         ip.Resolver
 	ipAddr             net.IPNet
+	ipv6Addr           net.IPNet
 	endpoint           net.UDPAddr
 	resourceAllocator  *resources.Allocator
 	wgClient           wgClient
 	releasePortMapping func()
 	mapPort            func(port int) (releasePortMapping func())
 	connectDelay       int // connect delay in milliseconds
+	// sharedSubnet is routed thru the interface when it is shared by several consumers, nil otherwise
+	sharedSubnet  *net.IPNet
+	sharedSubnet6 net.IPNet
+}
+
+// NewSharedConnectionEndpoint creates new wireguard connection endpoint for providing service to several consumers at once.
+// The whole subnet is routed thru its network interface, so consumers can be added as peers with their own IP addresses.
+func NewSharedConnectionEndpoint(
+	ipResolver ip.Resolver,
+	resourceAllocator *resources.Allocator,
+	mapPort func(port int) (releasePortMapping func()),
+	connectDelay int,
+	subnet, subnet6 net.IPNet) (wg.ConnectionEndpoint, error) {
+
+	endpoint, err := NewConnectionEndpoint(ipResolver, resourceAllocator, mapPort, connectDelay)
+	if err != nil {
+		return nil, err
+	}
+
+	ce := endpoint.(*connectionEndpoint)
+	ce.sharedSubnet = &subnet
+	ce.sharedSubnet6 = subnet6
+	return ce, nil
 }
 
 // Start starts and configure wireguard network interface for providing service.
 // If config is nil, required options will be generated automatically.
 func (ce *connectionEndpoint) Start(config *wg.ServiceConfig) error {
-	if err := ce.cleanAbandonedInterfaces(); err != nil {
-		return err
-	}
+	cleanAbandonedOnce.Do(func() {
+		if err := ce.cleanAbandonedInterfaces(); err != nil {
+			log.Warn(logPrefix, "failed to clean abandoned interfaces: ", err)
+		}
+	})
 
 	iface, err := ce.resourceAllocator.AllocateInterface()
 	if err != nil {
@@ -87,6 +121,14 @@ func (ce *connectionEndpoint) Start(config *wg.ServiceConfig) error {
 		}
 		ce.ipAddr = ipAddr
 		ce.ipAddr.IP = providerIP(ce.ipAddr)
+		if ipv6Addr, ok := ce.providerIPv6Net(ipAddr); ok {
+			ce.ipv6Addr = ipv6Addr
+			ce.ipv6Addr.IP = providerIP(ce.ipv6Addr)
+		}
+		if ce.sharedSubnet != nil {
+			ce.ipAddr.Mask = ce.sharedSubnet.Mask
+			ce.ipv6Addr.Mask = ce.sharedSubnet6.Mask
+		}
 		ce.endpoint.IP = net.ParseIP(pubIP)
 		ce.endpoint.Port = port
 		ce.releasePortMapping = ce.mapPort(port)
@@ -94,11 +136,23 @@ func (ce *connectionEndpoint) Start(config *wg.ServiceConfig) error {
 		deviceConfig.listenPort = ce.endpoint.Port
 	} else {
 		ce.ipAddr = config.Consumer.IPAddress
+		ce.ipv6Addr = config.Consumer.IPv6Address
 		ce.privateKey = config.Consumer.PrivateKey
 	}
 
 	deviceConfig.privateKey = ce.privateKey
-	return ce.wgClient.ConfigureDevice(ce.iface, deviceConfig, ce.ipAddr)
+	if err := ce.wgClient.ConfigureDevice(ce.iface, deviceConfig, ce.ipAddr); err != nil {
+		return err
+	}
+
+	if ce.ipv6Addr.IP != nil {
+		// IPv6 is optional, consumers are not given IPv6 addresses if the provider fails to configure it
+		if err := ce.wgClient.AssignIPv6(ce.iface, ce.ipv6Addr); err != nil {
+			log.Warn(logPrefix, "failed to assign IPv6 address: ", err)
+			ce.ipv6Addr = net.IPNet{}
+		}
+	}
+	return nil
 }
 
 // AddPeer adds new wireguard peer to the wireguard network interface.
@@ -116,6 +170,11 @@ func (ce *connectionEndpoint) PeerStats() (wg.Stats, error) {
 	return ce.wgClient.PeerStats()
 }
 
+// PeerStatsByKey returns stats information about the connected peer with the given public key.
+func (ce *connectionEndpoint) PeerStatsByKey(publicKey string) (wg.Stats, error) {
+	return ce.wgClient.PeerStatsByKey(publicKey)
+}
+
 // Config provides wireguard service configuration for the current connection endpoint.
 func (ce *connectionEndpoint) Config() (wg.ServiceConfig, error) {
 	publicKey, err := key.PrivateKeyToPublicKey(ce.privateKey)
@@ -137,14 +196,24 @@ func (ce *connectionEndpoint) Config() (wg.ServiceConfig, error) {
 	config.Provider.Endpoint = ce.endpoint
 	config.Consumer.IPAddress = ce.ipAddr
 	config.Consumer.IPAddress.IP = ce.consumerIP(ce.ipAddr)
+	if ce.ipv6Addr.IP != nil {
+		config.Consumer.IPv6Address = consumerIPv6Net(ce.ipv6Addr)
+	}
+	// consumer resolves names thru the provider tunnel address unless the service is configured otherwise
+	config.Consumer.DNS = []net.IP{ce.ipAddr.IP}
 	if outIP != pubIP {
 		config.Consumer.ConnectDelay = ce.connectDelay
 	}
 	return config, nil
 }
 
-func (ce *connectionEndpoint) ConfigureRoutes(ip net.IP) error {
-	return ce.wgClient.ConfigureRoutes(ce.iface, ip)
+// InterfaceName returns the name of the wireguard network interface of the connection endpoint.
+func (ce *connectionEndpoint) InterfaceName() string {
+	return ce.iface
+}
+
+func (ce *connectionEndpoint) ConfigureRoutes(ip net.IP, routes split.Routes) error {
+	return ce.wgClient.ConfigureRoutes(ce.iface, ip, routes)
 }
 
 // Stop closes wireguard client and destroys wireguard network interface.
@@ -203,6 +272,14 @@ func (p peerInfo) PublicKey() string {
 	return p.publicKey
 }
 
+// consumerIPv6Net returns the consumer IPv6 address in the /64 network of the given address.
+func consumerIPv6Net(ipv6Addr net.IPNet) net.IPNet {
+	ip := make(net.IP, len(ipv6Addr.IP))
+	copy(ip, ipv6Addr.IP)
+	ip[len(ip)-1] = byte(2)
+	return net.IPNet{IP: ip, Mask: net.CIDRMask(64, 128)}
+}
+
 func providerIP(subnet net.IPNet) net.IP {
 	subnet.IP[len(subnet.IP)-1] = byte(1)
 	return subnet.IP
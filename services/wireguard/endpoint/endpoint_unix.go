@@ -24,6 +24,13 @@ import (
 )
 
 func (ce *connectionEndpoint) consumerIP(subnet net.IPNet) net.IP {
-	subnet.IP[len(subnet.IP)-1] = byte(2)
-	return subnet.IP
+	// the address is copied, so the provider address of the interface is left intact
+	ip := make(net.IP, len(subnet.IP))
+	copy(ip, subnet.IP)
+	ip[len(ip)-1] = byte(2)
+	return ip
+}
+
+func (ce *connectionEndpoint) providerIPv6Net(ipAddr net.IPNet) (net.IPNet, bool) {
+	return ce.resourceAllocator.IPv6NetFor(ipAddr)
 }
@@ -30,3 +30,8 @@ func (ce *connectionEndpoint) consumerIP(subnet net.IPNet) net.IP {
 	}
 	return ipnet.IP
 }
+
+// IPv6 is not supported by the windows provider.
+func (ce *connectionEndpoint) providerIPv6Net(_ net.IPNet) (net.IPNet, bool) {
+	return net.IPNet{}, false
+}
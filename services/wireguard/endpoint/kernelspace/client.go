@@ -19,13 +19,14 @@ package kernelspace
 
 import (
 	"encoding/base64"
-	"errors"
 	"net"
 
 	log "github.com/cihub/seelog"
 	"github.com/jackpal/gateway"
+	"github.com/mysteriumnetwork/node/core/connection/split"
 	wg "github.com/mysteriumnetwork/node/services/wireguard"
 	"github.com/mysteriumnetwork/node/utils"
+	"github.com/pkg/errors"
 	"golang.zx2c4.com/wireguard/wgctrl"
 	"golang.zx2c4.com/wireguard/wgctrl/wgtypes"
 )
@@ -38,6 +39,10 @@ var allowedIPs = []net.IPNet{
 type client struct {
 	iface    string
 	wgClient *wgctrl.Client
+
+	// excluded are the networks routed around the tunnel via gateway, they are removed on close
+	excluded []net.IPNet
+	gateway  net.IP
 }
 
 // NewWireguardClient creates new wireguard kernel space client.
@@ -68,18 +73,30 @@ func (c *client) ConfigureDevice(iface string, config wg.DeviceConfig, ipAddr ne
 	return c.wgClient.ConfigureDevice(iface, deviceConfig)
 }
 
-func (c *client) AddPeer(iface string, peer wg.PeerInfo, _ ...string) error {
+func (c *client) AddPeer(iface string, peer wg.PeerInfo, allowedIP ...string) error {
 	endpoint := peer.Endpoint()
 	publicKey, err := stringToKey(peer.PublicKey())
 	if err != nil {
 		return err
 	}
 
+	peerAllowedIPs := allowedIPs
+	if len(allowedIP) > 0 {
+		peerAllowedIPs = make([]net.IPNet, 0, len(allowedIP))
+		for _, ip := range allowedIP {
+			_, ipnet, err := net.ParseCIDR(ip)
+			if err != nil {
+				return err
+			}
+			peerAllowedIPs = append(peerAllowedIPs, *ipnet)
+		}
+	}
+
 	var deviceConfig wgtypes.Config
 	deviceConfig.Peers = []wgtypes.PeerConfig{{
 		Endpoint:   endpoint,
 		PublicKey:  publicKey,
-		AllowedIPs: allowedIPs,
+		AllowedIPs: peerAllowedIPs,
 	}}
 	return c.wgClient.ConfigureDevice(iface, deviceConfig)
 }
@@ -113,6 +130,30 @@ func (c *client) PeerStats() (wg.Stats, error) {
 	}, nil
 }
 
+func (c *client) PeerStatsByKey(publicKey string) (wg.Stats, error) {
+	key, err := stringToKey(publicKey)
+	if err != nil {
+		return wg.Stats{}, err
+	}
+
+	d, err := c.wgClient.Device(c.iface)
+	if err != nil {
+		return wg.Stats{}, err
+	}
+
+	for _, peer := range d.Peers {
+		if peer.PublicKey == key {
+			return wg.Stats{
+				BytesReceived: uint64(peer.ReceiveBytes),
+				BytesSent:     uint64(peer.TransmitBytes),
+				LastHandshake: peer.LastHandshakeTime,
+			}, nil
+		}
+	}
+
+	return wg.Stats{}, errors.New("peer not found")
+}
+
 func (c *client) DestroyDevice(name string) error {
 	return utils.SudoExec("ip", "link", "del", "dev", name)
 }
@@ -131,20 +172,51 @@ func (c *client) up(iface string, ipAddr net.IPNet) error {
 	return utils.SudoExec("ip", "link", "set", "dev", iface, "up")
 }
 
-func (c *client) ConfigureRoutes(iface string, ip net.IP) error {
-	if err := excludeRoute(ip); err != nil {
-		return err
-	}
-	return addDefaultRoute(iface)
+func (c *client) AssignIPv6(iface string, ipAddr net.IPNet) error {
+	return utils.SudoExec("ip", "-6", "address", "replace", "dev", iface, ipAddr.String())
 }
 
-func excludeRoute(ip net.IP) error {
+func (c *client) ConfigureRoutes(iface string, ip net.IP, routes split.Routes) error {
 	gw, err := gateway.DiscoverGateway()
 	if err != nil {
 		return err
 	}
 
-	return utils.SudoExec("ip", "route", "replace", ip.String(), "via", gw.String())
+	if err := utils.SudoExec("ip", "route", "replace", ip.String(), "via", gw.String()); err != nil {
+		return err
+	}
+	c.gateway = gw
+	for _, network := range routes.Exclude {
+		if err := utils.SudoExec("ip", "route", "replace", network.String(), "via", gw.String()); err != nil {
+			return err
+		}
+		c.excluded = append(c.excluded, network)
+	}
+
+	if !routes.Full() {
+		return addRoutes(iface, routes.Include)
+	}
+	if err := addDefaultIPv6Route(iface); err != nil {
+		if wg.HostHasIPv6() {
+			return errors.Wrap(err, "failed to route IPv6 traffic thru wireguard interface")
+		}
+		// IPv6 traffic can not leak if IPv6 is not available on the host at all
+		log.Warn("IPv6 is not available, IPv6 traffic is not routed thru wireguard interface: ", err)
+	}
+	return addDefaultRoute(iface)
+}
+
+func addRoutes(iface string, networks []net.IPNet) error {
+	for _, network := range networks {
+		args := []string{"ip", "route", "replace", network.String(), "dev", iface}
+		if network.IP.To4() == nil {
+			args = []string{"ip", "-6", "route", "replace", network.String(), "dev", iface}
+		}
+		if err := utils.SudoExec(args...); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 func addDefaultRoute(iface string) error {
@@ -154,6 +226,13 @@ func addDefaultRoute(iface string) error {
 	return utils.SudoExec("ip", "route", "replace", "128.0.0.0/1", "dev", iface)
 }
 
+func addDefaultIPv6Route(iface string) error {
+	if err := utils.SudoExec("ip", "-6", "route", "replace", "::/1", "dev", iface); err != nil {
+		return err
+	}
+	return utils.SudoExec("ip", "-6", "route", "replace", "8000::/1", "dev", iface)
+}
+
 func (c *client) Close() (err error) {
 	var errs []error
 	defer func() {
@@ -165,6 +244,13 @@ func (c *client) Close() (err error) {
 		}
 	}()
 
+	for _, network := range c.excluded {
+		if err := utils.SudoExec("ip", "route", "del", network.String(), "via", c.gateway.String()); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	c.excluded = nil
+
 	if err := c.DestroyDevice(c.iface); err != nil {
 		errs = append(errs, err)
 	}
@@ -22,6 +22,8 @@ import (
 	"net"
 	"time"
 
+	log "github.com/cihub/seelog"
+	"github.com/mysteriumnetwork/node/core/connection/split"
 	wg "github.com/mysteriumnetwork/node/services/wireguard"
 	"github.com/mysteriumnetwork/wireguard-go/device"
 	"github.com/mysteriumnetwork/wireguard-go/tun"
@@ -31,6 +33,9 @@ import (
 type client struct {
 	tun    tun.TUNDevice
 	devAPI *device.DeviceApi
+
+	// excluded are the networks routed around the tunnel, they are removed on close
+	excluded []net.IPNet
 }
 
 // NewWireguardClient creates new wireguard user space client.
@@ -98,14 +103,47 @@ func (c *client) RemovePeer(_ string, publicKey string) error {
 }
 
 func (c *client) Close() error {
+	for _, network := range c.excluded {
+		if err := deleteExcludedNetwork(network); err != nil {
+			log.Warn("Failed to remove the route of the network excluded from the tunnel ", network.String(), ": ", err)
+		}
+	}
+	c.excluded = nil
+
 	c.devAPI.Close() // c.devAPI.Close() closes c.tun too
 	return nil
 }
 
-func (c *client) ConfigureRoutes(iface string, ip net.IP) error {
+func (c *client) AssignIPv6(iface string, ipAddr net.IPNet) error {
+	return assignIPv6(iface, ipAddr)
+}
+
+func (c *client) ConfigureRoutes(iface string, ip net.IP, routes split.Routes) error {
 	if err := excludeRoute(ip); err != nil {
 		return err
 	}
+	for _, network := range routes.Exclude {
+		if err := excludeNetwork(network); err != nil {
+			return err
+		}
+		c.excluded = append(c.excluded, network)
+	}
+
+	if !routes.Full() {
+		for _, network := range routes.Include {
+			if err := addRoute(iface, network); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if err := addDefaultIPv6Route(iface); err != nil {
+		if wg.HostHasIPv6() {
+			return errors.Wrap(err, "failed to route IPv6 traffic thru wireguard interface")
+		}
+		// IPv6 traffic can not leak if IPv6 is not available on the host at all
+		log.Warn("IPv6 is not available, IPv6 traffic is not routed thru wireguard interface: ", err)
+	}
 	return addDefaultRoute(iface)
 }
 
@@ -126,6 +164,30 @@ func (c *client) PeerStats() (wg.Stats, error) {
 	}, nil
 }
 
+func (c *client) PeerStatsByKey(publicKey string) (wg.Stats, error) {
+	key, err := base64stringTo32ByteArray(publicKey)
+	if err != nil {
+		return wg.Stats{}, err
+	}
+
+	peers, err := c.devAPI.Peers()
+	if err != nil {
+		return wg.Stats{}, err
+	}
+
+	for _, peer := range peers {
+		if peer.PublicKey == device.NoisePublicKey(key) {
+			return wg.Stats{
+				BytesSent:     peer.Stats.Sent,
+				BytesReceived: peer.Stats.Received,
+				LastHandshake: time.Unix(int64(peer.LastHanshake), 0),
+			}, nil
+		}
+	}
+
+	return wg.Stats{}, errors.New("peer not found")
+}
+
 func (c *client) DestroyDevice(name string) error {
 	return destroyDevice(name)
 }
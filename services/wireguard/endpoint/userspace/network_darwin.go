@@ -19,6 +19,7 @@ package userspace
 
 import (
 	"net"
+	"strconv"
 
 	"github.com/jackpal/gateway"
 	"github.com/mysteriumnetwork/node/utils"
@@ -28,6 +29,11 @@ func assignIP(iface string, subnet net.IPNet) error {
 	return utils.SudoExec("ifconfig", iface, subnet.String(), peerIP(subnet).String())
 }
 
+func assignIPv6(iface string, subnet net.IPNet) error {
+	prefixLength, _ := subnet.Mask.Size()
+	return utils.SudoExec("ifconfig", iface, "inet6", subnet.IP.String(), "prefixlen", strconv.Itoa(prefixLength), "alias")
+}
+
 func excludeRoute(ip net.IP) error {
 	gw, err := gateway.DiscoverGateway()
 	if err != nil {
@@ -37,6 +43,26 @@ func excludeRoute(ip net.IP) error {
 	return utils.SudoExec("route", "add", "-host", ip.String(), gw.String())
 }
 
+func excludeNetwork(network net.IPNet) error {
+	gw, err := gateway.DiscoverGateway()
+	if err != nil {
+		return err
+	}
+
+	return utils.SudoExec("route", "add", "-net", network.String(), gw.String())
+}
+
+func deleteExcludedNetwork(network net.IPNet) error {
+	return utils.SudoExec("route", "delete", "-net", network.String())
+}
+
+func addRoute(iface string, network net.IPNet) error {
+	if network.IP.To4() == nil {
+		return utils.SudoExec("route", "add", "-inet6", "-net", network.String(), "-interface", iface)
+	}
+	return utils.SudoExec("route", "add", "-net", network.String(), "-interface", iface)
+}
+
 func addDefaultRoute(iface string) error {
 	if err := utils.SudoExec("route", "add", "-net", "0.0.0.0/1", "-interface", iface); err != nil {
 		return err
@@ -45,6 +71,14 @@ func addDefaultRoute(iface string) error {
 	return utils.SudoExec("route", "add", "-net", "128.0.0.0/1", "-interface", iface)
 }
 
+func addDefaultIPv6Route(iface string) error {
+	if err := utils.SudoExec("route", "add", "-inet6", "-net", "::/1", "-interface", iface); err != nil {
+		return err
+	}
+
+	return utils.SudoExec("route", "add", "-inet6", "-net", "8000::/1", "-interface", iface)
+}
+
 func peerIP(subnet net.IPNet) net.IP {
 	lastOctetID := len(subnet.IP) - 1
 	if subnet.IP[lastOctetID] == byte(1) {
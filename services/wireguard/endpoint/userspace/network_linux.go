@@ -31,6 +31,10 @@ func assignIP(iface string, subnet net.IPNet) error {
 	return utils.SudoExec("ip", "link", "set", "dev", iface, "up")
 }
 
+func assignIPv6(iface string, subnet net.IPNet) error {
+	return utils.SudoExec("ip", "-6", "address", "replace", "dev", iface, subnet.String())
+}
+
 func excludeRoute(ip net.IP) error {
 	gw, err := gateway.DiscoverGateway()
 	if err != nil {
@@ -40,6 +44,26 @@ func excludeRoute(ip net.IP) error {
 	return utils.SudoExec("route", "add", "-host", ip.String(), gw.String())
 }
 
+func excludeNetwork(network net.IPNet) error {
+	gw, err := gateway.DiscoverGateway()
+	if err != nil {
+		return err
+	}
+
+	return utils.SudoExec("ip", "route", "replace", network.String(), "via", gw.String())
+}
+
+func deleteExcludedNetwork(network net.IPNet) error {
+	return utils.SudoExec("ip", "route", "del", network.String())
+}
+
+func addRoute(iface string, network net.IPNet) error {
+	if network.IP.To4() == nil {
+		return utils.SudoExec("ip", "-6", "route", "replace", network.String(), "dev", iface)
+	}
+	return utils.SudoExec("ip", "route", "replace", network.String(), "dev", iface)
+}
+
 func addDefaultRoute(iface string) error {
 	if err := utils.SudoExec("route", "add", "-net", "0.0.0.0/1", "-interface", iface); err != nil {
 		return err
@@ -48,6 +72,14 @@ func addDefaultRoute(iface string) error {
 	return utils.SudoExec("route", "add", "-net", "128.0.0.0/1", "-interface", iface)
 }
 
+func addDefaultIPv6Route(iface string) error {
+	if err := utils.SudoExec("ip", "-6", "route", "replace", "::/1", "dev", iface); err != nil {
+		return err
+	}
+
+	return utils.SudoExec("ip", "-6", "route", "replace", "8000::/1", "dev", iface)
+}
+
 func destroyDevice(name string) error {
 	return utils.SudoExec("ip", "link", "del", "dev", name)
 }
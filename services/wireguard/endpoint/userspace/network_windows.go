@@ -34,6 +34,11 @@ func assignIP(iface string, subnet net.IPNet) error {
 	return errors.Wrap(err, string(out))
 }
 
+func assignIPv6(iface string, subnet net.IPNet) error {
+	out, err := exec.Command("powershell", "-Command", "netsh interface ipv6 add address interface=\""+iface+"\" address="+subnet.String()).CombinedOutput()
+	return errors.Wrap(err, string(out))
+}
+
 func renameInterface(name, newname string) error {
 	out, err := exec.Command("powershell", "-Command", "netsh interface set interface name=\""+name+"\" newname=\""+newname+"\"").CombinedOutput()
 	return errors.Wrap(err, string(out))
@@ -49,6 +54,36 @@ func excludeRoute(ip net.IP) error {
 	return errors.Wrap(err, string(out))
 }
 
+func excludeNetwork(network net.IPNet) error {
+	gw, err := gateway.DiscoverGateway()
+	if err != nil {
+		return err
+	}
+
+	out, err := exec.Command("powershell", "-Command", "route add "+network.String()+" "+gw.String()).CombinedOutput()
+	return errors.Wrap(err, string(out))
+}
+
+func deleteExcludedNetwork(network net.IPNet) error {
+	out, err := exec.Command("powershell", "-Command", "route delete "+network.String()).CombinedOutput()
+	return errors.Wrap(err, string(out))
+}
+
+func addRoute(name string, network net.IPNet) error {
+	if network.IP.To4() == nil {
+		out, err := exec.Command("powershell", "-Command", "netsh interface ipv6 add route "+network.String()+" interface=\""+name+"\"").CombinedOutput()
+		return errors.Wrap(err, string(out))
+	}
+
+	id, gw, err := interfaceInfo(name)
+	if err != nil {
+		return errors.Wrap(err, "failed to get info of interface: "+name)
+	}
+
+	out, err := exec.Command("powershell", "-Command", "route add "+network.String()+" "+gw+" if "+id).CombinedOutput()
+	return errors.Wrap(err, string(out))
+}
+
 func addDefaultRoute(name string) error {
 	id, gw, err := interfaceInfo(name)
 	if err != nil {
@@ -63,6 +98,15 @@ func addDefaultRoute(name string) error {
 	return errors.Wrap(err, string(out))
 }
 
+func addDefaultIPv6Route(name string) error {
+	if out, err := exec.Command("powershell", "-Command", "netsh interface ipv6 add route ::/1 interface=\""+name+"\"").CombinedOutput(); err != nil {
+		return errors.Wrap(err, string(out))
+	}
+
+	out, err := exec.Command("powershell", "-Command", "netsh interface ipv6 add route 8000::/1 interface=\""+name+"\"").CombinedOutput()
+	return errors.Wrap(err, string(out))
+}
+
 func destroyDevice(name string) error {
 	// Windows implementation is using single device that are reused for the future needs.
 	// Nothing to destroy here.
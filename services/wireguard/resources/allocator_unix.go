@@ -20,6 +20,7 @@
 package resources
 
 import (
+	"encoding/binary"
 	"fmt"
 	"net"
 	"strconv"
@@ -43,19 +44,25 @@ type Allocator struct {
 	mu          sync.Mutex
 	Ifaces      map[int]struct{}
 	IPAddresses map[int]struct{}
+	// hosts are the addresses of the consumers served thru the shared interface, keyed by the offset in the subnet
+	hosts map[int]struct{}
 
 	portSupplier portSupplier
 	subnet       net.IPNet
+	subnet6      net.IPNet
 }
 
 // NewAllocator creates new resource pool for wireguard connection.
-func NewAllocator(ports portSupplier, subnet net.IPNet) *Allocator {
+// IPv6 addresses are allocated from the subnet6 if it is given.
+func NewAllocator(ports portSupplier, subnet, subnet6 net.IPNet) *Allocator {
 	return &Allocator{
 		Ifaces:      make(map[int]struct{}),
 		IPAddresses: make(map[int]struct{}),
+		hosts:       make(map[int]struct{}),
 
 		portSupplier: ports,
 		subnet:       subnet,
+		subnet6:      subnet6,
 	}
 }
 
@@ -123,6 +130,68 @@ func (a *Allocator) AllocateIPNet() (net.IPNet, error) {
 	return net.IPNet{}, errors.New("no more unused subnets")
 }
 
+// IPv6NetFor provides IPv6 network for the wireguard connection which was allocated the given IP network.
+// IPv6 networks are released together with the IP networks, false is returned if IPv6 is not configured.
+func (a *Allocator) IPv6NetFor(ipnet net.IPNet) (net.IPNet, bool) {
+	ip4 := ipnet.IP.To4()
+	if ip4 == nil || a.subnet6.IP == nil || a.subnet6.IP.To4() != nil {
+		return net.IPNet{}, false
+	}
+
+	return calcIPv6Net(a.subnet6, int(ip4[2])), true
+}
+
+// AllocateHostIPNet provides available host address of the subnet for a consumer served thru the shared interface.
+// The network address and the address of the shared interface, which come first in the subnet, are never given.
+func (a *Allocator) AllocateHostIPNet() (net.IPNet, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	ones, bits := a.subnet.Mask.Size()
+	size := 1 << uint(bits-ones)
+	for i := 2; i < size-1; i++ {
+		if _, ok := a.hosts[i]; !ok {
+			a.hosts[i] = struct{}{}
+			return net.IPNet{IP: calcHostIP(a.subnet.IP.To4(), i), Mask: net.CIDRMask(32, 32)}, nil
+		}
+	}
+	return net.IPNet{}, errors.New("no more unused host addresses")
+}
+
+// IPv6HostFor provides IPv6 host address for the consumer which was allocated the given host address.
+// IPv6 addresses are released together with the host addresses, false is returned if IPv6 is not configured.
+func (a *Allocator) IPv6HostFor(host net.IPNet) (net.IPNet, bool) {
+	ip4 := host.IP.To4()
+	if ip4 == nil || a.subnet6.IP == nil || a.subnet6.IP.To4() != nil {
+		return net.IPNet{}, false
+	}
+
+	return net.IPNet{IP: calcHostIP(a.subnet6.IP, a.hostOffset(ip4)), Mask: net.CIDRMask(128, 128)}, true
+}
+
+// ReleaseHostIPNet releases host address of the consumer served thru the shared interface.
+func (a *Allocator) ReleaseHostIPNet(host net.IPNet) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	ip4 := host.IP.To4()
+	if ip4 == nil {
+		return errors.New("allocated host address not found")
+	}
+
+	i := a.hostOffset(ip4)
+	if _, ok := a.hosts[i]; !ok {
+		return errors.New("allocated host address not found")
+	}
+
+	delete(a.hosts, i)
+	return nil
+}
+
+func (a *Allocator) hostOffset(ip4 net.IP) int {
+	return int(binary.BigEndian.Uint32(ip4) - binary.BigEndian.Uint32(a.subnet.IP.To4()))
+}
+
 // AllocatePort provides available UDP port for the wireguard endpoint.
 func (a *Allocator) AllocatePort() (int, error) {
 	a.mu.Lock()
@@ -172,6 +241,15 @@ func (a *Allocator) ReleaseIPNet(ipnet net.IPNet) error {
 	return nil
 }
 
+// IsInterfaceName reports whether the network interface name is the one given by the Allocator.
+func IsInterfaceName(name string) bool {
+	if !strings.HasPrefix(name, interfacePrefix) {
+		return false
+	}
+	_, err := strconv.Atoi(strings.TrimPrefix(name, interfacePrefix))
+	return err == nil
+}
+
 func interfaceExists(ifaces []net.Interface, name string) bool {
 	for _, iface := range ifaces {
 		if iface.Name == name {
@@ -181,6 +259,23 @@ func interfaceExists(ifaces []net.Interface, name string) bool {
 	return false
 }
 
+func calcIPv6Net(ipnet net.IPNet, index int) net.IPNet {
+	ip := make(net.IP, net.IPv6len)
+	copy(ip, ipnet.IP)
+	ip[6] = byte(index >> 8)
+	ip[7] = byte(index)
+	return net.IPNet{IP: ip, Mask: net.CIDRMask(64, 128)}
+}
+
+// calcHostIP adds the offset to the last 4 bytes of the network address
+func calcHostIP(network net.IP, offset int) net.IP {
+	ip := make(net.IP, len(network))
+	copy(ip, network)
+	last := ip[len(ip)-4:]
+	binary.BigEndian.PutUint32(last, binary.BigEndian.Uint32(last)+uint32(offset))
+	return ip
+}
+
 func calcIPNet(ipnet net.IPNet, index int) net.IPNet {
 	ip := make(net.IP, len(ipnet.IP))
 	copy(ip, ipnet.IP)
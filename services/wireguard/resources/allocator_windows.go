@@ -110,6 +110,11 @@ func (a *Allocator) ReleaseIPNet(ipnet net.IPNet) error {
 	return nil
 }
 
+// IsInterfaceName reports whether the network interface name is the one given by the Allocator.
+func IsInterfaceName(name string) bool {
+	return name == interfacePrefix
+}
+
 func calcIPNet(ipnet net.IPNet, index int) net.IPNet {
 	ip := make(net.IP, len(ipnet.IP))
 	copy(ip, ipnet.IP)
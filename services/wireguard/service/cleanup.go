@@ -0,0 +1,100 @@
+/*
+ * Copyright (C) 2019 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package service
+
+import (
+	"encoding/json"
+	"net"
+
+	log "github.com/cihub/seelog"
+	"github.com/mysteriumnetwork/node/core/ip"
+	"github.com/mysteriumnetwork/node/nat"
+	wg "github.com/mysteriumnetwork/node/services/wireguard"
+	"github.com/mysteriumnetwork/node/services/wireguard/endpoint"
+	"github.com/mysteriumnetwork/node/services/wireguard/resources"
+	"github.com/mysteriumnetwork/node/session"
+	"github.com/mysteriumnetwork/node/utils"
+	"github.com/pkg/errors"
+)
+
+// NewSessionCleanup creates cleanup which tears down the network interface (together with the consumer peer)
+// and the IPv4 and IPv6 NAT forwarding rules of a wireguard session left unfinished by the previous node run.
+func NewSessionCleanup(ipResolver ip.Resolver, natService nat.NATService) func(record session.Record) error {
+	return func(record session.Record) error {
+		var config wg.ServiceConfig
+		if err := json.Unmarshal(record.Config, &config); err != nil {
+			return errors.Wrap(err, "failed to parse session config")
+		}
+
+		if iface, found := sessionInterface(config.Consumer.IPAddress); found {
+			if err := endpoint.DestroyDevice(iface); err != nil {
+				log.Warn(logPrefix, "failed to destroy abandoned interface: ", iface, ", error: ", err)
+			} else {
+				log.Info(logPrefix, "abandoned interface destroyed: ", iface)
+			}
+		}
+
+		outIP, err := ipResolver.GetOutboundIP()
+		if err != nil {
+			return err
+		}
+
+		natRules := []nat.RuleForwarding{{SourceAddress: config.Consumer.IPAddress.String(), TargetIP: outIP}}
+		if config.Consumer.IPv6Address.IP != nil {
+			natRules = append(natRules, nat.RuleForwarding{SourceAddress: config.Consumer.IPv6Address.String()})
+		}
+
+		errs := utils.ErrorCollection{}
+		for _, natRule := range natRules {
+			errs.Add(natService.Del(natRule))
+		}
+		return errs.Errorf("failed to delete NAT forwarding rules: %s", ", ")
+	}
+}
+
+// sessionInterface finds the wireguard interface which was serving the session with the given subnet alone.
+// The shared interface holds an address of the whole service subnet, so it is never matched for a single session.
+func sessionInterface(subnet net.IPNet) (string, bool) {
+	ifaces, err := net.Interfaces()
+	if err != nil {
+		return "", false
+	}
+
+	for _, iface := range ifaces {
+		if !resources.IsInterfaceName(iface.Name) {
+			continue
+		}
+		addrs, err := iface.Addrs()
+		if err != nil {
+			continue
+		}
+		for _, addr := range addrs {
+			if ipNet, ok := addr.(*net.IPNet); ok && sameNetwork(*ipNet, subnet) && endpoint.IsDevice(iface.Name) {
+				return iface.Name, true
+			}
+		}
+	}
+	return "", false
+}
+
+// sameNetwork reports whether both addresses belong to the same network of the same size
+func sameNetwork(a, b net.IPNet) bool {
+	aOnes, aBits := a.Mask.Size()
+	bOnes, bBits := b.Mask.Size()
+	return aOnes == bOnes && aBits == bBits && a.IP.Mask(a.Mask).Equal(b.IP.Mask(b.Mask))
+}
@@ -0,0 +1,91 @@
+/*
+ * Copyright (C) 2019 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package service
+
+import (
+	"encoding/json"
+	"net"
+	"testing"
+
+	"github.com/mysteriumnetwork/node/core/ip"
+	"github.com/mysteriumnetwork/node/nat"
+	"github.com/mysteriumnetwork/node/session"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_SessionCleanup_DeletesNATRule(t *testing.T) {
+	natService := &natServiceRecorder{}
+	cleanup := NewSessionCleanup(ip.NewResolverMock("1.2.3.4"), natService)
+
+	config := json.RawMessage(`{
+		"provider": {"public_key": "key", "endpoint": "1.2.3.4:51820"},
+		"consumer": {"ip_address": "10.182.47.2/24", "connect_delay": 0}
+	}`)
+	err := cleanup(session.Record{ID: "session1", Config: config})
+
+	assert.NoError(t, err)
+	assert.Equal(t, []nat.RuleForwarding{{SourceAddress: "10.182.47.2/24", TargetIP: "1.2.3.4"}}, natService.deleted)
+}
+
+func Test_SessionCleanup_DeletesIPv6NATRule(t *testing.T) {
+	natService := &natServiceRecorder{}
+	cleanup := NewSessionCleanup(ip.NewResolverMock("1.2.3.4"), natService)
+
+	config := json.RawMessage(`{
+		"provider": {"public_key": "key", "endpoint": "1.2.3.4:51820"},
+		"consumer": {"ip_address": "10.182.47.2/24", "ipv6_address": "fd10:182:0:2f::2/64", "connect_delay": 0}
+	}`)
+	err := cleanup(session.Record{ID: "session1", Config: config})
+
+	assert.NoError(t, err)
+	assert.Equal(t, []nat.RuleForwarding{
+		{SourceAddress: "10.182.47.2/24", TargetIP: "1.2.3.4"},
+		{SourceAddress: "fd10:182:0:2f::2/64"},
+	}, natService.deleted)
+}
+
+func Test_SessionCleanup_FailsOnInvalidConfig(t *testing.T) {
+	natService := &natServiceRecorder{}
+	cleanup := NewSessionCleanup(ip.NewResolverMock("1.2.3.4"), natService)
+
+	err := cleanup(session.Record{ID: "session1", Config: json.RawMessage(`{}`)})
+
+	assert.Error(t, err)
+	assert.Len(t, natService.deleted, 0)
+}
+
+func Test_SameNetwork(t *testing.T) {
+	_, session, _ := net.ParseCIDR("10.182.47.2/24")
+	sessionIface := net.IPNet{IP: net.ParseIP("10.182.47.1"), Mask: net.CIDRMask(24, 32)}
+	sharedIface := net.IPNet{IP: net.ParseIP("10.182.0.1"), Mask: net.CIDRMask(16, 32)}
+	otherIface := net.IPNet{IP: net.ParseIP("10.182.48.1"), Mask: net.CIDRMask(24, 32)}
+
+	assert.True(t, sameNetwork(sessionIface, *session))
+	assert.False(t, sameNetwork(sharedIface, *session))
+	assert.False(t, sameNetwork(otherIface, *session))
+}
+
+type natServiceRecorder struct {
+	serviceFake
+	deleted []nat.RuleForwarding
+}
+
+func (service *natServiceRecorder) Del(rule nat.RuleForwarding) error {
+	service.deleted = append(service.deleted, rule)
+	return nil
+}
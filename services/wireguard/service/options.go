@@ -19,11 +19,18 @@ package service
 
 import (
 	"encoding/json"
+	"errors"
 	"net"
+	"strings"
 
 	log "github.com/cihub/seelog"
 	"github.com/mysteriumnetwork/node/core/port"
 	"github.com/mysteriumnetwork/node/core/service"
+	"github.com/mysteriumnetwork/node/datasize"
+	"github.com/mysteriumnetwork/node/dns"
+	"github.com/mysteriumnetwork/node/market"
+	"github.com/mysteriumnetwork/node/money"
+	wg "github.com/mysteriumnetwork/node/services/wireguard"
 	"github.com/mysteriumnetwork/node/services/wireguard/resources"
 	"github.com/urfave/cli"
 )
@@ -33,6 +40,20 @@ type Options struct {
 	ConnectDelay int
 	Ports        *port.Range
 	Subnet       net.IPNet
+	// Subnet6 is the IPv6 subnet with /48 prefix, consumers do not get IPv6 addresses if it is empty
+	Subnet6 net.IPNet
+
+	PricePerMinute float64
+	PricePerGB     float64
+
+	// SharedInterface makes all consumers to be served thru a single wireguard interface as separate peers
+	SharedInterface bool
+
+	// BandwidthLimit is the bandwidth per second in each direction every session is limited to, zero means unlimited
+	BandwidthLimit datasize.BitSize
+
+	// DNS servers pushed to consumers, the provider tunnel address is pushed if empty
+	DNS []net.IP
 }
 
 var (
@@ -50,6 +71,34 @@ var (
 		Usage: "Subnet allowed for using by the wireguard services",
 		Value: DefaultOptions.Subnet.String(),
 	}
+	subnet6 = cli.StringFlag{
+		Name:  "wireguard.allowed.subnet6",
+		Usage: "IPv6 subnet (/48) allowed for using by the wireguard services, IPv6 is disabled if empty",
+		Value: DefaultOptions.Subnet6.String(),
+	}
+	pricePerMinuteFlag = cli.Float64Flag{
+		Name:  "wireguard.price-per-minute",
+		Usage: "Price in MYST charged for a minute of the wireguard service",
+		Value: DefaultOptions.PricePerMinute,
+	}
+	pricePerGBFlag = cli.Float64Flag{
+		Name:  "wireguard.price-per-gb",
+		Usage: "Price in MYST charged for a gigabyte of traffic of the wireguard service, takes precedence over the price per minute",
+		Value: DefaultOptions.PricePerGB,
+	}
+	sharedInterfaceFlag = cli.BoolFlag{
+		Name:  "wireguard.shared-interface",
+		Usage: "Serve all consumers thru a single wireguard interface instead of creating an interface per session",
+	}
+	bandwidthLimitFlag = cli.Float64Flag{
+		Name:  "wireguard.bandwidth-limit",
+		Usage: "Bandwidth in Mbit/s every session is limited to in each direction, 0 means unlimited",
+		Value: toMbps(DefaultOptions.BandwidthLimit),
+	}
+	dnsFlag = cli.StringFlag{
+		Name:  "wireguard.dns",
+		Usage: "Comma separated DNS servers pushed to consumers, the provider tunnel address is used if empty",
+	}
 )
 
 // DefaultOptions is a wireguard service configuration that will be used if no options provided.
@@ -59,11 +108,16 @@ var DefaultOptions = Options{
 	Subnet: net.IPNet{
 		IP:   net.ParseIP("10.182.0.0"),
 		Mask: net.IPv4Mask(255, 255, 0, 0),
-	}}
+	},
+	Subnet6: net.IPNet{
+		IP:   net.ParseIP("fd10:182::"),
+		Mask: net.CIDRMask(48, 128),
+	},
+}
 
 // RegisterFlags function register Wireguard flags to flag list
 func RegisterFlags(flags *[]cli.Flag) {
-	*flags = append(*flags, delayFlag, ports, subnet)
+	*flags = append(*flags, delayFlag, ports, subnet, subnet6, pricePerMinuteFlag, pricePerGBFlag, sharedInterfaceFlag, bandwidthLimitFlag, dnsFlag)
 }
 
 // ParseFlags function fills in Wireguard options from CLI context
@@ -74,6 +128,24 @@ func ParseFlags(ctx *cli.Context) service.Options {
 		ipnet = &DefaultOptions.Subnet
 	}
 
+	ipnet6, err := parseSubnet6(ctx.String(subnet6.Name))
+	if err != nil {
+		log.Warn(logPrefix, "Failed to parse IPv6 subnet option, using default value. ", err)
+		ipnet6 = DefaultOptions.Subnet6
+	}
+
+	bandwidthLimit := ctx.Float64(bandwidthLimitFlag.Name)
+	if bandwidthLimit < 0 {
+		log.Warn(logPrefix, "Bandwidth limit can not be negative, sessions are not limited")
+		bandwidthLimit = 0
+	}
+
+	dnsServers, err := dns.ParseServers(ctx.String(dnsFlag.Name))
+	if err != nil {
+		log.Warn(logPrefix, "Failed to parse DNS servers, the provider tunnel address is used. ", err)
+		dnsServers = nil
+	}
+
 	portRange, err := port.ParseRange(ctx.String(ports.Name))
 	if err != nil {
 		log.Warn(logPrefix, "Failed to parse listen port range, using default value. ", err)
@@ -89,6 +161,15 @@ func ParseFlags(ctx *cli.Context) service.Options {
 		ConnectDelay: ctx.Int(delayFlag.Name),
 		Ports:        portRange,
 		Subnet:       *ipnet,
+		Subnet6:      ipnet6,
+
+		PricePerMinute: ctx.Float64(pricePerMinuteFlag.Name),
+		PricePerGB:     ctx.Float64(pricePerGBFlag.Name),
+
+		SharedInterface: ctx.Bool(sharedInterfaceFlag.Name),
+		BandwidthLimit:  fromMbps(bandwidthLimit),
+
+		DNS: dnsServers,
 	}
 }
 
@@ -103,25 +184,74 @@ func ParseJSONOptions(request *json.RawMessage) (service.Options, error) {
 	return opts, err
 }
 
+func parseSubnet6(value string) (net.IPNet, error) {
+	if value == "" {
+		return net.IPNet{}, nil
+	}
+
+	_, ipnet, err := net.ParseCIDR(value)
+	if err != nil {
+		return net.IPNet{}, err
+	}
+	if ipnet.IP.To4() != nil {
+		return net.IPNet{}, errors.New("IPv6 subnet expected")
+	}
+	return *ipnet, nil
+}
+
+// PaymentMethod returns the payment method of the service priced by the options
+func (o Options) PaymentMethod() (string, market.PaymentMethod) {
+	if o.PricePerGB > 0 {
+		return market.PaymentMethodPerGB, market.PaymentPerGB{
+			Price: money.NewMoney(o.PricePerGB, money.CurrencyMyst),
+		}
+	}
+
+	return wg.PaymentMethod, wg.Payment{
+		Price: money.NewMoney(o.PricePerMinute, money.CurrencyMyst),
+	}
+}
+
 // MarshalJSON implements json.Marshaler interface to provide human readable configuration.
 func (o Options) MarshalJSON() ([]byte, error) {
 	return json.Marshal(&struct {
-		ConnectDelay int    `json:"connectDelay"`
-		Ports        string `json:"ports"`
-		Subnet       string `json:"subnet"`
+		ConnectDelay    int      `json:"connectDelay"`
+		Ports           string   `json:"ports"`
+		Subnet          string   `json:"subnet"`
+		Subnet6         string   `json:"subnet6"`
+		PricePerMinute  float64  `json:"pricePerMinute"`
+		PricePerGB      float64  `json:"pricePerGB"`
+		SharedInterface bool     `json:"sharedInterface"`
+		BandwidthLimit  float64  `json:"bandwidthLimit"`
+		DNS             []string `json:"dns,omitempty"`
 	}{
 		ConnectDelay: o.ConnectDelay,
 		Ports:        o.Ports.String(),
 		Subnet:       o.Subnet.String(),
+		Subnet6:      subnet6String(o.Subnet6),
+
+		PricePerMinute: o.PricePerMinute,
+		PricePerGB:     o.PricePerGB,
+
+		SharedInterface: o.SharedInterface,
+		BandwidthLimit:  toMbps(o.BandwidthLimit),
+
+		DNS: ipStrings(o.DNS),
 	})
 }
 
 // UnmarshalJSON implements json.Unmarshaler interface to receive human readable configuration.
 func (o *Options) UnmarshalJSON(data []byte) error {
 	var options struct {
-		ConnectDelay int    `json:"connectDelay"`
-		Ports        string `json:"ports"`
-		Subnet       string `json:"subnet"`
+		ConnectDelay    int      `json:"connectDelay"`
+		Ports           string   `json:"ports"`
+		Subnet          string   `json:"subnet"`
+		Subnet6         *string  `json:"subnet6"`
+		PricePerMinute  *float64 `json:"pricePerMinute"`
+		PricePerGB      *float64 `json:"pricePerGB"`
+		SharedInterface *bool    `json:"sharedInterface"`
+		BandwidthLimit  *float64 `json:"bandwidthLimit"`
+		DNS             []string `json:"dns"`
 	}
 
 	if err := json.Unmarshal(data, &options); err != nil {
@@ -145,6 +275,64 @@ func (o *Options) UnmarshalJSON(data []byte) error {
 		}
 		o.Subnet = *ipnet
 	}
+	if options.Subnet6 != nil {
+		ipnet6, err := parseSubnet6(*options.Subnet6)
+		if err != nil {
+			return err
+		}
+		o.Subnet6 = ipnet6
+	}
+	if options.PricePerMinute != nil {
+		if *options.PricePerMinute < 0 {
+			return errors.New("price can not be negative")
+		}
+		o.PricePerMinute = *options.PricePerMinute
+	}
+	if options.PricePerGB != nil {
+		if *options.PricePerGB < 0 {
+			return errors.New("price can not be negative")
+		}
+		o.PricePerGB = *options.PricePerGB
+	}
+	if options.SharedInterface != nil {
+		o.SharedInterface = *options.SharedInterface
+	}
+	if options.BandwidthLimit != nil {
+		if *options.BandwidthLimit < 0 {
+			return errors.New("bandwidth limit can not be negative")
+		}
+		o.BandwidthLimit = fromMbps(*options.BandwidthLimit)
+	}
+	if len(options.DNS) > 0 {
+		servers, err := dns.ParseServers(strings.Join(options.DNS, ","))
+		if err != nil {
+			return err
+		}
+		o.DNS = servers
+	}
 
 	return nil
 }
+
+func subnet6String(ipnet net.IPNet) string {
+	if ipnet.IP == nil {
+		return ""
+	}
+	return ipnet.String()
+}
+
+func ipStrings(ips []net.IP) []string {
+	var strs []string
+	for _, ip := range ips {
+		strs = append(strs, ip.String())
+	}
+	return strs
+}
+
+func fromMbps(mbps float64) datasize.BitSize {
+	return datasize.BitSize(mbps * 1000 * 1000)
+}
+
+func toMbps(limit datasize.BitSize) float64 {
+	return float64(limit / (1000 * 1000))
+}
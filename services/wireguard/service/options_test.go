@@ -23,6 +23,10 @@ import (
 	"testing"
 
 	"github.com/mysteriumnetwork/node/core/port"
+	"github.com/mysteriumnetwork/node/datasize"
+	"github.com/mysteriumnetwork/node/market"
+	"github.com/mysteriumnetwork/node/money"
+	wg "github.com/mysteriumnetwork/node/services/wireguard"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -53,5 +57,110 @@ func Test_ParseJSONOptions_ValidRequest(t *testing.T) {
 			IP:   net.ParseIP("10.10.0.0").To4(),
 			Mask: net.IPv4Mask(255, 255, 0, 0),
 		},
+		Subnet6: DefaultOptions.Subnet6,
 	}, options)
 }
+
+func Test_ParseJSONOptions_ParsesSubnet6(t *testing.T) {
+	request := json.RawMessage(`{"subnet6": "fd00:1:2::/48"}`)
+	options, err := ParseJSONOptions(&request)
+
+	assert.NoError(t, err)
+	subnet6 := options.(Options).Subnet6
+	assert.Equal(t, "fd00:1:2::/48", subnet6.String())
+
+	request = json.RawMessage(`{"subnet6": ""}`)
+	options, err = ParseJSONOptions(&request)
+
+	assert.NoError(t, err)
+	assert.Nil(t, options.(Options).Subnet6.IP)
+
+	request = json.RawMessage(`{"subnet6": "10.0.0.0/16"}`)
+	_, err = ParseJSONOptions(&request)
+
+	assert.Error(t, err)
+}
+
+func Test_ParseJSONOptions_ParsesPrices(t *testing.T) {
+	request := json.RawMessage(`{"pricePerMinute": 0.01, "pricePerGB": 0.5}`)
+	options, err := ParseJSONOptions(&request)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 0.01, options.(Options).PricePerMinute)
+	assert.Equal(t, 0.5, options.(Options).PricePerGB)
+}
+
+func Test_Options_UnmarshalJSONResetsPricesToZero(t *testing.T) {
+	options := Options{PricePerMinute: 0.01, PricePerGB: 0.5, BandwidthLimit: 10 * 1000 * 1000 * datasize.Bit}
+	err := json.Unmarshal([]byte(`{"pricePerMinute": 0, "pricePerGB": 0, "bandwidthLimit": 0}`), &options)
+
+	assert.NoError(t, err)
+	assert.Zero(t, options.PricePerMinute)
+	assert.Zero(t, options.PricePerGB)
+	assert.Zero(t, options.BandwidthLimit)
+
+	options = Options{PricePerMinute: 0.01, PricePerGB: 0.5}
+	err = json.Unmarshal([]byte(`{}`), &options)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 0.01, options.PricePerMinute)
+	assert.Equal(t, 0.5, options.PricePerGB)
+}
+
+func Test_ParseJSONOptions_ParsesSharedInterface(t *testing.T) {
+	request := json.RawMessage(`{"sharedInterface": true}`)
+	options, err := ParseJSONOptions(&request)
+
+	assert.NoError(t, err)
+	assert.True(t, options.(Options).SharedInterface)
+}
+
+func Test_ParseJSONOptions_ParsesBandwidthLimit(t *testing.T) {
+	request := json.RawMessage(`{"bandwidthLimit": 2.5}`)
+	options, err := ParseJSONOptions(&request)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 2500*1000*datasize.Bit, options.(Options).BandwidthLimit)
+
+	request = json.RawMessage(`{"bandwidthLimit": -1}`)
+	_, err = ParseJSONOptions(&request)
+
+	assert.Error(t, err)
+}
+
+func Test_Options_MarshalJSONBandwidthLimit(t *testing.T) {
+	data, err := json.Marshal(Options{Ports: port.UnspecifiedRange(), BandwidthLimit: 10 * 1000 * 1000 * datasize.Bit})
+
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), `"bandwidthLimit":10`)
+}
+
+func Test_ParseJSONOptions_ParsesDNS(t *testing.T) {
+	request := json.RawMessage(`{"dns": ["1.1.1.1", "2606:4700:4700::1111"]}`)
+	options, err := ParseJSONOptions(&request)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []net.IP{net.ParseIP("1.1.1.1"), net.ParseIP("2606:4700:4700::1111")}, options.(Options).DNS)
+
+	request = json.RawMessage(`{"dns": ["dns.example"]}`)
+	_, err = ParseJSONOptions(&request)
+
+	assert.Error(t, err)
+}
+
+func Test_ParseJSONOptions_RejectsNegativePrice(t *testing.T) {
+	request := json.RawMessage(`{"pricePerMinute": -1}`)
+	_, err := ParseJSONOptions(&request)
+
+	assert.Error(t, err)
+}
+
+func Test_Options_PaymentMethod(t *testing.T) {
+	methodType, method := Options{PricePerMinute: 0.01}.PaymentMethod()
+	assert.Equal(t, wg.PaymentMethod, methodType)
+	assert.Equal(t, wg.Payment{Price: money.NewMoney(0.01, money.CurrencyMyst)}, method)
+
+	methodType, method = Options{PricePerMinute: 0.01, PricePerGB: 0.5}.PaymentMethod()
+	assert.Equal(t, market.PaymentMethodPerGB, methodType)
+	assert.Equal(t, market.PaymentPerGB{Price: money.NewMoney(0.5, money.CurrencyMyst)}, method)
+}
@@ -18,16 +18,18 @@
 package service
 
 import (
+	"net"
+
 	"github.com/mysteriumnetwork/node/core/location"
 	"github.com/mysteriumnetwork/node/market"
-	"github.com/mysteriumnetwork/node/money"
+	"github.com/mysteriumnetwork/node/nat/egress"
 	wg "github.com/mysteriumnetwork/node/services/wireguard"
 )
 
 const logPrefix = "[service-wireguard] "
 
-// GetProposal returns the proposal for wireguard service
-func GetProposal(location location.Location) market.ServiceProposal {
+// GetProposal returns the proposal for wireguard service priced by the given options
+func GetProposal(location location.Location, options Options) market.ServiceProposal {
 	marketLocation := market.Location{
 		Continent: location.Continent,
 		Country:   location.Country,
@@ -38,15 +40,38 @@ func GetProposal(location location.Location) market.ServiceProposal {
 		NodeType: location.NodeType,
 	}
 
+	paymentMethodType, paymentMethod := options.PaymentMethod()
 	return market.ServiceProposal{
 		ServiceType: wg.ServiceType,
 		ServiceDefinition: wg.ServiceDefinition{
 			Location:          marketLocation,
 			LocationOriginate: marketLocation,
+			SessionBandwidth:  options.BandwidthLimit,
 		},
-		PaymentMethodType: wg.PaymentMethod,
-		PaymentMethod: wg.Payment{
-			Price: money.NewMoney(0, money.CurrencyMyst),
-		},
+		PaymentMethodType: paymentMethodType,
+		PaymentMethod:     paymentMethod,
+	}
+}
+
+// defaultDNSServers are pushed to consumers if the service neither serves DNS itself nor is configured with DNS servers
+var defaultDNSServers = []net.IP{net.ParseIP("208.67.222.222"), net.ParseIP("208.67.220.220")}
+
+// pushDNS makes the consumer to resolve names thru the configured DNS servers,
+// thru the provider tunnel address if the forwarder serves DNS there or thru the default servers otherwise
+func pushDNS(config *wg.ServiceConfig, options Options, dnsServing bool) {
+	switch {
+	case len(options.DNS) > 0:
+		config.Consumer.DNS = options.DNS
+	case !dnsServing:
+		config.Consumer.DNS = defaultDNSServers
+	}
+}
+
+// EgressStats returns the counters of the consumer traffic blocked by the egress policy
+func (manager *Manager) EgressStats() (egress.Stats, bool) {
+	reporter, ok := manager.natService.(egress.Reporter)
+	if !ok {
+		return egress.Stats{}, false
 	}
+	return reporter.Stats(), true
 }
@@ -19,17 +19,23 @@ package service
 
 import (
 	"encoding/json"
+	"errors"
 	"net"
 	"testing"
 	"time"
 
+	"github.com/mysteriumnetwork/node/core/connection/split"
 	"github.com/mysteriumnetwork/node/core/ip"
 	"github.com/mysteriumnetwork/node/core/location"
+	"github.com/mysteriumnetwork/node/datasize"
 	"github.com/mysteriumnetwork/node/identity"
 	"github.com/mysteriumnetwork/node/market"
 	"github.com/mysteriumnetwork/node/money"
 	"github.com/mysteriumnetwork/node/nat"
 	wg "github.com/mysteriumnetwork/node/services/wireguard"
+	"github.com/mysteriumnetwork/node/services/wireguard/resources"
+	"github.com/mysteriumnetwork/node/session"
+	"github.com/mysteriumnetwork/node/shaper"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -59,10 +65,17 @@ func Test_GetProposal(t *testing.T) {
 				},
 			},
 		},
-		GetProposal(location.Location{Country: country}),
+		GetProposal(location.Location{Country: country}, DefaultOptions),
 	)
 }
 
+func Test_GetProposal_PricedPerGB(t *testing.T) {
+	proposal := GetProposal(location.Location{Country: country}, Options{PricePerGB: 0.5})
+
+	assert.Equal(t, "PER_GB", proposal.PaymentMethodType)
+	assert.Equal(t, market.PaymentPerGB{Price: money.NewMoney(0.5, money.CurrencyMyst)}, proposal.PaymentMethod)
+}
+
 func Test_Manager_Serve(t *testing.T) {
 	manager := newManagerStub(pubIP, outIP, country)
 
@@ -74,6 +87,79 @@ func Test_Manager_Serve(t *testing.T) {
 	sessionConfig, err := manager.ProvideConfig(json.RawMessage(`{"PublicKey": "gZfkZArbw9lqfl4Yzr1Kv3nqGlhe/ynH9KKRbzPFMGk="}`), nil)
 	assert.NoError(t, err)
 	assert.NotNil(t, sessionConfig)
+
+	dataTransfer, err := sessionConfig.DataTransferProvider()
+	assert.NoError(t, err)
+	assert.Equal(t, session.DataTransfer{BytesSent: 10, BytesReceived: 20}, dataTransfer)
+}
+
+func Test_Manager_ProvideConfig_PushesConfiguredDNS(t *testing.T) {
+	manager := newManagerStub(pubIP, outIP, country)
+	manager.options = Options{DNS: []net.IP{net.ParseIP("1.1.1.1")}}
+
+	sessionConfig, err := manager.ProvideConfig(json.RawMessage(`{"PublicKey": "gZfkZArbw9lqfl4Yzr1Kv3nqGlhe/ynH9KKRbzPFMGk="}`), nil)
+	assert.NoError(t, err)
+	assert.Equal(t, []net.IP{net.ParseIP("1.1.1.1")}, sessionConfig.SessionServiceConfig.(wg.ServiceConfig).Consumer.DNS)
+}
+
+func Test_Manager_ProvideConfig_ServesDNSOnTunnelIP(t *testing.T) {
+	listener := &dnsListenerFake{}
+	manager := newManagerStub(pubIP, outIP, country)
+	manager.options = Options{DNS: []net.IP{net.ParseIP("1.1.1.1")}}
+	manager.connectionEndpointFactory = func() (wg.ConnectionEndpoint, error) {
+		endpoint := &mockSharedEndpoint{peers: make(map[string][]string)}
+		endpoint.config.Consumer.DNS = []net.IP{net.ParseIP("10.182.1.1")}
+		return endpoint, nil
+	}
+	manager.HostDNS(listener)
+
+	sessionConfig, err := manager.ProvideConfig(json.RawMessage(`{"PublicKey": "key1"}`), nil)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"10.182.1.1"}, listener.ips)
+
+	sessionConfig.SessionDestroyCallback()
+	assert.Empty(t, listener.ips)
+}
+
+func Test_Manager_ProvideConfig_PushesTunnelIPWhenServingDNS(t *testing.T) {
+	manager := newManagerStub(pubIP, outIP, country)
+	manager.connectionEndpointFactory = func() (wg.ConnectionEndpoint, error) {
+		endpoint := &mockSharedEndpoint{peers: make(map[string][]string)}
+		endpoint.config.Consumer.DNS = []net.IP{net.ParseIP("10.182.1.1")}
+		return endpoint, nil
+	}
+	manager.HostDNS(&dnsListenerFake{})
+
+	sessionConfig, err := manager.ProvideConfig(json.RawMessage(`{"PublicKey": "key1"}`), nil)
+	assert.NoError(t, err)
+	assert.Equal(t, []net.IP{net.ParseIP("10.182.1.1")}, sessionConfig.SessionServiceConfig.(wg.ServiceConfig).Consumer.DNS)
+}
+
+func Test_Manager_ProvideConfig_PushesDefaultDNSWithoutForwarder(t *testing.T) {
+	manager := newManagerStub(pubIP, outIP, country)
+	manager.connectionEndpointFactory = func() (wg.ConnectionEndpoint, error) {
+		endpoint := &mockSharedEndpoint{peers: make(map[string][]string)}
+		endpoint.config.Consumer.DNS = []net.IP{net.ParseIP("10.182.1.1")}
+		return endpoint, nil
+	}
+
+	sessionConfig, err := manager.ProvideConfig(json.RawMessage(`{"PublicKey": "key1"}`), nil)
+	assert.NoError(t, err)
+	assert.Equal(t, defaultDNSServers, sessionConfig.SessionServiceConfig.(wg.ServiceConfig).Consumer.DNS)
+}
+
+func Test_Manager_ProvideConfig_FailsWhenDNSCanNotBeServed(t *testing.T) {
+	manager := newManagerStub(pubIP, outIP, country)
+	endpoint := &mockSharedEndpoint{peers: make(map[string][]string)}
+	endpoint.config.Consumer.DNS = []net.IP{net.ParseIP("10.182.1.1")}
+	manager.connectionEndpointFactory = func() (wg.ConnectionEndpoint, error) {
+		return endpoint, nil
+	}
+	manager.HostDNS(&dnsListenerFake{listenErr: errors.New("address in use")})
+
+	_, err := manager.ProvideConfig(json.RawMessage(`{"PublicKey": "key1"}`), nil)
+	assert.Error(t, err)
+	assert.True(t, endpoint.stopped)
 }
 
 func Test_Manager_Stop(t *testing.T) {
@@ -89,6 +175,212 @@ func Test_Manager_Stop(t *testing.T) {
 	assert.NoError(t, err)
 }
 
+func Test_Manager_ProvideConfig_SharedInterface(t *testing.T) {
+	sharedEndpoint := &mockSharedEndpoint{peers: make(map[string][]string)}
+	manager := newManagerStub(pubIP, outIP, country)
+	manager.options = Options{SharedInterface: true, Subnet: DefaultOptions.Subnet}
+	manager.resourceAllocator = resources.NewAllocator(nil, DefaultOptions.Subnet, DefaultOptions.Subnet6)
+	manager.sharedEndpointFactory = func() (wg.ConnectionEndpoint, error) {
+		// the shared interface takes the first IP network of the subnet
+		_, err := manager.resourceAllocator.AllocateIPNet()
+		return sharedEndpoint, err
+	}
+
+	go func() {
+		err := manager.Serve(providerID)
+		assert.NoError(t, err)
+	}()
+	waitABit()
+
+	sessionConfig1, err := manager.ProvideConfig(json.RawMessage(`{"PublicKey": "key1"}`), nil)
+	assert.NoError(t, err)
+	sessionConfig2, err := manager.ProvideConfig(json.RawMessage(`{"PublicKey": "key2"}`), nil)
+	assert.NoError(t, err)
+
+	assert.Equal(t, []string{"10.182.0.2/32"}, sharedEndpoint.peers["key1"])
+	assert.Equal(t, []string{"10.182.0.3/32"}, sharedEndpoint.peers["key2"])
+	consumerIP := sessionConfig2.SessionServiceConfig.(wg.ServiceConfig).Consumer.IPAddress
+	assert.Equal(t, "10.182.0.3/32", consumerIP.String())
+
+	dataTransfer, err := sessionConfig1.DataTransferProvider()
+	assert.NoError(t, err)
+	assert.Equal(t, session.DataTransfer{BytesSent: 30, BytesReceived: 40}, dataTransfer)
+
+	sessionConfig1.SessionDestroyCallback()
+	assert.NotContains(t, sharedEndpoint.peers, "key1")
+	assert.Contains(t, sharedEndpoint.peers, "key2")
+
+	sessionConfig3, err := manager.ProvideConfig(json.RawMessage(`{"PublicKey": "key3"}`), nil)
+	assert.NoError(t, err)
+	consumerIP = sessionConfig3.SessionServiceConfig.(wg.ServiceConfig).Consumer.IPAddress
+	assert.Equal(t, "10.182.0.2/32", consumerIP.String())
+
+	assert.NoError(t, manager.Stop())
+	assert.True(t, sharedEndpoint.stopped)
+}
+
+func Test_Manager_ProvideConfig_SharedInterfaceWithIPv6(t *testing.T) {
+	sharedEndpoint := &mockSharedEndpoint{peers: make(map[string][]string)}
+	sharedEndpoint.config.Consumer.IPv6Address = net.IPNet{IP: net.ParseIP("fd10:182::2"), Mask: net.CIDRMask(48, 128)}
+	manager := newManagerStub(pubIP, outIP, country)
+	manager.options = Options{SharedInterface: true, Subnet: DefaultOptions.Subnet, Subnet6: DefaultOptions.Subnet6}
+	manager.resourceAllocator = resources.NewAllocator(nil, DefaultOptions.Subnet, DefaultOptions.Subnet6)
+	manager.sharedEndpointFactory = func() (wg.ConnectionEndpoint, error) {
+		_, err := manager.resourceAllocator.AllocateIPNet()
+		return sharedEndpoint, err
+	}
+
+	go func() {
+		err := manager.Serve(providerID)
+		assert.NoError(t, err)
+	}()
+	waitABit()
+
+	sessionConfig, err := manager.ProvideConfig(json.RawMessage(`{"PublicKey": "key1"}`), nil)
+	assert.NoError(t, err)
+
+	assert.Equal(t, []string{"10.182.0.2/32", "fd10:182::2/128"}, sharedEndpoint.peers["key1"])
+	consumerIP := sessionConfig.SessionServiceConfig.(wg.ServiceConfig).Consumer.IPv6Address
+	assert.Equal(t, "fd10:182::2/128", consumerIP.String())
+
+	assert.NoError(t, manager.Stop())
+}
+
+func Test_Manager_SetBandwidthLimit_SharedInterface(t *testing.T) {
+	sharedEndpoint := &mockSharedEndpoint{peers: make(map[string][]string)}
+	sharedShaper := &mockShaper{limits: make(map[string]datasize.BitSize)}
+	manager := newManagerStub(pubIP, outIP, country)
+	manager.options = Options{SharedInterface: true, Subnet: DefaultOptions.Subnet}
+	manager.bandwidthLimit = datasize.MB
+	manager.resourceAllocator = resources.NewAllocator(nil, DefaultOptions.Subnet, DefaultOptions.Subnet6)
+	manager.sharedEndpointFactory = func() (wg.ConnectionEndpoint, error) {
+		_, err := manager.resourceAllocator.AllocateIPNet()
+		return sharedEndpoint, err
+	}
+	manager.shaperFactory = func(iface string) shaper.Shaper {
+		assert.Equal(t, "myst0", iface)
+		return sharedShaper
+	}
+
+	go func() {
+		err := manager.Serve(providerID)
+		assert.NoError(t, err)
+	}()
+	waitABit()
+
+	sessionConfig1, err := manager.ProvideConfig(json.RawMessage(`{"PublicKey": "key1"}`), nil)
+	assert.NoError(t, err)
+	_, err = manager.ProvideConfig(json.RawMessage(`{"PublicKey": "key2"}`), nil)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]datasize.BitSize{"10.182.0.2": datasize.MB, "10.182.0.3": datasize.MB}, sharedShaper.limits)
+
+	assert.NoError(t, manager.SetBandwidthLimit(2*datasize.MB))
+	assert.Equal(t, map[string]datasize.BitSize{"10.182.0.2": 2 * datasize.MB, "10.182.0.3": 2 * datasize.MB}, sharedShaper.limits)
+
+	sessionConfig1.SessionDestroyCallback()
+	assert.Equal(t, map[string]datasize.BitSize{"10.182.0.3": 2 * datasize.MB}, sharedShaper.limits)
+
+	assert.NoError(t, manager.SetBandwidthLimit(0))
+	assert.Empty(t, sharedShaper.limits)
+
+	_, err = manager.ProvideConfig(json.RawMessage(`{"PublicKey": "key3"}`), nil)
+	assert.NoError(t, err)
+	assert.Empty(t, sharedShaper.limits)
+
+	assert.NoError(t, manager.Stop())
+}
+
+func Test_Manager_ProvideConfig_FailsIfSessionCanNotBeLimited(t *testing.T) {
+	manager := newManagerStub(pubIP, outIP, country)
+	manager.bandwidthLimit = datasize.MB
+	manager.shaperFactory = func(iface string) shaper.Shaper {
+		return &shaperNotSupported{}
+	}
+
+	_, err := manager.ProvideConfig(json.RawMessage(`{"PublicKey": "key1"}`), nil)
+	assert.Equal(t, shaper.ErrNotSupported, err)
+	assert.Empty(t, manager.limitedSessions)
+}
+
+func Test_Manager_ProvideConfig_RollsBackOnFailure(t *testing.T) {
+	listener := &dnsListenerFake{}
+	connectionEndpoint := &mockSharedEndpoint{peers: make(map[string][]string)}
+	connectionEndpoint.config.Consumer.DNS = []net.IP{net.ParseIP("10.182.1.1")}
+	connectionEndpoint.config.Provider.Endpoint.Port = 52820
+	natService := &serviceFake{}
+	var inboundRules []int
+	manager := newManagerStub(pubIP, outIP, country)
+	manager.natService = natService
+	manager.connectionEndpointFactory = func() (wg.ConnectionEndpoint, error) {
+		return connectionEndpoint, nil
+	}
+	manager.addInboundRule = func(_ string, port int) error {
+		inboundRules = append(inboundRules, port)
+		return nil
+	}
+	manager.removeInboundRule = func(_ string, port int) error {
+		inboundRules = inboundRules[:0]
+		return nil
+	}
+	manager.bandwidthLimit = datasize.MB
+	manager.shaperFactory = func(iface string) shaper.Shaper {
+		return &shaperNotSupported{}
+	}
+	manager.HostDNS(listener)
+
+	_, err := manager.ProvideConfig(json.RawMessage(`{"PublicKey": "key1"}`), nil)
+	assert.Equal(t, shaper.ErrNotSupported, err)
+	assert.True(t, connectionEndpoint.stopped)
+	assert.Empty(t, inboundRules)
+	assert.Empty(t, natService.rules)
+	assert.Empty(t, listener.ips)
+}
+
+func Test_Manager_Stop_AfterServeFailed(t *testing.T) {
+	manager := newManagerStub(pubIP, outIP, country)
+	manager.options = Options{SharedInterface: true, Subnet: DefaultOptions.Subnet}
+	manager.sharedEndpointFactory = func() (wg.ConnectionEndpoint, error) {
+		return nil, errors.New("no more unused interfaces")
+	}
+
+	assert.EqualError(t, manager.Serve(providerID), "no more unused interfaces")
+
+	stopped := make(chan error)
+	go func() {
+		stopped <- manager.Stop()
+	}()
+	select {
+	case err := <-stopped:
+		assert.NoError(t, err)
+	case <-time.After(time.Second):
+		t.Fatal("service did not stop")
+	}
+}
+
+func Test_Manager_Stop_SharedInterfaceUnlistensDNS(t *testing.T) {
+	listener := &dnsListenerFake{}
+	sharedEndpoint := &mockSharedEndpoint{peers: make(map[string][]string)}
+	sharedEndpoint.config.Consumer.DNS = []net.IP{net.ParseIP("10.182.0.1")}
+	natService := &serviceFake{}
+	manager := newManagerStub(pubIP, outIP, country)
+	manager.natService = natService
+	manager.options = Options{SharedInterface: true, Subnet: DefaultOptions.Subnet}
+	manager.resourceAllocator = resources.NewAllocator(nil, DefaultOptions.Subnet, DefaultOptions.Subnet6)
+	manager.sharedEndpointFactory = func() (wg.ConnectionEndpoint, error) {
+		return sharedEndpoint, nil
+	}
+	manager.HostDNS(listener)
+
+	assert.NoError(t, manager.startSharedEndpoint())
+	assert.Equal(t, []string{"10.182.0.1"}, listener.ips)
+	assert.Len(t, natService.rules, 1)
+
+	assert.NoError(t, manager.Stop())
+	assert.Empty(t, listener.ips)
+	assert.Empty(t, natService.rules)
+	assert.True(t, sharedEndpoint.stopped)
+}
+
 // usually time.Sleep call gives a chance for other goroutines to kick in important when testing async code
 func waitABit() {
 	time.Sleep(10 * time.Millisecond)
@@ -101,9 +393,74 @@ func (mce *mockConnectionEndpoint) Start(_ *wg.ServiceConfig) error
 func (mce *mockConnectionEndpoint) Config() (wg.ServiceConfig, error)                   { return wg.ServiceConfig{}, nil }
 func (mce *mockConnectionEndpoint) AddPeer(_ string, _ *net.UDPAddr, _ ...string) error { return nil }
 func (mce *mockConnectionEndpoint) RemovePeer(_ string) error                           { return nil }
-func (mce *mockConnectionEndpoint) ConfigureRoutes(_ net.IP) error                      { return nil }
+func (mce *mockConnectionEndpoint) ConfigureRoutes(_ net.IP, _ split.Routes) error      { return nil }
+func (mce *mockConnectionEndpoint) InterfaceName() string                               { return "myst0" }
 func (mce *mockConnectionEndpoint) PeerStats() (wg.Stats, error) {
-	return wg.Stats{LastHandshake: time.Now()}, nil
+	return wg.Stats{BytesSent: 10, BytesReceived: 20, LastHandshake: time.Now()}, nil
+}
+func (mce *mockConnectionEndpoint) PeerStatsByKey(_ string) (wg.Stats, error) {
+	return wg.Stats{BytesSent: 30, BytesReceived: 40, LastHandshake: time.Now()}, nil
+}
+
+type mockSharedEndpoint struct {
+	mockConnectionEndpoint
+	config  wg.ServiceConfig
+	peers   map[string][]string
+	stopped bool
+}
+
+func (mse *mockSharedEndpoint) Config() (wg.ServiceConfig, error) {
+	return mse.config, nil
+}
+
+func (mse *mockSharedEndpoint) AddPeer(publicKey string, _ *net.UDPAddr, allowedIPs ...string) error {
+	mse.peers[publicKey] = allowedIPs
+	return nil
+}
+func (mse *mockSharedEndpoint) RemovePeer(publicKey string) error {
+	delete(mse.peers, publicKey)
+	return nil
+}
+func (mse *mockSharedEndpoint) Stop() error {
+	mse.stopped = true
+	return nil
+}
+
+type dnsListenerFake struct {
+	ips       []string
+	listenErr error
+}
+
+func (listener *dnsListenerFake) Listen(ip net.IP) error {
+	if listener.listenErr != nil {
+		return listener.listenErr
+	}
+	listener.ips = append(listener.ips, ip.String())
+	return nil
+}
+
+func (listener *dnsListenerFake) Unlisten(ip net.IP) error {
+	for i, listened := range listener.ips {
+		if listened == ip.String() {
+			listener.ips = append(listener.ips[:i], listener.ips[i+1:]...)
+			break
+		}
+	}
+	return nil
+}
+
+type mockShaper struct {
+	limits map[string]datasize.BitSize
+}
+
+func (ms *mockShaper) Limit(limit datasize.BitSize, ips ...net.IP) error {
+	ms.limits[ips[0].String()] = limit
+	return nil
+}
+
+func (ms *mockShaper) Unlimit(ips ...net.IP) error {
+	delete(ms.limits, ips[0].String())
+	return nil
 }
 
 func newManagerStub(pub, out, country string) *Manager {
@@ -113,12 +470,41 @@ func newManagerStub(pub, out, country string) *Manager {
 		connectionEndpointFactory: func() (wg.ConnectionEndpoint, error) {
 			return connectionEndpointStub, nil
 		},
+		shaperFactory: func(iface string) shaper.Shaper {
+			return &mockShaper{limits: make(map[string]datasize.BitSize)}
+		},
+		limitedSessions:   make(map[string]limitedSession),
+		stop:              make(chan struct{}),
+		addInboundRule:    func(_ string, _ int) error { return nil },
+		removeInboundRule: func(_ string, _ int) error { return nil },
 	}
 }
 
-type serviceFake struct{}
+type shaperNotSupported struct{}
+
+func (sns *shaperNotSupported) Limit(_ datasize.BitSize, _ ...net.IP) error {
+	return shaper.ErrNotSupported
+}
+func (sns *shaperNotSupported) Unlimit(_ ...net.IP) error { return nil }
+
+type serviceFake struct {
+	rules []nat.RuleForwarding
+}
+
+func (service *serviceFake) Add(rule nat.RuleForwarding) error {
+	service.rules = append(service.rules, rule)
+	return nil
+}
+
+func (service *serviceFake) Del(rule nat.RuleForwarding) error {
+	for i, added := range service.rules {
+		if added.SourceAddress == rule.SourceAddress {
+			service.rules = append(service.rules[:i], service.rules[i+1:]...)
+			break
+		}
+	}
+	return nil
+}
 
-func (service *serviceFake) Add(rule nat.RuleForwarding) error { return nil }
-func (service *serviceFake) Del(rule nat.RuleForwarding) error { return nil }
-func (service *serviceFake) Enable() error                     { return nil }
-func (service *serviceFake) Disable() error                    { return nil }
+func (service *serviceFake) Enable() error  { return nil }
+func (service *serviceFake) Disable() error { return nil }
@@ -21,11 +21,15 @@ package service
 
 import (
 	"encoding/json"
+	"net"
 	"sync"
 
 	log "github.com/cihub/seelog"
 	"github.com/mysteriumnetwork/node/core/ip"
 	"github.com/mysteriumnetwork/node/core/port"
+	"github.com/mysteriumnetwork/node/core/service"
+	"github.com/mysteriumnetwork/node/datasize"
+	"github.com/mysteriumnetwork/node/firewall"
 	"github.com/mysteriumnetwork/node/identity"
 	"github.com/mysteriumnetwork/node/nat"
 	"github.com/mysteriumnetwork/node/nat/traversal"
@@ -33,6 +37,9 @@ import (
 	"github.com/mysteriumnetwork/node/services/wireguard/endpoint"
 	"github.com/mysteriumnetwork/node/services/wireguard/resources"
 	"github.com/mysteriumnetwork/node/session"
+	"github.com/mysteriumnetwork/node/shaper"
+	"github.com/mysteriumnetwork/node/utils"
+	"github.com/pkg/errors"
 )
 
 // NewManager creates new instance of Wireguard service
@@ -43,43 +50,95 @@ func NewManager(
 	options Options,
 	portSupplier port.ServicePortSupplier,
 ) *Manager {
-	resourceAllocator := resources.NewAllocator(portSupplier, options.Subnet)
+	resourceAllocator := resources.NewAllocator(portSupplier, options.Subnet, options.Subnet6)
 	return &Manager{
-		natService: natService,
-		ipResolver: ipResolver,
+		natService:        natService,
+		ipResolver:        ipResolver,
+		resourceAllocator: resourceAllocator,
+		options:           options,
+		shaperFactory:     shaper.NewShaper,
+		bandwidthLimit:    options.BandwidthLimit,
+		limitedSessions:   make(map[string]limitedSession),
+		stop:              make(chan struct{}),
+		addInboundRule:    firewall.AddInboundRule,
+		removeInboundRule: firewall.RemoveInboundRule,
 
 		connectionEndpointFactory: func() (wg.ConnectionEndpoint, error) {
 			return endpoint.NewConnectionEndpoint(ipResolver, resourceAllocator, portMap, options.ConnectDelay)
 		},
+		sharedEndpointFactory: func() (wg.ConnectionEndpoint, error) {
+			return endpoint.NewSharedConnectionEndpoint(ipResolver, resourceAllocator, portMap, options.ConnectDelay, options.Subnet, options.Subnet6)
+		},
 	}
 }
 
 // Manager represents an instance of Wireguard service
 type Manager struct {
-	wg         sync.WaitGroup
+	stop       chan struct{}
+	stopOnce   sync.Once
 	natService nat.NATService
 
 	connectionEndpointFactory func() (wg.ConnectionEndpoint, error)
+	sharedEndpointFactory     func() (wg.ConnectionEndpoint, error)
+
+	// sharedEndpoint hosts all consumers as peers, it is set only when service runs with the shared interface
+	sharedEndpoint    wg.ConnectionEndpoint
+	sharedShaper      shaper.Shaper
+	sharedTeardown    rollback
+	sharedIPv6        bool
+	sharedDNSServing  bool
+	sharedLock        sync.Mutex
+	resourceAllocator *resources.Allocator
 
 	ipResolver ip.Resolver
+	options    Options
+
+	shaperFactory func(iface string) shaper.Shaper
+	// bandwidthLimit can be changed at runtime, so it is applied to limitedSessions keyed by the consumer public key
+	bandwidthLimit  datasize.BitSize
+	limitedSessions map[string]limitedSession
+	limitLock       sync.Mutex
+
+	addInboundRule    func(proto string, port int) error
+	removeInboundRule func(proto string, port int) error
+
+	// dnsListener is set if the service serves DNS to consumers on the provider tunnel addresses
+	dnsListener service.DNSListener
+}
+
+type limitedSession struct {
+	shaper shaper.Shaper
+	ips    []net.IP
 }
 
 // ProvideConfig provides the config for consumer
-func (manager *Manager) ProvideConfig(sessionConfig json.RawMessage, traversalParams *traversal.Params) (*session.ConfigParams, error) {
+func (manager *Manager) ProvideConfig(sessionConfig json.RawMessage, traversalParams *traversal.Params) (_ *session.ConfigParams, err error) {
 	key := &wg.ConsumerConfig{}
-	err := json.Unmarshal(sessionConfig, key)
+	err = json.Unmarshal(sessionConfig, key)
 	if err != nil {
 		return nil, err
 	}
 
+	if sharedEndpoint, sharedShaper, ipv6, dnsServing := manager.getSharedEndpoint(); sharedEndpoint != nil {
+		return manager.provideSharedConfig(sharedEndpoint, sharedShaper, ipv6, dnsServing, key.PublicKey, traversalParams)
+	}
+
 	connectionEndpoint, err := manager.connectionEndpointFactory()
 	if err != nil {
 		return nil, err
 	}
 
+	var teardown rollback
+	defer func() {
+		if err != nil {
+			teardown.run()
+		}
+	}()
+
 	if err := connectionEndpoint.Start(nil); err != nil {
 		return nil, err
 	}
+	teardown.add(func() { manager.stopEndpoint(connectionEndpoint) })
 
 	if err := connectionEndpoint.AddPeer(key.PublicKey, nil); err != nil {
 		return nil, err
@@ -89,6 +148,13 @@ func (manager *Manager) ProvideConfig(sessionConfig json.RawMessage, traversalPa
 	if err != nil {
 		return nil, err
 	}
+	tunnelIP := providerTunnelIP(config)
+
+	listenPort := config.Provider.Endpoint.Port
+	if err := manager.addInboundRule("udp", listenPort); err != nil {
+		return nil, errors.Wrap(err, "failed to add firewall rule")
+	}
+	teardown.add(func() { manager.removeListenPortRule(listenPort) })
 
 	outIP, err := manager.ipResolver.GetOutboundIP()
 	if err != nil {
@@ -99,32 +165,358 @@ func (manager *Manager) ProvideConfig(sessionConfig json.RawMessage, traversalPa
 	if err := manager.natService.Add(natRule); err != nil {
 		return nil, err
 	}
+	teardown.add(func() { manager.deleteNATRules(natRule) })
 
-	destroy := func() {
-		if err := manager.natService.Del(natRule); err != nil {
-			log.Error(logPrefix, "failed to delete NAT forwarding rule: ", err)
+	if config.Consumer.IPv6Address.IP != nil {
+		if natRule6, ok := manager.addIPv6NATRule(config.Consumer.IPv6Address); ok {
+			teardown.add(func() { manager.deleteNATRules(natRule6) })
+		} else {
+			config.Consumer.IPv6Address = net.IPNet{}
 		}
-		if err := connectionEndpoint.Stop(); err != nil {
-			log.Error(logPrefix, "failed to stop connection endpoint: ", err)
+	}
+
+	sessionShaper := manager.shaperFactory(connectionEndpoint.InterfaceName())
+	if err := manager.limitSession(key.PublicKey, sessionShaper, config); err != nil {
+		return nil, err
+	}
+	// the limit vanishes together with the interface of the session
+	teardown.add(func() { manager.unlimitSession(key.PublicKey, false) })
+
+	dnsServing, err := manager.listenDNS(tunnelIP)
+	if err != nil {
+		return nil, err
+	}
+	if dnsServing {
+		teardown.add(func() { manager.unlistenDNS(tunnelIP) })
+	}
+	pushDNS(&config, manager.options, dnsServing)
+
+	dataTransfer := func() (session.DataTransfer, error) {
+		stats, err := connectionEndpoint.PeerStats()
+		if err != nil {
+			return session.DataTransfer{}, err
 		}
+		return session.DataTransfer{BytesSent: stats.BytesSent, BytesReceived: stats.BytesReceived}, nil
 	}
 
-	return &session.ConfigParams{SessionServiceConfig: config, SessionDestroyCallback: destroy, TraversalParams: traversalParams}, nil
+	return &session.ConfigParams{
+		SessionServiceConfig:   config,
+		SessionDestroyCallback: teardown.run,
+		TraversalParams:        traversalParams,
+		DataTransferProvider:   dataTransfer,
+	}, nil
+}
+
+// provideSharedConfig adds the consumer as a peer of the shared interface with its own host address allocated
+func (manager *Manager) provideSharedConfig(
+	sharedEndpoint wg.ConnectionEndpoint,
+	sharedShaper shaper.Shaper,
+	ipv6 bool,
+	dnsServing bool,
+	publicKey string,
+	traversalParams *traversal.Params,
+) (_ *session.ConfigParams, err error) {
+	config, err := sharedEndpoint.Config()
+	if err != nil {
+		return nil, err
+	}
+	pushDNS(&config, manager.options, dnsServing)
+
+	var teardown rollback
+	defer func() {
+		if err != nil {
+			teardown.run()
+		}
+	}()
+
+	hostAddr, err := manager.resourceAllocator.AllocateHostIPNet()
+	if err != nil {
+		return nil, err
+	}
+	teardown.add(func() {
+		if err := manager.resourceAllocator.ReleaseHostIPNet(hostAddr); err != nil {
+			log.Error(logPrefix, "failed to release host address: ", err)
+		}
+	})
+
+	config.Consumer.IPAddress = hostAddr
+	config.Consumer.IPv6Address = net.IPNet{}
+	if ipv6Addr, ok := manager.resourceAllocator.IPv6HostFor(hostAddr); ok && ipv6 {
+		config.Consumer.IPv6Address = ipv6Addr
+	}
+
+	allowedIPs := []string{config.Consumer.IPAddress.String()}
+	if config.Consumer.IPv6Address.IP != nil {
+		allowedIPs = append(allowedIPs, config.Consumer.IPv6Address.String())
+	}
+
+	if err := sharedEndpoint.AddPeer(publicKey, nil, allowedIPs...); err != nil {
+		return nil, err
+	}
+	teardown.add(func() {
+		if err := sharedEndpoint.RemovePeer(publicKey); err != nil {
+			log.Error(logPrefix, "failed to remove peer: ", publicKey, err)
+		}
+	})
+
+	if err := manager.limitSession(publicKey, sharedShaper, config); err != nil {
+		return nil, err
+	}
+	teardown.add(func() { manager.unlimitSession(publicKey, true) })
+
+	dataTransfer := func() (session.DataTransfer, error) {
+		stats, err := sharedEndpoint.PeerStatsByKey(publicKey)
+		if err != nil {
+			return session.DataTransfer{}, err
+		}
+		return session.DataTransfer{BytesSent: stats.BytesSent, BytesReceived: stats.BytesReceived}, nil
+	}
+
+	return &session.ConfigParams{
+		SessionServiceConfig:   config,
+		SessionDestroyCallback: teardown.run,
+		TraversalParams:        traversalParams,
+		DataTransferProvider:   dataTransfer,
+	}, nil
 }
 
 // Serve starts service - does block
 func (manager *Manager) Serve(providerID identity.Identity) error {
-	manager.wg.Add(1)
+	if manager.options.SharedInterface {
+		if err := manager.startSharedEndpoint(); err != nil {
+			return err
+		}
+	}
 	log.Info(logPrefix, "Wireguard service started successfully")
 
-	manager.wg.Wait()
+	<-manager.stop
+	return nil
+}
+
+func (manager *Manager) startSharedEndpoint() (err error) {
+	sharedEndpoint, err := manager.sharedEndpointFactory()
+	if err != nil {
+		return err
+	}
+
+	var teardown rollback
+	defer func() {
+		if err != nil {
+			teardown.run()
+		}
+	}()
+
+	if err := sharedEndpoint.Start(nil); err != nil {
+		return err
+	}
+	teardown.add(func() { manager.stopEndpoint(sharedEndpoint) })
+
+	outIP, err := manager.ipResolver.GetOutboundIP()
+	if err != nil {
+		return err
+	}
+
+	config, err := sharedEndpoint.Config()
+	if err != nil {
+		return err
+	}
+
+	listenPort := config.Provider.Endpoint.Port
+	if err := manager.addInboundRule("udp", listenPort); err != nil {
+		return errors.Wrap(err, "failed to add firewall rule")
+	}
+	teardown.add(func() { manager.removeListenPortRule(listenPort) })
+
+	natRule := nat.RuleForwarding{SourceAddress: manager.options.Subnet.String(), TargetIP: outIP}
+	if err := manager.natService.Add(natRule); err != nil {
+		return err
+	}
+	teardown.add(func() { manager.deleteNATRules(natRule) })
+
+	ipv6 := false
+	if config.Consumer.IPv6Address.IP != nil {
+		var natRule6 nat.RuleForwarding
+		if natRule6, ipv6 = manager.addIPv6NATRule(manager.options.Subnet6); ipv6 {
+			teardown.add(func() { manager.deleteNATRules(natRule6) })
+		}
+	}
+
+	tunnelIP := providerTunnelIP(config)
+	dnsServing, err := manager.listenDNS(tunnelIP)
+	if err != nil {
+		return err
+	}
+	if dnsServing {
+		teardown.add(func() { manager.unlistenDNS(tunnelIP) })
+	}
+
+	manager.sharedLock.Lock()
+	defer manager.sharedLock.Unlock()
+
+	manager.sharedEndpoint = sharedEndpoint
+	manager.sharedShaper = manager.shaperFactory(sharedEndpoint.InterfaceName())
+	manager.sharedTeardown = teardown
+	manager.sharedIPv6 = ipv6
+	manager.sharedDNSServing = dnsServing
 	return nil
 }
 
+// HostDNS makes the service to serve DNS to consumers on the provider tunnel addresses
+func (manager *Manager) HostDNS(listener service.DNSListener) {
+	manager.dnsListener = listener
+}
+
+// listenDNS serves DNS on the provider tunnel address and reports whether it is served,
+// consumers would be left without name resolution if the enabled forwarder failed silently
+func (manager *Manager) listenDNS(ip net.IP) (bool, error) {
+	if manager.dnsListener == nil || ip == nil {
+		return false, nil
+	}
+	if err := manager.dnsListener.Listen(ip); err != nil {
+		return false, errors.Wrap(err, "failed to serve DNS on "+ip.String())
+	}
+	return true, nil
+}
+
+func (manager *Manager) unlistenDNS(ip net.IP) {
+	if manager.dnsListener == nil || ip == nil {
+		return
+	}
+	if err := manager.dnsListener.Unlisten(ip); err != nil {
+		log.Error(logPrefix, "failed to stop serving DNS on ", ip, ": ", err)
+	}
+}
+
+// providerTunnelIP returns the provider address of the tunnel, endpoint config pushes it to consumer as DNS server by default
+func providerTunnelIP(config wg.ServiceConfig) net.IP {
+	if len(config.Consumer.DNS) == 0 {
+		return nil
+	}
+	return config.Consumer.DNS[0]
+}
+
+// addIPv6NATRule masquerades IPv6 traffic of the given network, IPv6 is optional so failures are only logged
+func (manager *Manager) addIPv6NATRule(ipv6Addr net.IPNet) (nat.RuleForwarding, bool) {
+	natRule := nat.RuleForwarding{SourceAddress: ipv6Addr.String()}
+	if err := manager.natService.Add(natRule); err != nil {
+		log.Warn(logPrefix, "failed to add IPv6 NAT forwarding rule, IPv6 is disabled: ", err)
+		return natRule, false
+	}
+	return natRule, true
+}
+
+func (manager *Manager) deleteNATRules(natRules ...nat.RuleForwarding) {
+	for _, natRule := range natRules {
+		if err := manager.natService.Del(natRule); err != nil {
+			log.Error(logPrefix, "failed to delete NAT forwarding rule: ", err)
+		}
+	}
+}
+
+func (manager *Manager) stopEndpoint(connectionEndpoint wg.ConnectionEndpoint) {
+	if err := connectionEndpoint.Stop(); err != nil {
+		log.Error(logPrefix, "failed to stop connection endpoint: ", err)
+	}
+}
+
+func (manager *Manager) removeListenPortRule(port int) {
+	if err := manager.removeInboundRule("udp", port); err != nil {
+		log.Error(logPrefix, "failed to delete firewall rule for Wireguard: ", err)
+	}
+}
+
+func (manager *Manager) getSharedEndpoint() (sharedEndpoint wg.ConnectionEndpoint, sharedShaper shaper.Shaper, ipv6, dnsServing bool) {
+	manager.sharedLock.Lock()
+	defer manager.sharedLock.Unlock()
+
+	return manager.sharedEndpoint, manager.sharedShaper, manager.sharedIPv6, manager.sharedDNSServing
+}
+
+// limitSession applies the current bandwidth limit to the consumer and keeps it limited on the limit changes
+func (manager *Manager) limitSession(publicKey string, sessionShaper shaper.Shaper, config wg.ServiceConfig) error {
+	ips := []net.IP{config.Consumer.IPAddress.IP}
+	if config.Consumer.IPv6Address.IP != nil {
+		ips = append(ips, config.Consumer.IPv6Address.IP)
+	}
+
+	manager.limitLock.Lock()
+	defer manager.limitLock.Unlock()
+
+	if manager.bandwidthLimit > 0 {
+		if err := sessionShaper.Limit(manager.bandwidthLimit, ips...); err != nil {
+			return err
+		}
+	}
+	manager.limitedSessions[publicKey] = limitedSession{shaper: sessionShaper, ips: ips}
+	return nil
+}
+
+// unlimitSession stops tracking the consumer, its limit is removed only if the interface outlives the session
+func (manager *Manager) unlimitSession(publicKey string, removeLimit bool) {
+	manager.limitLock.Lock()
+	defer manager.limitLock.Unlock()
+
+	limited, ok := manager.limitedSessions[publicKey]
+	if !ok {
+		return
+	}
+	delete(manager.limitedSessions, publicKey)
+
+	if removeLimit && manager.bandwidthLimit > 0 {
+		if err := limited.shaper.Unlimit(limited.ips...); err != nil {
+			log.Error(logPrefix, "failed to remove bandwidth limit: ", err)
+		}
+	}
+}
+
+// SetBandwidthLimit changes the bandwidth limit of the running and future sessions, zero limit removes it
+func (manager *Manager) SetBandwidthLimit(limit datasize.BitSize) error {
+	manager.limitLock.Lock()
+	defer manager.limitLock.Unlock()
+
+	errs := utils.ErrorCollection{}
+	for _, limited := range manager.limitedSessions {
+		if limit > 0 {
+			errs.Add(limited.shaper.Limit(limit, limited.ips...))
+		} else if manager.bandwidthLimit > 0 {
+			errs.Add(limited.shaper.Unlimit(limited.ips...))
+		}
+	}
+	manager.bandwidthLimit = limit
+
+	log.Info(logPrefix, "Session bandwidth limit changed to ", toMbps(limit), " Mbit/s")
+	return errs.Errorf("failed to change bandwidth limit of some sessions: %s", ", ")
+}
+
 // Stop stops service.
 func (manager *Manager) Stop() error {
-	manager.wg.Done()
+	manager.stopOnce.Do(func() {
+		close(manager.stop)
+	})
+
+	manager.sharedLock.Lock()
+	defer manager.sharedLock.Unlock()
+
+	if manager.sharedEndpoint != nil {
+		manager.sharedTeardown.run()
+		manager.sharedEndpoint = nil
+		manager.sharedShaper = nil
+		manager.sharedTeardown = nil
+	}
 
 	log.Info(logPrefix, "Wireguard service stopped")
 	return nil
 }
+
+// rollback undoes the setup steps taken so far in the reverse order
+type rollback []func()
+
+func (r *rollback) add(undo func()) {
+	*r = append(*r, undo)
+}
+
+func (r rollback) run() {
+	for i := len(r) - 1; i >= 0; i-- {
+		r[i]()
+	}
+}
@@ -80,6 +80,7 @@ func (manager *Manager) ProvideConfig(publicKey json.RawMessage, traversalParams
 	if err != nil {
 		return nil, err
 	}
+	pushDNS(&config, manager.options, false)
 
 	if err := manager.connectionEndpoint.AddPeer(key.PublicKey, nil, config.Consumer.IPAddress.IP.String()+"/32"); err != nil {
 		return nil, err
@@ -101,6 +102,10 @@ func (manager *Manager) ProvideConfig(publicKey json.RawMessage, traversalParams
 func (manager *Manager) Serve(providerID identity.Identity) error {
 	manager.wg.Add(1)
 
+	if manager.options.BandwidthLimit > 0 {
+		log.Warn(logPrefix, "Bandwidth limits are not supported on Windows, sessions are not limited")
+	}
+
 	connectionEndpoint, err := endpoint.NewConnectionEndpoint(manager.ipResolver, manager.resourceAllocator, manager.portMap, manager.options.ConnectDelay)
 	if err != nil {
 		return err
@@ -19,9 +19,12 @@ package wireguard
 
 import (
 	"encoding/json"
+	"fmt"
 	"net"
 	"time"
 
+	"github.com/mysteriumnetwork/node/core/connection/split"
+	"github.com/mysteriumnetwork/node/datasize"
 	"github.com/mysteriumnetwork/node/market"
 	"github.com/mysteriumnetwork/node/money"
 )
@@ -37,6 +40,9 @@ type ServiceDefinition struct {
 	// Approximate information on location where the actual tunnelled traffic will originate from.
 	// This is used by providers having their own means of setting tunnels to other remote exit points.
 	LocationOriginate market.Location `json:"location_originate"`
+
+	// Bandwidth limit of the session per second in each direction, zero means unlimited
+	SessionBandwidth datasize.BitSize `json:"session_bandwidth,omitempty"`
 }
 
 // GetLocation returns geographic location of service definition provider
@@ -44,10 +50,16 @@ func (service ServiceDefinition) GetLocation() market.Location {
 	return service.Location
 }
 
+// WithSessionBandwidth returns the service definition announcing the given session bandwidth limit
+func (service ServiceDefinition) WithSessionBandwidth(limit datasize.BitSize) market.ServiceDefinition {
+	service.SessionBandwidth = limit
+	return service
+}
+
 // PaymentMethod indicates payment method for Wireguard service
 const PaymentMethod = "WG"
 
-// Payment structure describes price for Wireguard service payment
+// Payment structure describes price of a minute of Wireguard service
 type Payment struct {
 	Price money.Money `json:"price"`
 }
@@ -64,8 +76,10 @@ type ConnectionEndpoint interface {
 	AddPeer(publicKey string, endpoint *net.UDPAddr, allowedIPs ...string) error
 	RemovePeer(publicKey string) error
 	PeerStats() (Stats, error)
-	ConfigureRoutes(ip net.IP) error
+	PeerStatsByKey(publicKey string) (Stats, error)
+	ConfigureRoutes(ip net.IP, routes split.Routes) error
 	Config() (ServiceConfig, error)
+	InterfaceName() string
 	Stop() error
 }
 
@@ -105,9 +119,13 @@ type ServiceConfig struct {
 		Endpoint  net.UDPAddr
 	}
 	Consumer struct {
-		PrivateKey   string `json:"-"`
-		IPAddress    net.IPNet
+		PrivateKey string `json:"-"`
+		IPAddress  net.IPNet
+		// IPv6Address is empty if provider does not support IPv6
+		IPv6Address  net.IPNet
 		ConnectDelay int
+		// DNS servers reachable thru the tunnel, consumer keeps its own DNS settings if empty
+		DNS []net.IP
 	}
 }
 
@@ -118,9 +136,11 @@ func (s ServiceConfig) MarshalJSON() ([]byte, error) {
 		Endpoint  string `json:"endpoint"`
 	}
 	type consumer struct {
-		PrivateKey   string `json:"private_key"`
-		IPAddress    string `json:"ip_address"`
-		ConnectDelay int    `json:"connect_delay"`
+		PrivateKey   string   `json:"private_key"`
+		IPAddress    string   `json:"ip_address"`
+		IPv6Address  string   `json:"ipv6_address,omitempty"`
+		ConnectDelay int      `json:"connect_delay"`
+		DNS          []string `json:"dns,omitempty"`
 	}
 
 	return json.Marshal(&struct {
@@ -133,11 +153,28 @@ func (s ServiceConfig) MarshalJSON() ([]byte, error) {
 		},
 		consumer{
 			IPAddress:    s.Consumer.IPAddress.String(),
+			IPv6Address:  ipv6String(s.Consumer.IPv6Address),
 			ConnectDelay: s.Consumer.ConnectDelay,
+			DNS:          ipStrings(s.Consumer.DNS),
 		},
 	})
 }
 
+func ipStrings(ips []net.IP) []string {
+	var strs []string
+	for _, ip := range ips {
+		strs = append(strs, ip.String())
+	}
+	return strs
+}
+
+func ipv6String(ipnet net.IPNet) string {
+	if ipnet.IP == nil {
+		return ""
+	}
+	return ipnet.String()
+}
+
 // UnmarshalJSON implements json.Unmarshaler interface to receive human readable configuration.
 func (s *ServiceConfig) UnmarshalJSON(data []byte) error {
 	type provider struct {
@@ -145,9 +182,11 @@ func (s *ServiceConfig) UnmarshalJSON(data []byte) error {
 		Endpoint  string `json:"endpoint"`
 	}
 	type consumer struct {
-		PrivateKey   string `json:"private_key"`
-		IPAddress    string `json:"ip_address"`
-		ConnectDelay int    `json:"connect_delay"`
+		PrivateKey   string   `json:"private_key"`
+		IPAddress    string   `json:"ip_address"`
+		IPv6Address  string   `json:"ipv6_address,omitempty"`
+		ConnectDelay int      `json:"connect_delay"`
+		DNS          []string `json:"dns,omitempty"`
 	}
 	var config struct {
 		Provider provider `json:"provider"`
@@ -173,5 +212,22 @@ func (s *ServiceConfig) UnmarshalJSON(data []byte) error {
 	s.Consumer.IPAddress.IP = ip
 	s.Consumer.ConnectDelay = config.Consumer.ConnectDelay
 
+	if config.Consumer.IPv6Address != "" {
+		ip, ipnet, err := net.ParseCIDR(config.Consumer.IPv6Address)
+		if err != nil {
+			return err
+		}
+		s.Consumer.IPv6Address = *ipnet
+		s.Consumer.IPv6Address.IP = ip
+	}
+
+	for _, dns := range config.Consumer.DNS {
+		ip := net.ParseIP(dns)
+		if ip == nil {
+			return fmt.Errorf("invalid DNS server: %s", dns)
+		}
+		s.Consumer.DNS = append(s.Consumer.DNS, ip)
+	}
+
 	return nil
 }
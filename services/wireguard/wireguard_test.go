@@ -19,8 +19,10 @@ package wireguard
 
 import (
 	"encoding/json"
+	"net"
 	"testing"
 
+	"github.com/mysteriumnetwork/node/datasize"
 	"github.com/mysteriumnetwork/node/money"
 	"github.com/stretchr/testify/assert"
 )
@@ -101,3 +103,69 @@ func Test_PaymentMethod_Unserialize(t *testing.T) {
 		assert.Equal(t, test.expectedError, err)
 	}
 }
+
+func Test_ServiceConfig_SerializesIPv6Address(t *testing.T) {
+	var config ServiceConfig
+	err := json.Unmarshal([]byte(`{
+		"provider": {"public_key": "key", "endpoint": "1.2.3.4:51820"},
+		"consumer": {"ip_address": "10.182.1.2/24", "ipv6_address": "fd10:182:0:1::2/64", "connect_delay": 0}
+	}`), &config)
+	assert.NoError(t, err)
+	assert.Equal(t, "fd10:182:0:1::2/64", config.Consumer.IPv6Address.String())
+
+	jsonBytes, err := json.Marshal(config)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{
+		"provider": {"public_key": "key", "endpoint": "1.2.3.4:51820"},
+		"consumer": {"private_key": "", "ip_address": "10.182.1.2/24", "ipv6_address": "fd10:182:0:1::2/64", "connect_delay": 0}
+	}`, string(jsonBytes))
+}
+
+func Test_ServiceConfig_WithoutIPv6Address(t *testing.T) {
+	var config ServiceConfig
+	err := json.Unmarshal([]byte(`{
+		"provider": {"public_key": "key", "endpoint": "1.2.3.4:51820"},
+		"consumer": {"ip_address": "10.182.1.2/24", "connect_delay": 0}
+	}`), &config)
+	assert.NoError(t, err)
+	assert.Nil(t, config.Consumer.IPv6Address.IP)
+
+	jsonBytes, err := json.Marshal(config)
+	assert.NoError(t, err)
+	assert.NotContains(t, string(jsonBytes), "ipv6_address")
+}
+
+func Test_ServiceConfig_SerializesDNS(t *testing.T) {
+	var config ServiceConfig
+	err := json.Unmarshal([]byte(`{
+		"provider": {"public_key": "key", "endpoint": "1.2.3.4:51820"},
+		"consumer": {"ip_address": "10.182.1.2/24", "connect_delay": 0, "dns": ["10.182.1.1", "fd10:182:0:1::1"]}
+	}`), &config)
+	assert.NoError(t, err)
+	assert.Equal(t, []net.IP{net.ParseIP("10.182.1.1"), net.ParseIP("fd10:182:0:1::1")}, config.Consumer.DNS)
+
+	jsonBytes, err := json.Marshal(config)
+	assert.NoError(t, err)
+	assert.Contains(t, string(jsonBytes), `"dns":["10.182.1.1","fd10:182:0:1::1"]`)
+
+	err = json.Unmarshal([]byte(`{
+		"provider": {"public_key": "key", "endpoint": "1.2.3.4:51820"},
+		"consumer": {"ip_address": "10.182.1.2/24", "dns": ["invalid"]}
+	}`), &config)
+	assert.Error(t, err)
+}
+
+func Test_ServiceDefinition_AnnouncesSessionBandwidth(t *testing.T) {
+	data, err := json.Marshal(ServiceDefinition{})
+	assert.NoError(t, err)
+	assert.NotContains(t, string(data), "session_bandwidth")
+
+	definition := ServiceDefinition{}.WithSessionBandwidth(10 * 1000 * 1000 * datasize.Bit)
+	data, err = json.Marshal(definition)
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), `"session_bandwidth":10000000`)
+
+	var unserialized ServiceDefinition
+	assert.NoError(t, json.Unmarshal(data, &unserialized))
+	assert.Equal(t, definition, unserialized)
+}
@@ -18,8 +18,11 @@
 package session
 
 import (
+	"math/big"
 	"time"
 
+	"github.com/mysteriumnetwork/node/datasize"
+	"github.com/mysteriumnetwork/node/market"
 	"github.com/mysteriumnetwork/node/money"
 	"github.com/mysteriumnetwork/node/services/openvpn/discovery/dto"
 )
@@ -42,3 +45,38 @@ func (ac AmountCalc) TotalAmount(duration time.Duration) money.Money {
 		Currency: ac.PaymentDef.Price.Currency,
 	}
 }
+
+// TimePayment returns the price of the service time defined by the given payment method.
+// Payment methods which do not define the duration (e.g. wireguard) are priced per minute.
+func TimePayment(method market.PaymentMethod) dto.PaymentPerTime {
+	if perTime, ok := method.(dto.PaymentPerTime); ok && perTime.Duration > 0 {
+		return perTime
+	}
+
+	price := money.NewMoney(0, money.CurrencyMyst)
+	if method != nil {
+		price = method.GetPrice()
+	}
+	return dto.PaymentPerTime{
+		Price:    price,
+		Duration: time.Minute,
+	}
+}
+
+// DataAmountCalc calculates the pay required given the amount of transferred data
+type DataAmountCalc struct {
+	PaymentDef market.PaymentPerGB
+}
+
+// TotalAmount gets the total amount of money to pay given the transferred bytes
+func (ac DataAmountCalc) TotalAmount(bytes uint64) money.Money {
+	// price multiplied by the byte count easily overflows uint64, so big numbers are used for the calculation.
+	// the amount is rounded down, so the last started gigabyte is only paid for proportionally
+	amount := new(big.Int).Mul(new(big.Int).SetUint64(bytes), new(big.Int).SetUint64(ac.PaymentDef.Price.Amount))
+	amount.Div(amount, new(big.Int).SetUint64(uint64(datasize.GB.Bytes())))
+
+	return money.Money{
+		Amount:   amount.Uint64(),
+		Currency: ac.PaymentDef.Price.Currency,
+	}
+}
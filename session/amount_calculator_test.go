@@ -21,6 +21,8 @@ import (
 	"testing"
 	"time"
 
+	"github.com/mysteriumnetwork/node/datasize"
+	"github.com/mysteriumnetwork/node/market"
 	"github.com/mysteriumnetwork/node/money"
 	"github.com/mysteriumnetwork/node/services/openvpn/discovery/dto"
 	"github.com/stretchr/testify/assert"
@@ -43,3 +45,44 @@ func Test_CorrectMoneyValueIsReturnedForTotalAmount(t *testing.T) {
 
 	assert.Equal(t, uint64(300), totalAmount.Amount)
 }
+
+func Test_CorrectMoneyValueIsReturnedForTransferredData(t *testing.T) {
+	aCalc := DataAmountCalc{
+		PaymentDef: market.PaymentPerGB{
+			Price: money.Money{
+				Amount:   100,
+				Currency: money.CurrencyMyst,
+			},
+		},
+	}
+
+	transferred := uint64((2*datasize.GB + 512*datasize.MB).Bytes())
+
+	totalAmount := aCalc.TotalAmount(transferred)
+
+	assert.Equal(t, uint64(250), totalAmount.Amount)
+	assert.Equal(t, money.CurrencyMyst, totalAmount.Currency)
+}
+
+func Test_TotalAmountForTransferredDataDoesNotOverflow(t *testing.T) {
+	aCalc := DataAmountCalc{
+		PaymentDef: market.PaymentPerGB{
+			Price: money.NewMoney(10, money.CurrencyMyst),
+		},
+	}
+
+	totalAmount := aCalc.TotalAmount(uint64(datasize.TB.Bytes()))
+
+	assert.Equal(t, 1024*money.NewMoney(10, money.CurrencyMyst).Amount, totalAmount.Amount)
+}
+
+func Test_TimePayment(t *testing.T) {
+	perHour := dto.PaymentPerTime{Price: money.NewMoney(1, money.CurrencyMyst), Duration: time.Hour}
+	assert.Equal(t, perHour, TimePayment(perHour))
+
+	perMinute := dto.PaymentPerTime{Price: money.NewMoney(0.5, money.CurrencyMyst), Duration: time.Minute}
+	assert.Equal(t, perMinute, TimePayment(market.PaymentPerGB{Price: money.NewMoney(0.5, money.CurrencyMyst)}))
+
+	free := dto.PaymentPerTime{Price: money.NewMoney(0, money.CurrencyMyst), Duration: time.Minute}
+	assert.Equal(t, free, TimePayment(nil))
+}
@@ -31,6 +31,9 @@ type Request struct {
 type Message struct {
 	Balance    uint64 `json:"balance"`
 	SequenceID uint64 `json:"sequenceID"`
+
+	// DataTransferred is the amount of bytes provider charges for, set only if the service is paid per data volume
+	DataTransferred uint64 `json:"dataTransferred,omitempty"`
 }
 
 const endpointBalance = "session-balance"
@@ -53,6 +53,53 @@ func Test_BalanceTracker(t *testing.T) {
 	assert.Equal(t, tracker.totalPromised, promisedAmount+initialBalance)
 }
 
+func Test_DataBalanceTracker(t *testing.T) {
+	var initialBalance uint64 = 100
+	mockMoney := money.Money{
+		Amount:   30,
+		Currency: money.CurrencyMyst,
+	}
+	mdk := &mockDataKeeper{transferred: 2048}
+	mac := &mockDataAmountCalculator{toReturn: mockMoney}
+	tracker := NewDataBalanceTracker(mdk, mac, initialBalance)
+
+	tracker.Start()
+	assert.True(t, mdk.startCalled)
+
+	assert.Equal(t, initialBalance-mockMoney.Amount, tracker.GetBalance())
+	assert.Equal(t, uint64(2048), mac.calledWith)
+}
+
+func Test_BalanceTrackerDoesNotGoBelowZero(t *testing.T) {
+	mac := &mockDataAmountCalculator{toReturn: money.Money{Amount: 101, Currency: money.CurrencyMyst}}
+	tracker := NewDataBalanceTracker(&mockDataKeeper{}, mac, 100)
+
+	assert.Equal(t, uint64(0), tracker.GetBalance())
+}
+
+type mockDataKeeper struct {
+	transferred uint64
+	startCalled bool
+}
+
+func (mdk *mockDataKeeper) StartTracking() {
+	mdk.startCalled = true
+}
+
+func (mdk *mockDataKeeper) Transferred() uint64 {
+	return mdk.transferred
+}
+
+type mockDataAmountCalculator struct {
+	calledWith uint64
+	toReturn   money.Money
+}
+
+func (mac *mockDataAmountCalculator) TotalAmount(bytes uint64) money.Money {
+	mac.calledWith = bytes
+	return mac.toReturn
+}
+
 type mockTimeKeeper struct {
 	elapsed     time.Duration
 	startCalled bool
@@ -44,7 +44,7 @@ type createConsumer struct {
 
 // Creator defines method for session creation
 type Creator interface {
-	Create(consumerID, issuerID identity.Identity, proposalID int, config ServiceConfiguration, pingerPrams *traversal.Params) (Session, error)
+	Create(consumerID, issuerID identity.Identity, proposalID int, config ServiceConfiguration, pingerPrams *traversal.Params, dataTransferProvider DataTransferProvider) (Session, error)
 }
 
 // GetMessageEndpoint returns endpoint there to receive messages
@@ -75,7 +75,7 @@ func (consumer *createConsumer) Consume(requestPtr interface{}) (response interf
 	sessionConfigParams.TraversalParams.RequestConfig = request.Config
 	sessionConfigParams.TraversalParams.Cancel = make(chan struct{})
 
-	sessionInstance, err := consumer.sessionCreator.Create(consumer.peerID, issuerID, request.ProposalID, sessionConfigParams.SessionServiceConfig, sessionConfigParams.TraversalParams)
+	sessionInstance, err := consumer.sessionCreator.Create(consumer.peerID, issuerID, request.ProposalID, sessionConfigParams.SessionServiceConfig, sessionConfigParams.TraversalParams, sessionConfigParams.DataTransferProvider)
 	switch err {
 	case nil:
 		if sessionConfigParams.SessionDestroyCallback != nil {
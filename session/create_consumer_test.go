@@ -142,7 +142,7 @@ type managerFake struct {
 }
 
 // Create function creates and returns fake session
-func (manager *managerFake) Create(consumerID, issuerID identity.Identity, proposalID int, config ServiceConfiguration, pingParams *traversal.Params) (Session, error) {
+func (manager *managerFake) Create(consumerID, issuerID identity.Identity, proposalID int, config ServiceConfiguration, pingParams *traversal.Params, dataTransferProvider DataTransferProvider) (Session, error) {
 	manager.lastConsumerID = consumerID
 	manager.lastIssuerID = issuerID
 	manager.lastProposalID = proposalID
@@ -0,0 +1,73 @@
+/*
+ * Copyright (C) 2019 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package session
+
+import "sync"
+
+// DataTracker tracks the amount of data transferred in both directions from the beginning of the session.
+// It's passive (no internal go routines) and simply subtracts the data transfer counters seen at start from the current ones.
+// It is safe for concurrent use, as balance is charged and statistics are reported from different go routines
+type DataTracker struct {
+	started         bool
+	start           uint64
+	last            uint64
+	getDataTransfer DataTransferProvider
+	lock            sync.Mutex
+}
+
+// NewDataTracker initializes DataTracker with specified data transfer counters provider
+func NewDataTracker(getDataTransfer DataTransferProvider) DataTracker {
+	return DataTracker{
+		getDataTransfer: getDataTransfer,
+	}
+}
+
+// StartTracking starts tracking the transferred data
+func (dt *DataTracker) StartTracking() {
+	dt.lock.Lock()
+	defer dt.lock.Unlock()
+
+	dt.started = true
+	dt.start, _ = dt.total()
+	dt.last = 0
+}
+
+// Transferred gets the total amount of bytes transferred since we've started.
+// If the counters are not available at the moment, the last known amount is returned
+func (dt *DataTracker) Transferred() uint64 {
+	dt.lock.Lock()
+	defer dt.lock.Unlock()
+
+	if !dt.started {
+		return 0
+	}
+
+	total, ok := dt.total()
+	if ok && total >= dt.start && total-dt.start > dt.last {
+		dt.last = total - dt.start
+	}
+	return dt.last
+}
+
+func (dt *DataTracker) total() (uint64, bool) {
+	dataTransfer, err := dt.getDataTransfer()
+	if err != nil {
+		return 0, false
+	}
+	return dataTransfer.BytesSent + dataTransfer.BytesReceived, true
+}
@@ -0,0 +1,95 @@
+/*
+ * Copyright (C) 2019 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package session
+
+import (
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_NotStartedDataTrackerTransferredReturnsZeroValue(t *testing.T) {
+	dt := NewDataTracker(newMockedDataTransfer(DataTransfer{BytesSent: 10, BytesReceived: 10}))
+
+	assert.Equal(t, uint64(0), dt.Transferred())
+}
+
+func Test_TransferredReturnsCorrectValue(t *testing.T) {
+	dt := NewDataTracker(newMockedDataTransfer(
+		DataTransfer{BytesSent: 10, BytesReceived: 5},
+		DataTransfer{BytesSent: 40, BytesReceived: 25},
+	))
+
+	dt.StartTracking()
+	transferred := dt.Transferred()
+
+	assert.Equal(t, uint64(50), transferred)
+}
+
+func Test_TransferredReturnsLastKnownValueOnError(t *testing.T) {
+	calls := 0
+	dt := NewDataTracker(func() (DataTransfer, error) {
+		calls++
+		if calls > 2 {
+			return DataTransfer{}, errors.New("session not found")
+		}
+		return DataTransfer{BytesSent: uint64(calls * 10)}, nil
+	})
+
+	dt.StartTracking()
+	assert.Equal(t, uint64(10), dt.Transferred())
+	assert.Equal(t, uint64(10), dt.Transferred())
+}
+
+func Test_TransferredIsSafeForConcurrentUse(t *testing.T) {
+	var counter uint64
+	var counterLock sync.Mutex
+	dt := NewDataTracker(func() (DataTransfer, error) {
+		counterLock.Lock()
+		defer counterLock.Unlock()
+		counter += 10
+		return DataTransfer{BytesSent: counter}, nil
+	})
+	dt.StartTracking()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				dt.Transferred()
+			}
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, uint64(10010), dt.Transferred())
+}
+
+func newMockedDataTransfer(values ...DataTransfer) DataTransferProvider {
+	count := 0
+
+	return func() (DataTransfer, error) {
+		val := values[count]
+		count = count + 1
+		return val, nil
+	}
+}
@@ -34,15 +34,39 @@ type BalanceTracker interface {
 
 // Session structure holds all required information about current session between service consumer and provider
 type Session struct {
-	ID         ID
-	ConsumerID identity.Identity
-	Config     ServiceConfiguration
-	serviceID  string
-	CreatedAt  time.Time
-	Last       bool
-	done       chan struct{}
+	ID          ID
+	ConsumerID  identity.Identity
+	Config      ServiceConfiguration
+	serviceID   string
+	serviceType string
+	providerID  identity.Identity
+	issuerID    identity.Identity
+	CreatedAt   time.Time
+	Last        bool
+	done        chan struct{}
+
+	DataTransfer DataTransfer
+}
+
+// ServiceID returns the ID of the service instance serving the session
+func (session Session) ServiceID() string {
+	return session.serviceID
+}
+
+// ServiceType returns the type of the service serving the session
+func (session Session) ServiceType() string {
+	return session.serviceType
+}
+
+// DataTransfer holds the amount of data transferred during the session
+type DataTransfer struct {
+	BytesSent     uint64
+	BytesReceived uint64
 }
 
+// DataTransferProvider returns the current data transfer counters of the session
+type DataTransferProvider func() (DataTransfer, error)
+
 // ServiceConfiguration defines service configuration from underlying transport mechanism to be passed to remote party
 // should be serializable to json format
 type ServiceConfiguration interface{}
@@ -42,6 +42,9 @@ var (
 
 const managerLogPrefix = "[session-manager] "
 
+// dataTransferUpdateInterval defines how often the session data transfer counters are refreshed
+const dataTransferUpdateInterval = 5 * time.Second
+
 // IDGenerator defines method for session id generation
 type IDGenerator func() (ID, error)
 
@@ -50,6 +53,7 @@ type ConfigParams struct {
 	SessionServiceConfig   ServiceConfiguration
 	SessionDestroyCallback DestroyCallback
 	TraversalParams        *traversal.Params
+	DataTransferProvider   DataTransferProvider
 }
 
 // ConfigNegotiator is able to handle config negotiations
@@ -76,10 +80,12 @@ type Storage interface {
 	Add(sessionInstance Session)
 	Find(id ID) (Session, bool)
 	Remove(id ID)
+	UpdateDataTransfer(id ID, dataTransfer DataTransfer)
 }
 
-// BalanceTrackerFactory returns a new instance of balance tracker
-type BalanceTrackerFactory func(consumer, provider, issuer identity.Identity) (BalanceTracker, error)
+// BalanceTrackerFactory returns a new instance of balance tracker.
+// Data transfer provider gives the traffic of the session, measured by the provider
+type BalanceTrackerFactory func(consumer, provider, issuer identity.Identity, dataTransfer DataTransferProvider) (BalanceTracker, error)
 
 // NATEventGetter lets us access the last known traversal event
 type NATEventGetter interface {
@@ -105,7 +111,8 @@ func NewManager(
 		natEventGetter:        natEventGetter,
 		serviceId:             serviceId,
 
-		creationLock: sync.Mutex{},
+		dataTransferInterval: dataTransferUpdateInterval,
+		creationLock:         sync.Mutex{},
 	}
 }
 
@@ -120,11 +127,12 @@ type Manager struct {
 	natEventGetter        NATEventGetter
 	serviceId             string
 
-	creationLock sync.Mutex
+	dataTransferInterval time.Duration
+	creationLock         sync.Mutex
 }
 
 // Create creates session instance. Multiple sessions per peerID is possible in case different services are used
-func (manager *Manager) Create(consumerID identity.Identity, issuerID identity.Identity, proposalID int, config ServiceConfiguration, pingerParams *traversal.Params) (sessionInstance Session, err error) {
+func (manager *Manager) Create(consumerID identity.Identity, issuerID identity.Identity, proposalID int, config ServiceConfiguration, pingerParams *traversal.Params, dataTransferProvider DataTransferProvider) (sessionInstance Session, err error) {
 	manager.creationLock.Lock()
 	defer manager.creationLock.Unlock()
 
@@ -138,12 +146,15 @@ func (manager *Manager) Create(consumerID identity.Identity, issuerID identity.I
 		return
 	}
 	sessionInstance.serviceID = manager.serviceId
+	sessionInstance.serviceType = manager.currentProposal.ServiceType
+	sessionInstance.providerID = identity.FromAddress(manager.currentProposal.ProviderID)
+	sessionInstance.issuerID = issuerID
 	sessionInstance.ConsumerID = consumerID
 	sessionInstance.done = make(chan struct{})
 	sessionInstance.Config = config
 	sessionInstance.CreatedAt = time.Now().UTC()
 
-	balanceTracker, err := manager.balanceTrackerFactory(consumerID, identity.FromAddress(manager.currentProposal.ProviderID), issuerID)
+	balanceTracker, err := manager.balanceTrackerFactory(consumerID, sessionInstance.providerID, issuerID, manager.sessionDataTransfer(sessionInstance.ID))
 	if err != nil {
 		return
 	}
@@ -168,9 +179,44 @@ func (manager *Manager) Create(consumerID identity.Identity, issuerID identity.I
 
 	manager.natPingerChan(pingerParams)
 	manager.sessionStorage.Add(sessionInstance)
+
+	if dataTransferProvider != nil {
+		go manager.trackDataTransfer(sessionInstance.ID, sessionInstance.done, dataTransferProvider)
+	}
 	return sessionInstance, nil
 }
 
+// sessionDataTransfer returns the provider of the data transfer counters kept in the session storage
+func (manager *Manager) sessionDataTransfer(id ID) DataTransferProvider {
+	return func() (DataTransfer, error) {
+		sessionInstance, found := manager.sessionStorage.Find(id)
+		if !found {
+			return DataTransfer{}, ErrorSessionNotExists
+		}
+		return sessionInstance.DataTransfer, nil
+	}
+}
+
+// trackDataTransfer periodically refreshes the data transfer counters of the session until it is finished
+func (manager *Manager) trackDataTransfer(id ID, done <-chan struct{}, dataTransferProvider DataTransferProvider) {
+	ticker := time.NewTicker(manager.dataTransferInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+			dataTransfer, err := dataTransferProvider()
+			if err != nil {
+				log.Warn(managerLogPrefix, "failed to get data transfer of session ", id, ": ", err)
+				continue
+			}
+			manager.sessionStorage.UpdateDataTransfer(id, dataTransfer)
+		}
+	}
+}
+
 // Destroy destroys session by given sessionID
 func (manager *Manager) Destroy(consumerID identity.Identity, sessionID string) error {
 	manager.creationLock.Lock()
@@ -19,6 +19,7 @@ package session
 
 import (
 	"testing"
+	"time"
 
 	"github.com/mysteriumnetwork/node/identity"
 	"github.com/mysteriumnetwork/node/market"
@@ -57,7 +58,7 @@ func (m mockBalanceTracker) Stop() {
 
 }
 
-func mockBalanceTrackerFactory(consumer, provider, issuer identity.Identity) (BalanceTracker, error) {
+func mockBalanceTrackerFactory(consumer, provider, issuer identity.Identity, dataTransfer DataTransferProvider) (BalanceTracker, error) {
 	return &mockBalanceTracker{}, nil
 }
 
@@ -71,7 +72,7 @@ func TestManager_Create_StoresSession(t *testing.T) {
 		&MockNatEventTracker{}, "test service id")
 
 	pingerParams := &traversal.Params{}
-	sessionInstance, err := manager.Create(consumerID, consumerID, currentProposalID, nil, pingerParams)
+	sessionInstance, err := manager.Create(consumerID, consumerID, currentProposalID, nil, pingerParams, nil)
 	expectedResult.done = sessionInstance.done
 	assert.NoError(t, err)
 
@@ -91,11 +92,39 @@ func TestManager_Create_RejectsUnknownProposal(t *testing.T) {
 		&MockNatEventTracker{}, "test service id")
 
 	pingerParams := &traversal.Params{}
-	sessionInstance, err := manager.Create(consumerID, consumerID, 69, nil, pingerParams)
+	sessionInstance, err := manager.Create(consumerID, consumerID, 69, nil, pingerParams, nil)
 	assert.Exactly(t, err, ErrorInvalidProposal)
 	assert.Exactly(t, Session{}, sessionInstance)
 }
 
+func TestManager_Create_TracksDataTransfer(t *testing.T) {
+	sessionStore := NewStorageMemory()
+	natPinger := func(*traversal.Params) {}
+
+	manager := NewManager(currentProposal, generateSessionID, sessionStore, mockBalanceTrackerFactory, natPinger,
+		&MockNatEventTracker{}, "test service id")
+	manager.dataTransferInterval = time.Millisecond
+
+	dataTransfer := DataTransfer{BytesSent: 10, BytesReceived: 20}
+	pingerParams := &traversal.Params{Cancel: make(chan struct{})}
+	_, err := manager.Create(consumerID, consumerID, currentProposalID, nil, pingerParams, func() (DataTransfer, error) {
+		return dataTransfer, nil
+	})
+	assert.NoError(t, err)
+
+	deadline := time.Now().Add(time.Second)
+	for {
+		sessionInstance, _ := sessionStore.Find(expectedID)
+		if sessionInstance.DataTransfer == dataTransfer || time.Now().After(deadline) {
+			assert.Equal(t, dataTransfer, sessionInstance.DataTransfer)
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	assert.NoError(t, manager.Destroy(consumerID, string(expectedID)))
+}
+
 type MockNatEventTracker struct {
 }
 
@@ -24,6 +24,7 @@ import (
 	"github.com/mysteriumnetwork/node/core/connection"
 	"github.com/mysteriumnetwork/node/core/node"
 	"github.com/mysteriumnetwork/node/identity"
+	"github.com/mysteriumnetwork/node/market"
 	"github.com/mysteriumnetwork/node/services/openvpn/discovery/dto"
 	"github.com/mysteriumnetwork/node/session"
 	"github.com/mysteriumnetwork/node/session/balance"
@@ -42,25 +43,45 @@ const BalanceSendPeriod = time.Second * 20
 // PaymentIssuerFactoryFunc returns a factory for payment issuer. It will be noop if the experimental payment flag is not set
 func PaymentIssuerFactoryFunc(nodeOptions node.Options, signerFactory identity.SignerFactory) func(
 	initialState promise.PaymentInfo,
-	paymentDefinition dto.PaymentPerTime,
+	paymentMethod market.PaymentMethod,
 	messageChan chan balance.Message,
 	dialog communication.Dialog,
-	consumer, provider identity.Identity) (connection.PaymentIssuer, error) {
+	consumer, provider identity.Identity,
+	dataTransfer session.DataTransferProvider) (connection.PaymentIssuer, error) {
 	return paymentIssuerFactory(signerFactory)
 }
 
 func paymentIssuerFactory(signerFactory identity.SignerFactory) func(
 	initialState promise.PaymentInfo,
-	paymentDefinition dto.PaymentPerTime,
+	paymentMethod market.PaymentMethod,
 	messageChan chan balance.Message,
 	dialog communication.Dialog,
-	consumer, provider identity.Identity) (connection.PaymentIssuer, error) {
+	consumer, provider identity.Identity,
+	dataTransfer session.DataTransferProvider) (connection.PaymentIssuer, error) {
 	return func(
 		initialState promise.PaymentInfo,
-		paymentDefinition dto.PaymentPerTime,
+		paymentMethod market.PaymentMethod,
 		messageChan chan balance.Message,
 		dialog communication.Dialog,
-		consumer, provider identity.Identity) (connection.PaymentIssuer, error) {
+		consumer, provider identity.Identity,
+		dataTransfer session.DataTransferProvider) (connection.PaymentIssuer, error) {
+
+		var balanceTracker payment.BalanceTracker
+		var trafficValidator payment.TrafficValidator
+		switch paymentDefinition := paymentMethod.(type) {
+		case dto.PaymentPerTime:
+			timeTracker := session.NewTracker(time.Now)
+			amountCalc := session.AmountCalc{PaymentDef: paymentDefinition}
+			balanceTracker = balance.NewBalanceTracker(&timeTracker, amountCalc, initialState.FreeCredit)
+		case market.PaymentPerGB:
+			// consumer is charged for the data provider claims, as long as the claims match the traffic consumer measures
+			validator := payment.NewConsumerTrafficValidator(consumerTraffic(dataTransfer), payment.DefaultTrafficTolerance)
+			amountCalc := session.DataAmountCalc{PaymentDef: paymentDefinition}
+			balanceTracker = balance.NewDataBalanceTracker(validator, amountCalc, initialState.FreeCredit)
+			trafficValidator = validator
+		default:
+			return nil, errors.Errorf("unsupported payment method: %T", paymentMethod)
+		}
 
 		bl := balance.NewListener(messageChan)
 		ps := promise.NewSender(dialog)
@@ -68,16 +89,24 @@ func paymentIssuerFactory(signerFactory identity.SignerFactory) func(
 
 		promiseState := mapInitialStateToPromiseState(initialState)
 		tracker := promise.NewConsumerTracker(promiseState, consumer, provider, issuer)
-		timeTracker := session.NewTracker(time.Now)
-		amountCalc := session.AmountCalc{PaymentDef: paymentDefinition}
 
-		balanceTracker := balance.NewBalanceTracker(&timeTracker, amountCalc, initialState.FreeCredit)
-		payments := payment.NewSessionPayments(messageChan, ps, tracker, balanceTracker)
+		payments := payment.NewSessionPayments(messageChan, ps, tracker, balanceTracker, trafficValidator)
 		err := dialog.Receive(bl.GetConsumer())
 		return payments, errors.Wrap(err, "fail to receive from consumer")
 	}
 }
 
+// consumerTraffic returns the total amount of bytes transferred in the session, as measured by the consumer
+func consumerTraffic(dataTransfer session.DataTransferProvider) payment.DataTransferred {
+	return func() uint64 {
+		transferred, err := dataTransfer()
+		if err != nil {
+			return 0
+		}
+		return transferred.BytesSent + transferred.BytesReceived
+	}
+}
+
 func mapInitialStateToPromiseState(initialState promise.PaymentInfo) promise.State {
 	return promise.State{
 		Seq:    initialState.LastPromise.SequenceID,
@@ -82,6 +82,7 @@ type SessionBalance struct {
 	promiseWaitTimeout time.Duration
 	promiseValidator   PromiseValidator
 	promiseStorage     PromiseStorage
+	dataTransferred    DataTransferred
 	issuerID           identity.Identity
 	consumerID         identity.Identity
 	receiverID         identity.Identity
@@ -100,6 +101,7 @@ func NewSessionBalance(
 	promiseWaitTimeout time.Duration,
 	promiseValidator PromiseValidator,
 	promiseStorage PromiseStorage,
+	dataTransferred DataTransferred,
 	consumerID, receiverID, issuerID identity.Identity) *SessionBalance {
 	return &SessionBalance{
 		stop:                   make(chan struct{}),
@@ -110,6 +112,7 @@ func NewSessionBalance(
 		promiseWaitTimeout:     promiseWaitTimeout,
 		promiseValidator:       promiseValidator,
 		promiseStorage:         promiseStorage,
+		dataTransferred:        dataTransferred,
 		consumerID:             consumerID,
 		receiverID:             receiverID,
 		issuerID:               issuerID,
@@ -242,11 +245,16 @@ func (sb *SessionBalance) sendBalance() error {
 		return err
 	}
 
-	// TODO: figure out when to get a new sequenceID
-	return sb.peerBalanceSender.Send(balance.Message{
+	message := balance.Message{
 		Balance:    currentBalance,
 		SequenceID: sb.sequenceID,
-	})
+	}
+	if sb.dataTransferred != nil {
+		message.DataTransferred = sb.dataTransferred()
+	}
+
+	// TODO: figure out when to get a new sequenceID
+	return sb.peerBalanceSender.Send(message)
 }
 
 func (sb *SessionBalance) calculateAmountToAdd(pm promise.Message, p promise.StoredPromise) uint64 {
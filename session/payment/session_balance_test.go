@@ -57,6 +57,7 @@ func NewMockSessionBalance(balanceSender *MockPeerBalanceSender, mpv *MockPromis
 		time.Millisecond*1,
 		mpv,
 		mps,
+		nil,
 		consumer,
 		receiver,
 		issuer,
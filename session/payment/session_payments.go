@@ -47,17 +47,21 @@ type SessionPayments struct {
 	peerPromiseSender PeerPromiseSender
 	promiseTracker    PromiseTracker
 	balanceTracker    BalanceTracker
+	trafficValidator  TrafficValidator
 	once              sync.Once
 }
 
-// NewSessionPayments returns a new instance of consumer payment orchestrator
-func NewSessionPayments(balanceChan chan balance.Message, peerPromiseSender PeerPromiseSender, promiseTracker PromiseTracker, balanceTracker BalanceTracker) *SessionPayments {
+// NewSessionPayments returns a new instance of consumer payment orchestrator.
+// Provider balance is compared with the balance tracked by consumer, if traffic validator is given
+// the data provider charges for is validated too, before the balance tracker charges for it.
+func NewSessionPayments(balanceChan chan balance.Message, peerPromiseSender PeerPromiseSender, promiseTracker PromiseTracker, balanceTracker BalanceTracker, trafficValidator TrafficValidator) *SessionPayments {
 	return &SessionPayments{
 		stop:              make(chan struct{}),
 		balanceChan:       balanceChan,
 		peerPromiseSender: peerPromiseSender,
 		promiseTracker:    promiseTracker,
 		balanceTracker:    balanceTracker,
+		trafficValidator:  trafficValidator,
 	}
 }
 
@@ -77,7 +81,7 @@ func (cpo *SessionPayments) Start() error {
 		case <-cpo.stop:
 			return nil
 		case balance := <-cpo.balanceChan:
-			err := cpo.validateBalanceDifference(balance.Balance)
+			err := cpo.validateBalance(balance)
 			if err != nil {
 				return err
 			}
@@ -123,6 +127,15 @@ func (cpo *SessionPayments) issuePromise(balance balance.Message) error {
 	return nil
 }
 
+func (cpo *SessionPayments) validateBalance(balance balance.Message) error {
+	if cpo.trafficValidator != nil {
+		if err := cpo.trafficValidator.Validate(balance.DataTransferred); err != nil {
+			return err
+		}
+	}
+	return cpo.validateBalanceDifference(balance.Balance)
+}
+
 func (cpo *SessionPayments) validateBalanceDifference(balance uint64) error {
 	myBalance := cpo.balanceTracker.GetBalance()
 	diff := calculateBalanceDifference(balance, myBalance)
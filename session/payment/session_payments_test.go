@@ -75,6 +75,7 @@ func NewTestSessionPayments(bm chan balance.Message, ps PeerPromiseSender, pt Pr
 		ps,
 		pt,
 		bt,
+		nil,
 	)
 }
 
@@ -134,6 +135,50 @@ func Test_SessionPayments_ErrsOnBalanceMissmatch(t *testing.T) {
 	<-testDone
 }
 
+func Test_SessionPayments_ValidatesTrafficAndBalance(t *testing.T) {
+	balanceChannel := make(chan balance.Message, 1)
+	promiseSender := newPromiseSender()
+	validator := NewConsumerTrafficValidator(func() uint64 { return 1000 }, 0)
+	cpo := NewSessionPayments(balanceChannel, promiseSender, promiseTracker, &MockBalanceTracker{balanceToReturn: 100}, validator)
+	go cpo.Start()
+	defer cpo.Stop()
+
+	balanceChannel <- balance.Message{Balance: 100, SequenceID: 1, DataTransferred: 1000}
+	assert.Exactly(t, promise.Message{SequenceID: 1, Amount: 0, Signature: "0x"}, <-promiseSender.chanToWriteTo)
+}
+
+func Test_SessionPayments_ErrsOnTrafficMissmatch(t *testing.T) {
+	balanceChannel := make(chan balance.Message, 1)
+	validator := NewConsumerTrafficValidator(func() uint64 { return 0 }, 0)
+	cpo := NewSessionPayments(balanceChannel, newPromiseSender(), promiseTracker, &MockBalanceTracker{}, validator)
+	testDone := make(chan struct{})
+
+	go func() {
+		err := cpo.Start()
+		assert.Equal(t, ErrTrafficMissmatch, err)
+		testDone <- struct{}{}
+	}()
+
+	balanceChannel <- balance.Message{Balance: 0, SequenceID: 1, DataTransferred: 1 << 40}
+	<-testDone
+}
+
+func Test_SessionPayments_ErrsOnBalanceMissmatchOfValidTraffic(t *testing.T) {
+	balanceChannel := make(chan balance.Message, 1)
+	validator := NewConsumerTrafficValidator(func() uint64 { return 1000 }, 0)
+	cpo := NewSessionPayments(balanceChannel, newPromiseSender(), promiseTracker, &MockBalanceTracker{balanceToReturn: 100}, validator)
+	testDone := make(chan struct{})
+
+	go func() {
+		err := cpo.Start()
+		assert.Equal(t, ErrBalanceMissmatch, err)
+		testDone <- struct{}{}
+	}()
+
+	balanceChannel <- balance.Message{Balance: 0, SequenceID: 1, DataTransferred: 1000}
+	<-testDone
+}
+
 func Test_SessionPayments_NoPanicOnSecondStop(t *testing.T) {
 	balanceChannel := make(chan balance.Message, 1)
 	promiseSender := newPromiseSender()
@@ -0,0 +1,87 @@
+/*
+ * Copyright (C) 2019 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package payment
+
+import (
+	"errors"
+	"sync"
+
+	"github.com/mysteriumnetwork/node/datasize"
+)
+
+// DataTransferred returns the amount of bytes transferred during the session
+type DataTransferred func() uint64
+
+// TrafficValidator validates the amount of data provider charges for
+type TrafficValidator interface {
+	Validate(claimedBytes uint64) error
+}
+
+// DefaultTrafficTolerance is the part of consumer measured traffic provider is allowed to charge for on top of it
+const DefaultTrafficTolerance = 0.1
+
+// trafficSlack is the amount of bytes provider is always allowed to charge for on top of consumer measured traffic,
+// as the peers measure the traffic at different moments
+var trafficSlack = uint64(10 * datasize.MB.Bytes())
+
+// ErrTrafficMissmatch represents an error that occurs when provider charges for more data than consumer has transferred
+var ErrTrafficMissmatch = errors.New("traffic missmatch")
+
+// ConsumerTrafficValidator validates the data provider charges for against the traffic measured by consumer.
+// It keeps the largest valid claim, so consumer balance is tracked by the data it has agreed to pay for.
+type ConsumerTrafficValidator struct {
+	consumerTraffic DataTransferred
+	tolerance       float64
+
+	claimed uint64
+	lock    sync.Mutex
+}
+
+// NewConsumerTrafficValidator returns a new instance of traffic validator
+func NewConsumerTrafficValidator(consumerTraffic DataTransferred, tolerance float64) *ConsumerTrafficValidator {
+	return &ConsumerTrafficValidator{
+		consumerTraffic: consumerTraffic,
+		tolerance:       tolerance,
+	}
+}
+
+// Validate checks if the claimed amount of bytes does not exceed consumer measured traffic more than tolerated
+func (ctv *ConsumerTrafficValidator) Validate(claimedBytes uint64) error {
+	measured := ctv.consumerTraffic()
+	allowed := measured + uint64(float64(measured)*ctv.tolerance) + trafficSlack
+	if claimedBytes > allowed {
+		return ErrTrafficMissmatch
+	}
+
+	ctv.lock.Lock()
+	defer ctv.lock.Unlock()
+	if claimedBytes > ctv.claimed {
+		ctv.claimed = claimedBytes
+	}
+	return nil
+}
+
+// StartTracking is a noop, the claims are validated from the beginning of the session
+func (ctv *ConsumerTrafficValidator) StartTracking() {}
+
+// Transferred returns the amount of bytes consumer has agreed to pay for
+func (ctv *ConsumerTrafficValidator) Transferred() uint64 {
+	ctv.lock.Lock()
+	defer ctv.lock.Unlock()
+	return ctv.claimed
+}
@@ -0,0 +1,51 @@
+/*
+ * Copyright (C) 2019 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package payment
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_ConsumerTrafficValidator_AcceptsClaimsWithinTolerance(t *testing.T) {
+	measured := uint64(100 * 1024 * 1024)
+	validator := NewConsumerTrafficValidator(func() uint64 { return measured }, 0.1)
+
+	assert.NoError(t, validator.Validate(0))
+	assert.NoError(t, validator.Validate(measured))
+	assert.NoError(t, validator.Validate(measured+measured/10+trafficSlack))
+}
+
+func Test_ConsumerTrafficValidator_RejectsClaimsAboveTolerance(t *testing.T) {
+	measured := uint64(100 * 1024 * 1024)
+	validator := NewConsumerTrafficValidator(func() uint64 { return measured }, 0.1)
+
+	assert.Equal(t, ErrTrafficMissmatch, validator.Validate(measured+measured/10+trafficSlack+1))
+}
+
+func Test_ConsumerTrafficValidator_TracksLargestValidClaim(t *testing.T) {
+	measured := uint64(100 * 1024 * 1024)
+	validator := NewConsumerTrafficValidator(func() uint64 { return measured }, 0)
+
+	assert.NoError(t, validator.Validate(measured))
+	assert.NoError(t, validator.Validate(measured/2))
+	assert.Error(t, validator.Validate(measured+trafficSlack+1))
+
+	assert.Equal(t, measured, validator.Transferred())
+}
@@ -0,0 +1,74 @@
+/*
+ * Copyright (C) 2019 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package promise
+
+import (
+	"github.com/mysteriumnetwork/node/identity"
+)
+
+// EarningsDayFormat is the layout of the days earnings are aggregated by
+const EarningsDayFormat = "2006-01-02"
+
+// Earnings aggregates the amounts promised to the provider
+type Earnings struct {
+	Total      uint64
+	ByDay      map[string]uint64
+	ByConsumer map[identity.Identity]uint64
+}
+
+// GetEarnings aggregates the amounts of all received promises per day and per consumer.
+// Promises are cumulative, so every increment of a promise is accounted to the day it was received.
+// The amount not covered by the increments (promises stored before they were recorded) goes to the day of the last update.
+func (s *Storage) GetEarnings() (Earnings, error) {
+	s.Lock()
+	defer s.Unlock()
+
+	earnings := Earnings{
+		ByDay:      make(map[string]uint64),
+		ByConsumer: make(map[identity.Identity]uint64),
+	}
+	for _, issuerID := range s.getAllKnownIssuers() {
+		promises, err := s.getAllPromisesForIssuer(issuerID)
+		if err != nil {
+			return Earnings{}, err
+		}
+
+		for _, promise := range promises {
+			if promise.Message == nil {
+				continue
+			}
+
+			var recorded uint64
+			for _, increment := range promise.Increments {
+				earnings.ByDay[increment.At.UTC().Format(EarningsDayFormat)] += increment.Amount
+				recorded += increment.Amount
+			}
+			if promise.Message.Amount > recorded {
+				updatedAt := promise.UpdatedAt
+				if updatedAt.IsZero() {
+					updatedAt = promise.AddedAt
+				}
+				earnings.ByDay[updatedAt.UTC().Format(EarningsDayFormat)] += promise.Message.Amount - recorded
+			}
+
+			earnings.Total += promise.Message.Amount
+			earnings.ByConsumer[promise.ConsumerID] += promise.Message.Amount
+		}
+	}
+	return earnings, nil
+}
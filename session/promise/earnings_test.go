@@ -0,0 +1,88 @@
+/*
+ * Copyright (C) 2019 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package promise
+
+import (
+	"testing"
+	"time"
+
+	"github.com/mysteriumnetwork/node/identity"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Storage_GetEarnings(t *testing.T) {
+	otherConsumerID := identity.FromAddress("0x1")
+	otherIssuerID := identity.FromAddress("0x01")
+	day1 := time.Date(2019, 5, 1, 10, 0, 0, 0, time.UTC)
+	day2 := time.Date(2019, 5, 2, 10, 0, 0, 0, time.UTC)
+
+	ms := newMockStorage(&map[string][]StoredPromise{
+		getBucketNameFromIssuer(issuerID): {
+			{SequenceID: 1, AddedAt: day1, UpdatedAt: day2, ConsumerID: consumerID, Receiver: receiverID, Message: &Message{Amount: 10}},
+			{SequenceID: 2, AddedAt: day2, ConsumerID: consumerID, Receiver: receiverID, Message: &Message{Amount: 5}},
+			{SequenceID: 3, AddedAt: day2, ConsumerID: consumerID, Receiver: receiverID},
+		},
+		getBucketNameFromIssuer(otherIssuerID): {
+			{SequenceID: 1, AddedAt: day1, UpdatedAt: day1, ConsumerID: otherConsumerID, Receiver: receiverID, Message: &Message{Amount: 7}},
+		},
+		"unrelated-bucket": {},
+	})
+	s := NewStorage(ms, &mockPublisher{})
+
+	earnings, err := s.GetEarnings()
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(22), earnings.Total)
+	assert.Equal(t, map[string]uint64{"2019-05-01": 7, "2019-05-02": 15}, earnings.ByDay)
+	assert.Equal(t, map[identity.Identity]uint64{consumerID: 15, otherConsumerID: 7}, earnings.ByConsumer)
+}
+
+func Test_Storage_GetEarnings_SplitsIncrementsByDay(t *testing.T) {
+	day1 := time.Date(2019, 5, 1, 23, 0, 0, 0, time.UTC)
+	day2 := time.Date(2019, 5, 2, 1, 0, 0, 0, time.UTC)
+
+	ms := newMockStorage(&map[string][]StoredPromise{
+		getBucketNameFromIssuer(issuerID): {
+			{
+				SequenceID: 1,
+				AddedAt:    day1,
+				UpdatedAt:  day2,
+				ConsumerID: consumerID,
+				Receiver:   receiverID,
+				Message:    &Message{Amount: 10},
+				Increments: []Increment{{Amount: 4, At: day1}, {Amount: 6, At: day2}},
+			},
+		},
+	})
+	s := NewStorage(ms, &mockPublisher{})
+
+	earnings, err := s.GetEarnings()
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(10), earnings.Total)
+	assert.Equal(t, map[string]uint64{"2019-05-01": 4, "2019-05-02": 6}, earnings.ByDay)
+	assert.Equal(t, map[identity.Identity]uint64{consumerID: 10}, earnings.ByConsumer)
+}
+
+func Test_Storage_GetEarnings_Empty(t *testing.T) {
+	s := NewStorage(newMockStorage(nil), &mockPublisher{})
+
+	earnings, err := s.GetEarnings()
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(0), earnings.Total)
+	assert.Len(t, earnings.ByDay, 0)
+	assert.Len(t, earnings.ByConsumer, 0)
+}
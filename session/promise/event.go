@@ -0,0 +1,36 @@
+/*
+ * Copyright (C) 2018 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package promise
+
+import "github.com/mysteriumnetwork/node/identity"
+
+// AmountTopic is the topic on which the raises of the promised amounts are published
+const AmountTopic = "Promise amount"
+
+// AmountEvent is published when the stored promise is raised by the consumer
+type AmountEvent struct {
+	ConsumerID identity.Identity
+	Increment  uint64
+	// First is set for the first message of the promise, the promise was only reserved for the consumer before it
+	First bool
+}
+
+// Publisher is responsible for publishing given events
+type Publisher interface {
+	Publish(topic string, data interface{})
+}
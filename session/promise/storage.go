@@ -52,14 +52,16 @@ type Storer interface {
 // Storage stores promises. It also issues sequence ID's for promises.
 // It's designed to be used as a singleton for promise storage.
 type Storage struct {
-	storage Storer
+	storage   Storer
+	publisher Publisher
 	sync.Mutex
 }
 
-// NewStorage returns a new instance of promise storage
-func NewStorage(storage Storer) *Storage {
+// NewStorage returns a new instance of promise storage, raises of the promised amounts are published with the given publisher
+func NewStorage(storage Storer, publisher Publisher) *Storage {
 	return &Storage{
-		storage: storage,
+		storage:   storage,
+		publisher: publisher,
 	}
 }
 
@@ -73,6 +75,14 @@ type StoredPromise struct {
 	ConsumerID       identity.Identity
 	Receiver         identity.Identity
 	Cleared          bool
+	// Increments records every raise of the promised amount, the amounts sum up to the amount of the message
+	Increments []Increment
+}
+
+// Increment is a raise of the promised amount at the given time
+type Increment struct {
+	Amount uint64
+	At     time.Time
 }
 
 // GetNewSeqIDForIssuer returns a new sequenceID for the provided issuer.
@@ -120,12 +130,14 @@ func (s *Storage) Update(issuerID identity.Identity, sp StoredPromise) error {
 
 	// The storage layers update doesn't really care if the promise exists, it will just insert a new one.
 	// In this case - we'll want to make sure we don't update something that does not exist
-	_, err := s.getPromiseByID(issuerID, sp.SequenceID)
+	previous, err := s.getPromiseByID(issuerID, sp.SequenceID)
 	if err != nil {
 		return err
 	}
 
-	return s.update(issuerID, sp)
+	// increments are owned by the storage, the callers do not have to carry them over
+	sp.Increments = previous.Increments
+	return s.update(issuerID, sp, promisedAmount(previous))
 }
 
 // GetLastPromise fetches the last promise for the provider
@@ -140,7 +152,10 @@ func (s *Storage) GetLastPromise(issuerID identity.Identity) (StoredPromise, err
 func (s *Storage) GetAllKnownIssuers() []identity.Identity {
 	s.Lock()
 	defer s.Unlock()
+	return s.getAllKnownIssuers()
+}
 
+func (s *Storage) getAllKnownIssuers() []identity.Identity {
 	buckets := s.storage.GetBuckets()
 
 	res := make([]identity.Identity, 0)
@@ -263,14 +278,51 @@ func (s *Storage) getAllPromisesForIssuer(issuerID identity.Identity) (res []Sto
 	return
 }
 
-func (s *Storage) update(issuerID identity.Identity, promise StoredPromise) error {
+func (s *Storage) update(issuerID identity.Identity, promise StoredPromise, previousAmount uint64) error {
 	promise.UpdatedAt = time.Now().UTC()
-	return s.storage.Update(getBucketNameFromIssuer(issuerID), &promise)
+	amount := promisedAmount(promise)
+	if amount > previousAmount {
+		promise.Increments = append(promise.Increments, Increment{Amount: amount - previousAmount, At: promise.UpdatedAt})
+	}
+	if err := s.storage.Update(getBucketNameFromIssuer(issuerID), &promise); err != nil {
+		return err
+	}
+
+	s.publishAmount(promise.ConsumerID, previousAmount, amount)
+	return nil
 }
 
 func (s *Storage) store(issuerID identity.Identity, sp StoredPromise) error {
 	sp.AddedAt = time.Now().UTC()
-	return s.storage.Store(getBucketNameFromIssuer(issuerID), &sp)
+	sp.Increments = nil
+	amount := promisedAmount(sp)
+	if amount > 0 {
+		sp.Increments = []Increment{{Amount: amount, At: sp.AddedAt}}
+	}
+	if err := s.storage.Store(getBucketNameFromIssuer(issuerID), &sp); err != nil {
+		return err
+	}
+
+	s.publishAmount(sp.ConsumerID, 0, amount)
+	return nil
+}
+
+func (s *Storage) publishAmount(consumerID identity.Identity, previousAmount, amount uint64) {
+	if amount <= previousAmount {
+		return
+	}
+	s.publisher.Publish(AmountTopic, AmountEvent{
+		ConsumerID: consumerID,
+		Increment:  amount - previousAmount,
+		First:      previousAmount == 0,
+	})
+}
+
+func promisedAmount(sp StoredPromise) uint64 {
+	if sp.Message == nil {
+		return 0
+	}
+	return sp.Message.Amount
 }
 
 func getBucketNameFromIssuer(issuerID identity.Identity) string {
@@ -50,7 +50,7 @@ var (
 
 func Test_Storage_IssuesOneForUnknownID(t *testing.T) {
 	ms := newMockStorage(nil)
-	s := NewStorage(ms)
+	s := NewStorage(ms, &mockPublisher{})
 	res, err := s.GetNewSeqIDForIssuer(consumerID, receiverID, issuerID)
 	assert.Nil(t, err)
 	assert.Equal(t, firstPromiseID, res)
@@ -68,7 +68,7 @@ func Test_Storage_IssuesOneForUnknownID(t *testing.T) {
 
 func Test_Storage_UpdatesPromise(t *testing.T) {
 	ms := newMockStorage(&mock)
-	s := NewStorage(ms)
+	s := NewStorage(ms, &mockPublisher{})
 
 	var stored []StoredPromise
 	err := ms.GetAllFrom(getBucketNameFromIssuer(issuerID), &stored)
@@ -90,9 +90,52 @@ func Test_Storage_UpdatesPromise(t *testing.T) {
 	assert.Equal(t, msg, promise.Message)
 }
 
+func Test_Storage_UpdateRecordsIncrements(t *testing.T) {
+	ms := newMockStorage(nil)
+	s := NewStorage(ms, &mockPublisher{})
+
+	err := s.Store(issuerID, StoredPromise{SequenceID: 1, Message: &Message{Amount: 5}})
+	assert.Nil(t, err)
+	err = s.Update(issuerID, StoredPromise{SequenceID: 1, Message: &Message{Amount: 12}})
+	assert.Nil(t, err)
+	err = s.Update(issuerID, StoredPromise{SequenceID: 1, Message: &Message{Amount: 12}, UnconsumedAmount: 3})
+	assert.Nil(t, err)
+
+	promise, err := s.getPromiseByID(issuerID, 1)
+	assert.Nil(t, err)
+	assert.Len(t, promise.Increments, 2)
+	assert.Equal(t, uint64(5), promise.Increments[0].Amount)
+	assert.Equal(t, promise.AddedAt, promise.Increments[0].At)
+	assert.Equal(t, uint64(7), promise.Increments[1].Amount)
+	assert.False(t, promise.Increments[1].At.IsZero())
+}
+
+func Test_Storage_PublishesAmountRaises(t *testing.T) {
+	publisher := &mockPublisher{}
+	s := NewStorage(newMockStorage(nil), publisher)
+
+	err := s.Store(issuerID, StoredPromise{SequenceID: 1, ConsumerID: consumerID})
+	assert.Nil(t, err)
+	err = s.Update(issuerID, StoredPromise{SequenceID: 1, ConsumerID: consumerID, Message: &Message{Amount: 5}})
+	assert.Nil(t, err)
+	err = s.Update(issuerID, StoredPromise{SequenceID: 1, ConsumerID: consumerID, Message: &Message{Amount: 5}, UnconsumedAmount: 3})
+	assert.Nil(t, err)
+	err = s.Update(issuerID, StoredPromise{SequenceID: 1, ConsumerID: consumerID, Message: &Message{Amount: 12}})
+	assert.Nil(t, err)
+
+	assert.Equal(
+		t,
+		[]AmountEvent{
+			{ConsumerID: consumerID, Increment: 5, First: true},
+			{ConsumerID: consumerID, Increment: 7},
+		},
+		publisher.published,
+	)
+}
+
 func Test_Storage_UpdateErrsOnNonExistingPromise(t *testing.T) {
 	ms := newMockStorage(nil)
-	s := NewStorage(ms)
+	s := NewStorage(ms, &mockPublisher{})
 	err := s.Update(issuerID, StoredPromise{
 		SequenceID: 1,
 	})
@@ -101,7 +144,7 @@ func Test_Storage_UpdateErrsOnNonExistingPromise(t *testing.T) {
 
 func Test_Storage_Store(t *testing.T) {
 	ms := newMockStorage(nil)
-	s := NewStorage(ms)
+	s := NewStorage(ms, &mockPublisher{})
 
 	mockMsg := Message{}
 	err := s.Store(issuerID, StoredPromise{
@@ -120,7 +163,7 @@ func Test_Storage_Store(t *testing.T) {
 
 func Test_Storage_IssuesSecondForKnownID(t *testing.T) {
 	ms := newMockStorage(&mock)
-	s := NewStorage(ms)
+	s := NewStorage(ms, &mockPublisher{})
 	res, err := s.GetNewSeqIDForIssuer(consumerID, receiverID, issuerID)
 	assert.Nil(t, err)
 	assert.Equal(t, uint64(2), res)
@@ -128,7 +171,7 @@ func Test_Storage_IssuesSecondForKnownID(t *testing.T) {
 
 func Test_Storage_GetAllKnownIssuers_GetsKnownIdentities(t *testing.T) {
 	ms := newMockStorage(&mock)
-	s := NewStorage(ms)
+	s := NewStorage(ms, &mockPublisher{})
 	id2 := identity.FromAddress("0x1")
 	err := s.Store(id2, StoredPromise{
 		SequenceID: 1,
@@ -153,14 +196,14 @@ func containsIdentity(s []identity.Identity, e identity.Identity) bool {
 
 func Test_Storage_GetAllKnownIssuers_ReturnsEmptyIdentityList(t *testing.T) {
 	ms := newMockStorage(nil)
-	s := NewStorage(ms)
+	s := NewStorage(ms, &mockPublisher{})
 	issuers := s.GetAllKnownIssuers()
 	assert.Len(t, issuers, 0)
 }
 
 func Test_Storage_GetAllPromisesForIssuer_ReturnsEmptyPromiseList(t *testing.T) {
 	ms := newMockStorage(nil)
-	s := NewStorage(ms)
+	s := NewStorage(ms, &mockPublisher{})
 	res, err := s.GetAllPromisesFromIssuer(issuerID)
 	assert.Nil(t, err)
 	assert.Len(t, res, 0)
@@ -168,7 +211,7 @@ func Test_Storage_GetAllPromisesForIssuer_ReturnsEmptyPromiseList(t *testing.T)
 
 func Test_LoadPaymentInfo_EmptyOnError(t *testing.T) {
 	ms := newMockStorage(nil)
-	s := NewStorage(ms)
+	s := NewStorage(ms, &mockPublisher{})
 	pi := s.LoadPaymentInfo(issuerID, receiverID, consumerID)
 	assert.Equal(t, &PaymentInfo{
 		LastPromise: LastPromise{
@@ -180,7 +223,7 @@ func Test_LoadPaymentInfo_EmptyOnError(t *testing.T) {
 
 func Test_LoadPaymentInfo_ZeroAmountOnNoMessage(t *testing.T) {
 	ms := newMockStorage(&mock)
-	s := NewStorage(ms)
+	s := NewStorage(ms, &mockPublisher{})
 	pi := s.LoadPaymentInfo(issuerID, receiverID, consumerID)
 	assert.Equal(t, &PaymentInfo{
 		LastPromise: LastPromise{
@@ -208,7 +251,7 @@ func Test_LoadPaymentInfo_LoadsProperInfo(t *testing.T) {
 		},
 	}
 	ms := newMockStorage(&newMock)
-	s := NewStorage(ms)
+	s := NewStorage(ms, &mockPublisher{})
 	pi := s.LoadPaymentInfo(consumerID, receiverID, issuerID)
 	assert.Equal(t, uint64(10), pi.LastPromise.SequenceID)
 	assert.Equal(t, uint64(300), pi.LastPromise.Amount)
@@ -244,7 +287,7 @@ func Test_LoadPaymentInfo_WithPreviousConsumersIssuesNewID(t *testing.T) {
 	}
 
 	ms := newMockStorage(&newMock)
-	s := NewStorage(ms)
+	s := NewStorage(ms, &mockPublisher{})
 	pi := s.LoadPaymentInfo(consumerID, receiverID, issuerID)
 	assert.Equal(t, &PaymentInfo{
 		LastPromise: LastPromise{
@@ -272,7 +315,7 @@ func Test_LoadPaymentInfo_NextIfReceiversDontMatch(t *testing.T) {
 	}
 
 	ms := newMockStorage(&newMock)
-	s := NewStorage(ms)
+	s := NewStorage(ms, &mockPublisher{})
 	pi := s.LoadPaymentInfo(consumerID, receiverID, issuerID)
 	assert.Equal(t, &PaymentInfo{
 		LastPromise: LastPromise{
@@ -284,7 +327,7 @@ func Test_LoadPaymentInfo_NextIfReceiversDontMatch(t *testing.T) {
 
 func Test_Storage_GetAllPromisesForIssuer_GetsAllPromises(t *testing.T) {
 	ms := newMockStorage(&mock)
-	s := NewStorage(ms)
+	s := NewStorage(ms, &mockPublisher{})
 	res, err := s.GetAllPromisesFromIssuer(issuerID)
 	assert.Nil(t, err)
 	assert.Len(t, res, 1)
@@ -292,7 +335,7 @@ func Test_Storage_GetAllPromisesForIssuer_GetsAllPromises(t *testing.T) {
 
 func Test_Storage_IssuesUniqueSequencesForMultipleIssuers(t *testing.T) {
 	ms := newMockStorage(&mock)
-	s := NewStorage(ms)
+	s := NewStorage(ms, &mockPublisher{})
 	res, err := s.GetNewSeqIDForIssuer(consumerID, receiverID, issuerID)
 	assert.Nil(t, err)
 	assert.Equal(t, uint64(2), res)
@@ -305,7 +348,7 @@ func Test_Storage_IssuesUniqueSequencesForMultipleIssuers(t *testing.T) {
 
 func Test_Storage_DoesAtomicIncrementsUnderConcurrentLoad(t *testing.T) {
 	ms := newMockStorage(nil)
-	s := NewStorage(ms)
+	s := NewStorage(ms, &mockPublisher{})
 
 	routineCount := 1000
 
@@ -335,7 +378,7 @@ func Test_Storage_DoesAtomicIncrementsUnderConcurrentLoad(t *testing.T) {
 
 func Test_FindPromiseForConsumer_ErrsOnEmptySlice(t *testing.T) {
 	ms := newMockStorage(nil)
-	s := NewStorage(ms)
+	s := NewStorage(ms, &mockPublisher{})
 	_, err := s.FindPromiseForConsumer(issuerID, receiverID, identity.FromAddress("0x000"))
 	assert.Equal(t, errNoPromiseForConsumer, err)
 }
@@ -356,7 +399,7 @@ func Test_FindPromiseForConsumer_ErrsOnNoConsumerPromise(t *testing.T) {
 		},
 	}
 	ms := newMockStorage(&mock)
-	s := NewStorage(ms)
+	s := NewStorage(ms, &mockPublisher{})
 	_, err := s.FindPromiseForConsumer(identity.FromAddress("0x000"), receiverID, issuerID)
 	assert.Equal(t, errNoPromiseForConsumer, err)
 }
@@ -380,7 +423,7 @@ func Test_FindPromiseForConsumer_FindsConsumer(t *testing.T) {
 		},
 	}
 	ms := newMockStorage(&mock)
-	s := NewStorage(ms)
+	s := NewStorage(ms, &mockPublisher{})
 	pr, err := s.FindPromiseForConsumer(consumerID, receiverID, issuerID)
 	assert.Nil(t, err)
 	assert.Equal(t, uint64(1), pr.SequenceID)
@@ -406,7 +449,7 @@ func Test_FindPromiseForConsumer_FindsClearerd(t *testing.T) {
 		},
 	}
 	ms := newMockStorage(&mock)
-	s := NewStorage(ms)
+	s := NewStorage(ms, &mockPublisher{})
 	_, err := s.FindPromiseForConsumer(issuerID, receiverID, consumerID)
 	assert.Equal(t, errNoPromiseForConsumer, err)
 }
@@ -487,6 +530,16 @@ func Test_MockStorage(t *testing.T) {
 	assert.Equal(t, bucket, buckets[0])
 }
 
+type mockPublisher struct {
+	published []AmountEvent
+}
+
+func (mp *mockPublisher) Publish(topic string, data interface{}) {
+	if topic == AmountTopic {
+		mp.published = append(mp.published, data.(AmountEvent))
+	}
+}
+
 type mockStorage struct {
 	inMemStorage map[string][]StoredPromise
 }
@@ -554,6 +607,9 @@ func (ms *mockStorage) Update(bucket string, data interface{}) error {
 			if ms.inMemStorage[bucket][i].SequenceID == casted.SequenceID {
 				ms.inMemStorage[bucket][i].Message = casted.Message
 				ms.inMemStorage[bucket][i].UpdatedAt = casted.UpdatedAt
+				if casted.Increments != nil {
+					ms.inMemStorage[bucket][i].Increments = casted.Increments
+				}
 				break
 			}
 		}
@@ -0,0 +1,232 @@
+/*
+ * Copyright (C) 2019 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package session
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	log "github.com/cihub/seelog"
+	"github.com/mysteriumnetwork/node/identity"
+	"github.com/mysteriumnetwork/node/session/promise"
+)
+
+const storageBoltLogPrefix = "[session-storage-bolt] "
+const storageBoltBucketName = "provider-sessions"
+
+const (
+	// StatusActive marks the session which is still being served
+	StatusActive = "Active"
+	// StatusTerminated marks the session which is finished
+	StatusTerminated = "Terminated"
+)
+
+const (
+	// TerminationReasonDestroyed is used for sessions destroyed during the normal node operation
+	TerminationReasonDestroyed = "destroyed"
+	// TerminationReasonServiceStopped is used for sessions removed together with their service
+	TerminationReasonServiceStopped = "service stopped"
+	// TerminationReasonNodeRestarted is used for sessions left unfinished by the previous node run
+	TerminationReasonNodeRestarted = "node restarted"
+)
+
+// Storer allows us to get all session records, save and update them
+type Storer interface {
+	Store(bucket string, object interface{}) error
+	Update(bucket string, object interface{}) error
+	GetAllFrom(bucket string, array interface{}) error
+	GetOneByField(bucket string, fieldName string, key interface{}, to interface{}) error
+}
+
+// PromiseFinder finds the last known promise of the consumer
+type PromiseFinder interface {
+	FindPromiseForConsumer(consumerID, receiverID, issuerID identity.Identity) (promise.StoredPromise, error)
+}
+
+// Record is the persisted representation of a provider session.
+// Once the session is terminated, the record becomes its history entry.
+type Record struct {
+	ID                ID `storm:"id"`
+	ServiceID         string
+	ServiceType       string
+	ConsumerID        identity.Identity
+	ProviderID        identity.Identity
+	IssuerID          identity.Identity
+	Config            json.RawMessage
+	CreatedAt         time.Time
+	Status            string
+	TerminatedAt      time.Time
+	TerminationReason string
+
+	BytesSent     uint64
+	BytesReceived uint64
+
+	// InitialPromise is the last consumer promise known when the session started
+	InitialPromise promise.LastPromise
+	// LastPromise is the last consumer promise known when the session ended
+	LastPromise promise.LastPromise
+	// PromisedAmount is the amount consumer promised during the session
+	PromisedAmount uint64
+}
+
+// NewStorageBolt initiates new session storage which persists sessions to the given storage
+func NewStorageBolt(storage Storer, promiseFinder PromiseFinder) *StorageBolt {
+	return &StorageBolt{
+		StorageMemory: NewStorageMemory(),
+		storage:       storage,
+		promiseFinder: promiseFinder,
+	}
+}
+
+// StorageBolt keeps live sessions in memory and persists their records,
+// so sessions left unfinished by a crashed node can be recovered on the next start
+type StorageBolt struct {
+	*StorageMemory
+	storage       Storer
+	promiseFinder PromiseFinder
+}
+
+// Add puts given session to storage and persists its record
+func (storage *StorageBolt) Add(sessionInstance Session) {
+	storage.StorageMemory.Add(sessionInstance)
+
+	config, err := json.Marshal(sessionInstance.Config)
+	if err != nil {
+		log.Warn(storageBoltLogPrefix, fmt.Sprintf("Session %v config not serializable: %v", sessionInstance.ID, err))
+	}
+
+	record := &Record{
+		ID:          sessionInstance.ID,
+		ServiceID:   sessionInstance.serviceID,
+		ServiceType: sessionInstance.serviceType,
+		ConsumerID:  sessionInstance.ConsumerID,
+		ProviderID:  sessionInstance.providerID,
+		IssuerID:    sessionInstance.issuerID,
+		Config:      config,
+		CreatedAt:   sessionInstance.CreatedAt,
+		Status:      StatusActive,
+	}
+	record.InitialPromise, _ = storage.findLastPromise(*record)
+
+	if err := storage.storage.Store(storageBoltBucketName, record); err != nil {
+		log.Error(storageBoltLogPrefix, fmt.Sprintf("Session %v not saved: %v", sessionInstance.ID, err))
+	}
+}
+
+// Remove removes given session from underlying storage and marks its record as terminated
+func (storage *StorageBolt) Remove(id ID) {
+	sessionInstance, _ := storage.StorageMemory.Find(id)
+	storage.StorageMemory.Remove(id)
+	storage.terminate(id, TerminationReasonDestroyed, sessionInstance.DataTransfer)
+}
+
+// RemoveForService removes all sessions which belong to given service
+func (storage *StorageBolt) RemoveForService(serviceID string) {
+	sessions := storage.GetAll()
+	for _, session := range sessions {
+		if session.serviceID == serviceID {
+			storage.StorageMemory.Remove(session.ID)
+			storage.terminate(session.ID, TerminationReasonServiceStopped, session.DataTransfer)
+		}
+	}
+}
+
+// GetUnfinished returns records of the sessions which were not terminated
+func (storage *StorageBolt) GetUnfinished() ([]Record, error) {
+	return storage.getRecords(func(record Record) bool {
+		return record.Status != StatusTerminated
+	})
+}
+
+// GetHistory returns records of the terminated sessions
+func (storage *StorageBolt) GetHistory() ([]Record, error) {
+	return storage.getRecords(func(record Record) bool {
+		return record.Status == StatusTerminated
+	})
+}
+
+func (storage *StorageBolt) getRecords(match func(Record) bool) ([]Record, error) {
+	var records []Record
+	if err := storage.storage.GetAllFrom(storageBoltBucketName, &records); err != nil {
+		return nil, err
+	}
+
+	matched := make([]Record, 0)
+	for _, record := range records {
+		if match(record) {
+			matched = append(matched, record)
+		}
+	}
+	return matched, nil
+}
+
+// Terminate marks the session record as terminated with the given reason
+// and completes it with the promises consumer issued during the session
+func (storage *StorageBolt) Terminate(id ID, reason string) error {
+	return storage.terminateRecord(id, reason, nil)
+}
+
+func (storage *StorageBolt) terminateRecord(id ID, reason string, dataTransfer *DataTransfer) error {
+	var record Record
+	if err := storage.storage.GetOneByField(storageBoltBucketName, "ID", id, &record); err != nil {
+		return err
+	}
+
+	if dataTransfer != nil {
+		record.BytesSent = dataTransfer.BytesSent
+		record.BytesReceived = dataTransfer.BytesReceived
+	}
+	record.Status = StatusTerminated
+	record.TerminatedAt = time.Now().UTC()
+	record.TerminationReason = reason
+	if lastPromise, found := storage.findLastPromise(record); found {
+		record.LastPromise = lastPromise
+		record.PromisedAmount = promisedAmount(record.InitialPromise, lastPromise)
+	}
+
+	return storage.storage.Update(storageBoltBucketName, &record)
+}
+
+func (storage *StorageBolt) findLastPromise(record Record) (promise.LastPromise, bool) {
+	storedPromise, err := storage.promiseFinder.FindPromiseForConsumer(record.ConsumerID, record.ProviderID, record.IssuerID)
+	if err != nil || storedPromise.Message == nil {
+		return promise.LastPromise{}, false
+	}
+	return promise.LastPromise{
+		SequenceID: storedPromise.SequenceID,
+		Amount:     storedPromise.Message.Amount,
+	}, true
+}
+
+// promisedAmount calculates the amount promised between the two promise snapshots.
+// Promises are cumulative, so only the growth of the same promise counts.
+func promisedAmount(initial, last promise.LastPromise) uint64 {
+	if initial.SequenceID == last.SequenceID && last.Amount >= initial.Amount {
+		return last.Amount - initial.Amount
+	}
+	return last.Amount
+}
+
+func (storage *StorageBolt) terminate(id ID, reason string, dataTransfer DataTransfer) {
+	if err := storage.terminateRecord(id, reason, &dataTransfer); err != nil {
+		log.Error(storageBoltLogPrefix, fmt.Sprintf("Session %v not terminated: %v", id, err))
+	} else {
+		log.Trace(storageBoltLogPrefix, fmt.Sprintf("Session %v terminated: %v", id, reason))
+	}
+}
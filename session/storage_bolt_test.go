@@ -0,0 +1,232 @@
+/*
+ * Copyright (C) 2019 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package session
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/mysteriumnetwork/node/identity"
+	"github.com/mysteriumnetwork/node/session/promise"
+	"github.com/stretchr/testify/assert"
+)
+
+func mockBoltSession(id ID, serviceID string) Session {
+	return Session{
+		ID:          id,
+		ConsumerID:  identity.FromAddress("consumer"),
+		Config:      map[string]string{"key": "value"},
+		serviceID:   serviceID,
+		serviceType: "wireguard",
+	}
+}
+
+func TestStorageBolt_AddPersistsRecord(t *testing.T) {
+	storage := NewStorageBolt(newStubRecordStorer(), &stubPromiseFinder{})
+
+	storage.Add(mockBoltSession("id1", "service1"))
+
+	_, found := storage.Find("id1")
+	assert.True(t, found)
+
+	records, err := storage.GetUnfinished()
+	assert.NoError(t, err)
+	assert.Len(t, records, 1)
+	assert.Equal(t, ID("id1"), records[0].ID)
+	assert.Equal(t, "service1", records[0].ServiceID)
+	assert.Equal(t, "wireguard", records[0].ServiceType)
+	assert.Equal(t, identity.FromAddress("consumer"), records[0].ConsumerID)
+	assert.JSONEq(t, `{"key": "value"}`, string(records[0].Config))
+	assert.Equal(t, StatusActive, records[0].Status)
+}
+
+func TestStorageBolt_RemoveTerminatesRecord(t *testing.T) {
+	storage := NewStorageBolt(newStubRecordStorer(), &stubPromiseFinder{})
+
+	storage.Add(mockBoltSession("id1", "service1"))
+	storage.Add(mockBoltSession("id2", "service1"))
+	storage.Remove("id1")
+
+	_, found := storage.Find("id1")
+	assert.False(t, found)
+
+	records, err := storage.GetUnfinished()
+	assert.NoError(t, err)
+	assert.Len(t, records, 1)
+	assert.Equal(t, ID("id2"), records[0].ID)
+
+	record := storage.storage.(*stubRecordStorer).records["id1"]
+	assert.Equal(t, StatusTerminated, record.Status)
+	assert.Equal(t, TerminationReasonDestroyed, record.TerminationReason)
+	assert.False(t, record.TerminatedAt.IsZero())
+	assert.Equal(t, "wireguard", record.ServiceType)
+}
+
+func TestStorageBolt_RemoveRecordsDataTransfer(t *testing.T) {
+	storage := NewStorageBolt(newStubRecordStorer(), &stubPromiseFinder{})
+
+	storage.Add(mockBoltSession("id1", "service1"))
+	storage.UpdateDataTransfer("id1", DataTransfer{BytesSent: 100, BytesReceived: 200})
+	storage.Remove("id1")
+
+	history, err := storage.GetHistory()
+	assert.NoError(t, err)
+	assert.Len(t, history, 1)
+	assert.Equal(t, uint64(100), history[0].BytesSent)
+	assert.Equal(t, uint64(200), history[0].BytesReceived)
+}
+
+func TestStorageBolt_RemoveForServiceTerminatesRecords(t *testing.T) {
+	storage := NewStorageBolt(newStubRecordStorer(), &stubPromiseFinder{})
+
+	storage.Add(mockBoltSession("id1", "service1"))
+	storage.Add(mockBoltSession("id2", "service2"))
+	storage.RemoveForService("service1")
+
+	assert.Len(t, storage.GetAll(), 1)
+
+	records, err := storage.GetUnfinished()
+	assert.NoError(t, err)
+	assert.Len(t, records, 1)
+	assert.Equal(t, ID("id2"), records[0].ID)
+}
+
+func TestStorageBolt_TerminateUnfinished(t *testing.T) {
+	storage := NewStorageBolt(newStubRecordStorer(), &stubPromiseFinder{})
+
+	storage.Add(mockBoltSession("id1", "service1"))
+
+	// emulate a node restart by using a fresh in-memory state over the same database
+	restarted := NewStorageBolt(storage.storage, &stubPromiseFinder{})
+	assert.Len(t, restarted.GetAll(), 0)
+
+	records, err := restarted.GetUnfinished()
+	assert.NoError(t, err)
+	assert.Len(t, records, 1)
+
+	err = restarted.Terminate(records[0].ID, TerminationReasonNodeRestarted)
+	assert.NoError(t, err)
+
+	records, err = restarted.GetUnfinished()
+	assert.NoError(t, err)
+	assert.Len(t, records, 0)
+}
+
+func TestStorageBolt_GetUnfinishedReturnsError(t *testing.T) {
+	storer := newStubRecordStorer()
+	storer.getAllErr = errors.New("storage failed")
+	storage := NewStorageBolt(storer, &stubPromiseFinder{})
+
+	records, err := storage.GetUnfinished()
+	assert.Error(t, err)
+	assert.Nil(t, records)
+}
+
+func TestStorageBolt_TerminateRecordsPromisedAmount(t *testing.T) {
+	finder := &stubPromiseFinder{promise: promise.StoredPromise{SequenceID: 3, Message: &promise.Message{SequenceID: 3, Amount: 100}}}
+	storage := NewStorageBolt(newStubRecordStorer(), finder)
+
+	storage.Add(mockBoltSession("id1", "service1"))
+	finder.promise.Message.Amount = 250
+	storage.Remove("id1")
+
+	history, err := storage.GetHistory()
+	assert.NoError(t, err)
+	assert.Len(t, history, 1)
+	assert.Equal(t, promise.LastPromise{SequenceID: 3, Amount: 100}, history[0].InitialPromise)
+	assert.Equal(t, promise.LastPromise{SequenceID: 3, Amount: 250}, history[0].LastPromise)
+	assert.Equal(t, uint64(150), history[0].PromisedAmount)
+}
+
+func TestStorageBolt_GetHistoryReturnsTerminatedOnly(t *testing.T) {
+	storage := NewStorageBolt(newStubRecordStorer(), &stubPromiseFinder{})
+
+	storage.Add(mockBoltSession("id1", "service1"))
+	storage.Add(mockBoltSession("id2", "service1"))
+	storage.Remove("id2")
+
+	history, err := storage.GetHistory()
+	assert.NoError(t, err)
+	assert.Len(t, history, 1)
+	assert.Equal(t, ID("id2"), history[0].ID)
+}
+
+func TestPromisedAmount(t *testing.T) {
+	assert.Equal(t, uint64(0), promisedAmount(promise.LastPromise{}, promise.LastPromise{}))
+	assert.Equal(t, uint64(50), promisedAmount(promise.LastPromise{SequenceID: 1, Amount: 10}, promise.LastPromise{SequenceID: 1, Amount: 60}))
+	assert.Equal(t, uint64(60), promisedAmount(promise.LastPromise{SequenceID: 1, Amount: 10}, promise.LastPromise{SequenceID: 2, Amount: 60}))
+}
+
+type stubPromiseFinder struct {
+	promise promise.StoredPromise
+}
+
+func (spf *stubPromiseFinder) FindPromiseForConsumer(consumerID, receiverID, issuerID identity.Identity) (promise.StoredPromise, error) {
+	if spf.promise.Message == nil {
+		return promise.StoredPromise{}, errors.New("not found")
+	}
+	sp := spf.promise
+	message := *spf.promise.Message
+	sp.Message = &message
+	return sp, nil
+}
+
+// stubRecordStorer keeps records in memory
+type stubRecordStorer struct {
+	records   map[ID]Record
+	getAllErr error
+}
+
+func newStubRecordStorer() *stubRecordStorer {
+	return &stubRecordStorer{records: make(map[ID]Record)}
+}
+
+func (srs *stubRecordStorer) Store(bucket string, object interface{}) error {
+	record := object.(*Record)
+	srs.records[record.ID] = *record
+	return nil
+}
+
+func (srs *stubRecordStorer) Update(bucket string, object interface{}) error {
+	record := object.(*Record)
+	if _, found := srs.records[record.ID]; !found {
+		return errors.New("not found")
+	}
+	srs.records[record.ID] = *record
+	return nil
+}
+
+func (srs *stubRecordStorer) GetOneByField(bucket string, fieldName string, key interface{}, to interface{}) error {
+	record, found := srs.records[key.(ID)]
+	if !found {
+		return errors.New("not found")
+	}
+	*to.(*Record) = record
+	return nil
+}
+
+func (srs *stubRecordStorer) GetAllFrom(bucket string, array interface{}) error {
+	if srs.getAllErr != nil {
+		return srs.getAllErr
+	}
+	records := array.(*[]Record)
+	for _, record := range srs.records {
+		*records = append(*records, record)
+	}
+	return nil
+}
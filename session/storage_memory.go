@@ -82,6 +82,17 @@ func (storage *StorageMemory) Remove(id ID) {
 	delete(storage.sessions, id)
 }
 
+// UpdateDataTransfer updates the data transfer counters of the given session
+func (storage *StorageMemory) UpdateDataTransfer(id ID, dataTransfer DataTransfer) {
+	storage.lock.Lock()
+	defer storage.lock.Unlock()
+
+	if instance, found := storage.sessions[id]; found {
+		instance.DataTransfer = dataTransfer
+		storage.sessions[id] = instance
+	}
+}
+
 // RemoveForService removes all sessions which belong to given service
 func (storage *StorageMemory) RemoveForService(serviceId string) {
 	sessions := storage.GetAll()
@@ -84,6 +84,17 @@ func TestStorage_GetAll(t *testing.T) {
 	assert.Contains(t, sessions, sessionSecond)
 }
 
+func TestStorage_UpdateDataTransfer(t *testing.T) {
+	storage := mockStorage(sessionExisting)
+	dataTransfer := DataTransfer{BytesSent: 1, BytesReceived: 2}
+
+	storage.UpdateDataTransfer(sessionExisting.ID, dataTransfer)
+	storage.UpdateDataTransfer(ID("unknown-id"), dataTransfer)
+
+	assert.Len(t, storage.sessions, 1)
+	assert.Equal(t, dataTransfer, storage.sessions[sessionExisting.ID].DataTransfer)
+}
+
 func TestStorage_Remove(t *testing.T) {
 	storage := mockStorage(sessionExisting)
 
@@ -0,0 +1,36 @@
+/*
+ * Copyright (C) 2019 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package shaper
+
+import (
+	"errors"
+	"net"
+
+	"github.com/mysteriumnetwork/node/datasize"
+)
+
+// ErrNotSupported indicates that bandwidth limits can not be enforced on the platform
+var ErrNotSupported = errors.New("bandwidth limits are not supported on this platform")
+
+// Shaper limits the bandwidth of the consumers served thru a single network interface
+type Shaper interface {
+	// Limit sets or changes the bandwidth limit per second in each direction of the consumer addressed by the given IPs
+	Limit(limit datasize.BitSize, ips ...net.IP) error
+	// Unlimit removes the bandwidth limit of the consumer addressed by the given IPs
+	Unlimit(ips ...net.IP) error
+}
@@ -0,0 +1,168 @@
+/*
+ * Copyright (C) 2019 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package shaper
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+
+	log "github.com/cihub/seelog"
+	"github.com/mysteriumnetwork/node/datasize"
+	"github.com/mysteriumnetwork/node/utils"
+)
+
+const (
+	shaperLogPrefix = "[shaper] "
+
+	tcBinary = "/sbin/tc"
+	// minBurst is the least burst which still lets the full sized packets thru the policer
+	minBurst = 16 * datasize.KB
+)
+
+// shaperTC queues the traffic sent to each consumer in a separate HTB class
+// and polices the traffic received from the consumer, because ingress traffic can not be queued.
+type shaperTC struct {
+	iface string
+	exec  func(args ...string) error
+
+	mu      sync.Mutex
+	started bool
+}
+
+func (shaper *shaperTC) Limit(limit datasize.BitSize, ips ...net.IP) error {
+	classID, handle, err := consumerIDs(ips)
+	if err != nil {
+		return err
+	}
+
+	shaper.mu.Lock()
+	defer shaper.mu.Unlock()
+
+	if err := shaper.start(); err != nil {
+		return err
+	}
+
+	rate := fmt.Sprintf("%dbit", limit.Bits())
+	burst := fmt.Sprintf("%d", uint64(burstSize(limit).Bytes()))
+	if err := shaper.tc("class", "replace", "dev", shaper.iface, "parent", "1:", "classid", classID, "htb", "rate", rate, "ceil", rate); err != nil {
+		return err
+	}
+	for _, ip := range ips {
+		protocol, prio := protocolOf(ip)
+		if err := shaper.tc("filter", "replace", "dev", shaper.iface, "parent", "1:", "protocol", protocol, "prio", prio, "handle", handle,
+			"flower", "dst_ip", ip.String(), "classid", classID); err != nil {
+			return err
+		}
+		// policer is shared by the index, so the IPv4 and IPv6 traffic of the consumer is limited together
+		if err := shaper.tc("filter", "replace", "dev", shaper.iface, "parent", "ffff:", "protocol", protocol, "prio", prio, "handle", handle,
+			"flower", "src_ip", ip.String(), "action", "police", "rate", rate, "burst", burst, "drop", "index", handle); err != nil {
+			return err
+		}
+	}
+
+	log.Info(shaperLogPrefix, "Bandwidth of ", ips, " limited to ", rate, " on ", shaper.iface)
+	return nil
+}
+
+func (shaper *shaperTC) Unlimit(ips ...net.IP) error {
+	classID, handle, err := consumerIDs(ips)
+	if err != nil {
+		return err
+	}
+
+	shaper.mu.Lock()
+	defer shaper.mu.Unlock()
+
+	if !shaper.started {
+		return nil
+	}
+
+	errs := utils.ErrorCollection{}
+	for _, ip := range ips {
+		protocol, prio := protocolOf(ip)
+		for _, parent := range []string{"1:", "ffff:"} {
+			errs.Add(shaper.tc("filter", "del", "dev", shaper.iface, "parent", parent, "protocol", protocol, "prio", prio, "handle", handle, "flower"))
+		}
+	}
+	errs.Add(shaper.tc("class", "del", "dev", shaper.iface, "classid", classID))
+
+	log.Info(shaperLogPrefix, "Bandwidth limit of ", ips, " removed on ", shaper.iface)
+	return errs.Errorf("failed to remove bandwidth limit: %s", ", ")
+}
+
+// start attaches the queueing disciplines to the interface, the traffic not matched by any consumer is not limited
+func (shaper *shaperTC) start() error {
+	if shaper.started {
+		return nil
+	}
+
+	if err := shaper.tc("qdisc", "replace", "dev", shaper.iface, "root", "handle", "1:", "htb"); err != nil {
+		return err
+	}
+	if err := shaper.tc("qdisc", "replace", "dev", shaper.iface, "handle", "ffff:", "ingress"); err != nil {
+		return err
+	}
+
+	shaper.started = true
+	return nil
+}
+
+func (shaper *shaperTC) tc(args ...string) error {
+	err := shaper.exec(append([]string{tcBinary}, args...)...)
+	if err != nil {
+		log.Warn(shaperLogPrefix, "Failed to shape traffic: ", err)
+	}
+	return err
+}
+
+// consumerIDs derives the class and filter handle of the consumer from the last bytes of its first IP,
+// which are unique for every consumer of the interface
+func consumerIDs(ips []net.IP) (classID, handle string, err error) {
+	if len(ips) == 0 {
+		return "", "", errors.New("consumer IP is required")
+	}
+
+	ip := ips[0].To16()
+	if ip == nil {
+		return "", "", errors.New("invalid consumer IP")
+	}
+
+	minor := uint16(ip[14])<<8 | uint16(ip[15])
+	if minor == 0 {
+		return "", "", errors.New("consumer IP can not be a network address")
+	}
+	return fmt.Sprintf("1:%x", minor), fmt.Sprintf("%d", minor), nil
+}
+
+func protocolOf(ip net.IP) (protocol, prio string) {
+	if ip.To4() != nil {
+		return "ip", "1"
+	}
+	return "ipv6", "2"
+}
+
+func burstSize(limit datasize.BitSize) datasize.BitSize {
+	// a tenth of a second of traffic may pass at once
+	burst := limit / 10
+	if burst < minBurst {
+		return minBurst
+	}
+	return burst
+}
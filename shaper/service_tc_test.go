@@ -0,0 +1,121 @@
+/*
+ * Copyright (C) 2019 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package shaper
+
+import (
+	"errors"
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/mysteriumnetwork/node/datasize"
+	"github.com/stretchr/testify/assert"
+)
+
+type execRecorder struct {
+	commands []string
+	err      error
+}
+
+func (er *execRecorder) exec(args ...string) error {
+	er.commands = append(er.commands, strings.Join(args, " "))
+	return er.err
+}
+
+func newTestShaper() (*shaperTC, *execRecorder) {
+	recorder := &execRecorder{}
+	return &shaperTC{iface: "myst0", exec: recorder.exec}, recorder
+}
+
+func Test_shaperTC_LimitAttachesQdiscsOnce(t *testing.T) {
+	shaper, recorder := newTestShaper()
+
+	assert.NoError(t, shaper.Limit(8*datasize.MB, net.ParseIP("10.182.1.2")))
+	assert.NoError(t, shaper.Limit(datasize.MB, net.ParseIP("10.182.1.2")))
+
+	assert.Equal(
+		t,
+		[]string{
+			"/sbin/tc qdisc replace dev myst0 root handle 1: htb",
+			"/sbin/tc qdisc replace dev myst0 handle ffff: ingress",
+			"/sbin/tc class replace dev myst0 parent 1: classid 1:102 htb rate 67108864bit ceil 67108864bit",
+			"/sbin/tc filter replace dev myst0 parent 1: protocol ip prio 1 handle 258 flower dst_ip 10.182.1.2 classid 1:102",
+			"/sbin/tc filter replace dev myst0 parent ffff: protocol ip prio 1 handle 258 flower src_ip 10.182.1.2 action police rate 67108864bit burst 838860 drop index 258",
+			"/sbin/tc class replace dev myst0 parent 1: classid 1:102 htb rate 8388608bit ceil 8388608bit",
+			"/sbin/tc filter replace dev myst0 parent 1: protocol ip prio 1 handle 258 flower dst_ip 10.182.1.2 classid 1:102",
+			"/sbin/tc filter replace dev myst0 parent ffff: protocol ip prio 1 handle 258 flower src_ip 10.182.1.2 action police rate 8388608bit burst 104857 drop index 258",
+		},
+		recorder.commands,
+	)
+}
+
+func Test_shaperTC_LimitSharesClassBetweenConsumerIPs(t *testing.T) {
+	shaper, recorder := newTestShaper()
+
+	assert.NoError(t, shaper.Limit(datasize.KB, net.ParseIP("10.182.1.2"), net.ParseIP("fd10:182:0:1::2")))
+
+	assert.Equal(
+		t,
+		[]string{
+			"/sbin/tc filter replace dev myst0 parent 1: protocol ipv6 prio 2 handle 258 flower dst_ip fd10:182:0:1::2 classid 1:102",
+			"/sbin/tc filter replace dev myst0 parent ffff: protocol ipv6 prio 2 handle 258 flower src_ip fd10:182:0:1::2 action police rate 8192bit burst 16384 drop index 258",
+		},
+		recorder.commands[5:],
+	)
+}
+
+func Test_shaperTC_UnlimitRemovesFiltersAndClass(t *testing.T) {
+	shaper, recorder := newTestShaper()
+	assert.NoError(t, shaper.Limit(datasize.MB, net.ParseIP("10.182.1.2")))
+	recorder.commands = nil
+
+	assert.NoError(t, shaper.Unlimit(net.ParseIP("10.182.1.2")))
+
+	assert.Equal(
+		t,
+		[]string{
+			"/sbin/tc filter del dev myst0 parent 1: protocol ip prio 1 handle 258 flower",
+			"/sbin/tc filter del dev myst0 parent ffff: protocol ip prio 1 handle 258 flower",
+			"/sbin/tc class del dev myst0 classid 1:102",
+		},
+		recorder.commands,
+	)
+}
+
+func Test_shaperTC_UnlimitDoesNothingIfNeverLimited(t *testing.T) {
+	shaper, recorder := newTestShaper()
+
+	assert.NoError(t, shaper.Unlimit(net.ParseIP("10.182.1.2")))
+	assert.Empty(t, recorder.commands)
+}
+
+func Test_shaperTC_LimitReturnsCommandError(t *testing.T) {
+	shaper, recorder := newTestShaper()
+	recorder.err = errors.New("tc not found")
+
+	assert.EqualError(t, shaper.Limit(datasize.MB, net.ParseIP("10.182.1.2")), "tc not found")
+	assert.False(t, shaper.started)
+}
+
+func Test_shaperTC_LimitRequiresConsumerIP(t *testing.T) {
+	shaper, recorder := newTestShaper()
+
+	assert.Error(t, shaper.Limit(datasize.MB))
+	assert.Error(t, shaper.Limit(datasize.MB, net.ParseIP("10.182.0.0")))
+	assert.Empty(t, recorder.commands)
+}
@@ -34,7 +34,7 @@ type tequilapiTestSuite struct {
 func (testSuite *tequilapiTestSuite) SetupSuite() {
 	listener, err := net.Listen("tcp", "localhost:0")
 	assert.Nil(testSuite.T(), err)
-	testSuite.server = NewServer(listener, NewAPIRouter(), RegexpCorsPolicy{})
+	testSuite.server = NewServer([]net.Listener{listener}, NewAPIRouter(), RegexpCorsPolicy{})
 
 	testSuite.server.StartServing()
 	address, err := testSuite.server.Address()
@@ -0,0 +1,207 @@
+/*
+ * Copyright (C) 2019 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/cihub/seelog"
+)
+
+const logPrefix = "[tequilapi-auth] "
+
+// TokenFilename is the name of the file in the node data directory which holds the API token
+const TokenFilename = "tequilapi.token"
+
+const tokenLength = 32
+
+// free login attempts after which every failed login doubles the time the next attempt has to wait
+const (
+	freeLoginAttempts = 3
+	loginBackoffStart = time.Second
+	loginBackoffMax   = 5 * time.Minute
+)
+
+var (
+	// ErrBadCredentials indicates that the given username or password does not match the configured ones
+	ErrBadCredentials = errors.New("bad credentials")
+	// ErrLoginDisabled indicates that the credentials are not configured, so the token can not be obtained by login
+	ErrLoginDisabled = errors.New("login is disabled, credentials are not configured")
+	// ErrTooManyAttempts indicates that the login is refused without checking the credentials after too many failed attempts
+	ErrTooManyAttempts = errors.New("too many failed login attempts, try again later")
+)
+
+// Credentials are exchanged for the API token at login
+type Credentials struct {
+	Username string
+	Password string
+}
+
+// Authenticator holds the API token which authorizes tequilapi clients
+type Authenticator struct {
+	tokenFile   string
+	credentials Credentials
+
+	token string
+	lock  sync.RWMutex
+
+	failedLogins   int
+	loginBlockedTo time.Time
+	loginLock      sync.Mutex
+	now            func() time.Time
+}
+
+// NewAuthenticator loads the API token stored in the given data directory, the token is generated if it is not stored yet
+func NewAuthenticator(dataDir string, credentials Credentials) (*Authenticator, error) {
+	authenticator := &Authenticator{
+		tokenFile:   filepath.Join(dataDir, TokenFilename),
+		credentials: credentials,
+		now:         time.Now,
+	}
+
+	token, err := LoadToken(dataDir)
+	if os.IsNotExist(err) {
+		log.Info(logPrefix, "Generating API token: ", authenticator.tokenFile)
+		_, err = authenticator.RotateToken()
+		return authenticator, err
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	authenticator.token = token
+	return authenticator, nil
+}
+
+// Authorized tells if the given token is the API token
+func (a *Authenticator) Authorized(token string) bool {
+	a.lock.RLock()
+	defer a.lock.RUnlock()
+
+	return a.token != "" && subtle.ConstantTimeCompare([]byte(token), []byte(a.token)) == 1
+}
+
+// Login returns the API token if the given credentials match the configured ones.
+// After a few failed attempts the logins are refused for an exponentially growing time.
+func (a *Authenticator) Login(username, password string) (string, error) {
+	if a.credentials.Username == "" || a.credentials.Password == "" {
+		return "", ErrLoginDisabled
+	}
+
+	a.loginLock.Lock()
+	defer a.loginLock.Unlock()
+
+	now := a.now()
+	if now.Before(a.loginBlockedTo) {
+		return "", ErrTooManyAttempts
+	}
+
+	usernameMatches := subtle.ConstantTimeCompare([]byte(username), []byte(a.credentials.Username)) == 1
+	passwordMatches := subtle.ConstantTimeCompare([]byte(password), []byte(a.credentials.Password)) == 1
+	if !usernameMatches || !passwordMatches {
+		a.failedLogins++
+		if a.failedLogins >= freeLoginAttempts {
+			a.loginBlockedTo = now.Add(loginBackoff(a.failedLogins - freeLoginAttempts))
+		}
+		log.Warn(logPrefix, "Login failed for user: ", username)
+		return "", ErrBadCredentials
+	}
+	a.failedLogins = 0
+
+	a.lock.RLock()
+	defer a.lock.RUnlock()
+
+	return a.token, nil
+}
+
+// RotateToken replaces the API token with a newly generated one, the clients using the old token have to obtain the new one
+func (a *Authenticator) RotateToken() (string, error) {
+	token, err := generateToken()
+	if err != nil {
+		return "", err
+	}
+
+	a.lock.Lock()
+	defer a.lock.Unlock()
+
+	if err := storeToken(a.tokenFile, token); err != nil {
+		return "", err
+	}
+	a.token = token
+	return token, nil
+}
+
+func loginBackoff(excessAttempts int) time.Duration {
+	backoff := loginBackoffStart
+	for i := 0; i < excessAttempts && backoff < loginBackoffMax; i++ {
+		backoff *= 2
+	}
+	if backoff > loginBackoffMax {
+		return loginBackoffMax
+	}
+	return backoff
+}
+
+// LoadToken reads the API token stored in the given data directory, clients running next to the node use it
+func LoadToken(dataDir string) (string, error) {
+	content, err := ioutil.ReadFile(filepath.Join(dataDir, TokenFilename))
+	if err != nil {
+		return "", err
+	}
+
+	token := strings.TrimSpace(string(content))
+	if token == "" {
+		return "", errors.New("API token file is empty: " + filepath.Join(dataDir, TokenFilename))
+	}
+	return token, nil
+}
+
+func generateToken() (string, error) {
+	token := make([]byte, tokenLength)
+	if _, err := rand.Read(token); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(token), nil
+}
+
+// storeToken replaces the token file at once, so that clients never read a partially written token
+func storeToken(path, token string) error {
+	tmpFile, err := ioutil.TempFile(filepath.Dir(path), TokenFilename)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmpFile.Name())
+
+	if _, err := tmpFile.WriteString(token); err != nil {
+		tmpFile.Close()
+		return err
+	}
+	if err := tmpFile.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmpFile.Name(), path)
+}
@@ -0,0 +1,147 @@
+/*
+ * Copyright (C) 2019 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package auth
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func tempDataDir(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "tequilapi-auth")
+	assert.NoError(t, err)
+	return dir
+}
+
+func TestNewAuthenticatorGeneratesAndStoresToken(t *testing.T) {
+	dir := tempDataDir(t)
+	defer os.RemoveAll(dir)
+
+	authenticator, err := NewAuthenticator(dir, Credentials{})
+	assert.NoError(t, err)
+
+	token, err := LoadToken(dir)
+	assert.NoError(t, err)
+	assert.Len(t, token, 2*tokenLength)
+	assert.True(t, authenticator.Authorized(token))
+	assert.False(t, authenticator.Authorized(""))
+	assert.False(t, authenticator.Authorized("wrong"))
+
+	info, err := os.Stat(filepath.Join(dir, TokenFilename))
+	assert.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+}
+
+func TestNewAuthenticatorLoadsStoredToken(t *testing.T) {
+	dir := tempDataDir(t)
+	defer os.RemoveAll(dir)
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, TokenFilename), []byte("stored-token\n"), 0600))
+
+	authenticator, err := NewAuthenticator(dir, Credentials{})
+	assert.NoError(t, err)
+	assert.True(t, authenticator.Authorized("stored-token"))
+}
+
+func TestRotateTokenReplacesToken(t *testing.T) {
+	dir := tempDataDir(t)
+	defer os.RemoveAll(dir)
+	authenticator, err := NewAuthenticator(dir, Credentials{})
+	assert.NoError(t, err)
+	oldToken, err := LoadToken(dir)
+	assert.NoError(t, err)
+
+	newToken, err := authenticator.RotateToken()
+	assert.NoError(t, err)
+
+	assert.NotEqual(t, oldToken, newToken)
+	assert.False(t, authenticator.Authorized(oldToken))
+	assert.True(t, authenticator.Authorized(newToken))
+	storedToken, err := LoadToken(dir)
+	assert.NoError(t, err)
+	assert.Equal(t, newToken, storedToken)
+}
+
+func TestLoginReturnsTokenForConfiguredCredentials(t *testing.T) {
+	dir := tempDataDir(t)
+	defer os.RemoveAll(dir)
+	authenticator, err := NewAuthenticator(dir, Credentials{Username: "myst", Password: "secret"})
+	assert.NoError(t, err)
+
+	token, err := authenticator.Login("myst", "secret")
+	assert.NoError(t, err)
+	assert.True(t, authenticator.Authorized(token))
+
+	_, err = authenticator.Login("myst", "wrong")
+	assert.Equal(t, ErrBadCredentials, err)
+}
+
+func TestLoginBacksOffAfterFailedAttempts(t *testing.T) {
+	dir := tempDataDir(t)
+	defer os.RemoveAll(dir)
+	authenticator, err := NewAuthenticator(dir, Credentials{Username: "myst", Password: "secret"})
+	assert.NoError(t, err)
+	now := time.Date(2019, 5, 1, 10, 0, 0, 0, time.UTC)
+	authenticator.now = func() time.Time { return now }
+
+	for i := 0; i < freeLoginAttempts; i++ {
+		_, err = authenticator.Login("myst", "wrong")
+		assert.Equal(t, ErrBadCredentials, err)
+	}
+
+	_, err = authenticator.Login("myst", "secret")
+	assert.Equal(t, ErrTooManyAttempts, err)
+
+	now = now.Add(loginBackoffStart)
+	_, err = authenticator.Login("myst", "wrong")
+	assert.Equal(t, ErrBadCredentials, err)
+
+	now = now.Add(loginBackoffStart)
+	_, err = authenticator.Login("myst", "secret")
+	assert.Equal(t, ErrTooManyAttempts, err)
+
+	now = now.Add(loginBackoffStart)
+	token, err := authenticator.Login("myst", "secret")
+	assert.NoError(t, err)
+	assert.True(t, authenticator.Authorized(token))
+
+	_, err = authenticator.Login("myst", "wrong")
+	assert.Equal(t, ErrBadCredentials, err)
+	_, err = authenticator.Login("myst", "secret")
+	assert.NoError(t, err)
+}
+
+func TestLoginBackoffIsCapped(t *testing.T) {
+	assert.Equal(t, loginBackoffStart, loginBackoff(0))
+	assert.Equal(t, 4*loginBackoffStart, loginBackoff(2))
+	assert.Equal(t, loginBackoffMax, loginBackoff(100))
+}
+
+func TestLoginIsDisabledWithoutCredentials(t *testing.T) {
+	dir := tempDataDir(t)
+	defer os.RemoveAll(dir)
+	authenticator, err := NewAuthenticator(dir, Credentials{})
+	assert.NoError(t, err)
+
+	_, err = authenticator.Login("", "")
+	assert.Equal(t, ErrLoginDisabled, err)
+}
@@ -18,9 +18,21 @@
 package client
 
 import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
 	"encoding/json"
 	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
 	"net/url"
+	"strconv"
+)
+
+const (
+	clientLogPrefix = "[Tequilapi.Client] "
+	clientUserAgent = "goclient-v0.1"
 )
 
 // NewClient returns a new instance of Client
@@ -28,17 +40,103 @@ func NewClient(ip string, port int) *Client {
 	return &Client{
 		http: newHTTPClient(
 			fmt.Sprintf("http://%s:%d", ip, port),
-			"[Tequilapi.Client] ",
-			"goclient-v0.1",
+			clientLogPrefix,
+			clientUserAgent,
 		),
 	}
 }
 
+// NewUnixClient returns a new instance of Client talking to tequilapi served on the Unix domain socket
+func NewUnixClient(socketPath string) *Client {
+	transport := &http.Transport{
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			var dialer net.Dialer
+			return dialer.DialContext(ctx, "unix", socketPath)
+		},
+	}
+	return &Client{
+		// host is not used to reach the socket, it only makes a valid URL
+		http: newHTTPClientWithTransport("http://tequilapi", clientLogPrefix, clientUserAgent, transport),
+	}
+}
+
+// NewTLSClient returns a new instance of Client talking to tequilapi served over HTTPS.
+// Server is verified against the certificates of the given PEM file, e.g. the self-signed one generated by the node,
+// or against the system roots if the file is not given.
+func NewTLSClient(ip string, port int, certFile string) (*Client, error) {
+	tlsConfig := &tls.Config{}
+	if certFile != "" {
+		certPEM, err := ioutil.ReadFile(certFile)
+		if err != nil {
+			return nil, err
+		}
+		tlsConfig.RootCAs = x509.NewCertPool()
+		if !tlsConfig.RootCAs.AppendCertsFromPEM(certPEM) {
+			return nil, fmt.Errorf("no certificates found in %s", certFile)
+		}
+	}
+
+	return &Client{
+		http: newHTTPClientWithTransport(
+			fmt.Sprintf("https://%s", net.JoinHostPort(ip, strconv.Itoa(port))),
+			clientLogPrefix,
+			clientUserAgent,
+			&http.Transport{TLSClientConfig: tlsConfig},
+		),
+	}, nil
+}
+
 // Client is able perform remote requests to Tequilapi server
 type Client struct {
 	http httpClientInterface
 }
 
+// SetToken authenticates the subsequent requests with the given API token
+func (client *Client) SetToken(token string) {
+	client.http.SetToken(token)
+}
+
+// Login exchanges credentials for the API token and authenticates the subsequent requests with it
+func (client *Client) Login(username, password string) (token string, err error) {
+	payload := struct {
+		Username string `json:"username"`
+		Password string `json:"password"`
+	}{
+		username,
+		password,
+	}
+	response, err := client.http.Post("auth/login", payload)
+	if err != nil {
+		return
+	}
+	defer response.Body.Close()
+
+	var tokenDTO AuthTokenDTO
+	if err = parseResponseJSON(response, &tokenDTO); err != nil {
+		return
+	}
+
+	client.http.SetToken(tokenDTO.Token)
+	return tokenDTO.Token, nil
+}
+
+// RotateToken replaces the API token on the node and authenticates the subsequent requests with the new one
+func (client *Client) RotateToken() (token string, err error) {
+	response, err := client.http.Post("auth/token", nil)
+	if err != nil {
+		return
+	}
+	defer response.Body.Close()
+
+	var tokenDTO AuthTokenDTO
+	if err = parseResponseJSON(response, &tokenDTO); err != nil {
+		return
+	}
+
+	client.http.SetToken(tokenDTO.Token)
+	return tokenDTO.Token, nil
+}
+
 // GetIdentities returns a list of client identities
 func (client *Client) GetIdentities() (ids []IdentityDTO, err error) {
 	response, err := client.http.Get("identities", url.Values{})
@@ -127,6 +225,27 @@ func (client *Client) Connect(consumerID, providerID, serviceType string, option
 	return status, err
 }
 
+// ConnectBySelector initiates a new connection to the best ranked provider matching the selector
+func (client *Client) ConnectBySelector(consumerID, serviceType string, selector ProposalSelectorDTO, options ConnectOptions) (status StatusDTO, err error) {
+	payload := struct {
+		Identity    string              `json:"consumerId"`
+		ServiceType string              `json:"serviceType"`
+		Selector    ProposalSelectorDTO `json:"proposalSelector"`
+		Options     ConnectOptions      `json:"connectOptions"`
+	}{
+		Identity:    consumerID,
+		ServiceType: serviceType,
+		Selector:    selector,
+		Options:     options,
+	}
+	response, err := client.http.Put("connection", payload)
+	if err != nil {
+		return StatusDTO{}, err
+	}
+	err = parseResponseJSON(response, &status)
+	return status, err
+}
+
 // Disconnect terminates current connection
 func (client *Client) Disconnect() (err error) {
 	response, err := client.http.Delete("connection", nil)
@@ -385,6 +504,33 @@ func (client *Client) ServiceSessions() (ServiceSessionListDTO, error) {
 	return sessions, err
 }
 
+// ServiceSessionsHistory returns a page of finished service sessions matching the given query,
+// e.g. consumerId, serviceType, from, to, page and pageSize
+func (client *Client) ServiceSessionsHistory(query url.Values) (ServiceSessionHistoryListDTO, error) {
+	history := ServiceSessionHistoryListDTO{}
+	response, err := client.http.Get("service-sessions/history", query)
+	if err != nil {
+		return history, err
+	}
+	defer response.Body.Close()
+
+	err = parseResponseJSON(response, &history)
+	return history, err
+}
+
+// ServiceEarnings returns amounts promised by consumers, aggregated per day and per consumer
+func (client *Client) ServiceEarnings() (ServiceEarningsDTO, error) {
+	earnings := ServiceEarningsDTO{}
+	response, err := client.http.Get("service-sessions/earnings", url.Values{})
+	if err != nil {
+		return earnings, err
+	}
+	defer response.Body.Close()
+
+	err = parseResponseJSON(response, &earnings)
+	return earnings, err
+}
+
 // filterSessionsByType removes all sessions of irrelevant types
 func filterSessionsByType(serviceType string, sessions ConnectionSessionListDTO) ConnectionSessionListDTO {
 	matches := 0
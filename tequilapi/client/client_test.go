@@ -18,10 +18,15 @@
 package client
 
 import (
+	"encoding/pem"
 	"errors"
 	"io"
+	"io/ioutil"
+	"net"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 
@@ -90,6 +95,88 @@ func TestConnectionErrorIsReturnedByClientInsteadOfDoubleParsing(t *testing.T) {
 	assert.True(t, responseBody.Closed)
 }
 
+func Test_Login_AuthenticatesSubsequentRequests(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/auth/login":
+			assert.Empty(t, r.Header.Get("Authorization"))
+			w.Write([]byte(`{"token": "api-token"}`))
+		case "/nat/status":
+			assert.Equal(t, "Bearer api-token", r.Header.Get("Authorization"))
+			w.Write([]byte(`{"status": "successful"}`))
+		default:
+			t.Errorf("unexpected request: %s", r.URL.Path)
+		}
+	}))
+	defer server.Close()
+	client := Client{http: newHTTPClient(server.URL, "", "")}
+
+	token, err := client.Login("myst", "secret")
+	assert.NoError(t, err)
+	assert.Equal(t, "api-token", token)
+
+	_, err = client.NATStatus()
+	assert.NoError(t, err)
+}
+
+func Test_Login_ReturnsError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+		w.Write([]byte(`{"message": "bad credentials"}`))
+	}))
+	defer server.Close()
+	client := Client{http: newHTTPClient(server.URL, "", "")}
+
+	_, err := client.Login("myst", "wrong")
+	assert.Error(t, err)
+}
+
+func Test_NewUnixClient_RequestsOverSocket(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tequilapi-client")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	socketPath := filepath.Join(dir, "tequilapi.sock")
+
+	listener, err := net.Listen("unix", socketPath)
+	assert.NoError(t, err)
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/nat/status", r.URL.Path)
+		w.Write([]byte(`{"status": "successful"}`))
+	}))
+	server.Listener = listener
+	server.Start()
+	defer server.Close()
+
+	status, err := NewUnixClient(socketPath).NATStatus()
+	assert.NoError(t, err)
+	assert.Equal(t, "successful", status.Status)
+}
+
+func Test_NewTLSClient_VerifiesServerWithCertificateFile(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"status": "successful"}`))
+	}))
+	defer server.Close()
+	addr := server.Listener.Addr().(*net.TCPAddr)
+
+	certFile, err := ioutil.TempFile("", "tequilapi-client")
+	assert.NoError(t, err)
+	defer os.Remove(certFile.Name())
+	assert.NoError(t, pem.Encode(certFile, &pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw}))
+	assert.NoError(t, certFile.Close())
+
+	client, err := NewTLSClient(addr.IP.String(), addr.Port, certFile.Name())
+	assert.NoError(t, err)
+	status, err := client.NATStatus()
+	assert.NoError(t, err)
+	assert.Equal(t, "successful", status.Status)
+
+	untrusting, err := NewTLSClient(addr.IP.String(), addr.Port, "")
+	assert.NoError(t, err)
+	_, err = untrusting.NATStatus()
+	assert.Error(t, err)
+}
+
 func mockHTTPClient(t *testing.T, method, url string, statusCode int, response string) httpClientInterface {
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		assert.Equal(t, method, r.Method)
@@ -24,9 +24,18 @@ import (
 
 // StatusDTO holds connection status and session id
 type StatusDTO struct {
-	Status    string      `json:"status"`
-	SessionID string      `json:"sessionId"`
-	Proposal  ProposalDTO `json:"proposal"`
+	Status           string      `json:"status"`
+	SessionID        string      `json:"sessionId"`
+	Proposal         ProposalDTO `json:"proposal"`
+	ReconnectAttempt int         `json:"reconnectAttempt"`
+	KillSwitch       bool        `json:"killSwitch"`
+	Routes           *RoutesDTO  `json:"routes,omitempty"`
+}
+
+// RoutesDTO holds the effective route set of the split tunnel
+type RoutesDTO struct {
+	Include []string `json:"include,omitempty"`
+	Exclude []string `json:"exclude,omitempty"`
 }
 
 // StatisticsDTO holds statistics about connection
@@ -47,6 +56,19 @@ type ProposalDTO struct {
 	ProviderID        string               `json:"providerId"`
 	ServiceType       string               `json:"serviceType"`
 	ServiceDefinition ServiceDefinitionDTO `json:"serviceDefinition"`
+	PaymentMethod     *PaymentMethodDTO    `json:"paymentMethod,omitempty"`
+}
+
+// PaymentMethodDTO describes price of the proposed service
+type PaymentMethodDTO struct {
+	Type  string   `json:"type"`
+	Price MoneyDTO `json:"price"`
+}
+
+// MoneyDTO describes amount of the money in the given currency
+type MoneyDTO struct {
+	Amount   uint64 `json:"amount"`
+	Currency string `json:"currency"`
 }
 
 func (p ProposalDTO) String() string {
@@ -63,6 +85,11 @@ type LocationDTO struct {
 	Country string `json:"country"`
 }
 
+// AuthTokenDTO holds the API token
+type AuthTokenDTO struct {
+	Token string `json:"token"`
+}
+
 // IdentityDTO holds identity address
 type IdentityDTO struct {
 	Address string `json:"id"`
@@ -110,7 +137,30 @@ type SignatureDTO struct {
 
 // ConnectOptions copied from tequilapi endpoint
 type ConnectOptions struct {
-	DisableKillSwitch bool `json:"killSwitch"`
+	DisableKillSwitch bool                   `json:"killSwitch"`
+	Reconnect         *ReconnectOptionsDTO   `json:"reconnect,omitempty"`
+	Split             *SplitTunnelOptionsDTO `json:"split,omitempty"`
+}
+
+// SplitTunnelOptionsDTO copied from tequilapi endpoint
+type SplitTunnelOptionsDTO struct {
+	Include []string `json:"include,omitempty"`
+	Exclude []string `json:"exclude,omitempty"`
+}
+
+// ReconnectOptionsDTO copied from tequilapi endpoint
+type ReconnectOptionsDTO struct {
+	MaxAttempts    int  `json:"maxAttempts"`
+	BackoffSeconds int  `json:"backoffSeconds"`
+	Failover       bool `json:"failover"`
+}
+
+// ProposalSelectorDTO copied from tequilapi endpoint
+type ProposalSelectorDTO struct {
+	Country           string  `json:"country,omitempty"`
+	PaymentMethodType string  `json:"paymentMethodType,omitempty"`
+	PriceMax          uint64  `json:"priceMax,omitempty"`
+	QualityMin        float64 `json:"qualityMin,omitempty"`
 }
 
 // ConnectionSessionListDTO copied from tequilapi endpoint
@@ -151,8 +201,46 @@ type ServiceSessionListDTO struct {
 
 // ServiceSessionDTO copied from tequilapi endpoint
 type ServiceSessionDTO struct {
-	ID         string `json:"id"`
-	ConsumerID string `json:"consumerId"`
+	ID            string `json:"id"`
+	ConsumerID    string `json:"consumerId"`
+	BytesSent     uint64 `json:"bytesSent"`
+	BytesReceived uint64 `json:"bytesReceived"`
+}
+
+// ServiceSessionHistoryListDTO copied from tequilapi endpoint
+type ServiceSessionHistoryListDTO struct {
+	Sessions   []ServiceSessionHistoryDTO `json:"sessions"`
+	Page       int                        `json:"page"`
+	PageSize   int                        `json:"pageSize"`
+	TotalCount int                        `json:"totalCount"`
+}
+
+// ServiceSessionHistoryDTO copied from tequilapi endpoint
+type ServiceSessionHistoryDTO struct {
+	ID                    string `json:"id"`
+	ConsumerID            string `json:"consumerId"`
+	ServiceType           string `json:"serviceType"`
+	DateStarted           string `json:"dateStarted"`
+	DateEnded             string `json:"dateEnded"`
+	Duration              uint64 `json:"duration"`
+	BytesSent             uint64 `json:"bytesSent"`
+	BytesReceived         uint64 `json:"bytesReceived"`
+	PromisedAmount        uint64 `json:"promisedAmount"`
+	LastPromiseSequenceID uint64 `json:"lastPromiseSequenceId"`
+	TerminationReason     string `json:"terminationReason"`
+}
+
+// ServiceEarningsDTO copied from tequilapi endpoint
+type ServiceEarningsDTO struct {
+	Total uint64 `json:"total"`
+	Daily []struct {
+		Date   string `json:"date"`
+		Amount uint64 `json:"amount"`
+	} `json:"daily"`
+	Consumers []struct {
+		ConsumerID string `json:"consumerId"`
+		Amount     uint64 `json:"amount"`
+	} `json:"consumers"`
 }
 
 // AccessPoliciesRequest represents the access controls for service start
@@ -165,3 +253,22 @@ type NATStatusDTO struct {
 	Status string `json:"status"`
 	Error  string `json:"error,omitempty"`
 }
+
+// NATEventDTO copied from tequilapi endpoint
+type NATEventDTO struct {
+	Stage      string `json:"stage"`
+	Successful bool   `json:"successful"`
+	Error      string `json:"error,omitempty"`
+}
+
+// EventDTO holds the node event streamed by tequilapi, data holds the JSON of the topic specific DTO:
+// StatusDTO, StatisticsDTO, NATEventDTO or ServiceInfoDTO
+type EventDTO struct {
+	Topic string
+	Data  json.RawMessage
+}
+
+// Decode decodes data of the event to the topic specific DTO
+func (event EventDTO) Decode(dto interface{}) error {
+	return json.Unmarshal(event.Data, dto)
+}
@@ -0,0 +1,94 @@
+/*
+ * Copyright (C) 2019 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package client
+
+import (
+	"bufio"
+	"io"
+	"net/url"
+	"strings"
+	"sync"
+)
+
+// Topics of the events streamed by tequilapi
+const (
+	// EventTopicConnectionState carries StatusDTO
+	EventTopicConnectionState = "connection-state"
+	// EventTopicConnectionStatistics carries StatisticsDTO
+	EventTopicConnectionStatistics = "connection-statistics"
+	// EventTopicNAT carries NATEventDTO
+	EventTopicNAT = "nat"
+	// EventTopicServiceStop carries ServiceInfoDTO
+	EventTopicServiceStop = "service-stop"
+)
+
+// Events subscribes to the node events of the given topics, events of all the topics are streamed if none are given.
+// Events are delivered until the node closes the stream or the returned stop function is called, the channel is closed afterwards.
+func (client *Client) Events(topics ...string) (events <-chan EventDTO, stop func(), err error) {
+	values := url.Values{}
+	if len(topics) > 0 {
+		values.Set("topics", strings.Join(topics, ","))
+	}
+
+	response, err := client.http.Stream("events", values)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	eventChan := make(chan EventDTO)
+	done := make(chan struct{})
+	go readEvents(response.Body, eventChan, done)
+
+	var once sync.Once
+	stop = func() {
+		once.Do(func() {
+			close(done)
+			response.Body.Close()
+		})
+	}
+	return eventChan, stop, nil
+}
+
+// readEvents parses server-sent events of the stream, the comments are skipped
+func readEvents(stream io.Reader, events chan<- EventDTO, done <-chan struct{}) {
+	defer close(events)
+
+	var event EventDTO
+	var data []string
+	scanner := bufio.NewScanner(stream)
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case line == "":
+			if len(data) == 0 {
+				continue
+			}
+			event.Data = []byte(strings.Join(data, "\n"))
+			select {
+			case events <- event:
+			case <-done:
+				return
+			}
+			event, data = EventDTO{}, nil
+		case strings.HasPrefix(line, "event:"):
+			event.Topic = strings.TrimSpace(strings.TrimPrefix(line, "event:"))
+		case strings.HasPrefix(line, "data:"):
+			data = append(data, strings.TrimPrefix(strings.TrimPrefix(line, "data:"), " "))
+		}
+	}
+}
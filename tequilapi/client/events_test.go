@@ -0,0 +1,71 @@
+/*
+ * Copyright (C) 2019 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package client
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Events_StreamsEventsOfRequestedTopics(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/events", r.URL.Path)
+		assert.Equal(t, "connection-state,nat", r.URL.Query().Get("topics"))
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, ": keep-alive\n\n")
+		fmt.Fprint(w, "event: connection-state\ndata: {\"status\":\"Connected\",\"sessionId\":\"session1\"}\n\n")
+		fmt.Fprint(w, "event: nat\ndata: {\"stage\":\"hole_punching\",\"successful\":true}\n\n")
+	}))
+	defer server.Close()
+	client := Client{http: newHTTPClient(server.URL, "", "")}
+
+	events, stop, err := client.Events(EventTopicConnectionState, EventTopicNAT)
+	assert.NoError(t, err)
+	defer stop()
+
+	event := <-events
+	assert.Equal(t, EventTopicConnectionState, event.Topic)
+	var status StatusDTO
+	assert.NoError(t, event.Decode(&status))
+	assert.Equal(t, StatusDTO{Status: "Connected", SessionID: "session1"}, status)
+
+	event = <-events
+	assert.Equal(t, EventTopicNAT, event.Topic)
+	var natEvent NATEventDTO
+	assert.NoError(t, event.Decode(&natEvent))
+	assert.Equal(t, NATEventDTO{Stage: "hole_punching", Successful: true}, natEvent)
+
+	_, open := <-events
+	assert.False(t, open)
+}
+
+func Test_Events_ReturnsError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		fmt.Fprint(w, `{"message": "unknown topic"}`)
+	}))
+	defer server.Close()
+	client := Client{http: newHTTPClient(server.URL, "", "")}
+
+	_, _, err := client.Events("foo")
+	assert.Error(t, err)
+}
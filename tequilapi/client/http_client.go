@@ -24,6 +24,7 @@ import (
 	"io/ioutil"
 	"net/http"
 	"net/url"
+	"sync"
 	"time"
 
 	log "github.com/cihub/seelog"
@@ -34,6 +35,8 @@ type httpClientInterface interface {
 	Post(path string, payload interface{}) (*http.Response, error)
 	Put(path string, payload interface{}) (*http.Response, error)
 	Delete(path string, payload interface{}) (*http.Response, error)
+	Stream(path string, values url.Values) (*http.Response, error)
+	SetToken(token string)
 }
 
 type httpRequestInterface interface {
@@ -41,11 +44,19 @@ type httpRequestInterface interface {
 }
 
 func newHTTPClient(baseURL string, logPrefix string, ua string) *httpClient {
+	return newHTTPClientWithTransport(baseURL, logPrefix, ua, &http.Transport{})
+}
+
+func newHTTPClientWithTransport(baseURL string, logPrefix string, ua string, transport *http.Transport) *httpClient {
 	return &httpClient{
 		http: &http.Client{
-			Transport: &http.Transport{},
+			Transport: transport,
 			Timeout:   time.Second * 120,
 		},
+		// streamed responses are read as long as the client needs them, so they are not limited by timeout
+		stream: &http.Client{
+			Transport: transport,
+		},
 		baseURL:   baseURL,
 		logPrefix: logPrefix,
 		ua:        ua,
@@ -54,22 +65,25 @@ func newHTTPClient(baseURL string, logPrefix string, ua string) *httpClient {
 
 type httpClient struct {
 	http      httpRequestInterface
+	stream    httpRequestInterface
 	baseURL   string
 	logPrefix string
 	ua        string
+
+	token     string
+	tokenLock sync.RWMutex
 }
 
-func (client *httpClient) Get(path string, values url.Values) (*http.Response, error) {
-	basePath := fmt.Sprintf("%v/%v", client.baseURL, path)
+// SetToken sets the API token sent with the subsequent requests
+func (client *httpClient) SetToken(token string) {
+	client.tokenLock.Lock()
+	defer client.tokenLock.Unlock()
 
-	var fullPath string
-	params := values.Encode()
-	if params == "" {
-		fullPath = basePath
-	} else {
-		fullPath = fmt.Sprintf("%v?%v", basePath, params)
-	}
-	return client.executeRequest("GET", fullPath, nil)
+	client.token = token
+}
+
+func (client *httpClient) Get(path string, values url.Values) (*http.Response, error) {
+	return client.executeRequest("GET", client.fullPath(path, values), nil)
 }
 
 func (client *httpClient) Post(path string, payload interface{}) (*http.Response, error) {
@@ -84,7 +98,31 @@ func (client *httpClient) Delete(path string, payload interface{}) (*http.Respon
 	return client.doPayloadRequest("DELETE", path, payload)
 }
 
-func (client httpClient) doPayloadRequest(method, path string, payload interface{}) (*http.Response, error) {
+// Stream requests the resource which streams its response, the caller reads the body until it is done and closes it
+func (client *httpClient) Stream(path string, values url.Values) (*http.Response, error) {
+	request, err := http.NewRequest("GET", client.fullPath(path, values), nil)
+	if err != nil {
+		log.Critical(client.logPrefix, err)
+		return nil, err
+	}
+	request.Header.Set("User-Agent", client.ua)
+	request.Header.Set("Accept", "text/event-stream")
+	client.authorize(request)
+
+	return client.do(client.stream, request)
+}
+
+func (client *httpClient) fullPath(path string, values url.Values) string {
+	basePath := fmt.Sprintf("%v/%v", client.baseURL, path)
+
+	params := values.Encode()
+	if params == "" {
+		return basePath
+	}
+	return fmt.Sprintf("%v?%v", basePath, params)
+}
+
+func (client *httpClient) doPayloadRequest(method, path string, payload interface{}) (*http.Response, error) {
 	payloadJSON, err := json.Marshal(payload)
 	if err != nil {
 		log.Critical(client.logPrefix, err)
@@ -103,8 +141,22 @@ func (client *httpClient) executeRequest(method, fullPath string, payloadJSON []
 	request.Header.Set("User-Agent", client.ua)
 	request.Header.Set("Content-Type", "application/json")
 	request.Header.Set("Accept", "application/json")
+	client.authorize(request)
+
+	return client.do(client.http, request)
+}
+
+func (client *httpClient) authorize(request *http.Request) {
+	client.tokenLock.RLock()
+	defer client.tokenLock.RUnlock()
+
+	if client.token != "" {
+		request.Header.Set("Authorization", "Bearer "+client.token)
+	}
+}
 
-	response, err := client.http.Do(request)
+func (client *httpClient) do(doer httpRequestInterface, request *http.Request) (*http.Response, error) {
+	response, err := doer.Do(request)
 
 	if err != nil {
 		log.Error(client.logPrefix, err)
@@ -0,0 +1,165 @@
+/*
+ * Copyright (C) 2019 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package endpoints
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/mysteriumnetwork/node/tequilapi/auth"
+	"github.com/mysteriumnetwork/node/tequilapi/utils"
+	"github.com/mysteriumnetwork/node/tequilapi/validation"
+)
+
+// Authenticator issues the API token to tequilapi clients
+type Authenticator interface {
+	Login(username, password string) (string, error)
+	RotateToken() (string, error)
+}
+
+// swagger:model LoginRequestDTO
+type loginRequest struct {
+	// example: myst
+	Username string `json:"username"`
+
+	// example: mystberry
+	Password string `json:"password"`
+}
+
+// swagger:model AuthTokenDTO
+type tokenResponse struct {
+	// API token to be sent in Authorization header as "Bearer <token>"
+	// example: 9d2c8c7a4d0f4b0e8a0e6b7c5f1d2e3a9d2c8c7a4d0f4b0e8a0e6b7c5f1d2e3a
+	Token string `json:"token"`
+}
+
+// AuthEndpoint struct represents /auth resource and it's subresources
+type AuthEndpoint struct {
+	authenticator Authenticator
+}
+
+// NewAuthEndpoint creates and returns auth endpoint
+func NewAuthEndpoint(authenticator Authenticator) *AuthEndpoint {
+	return &AuthEndpoint{authenticator: authenticator}
+}
+
+// Login exchanges credentials for the API token
+// swagger:operation POST /auth/login Authentication login
+// ---
+// summary: Returns API token
+// description: Exchanges credentials configured on the node for the API token, the request does not require the token itself
+// parameters:
+//   - in: body
+//     name: body
+//     description: Credentials configured on the node
+//     schema:
+//       $ref: "#/definitions/LoginRequestDTO"
+// responses:
+//   200:
+//     description: API token
+//     schema:
+//       "$ref": "#/definitions/AuthTokenDTO"
+//   400:
+//     description: Body parsing error
+//     schema:
+//       "$ref": "#/definitions/ErrorMessageDTO"
+//   401:
+//     description: Bad credentials
+//     schema:
+//       "$ref": "#/definitions/ErrorMessageDTO"
+//   403:
+//     description: Login is disabled, credentials are not configured on the node
+//     schema:
+//       "$ref": "#/definitions/ErrorMessageDTO"
+//   422:
+//     description: Parameters validation error
+//     schema:
+//       "$ref": "#/definitions/ValidationErrorDTO"
+//   429:
+//     description: Too many failed login attempts, login is refused for a while
+//     schema:
+//       "$ref": "#/definitions/ErrorMessageDTO"
+func (ae *AuthEndpoint) Login(resp http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	var request loginRequest
+	if err := json.NewDecoder(req.Body).Decode(&request); err != nil {
+		utils.SendError(resp, err, http.StatusBadRequest)
+		return
+	}
+
+	errorMap := validateLoginRequest(request)
+	if errorMap.HasErrors() {
+		utils.SendValidationErrorMessage(resp, errorMap)
+		return
+	}
+
+	token, err := ae.authenticator.Login(request.Username, request.Password)
+	switch err {
+	case nil:
+		utils.WriteAsJSON(tokenResponse{Token: token}, resp)
+	case auth.ErrBadCredentials:
+		utils.SendError(resp, err, http.StatusUnauthorized)
+	case auth.ErrLoginDisabled:
+		utils.SendError(resp, err, http.StatusForbidden)
+	case auth.ErrTooManyAttempts:
+		utils.SendError(resp, err, http.StatusTooManyRequests)
+	default:
+		utils.SendError(resp, err, http.StatusInternalServerError)
+	}
+}
+
+// RotateToken replaces the API token
+// swagger:operation POST /auth/token Authentication rotateToken
+// ---
+// summary: Rotates API token
+// description: Replaces the API token with a new one, the old token is no longer accepted
+// responses:
+//   200:
+//     description: New API token
+//     schema:
+//       "$ref": "#/definitions/AuthTokenDTO"
+//   500:
+//     description: Internal server error
+//     schema:
+//       "$ref": "#/definitions/ErrorMessageDTO"
+func (ae *AuthEndpoint) RotateToken(resp http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+	token, err := ae.authenticator.RotateToken()
+	if err != nil {
+		utils.SendError(resp, err, http.StatusInternalServerError)
+		return
+	}
+	utils.WriteAsJSON(tokenResponse{Token: token}, resp)
+}
+
+// AddRoutesForAuth adds auth routes to given router
+func AddRoutesForAuth(router *httprouter.Router, authenticator Authenticator) {
+	authEndpoint := NewAuthEndpoint(authenticator)
+	router.POST("/auth/login", authEndpoint.Login)
+	router.POST("/auth/token", authEndpoint.RotateToken)
+}
+
+func validateLoginRequest(request loginRequest) *validation.FieldErrorMap {
+	errors := validation.NewErrorMap()
+	if request.Username == "" {
+		errors.ForField("username").AddError("required", "Field is required")
+	}
+	if request.Password == "" {
+		errors.ForField("password").AddError("required", "Field is required")
+	}
+	return errors
+}
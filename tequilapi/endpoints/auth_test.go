@@ -0,0 +1,136 @@
+/*
+ * Copyright (C) 2019 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package endpoints
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/mysteriumnetwork/node/tequilapi/auth"
+	"github.com/stretchr/testify/assert"
+)
+
+type authenticatorFake struct {
+	token     string
+	loginErr  error
+	rotateErr error
+}
+
+func (fake *authenticatorFake) Login(username, password string) (string, error) {
+	if fake.loginErr != nil {
+		return "", fake.loginErr
+	}
+	if username != "myst" || password != "secret" {
+		return "", auth.ErrBadCredentials
+	}
+	return fake.token, nil
+}
+
+func (fake *authenticatorFake) RotateToken() (string, error) {
+	if fake.rotateErr != nil {
+		return "", fake.rotateErr
+	}
+	fake.token = "rotated-token"
+	return fake.token, nil
+}
+
+func loginRequestFor(body string) *http.Request {
+	return httptest.NewRequest(http.MethodPost, "/auth/login", strings.NewReader(body))
+}
+
+func TestLoginReturnsToken(t *testing.T) {
+	authEndpoint := NewAuthEndpoint(&authenticatorFake{token: "token"})
+	resp := httptest.NewRecorder()
+
+	authEndpoint.Login(resp, loginRequestFor(`{"username": "myst", "password": "secret"}`), httprouter.Params{})
+
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.JSONEq(t, `{"token": "token"}`, resp.Body.String())
+}
+
+func TestLoginFailsWithBadCredentials(t *testing.T) {
+	authEndpoint := NewAuthEndpoint(&authenticatorFake{token: "token"})
+	resp := httptest.NewRecorder()
+
+	authEndpoint.Login(resp, loginRequestFor(`{"username": "myst", "password": "wrong"}`), httprouter.Params{})
+
+	assert.Equal(t, http.StatusUnauthorized, resp.Code)
+	assert.JSONEq(t, `{"message": "bad credentials"}`, resp.Body.String())
+}
+
+func TestLoginFailsWhenDisabled(t *testing.T) {
+	authEndpoint := NewAuthEndpoint(&authenticatorFake{loginErr: auth.ErrLoginDisabled})
+	resp := httptest.NewRecorder()
+
+	authEndpoint.Login(resp, loginRequestFor(`{"username": "myst", "password": "secret"}`), httprouter.Params{})
+
+	assert.Equal(t, http.StatusForbidden, resp.Code)
+}
+
+func TestLoginFailsAfterTooManyAttempts(t *testing.T) {
+	authEndpoint := NewAuthEndpoint(&authenticatorFake{loginErr: auth.ErrTooManyAttempts})
+	resp := httptest.NewRecorder()
+
+	authEndpoint.Login(resp, loginRequestFor(`{"username": "myst", "password": "secret"}`), httprouter.Params{})
+
+	assert.Equal(t, http.StatusTooManyRequests, resp.Code)
+}
+
+func TestLoginValidatesCredentials(t *testing.T) {
+	authEndpoint := NewAuthEndpoint(&authenticatorFake{})
+	resp := httptest.NewRecorder()
+
+	authEndpoint.Login(resp, loginRequestFor(`{}`), httprouter.Params{})
+
+	assert.Equal(t, http.StatusUnprocessableEntity, resp.Code)
+	assert.JSONEq(
+		t,
+		`{
+			"message": "validation_error",
+			"errors" : {
+				"username": [ {"code" : "required" , "message" : "Field is required" } ],
+				"password": [ {"code" : "required" , "message" : "Field is required" } ]
+			}
+		}`,
+		resp.Body.String(),
+	)
+}
+
+func TestRotateTokenReturnsNewToken(t *testing.T) {
+	authEndpoint := NewAuthEndpoint(&authenticatorFake{token: "token"})
+	resp := httptest.NewRecorder()
+
+	authEndpoint.RotateToken(resp, httptest.NewRequest(http.MethodPost, "/auth/token", nil), httprouter.Params{})
+
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.JSONEq(t, `{"token": "rotated-token"}`, resp.Body.String())
+}
+
+func TestRotateTokenReturnsError(t *testing.T) {
+	authEndpoint := NewAuthEndpoint(&authenticatorFake{rotateErr: errors.New("disk full")})
+	resp := httptest.NewRecorder()
+
+	authEndpoint.RotateToken(resp, httptest.NewRequest(http.MethodPost, "/auth/token", nil), httprouter.Params{})
+
+	assert.Equal(t, http.StatusInternalServerError, resp.Code)
+	assert.JSONEq(t, `{"message": "disk full"}`, resp.Body.String())
+}
@@ -20,13 +20,16 @@ package endpoints
 import (
 	"encoding/json"
 	"errors"
+	"net"
 	"net/http"
 	"time"
 
 	log "github.com/cihub/seelog"
 	"github.com/julienschmidt/httprouter"
 	"github.com/mysteriumnetwork/node/consumer"
+	"github.com/mysteriumnetwork/node/consumer/selector"
 	"github.com/mysteriumnetwork/node/core/connection"
+	"github.com/mysteriumnetwork/node/core/connection/split"
 	"github.com/mysteriumnetwork/node/core/ip"
 	"github.com/mysteriumnetwork/node/identity"
 	"github.com/mysteriumnetwork/node/market"
@@ -45,6 +48,71 @@ type ConnectOptions struct {
 	// required: false
 	// example: true
 	DisableKillSwitch bool `json:"killSwitch"`
+
+	// reconnect policy applied when established connection is lost
+	// required: false
+	Reconnect *ReconnectOptions `json:"reconnect,omitempty"`
+
+	// destinations routed thru or around the tunnel, all the traffic is routed thru the tunnel if not given
+	// required: false
+	Split *SplitTunnelOptions `json:"split,omitempty"`
+}
+
+// SplitTunnelOptions holds tequilapi split tunnel options, destinations are CIDRs, IPs or domains resolved on connect
+// swagger:model SplitTunnelOptionsDTO
+type SplitTunnelOptions struct {
+	// destinations routed thru the tunnel, the rest of the traffic bypasses it
+	// required: false
+	// example: ["10.8.0.0/16", "example.com"]
+	Include []string `json:"include,omitempty"`
+
+	// destinations routed around the tunnel, IPv6 networks can not be excluded
+	// required: false
+	// example: ["192.168.1.0/24"]
+	Exclude []string `json:"exclude,omitempty"`
+}
+
+// ReconnectOptions holds tequilapi reconnect options
+// swagger:model ReconnectOptionsDTO
+type ReconnectOptions struct {
+	// number of reconnect attempts, 0 disables reconnecting
+	// required: false
+	// example: 3
+	MaxAttempts int `json:"maxAttempts"`
+
+	// delay in seconds before the first reconnect attempt, doubled on every subsequent attempt
+	// required: false
+	// example: 5
+	BackoffSeconds int `json:"backoffSeconds"`
+
+	// if true, reconnect to another provider of the same service type when the original one is unreachable
+	// required: false
+	// example: true
+	Failover bool `json:"failover"`
+}
+
+// SelectorOptions holds tequilapi options of the proposal selection
+// swagger:model ProposalSelectorDTO
+type SelectorOptions struct {
+	// country code of the provider location
+	// required: false
+	// example: NL
+	Country string `json:"country,omitempty"`
+
+	// payment method of the proposal, required with priceMax
+	// required: false
+	// example: PER_GB
+	PaymentMethodType string `json:"paymentMethodType,omitempty"`
+
+	// highest acceptable price amount of the proposals of paymentMethodType
+	// required: false
+	// example: 50000000
+	PriceMax uint64 `json:"priceMax,omitempty"`
+
+	// lowest acceptable connection success rate (from 0 to 1), as measured by the quality oracle
+	// required: false
+	// example: 0.5
+	QualityMin float64 `json:"qualityMin,omitempty"`
 }
 
 // swagger:model ConnectionRequestDTO
@@ -54,11 +122,15 @@ type connectionRequest struct {
 	// example: 0x0000000000000000000000000000000000000001
 	ConsumerID string `json:"consumerId"`
 
-	// provider identity
-	// required: true
+	// provider identity, required unless the proposal selector is given
+	// required: false
 	// example: 0x0000000000000000000000000000000000000002
 	ProviderID string `json:"providerId"`
 
+	// selects the best ranked proposal of the given service type to connect to, used when provider identity is not given
+	// required: false
+	ProposalSelector *SelectorOptions `json:"proposalSelector,omitempty"`
+
 	// service type. Possible values are "openvpn", "wireguard" and "noop"
 	// required: false
 	// default: openvpn
@@ -72,6 +144,10 @@ type connectionRequest struct {
 
 // swagger:model ConnectionStatusDTO
 type connectionResponse struct {
+	// connection ID, set for the connections listed under /connections
+	// example: 1
+	ID string `json:"id,omitempty"`
+
 	// example: Connected
 	Status string `json:"status"`
 
@@ -80,6 +156,28 @@ type connectionResponse struct {
 
 	// example: {"id":1,"providerId":"0x71ccbdee7f6afe85a5bc7106323518518cd23b94","serviceType":"openvpn","serviceDefinition":{"locationOriginate":{"asn":"","country":"CA"}}}
 	Proposal *proposalRes `json:"proposal,omitempty"`
+
+	// number of the ongoing reconnect attempt
+	// example: 1
+	ReconnectAttempt int `json:"reconnectAttempt,omitempty"`
+
+	// kill switch restricts the traffic to the VPN tunnel
+	// example: true
+	KillSwitch bool `json:"killSwitch,omitempty"`
+
+	// effective route set of the tunnel, omitted if all the traffic is routed thru it
+	Routes *routesResponse `json:"routes,omitempty"`
+}
+
+// swagger:model RoutesDTO
+type routesResponse struct {
+	// networks routed thru the tunnel, all the traffic except excluded networks is routed thru it if empty
+	// example: ["10.8.0.0/16"]
+	Include []string `json:"include,omitempty"`
+
+	// networks routed around the tunnel
+	// example: ["192.168.1.0/24"]
+	Exclude []string `json:"exclude,omitempty"`
 }
 
 // swagger:model IPDTO
@@ -91,6 +189,10 @@ type ipResponse struct {
 
 // swagger:model ConnectionStatisticsDTO
 type statisticsResponse struct {
+	// connection ID, set for the additional connections of the pool
+	// example: 1
+	ID string `json:"id,omitempty"`
+
 	// example: 1024
 	BytesSent uint64 `json:"bytesSent"`
 
@@ -108,6 +210,11 @@ type SessionStatisticsTracker interface {
 	GetSessionDuration() time.Duration
 }
 
+// ProposalSelector ranks the proposals matching the criteria
+type ProposalSelector interface {
+	Candidates(criteria selector.Criteria) ([]market.ServiceProposal, error)
+}
+
 // ConnectionEndpoint struct represents /connection resource and it's subresources
 type ConnectionEndpoint struct {
 	manager           connection.Manager
@@ -115,17 +222,22 @@ type ConnectionEndpoint struct {
 	statisticsTracker SessionStatisticsTracker
 	//TODO connection should use concrete proposal from connection params and avoid going to marketplace
 	proposalProvider ProposalProvider
+	proposalSelector ProposalSelector
 }
 
 const connectionLogPrefix = "[Connection] "
 
+// maxSelectedProposalAttempts limits the number of the best ranked proposals tried when connecting by the selector
+const maxSelectedProposalAttempts = 3
+
 // NewConnectionEndpoint creates and returns connection endpoint
-func NewConnectionEndpoint(manager connection.Manager, ipResolver ip.Resolver, statsKeeper SessionStatisticsTracker, proposalProvider ProposalProvider) *ConnectionEndpoint {
+func NewConnectionEndpoint(manager connection.Manager, ipResolver ip.Resolver, statsKeeper SessionStatisticsTracker, proposalProvider ProposalProvider, proposalSelector ProposalSelector) *ConnectionEndpoint {
 	return &ConnectionEndpoint{
 		manager:           manager,
 		ipResolver:        ipResolver,
 		statisticsTracker: statsKeeper,
 		proposalProvider:  proposalProvider,
+		proposalSelector:  proposalSelector,
 	}
 }
 
@@ -152,11 +264,11 @@ func (ce *ConnectionEndpoint) Status(resp http.ResponseWriter, _ *http.Request,
 // swagger:operation PUT /connection Connection connectionCreate
 // ---
 // summary: Starts new connection
-// description: Consumer opens connection to provider
+// description: Consumer opens connection to the given provider, or to the best ranked provider matching the proposal selector
 // parameters:
 //   - in: body
 //     name: body
-//     description: Parameters in body (consumerId, providerId, serviceType) required for creating new connection
+//     description: Parameters in body (consumerId, providerId or proposalSelector, serviceType) required for creating new connection
 //     schema:
 //       $ref: "#/definitions/ConnectionRequestDTO"
 // responses:
@@ -168,8 +280,12 @@ func (ce *ConnectionEndpoint) Status(resp http.ResponseWriter, _ *http.Request,
 //     description: Bad request
 //     schema:
 //       "$ref": "#/definitions/ErrorMessageDTO"
+//   404:
+//     description: No proposals match the proposal selector
+//     schema:
+//       "$ref": "#/definitions/ErrorMessageDTO"
 //   409:
-//     description: Conflict. Connection already exists
+//     description: Conflict. Connection already exists or another connection routes all the traffic
 //     schema:
 //       "$ref": "#/definitions/ErrorMessageDTO"
 //   422:
@@ -185,16 +301,32 @@ func (ce *ConnectionEndpoint) Status(resp http.ResponseWriter, _ *http.Request,
 //     schema:
 //       "$ref": "#/definitions/ErrorMessageDTO"
 func (ce *ConnectionEndpoint) Create(resp http.ResponseWriter, req *http.Request, params httprouter.Params) {
+	if !ce.connect(resp, req, ce.manager.Connect) {
+		return
+	}
+	resp.WriteHeader(http.StatusCreated)
+	ce.Status(resp, req, params)
+}
+
+// connectFunc connects the consumer to the given proposal
+type connectFunc func(consumerID identity.Identity, proposal market.ServiceProposal, params connection.ConnectParams) error
+
+// connect finds the requested proposal and connects to it, the error response is written if connecting fails
+func (ce *ConnectionEndpoint) connect(resp http.ResponseWriter, req *http.Request, connect connectFunc) bool {
 	cr, err := toConnectionRequest(req)
 	if err != nil {
 		utils.SendError(resp, err, http.StatusBadRequest)
-		return
+		return false
 	}
 
 	errorMap := validateConnectionRequest(cr)
 	if errorMap.HasErrors() {
 		utils.SendValidationErrorMessage(resp, errorMap)
-		return
+		return false
+	}
+
+	if cr.ProviderID == "" {
+		return ce.connectBySelector(resp, cr, connect)
 	}
 
 	// TODO Pass proposal ID directly in request
@@ -204,30 +336,63 @@ func (ce *ConnectionEndpoint) Create(resp http.ResponseWriter, req *http.Request
 	})
 	if err != nil {
 		utils.SendError(resp, err, http.StatusInternalServerError)
-		return
+		return false
 	}
 	if proposal == nil {
 		utils.SendError(resp, errors.New("provider has no service proposals"), http.StatusBadRequest)
-		return
+		return false
 	}
 
 	connectOptions := getConnectOptions(cr)
-	err = ce.manager.Connect(identity.FromAddress(cr.ConsumerID), *proposal, connectOptions)
+	err = connect(identity.FromAddress(cr.ConsumerID), *proposal, connectOptions)
+	return respondToConnectError(resp, err)
+}
 
+// connectBySelector tries to connect to the best ranked proposals matching the selector, until the connection succeeds
+func (ce *ConnectionEndpoint) connectBySelector(resp http.ResponseWriter, cr *connectionRequest, connect connectFunc) bool {
+	candidates, err := ce.proposalSelector.Candidates(selector.Criteria{
+		Filter:     selectorFilter(cr),
+		QualityMin: cr.ProposalSelector.QualityMin,
+	})
 	if err != nil {
-		switch err {
-		case connection.ErrAlreadyExists:
-			utils.SendError(resp, err, http.StatusConflict)
-		case connection.ErrConnectionCancelled:
-			utils.SendError(resp, err, statusConnectCancelled)
-		default:
-			log.Error(connectionLogPrefix, err)
-			utils.SendError(resp, err, http.StatusInternalServerError)
+		utils.SendError(resp, err, http.StatusInternalServerError)
+		return false
+	}
+	if len(candidates) == 0 {
+		utils.SendError(resp, errors.New("no proposals match the selector"), http.StatusNotFound)
+		return false
+	}
+	if len(candidates) > maxSelectedProposalAttempts {
+		candidates = candidates[:maxSelectedProposalAttempts]
+	}
+
+	connectOptions := getConnectOptions(cr)
+	for _, proposal := range candidates {
+		err = connect(identity.FromAddress(cr.ConsumerID), proposal, connectOptions)
+		if err == nil || err == connection.ErrAlreadyExists || err == connection.ErrFullTunnelExists || err == connection.ErrConnectionCancelled {
+			break
 		}
-		return
+		log.Warn(connectionLogPrefix, "Failed to connect to the selected provider ", proposal.ProviderID, ": ", err)
 	}
-	resp.WriteHeader(http.StatusCreated)
-	ce.Status(resp, req, params)
+	return respondToConnectError(resp, err)
+}
+
+// respondToConnectError writes the error response if connecting failed
+func respondToConnectError(resp http.ResponseWriter, err error) bool {
+	if err == nil {
+		return true
+	}
+
+	switch err {
+	case connection.ErrAlreadyExists, connection.ErrFullTunnelExists:
+		utils.SendError(resp, err, http.StatusConflict)
+	case connection.ErrConnectionCancelled:
+		utils.SendError(resp, err, statusConnectCancelled)
+	default:
+		log.Error(connectionLogPrefix, err)
+		utils.SendError(resp, err, http.StatusInternalServerError)
+	}
+	return false
 }
 
 // Kill stops connection
@@ -322,8 +487,8 @@ func (ce *ConnectionEndpoint) GetStatistics(writer http.ResponseWriter, request
 
 // AddRoutesForConnection adds connections routes to given router
 func AddRoutesForConnection(router *httprouter.Router, manager connection.Manager, ipResolver ip.Resolver,
-	statsKeeper SessionStatisticsTracker, proposalProvider ProposalProvider) {
-	connectionEndpoint := NewConnectionEndpoint(manager, ipResolver, statsKeeper, proposalProvider)
+	statsKeeper SessionStatisticsTracker, proposalProvider ProposalProvider, proposalSelector ProposalSelector) {
+	connectionEndpoint := NewConnectionEndpoint(manager, ipResolver, statsKeeper, proposalProvider, proposalSelector)
 	router.GET("/connection", connectionEndpoint.Status)
 	router.PUT("/connection", connectionEndpoint.Create)
 	router.DELETE("/connection", connectionEndpoint.Kill)
@@ -345,7 +510,33 @@ func toConnectionRequest(req *http.Request) (*connectionRequest, error) {
 }
 
 func getConnectOptions(cr *connectionRequest) connection.ConnectParams {
-	return connection.ConnectParams{DisableKillSwitch: cr.ConnectOptions.DisableKillSwitch}
+	params := connection.ConnectParams{DisableKillSwitch: cr.ConnectOptions.DisableKillSwitch}
+	if reconnect := cr.ConnectOptions.Reconnect; reconnect != nil {
+		params.Reconnect = connection.ReconnectPolicy{
+			MaxAttempts: reconnect.MaxAttempts,
+			Backoff:     time.Duration(reconnect.BackoffSeconds) * time.Second,
+			Failover:    reconnect.Failover,
+			ProposalFilter: market.ProposalFilter{
+				ServiceType: cr.ServiceType,
+			},
+		}
+		if cr.ProposalSelector != nil {
+			params.Reconnect.ProposalFilter = selectorFilter(cr)
+		}
+	}
+	if splitOptions := cr.ConnectOptions.Split; splitOptions != nil {
+		params.Split = split.Params{Include: splitOptions.Include, Exclude: splitOptions.Exclude}
+	}
+	return params
+}
+
+func selectorFilter(cr *connectionRequest) market.ProposalFilter {
+	return market.ProposalFilter{
+		ServiceType:       cr.ServiceType,
+		LocationCountry:   cr.ProposalSelector.Country,
+		PaymentMethodType: cr.ProposalSelector.PaymentMethodType,
+		PriceMax:          cr.ProposalSelector.PriceMax,
+	}
 }
 
 func validateConnectionRequest(cr *connectionRequest) *validation.FieldErrorMap {
@@ -353,21 +544,59 @@ func validateConnectionRequest(cr *connectionRequest) *validation.FieldErrorMap
 	if len(cr.ConsumerID) == 0 {
 		errs.ForField("consumerId").AddError("required", "Field is required")
 	}
-	if len(cr.ProviderID) == 0 {
+	if len(cr.ProviderID) == 0 && cr.ProposalSelector == nil {
 		errs.ForField("providerId").AddError("required", "Field is required")
 	}
+	if selectorOptions := cr.ProposalSelector; selectorOptions != nil {
+		if selectorOptions.QualityMin < 0 || selectorOptions.QualityMin > 1 {
+			errs.ForField("proposalSelector.qualityMin").AddError("invalid", "Must be a number between 0 and 1")
+		}
+		if selectorOptions.PriceMax != 0 && selectorOptions.PaymentMethodType == "" {
+			errs.ForField("proposalSelector.paymentMethodType").AddError("required", "Field is required with priceMax, prices of payment methods are not comparable")
+		}
+	}
+	if reconnect := cr.ConnectOptions.Reconnect; reconnect != nil {
+		if reconnect.MaxAttempts < 0 {
+			errs.ForField("connectOptions.reconnect.maxAttempts").AddError("invalid", "Must not be negative")
+		}
+		if reconnect.BackoffSeconds < 0 {
+			errs.ForField("connectOptions.reconnect.backoffSeconds").AddError("invalid", "Must not be negative")
+		}
+	}
+	if splitOptions := cr.ConnectOptions.Split; splitOptions != nil {
+		params := split.Params{Include: splitOptions.Include, Exclude: splitOptions.Exclude}
+		if err := params.Validate(); err != nil {
+			errs.ForField("connectOptions.split").AddError("invalid", err.Error())
+		}
+	}
 	return errs
 }
 
 func toConnectionResponse(status connection.Status) connectionResponse {
 	response := connectionResponse{
-		Status:    string(status.State),
-		SessionID: string(status.SessionID),
+		Status:           string(status.State),
+		SessionID:        string(status.SessionID),
+		ReconnectAttempt: status.ReconnectAttempt,
+		KillSwitch:       status.KillSwitch,
 	}
 
 	if status.Proposal.ProviderID != "" {
 		proposalRes := proposalToRes(status.Proposal)
 		response.Proposal = &proposalRes
 	}
+	if len(status.Routes.Include) > 0 || len(status.Routes.Exclude) > 0 {
+		response.Routes = &routesResponse{
+			Include: networksToRes(status.Routes.Include),
+			Exclude: networksToRes(status.Routes.Exclude),
+		}
+	}
 	return response
 }
+
+func networksToRes(networks []net.IPNet) []string {
+	var res []string
+	for _, network := range networks {
+		res = append(res, network.String())
+	}
+	return res
+}
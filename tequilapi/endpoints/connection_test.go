@@ -19,6 +19,7 @@ package endpoints
 
 import (
 	"errors"
+	"net"
 	"net/http"
 	"net/http/httptest"
 	"strings"
@@ -27,7 +28,9 @@ import (
 
 	"github.com/julienschmidt/httprouter"
 	"github.com/mysteriumnetwork/node/consumer"
+	"github.com/mysteriumnetwork/node/consumer/selector"
 	"github.com/mysteriumnetwork/node/core/connection"
+	"github.com/mysteriumnetwork/node/core/connection/split"
 	"github.com/mysteriumnetwork/node/core/ip"
 	"github.com/mysteriumnetwork/node/identity"
 	"github.com/mysteriumnetwork/node/market"
@@ -36,18 +39,26 @@ import (
 
 type mockConnectionManager struct {
 	onConnectReturn      error
+	onConnectErrors      map[string]error
+	connectedProviders   []string
 	onDisconnectReturn   error
 	onStatusReturn       connection.Status
 	disconnectCount      int
 	requestedConsumerID  identity.Identity
 	requestedProvider    identity.Identity
 	requestedServiceType string
+	requestedParams      connection.ConnectParams
 }
 
 func (cm *mockConnectionManager) Connect(consumerID identity.Identity, proposal market.ServiceProposal, options connection.ConnectParams) error {
 	cm.requestedConsumerID = consumerID
 	cm.requestedProvider = identity.FromAddress(proposal.ProviderID)
 	cm.requestedServiceType = proposal.ServiceType
+	cm.requestedParams = options
+	cm.connectedProviders = append(cm.connectedProviders, proposal.ProviderID)
+	if err, ok := cm.onConnectErrors[proposal.ProviderID]; ok {
+		return err
+	}
 	return cm.onConnectReturn
 }
 
@@ -100,7 +111,7 @@ func TestAddRoutesForConnectionAddsRoutes(t *testing.T) {
 	ipResolver := ip.NewResolverMock("123.123.123.123")
 
 	mockedProposalProvider := getMockProposalProviderWithSpecifiedProposal("node1", "noop")
-	AddRoutesForConnection(router, &fakeManager, ipResolver, statsKeeper, mockedProposalProvider)
+	AddRoutesForConnection(router, &fakeManager, ipResolver, statsKeeper, mockedProposalProvider, nil)
 
 	tests := []struct {
 		method         string
@@ -155,7 +166,7 @@ func TestDisconnectingState(t *testing.T) {
 		SessionID: "",
 	}
 
-	connEndpoint := NewConnectionEndpoint(&fakeManager, nil, nil, &mockProposalProvider{})
+	connEndpoint := NewConnectionEndpoint(&fakeManager, nil, nil, &mockProposalProvider{}, nil)
 	req := httptest.NewRequest(http.MethodGet, "/irrelevant", nil)
 	resp := httptest.NewRecorder()
 
@@ -177,7 +188,7 @@ func TestNotConnectedStateIsReturnedWhenNoConnection(t *testing.T) {
 		SessionID: "",
 	}
 
-	connEndpoint := NewConnectionEndpoint(&fakeManager, nil, nil, &mockProposalProvider{})
+	connEndpoint := NewConnectionEndpoint(&fakeManager, nil, nil, &mockProposalProvider{}, nil)
 	req := httptest.NewRequest(http.MethodGet, "/irrelevant", nil)
 	resp := httptest.NewRecorder()
 
@@ -199,7 +210,7 @@ func TestStateConnectingIsReturnedWhenIsConnectionInProgress(t *testing.T) {
 		State: connection.Connecting,
 	}
 
-	connEndpoint := NewConnectionEndpoint(&fakeManager, nil, nil, &mockProposalProvider{})
+	connEndpoint := NewConnectionEndpoint(&fakeManager, nil, nil, &mockProposalProvider{}, nil)
 	req := httptest.NewRequest(http.MethodGet, "/irrelevant", nil)
 	resp := httptest.NewRecorder()
 
@@ -222,7 +233,7 @@ func TestConnectedStateAndSessionIdIsReturnedWhenIsConnected(t *testing.T) {
 		SessionID: "My-super-session",
 	}
 
-	connEndpoint := NewConnectionEndpoint(&fakeManager, nil, nil, &mockProposalProvider{})
+	connEndpoint := NewConnectionEndpoint(&fakeManager, nil, nil, &mockProposalProvider{}, nil)
 	req := httptest.NewRequest(http.MethodGet, "/irrelevant", nil)
 	resp := httptest.NewRecorder()
 
@@ -239,10 +250,80 @@ func TestConnectedStateAndSessionIdIsReturnedWhenIsConnected(t *testing.T) {
 
 }
 
+func TestReconnectAttemptIsReturnedWhenReconnecting(t *testing.T) {
+	var fakeManager = mockConnectionManager{}
+	fakeManager.onStatusReturn = connection.Status{
+		State:            connection.Reconnecting,
+		ReconnectAttempt: 2,
+	}
+
+	connEndpoint := NewConnectionEndpoint(&fakeManager, nil, nil, &mockProposalProvider{}, nil)
+	req := httptest.NewRequest(http.MethodGet, "/irrelevant", nil)
+	resp := httptest.NewRecorder()
+
+	connEndpoint.Status(resp, req, httprouter.Params{})
+
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.JSONEq(
+		t,
+		`{
+			"status" : "Reconnecting",
+			"reconnectAttempt" : 2
+		}`,
+		resp.Body.String())
+}
+
+func TestKillSwitchIsReturnedWhenEnabled(t *testing.T) {
+	var fakeManager = mockConnectionManager{}
+	fakeManager.onStatusReturn = connection.Status{
+		State:      connection.Reconnecting,
+		KillSwitch: true,
+	}
+
+	connEndpoint := NewConnectionEndpoint(&fakeManager, nil, nil, &mockProposalProvider{}, nil)
+	req := httptest.NewRequest(http.MethodGet, "/irrelevant", nil)
+	resp := httptest.NewRecorder()
+
+	connEndpoint.Status(resp, req, httprouter.Params{})
+
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.JSONEq(
+		t,
+		`{
+			"status" : "Reconnecting",
+			"killSwitch" : true
+		}`,
+		resp.Body.String())
+}
+
+func TestRoutesAreReturnedWhenTunnelIsSplit(t *testing.T) {
+	_, included, _ := net.ParseCIDR("10.8.0.0/16")
+	var fakeManager = mockConnectionManager{}
+	fakeManager.onStatusReturn = connection.Status{
+		State:  connection.Connected,
+		Routes: split.Routes{Include: []net.IPNet{*included}},
+	}
+
+	connEndpoint := NewConnectionEndpoint(&fakeManager, nil, nil, &mockProposalProvider{}, nil)
+	req := httptest.NewRequest(http.MethodGet, "/irrelevant", nil)
+	resp := httptest.NewRecorder()
+
+	connEndpoint.Status(resp, req, httprouter.Params{})
+
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.JSONEq(
+		t,
+		`{
+			"status" : "Connected",
+			"routes" : {"include" : ["10.8.0.0/16"]}
+		}`,
+		resp.Body.String())
+}
+
 func TestPutReturns400ErrorIfRequestBodyIsNotJSON(t *testing.T) {
 	fakeManager := mockConnectionManager{}
 
-	connEndpoint := NewConnectionEndpoint(&fakeManager, nil, nil, &mockProposalProvider{})
+	connEndpoint := NewConnectionEndpoint(&fakeManager, nil, nil, &mockProposalProvider{}, nil)
 	req := httptest.NewRequest(http.MethodPut, "/irrelevant", strings.NewReader("a"))
 	resp := httptest.NewRecorder()
 
@@ -261,7 +342,7 @@ func TestPutReturns400ErrorIfRequestBodyIsNotJSON(t *testing.T) {
 func TestPutReturns422ErrorIfRequestBodyIsMissingFieldValues(t *testing.T) {
 	fakeManager := mockConnectionManager{}
 
-	connEndpoint := NewConnectionEndpoint(&fakeManager, nil, nil, &mockProposalProvider{})
+	connEndpoint := NewConnectionEndpoint(&fakeManager, nil, nil, &mockProposalProvider{}, nil)
 	req := httptest.NewRequest(http.MethodPut, "/irrelevant", strings.NewReader("{}"))
 	resp := httptest.NewRecorder()
 
@@ -284,7 +365,7 @@ func TestPutWithValidBodyCreatesConnection(t *testing.T) {
 	fakeManager := mockConnectionManager{}
 
 	proposalProvider := getMockProposalProviderWithSpecifiedProposal("required-node", "openvpn")
-	connEndpoint := NewConnectionEndpoint(&fakeManager, nil, nil, proposalProvider)
+	connEndpoint := NewConnectionEndpoint(&fakeManager, nil, nil, proposalProvider, nil)
 	req := httptest.NewRequest(
 		http.MethodPut,
 		"/irrelevant",
@@ -308,7 +389,7 @@ func TestPutWithServiceTypeOverridesDefault(t *testing.T) {
 	fakeManager := mockConnectionManager{}
 
 	mystAPI := getMockProposalProviderWithSpecifiedProposal("required-node", "noop")
-	connEndpoint := NewConnectionEndpoint(&fakeManager, nil, nil, mystAPI)
+	connEndpoint := NewConnectionEndpoint(&fakeManager, nil, nil, mystAPI, nil)
 	req := httptest.NewRequest(
 		http.MethodPut,
 		"/irrelevant",
@@ -329,10 +410,230 @@ func TestPutWithServiceTypeOverridesDefault(t *testing.T) {
 	assert.Equal(t, "noop", fakeManager.requestedServiceType)
 }
 
+func TestPutWithReconnectOptionsPassesReconnectPolicy(t *testing.T) {
+	fakeManager := mockConnectionManager{}
+
+	proposalProvider := getMockProposalProviderWithSpecifiedProposal("required-node", "wireguard")
+	connEndpoint := NewConnectionEndpoint(&fakeManager, nil, nil, proposalProvider, nil)
+	req := httptest.NewRequest(
+		http.MethodPut,
+		"/irrelevant",
+		strings.NewReader(
+			`{
+				"consumerId" : "my-identity",
+				"providerId" : "required-node",
+				"serviceType": "wireguard",
+				"connectOptions": {
+					"reconnect": {"maxAttempts": 3, "backoffSeconds": 5, "failover": true}
+				}
+			}`))
+	resp := httptest.NewRecorder()
+
+	connEndpoint.Create(resp, req, httprouter.Params{})
+
+	assert.Equal(t, http.StatusCreated, resp.Code)
+	assert.Equal(
+		t,
+		connection.ReconnectPolicy{
+			MaxAttempts:    3,
+			Backoff:        5 * time.Second,
+			Failover:       true,
+			ProposalFilter: market.ProposalFilter{ServiceType: "wireguard"},
+		},
+		fakeManager.requestedParams.Reconnect,
+	)
+}
+
+func TestPutReturns422ErrorIfReconnectOptionsAreNegative(t *testing.T) {
+	fakeManager := mockConnectionManager{}
+
+	connEndpoint := NewConnectionEndpoint(&fakeManager, nil, nil, &mockProposalProvider{}, nil)
+	req := httptest.NewRequest(
+		http.MethodPut,
+		"/irrelevant",
+		strings.NewReader(
+			`{
+				"consumerId" : "my-identity",
+				"providerId" : "required-node",
+				"connectOptions": {"reconnect": {"maxAttempts": -1}}
+			}`))
+	resp := httptest.NewRecorder()
+
+	connEndpoint.Create(resp, req, httprouter.Params{})
+
+	assert.Equal(t, http.StatusUnprocessableEntity, resp.Code)
+	assert.JSONEq(
+		t,
+		`{
+			"message" : "validation_error",
+			"errors" : {
+				"connectOptions.reconnect.maxAttempts" : [ { "code" : "invalid" , "message" : "Must not be negative" } ]
+			}
+		}`, resp.Body.String())
+}
+
+func TestPutWithSplitOptionsPassesSplitParams(t *testing.T) {
+	fakeManager := mockConnectionManager{}
+
+	connEndpoint := NewConnectionEndpoint(&fakeManager, nil, nil, getMockProposalProviderWithSpecifiedProposal("required-node", "openvpn"), nil)
+	req := httptest.NewRequest(
+		http.MethodPut,
+		"/irrelevant",
+		strings.NewReader(
+			`{
+				"consumerId" : "my-identity",
+				"providerId" : "required-node",
+				"connectOptions": {"split": {"include": ["10.8.0.0/16"], "exclude": ["example.com"]}}
+			}`))
+	resp := httptest.NewRecorder()
+
+	connEndpoint.Create(resp, req, httprouter.Params{})
+
+	assert.Equal(t, http.StatusCreated, resp.Code)
+	assert.Equal(
+		t,
+		split.Params{Include: []string{"10.8.0.0/16"}, Exclude: []string{"example.com"}},
+		fakeManager.requestedParams.Split,
+	)
+}
+
+func TestPutReturns422ErrorIfSplitDestinationIsInvalid(t *testing.T) {
+	fakeManager := mockConnectionManager{}
+
+	connEndpoint := NewConnectionEndpoint(&fakeManager, nil, nil, &mockProposalProvider{}, nil)
+	req := httptest.NewRequest(
+		http.MethodPut,
+		"/irrelevant",
+		strings.NewReader(
+			`{
+				"consumerId" : "my-identity",
+				"providerId" : "required-node",
+				"connectOptions": {"split": {"exclude": ["2001:db8::/32"]}}
+			}`))
+	resp := httptest.NewRecorder()
+
+	connEndpoint.Create(resp, req, httprouter.Params{})
+
+	assert.Equal(t, http.StatusUnprocessableEntity, resp.Code)
+	assert.JSONEq(
+		t,
+		`{
+			"message" : "validation_error",
+			"errors" : {
+				"connectOptions.split" : [ { "code" : "invalid" , "message" : "IPv6 destination can not be excluded: 2001:db8::/32" } ]
+			}
+		}`, resp.Body.String())
+}
+
+func TestPutWithProposalSelectorConnectsToBestCandidate(t *testing.T) {
+	fakeManager := mockConnectionManager{
+		onConnectErrors: map[string]error{"node1": errors.New("unreachable")},
+	}
+	proposalSelector := &mockProposalSelector{
+		candidates: []market.ServiceProposal{
+			{ProviderID: "node1", ServiceType: "wireguard"},
+			{ProviderID: "node2", ServiceType: "wireguard"},
+			{ProviderID: "node3", ServiceType: "wireguard"},
+		},
+	}
+
+	connEndpoint := NewConnectionEndpoint(&fakeManager, nil, nil, &mockProposalProvider{}, proposalSelector)
+	req := httptest.NewRequest(
+		http.MethodPut,
+		"/irrelevant",
+		strings.NewReader(
+			`{
+				"consumerId" : "my-identity",
+				"serviceType": "wireguard",
+				"proposalSelector": {"country": "NL", "paymentMethodType": "PER_GB", "priceMax": 100, "qualityMin": 0.5},
+				"connectOptions": {"reconnect": {"maxAttempts": 1, "failover": true}}
+			}`))
+	resp := httptest.NewRecorder()
+
+	connEndpoint.Create(resp, req, httprouter.Params{})
+
+	assert.Equal(t, http.StatusCreated, resp.Code)
+	assert.Equal(t, []string{"node1", "node2"}, fakeManager.connectedProviders)
+	assert.Equal(t, identity.FromAddress("node2"), fakeManager.requestedProvider)
+
+	expectedFilter := market.ProposalFilter{ServiceType: "wireguard", LocationCountry: "NL", PaymentMethodType: "PER_GB", PriceMax: 100}
+	assert.Equal(t, selector.Criteria{Filter: expectedFilter, QualityMin: 0.5}, proposalSelector.recordedCriteria)
+	assert.Equal(t, expectedFilter, fakeManager.requestedParams.Reconnect.ProposalFilter)
+}
+
+func TestPutWithProposalSelectorGivesUpAfterMaxAttempts(t *testing.T) {
+	connectErr := errors.New("unreachable")
+	fakeManager := mockConnectionManager{onConnectReturn: connectErr}
+	proposalSelector := &mockProposalSelector{
+		candidates: []market.ServiceProposal{
+			{ProviderID: "node1"}, {ProviderID: "node2"}, {ProviderID: "node3"}, {ProviderID: "node4"},
+		},
+	}
+
+	connEndpoint := NewConnectionEndpoint(&fakeManager, nil, nil, &mockProposalProvider{}, proposalSelector)
+	req := httptest.NewRequest(
+		http.MethodPut,
+		"/irrelevant",
+		strings.NewReader(`{"consumerId" : "my-identity", "proposalSelector": {}}`))
+	resp := httptest.NewRecorder()
+
+	connEndpoint.Create(resp, req, httprouter.Params{})
+
+	assert.Equal(t, http.StatusInternalServerError, resp.Code)
+	assert.Equal(t, []string{"node1", "node2", "node3"}, fakeManager.connectedProviders)
+}
+
+func TestPutWithProposalSelectorReturns404WhenNothingMatches(t *testing.T) {
+	fakeManager := mockConnectionManager{}
+
+	connEndpoint := NewConnectionEndpoint(&fakeManager, nil, nil, &mockProposalProvider{}, &mockProposalSelector{})
+	req := httptest.NewRequest(
+		http.MethodPut,
+		"/irrelevant",
+		strings.NewReader(`{"consumerId" : "my-identity", "proposalSelector": {"country": "NL"}}`))
+	resp := httptest.NewRecorder()
+
+	connEndpoint.Create(resp, req, httprouter.Params{})
+
+	assert.Equal(t, http.StatusNotFound, resp.Code)
+	assert.Len(t, fakeManager.connectedProviders, 0)
+}
+
+func TestPutReturns422ErrorIfSelectorQualityIsInvalid(t *testing.T) {
+	connEndpoint := NewConnectionEndpoint(&mockConnectionManager{}, nil, nil, &mockProposalProvider{}, &mockProposalSelector{})
+	req := httptest.NewRequest(
+		http.MethodPut,
+		"/irrelevant",
+		strings.NewReader(`{"consumerId" : "my-identity", "proposalSelector": {"qualityMin": 2}}`))
+	resp := httptest.NewRecorder()
+
+	connEndpoint.Create(resp, req, httprouter.Params{})
+
+	assert.Equal(t, http.StatusUnprocessableEntity, resp.Code)
+	assert.JSONEq(
+		t,
+		`{
+			"message" : "validation_error",
+			"errors" : {
+				"proposalSelector.qualityMin" : [ { "code" : "invalid" , "message" : "Must be a number between 0 and 1" } ]
+			}
+		}`, resp.Body.String())
+}
+
+type mockProposalSelector struct {
+	candidates       []market.ServiceProposal
+	recordedCriteria selector.Criteria
+}
+
+func (mps *mockProposalSelector) Candidates(criteria selector.Criteria) ([]market.ServiceProposal, error) {
+	mps.recordedCriteria = criteria
+	return mps.candidates, nil
+}
+
 func TestDeleteCallsDisconnect(t *testing.T) {
 	fakeManager := mockConnectionManager{}
 
-	connEndpoint := NewConnectionEndpoint(&fakeManager, nil, nil, &mockProposalProvider{})
+	connEndpoint := NewConnectionEndpoint(&fakeManager, nil, nil, &mockProposalProvider{}, nil)
 	req := httptest.NewRequest(http.MethodDelete, "/irrelevant", nil)
 	resp := httptest.NewRecorder()
 
@@ -346,7 +647,7 @@ func TestDeleteCallsDisconnect(t *testing.T) {
 func TestGetIPEndpointSucceeds(t *testing.T) {
 	manager := mockConnectionManager{}
 	ipResolver := ip.NewResolverMock("123.123.123.123")
-	connEndpoint := NewConnectionEndpoint(&manager, ipResolver, nil, &mockProposalProvider{})
+	connEndpoint := NewConnectionEndpoint(&manager, ipResolver, nil, &mockProposalProvider{}, nil)
 	resp := httptest.NewRecorder()
 
 	connEndpoint.GetIP(resp, nil, nil)
@@ -364,7 +665,7 @@ func TestGetIPEndpointSucceeds(t *testing.T) {
 func TestGetIPEndpointReturnsErrorWhenIPDetectionFails(t *testing.T) {
 	manager := mockConnectionManager{}
 	ipResolver := ip.NewResolverMockFailing(errors.New("fake error"))
-	connEndpoint := NewConnectionEndpoint(&manager, ipResolver, nil, &mockProposalProvider{})
+	connEndpoint := NewConnectionEndpoint(&manager, ipResolver, nil, &mockProposalProvider{}, nil)
 	resp := httptest.NewRecorder()
 
 	connEndpoint.GetIP(resp, nil, nil)
@@ -386,7 +687,7 @@ func TestGetStatisticsEndpointReturnsStatistics(t *testing.T) {
 	}
 
 	manager := mockConnectionManager{}
-	connEndpoint := NewConnectionEndpoint(&manager, nil, statsKeeper, &mockProposalProvider{})
+	connEndpoint := NewConnectionEndpoint(&manager, nil, statsKeeper, &mockProposalProvider{}, nil)
 
 	resp := httptest.NewRecorder()
 	connEndpoint.GetStatistics(resp, nil, nil)
@@ -407,7 +708,7 @@ func TestGetStatisticsEndpointReturnsStatisticsWhenSessionIsNotStarted(t *testin
 	}
 
 	manager := mockConnectionManager{}
-	connEndpoint := NewConnectionEndpoint(&manager, nil, statsKeeper, &mockProposalProvider{})
+	connEndpoint := NewConnectionEndpoint(&manager, nil, statsKeeper, &mockProposalProvider{}, nil)
 
 	resp := httptest.NewRecorder()
 	connEndpoint.GetStatistics(resp, nil, nil)
@@ -427,7 +728,7 @@ func TestEndpointReturnsConflictStatusIfConnectionAlreadyExists(t *testing.T) {
 	manager.onConnectReturn = connection.ErrAlreadyExists
 
 	mystAPI := getMockProposalProviderWithSpecifiedProposal("required-node", "openvpn")
-	connectionEndpoint := NewConnectionEndpoint(&manager, nil, nil, mystAPI)
+	connectionEndpoint := NewConnectionEndpoint(&manager, nil, nil, mystAPI, nil)
 
 	req := httptest.NewRequest(
 		http.MethodPut,
@@ -455,7 +756,7 @@ func TestDisconnectReturnsConflictStatusIfConnectionDoesNotExist(t *testing.T) {
 	manager := mockConnectionManager{}
 	manager.onDisconnectReturn = connection.ErrNoConnection
 
-	connectionEndpoint := NewConnectionEndpoint(&manager, nil, nil, &mockProposalProvider{})
+	connectionEndpoint := NewConnectionEndpoint(&manager, nil, nil, &mockProposalProvider{}, nil)
 
 	req := httptest.NewRequest(
 		http.MethodDelete,
@@ -481,7 +782,7 @@ func TestConnectReturnsConnectCancelledStatusWhenErrConnectionCancelledIsEncount
 	manager.onConnectReturn = connection.ErrConnectionCancelled
 
 	mockProposalProvider := getMockProposalProviderWithSpecifiedProposal("required-node", "openvpn")
-	connectionEndpoint := NewConnectionEndpoint(&manager, nil, nil, mockProposalProvider)
+	connectionEndpoint := NewConnectionEndpoint(&manager, nil, nil, mockProposalProvider, nil)
 	req := httptest.NewRequest(
 		http.MethodPut,
 		"/irrelevant",
@@ -508,7 +809,7 @@ func TestConnectReturnsErrorIfNoProposals(t *testing.T) {
 	manager := mockConnectionManager{}
 	manager.onConnectReturn = connection.ErrConnectionCancelled
 
-	connectionEndpoint := NewConnectionEndpoint(&manager, nil, nil, &mockProposalProvider{proposals: make([]market.ServiceProposal, 0)})
+	connectionEndpoint := NewConnectionEndpoint(&manager, nil, nil, &mockProposalProvider{proposals: make([]market.ServiceProposal, 0)}, nil)
 	req := httptest.NewRequest(
 		http.MethodPut,
 		"/irrelevant",
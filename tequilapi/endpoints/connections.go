@@ -0,0 +1,263 @@
+/*
+ * Copyright (C) 2019 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package endpoints
+
+import (
+	"net/http"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/mysteriumnetwork/node/core/connection"
+	"github.com/mysteriumnetwork/node/identity"
+	"github.com/mysteriumnetwork/node/market"
+	"github.com/mysteriumnetwork/node/tequilapi/utils"
+)
+
+// ConnectionPool holds the primary connection and the additional ones established next to it
+type ConnectionPool interface {
+	Add(consumerID identity.Identity, proposal market.ServiceProposal, params connection.ConnectParams) (connection.ID, error)
+	Get(id connection.ID) (connection.PoolManager, error)
+	Remove(id connection.ID) error
+	Connections() []connection.ID
+}
+
+// swagger:model ConnectionListDTO
+type connectionsResponse struct {
+	// primary connection goes first if it is established
+	Connections []connectionResponse `json:"connections"`
+}
+
+// ConnectionsEndpoint struct represents /connections resource and it's subresources
+type ConnectionsEndpoint struct {
+	pool ConnectionPool
+	// connector finds the requested proposals the same way the /connection resource does
+	connector *ConnectionEndpoint
+}
+
+// NewConnectionsEndpoint creates and returns connections endpoint
+func NewConnectionsEndpoint(pool ConnectionPool, proposalProvider ProposalProvider, proposalSelector ProposalSelector) *ConnectionsEndpoint {
+	return &ConnectionsEndpoint{
+		pool:      pool,
+		connector: NewConnectionEndpoint(nil, nil, nil, proposalProvider, proposalSelector),
+	}
+}
+
+// List returns statuses of all connections
+// swagger:operation GET /connections Connection listConnections
+// ---
+// summary: Returns all connections
+// description: Returns statuses of the primary connection and the additional ones
+// responses:
+//   200:
+//     description: List of connections
+//     schema:
+//       "$ref": "#/definitions/ConnectionListDTO"
+func (ce *ConnectionsEndpoint) List(resp http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+	response := connectionsResponse{Connections: []connectionResponse{}}
+	for _, id := range ce.pool.Connections() {
+		manager, err := ce.pool.Get(id)
+		if err != nil {
+			// connection was closed in the meantime
+			continue
+		}
+		response.Connections = append(response.Connections, toPoolConnectionResponse(id, manager.Status()))
+	}
+	utils.WriteAsJSON(response, resp)
+}
+
+// Create starts additional connection
+// swagger:operation PUT /connections Connection addConnection
+// ---
+// summary: Starts additional connection
+// description: Consumer opens connection next to the existing ones, only one connection is allowed to route all the traffic
+// parameters:
+//   - in: body
+//     name: body
+//     description: Parameters in body (consumerId, providerId or proposalSelector, serviceType) required for creating new connection
+//     schema:
+//       $ref: "#/definitions/ConnectionRequestDTO"
+// responses:
+//   201:
+//     description: Connection started
+//     schema:
+//       "$ref": "#/definitions/ConnectionStatusDTO"
+//   400:
+//     description: Bad request
+//     schema:
+//       "$ref": "#/definitions/ErrorMessageDTO"
+//   404:
+//     description: No proposals match the proposal selector
+//     schema:
+//       "$ref": "#/definitions/ErrorMessageDTO"
+//   409:
+//     description: Conflict. Another connection routes all the traffic
+//     schema:
+//       "$ref": "#/definitions/ErrorMessageDTO"
+//   422:
+//     description: Parameters validation error
+//     schema:
+//       "$ref": "#/definitions/ValidationErrorDTO"
+//   499:
+//     description: Connection was cancelled
+//     schema:
+//       "$ref": "#/definitions/ErrorMessageDTO"
+//   500:
+//     description: Internal server error
+//     schema:
+//       "$ref": "#/definitions/ErrorMessageDTO"
+func (ce *ConnectionsEndpoint) Create(resp http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	var id connection.ID
+	add := func(consumerID identity.Identity, proposal market.ServiceProposal, params connection.ConnectParams) (err error) {
+		id, err = ce.pool.Add(consumerID, proposal, params)
+		return err
+	}
+	if !ce.connector.connect(resp, req, add) {
+		return
+	}
+
+	manager, err := ce.pool.Get(id)
+	if err != nil {
+		utils.SendError(resp, err, http.StatusInternalServerError)
+		return
+	}
+	resp.WriteHeader(http.StatusCreated)
+	utils.WriteAsJSON(toPoolConnectionResponse(id, manager.Status()), resp)
+}
+
+// Status returns status of the connection
+// swagger:operation GET /connections/{id} Connection getConnection
+// ---
+// summary: Returns connection status
+// description: Returns status of the connection with the given ID, the primary connection has ID "primary"
+// parameters:
+// - name: id
+//   in: path
+//   description: connection ID
+//   type: string
+//   required: true
+// responses:
+//   200:
+//     description: Status
+//     schema:
+//       "$ref": "#/definitions/ConnectionStatusDTO"
+//   404:
+//     description: Connection not found
+//     schema:
+//       "$ref": "#/definitions/ErrorMessageDTO"
+func (ce *ConnectionsEndpoint) Status(resp http.ResponseWriter, _ *http.Request, params httprouter.Params) {
+	id := connection.ID(params.ByName("id"))
+	manager, err := ce.pool.Get(id)
+	if err != nil {
+		utils.SendError(resp, err, http.StatusNotFound)
+		return
+	}
+	utils.WriteAsJSON(toPoolConnectionResponse(id, manager.Status()), resp)
+}
+
+// Kill stops the connection
+// swagger:operation DELETE /connections/{id} Connection removeConnection
+// ---
+// summary: Stops connection
+// description: Stops the connection with the given ID
+// parameters:
+// - name: id
+//   in: path
+//   description: connection ID
+//   type: string
+//   required: true
+// responses:
+//   202:
+//     description: Connection stopped
+//   404:
+//     description: Connection not found
+//     schema:
+//       "$ref": "#/definitions/ErrorMessageDTO"
+//   409:
+//     description: Conflict. Connection is not established
+//     schema:
+//       "$ref": "#/definitions/ErrorMessageDTO"
+//   500:
+//     description: Internal server error
+//     schema:
+//       "$ref": "#/definitions/ErrorMessageDTO"
+func (ce *ConnectionsEndpoint) Kill(resp http.ResponseWriter, _ *http.Request, params httprouter.Params) {
+	err := ce.pool.Remove(connection.ID(params.ByName("id")))
+	if err != nil {
+		switch err {
+		case connection.ErrConnectionNotFound:
+			utils.SendError(resp, err, http.StatusNotFound)
+		case connection.ErrNoConnection:
+			utils.SendError(resp, err, http.StatusConflict)
+		default:
+			utils.SendError(resp, err, http.StatusInternalServerError)
+		}
+		return
+	}
+	resp.WriteHeader(http.StatusAccepted)
+}
+
+// GetStatistics returns statistics of the connection
+// swagger:operation GET /connections/{id}/statistics Connection getConnectionStatistics
+// ---
+// summary: Returns connection statistics
+// description: Returns statistics of the connection with the given ID
+// parameters:
+// - name: id
+//   in: path
+//   description: connection ID
+//   type: string
+//   required: true
+// responses:
+//   200:
+//     description: Connection statistics
+//     schema:
+//       "$ref": "#/definitions/ConnectionStatisticsDTO"
+//   404:
+//     description: Connection not found
+//     schema:
+//       "$ref": "#/definitions/ErrorMessageDTO"
+func (ce *ConnectionsEndpoint) GetStatistics(resp http.ResponseWriter, _ *http.Request, params httprouter.Params) {
+	manager, err := ce.pool.Get(connection.ID(params.ByName("id")))
+	if err != nil {
+		utils.SendError(resp, err, http.StatusNotFound)
+		return
+	}
+
+	stats, duration := manager.Statistics()
+	response := statisticsResponse{
+		BytesSent:     stats.BytesSent,
+		BytesReceived: stats.BytesReceived,
+		Duration:      int(duration.Seconds()),
+	}
+	utils.WriteAsJSON(response, resp)
+}
+
+// AddRoutesForConnections adds routes of the primary and additional connections to given router
+func AddRoutesForConnections(router *httprouter.Router, pool ConnectionPool, proposalProvider ProposalProvider, proposalSelector ProposalSelector) {
+	connectionsEndpoint := NewConnectionsEndpoint(pool, proposalProvider, proposalSelector)
+	router.GET("/connections", connectionsEndpoint.List)
+	router.PUT("/connections", connectionsEndpoint.Create)
+	router.GET("/connections/:id", connectionsEndpoint.Status)
+	router.DELETE("/connections/:id", connectionsEndpoint.Kill)
+	router.GET("/connections/:id/statistics", connectionsEndpoint.GetStatistics)
+}
+
+func toPoolConnectionResponse(id connection.ID, status connection.Status) connectionResponse {
+	response := toConnectionResponse(status)
+	response.ID = string(id)
+	return response
+}
@@ -0,0 +1,196 @@
+/*
+ * Copyright (C) 2019 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package endpoints
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/mysteriumnetwork/node/consumer"
+	"github.com/mysteriumnetwork/node/core/connection"
+	"github.com/mysteriumnetwork/node/identity"
+	"github.com/mysteriumnetwork/node/market"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockPoolManager struct {
+	mockConnectionManager
+	stats    consumer.SessionStatistics
+	duration time.Duration
+}
+
+func (pm *mockPoolManager) Statistics() (consumer.SessionStatistics, time.Duration) {
+	return pm.stats, pm.duration
+}
+
+type mockConnectionPool struct {
+	managers    map[connection.ID]*mockPoolManager
+	ids         []connection.ID
+	onAddReturn error
+	addedParams connection.ConnectParams
+	removed     []connection.ID
+}
+
+func (pool *mockConnectionPool) Add(_ identity.Identity, _ market.ServiceProposal, params connection.ConnectParams) (connection.ID, error) {
+	pool.addedParams = params
+	if pool.onAddReturn != nil {
+		return "", pool.onAddReturn
+	}
+
+	id := connection.ID("1")
+	pool.managers[id] = &mockPoolManager{
+		mockConnectionManager: mockConnectionManager{onStatusReturn: connection.Status{State: connection.Connected}},
+	}
+	pool.ids = append(pool.ids, id)
+	return id, nil
+}
+
+func (pool *mockConnectionPool) Get(id connection.ID) (connection.PoolManager, error) {
+	manager, exists := pool.managers[id]
+	if !exists {
+		return nil, connection.ErrConnectionNotFound
+	}
+	return manager, nil
+}
+
+func (pool *mockConnectionPool) Remove(id connection.ID) error {
+	if _, exists := pool.managers[id]; !exists {
+		return connection.ErrConnectionNotFound
+	}
+	pool.removed = append(pool.removed, id)
+	return nil
+}
+
+func (pool *mockConnectionPool) Connections() []connection.ID {
+	return pool.ids
+}
+
+func newMockConnectionPool() *mockConnectionPool {
+	return &mockConnectionPool{managers: make(map[connection.ID]*mockPoolManager)}
+}
+
+func TestConnectionsListReturnsAllConnections(t *testing.T) {
+	pool := newMockConnectionPool()
+	pool.managers[connection.PrimaryID] = &mockPoolManager{
+		mockConnectionManager: mockConnectionManager{onStatusReturn: connection.Status{State: connection.Connected, KillSwitch: true}},
+	}
+	pool.managers["2"] = &mockPoolManager{
+		mockConnectionManager: mockConnectionManager{onStatusReturn: connection.Status{State: connection.Reconnecting}},
+	}
+	pool.ids = []connection.ID{connection.PrimaryID, "2"}
+
+	connectionsEndpoint := NewConnectionsEndpoint(pool, nil, nil)
+	resp := httptest.NewRecorder()
+	connectionsEndpoint.List(resp, httptest.NewRequest(http.MethodGet, "/connections", nil), httprouter.Params{})
+
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.JSONEq(
+		t,
+		`{
+			"connections": [
+				{"id": "primary", "status": "Connected", "killSwitch": true},
+				{"id": "2", "status": "Reconnecting"}
+			]
+		}`,
+		resp.Body.String(),
+	)
+}
+
+func TestConnectionsCreateAddsConnection(t *testing.T) {
+	pool := newMockConnectionPool()
+	proposalProvider := getMockProposalProviderWithSpecifiedProposal("required-node", "wireguard")
+	connectionsEndpoint := NewConnectionsEndpoint(pool, proposalProvider, nil)
+	req := httptest.NewRequest(
+		http.MethodPut,
+		"/connections",
+		strings.NewReader(
+			`{
+				"consumerId" : "my-identity",
+				"providerId" : "required-node",
+				"serviceType": "wireguard",
+				"connectOptions": {"split": {"include": ["10.8.0.0/16"]}}
+			}`))
+	resp := httptest.NewRecorder()
+
+	connectionsEndpoint.Create(resp, req, httprouter.Params{})
+
+	assert.Equal(t, http.StatusCreated, resp.Code)
+	assert.JSONEq(t, `{"id": "1", "status": "Connected"}`, resp.Body.String())
+	assert.Equal(t, []string{"10.8.0.0/16"}, pool.addedParams.Split.Include)
+}
+
+func TestConnectionsCreateReturnsConflictWhenFullTunnelExists(t *testing.T) {
+	pool := newMockConnectionPool()
+	pool.onAddReturn = connection.ErrFullTunnelExists
+	proposalProvider := getMockProposalProviderWithSpecifiedProposal("required-node", "wireguard")
+	connectionsEndpoint := NewConnectionsEndpoint(pool, proposalProvider, nil)
+	req := httptest.NewRequest(
+		http.MethodPut,
+		"/connections",
+		strings.NewReader(`{"consumerId" : "my-identity", "providerId" : "required-node", "serviceType": "wireguard"}`))
+	resp := httptest.NewRecorder()
+
+	connectionsEndpoint.Create(resp, req, httprouter.Params{})
+
+	assert.Equal(t, http.StatusConflict, resp.Code)
+	assert.JSONEq(t, `{"message": "all traffic is already routed thru another connection"}`, resp.Body.String())
+}
+
+func TestConnectionsStatusReturnsNotFoundForUnknownConnection(t *testing.T) {
+	connectionsEndpoint := NewConnectionsEndpoint(newMockConnectionPool(), nil, nil)
+	resp := httptest.NewRecorder()
+
+	connectionsEndpoint.Status(resp, httptest.NewRequest(http.MethodGet, "/connections/7", nil), httprouter.Params{{Key: "id", Value: "7"}})
+
+	assert.Equal(t, http.StatusNotFound, resp.Code)
+	assert.JSONEq(t, `{"message": "connection not found"}`, resp.Body.String())
+}
+
+func TestConnectionsKillRemovesConnection(t *testing.T) {
+	pool := newMockConnectionPool()
+	pool.managers["1"] = &mockPoolManager{}
+	connectionsEndpoint := NewConnectionsEndpoint(pool, nil, nil)
+
+	resp := httptest.NewRecorder()
+	connectionsEndpoint.Kill(resp, httptest.NewRequest(http.MethodDelete, "/connections/1", nil), httprouter.Params{{Key: "id", Value: "1"}})
+	assert.Equal(t, http.StatusAccepted, resp.Code)
+	assert.Equal(t, []connection.ID{"1"}, pool.removed)
+
+	resp = httptest.NewRecorder()
+	connectionsEndpoint.Kill(resp, httptest.NewRequest(http.MethodDelete, "/connections/2", nil), httprouter.Params{{Key: "id", Value: "2"}})
+	assert.Equal(t, http.StatusNotFound, resp.Code)
+}
+
+func TestConnectionsGetStatisticsReturnsStatisticsOfConnection(t *testing.T) {
+	pool := newMockConnectionPool()
+	pool.managers["1"] = &mockPoolManager{
+		stats:    consumer.SessionStatistics{BytesSent: 1, BytesReceived: 2},
+		duration: time.Minute,
+	}
+	connectionsEndpoint := NewConnectionsEndpoint(pool, nil, nil)
+	resp := httptest.NewRecorder()
+
+	connectionsEndpoint.GetStatistics(resp, httptest.NewRequest(http.MethodGet, "/connections/1/statistics", nil), httprouter.Params{{Key: "id", Value: "1"}})
+
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.JSONEq(t, `{"bytesSent": 1, "bytesReceived": 2, "duration": 60}`, resp.Body.String())
+}
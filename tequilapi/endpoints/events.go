@@ -0,0 +1,338 @@
+/*
+ * Copyright (C) 2019 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package endpoints
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/cihub/seelog"
+	"github.com/julienschmidt/httprouter"
+	"github.com/mysteriumnetwork/node/consumer"
+	"github.com/mysteriumnetwork/node/core/connection"
+	"github.com/mysteriumnetwork/node/core/service"
+	"github.com/mysteriumnetwork/node/nat/event"
+	"github.com/mysteriumnetwork/node/tequilapi/utils"
+)
+
+const eventsLogPrefix = "[Events] "
+
+// topics of the streamed events
+const (
+	topicConnectionState      = "connection-state"
+	topicConnectionStatistics = "connection-statistics"
+	topicNAT                  = "nat"
+	topicServiceStop          = "service-stop"
+)
+
+var eventTopics = []string{topicConnectionState, topicConnectionStatistics, topicNAT, topicServiceStop}
+
+const (
+	// eventsBufferSize is the number of events kept for a slow client, newer events are dropped once it is full
+	eventsBufferSize = 64
+	// eventsKeepAliveInterval keeps idle streams from being closed by proxies
+	eventsKeepAliveInterval = 15 * time.Second
+)
+
+// EventSubscriber allows subscribing to the events published by the node
+type EventSubscriber interface {
+	Subscribe(topic string, fn interface{}) error
+}
+
+// swagger:model NATEventDTO
+type natEventResponse struct {
+	// example: hole_punching
+	Stage string `json:"stage"`
+
+	// example: true
+	Successful bool `json:"successful"`
+
+	// example: failed to traverse NAT
+	Error string `json:"error,omitempty"`
+}
+
+type streamedEvent struct {
+	topic string
+	data  []byte
+}
+
+type eventsClient struct {
+	events chan streamedEvent
+	// topics the client is interested in, all the topics are streamed if it is empty
+	topics map[string]bool
+}
+
+func (client *eventsClient) wants(topic string) bool {
+	return len(client.topics) == 0 || client.topics[topic]
+}
+
+// EventsEndpoint streams the node events to the subscribed clients
+type EventsEndpoint struct {
+	statisticsTracker SessionStatisticsTracker
+	keepAliveInterval time.Duration
+
+	clients map[*eventsClient]struct{}
+	// pooledCreated keeps the creation time of the sessions of the additional connections of the pool
+	pooledCreated map[connection.ID]time.Time
+	lock          sync.Mutex
+}
+
+// NewEventsEndpoint creates and returns events endpoint
+func NewEventsEndpoint(statisticsTracker SessionStatisticsTracker) *EventsEndpoint {
+	return &EventsEndpoint{
+		statisticsTracker: statisticsTracker,
+		keepAliveInterval: eventsKeepAliveInterval,
+		clients:           make(map[*eventsClient]struct{}),
+		pooledCreated:     make(map[connection.ID]time.Time),
+	}
+}
+
+// Subscribe starts listening for the events published on the event bus,
+// the endpoint subscribes once and fans the events out to its clients
+func (ee *EventsEndpoint) Subscribe(subscriber EventSubscriber) error {
+	if err := subscriber.Subscribe(connection.StateEventTopic, ee.consumeStateEvent); err != nil {
+		return err
+	}
+	if err := subscriber.Subscribe(connection.StatisticsEventTopic, ee.consumeStatisticsEvent); err != nil {
+		return err
+	}
+	if err := subscriber.Subscribe(connection.PooledEventTopic, ee.consumePooledEvent); err != nil {
+		return err
+	}
+	if err := subscriber.Subscribe(event.Topic, ee.consumeNATEvent); err != nil {
+		return err
+	}
+	return subscriber.Subscribe(service.StopTopic, ee.consumeServiceStopEvent)
+}
+
+// Stream streams the node events
+// swagger:operation GET /events Events streamEvents
+// ---
+// summary: Streams node events
+// description: |
+//   Streams node events as server-sent events until the client disconnects.
+//   Event name is the topic of the event ("connection-state", "connection-statistics", "nat" or "service-stop"),
+//   event data is JSON in the format of the matching resource (ConnectionStatusDTO, ConnectionStatisticsDTO, NATEventDTO or ServiceInfoDTO).
+//   Connection events of the additional connections listed under /connections have their connection ID set.
+// parameters:
+// - name: token
+//   in: query
+//   description: API token for the clients which can not set Authorization header (EventSource), "token" cookie is accepted too
+//   type: string
+// - name: topics
+//   in: query
+//   description: comma separated topics to stream, all the topics are streamed if it is not given
+//   type: string
+// produces:
+//   - text/event-stream
+// responses:
+//   200:
+//     description: Stream of events
+//   400:
+//     description: Unknown topic
+//     schema:
+//       "$ref": "#/definitions/ErrorMessageDTO"
+//   500:
+//     description: Internal server error
+//     schema:
+//       "$ref": "#/definitions/ErrorMessageDTO"
+func (ee *EventsEndpoint) Stream(resp http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	flusher, ok := resp.(http.Flusher)
+	if !ok {
+		utils.SendErrorMessage(resp, "streaming is not supported", http.StatusInternalServerError)
+		return
+	}
+
+	topics, err := parseEventTopics(req.URL.Query().Get("topics"))
+	if err != nil {
+		utils.SendError(resp, err, http.StatusBadRequest)
+		return
+	}
+
+	client := ee.register(topics)
+	defer ee.unregister(client)
+
+	resp.Header().Set("Content-Type", "text/event-stream")
+	resp.Header().Set("Connection", "keep-alive")
+	resp.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	keepAlive := time.NewTicker(ee.keepAliveInterval)
+	defer keepAlive.Stop()
+
+	for {
+		select {
+		case <-req.Context().Done():
+			return
+		case e := <-client.events:
+			fmt.Fprintf(resp, "event: %s\ndata: %s\n\n", e.topic, e.data)
+		case <-keepAlive.C:
+			fmt.Fprint(resp, ": keep-alive\n\n")
+		}
+		flusher.Flush()
+	}
+}
+
+// AddRoutesForEvents adds events routes to given router and subscribes the endpoint to the node events
+func AddRoutesForEvents(router *httprouter.Router, subscriber EventSubscriber, statisticsTracker SessionStatisticsTracker) error {
+	eventsEndpoint := NewEventsEndpoint(statisticsTracker)
+	if err := eventsEndpoint.Subscribe(subscriber); err != nil {
+		return err
+	}
+
+	router.GET("/events", eventsEndpoint.Stream)
+	return nil
+}
+
+func (ee *EventsEndpoint) consumeStateEvent(stateEvent connection.StateEvent) {
+	status := connection.Status{
+		State:            stateEvent.State,
+		SessionID:        stateEvent.SessionInfo.SessionID,
+		Proposal:         stateEvent.SessionInfo.Proposal,
+		ReconnectAttempt: stateEvent.ReconnectAttempt,
+	}
+	ee.broadcast(topicConnectionState, toConnectionResponse(status))
+}
+
+func (ee *EventsEndpoint) consumeStatisticsEvent(stats consumer.SessionStatistics) {
+	ee.broadcast(topicConnectionStatistics, statisticsResponse{
+		BytesSent:     stats.BytesSent,
+		BytesReceived: stats.BytesReceived,
+		Duration:      int(ee.statisticsTracker.GetSessionDuration().Seconds()),
+	})
+}
+
+// consumePooledEvent streams the connection events of the additional connections of the pool tagged with their ID
+func (ee *EventsEndpoint) consumePooledEvent(pooledEvent connection.PooledEvent) {
+	id := pooledEvent.ConnectionID
+	switch e := pooledEvent.Event.(type) {
+	case connection.StateEvent:
+		response := toConnectionResponse(connection.Status{
+			State:            e.State,
+			SessionID:        e.SessionInfo.SessionID,
+			Proposal:         e.SessionInfo.Proposal,
+			ReconnectAttempt: e.ReconnectAttempt,
+		})
+		response.ID = string(id)
+		ee.broadcast(topicConnectionState, response)
+	case consumer.SessionStatistics:
+		ee.lock.Lock()
+		created, ok := ee.pooledCreated[id]
+		ee.lock.Unlock()
+
+		response := statisticsResponse{ID: string(id), BytesSent: e.BytesSent, BytesReceived: e.BytesReceived}
+		if ok {
+			response.Duration = int(time.Since(created).Seconds())
+		}
+		ee.broadcast(topicConnectionStatistics, response)
+	case connection.SessionEvent:
+		ee.lock.Lock()
+		defer ee.lock.Unlock()
+
+		switch e.Status {
+		case connection.SessionCreatedStatus:
+			ee.pooledCreated[id] = time.Now()
+		case connection.SessionEndedStatus:
+			delete(ee.pooledCreated, id)
+		}
+	}
+}
+
+func (ee *EventsEndpoint) consumeNATEvent(natEvent event.Event) {
+	response := natEventResponse{Stage: natEvent.Stage, Successful: natEvent.Successful}
+	if natEvent.Error != nil {
+		response.Error = natEvent.Error.Error()
+	}
+	ee.broadcast(topicNAT, response)
+}
+
+func (ee *EventsEndpoint) consumeServiceStopEvent(instance *service.Instance) {
+	ee.broadcast(topicServiceStop, toServiceInfoResponse(instance.ID(), instance))
+}
+
+// broadcast never blocks the publisher, the event is dropped for the clients which do not keep up
+func (ee *EventsEndpoint) broadcast(topic string, payload interface{}) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		log.Error(eventsLogPrefix, "Failed to encode ", topic, " event: ", err)
+		return
+	}
+
+	ee.lock.Lock()
+	defer ee.lock.Unlock()
+
+	for client := range ee.clients {
+		if !client.wants(topic) {
+			continue
+		}
+		select {
+		case client.events <- streamedEvent{topic: topic, data: data}:
+		default:
+			log.Warn(eventsLogPrefix, "Client is too slow, ", topic, " event dropped")
+		}
+	}
+}
+
+func (ee *EventsEndpoint) register(topics map[string]bool) *eventsClient {
+	client := &eventsClient{
+		events: make(chan streamedEvent, eventsBufferSize),
+		topics: topics,
+	}
+
+	ee.lock.Lock()
+	defer ee.lock.Unlock()
+
+	ee.clients[client] = struct{}{}
+	return client
+}
+
+func (ee *EventsEndpoint) unregister(client *eventsClient) {
+	ee.lock.Lock()
+	defer ee.lock.Unlock()
+
+	delete(ee.clients, client)
+}
+
+func parseEventTopics(query string) (map[string]bool, error) {
+	topics := make(map[string]bool)
+	if query == "" {
+		return topics, nil
+	}
+
+	for _, topic := range strings.Split(query, ",") {
+		topic = strings.TrimSpace(topic)
+		if !isEventTopic(topic) {
+			return nil, fmt.Errorf("unknown topic %q, expected one of: %s", topic, strings.Join(eventTopics, ", "))
+		}
+		topics[topic] = true
+	}
+	return topics, nil
+}
+
+func isEventTopic(topic string) bool {
+	for _, known := range eventTopics {
+		if topic == known {
+			return true
+		}
+	}
+	return false
+}
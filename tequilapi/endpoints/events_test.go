@@ -0,0 +1,168 @@
+/*
+ * Copyright (C) 2019 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package endpoints
+
+import (
+	"bufio"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/mysteriumnetwork/node/consumer"
+	"github.com/mysteriumnetwork/node/core/connection"
+	"github.com/mysteriumnetwork/node/eventbus"
+	"github.com/mysteriumnetwork/node/nat/event"
+	"github.com/stretchr/testify/assert"
+)
+
+func startEventsServer(t *testing.T) (eventbus.EventBus, *httptest.Server) {
+	bus := eventbus.New()
+	router := httprouter.New()
+	err := AddRoutesForEvents(router, bus, &StubStatisticsTracker{duration: time.Minute})
+	assert.NoError(t, err)
+	return bus, httptest.NewServer(router)
+}
+
+func readEvent(t *testing.T, reader *bufio.Reader) (string, string) {
+	var lines []string
+	for {
+		line, err := reader.ReadString('\n')
+		assert.NoError(t, err)
+		if line == "\n" {
+			break
+		}
+		lines = append(lines, line)
+	}
+	assert.Len(t, lines, 2)
+	return lines[0], lines[1]
+}
+
+func TestEventsStreamsBusEvents(t *testing.T) {
+	bus, server := startEventsServer(t)
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "/events")
+	assert.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+
+	bus.Publish(connection.StateEventTopic, connection.StateEvent{State: connection.Reconnecting, ReconnectAttempt: 2})
+	bus.Publish(connection.StatisticsEventTopic, consumer.SessionStatistics{BytesSent: 1, BytesReceived: 2})
+	bus.Publish(event.Topic, event.Event{Stage: "hole_punching", Error: errors.New("no response")})
+
+	reader := bufio.NewReader(resp.Body)
+	name, data := readEvent(t, reader)
+	assert.Equal(t, "event: connection-state\n", name)
+	assert.Equal(t, `data: {"status":"Reconnecting","reconnectAttempt":2}`+"\n", data)
+
+	name, data = readEvent(t, reader)
+	assert.Equal(t, "event: connection-statistics\n", name)
+	assert.Equal(t, `data: {"bytesSent":1,"bytesReceived":2,"duration":60}`+"\n", data)
+
+	name, data = readEvent(t, reader)
+	assert.Equal(t, "event: nat\n", name)
+	assert.Equal(t, `data: {"stage":"hole_punching","successful":false,"error":"no response"}`+"\n", data)
+}
+
+func TestEventsStreamsPooledEventsWithConnectionID(t *testing.T) {
+	bus, server := startEventsServer(t)
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "/events")
+	assert.NoError(t, err)
+	defer resp.Body.Close()
+
+	bus.Publish(connection.PooledEventTopic, connection.PooledEvent{
+		ConnectionID: connection.ID("1"),
+		Topic:        connection.StateEventTopic,
+		Event:        connection.StateEvent{State: connection.Connected},
+	})
+	bus.Publish(connection.PooledEventTopic, connection.PooledEvent{
+		ConnectionID: connection.ID("1"),
+		Topic:        connection.StatisticsEventTopic,
+		Event:        consumer.SessionStatistics{BytesSent: 1, BytesReceived: 2},
+	})
+
+	reader := bufio.NewReader(resp.Body)
+	name, data := readEvent(t, reader)
+	assert.Equal(t, "event: connection-state\n", name)
+	assert.Equal(t, `data: {"id":"1","status":"Connected"}`+"\n", data)
+
+	name, data = readEvent(t, reader)
+	assert.Equal(t, "event: connection-statistics\n", name)
+	assert.Equal(t, `data: {"id":"1","bytesSent":1,"bytesReceived":2,"duration":0}`+"\n", data)
+}
+
+func TestEventsStreamsOnlyRequestedTopics(t *testing.T) {
+	bus, server := startEventsServer(t)
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "/events?topics=nat")
+	assert.NoError(t, err)
+	defer resp.Body.Close()
+
+	bus.Publish(connection.StateEventTopic, connection.StateEvent{State: connection.Connected})
+	bus.Publish(event.Topic, event.Event{Stage: "hole_punching", Successful: true})
+
+	name, data := readEvent(t, bufio.NewReader(resp.Body))
+	assert.Equal(t, "event: nat\n", name)
+	assert.Equal(t, `data: {"stage":"hole_punching","successful":true}`+"\n", data)
+}
+
+func TestEventsRejectsUnknownTopic(t *testing.T) {
+	eventsEndpoint := NewEventsEndpoint(&StubStatisticsTracker{})
+	resp := httptest.NewRecorder()
+
+	eventsEndpoint.Stream(resp, httptest.NewRequest(http.MethodGet, "/events?topics=nat,foo", nil), httprouter.Params{})
+
+	assert.Equal(t, http.StatusBadRequest, resp.Code)
+	assert.JSONEq(
+		t,
+		`{"message": "unknown topic \"foo\", expected one of: connection-state, connection-statistics, nat, service-stop"}`,
+		resp.Body.String(),
+	)
+}
+
+func TestEventsClientIsUnregisteredWhenStreamIsClosed(t *testing.T) {
+	eventsEndpoint := NewEventsEndpoint(&StubStatisticsTracker{})
+	server := httptest.NewServer(http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
+		eventsEndpoint.Stream(resp, req, httprouter.Params{})
+	}))
+	defer server.Close()
+
+	resp, err := http.Get(server.URL)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, eventsEndpoint.clientCount())
+	resp.Body.Close()
+
+	for i := 0; i < 100 && eventsEndpoint.clientCount() > 0; i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+	assert.Equal(t, 0, eventsEndpoint.clientCount())
+}
+
+func (ee *EventsEndpoint) clientCount() int {
+	ee.lock.Lock()
+	defer ee.lock.Unlock()
+
+	return len(ee.clients)
+}
@@ -0,0 +1,38 @@
+/*
+ * Copyright (C) 2019 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package endpoints
+
+import (
+	"net/http"
+
+	"github.com/julienschmidt/httprouter"
+)
+
+// AddRoutesForMetrics adds metrics routes to given router
+// swagger:operation GET /metrics Client metrics
+// ---
+// summary: Returns node metrics
+// description: Returns metrics of the node in the Prometheus text exposition format, Prometheus scrapes them with the API token as the bearer token
+// produces:
+//   - text/plain
+// responses:
+//   200:
+//     description: Node metrics
+func AddRoutesForMetrics(router *httprouter.Router, metrics http.Handler) {
+	router.Handler(http.MethodGet, "/metrics", metrics)
+}
@@ -20,16 +20,40 @@ package endpoints
 import (
 	"encoding/json"
 	"net/http"
+	"sort"
+	"strconv"
+	"time"
 
 	"github.com/julienschmidt/httprouter"
 	"github.com/mysteriumnetwork/node/market"
 	"github.com/mysteriumnetwork/node/market/metrics"
+	"github.com/mysteriumnetwork/node/market/mysterium"
+	"github.com/mysteriumnetwork/node/money"
 	"github.com/mysteriumnetwork/node/tequilapi/utils"
+	"github.com/mysteriumnetwork/node/tequilapi/validation"
 )
 
 // swagger:model ProposalsList
 type proposalsRes struct {
 	Proposals []proposalRes `json:"proposals"`
+
+	// freshness of the proposals, if they are served from the cache
+	Cache *proposalsCacheRes `json:"cache,omitempty"`
+}
+
+// swagger:model ProposalsCacheDTO
+type proposalsCacheRes struct {
+	// time when the proposals were fetched from discovery
+	// example: 2019-06-06T11:04:43Z
+	FetchedAt string `json:"fetchedAt"`
+
+	// age of the proposals in seconds
+	// example: 30
+	Age int `json:"age"`
+
+	// set when discovery is unreachable and older proposals are served
+	// example: false
+	Stale bool `json:"stale"`
 }
 
 // swagger:model ServiceLocationDTO
@@ -55,6 +79,14 @@ type serviceDefinitionRes struct {
 	LocationOriginate locationRes `json:"locationOriginate"`
 }
 
+// swagger:model PaymentMethodDTO
+type paymentMethodRes struct {
+	// example: PER_GB
+	Type string `json:"type"`
+
+	Price money.Money `json:"price"`
+}
+
 // swagger:model ProposalDTO
 type proposalRes struct {
 	// per provider unique serial number of service description provided
@@ -77,6 +109,9 @@ type proposalRes struct {
 
 	// AccessPolicies
 	AccessPolicies *[]market.AccessPolicy `json:"accessPolicies,omitempty"`
+
+	// price of the service
+	PaymentMethod *paymentMethodRes `json:"paymentMethod,omitempty"`
 }
 
 func proposalToRes(p market.ServiceProposal) proposalRes {
@@ -96,6 +131,17 @@ func proposalToRes(p market.ServiceProposal) proposalRes {
 			},
 		},
 		AccessPolicies: p.AccessPolicies,
+		PaymentMethod:  paymentMethodToRes(p),
+	}
+}
+
+func paymentMethodToRes(p market.ServiceProposal) *paymentMethodRes {
+	if p.PaymentMethod == nil {
+		return nil
+	}
+	return &paymentMethodRes{
+		Type:  p.PaymentMethodType,
+		Price: p.PaymentMethod.GetPrice(),
 	}
 }
 
@@ -117,6 +163,11 @@ type ProposalProvider interface {
 	FindProposals(filter market.ProposalFilter) ([]market.ServiceProposal, error)
 }
 
+// ProposalFreshnessProvider reports when the proposals matching the filter were fetched from discovery
+type ProposalFreshnessProvider interface {
+	Freshness(filter market.ProposalFilter) (mysterium.Freshness, bool)
+}
+
 type proposalsEndpoint struct {
 	proposalProvider     ProposalProvider
 	mysteriumMorqaClient metrics.QualityOracle
@@ -130,7 +181,7 @@ func NewProposalsEndpoint(proposalProvider ProposalProvider, morqaClient metrics
 // swagger:operation GET /proposals Proposal listProposals
 // ---
 // summary: Returns proposals
-// description: Returns list of proposals filtered by provider id
+// description: Returns list of proposals filtered by the given parameters
 // parameters:
 //   - in: query
 //     name: providerId
@@ -149,6 +200,42 @@ func NewProposalsEndpoint(proposalProvider ProposalProvider, morqaClient metrics
 //     description: the access policy source to filter the proposals by
 //     type: string
 //   - in: query
+//     name: locationCountry
+//     description: the country code of the provider location to filter the proposals by
+//     type: string
+//   - in: query
+//     name: locationContinent
+//     description: the continent code of the provider location to filter the proposals by
+//     type: string
+//   - in: query
+//     name: locationIsp
+//     description: the ISP of the provider to filter the proposals by
+//     type: string
+//   - in: query
+//     name: locationAsn
+//     description: the autonomous system number of the provider to filter the proposals by
+//     type: integer
+//   - in: query
+//     name: locationNodeType
+//     description: the node type of the provider to filter the proposals by. Possible values are "residential" and "datacenter"
+//     type: string
+//   - in: query
+//     name: paymentMethodType
+//     description: the payment method of the proposals, e.g. "PER_GB". It is required with priceMax
+//     type: string
+//   - in: query
+//     name: priceMax
+//     description: the highest price amount of the proposals of paymentMethodType
+//     type: integer
+//   - in: query
+//     name: qualityMin
+//     description: the lowest connection success rate (from 0 to 1) of the proposal, as measured by the quality oracle
+//     type: number
+//   - in: query
+//     name: sortBy
+//     description: sorts the proposals by the ascending price within each payment method or by the descending quality. Possible values are "price" and "quality"
+//     type: string
+//   - in: query
 //     name: fetchConnectCounts
 //     description: if set to true, fetches the connection success metrics for nodes. False by default.
 //     type: boolean
@@ -157,31 +244,169 @@ func NewProposalsEndpoint(proposalProvider ProposalProvider, morqaClient metrics
 //     description: List of proposals
 //     schema:
 //       "$ref": "#/definitions/ProposalsList"
+//   422:
+//     description: Parameters validation error
+//     schema:
+//       "$ref": "#/definitions/ValidationErrorDTO"
 //   500:
 //     description: Internal server error
 //     schema:
 //       "$ref": "#/definitions/ErrorMessageDTO"
 func (pe *proposalsEndpoint) List(resp http.ResponseWriter, req *http.Request, params httprouter.Params) {
-	fetchConnectCounts := req.URL.Query().Get("fetchConnectCounts")
-
-	proposals, err := pe.proposalProvider.FindProposals(market.ProposalFilter{
-		ProviderID:         req.URL.Query().Get("providerId"),
-		ServiceType:        req.URL.Query().Get("serviceType"),
-		AccessPolicyID:     req.URL.Query().Get("accessPolicyId"),
-		AccessPolicySource: req.URL.Query().Get("accessPolicySource"),
-	})
+	query, errs := toProposalsQuery(req)
+	if errs.HasErrors() {
+		utils.SendValidationErrorMessage(resp, errs)
+		return
+	}
+
+	proposals, err := pe.proposalProvider.FindProposals(query.filter)
 	if err != nil {
 		utils.SendError(resp, err, http.StatusInternalServerError)
 		return
 	}
 
 	addMetricsToRes := noMetrics
-	if fetchConnectCounts == "true" {
+	if query.fetchConnectCounts || query.needsQuality() {
 		addMetricsToRes = addMetrics(pe.mysteriumMorqaClient)
 	}
 
-	proposalsRes := proposalsRes{mapProposalsToRes(proposals, proposalToRes, addMetricsToRes)}
-	utils.WriteAsJSON(proposalsRes, resp)
+	proposalsList := mapProposalsToRes(proposals, proposalToRes, addMetricsToRes)
+	if query.qualityMin > 0 {
+		proposalsList = filterByQuality(proposalsList, query.qualityMin)
+	}
+	sortProposals(proposalsList, query.sortBy)
+
+	utils.WriteAsJSON(proposalsRes{Proposals: proposalsList, Cache: pe.cacheFreshness(query.filter)}, resp)
+}
+
+func (pe *proposalsEndpoint) cacheFreshness(filter market.ProposalFilter) *proposalsCacheRes {
+	freshnessProvider, ok := pe.proposalProvider.(ProposalFreshnessProvider)
+	if !ok {
+		return nil
+	}
+	freshness, found := freshnessProvider.Freshness(filter)
+	if !found {
+		return nil
+	}
+	return &proposalsCacheRes{
+		FetchedAt: freshness.FetchedAt.UTC().Format(time.RFC3339),
+		Age:       int(time.Since(freshness.FetchedAt).Seconds()),
+		Stale:     freshness.Stale,
+	}
+}
+
+const (
+	proposalsSortByPrice   = "price"
+	proposalsSortByQuality = "quality"
+)
+
+type proposalsQuery struct {
+	filter             market.ProposalFilter
+	qualityMin         float64
+	sortBy             string
+	fetchConnectCounts bool
+}
+
+func (query proposalsQuery) needsQuality() bool {
+	return query.qualityMin > 0 || query.sortBy == proposalsSortByQuality
+}
+
+func toProposalsQuery(request *http.Request) (proposalsQuery, *validation.FieldErrorMap) {
+	values := request.URL.Query()
+	errs := validation.NewErrorMap()
+	query := proposalsQuery{
+		filter: market.ProposalFilter{
+			ProviderID:         values.Get("providerId"),
+			ServiceType:        values.Get("serviceType"),
+			AccessPolicyID:     values.Get("accessPolicyId"),
+			AccessPolicySource: values.Get("accessPolicySource"),
+			LocationCountry:    values.Get("locationCountry"),
+			LocationContinent:  values.Get("locationContinent"),
+			LocationISP:        values.Get("locationIsp"),
+			LocationNodeType:   values.Get("locationNodeType"),
+			PaymentMethodType:  values.Get("paymentMethodType"),
+		},
+		sortBy:             values.Get("sortBy"),
+		fetchConnectCounts: values.Get("fetchConnectCounts") == "true",
+	}
+
+	if asn := values.Get("locationAsn"); asn != "" {
+		parsed, err := strconv.Atoi(asn)
+		if err != nil || parsed < 1 {
+			errs.ForField("locationAsn").AddError("invalid", "Must be a positive number")
+		}
+		query.filter.LocationASN = parsed
+	}
+	if priceMax := values.Get("priceMax"); priceMax != "" {
+		parsed, err := strconv.ParseUint(priceMax, 10, 64)
+		if err != nil {
+			errs.ForField("priceMax").AddError("invalid", "Must be a non negative number")
+		}
+		query.filter.PriceMax = parsed
+		if query.filter.PaymentMethodType == "" {
+			errs.ForField("paymentMethodType").AddError("required", "Field is required with priceMax, prices of payment methods are not comparable")
+		}
+	}
+	if qualityMin := values.Get("qualityMin"); qualityMin != "" {
+		parsed, err := strconv.ParseFloat(qualityMin, 64)
+		if err != nil || parsed < 0 || parsed > 1 {
+			errs.ForField("qualityMin").AddError("invalid", "Must be a number between 0 and 1")
+		}
+		query.qualityMin = parsed
+	}
+	switch query.sortBy {
+	case "", proposalsSortByPrice, proposalsSortByQuality:
+	default:
+		errs.ForField("sortBy").AddError("invalid", "Must be one of: price, quality")
+	}
+	return query, errs
+}
+
+func filterByQuality(proposals []proposalRes, qualityMin float64) []proposalRes {
+	filtered := make([]proposalRes, 0, len(proposals))
+	for _, p := range proposals {
+		if proposalQuality(p) >= qualityMin {
+			filtered = append(filtered, p)
+		}
+	}
+	return filtered
+}
+
+func sortProposals(proposals []proposalRes, sortBy string) {
+	switch sortBy {
+	case proposalsSortByPrice:
+		sort.SliceStable(proposals, func(i, j int) bool {
+			return cheaper(proposals[i], proposals[j])
+		})
+	case proposalsSortByQuality:
+		sort.SliceStable(proposals, func(i, j int) bool {
+			return proposalQuality(proposals[i]) > proposalQuality(proposals[j])
+		})
+	}
+}
+
+// cheaper compares the prices of the proposals, prices of different payment methods are metered in different units,
+// so proposals are grouped by payment method and sorted by price within the group.
+// Proposals without a price are the most expensive.
+func cheaper(p, other proposalRes) bool {
+	if p.PaymentMethod == nil || other.PaymentMethod == nil {
+		return p.PaymentMethod != nil && other.PaymentMethod == nil
+	}
+	if p.PaymentMethod.Type != other.PaymentMethod.Type {
+		return p.PaymentMethod.Type < other.PaymentMethod.Type
+	}
+	return p.PaymentMethod.Price.Amount < other.PaymentMethod.Price.Amount
+}
+
+// proposalQuality returns the connection success rate of the proposal measured by the quality oracle
+func proposalQuality(p proposalRes) float64 {
+	var proposalMetrics struct {
+		ConnectCount metrics.ConnectCount `json:"connectCount"`
+	}
+	if err := json.Unmarshal(p.Metrics, &proposalMetrics); err != nil {
+		return 0
+	}
+	return proposalMetrics.ConnectCount.SuccessRate()
 }
 
 // AddRoutesForProposals attaches proposals endpoints to router
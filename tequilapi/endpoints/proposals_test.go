@@ -19,11 +19,15 @@ package endpoints
 
 import (
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/mysteriumnetwork/node/market"
+	"github.com/mysteriumnetwork/node/market/mysterium"
+	"github.com/mysteriumnetwork/node/money"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -192,6 +196,54 @@ func TestProposalsEndpointList(t *testing.T) {
 	)
 }
 
+func TestProposalsEndpointListShowsPaymentMethod(t *testing.T) {
+	proposal := serviceProposals[0]
+	proposal.PaymentMethodType = market.PaymentMethodPerGB
+	proposal.PaymentMethod = market.PaymentPerGB{Price: money.NewMoney(0.5, money.CurrencyMyst)}
+	proposalProvider := &mockProposalProvider{
+		proposals: []market.ServiceProposal{proposal},
+	}
+
+	req, err := http.NewRequest(
+		http.MethodGet,
+		"/irrelevant",
+		nil,
+	)
+	assert.Nil(t, err)
+
+	resp := httptest.NewRecorder()
+	handlerFunc := NewProposalsEndpoint(proposalProvider, &mysteriumMorqaFake{}).List
+	handlerFunc(resp, req, nil)
+
+	assert.JSONEq(
+		t,
+		`{
+            "proposals": [
+                {
+                    "id": 1,
+                    "providerId": "0xProviderId",
+                    "serviceType": "testprotocol",
+                    "serviceDefinition": {
+                        "locationOriginate": {
+                            "asn": 123,
+                            "country": "Lithuania",
+                            "city": "Vilnius"
+                        }
+                    },
+                    "paymentMethod": {
+                        "type": "PER_GB",
+                        "price": {
+                            "amount": 50000000,
+                            "currency": "MYST"
+                        }
+                    }
+                }
+            ]
+        }`,
+		resp.Body.String(),
+	)
+}
+
 func TestProposalsEndpointListFetchConnectCounts(t *testing.T) {
 	proposalProvider := &mockProposalProvider{
 		proposals: serviceProposals,
@@ -249,6 +301,197 @@ func TestProposalsEndpointListFetchConnectCounts(t *testing.T) {
 	)
 }
 
+func TestProposalsEndpointListPassesLocationAndPriceFilter(t *testing.T) {
+	mockProposalProvider := &mockProposalProvider{}
+
+	req, err := http.NewRequest(
+		http.MethodGet,
+		"/irrelevant?locationCountry=LT&locationContinent=EU&locationIsp=Telia&locationAsn=8764&locationNodeType=residential&paymentMethodType=PER_GB&priceMax=100",
+		nil,
+	)
+	assert.Nil(t, err)
+
+	resp := httptest.NewRecorder()
+	handlerFunc := NewProposalsEndpoint(mockProposalProvider, &mysteriumMorqaFake{}).List
+	handlerFunc(resp, req, nil)
+
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.Equal(t,
+		market.ProposalFilter{
+			LocationCountry:   "LT",
+			LocationContinent: "EU",
+			LocationISP:       "Telia",
+			LocationASN:       8764,
+			LocationNodeType:  "residential",
+			PaymentMethodType: "PER_GB",
+			PriceMax:          100,
+		},
+		mockProposalProvider.recordedFilter,
+	)
+}
+
+func TestProposalsEndpointListValidatesParams(t *testing.T) {
+	req, err := http.NewRequest(
+		http.MethodGet,
+		"/irrelevant?locationAsn=abc&priceMax=-1&qualityMin=2&sortBy=name",
+		nil,
+	)
+	assert.Nil(t, err)
+
+	resp := httptest.NewRecorder()
+	handlerFunc := NewProposalsEndpoint(&mockProposalProvider{}, &mysteriumMorqaFake{}).List
+	handlerFunc(resp, req, nil)
+
+	assert.Equal(t, http.StatusUnprocessableEntity, resp.Code)
+	assert.JSONEq(
+		t,
+		`{
+			"message": "validation_error",
+			"errors": {
+				"locationAsn": [{"code": "invalid", "message": "Must be a positive number"}],
+				"priceMax": [{"code": "invalid", "message": "Must be a non negative number"}],
+				"paymentMethodType": [{"code": "required", "message": "Field is required with priceMax, prices of payment methods are not comparable"}],
+				"qualityMin": [{"code": "invalid", "message": "Must be a number between 0 and 1"}],
+				"sortBy": [{"code": "invalid", "message": "Must be one of: price, quality"}]
+			}
+		}`,
+		resp.Body.String(),
+	)
+}
+
+func TestProposalsEndpointListSortsByPrice(t *testing.T) {
+	cheap := serviceProposals[1]
+	cheap.PaymentMethod = market.PaymentPerGB{Price: money.NewMoney(0.1, money.CurrencyMyst)}
+	expensive := serviceProposals[0]
+	expensive.PaymentMethod = market.PaymentPerGB{Price: money.NewMoney(0.5, money.CurrencyMyst)}
+	proposalProvider := &mockProposalProvider{
+		proposals: []market.ServiceProposal{serviceProposals[0], expensive, cheap},
+	}
+
+	req, err := http.NewRequest(http.MethodGet, "/irrelevant?sortBy=price", nil)
+	assert.Nil(t, err)
+
+	resp := httptest.NewRecorder()
+	handlerFunc := NewProposalsEndpoint(proposalProvider, &mysteriumMorqaFake{}).List
+	handlerFunc(resp, req, nil)
+
+	var result proposalsRes
+	assert.NoError(t, json.Unmarshal(resp.Body.Bytes(), &result))
+	assert.Len(t, result.Proposals, 3)
+	assert.Equal(t, uint64(10000000), result.Proposals[0].PaymentMethod.Price.Amount)
+	assert.Equal(t, uint64(50000000), result.Proposals[1].PaymentMethod.Price.Amount)
+	assert.Nil(t, result.Proposals[2].PaymentMethod)
+}
+
+func TestProposalsEndpointListSortsByPriceWithinPaymentMethod(t *testing.T) {
+	perGB := serviceProposals[0]
+	perGB.PaymentMethodType = market.PaymentMethodPerGB
+	perGB.PaymentMethod = market.PaymentPerGB{Price: money.NewMoney(0.5, money.CurrencyMyst)}
+	cheaperPerGB := serviceProposals[1]
+	cheaperPerGB.PaymentMethodType = market.PaymentMethodPerGB
+	cheaperPerGB.PaymentMethod = market.PaymentPerGB{Price: money.NewMoney(0.1, money.CurrencyMyst)}
+	perTime := serviceProposals[0]
+	perTime.PaymentMethodType = "PER_TIME"
+	perTime.PaymentMethod = market.PaymentPerGB{Price: money.NewMoney(0.001, money.CurrencyMyst)}
+	proposalProvider := &mockProposalProvider{
+		proposals: []market.ServiceProposal{perTime, perGB, cheaperPerGB},
+	}
+
+	req, err := http.NewRequest(http.MethodGet, "/irrelevant?sortBy=price", nil)
+	assert.Nil(t, err)
+
+	resp := httptest.NewRecorder()
+	handlerFunc := NewProposalsEndpoint(proposalProvider, &mysteriumMorqaFake{}).List
+	handlerFunc(resp, req, nil)
+
+	var result proposalsRes
+	assert.NoError(t, json.Unmarshal(resp.Body.Bytes(), &result))
+	assert.Len(t, result.Proposals, 3)
+	assert.Equal(t, "PER_GB", result.Proposals[0].PaymentMethod.Type)
+	assert.Equal(t, uint64(10000000), result.Proposals[0].PaymentMethod.Price.Amount)
+	assert.Equal(t, "PER_GB", result.Proposals[1].PaymentMethod.Type)
+	assert.Equal(t, uint64(50000000), result.Proposals[1].PaymentMethod.Price.Amount)
+	assert.Equal(t, "PER_TIME", result.Proposals[2].PaymentMethod.Type)
+}
+
+func TestProposalsEndpointListFiltersAndSortsByQuality(t *testing.T) {
+	proposalProvider := &mockProposalProvider{
+		proposals: []market.ServiceProposal{
+			{ID: 1, ServiceType: "testprotocol", ServiceDefinition: TestServiceDefinition{}, ProviderID: "0x1"},
+			{ID: 1, ServiceType: "testprotocol", ServiceDefinition: TestServiceDefinition{}, ProviderID: "0x2"},
+			{ID: 1, ServiceType: "testprotocol", ServiceDefinition: TestServiceDefinition{}, ProviderID: "0x3"},
+			{ID: 1, ServiceType: "testprotocol", ServiceDefinition: TestServiceDefinition{}, ProviderID: "0x4"},
+		},
+	}
+	qualityOracle := &qualityOracleStub{
+		metrics: []json.RawMessage{
+			connectCountMetrics("0x1", 5, 5),
+			connectCountMetrics("0x2", 9, 1),
+			connectCountMetrics("0x3", 1, 9),
+		},
+	}
+
+	req, err := http.NewRequest(http.MethodGet, "/irrelevant?qualityMin=0.5&sortBy=quality", nil)
+	assert.Nil(t, err)
+
+	resp := httptest.NewRecorder()
+	handlerFunc := NewProposalsEndpoint(proposalProvider, qualityOracle).List
+	handlerFunc(resp, req, nil)
+
+	var result proposalsRes
+	assert.NoError(t, json.Unmarshal(resp.Body.Bytes(), &result))
+	assert.Len(t, result.Proposals, 2)
+	assert.Equal(t, "0x2", result.Proposals[0].ProviderID)
+	assert.Equal(t, "0x1", result.Proposals[1].ProviderID)
+}
+
+func connectCountMetrics(providerID string, success, fail int) json.RawMessage {
+	return json.RawMessage(fmt.Sprintf(`{
+		"proposalID": {"providerID": "%s", "serviceType": "testprotocol"},
+		"connectCount": {"success": %d, "fail": %d, "timeout": 0}
+	}`, providerID, success, fail))
+}
+
+func TestProposalsEndpointListShowsCacheFreshness(t *testing.T) {
+	fetchedAt := time.Now().Add(-time.Minute)
+	proposalProvider := &cachedProposalProviderStub{
+		mockProposalProvider: mockProposalProvider{proposals: []market.ServiceProposal{serviceProposals[0]}},
+		freshness:            mysterium.Freshness{FetchedAt: fetchedAt, Stale: true},
+	}
+
+	req, err := http.NewRequest(http.MethodGet, "/irrelevant?serviceType=openvpn", nil)
+	assert.Nil(t, err)
+
+	resp := httptest.NewRecorder()
+	handlerFunc := NewProposalsEndpoint(proposalProvider, &mysteriumMorqaFake{}).List
+	handlerFunc(resp, req, nil)
+
+	var result proposalsRes
+	assert.NoError(t, json.Unmarshal(resp.Body.Bytes(), &result))
+	assert.Len(t, result.Proposals, 1)
+	assert.Equal(t, market.ProposalFilter{ServiceType: "openvpn"}, proposalProvider.recordedFreshnessFilter)
+	assert.Equal(t, &proposalsCacheRes{FetchedAt: fetchedAt.UTC().Format(time.RFC3339), Age: 60, Stale: true}, result.Cache)
+}
+
+type cachedProposalProviderStub struct {
+	mockProposalProvider
+	freshness               mysterium.Freshness
+	recordedFreshnessFilter market.ProposalFilter
+}
+
+func (stub *cachedProposalProviderStub) Freshness(filter market.ProposalFilter) (mysterium.Freshness, bool) {
+	stub.recordedFreshnessFilter = filter
+	return stub.freshness, true
+}
+
+type qualityOracleStub struct {
+	metrics []json.RawMessage
+}
+
+func (stub *qualityOracleStub) ProposalsMetrics() []json.RawMessage {
+	return stub.metrics
+}
+
 type mysteriumMorqaFake struct{}
 
 // ProposalsMetrics returns a list of proposals connection metrics
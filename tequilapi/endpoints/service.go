@@ -24,6 +24,7 @@ import (
 
 	"github.com/julienschmidt/httprouter"
 	"github.com/mysteriumnetwork/node/core/service"
+	"github.com/mysteriumnetwork/node/datasize"
 	"github.com/mysteriumnetwork/node/identity"
 	"github.com/mysteriumnetwork/node/market"
 	"github.com/mysteriumnetwork/node/tequilapi/utils"
@@ -58,6 +59,14 @@ type accessPoliciesRequest struct {
 	Ids []string `json:"ids"`
 }
 
+// swagger:model ServiceBandwidthLimitRequestDTO
+type bandwidthLimitRequest struct {
+	// bandwidth in Mbit/s every session of the service is limited to in each direction, 0 removes the limit
+	// required: true
+	// example: 10
+	BandwidthLimit *float64 `json:"bandwidthLimit"`
+}
+
 // swagger:model ServiceListDTO
 type serviceList []serviceInfo
 
@@ -84,6 +93,24 @@ type serviceInfo struct {
 	Proposal proposalRes `json:"proposal"`
 
 	AccessPolicies *[]market.AccessPolicy `json:"accessPolicies,omitempty"`
+
+	// counters of the consumer traffic blocked by the egress policy, omitted if the service does not enforce it
+	Egress *egressStats `json:"egress,omitempty"`
+}
+
+// swagger:model ServiceEgressStatsDTO
+type egressStats struct {
+	// packets sent to the blocked ports
+	// example: 12
+	BlockedPorts uint64 `json:"blockedPorts"`
+
+	// packets sent to the private networks
+	// example: 3
+	BlockedPrivate uint64 `json:"blockedPrivate"`
+
+	// packets sent to the destinations of the blocklist
+	// example: 0
+	BlockedBlocklist uint64 `json:"blockedBlocklist"`
 }
 
 // ServiceEndpoint struct represents management of service resource and it's sub-resources
@@ -273,6 +300,72 @@ func (se *ServiceEndpoint) ServiceStop(resp http.ResponseWriter, _ *http.Request
 	resp.WriteHeader(http.StatusAccepted)
 }
 
+// ServiceBandwidthLimit changes the bandwidth limit of the running service sessions.
+// swagger:operation PUT /services/:id/bandwidth-limit Service serviceBandwidthLimit
+// ---
+// summary: Changes bandwidth limit of service sessions
+// description: Limits the bandwidth of the running and future sessions of the service, the limit is announced in the service proposal
+// parameters:
+//   - in: body
+//     name: body
+//     description: Bandwidth limit of every session
+//     schema:
+//       $ref: "#/definitions/ServiceBandwidthLimitRequestDTO"
+// responses:
+//   200:
+//     description: Bandwidth limit changed
+//     schema:
+//       "$ref": "#/definitions/ServiceInfoDTO"
+//   400:
+//     description: Bad request or service does not support bandwidth limits
+//     schema:
+//       "$ref": "#/definitions/ErrorMessageDTO"
+//   404:
+//     description: Service not found
+//     schema:
+//       "$ref": "#/definitions/ErrorMessageDTO"
+//   422:
+//     description: Parameters validation error
+//     schema:
+//       "$ref": "#/definitions/ValidationErrorDTO"
+//   500:
+//     description: Internal server error
+//     schema:
+//       "$ref": "#/definitions/ErrorMessageDTO"
+func (se *ServiceEndpoint) ServiceBandwidthLimit(resp http.ResponseWriter, req *http.Request, params httprouter.Params) {
+	id := service.ID(params.ByName("id"))
+
+	var limitRequest bandwidthLimitRequest
+	if err := json.NewDecoder(req.Body).Decode(&limitRequest); err != nil {
+		utils.SendError(resp, err, http.StatusBadRequest)
+		return
+	}
+
+	errorMap := validateBandwidthLimitRequest(limitRequest)
+	if errorMap.HasErrors() {
+		utils.SendValidationErrorMessage(resp, errorMap)
+		return
+	}
+
+	if se.serviceManager.Service(id) == nil {
+		utils.SendErrorMessage(resp, "Service not found", http.StatusNotFound)
+		return
+	}
+
+	limit := datasize.BitSize(*limitRequest.BandwidthLimit * 1000 * 1000)
+	err := se.serviceManager.SetBandwidthLimit(id, limit)
+	if err == service.ErrBandwidthLimitNotSupported {
+		utils.SendError(resp, err, http.StatusBadRequest)
+		return
+	} else if err != nil {
+		utils.SendError(resp, err, http.StatusInternalServerError)
+		return
+	}
+
+	statusResponse := toServiceInfoResponse(id, se.serviceManager.Service(id))
+	utils.WriteAsJSON(statusResponse, resp)
+}
+
 func (se *ServiceEndpoint) isAlreadyRunning(sr serviceRequest) bool {
 	for _, instance := range se.serviceManager.List() {
 		proposal := instance.Proposal()
@@ -291,6 +384,7 @@ func AddRoutesForService(router *httprouter.Router, serviceManager ServiceManage
 	router.POST("/services", serviceEndpoint.ServiceStart)
 	router.GET("/services/:id", serviceEndpoint.ServiceGet)
 	router.DELETE("/services/:id", serviceEndpoint.ServiceStop)
+	router.PUT("/services/:id/bandwidth-limit", serviceEndpoint.ServiceBandwidthLimit)
 }
 
 func (se *ServiceEndpoint) toServiceRequest(req *http.Request) (serviceRequest, error) {
@@ -348,7 +442,7 @@ func (se *ServiceEndpoint) toServiceOptions(serviceType string, value *json.RawM
 
 func toServiceInfoResponse(id service.ID, instance *service.Instance) serviceInfo {
 	proposal := instance.Proposal()
-	return serviceInfo{
+	info := serviceInfo{
 		ID:         string(id),
 		ProviderID: proposal.ProviderID,
 		Type:       proposal.ServiceType,
@@ -356,6 +450,14 @@ func toServiceInfoResponse(id service.ID, instance *service.Instance) serviceInf
 		Status:     string(instance.State()),
 		Proposal:   proposalToRes(instance.Proposal()),
 	}
+	if stats, ok := instance.EgressStats(); ok {
+		info.Egress = &egressStats{
+			BlockedPorts:     stats.BlockedPorts,
+			BlockedPrivate:   stats.BlockedPrivate,
+			BlockedBlocklist: stats.BlockedBlocklist,
+		}
+	}
+	return info
 }
 
 func toServiceListResponse(instances map[service.ID]*service.Instance) serviceList {
@@ -383,6 +485,16 @@ func validateServiceRequest(sr serviceRequest) *validation.FieldErrorMap {
 	return errors
 }
 
+func validateBandwidthLimitRequest(lr bandwidthLimitRequest) *validation.FieldErrorMap {
+	errors := validation.NewErrorMap()
+	if lr.BandwidthLimit == nil {
+		errors.ForField("bandwidthLimit").AddError("required", "Field is required")
+	} else if *lr.BandwidthLimit < 0 {
+		errors.ForField("bandwidthLimit").AddError("invalid", "Must not be negative")
+	}
+	return errors
+}
+
 // ServiceManager represents service manager that will be used for manipulation node services.
 type ServiceManager interface {
 	Start(providerID identity.Identity, serviceType string, accessPolicies *[]market.AccessPolicy, options service.Options) (service.ID, error)
@@ -390,4 +502,5 @@ type ServiceManager interface {
 	Service(id service.ID) *service.Instance
 	Kill() error
 	List() map[service.ID]*service.Instance
+	SetBandwidthLimit(id service.ID, limit datasize.BitSize) error
 }
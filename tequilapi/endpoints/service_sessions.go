@@ -20,10 +20,19 @@ package endpoints
 import (
 	"net/http"
 	"sort"
+	"strconv"
+	"time"
 
 	"github.com/julienschmidt/httprouter"
 	"github.com/mysteriumnetwork/node/session"
+	"github.com/mysteriumnetwork/node/session/promise"
 	"github.com/mysteriumnetwork/node/tequilapi/utils"
+	"github.com/mysteriumnetwork/node/tequilapi/validation"
+)
+
+const (
+	defaultHistoryPageSize = 50
+	maxHistoryPageSize     = 1000
 )
 
 // serviceSessionsList defines session list representable as json
@@ -40,20 +49,117 @@ type serviceSession struct {
 
 	// example: 0x0000000000000000000000000000000000000001
 	ConsumerID string `json:"consumerId"`
+
+	// example: 1024
+	BytesSent uint64 `json:"bytesSent"`
+
+	// example: 1024
+	BytesReceived uint64 `json:"bytesReceived"`
+}
+
+// serviceSessionHistoryList defines a page of session history representable as json
+// swagger:model ServiceSessionHistoryListDTO
+type serviceSessionHistoryList struct {
+	Sessions []serviceSessionHistory `json:"sessions"`
+
+	// example: 1
+	Page int `json:"page"`
+
+	// example: 50
+	PageSize int `json:"pageSize"`
+
+	// example: 120
+	TotalCount int `json:"totalCount"`
+}
+
+// serviceSessionHistory represents the finished session object
+// swagger:model ServiceSessionHistoryDTO
+type serviceSessionHistory struct {
+	// example: 4cfb0324-daf6-4ad8-448b-e61fe0a1f918
+	ID string `json:"id"`
+
+	// example: 0x0000000000000000000000000000000000000001
+	ConsumerID string `json:"consumerId"`
+
+	// example: wireguard
+	ServiceType string `json:"serviceType"`
+
+	// example: 2019-06-06T11:04:43Z
+	DateStarted string `json:"dateStarted"`
+
+	// example: 2019-06-06T11:06:43Z
+	DateEnded string `json:"dateEnded"`
+
+	// duration in seconds
+	// example: 120
+	Duration uint64 `json:"duration"`
+
+	// example: 1024
+	BytesSent uint64 `json:"bytesSent"`
+
+	// example: 1024
+	BytesReceived uint64 `json:"bytesReceived"`
+
+	// example: 500
+	PromisedAmount uint64 `json:"promisedAmount"`
+
+	// example: 3
+	LastPromiseSequenceID uint64 `json:"lastPromiseSequenceId"`
+
+	// example: destroyed
+	TerminationReason string `json:"terminationReason"`
+}
+
+// serviceEarnings represents the amounts promised to the provider
+// swagger:model ServiceEarningsDTO
+type serviceEarnings struct {
+	// example: 1500
+	Total uint64 `json:"total"`
+
+	Daily []dailyEarnings `json:"daily"`
+
+	Consumers []consumerEarnings `json:"consumers"`
+}
+
+// dailyEarnings represents the amount promised during a day
+// swagger:model DailyEarningsDTO
+type dailyEarnings struct {
+	// example: 2019-06-06
+	Date string `json:"date"`
+
+	// example: 500
+	Amount uint64 `json:"amount"`
+}
+
+// consumerEarnings represents the amount promised by a consumer
+// swagger:model ConsumerEarningsDTO
+type consumerEarnings struct {
+	// example: 0x0000000000000000000000000000000000000001
+	ConsumerID string `json:"consumerId"`
+
+	// example: 500
+	Amount uint64 `json:"amount"`
 }
 
 type serviceSessionStorage interface {
 	GetAll() []session.Session
+	GetHistory() ([]session.Record, error)
+}
+
+type earningsProvider interface {
+	GetEarnings() (promise.Earnings, error)
 }
 
 type serviceSessionsEndpoint struct {
-	sessionStorage serviceSessionStorage
+	sessionStorage   serviceSessionStorage
+	earningsProvider earningsProvider
 }
 
 // NewServiceSessionsEndpoint creates and returns sessions endpoint
-func NewServiceSessionsEndpoint(sessionStorage serviceSessionStorage) *serviceSessionsEndpoint {
+func NewServiceSessionsEndpoint(sessionStorage serviceSessionStorage, earningsProvider earningsProvider) *serviceSessionsEndpoint {
 	return &serviceSessionsEndpoint{
-		sessionStorage: sessionStorage,
+		sessionStorage:   sessionStorage,
+		earningsProvider: earningsProvider,
 	}
 }
 
@@ -77,16 +183,229 @@ func (endpoint *serviceSessionsEndpoint) List(resp http.ResponseWriter, request
 	utils.WriteAsJSON(sessionsSerializable, resp)
 }
 
+// swagger:operation GET /service-sessions/history Service serviceSessionsHistory
+// ---
+// summary: Returns finished sessions
+// description: Returns a page of finished provider sessions, most recent first
+// parameters:
+//   - in: query
+//     name: consumerId
+//     description: consumer identity to filter the sessions by
+//     type: string
+//   - in: query
+//     name: serviceType
+//     description: service type to filter the sessions by
+//     type: string
+//   - in: query
+//     name: from
+//     description: RFC3339 time, only sessions started at or after it are returned
+//     type: string
+//   - in: query
+//     name: to
+//     description: RFC3339 time, only sessions started before it are returned
+//     type: string
+//   - in: query
+//     name: page
+//     description: page number, starting from 1
+//     type: integer
+//   - in: query
+//     name: pageSize
+//     description: number of sessions in a page, 50 by default
+//     type: integer
+// responses:
+//   200:
+//     description: Page of finished sessions
+//     schema:
+//       "$ref": "#/definitions/ServiceSessionHistoryListDTO"
+//   422:
+//     description: Parameters validation error
+//     schema:
+//       "$ref": "#/definitions/ValidationErrorDTO"
+//   500:
+//     description: Internal server error
+//     schema:
+//       "$ref": "#/definitions/ErrorMessageDTO"
+func (endpoint *serviceSessionsEndpoint) History(resp http.ResponseWriter, request *http.Request, params httprouter.Params) {
+	query, errorMap := toHistoryQuery(request)
+	if errorMap.HasErrors() {
+		utils.SendValidationErrorMessage(resp, errorMap)
+		return
+	}
+
+	records, err := endpoint.sessionStorage.GetHistory()
+	if err != nil {
+		utils.SendError(resp, err, http.StatusInternalServerError)
+		return
+	}
+
+	matched := make([]session.Record, 0)
+	for _, record := range records {
+		if query.matches(record) {
+			matched = append(matched, record)
+		}
+	}
+	sort.Slice(matched, func(i, j int) bool { return matched[i].CreatedAt.After(matched[j].CreatedAt) })
+
+	historySerializable := serviceSessionHistoryList{
+		Sessions:   make([]serviceSessionHistory, 0),
+		Page:       query.page,
+		PageSize:   query.pageSize,
+		TotalCount: len(matched),
+	}
+	start := (query.page - 1) * query.pageSize
+	if start < len(matched) {
+		end := start + query.pageSize
+		if end > len(matched) {
+			end = len(matched)
+		}
+		for _, record := range matched[start:end] {
+			historySerializable.Sessions = append(historySerializable.Sessions, serviceSessionHistoryToDto(record))
+		}
+	}
+	utils.WriteAsJSON(historySerializable, resp)
+}
+
+// swagger:operation GET /service-sessions/earnings Service serviceEarnings
+// ---
+// summary: Returns earnings
+// description: Returns amounts promised by consumers, aggregated per day and per consumer
+// responses:
+//   200:
+//     description: Aggregated earnings
+//     schema:
+//       "$ref": "#/definitions/ServiceEarningsDTO"
+//   500:
+//     description: Internal server error
+//     schema:
+//       "$ref": "#/definitions/ErrorMessageDTO"
+func (endpoint *serviceSessionsEndpoint) Earnings(resp http.ResponseWriter, request *http.Request, params httprouter.Params) {
+	earnings, err := endpoint.earningsProvider.GetEarnings()
+	if err != nil {
+		utils.SendError(resp, err, http.StatusInternalServerError)
+		return
+	}
+
+	utils.WriteAsJSON(earningsToDto(earnings), resp)
+}
+
 // AddRoutesForServiceSessions attaches service sessions endpoints to router
-func AddRoutesForServiceSessions(router *httprouter.Router, sessionStorage serviceSessionStorage) {
-	sessionsEndpoint := NewServiceSessionsEndpoint(sessionStorage)
+func AddRoutesForServiceSessions(router *httprouter.Router, sessionStorage serviceSessionStorage, earningsProvider earningsProvider) {
+	sessionsEndpoint := NewServiceSessionsEndpoint(sessionStorage, earningsProvider)
 	router.GET("/service-sessions", sessionsEndpoint.List)
+	router.GET("/service-sessions/history", sessionsEndpoint.History)
+	router.GET("/service-sessions/earnings", sessionsEndpoint.Earnings)
+}
+
+type historyQuery struct {
+	consumerID  string
+	serviceType string
+	from        time.Time
+	to          time.Time
+	page        int
+	pageSize    int
+}
+
+func (query historyQuery) matches(record session.Record) bool {
+	if query.consumerID != "" && record.ConsumerID.Address != query.consumerID {
+		return false
+	}
+	if query.serviceType != "" && record.ServiceType != query.serviceType {
+		return false
+	}
+	if !query.from.IsZero() && record.CreatedAt.Before(query.from) {
+		return false
+	}
+	if !query.to.IsZero() && !record.CreatedAt.Before(query.to) {
+		return false
+	}
+	return true
+}
+
+func toHistoryQuery(request *http.Request) (historyQuery, *validation.FieldErrorMap) {
+	values := request.URL.Query()
+	errs := validation.NewErrorMap()
+	query := historyQuery{
+		consumerID:  values.Get("consumerId"),
+		serviceType: values.Get("serviceType"),
+		page:        1,
+		pageSize:    defaultHistoryPageSize,
+	}
+
+	parseTime := func(field string) time.Time {
+		if values.Get(field) == "" {
+			return time.Time{}
+		}
+		parsed, err := time.Parse(time.RFC3339, values.Get(field))
+		if err != nil {
+			errs.ForField(field).AddError("invalid", "Must be RFC3339 time")
+		}
+		return parsed
+	}
+	query.from = parseTime("from")
+	query.to = parseTime("to")
+
+	if page := values.Get("page"); page != "" {
+		parsed, err := strconv.Atoi(page)
+		if err != nil || parsed < 1 {
+			errs.ForField("page").AddError("invalid", "Must be a positive number")
+		}
+		query.page = parsed
+	}
+	if pageSize := values.Get("pageSize"); pageSize != "" {
+		parsed, err := strconv.Atoi(pageSize)
+		if err != nil || parsed < 1 || parsed > maxHistoryPageSize {
+			errs.ForField("pageSize").AddError("invalid", "Must be a number between 1 and "+strconv.Itoa(maxHistoryPageSize))
+		}
+		query.pageSize = parsed
+	}
+	return query, errs
+}
+
+func serviceSessionHistoryToDto(record session.Record) serviceSessionHistory {
+	return serviceSessionHistory{
+		ID:                    string(record.ID),
+		ConsumerID:            record.ConsumerID.Address,
+		ServiceType:           record.ServiceType,
+		DateStarted:           record.CreatedAt.Format(time.RFC3339),
+		DateEnded:             record.TerminatedAt.Format(time.RFC3339),
+		Duration:              uint64(record.TerminatedAt.Sub(record.CreatedAt).Seconds()),
+		BytesSent:             record.BytesSent,
+		BytesReceived:         record.BytesReceived,
+		PromisedAmount:        record.PromisedAmount,
+		LastPromiseSequenceID: record.LastPromise.SequenceID,
+		TerminationReason:     record.TerminationReason,
+	}
+}
+
+func earningsToDto(earnings promise.Earnings) serviceEarnings {
+	dto := serviceEarnings{
+		Total:     earnings.Total,
+		Daily:     make([]dailyEarnings, 0, len(earnings.ByDay)),
+		Consumers: make([]consumerEarnings, 0, len(earnings.ByConsumer)),
+	}
+	for date, amount := range earnings.ByDay {
+		dto.Daily = append(dto.Daily, dailyEarnings{Date: date, Amount: amount})
+	}
+	sort.Slice(dto.Daily, func(i, j int) bool { return dto.Daily[i].Date < dto.Daily[j].Date })
+
+	for consumerID, amount := range earnings.ByConsumer {
+		dto.Consumers = append(dto.Consumers, consumerEarnings{ConsumerID: consumerID.Address, Amount: amount})
+	}
+	sort.Slice(dto.Consumers, func(i, j int) bool {
+		if dto.Consumers[i].Amount != dto.Consumers[j].Amount {
+			return dto.Consumers[i].Amount > dto.Consumers[j].Amount
+		}
+		return dto.Consumers[i].ConsumerID < dto.Consumers[j].ConsumerID
+	})
+	return dto
 }
 
 func serviceSessionToDto(se session.Session) serviceSession {
 	return serviceSession{
-		ID:         string(se.ID),
-		ConsumerID: se.ConsumerID.Address,
+		ID:            string(se.ID),
+		ConsumerID:    se.ConsumerID.Address,
+		BytesSent:     se.DataTransfer.BytesSent,
+		BytesReceived: se.DataTransfer.BytesReceived,
 	}
 }
 
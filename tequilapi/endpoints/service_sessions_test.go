@@ -19,6 +19,7 @@ package endpoints
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -28,12 +29,14 @@ import (
 
 	"github.com/mysteriumnetwork/node/identity"
 	"github.com/mysteriumnetwork/node/session"
+	"github.com/mysteriumnetwork/node/session/promise"
 )
 
 var (
 	serviceSessionMock = session.Session{
-		ID:         session.ID("session1"),
-		ConsumerID: identity.FromAddress("consumer1"),
+		ID:           session.ID("session1"),
+		ConsumerID:   identity.FromAddress("consumer1"),
+		DataTransfer: session.DataTransfer{BytesSent: 10, BytesReceived: 20},
 	}
 )
 
@@ -43,6 +46,8 @@ func Test_ServiceSessionsEndpoint_SessionToDto(t *testing.T) {
 
 	assert.Equal(t, string(serviceSessionMock.ID), sessionDTO.ID)
 	assert.Equal(t, serviceSessionMock.ConsumerID.Address, sessionDTO.ConsumerID)
+	assert.Equal(t, uint64(10), sessionDTO.BytesSent)
+	assert.Equal(t, uint64(20), sessionDTO.BytesReceived)
 }
 
 func Test_ServiceSessionsEndpoint_List(t *testing.T) {
@@ -67,7 +72,7 @@ func Test_ServiceSessionsEndpoint_List(t *testing.T) {
 	}
 
 	resp := httptest.NewRecorder()
-	handlerFunc := NewServiceSessionsEndpoint(ssm).List
+	handlerFunc := NewServiceSessionsEndpoint(ssm, &earningsProviderMock{}).List
 	handlerFunc(resp, req, nil)
 
 	parsedResponse := &serviceSessionsList{}
@@ -77,10 +82,140 @@ func Test_ServiceSessionsEndpoint_List(t *testing.T) {
 	assert.EqualValues(t, serviceSessionToDto(anotherSession), parsedResponse.Sessions[1])
 }
 
+func Test_ServiceSessionsEndpoint_History(t *testing.T) {
+	started := time.Date(2019, 6, 6, 11, 0, 0, 0, time.UTC)
+	ssm := &serviceSessionStorageMock{
+		historyToReturn: []session.Record{
+			{ID: "session1", ConsumerID: identity.FromAddress("consumer1"), ServiceType: "wireguard", CreatedAt: started, TerminatedAt: started.Add(time.Minute)},
+			{ID: "session2", ConsumerID: identity.FromAddress("consumer2"), ServiceType: "openvpn", CreatedAt: started.Add(time.Hour)},
+			{ID: "session3", ConsumerID: identity.FromAddress("consumer1"), ServiceType: "wireguard", CreatedAt: started.Add(2 * time.Hour)},
+			{
+				ID:                "session4",
+				ConsumerID:        identity.FromAddress("consumer1"),
+				ServiceType:       "wireguard",
+				CreatedAt:         started.Add(3 * time.Hour),
+				TerminatedAt:      started.Add(3*time.Hour + 2*time.Minute),
+				PromisedAmount:    150,
+				LastPromise:       promise.LastPromise{SequenceID: 3, Amount: 250},
+				TerminationReason: session.TerminationReasonDestroyed,
+			},
+		},
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/service-sessions/history?consumerId=consumer1&serviceType=wireguard&pageSize=2", nil)
+	resp := httptest.NewRecorder()
+	NewServiceSessionsEndpoint(ssm, &earningsProviderMock{}).History(resp, req, nil)
+
+	assert.Equal(t, http.StatusOK, resp.Code)
+	parsedResponse := &serviceSessionHistoryList{}
+	err := json.Unmarshal(resp.Body.Bytes(), parsedResponse)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, parsedResponse.Page)
+	assert.Equal(t, 2, parsedResponse.PageSize)
+	assert.Equal(t, 3, parsedResponse.TotalCount)
+	assert.Len(t, parsedResponse.Sessions, 2)
+	assert.Equal(t,
+		serviceSessionHistory{
+			ID:                    "session4",
+			ConsumerID:            "consumer1",
+			ServiceType:           "wireguard",
+			DateStarted:           "2019-06-06T14:00:00Z",
+			DateEnded:             "2019-06-06T14:02:00Z",
+			Duration:              120,
+			PromisedAmount:        150,
+			LastPromiseSequenceID: 3,
+			TerminationReason:     "destroyed",
+		},
+		parsedResponse.Sessions[0],
+	)
+	assert.Equal(t, "session3", parsedResponse.Sessions[1].ID)
+
+	req = httptest.NewRequest(http.MethodGet, "/service-sessions/history?consumerId=consumer1&pageSize=2&page=2&to=2019-06-06T13:00:00Z", nil)
+	resp = httptest.NewRecorder()
+	NewServiceSessionsEndpoint(ssm, &earningsProviderMock{}).History(resp, req, nil)
+
+	parsedResponse = &serviceSessionHistoryList{}
+	err = json.Unmarshal(resp.Body.Bytes(), parsedResponse)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, parsedResponse.TotalCount)
+	assert.Len(t, parsedResponse.Sessions, 0)
+}
+
+func Test_ServiceSessionsEndpoint_HistoryValidatesQuery(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/service-sessions/history?from=yesterday&page=0&pageSize=100000", nil)
+	resp := httptest.NewRecorder()
+	NewServiceSessionsEndpoint(&serviceSessionStorageMock{}, &earningsProviderMock{}).History(resp, req, nil)
+
+	assert.Equal(t, http.StatusUnprocessableEntity, resp.Code)
+	assert.JSONEq(
+		t,
+		`{
+			"message": "validation_error",
+			"errors": {
+				"from": [{"code": "invalid", "message": "Must be RFC3339 time"}],
+				"page": [{"code": "invalid", "message": "Must be a positive number"}],
+				"pageSize": [{"code": "invalid", "message": "Must be a number between 1 and 1000"}]
+			}
+		}`,
+		resp.Body.String(),
+	)
+}
+
+func Test_ServiceSessionsEndpoint_HistoryReturnsStorageError(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/service-sessions/history", nil)
+	resp := httptest.NewRecorder()
+	ssm := &serviceSessionStorageMock{historyError: errors.New("storage failed")}
+	NewServiceSessionsEndpoint(ssm, &earningsProviderMock{}).History(resp, req, nil)
+
+	assert.Equal(t, http.StatusInternalServerError, resp.Code)
+}
+
+func Test_ServiceSessionsEndpoint_Earnings(t *testing.T) {
+	epm := &earningsProviderMock{
+		earnings: promise.Earnings{
+			Total: 30,
+			ByDay: map[string]uint64{"2019-06-07": 20, "2019-06-06": 10},
+			ByConsumer: map[identity.Identity]uint64{
+				identity.FromAddress("consumer1"): 5,
+				identity.FromAddress("consumer2"): 25,
+			},
+		},
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/service-sessions/earnings", nil)
+	resp := httptest.NewRecorder()
+	NewServiceSessionsEndpoint(&serviceSessionStorageMock{}, epm).Earnings(resp, req, nil)
+
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.JSONEq(
+		t,
+		`{
+			"total": 30,
+			"daily": [{"date": "2019-06-06", "amount": 10}, {"date": "2019-06-07", "amount": 20}],
+			"consumers": [{"consumerId": "consumer2", "amount": 25}, {"consumerId": "consumer1", "amount": 5}]
+		}`,
+		resp.Body.String(),
+	)
+}
+
 type serviceSessionStorageMock struct {
 	sessionsToReturn []session.Session
+	historyToReturn  []session.Record
+	historyError     error
 }
 
 func (ssm *serviceSessionStorageMock) GetAll() []session.Session {
 	return ssm.sessionsToReturn
 }
+
+func (ssm *serviceSessionStorageMock) GetHistory() ([]session.Record, error) {
+	return ssm.historyToReturn, ssm.historyError
+}
+
+type earningsProviderMock struct {
+	earnings promise.Earnings
+}
+
+func (epm *earningsProviderMock) GetEarnings() (promise.Earnings, error) {
+	return epm.earnings, nil
+}
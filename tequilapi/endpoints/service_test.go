@@ -28,8 +28,10 @@ import (
 
 	"github.com/julienschmidt/httprouter"
 	"github.com/mysteriumnetwork/node/core/service"
+	"github.com/mysteriumnetwork/node/datasize"
 	"github.com/mysteriumnetwork/node/identity"
 	"github.com/mysteriumnetwork/node/market"
+	"github.com/mysteriumnetwork/node/nat/egress"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -81,7 +83,9 @@ type fancyServiceOptions struct {
 	Foo string `json:"foo"`
 }
 
-type mockServiceManager struct{}
+type mockServiceManager struct {
+	bandwidthLimit datasize.BitSize
+}
 
 func (sm *mockServiceManager) Start(providerID identity.Identity, serviceType string, accessPolicies *[]market.AccessPolicy, options service.Options) (service.ID, error) {
 	if serviceType == serviceTypeWithAccessPolicy {
@@ -105,6 +109,10 @@ func (sm *mockServiceManager) List() map[service.ID]*service.Instance {
 	}
 }
 func (sm *mockServiceManager) Kill() error { return nil }
+func (sm *mockServiceManager) SetBandwidthLimit(id service.ID, limit datasize.BitSize) error {
+	sm.bandwidthLimit = limit
+	return nil
+}
 
 var fakeOptionsParser = map[string]ServiceOptionsParser{
 	"testprotocol": func(opts *json.RawMessage) (service.Options, error) {
@@ -371,6 +379,68 @@ func Test_ServiceGetReturnsServiceInfo(t *testing.T) {
 		resp.Body.String(),
 	)
 }
+func Test_ServiceBandwidthLimit_ChangesLimit(t *testing.T) {
+	serviceManager := &mockServiceManager{}
+	serviceEndpoint := NewServiceEndpoint(serviceManager, fakeOptionsParser, mockAccessPolicyEndpoint)
+
+	req := httptest.NewRequest(http.MethodPut, "/irrelevant", strings.NewReader(`{"bandwidthLimit": 2.5}`))
+	resp := httptest.NewRecorder()
+
+	serviceEndpoint.ServiceBandwidthLimit(resp, req, httprouter.Params{{Key: "id", Value: string(mockServiceID)}})
+
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.Equal(t, 2500*1000*datasize.Bit, serviceManager.bandwidthLimit)
+}
+
+func Test_ServiceBandwidthLimit_ValidatesLimit(t *testing.T) {
+	serviceEndpoint := NewServiceEndpoint(&mockServiceManager{}, fakeOptionsParser, mockAccessPolicyEndpoint)
+
+	req := httptest.NewRequest(http.MethodPut, "/irrelevant", strings.NewReader(`{"bandwidthLimit": -1}`))
+	resp := httptest.NewRecorder()
+
+	serviceEndpoint.ServiceBandwidthLimit(resp, req, httprouter.Params{{Key: "id", Value: string(mockServiceID)}})
+
+	assert.Equal(t, http.StatusUnprocessableEntity, resp.Code)
+	assert.JSONEq(
+		t,
+		`{
+			"message": "validation_error",
+			"errors": {
+				"bandwidthLimit": [{"code": "invalid", "message": "Must not be negative"}]
+			}
+		}`,
+		resp.Body.String(),
+	)
+}
+
+func Test_ServiceBandwidthLimit_NotFoundIsReturnedWhenNotStarted(t *testing.T) {
+	serviceEndpoint := NewServiceEndpoint(&mockServiceManager{}, fakeOptionsParser, mockAccessPolicyEndpoint)
+
+	req := httptest.NewRequest(http.MethodPut, "/irrelevant", strings.NewReader(`{"bandwidthLimit": 0}`))
+	resp := httptest.NewRecorder()
+
+	serviceEndpoint.ServiceBandwidthLimit(resp, req, httprouter.Params{{Key: "id", Value: "unknown"}})
+
+	assert.Equal(t, http.StatusNotFound, resp.Code)
+}
+
+type egressServiceFake struct{}
+
+func (service *egressServiceFake) Stop() error { return nil }
+func (service *egressServiceFake) EgressStats() (egress.Stats, bool) {
+	return egress.Stats{BlockedPorts: 12, BlockedPrivate: 3}, true
+}
+
+func Test_ServiceGetReturnsEgressStats(t *testing.T) {
+	instance := service.NewInstance(mockServiceOptions, service.Running, &egressServiceFake{}, mockProposal, nil, nil)
+	info := toServiceInfoResponse(mockServiceID, instance)
+
+	assert.Equal(t, &egressStats{BlockedPorts: 12, BlockedPrivate: 3}, info.Egress)
+
+	info = toServiceInfoResponse(mockServiceID, mockServiceRunning)
+	assert.Nil(t, info.Egress)
+}
+
 func Test_ServiceCreate_Returns400ErrorIfRequestBodyIsNotJSON(t *testing.T) {
 	serviceEndpoint := NewServiceEndpoint(&mockServiceManager{}, fakeOptionsParser, mockAccessPolicyEndpoint)
 
@@ -35,22 +35,25 @@ type APIServer interface {
 type apiServer struct {
 	errorChannel chan error
 	handler      http.Handler
-	listener     net.Listener
+	listeners    []net.Listener
 }
 
-// NewServer creates http api server for given address port and http handler
-func NewServer(listener net.Listener, handler http.Handler, corsPolicy CorsPolicy) APIServer {
+// NewServer creates http api server for given listeners and http handler,
+// the same api is served on all the listeners (e.g. TCP, TLS and Unix domain socket)
+func NewServer(listeners []net.Listener, handler http.Handler, corsPolicy CorsPolicy) APIServer {
 	server := apiServer{
-		errorChannel: make(chan error, 1),
+		errorChannel: make(chan error, len(listeners)),
 		handler:      DisableCaching(ApplyCors(handler, corsPolicy)),
-		listener:     listener,
+		listeners:    listeners,
 	}
 	return &server
 }
 
 // Stop method stops underlying http server
 func (server *apiServer) Stop() {
-	server.listener.Close()
+	for _, listener := range server.listeners {
+		listener.Close()
+	}
 }
 
 // Wait method waits for http server to finish handling requests (i.e. when Stop() was called)
@@ -58,22 +61,35 @@ func (server *apiServer) Wait() error {
 	return <-server.errorChannel
 }
 
-// Address method returns bind port for given http server (useful when random port is used)
+// Address method returns bound addresses of the listeners (useful when random port is used)
 func (server *apiServer) Address() (string, error) {
-	return extractBoundAddress(server.listener)
+	addresses := make([]string, 0, len(server.listeners))
+	for _, listener := range server.listeners {
+		address, err := extractBoundAddress(listener)
+		if err != nil {
+			return "", err
+		}
+		addresses = append(addresses, address)
+	}
+	return strings.Join(addresses, ", "), nil
 }
 
 // StartServing starts http request serving
 func (server *apiServer) StartServing() {
-	go server.serve(server.handler)
+	for _, listener := range server.listeners {
+		go server.serve(listener, server.handler)
+	}
 }
 
-func (server *apiServer) serve(handler http.Handler) {
-	server.errorChannel <- http.Serve(server.listener, handler)
+func (server *apiServer) serve(listener net.Listener, handler http.Handler) {
+	server.errorChannel <- http.Serve(listener, handler)
 }
 
 func extractBoundAddress(listener net.Listener) (string, error) {
 	addr := listener.Addr()
+	if addr.Network() == "unix" {
+		return "unix:" + addr.String(), nil
+	}
 	parts := strings.Split(addr.String(), ":")
 	if len(parts) < 2 {
 		return "", errors.New("Unable to locate address: " + addr.String())
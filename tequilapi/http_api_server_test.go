@@ -29,7 +29,7 @@ func TestLocalAPIServerPortIsAsExpected(t *testing.T) {
 	listener, err := net.Listen("tcp", "localhost:31337")
 	assert.Nil(t, err)
 
-	server := NewServer(listener, nil, RegexpCorsPolicy{})
+	server := NewServer([]net.Listener{listener}, nil, RegexpCorsPolicy{})
 
 	server.StartServing()
 
@@ -46,6 +46,6 @@ func TestLocalAPIServerPortIsAsExpected(t *testing.T) {
 func TestStopBeforeStartingListeningDoesNotCausePanic(t *testing.T) {
 	listener, err := net.Listen("tcp", "localhost:31337")
 	assert.Nil(t, err)
-	server := NewServer(listener, nil, RegexpCorsPolicy{})
+	server := NewServer([]net.Listener{listener}, nil, RegexpCorsPolicy{})
 	server.Stop()
 }
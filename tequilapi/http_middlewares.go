@@ -20,6 +20,8 @@ package tequilapi
 import (
 	"net/http"
 	"strings"
+
+	"github.com/mysteriumnetwork/node/tequilapi/utils"
 )
 
 type corsHandler struct {
@@ -90,3 +92,66 @@ func DisableCaching(original http.Handler) http.Handler {
 		original,
 	}
 }
+
+const authorizationHeader = "Authorization"
+const bearerPrefix = "Bearer "
+
+// TokenParam is the query parameter and the cookie name which carry the API token on the streaming paths
+const TokenParam = "token"
+
+// streamingPaths accept the API token in query or cookie too, browsers can not set headers on EventSource requests
+var streamingPaths = map[string]bool{
+	"/events": true,
+}
+
+// unauthenticatedPaths are served without the API token, so that clients can check the node and obtain the token
+var unauthenticatedPaths = map[string]bool{
+	"/healthcheck": true,
+	"/auth/login":  true,
+}
+
+// TokenValidator tells if the given API token authorizes the request
+type TokenValidator interface {
+	Authorized(token string) bool
+}
+
+type authenticationHandler struct {
+	originalHandler http.Handler
+	validator       TokenValidator
+}
+
+func (wrapper authenticationHandler) ServeHTTP(resp http.ResponseWriter, req *http.Request) {
+	if unauthenticatedPaths[req.URL.Path] || wrapper.validator.Authorized(requestToken(req)) {
+		wrapper.originalHandler.ServeHTTP(resp, req)
+		return
+	}
+
+	resp.Header().Set("WWW-Authenticate", `Bearer realm="tequilapi"`)
+	utils.SendErrorMessage(resp, "API token is missing or invalid", http.StatusUnauthorized)
+}
+
+// ApplyAuthentication wraps original handler by rejecting the requests which do not carry valid API token in Authorization header
+func ApplyAuthentication(original http.Handler, validator TokenValidator) http.Handler {
+	return authenticationHandler{originalHandler: original, validator: validator}
+}
+
+func requestToken(req *http.Request) string {
+	if token := bearerToken(req); token != "" || !streamingPaths[req.URL.Path] {
+		return token
+	}
+	if token := req.URL.Query().Get(TokenParam); token != "" {
+		return token
+	}
+	if cookie, err := req.Cookie(TokenParam); err == nil {
+		return cookie.Value
+	}
+	return ""
+}
+
+func bearerToken(req *http.Request) string {
+	header := req.Header.Get(authorizationHeader)
+	if !strings.HasPrefix(header, bearerPrefix) {
+		return ""
+	}
+	return strings.TrimSpace(strings.TrimPrefix(header, bearerPrefix))
+}
@@ -103,6 +103,87 @@ func TestCacheControlHeadersAreAddedToResponse(t *testing.T) {
 
 }
 
+type tokenValidatorFake string
+
+func (token tokenValidatorFake) Authorized(requestToken string) bool {
+	return requestToken == string(token)
+}
+
+func TestRequestWithValidTokenIsAuthenticated(t *testing.T) {
+	req, err := http.NewRequest(http.MethodGet, "/connection", nil)
+	assert.NoError(t, err)
+	req.Header.Add("Authorization", "Bearer valid-token")
+	respRecorder := httptest.NewRecorder()
+
+	mock := &mockedHTTPHandler{}
+
+	ApplyAuthentication(mock, tokenValidatorFake("valid-token")).ServeHTTP(respRecorder, req)
+
+	assert.Equal(t, http.StatusOK, respRecorder.Code)
+	assert.True(t, mock.wasCalled)
+}
+
+func TestRequestWithoutValidTokenIsRejected(t *testing.T) {
+	for _, header := range []string{"", "Bearer invalid-token", "valid-token", "Basic valid-token"} {
+		req, err := http.NewRequest(http.MethodGet, "/connection", nil)
+		assert.NoError(t, err)
+		req.Header.Add("Authorization", header)
+		respRecorder := httptest.NewRecorder()
+
+		mock := &mockedHTTPHandler{}
+
+		ApplyAuthentication(mock, tokenValidatorFake("valid-token")).ServeHTTP(respRecorder, req)
+
+		assert.Equal(t, http.StatusUnauthorized, respRecorder.Code)
+		assert.JSONEq(t, `{"message": "API token is missing or invalid"}`, respRecorder.Body.String())
+		assert.False(t, mock.wasCalled)
+	}
+}
+
+func TestEventsAcceptTokenInQueryOrCookie(t *testing.T) {
+	queryReq, err := http.NewRequest(http.MethodGet, "/events?token=valid-token", nil)
+	assert.NoError(t, err)
+	cookieReq, err := http.NewRequest(http.MethodGet, "/events", nil)
+	assert.NoError(t, err)
+	cookieReq.AddCookie(&http.Cookie{Name: "token", Value: "valid-token"})
+
+	for _, req := range []*http.Request{queryReq, cookieReq} {
+		respRecorder := httptest.NewRecorder()
+		mock := &mockedHTTPHandler{}
+
+		ApplyAuthentication(mock, tokenValidatorFake("valid-token")).ServeHTTP(respRecorder, req)
+
+		assert.Equal(t, http.StatusOK, respRecorder.Code)
+		assert.True(t, mock.wasCalled)
+	}
+}
+
+func TestOtherPathsRejectTokenInQuery(t *testing.T) {
+	req, err := http.NewRequest(http.MethodGet, "/connection?token=valid-token", nil)
+	assert.NoError(t, err)
+	respRecorder := httptest.NewRecorder()
+	mock := &mockedHTTPHandler{}
+
+	ApplyAuthentication(mock, tokenValidatorFake("valid-token")).ServeHTTP(respRecorder, req)
+
+	assert.Equal(t, http.StatusUnauthorized, respRecorder.Code)
+	assert.False(t, mock.wasCalled)
+}
+
+func TestHealthcheckAndLoginAreServedWithoutToken(t *testing.T) {
+	for _, path := range []string{"/healthcheck", "/auth/login"} {
+		req, err := http.NewRequest(http.MethodGet, path, nil)
+		assert.NoError(t, err)
+		respRecorder := httptest.NewRecorder()
+
+		mock := &mockedHTTPHandler{}
+
+		ApplyAuthentication(mock, tokenValidatorFake("valid-token")).ServeHTTP(respRecorder, req)
+
+		assert.True(t, mock.wasCalled)
+	}
+}
+
 type mockedHTTPHandler struct {
 	wasCalled bool
 }
@@ -0,0 +1,122 @@
+/*
+ * Copyright (C) 2019 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package tequilapi
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func tempDir(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "tequilapi")
+	assert.NoError(t, err)
+	return dir
+}
+
+func TestListenUnixRestrictsSocketAccess(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("file mode of the socket is not applicable on Windows")
+	}
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "tequilapi.sock")
+
+	listener, err := ListenUnix(path, 0600)
+	assert.NoError(t, err)
+	defer listener.Close()
+
+	info, err := os.Stat(path)
+	assert.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+}
+
+func TestListenUnixReplacesStaleSocket(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "tequilapi.sock")
+
+	stale, err := net.Listen("unix", path)
+	assert.NoError(t, err)
+	stale.(*net.UnixListener).SetUnlinkOnClose(false)
+	stale.Close()
+
+	listener, err := ListenUnix(path, 0600)
+	assert.NoError(t, err)
+	listener.Close()
+}
+
+func TestListenUnixFailsWhenSocketIsInUse(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "tequilapi.sock")
+
+	listener, err := ListenUnix(path, 0600)
+	assert.NoError(t, err)
+	defer listener.Close()
+
+	_, err = ListenUnix(path, 0600)
+	assert.Error(t, err)
+}
+
+func TestListenUnixDoesNotReplaceOtherFiles(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "tequilapi.sock")
+	assert.NoError(t, ioutil.WriteFile(path, []byte("data"), 0600))
+
+	_, err := ListenUnix(path, 0600)
+	assert.EqualError(t, err, path+" exists and is not a socket")
+}
+
+func TestTLSListenerServesGeneratedCertificate(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+	certFile, keyFile := filepath.Join(dir, "tequilapi.crt"), filepath.Join(dir, "tequilapi.key")
+
+	certificate, err := LoadOrGenerateCertificate(certFile, keyFile, []string{"127.0.0.1", "localhost"})
+	assert.NoError(t, err)
+	reloaded, err := LoadOrGenerateCertificate(certFile, keyFile, []string{"127.0.0.1", "localhost"})
+	assert.NoError(t, err)
+	assert.Equal(t, certificate.Certificate, reloaded.Certificate)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	server := NewServer([]net.Listener{NewTLSListener(listener, certificate)}, http.NotFoundHandler(), RegexpCorsPolicy{})
+	server.StartServing()
+	defer server.Stop()
+
+	certPEM, err := ioutil.ReadFile(certFile)
+	assert.NoError(t, err)
+	roots := x509.NewCertPool()
+	assert.True(t, roots.AppendCertsFromPEM(certPEM))
+	client := http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{RootCAs: roots}}}
+
+	resp, err := client.Get("https://" + listener.Addr().String() + "/healthcheck")
+	assert.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
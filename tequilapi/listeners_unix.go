@@ -0,0 +1,35 @@
+// +build !windows
+
+/*
+ * Copyright (C) 2019 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package tequilapi
+
+import (
+	"net"
+	"os"
+	"syscall"
+)
+
+// listenUnix creates the socket with the given file mode at once, so that it is never accessible to others.
+// Umask is process wide, the node binds to the socket on start before the files are written by other goroutines.
+func listenUnix(path string, mode os.FileMode) (net.Listener, error) {
+	oldMask := syscall.Umask(int(^mode.Perm() & os.ModePerm))
+	defer syscall.Umask(oldMask)
+
+	return net.Listen("unix", path)
+}
@@ -0,0 +1,43 @@
+// +build !windows
+
+/*
+ * Copyright (C) 2019 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package tequilapi
+
+import (
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestListenUnixRestoresUmask(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	oldMask := syscall.Umask(0022)
+	defer syscall.Umask(oldMask)
+
+	listener, err := ListenUnix(filepath.Join(dir, "tequilapi.sock"), 0600)
+	assert.NoError(t, err)
+	defer listener.Close()
+
+	assert.Equal(t, 0022, syscall.Umask(0022))
+}